@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,15 +17,24 @@ import (
 	// Import our packages (adjust path to match your go.mod)
 
 	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/blockchain"
 	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/geoip"
 	"github.com/ezhilnn/epr-backend/internal/handlers"
 	"github.com/ezhilnn/epr-backend/internal/middleware"
 	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/pan"
+	"github.com/ezhilnn/epr-backend/internal/payment"
 	"github.com/ezhilnn/epr-backend/internal/repository"
 	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/ezhilnn/epr-backend/migrations"
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate", false, "apply pending database migrations and exit")
+	flag.Parse()
+
 	// Load configuration from .env
 	cfg, err := config.Load()
 	if err != nil {
@@ -34,22 +45,39 @@ func main() {
 
 	// Connect to PostgreSQL
 	db, err := database.NewPostgresDB(database.Config{
-		Host:            cfg.Database.Host,
-		Port:            cfg.Database.Port,
-		User:            cfg.Database.User,
-		Password:        cfg.Database.Password,
-		DBName:          cfg.Database.DBName,
-		SSLMode:         cfg.Database.SSLMode,
-		MaxConnections:  cfg.Database.MaxConnections,
-		MaxIdleConns:    cfg.Database.MaxIdleConns,
-		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		Host:             cfg.Database.Host,
+		Port:             cfg.Database.Port,
+		User:             cfg.Database.User,
+		Password:         cfg.Database.Password,
+		DBName:           cfg.Database.DBName,
+		SSLMode:          cfg.Database.SSLMode,
+		MaxConnections:   cfg.Database.MaxConnections,
+		MaxIdleConns:     cfg.Database.MaxIdleConns,
+		ConnMaxLifetime:  cfg.Database.ConnMaxLifetime,
+		StatementTimeout: cfg.Database.StatementTimeout,
 	})
 	if err != nil {
 		log.Fatalf("❌ Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
+	// Apply pending migrations with -migrate, or on every start when DB_AUTO_MIGRATE is set in development
+	if *migrateOnly || cfg.Database.AutoMigrate {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		applied, err := db.Migrate(ctx, migrations.FS)
+		cancel()
+		if err != nil {
+			log.Fatalf("❌ Failed to apply migrations: %v", err)
+		}
+		log.Printf("✅ Database schema is up to date (%d migrations applied)", len(applied))
+
+		if *migrateOnly {
+			return
+		}
+	}
+
 	// Connect to Redis
+	// Redis is optional - without it caching, rate limiting and token revocation are skipped
 	redisClient, err := database.NewRedisClient(database.RedisConfig{
 		Host:     cfg.Redis.Host,
 		Port:     cfg.Redis.Port,
@@ -57,31 +85,129 @@ func main() {
 		DB:       cfg.Redis.DB,
 	})
 	if err != nil {
-		log.Fatalf("❌ Failed to connect to Redis: %v", err)
+		if cfg.Redis.Required {
+			log.Fatalf("❌ Failed to connect to Redis: %v", err)
+		}
+		log.Printf("⚠️  Redis unavailable, continuing in degraded mode: %v", err)
+		redisClient = nil
 	}
 	defer redisClient.Close()
 
 	// Initialize repositories
+	repository.SetSlowQueryThreshold(cfg.Database.SlowQueryThreshold)
 	userRepo := repository.NewUserRepository(db.DB)
-	billRepo := repository.NewBillRepository(db.DB)
+	billRepo := repository.NewBillRepository(db.DB, redisClient, cfg.Redis.BillCacheTTL)
+	billAliasRepo := repository.NewBillAliasRepository(db.DB)
+	inviteRepo := repository.NewInviteRepository(db.DB)
+	billTransferRepo := repository.NewBillTransferRepository(db.DB)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db.DB)
 	verificationRepo := repository.NewVerificationRepository(db.DB)
+	walletTxRepo := repository.NewWalletTransactionRepository(db.DB)
+	tokenDenylist := repository.NewTokenDenylistRepository(redisClient)
+	paymentOrderRepo := repository.NewPaymentOrderRepository(db.DB)
+	activityCounterRepo := repository.NewActivityCounterRepository(redisClient)
+	idempotencyRepo := repository.NewIdempotencyKeyRepository(db.DB)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db.DB)
+	outboxRepo := repository.NewOutboxRepository(db.DB)
+	loginHistoryRepo := repository.NewLoginHistoryRepository(db.DB)
+	auditRepo := repository.NewAuditRepository(db.DB)
 
 	// Initialize services
-	billService := services.NewBillService(billRepo, userRepo, cfg)
-	verificationService := services.NewVerificationService(verificationRepo, billRepo, userRepo, cfg)
+	walletService := services.NewWalletService(db, userRepo, walletTxRepo)
+
+	// Initialize payment gateway
+	var paymentGateway payment.Gateway
+	switch cfg.Payment.Gateway {
+	case "mock":
+		// config.Validate keeps the mock gateway out of production
+		log.Println("⚠️  Using mock payment gateway - top-ups are not backed by real payments")
+		paymentGateway = payment.NewMockGateway(cfg.Payment.KeySecret, cfg.IsDevelopment())
+	default:
+		log.Fatalf("❌ Unsupported payment gateway: %s", cfg.Payment.Gateway)
+	}
+	paymentService := services.NewPaymentService(db, paymentGateway, paymentOrderRepo, walletService, cfg.Payment.Currency)
+
 	// Initialize PDF service
 	pdfService := services.NewPDFService(cfg.App.FrontendURL)
 
 	// Initialize Email service
-	emailService := services.NewEmailService(cfg, billRepo, userRepo, pdfService)
+	emailService := services.NewEmailService(cfg, billRepo, billAliasRepo, userRepo, pdfService)
+
+	// Login alerts include a coarse location once a GeoIP lookup is configured
+	loginActivityService := services.NewLoginActivityService(loginHistoryRepo, emailService, geoip.NewNoopLocator())
+	accountDataService := services.NewAccountDataService(db, userRepo, billRepo, verificationRepo, walletTxRepo, loginHistoryRepo)
+
+	// Charging services warn users when their wallet runs low
+	lowBalanceNotifier := services.NewLowBalanceNotifier(userRepo, emailService, cfg)
+	billService := services.NewBillService(db, billRepo, billAliasRepo, userRepo, verificationRepo, walletTxRepo, idempotencyRepo, outboxRepo, auditRepo, lowBalanceNotifier, cfg)
+	suspiciousDetector := services.NewSuspiciousActivityDetector(activityCounterRepo, cfg)
+	webhookService := services.NewWebhookService(userRepo, webhookDeliveryRepo)
+	fakeBillAlerter := services.NewFakeBillAlerter(db, activityCounterRepo, outboxRepo, cfg)
+	verificationService := services.NewVerificationService(db, verificationRepo, billRepo, billAliasRepo, userRepo, walletTxRepo, outboxRepo, activityCounterRepo, suspiciousDetector, fakeBillAlerter, lowBalanceNotifier, cfg)
+
+	// Initialize KYC service
+	kycService := services.NewKYCService(db, userRepo, auditRepo, emailService)
+	inviteService := services.NewInviteService(db, userRepo, inviteRepo, auditRepo, emailService, cfg)
+	billTransferService := services.NewBillTransferService(db, billRepo, userRepo, billTransferRepo, auditRepo, emailService, cfg)
+
+	// PANs given at signup are checked once a PAN verification service is configured
+	panVerificationService := services.NewPANVerificationService(userRepo, pan.NewNoopVerifier())
+
+	// Config validation already checked the key
+	twoFactorKey, err := cfg.TwoFactorKey()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	twoFactorService := services.NewTwoFactorService(db, userRepo, recoveryCodeRepo, twoFactorKey)
+	adminService := services.NewAdminService(db, userRepo, billRepo, verificationRepo, walletTxRepo, tokenDenylist, auditRepo, cfg)
+
+	// Start background workers - shutdown cancels them and waits for in-flight work
+	workers := services.NewWorkerManager()
+	defer workers.Stop()
+	if cfg.Blockchain.Enabled {
+		var blockchainClient blockchain.Client
+		switch cfg.Blockchain.Client {
+		case "noop":
+			// config.Validate keeps the noop client out of production
+			log.Println("⚠️  Using noop blockchain client - bills are marked confirmed without being anchored")
+			blockchainClient = blockchain.NewNoopClient()
+		default:
+			log.Fatalf("❌ Unsupported blockchain client: %s", cfg.Blockchain.Client)
+		}
+		blockchainWorker := services.NewBlockchainWorker(billRepo, blockchainClient, cfg)
+		workers.Go("blockchain", blockchainWorker.Run)
+	}
+	outboxDispatcher := services.NewOutboxDispatcher(outboxRepo, emailService, webhookService, cfg)
+	workers.Go("outbox", outboxDispatcher.Run)
+	planQuotaWorker := services.NewPlanQuotaWorker(userRepo, cfg.Location())
+	workers.Go("plan-quota", planQuotaWorker.Run)
+	if cfg.Retention.VerificationRetention > 0 {
+		verificationPruner := services.NewVerificationPruner(verificationRepo, cfg)
+		workers.Go("verification-pruner", verificationPruner.Run)
+	}
+	if cfg.Email.DailySummaryEnabled {
+		summaryScheduler := services.NewDailySummaryScheduler(userRepo, emailService, cfg)
+		workers.Go("daily-summary", summaryScheduler.Run)
+	}
+
+	// Signing key plus any retired keys still accepted during a rotation
+	jwtKeys := utils.NewJWTKeySet(cfg.JWT.SigningMethod, cfg.JWT.KeyID, cfg.JWT.Secret, cfg.JWT.PreviousKeys)
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userRepo, cfg)
-	billHandler := handlers.NewBillHandler(billService)
-	verificationHandler := handlers.NewVerificationHandler(verificationService)
+	authHandler := handlers.NewAuthHandler(userRepo, walletService, emailService, loginActivityService, tokenDenylist, accountDataService, inviteService, panVerificationService, twoFactorService, jwtKeys, cfg)
+	billHandler := handlers.NewBillHandler(billService, billTransferService, cfg.Location())
+	verificationHandler := handlers.NewVerificationHandler(verificationService, pdfService, cfg.Location())
 	dashboardHandler := handlers.NewDashboardHandler(billService, verificationService)
-	pdfHandler := handlers.NewPDFHandler(billRepo, pdfService)
+	pdfHandler := handlers.NewPDFHandler(billRepo, billService, pdfService)
 	emailHandler := handlers.NewEmailHandler(emailService)
+	walletHandler := handlers.NewWalletHandler(walletService, paymentService)
+	kycHandler := handlers.NewKYCHandler(kycService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	adminHandler := handlers.NewAdminHandler(billService, adminService, inviteService, cfg.Location())
+
+	// Page size limits shared by every list endpoint
+	utils.DefaultPageSize = cfg.App.DefaultPageSize
+	utils.MaxPageSize = cfg.App.MaxPageSize
 
 	// Set Gin mode
 	if cfg.IsProduction() {
@@ -92,12 +218,12 @@ func main() {
 
 	// Create Gin router
 	router := gin.Default()
-
-	// Apply global middleware
-	router.Use(middleware.CORSMiddleware([]string{cfg.App.FrontendURL, "*"}))
+	if err := useGlobalMiddleware(router, cfg, jwtKeys, redisClient); err != nil {
+		log.Fatalf("❌ Failed to set up router: %v", err)
+	}
 
 	// Setup routes
-	setupRoutes(router, db, redisClient, cfg, authHandler, billHandler, verificationHandler, dashboardHandler, billRepo, verificationRepo, userRepo, pdfHandler, emailHandler)
+	setupRoutes(router, db, redisClient, cfg, jwtKeys, authHandler, billHandler, verificationHandler, dashboardHandler, billRepo, verificationRepo, userRepo, pdfHandler, emailHandler, walletHandler, kycHandler, adminHandler, webhookHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -130,15 +256,37 @@ func main() {
 		log.Printf("❌ Server forced to shutdown: %v", err)
 	}
 
+	// Stop workers only once no request can queue more work for them
+	// Anything they don't finish stays pending in the database for the next start
+	workers.Shutdown(cfg.Server.WorkerDrainTimeout)
+
 	log.Println("✅ Server exited gracefully")
 }
 
 // setupRoutes configures all API routes
+// useGlobalMiddleware sets which proxies are trusted and applies the middleware every route runs behind
+func useGlobalMiddleware(router *gin.Engine, cfg *config.Config, jwtKeys utils.JWTKeySet, redisClient *database.RedisClient) error {
+	// gin trusts X-Forwarded-For from every peer by default, which would let any client pick the IP
+	// its rate limits and anonymous quotas are counted against
+	if err := router.SetTrustedProxies(cfg.App.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	router.Use(middleware.RequestID())
+	router.Use(middleware.BodyLimit(int64(cfg.App.MaxBodyBytes)))
+	router.Use(middleware.CORSMiddleware(cfg.App.CORSAllowedOrigins, cfg.IsDevelopment()))
+	router.Use(middleware.IdentifyCaller(jwtKeys))
+	router.Use(middleware.RateLimit(redisClient, cfg.App.RateLimitRPM))
+
+	return nil
+}
+
 func setupRoutes(
 	router *gin.Engine,
 	db *database.DB,
 	redis *database.RedisClient,
 	cfg *config.Config,
+	jwtKeys utils.JWTKeySet,
 	authHandler *handlers.AuthHandler,
 	billHandler *handlers.BillHandler,
 	verificationHandler *handlers.VerificationHandler,
@@ -148,7 +296,14 @@ func setupRoutes(
 	userRepo *repository.UserRepository,
 	pdfHandler *handlers.PDFHandler,
 	emailHandler *handlers.EmailHandler,
+	walletHandler *handlers.WalletHandler,
+	kycHandler *handlers.KYCHandler,
+	adminHandler *handlers.AdminHandler,
+	webhookHandler *handlers.WebhookHandler,
 ) {
+	// Bill generation and wallet top-ups need a verified email when ENFORCE_EMAIL_VERIFICATION is set
+	requireVerifiedEmail := middleware.RequireVerifiedEmail(userRepo, cfg.App.RequireVerifiedEmail)
+
 	// API v1 group
 	v1 := router.Group("/api/v1")
 	{
@@ -160,15 +315,33 @@ func setupRoutes(
 				dbStatus = fmt.Sprintf("unhealthy: %v", dbErr)
 			}
 
+			// A reachable database can still be missing migrations
+			schema := gin.H{"status": "skipped"}
+			var schemaErr error
+			if dbErr == nil {
+				var schemaStatus *database.SchemaStatus
+				schemaStatus, schemaErr = db.SchemaCheck()
+				schema = gin.H{"status": "healthy", "detail": schemaStatus}
+				if schemaErr != nil {
+					schema["status"] = fmt.Sprintf("unhealthy: %v", schemaErr)
+				}
+			}
+
 			redisErr := redis.HealthCheck()
 			redisStatus := "healthy"
-			if redisErr != nil {
+			if !redis.Available() {
+				redisStatus = "unavailable"
+			} else if redisErr != nil {
 				redisStatus = fmt.Sprintf("unhealthy: %v", redisErr)
 			}
 
+			// Redis is optional, so losing it degrades the API without taking it out of service
 			overallStatus := "healthy"
 			statusCode := http.StatusOK
-			if dbErr != nil || redisErr != nil {
+			if redisErr != nil || schemaErr != nil {
+				overallStatus = "degraded"
+			}
+			if dbErr != nil {
 				overallStatus = "degraded"
 				statusCode = http.StatusServiceUnavailable
 			}
@@ -181,6 +354,7 @@ func setupRoutes(
 					"database": gin.H{
 						"status": dbStatus,
 						"stats":  db.Stats(),
+						"schema": schema,
 					},
 					"redis": gin.H{
 						"status": redisStatus,
@@ -190,6 +364,43 @@ func setupRoutes(
 			})
 		})
 
+		// Liveness probe - the process is up and serving; dependencies aren't checked,
+		// so an outage elsewhere doesn't get the pod restarted
+		v1.GET("/livez", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "alive"})
+		})
+
+		// Readiness probe - 503 until the database is reachable and migrated, and Redis too when REDIS_REQUIRED is set
+		// Without REDIS_REQUIRED a Redis outage only degrades the API, so the pod stays ready
+		v1.GET("/readyz", func(c *gin.Context) {
+			ready := true
+			checks := gin.H{"database": "ok", "schema": "ok", "redis": "ok"}
+
+			if err := db.HealthCheck(); err != nil {
+				ready = false
+				checks["database"] = err.Error()
+				checks["schema"] = "skipped"
+			} else if _, err := db.SchemaCheck(); err != nil {
+				ready = false
+				checks["schema"] = err.Error()
+			}
+
+			if err := redis.HealthCheck(); err != nil {
+				if cfg.Redis.Required {
+					ready = false
+					checks["redis"] = err.Error()
+				} else {
+					checks["redis"] = fmt.Sprintf("degraded: %v", err)
+				}
+			}
+
+			if !ready {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "checks": checks})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+		})
+
 		// Ping endpoint (public)
 		v1.GET("/ping", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{
@@ -200,23 +411,97 @@ func setupRoutes(
 
 		// Authentication routes (public)
 		auth := v1.Group("/auth")
+		auth.Use(middleware.BodyLimit(int64(cfg.App.AuthMaxBodyBytes)))
 		{
 			auth.POST("/signup", authHandler.Signup)
+			auth.POST("/signup/invite", authHandler.SignupWithInvite)
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/logout", authHandler.Logout)
+			auth.GET("/verify-email", authHandler.VerifyEmail)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
 
 			// Protected route - requires authentication
-			auth.GET("/me", middleware.AuthMiddleware(cfg.JWT.Secret), authHandler.GetMe)
-			auth.POST("/wallet/topup", middleware.AuthMiddleware(cfg.JWT.Secret), authHandler.TopupWallet)
+			auth.GET("/me", middleware.AuthMiddleware(jwtKeys), authHandler.GetMe)
+			auth.POST("/deactivate", middleware.AuthMiddleware(jwtKeys), authHandler.Deactivate)
+			auth.GET("/sessions", middleware.AuthMiddleware(jwtKeys), authHandler.ListSessions)
+			auth.GET("/export", middleware.AuthMiddleware(jwtKeys), authHandler.ExportData)
+			auth.POST("/erase", middleware.AuthMiddleware(jwtKeys), authHandler.EraseData)
+			auth.GET("/preferences", middleware.AuthMiddleware(jwtKeys), authHandler.GetPreferences)
+			auth.PUT("/preferences", middleware.AuthMiddleware(jwtKeys), authHandler.UpdatePreferences)
+
+			// Two-factor authentication (verifiers and master admins)
+			auth.POST("/2fa/enable", middleware.AuthMiddleware(jwtKeys), authHandler.EnableTwoFactor)
+			auth.POST("/2fa/verify", middleware.AuthMiddleware(jwtKeys), authHandler.VerifyTwoFactor)
+			auth.POST("/2fa/disable", middleware.AuthMiddleware(jwtKeys), authHandler.DisableTwoFactor)
+
+			// Direct top-up without payment - development only
+			if !cfg.IsProduction() {
+				auth.POST("/wallet/topup", middleware.AuthMiddleware(jwtKeys), requireVerifiedEmail, authHandler.TopupWallet)
+			}
+		}
+
+		// Wallet routes (protected)
+		wallet := v1.Group("/wallet")
+		wallet.Use(middleware.AuthMiddleware(jwtKeys))
+		{
+			wallet.GET("/transactions", walletHandler.ListTransactions)
+			wallet.POST("/order", requireVerifiedEmail, walletHandler.CreateOrder)
+			wallet.POST("/confirm", walletHandler.ConfirmPayment)
+		}
+
+		// KYC routes (institutions only)
+		kyc := v1.Group("/kyc")
+		kyc.Use(middleware.AuthMiddleware(jwtKeys))
+		kyc.Use(middleware.RequireRole(
+			string(models.RoleInstitutionUser),
+			string(models.RoleInstitutionAdmin),
+		))
+		{
+			kyc.POST("/submit", kycHandler.SubmitKYC)
+		}
+
+		// Webhook routes (institutions only)
+		webhooks := v1.Group("/webhooks")
+		webhooks.Use(middleware.AuthMiddleware(jwtKeys))
+		webhooks.Use(middleware.RequireRole(
+			string(models.RoleInstitutionUser),
+			string(models.RoleInstitutionAdmin),
+		))
+		{
+			webhooks.PUT("", webhookHandler.SetWebhook)
+			webhooks.GET("/deliveries", webhookHandler.ListDeliveries)
 		}
 
 		// Bill verification (public - no auth required)
 		v1.GET("/bills/verify/:bill_number", billHandler.VerifyBill)
+		// Deprecated: the original PDF path, kept as a redirect to /bills/:bill_number/pdf for old links
 		v1.GET("public/bills/:bill_number/pdf", func(c *gin.Context) {
-			// Try to get auth, but don't require it for public bills
+			target := "/api/v1/bills/" + url.PathEscape(c.Param("bill_number")) + "/pdf"
+			if c.Request.URL.RawQuery != "" {
+				target += "?" + c.Request.URL.RawQuery
+			}
+			c.Header("Deprecation", "true")
+			c.Header("Link", "<"+target+">; rel=\"successor-version\"")
+			c.Redirect(http.StatusPermanentRedirect, target)
+		})
+
+		// Check a copy of a bill's data against its registered hash (public and free,
+		// rate limited like the verify endpoints)
+		// The wildcard is the bill number but is named id: gin needs the /bills/:x/... routes of
+		// a method to share a name, and the bill ID routes (restore, revisions, ...) use id
+		v1.POST("/bills/:id/verify-hash",
+			middleware.RateLimitScoped(redis, "verify", cfg.App.VerifyRateLimitRPM),
+			billHandler.VerifyDataHash,
+		)
+
+		// Bill PDF download (optional auth - public bills work anonymously,
+		// other access levels are checked inside the handler); :id is the bill number (see verify-hash)
+		v1.GET("/bills/:id/pdf", func(c *gin.Context) {
 			authHeader := c.GetHeader("Authorization")
 			if authHeader != "" {
-				middleware.AuthMiddleware(cfg.JWT.Secret)(c)
+				middleware.AuthMiddleware(jwtKeys)(c)
 				if c.IsAborted() {
 					return
 				}
@@ -226,6 +511,8 @@ func setupRoutes(
 
 		// Verification endpoints
 		verify := v1.Group("/verify")
+		verify.Use(middleware.RateLimitScoped(redis, "verify", cfg.App.VerifyRateLimitRPM))
+		anonymousVerifyLimit := middleware.RateLimitAnonymous(redis, "verify-anonymous", cfg.App.AnonymousVerifyRateLimitRPM)
 		{
 			// Public verification (optional auth - can work without login)
 			verify.POST("", func(c *gin.Context) {
@@ -233,23 +520,60 @@ func setupRoutes(
 				authHeader := c.GetHeader("Authorization")
 				if authHeader != "" {
 					// If auth provided, validate it
-					middleware.AuthMiddleware(cfg.JWT.Secret)(c)
+					middleware.AuthMiddleware(jwtKeys)(c)
 					if c.IsAborted() {
 						return
 					}
 				}
+				anonymousVerifyLimit(c)
+				if c.IsAborted() {
+					return
+				}
 				verificationHandler.VerifyBill(c)
 			})
 
+			// Verify by uploading a photo/screenshot of the bill's QR code (optional auth)
+			verify.POST("/qr", middleware.BodyLimit(handlers.MaxQRUploadSize), func(c *gin.Context) {
+				authHeader := c.GetHeader("Authorization")
+				if authHeader != "" {
+					middleware.AuthMiddleware(jwtKeys)(c)
+					if c.IsAborted() {
+						return
+					}
+				}
+				anonymousVerifyLimit(c)
+				if c.IsAborted() {
+					return
+				}
+				verificationHandler.VerifyBillByQR(c)
+			})
+
+			// Dry-run a verification up front: fee, free credit and access level, without charging (optional auth)
+			verify.GET("/price-estimate", func(c *gin.Context) {
+				authHeader := c.GetHeader("Authorization")
+				if authHeader != "" {
+					middleware.AuthMiddleware(jwtKeys)(c)
+					if c.IsAborted() {
+						return
+					}
+				}
+				verificationHandler.EstimatePrice(c)
+			})
+
 			// Protected verification endpoints (require auth)
-			verify.GET("/history", middleware.AuthMiddleware(cfg.JWT.Secret), verificationHandler.GetVerificationHistory)
-			verify.GET("/stats", middleware.AuthMiddleware(cfg.JWT.Secret), verificationHandler.GetVerificationStats)
-			verify.GET("/search", middleware.AuthMiddleware(cfg.JWT.Secret), verificationHandler.SearchVerifications)
+			verify.GET("/history", middleware.AuthMiddleware(jwtKeys), verificationHandler.GetVerificationHistory)
+			verify.GET("/history/export", middleware.AuthMiddleware(jwtKeys), verificationHandler.ExportVerificationHistory)
+			verify.GET("/stats", middleware.AuthMiddleware(jwtKeys), verificationHandler.GetVerificationStats)
+			verify.GET("/trends", middleware.AuthMiddleware(jwtKeys), verificationHandler.GetVerificationTrends)
+			verify.GET("/quota", middleware.AuthMiddleware(jwtKeys), verificationHandler.GetVerificationQuota)
+			verify.GET("/search", middleware.AuthMiddleware(jwtKeys), verificationHandler.SearchVerifications)
+			verify.GET("/:id", middleware.AuthMiddleware(jwtKeys), verificationHandler.GetVerification)
+			verify.GET("/:id/certificate", middleware.AuthMiddleware(jwtKeys), verificationHandler.DownloadVerificationCertificate)
 		}
 
 		// Dashboard endpoints (protected)
 		dashboard := v1.Group("/dashboard")
-		dashboard.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		dashboard.Use(middleware.AuthMiddleware(jwtKeys))
 		{
 			// Public user dashboard
 			dashboard.GET("", dashboardHandler.GetPublicDashboard)
@@ -270,19 +594,31 @@ func setupRoutes(
 
 		// Bill routes (protected - requires authentication)
 		bills := v1.Group("/bills")
-		bills.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		bills.Use(middleware.AuthMiddleware(jwtKeys))
 		{
 			// Only institutions can generate bills
 			bills.POST("", middleware.RequireRole(
 				string(models.RoleInstitutionUser),
 				string(models.RoleInstitutionAdmin),
 				string(models.RoleMasterAdmin),
-			), billHandler.CreateBill)
+			), requireVerifiedEmail, billHandler.CreateBill)
+			bills.POST("/bulk", middleware.BodyLimit(int64(cfg.App.BulkMaxBodyBytes)), middleware.RequireRole(
+				string(models.RoleInstitutionUser),
+				string(models.RoleInstitutionAdmin),
+				string(models.RoleMasterAdmin),
+			), requireVerifiedEmail, billHandler.BulkCreateBills)
 
 			// Get user's bills
 			bills.GET("", billHandler.ListBills)
 			bills.GET("/search", billHandler.SearchBills)
 			bills.GET("/stats", billHandler.GetBillStats)
+			bills.GET("/trends", billHandler.GetBillTrends)
+			bills.GET("/schema/:bill_type", billHandler.GetBillSchema)
+			bills.GET("/export/zip", middleware.RequireRole(
+				string(models.RoleInstitutionUser),
+				string(models.RoleInstitutionAdmin),
+				string(models.RoleMasterAdmin),
+			), pdfHandler.ExportBillsZip)
 
 			// Single bill operations
 			bills.GET("id/:id", billHandler.GetBill)
@@ -291,47 +627,47 @@ func setupRoutes(
 			bills.GET("id/:id/verifications", func(c *gin.Context) {
 				handlers.GetBillVerificationLogs(c, billRepo, verificationRepo, userRepo)
 			})
+			bills.PUT("/:id", billHandler.UpdateBill)
+			// Routes under /:id share the wildcard with verify-hash, pdf and email, which take a bill number
+			bills.GET("/:id/revisions", billHandler.ListBillRevisions)
 			bills.DELETE("id/:id", billHandler.DeleteBill)
-			bills.GET("/:bill_number/pdf", func(c *gin.Context) {
-				// This endpoint has optional auth - it checks inside the handler
-				pdfHandler.DownloadBillPDF(c)
-			})
+			bills.POST("/:id/restore", billHandler.RestoreBill)
+			bills.POST("/:id/short-link", billHandler.CreateShortLink)
+
+			// Ownership transfers - the receiving account accepts with its emailed token
+			bills.POST("/:id/transfer", billHandler.TransferBill)
+			bills.GET("id/:id/transfers", billHandler.ListBillTransfers)
+			bills.POST("/transfers/confirm", billHandler.ConfirmBillTransfer)
 
-			// Email Bill - requires authentication
-			bills.POST("/:bill_number/email", emailHandler.SendBillEmail)
+			// Email Bill - requires authentication; :id is the bill number (see verify-hash)
+			bills.POST("/:id/email", emailHandler.SendBillEmail)
+			bills.POST("/summary/send", middleware.RequireRole(
+				string(models.RoleInstitutionUser),
+				string(models.RoleInstitutionAdmin),
+			), emailHandler.SendDailySummary)
 		}
 
-		// Protected routes example (we'll add more later)
-		// protected := v1.Group("")
-		// protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
-		// {
-		// 	// Example: Only authenticated users can access this
-		// 	protected.GET("/dashboard", func(c *gin.Context) {
-		// 		userID, _ := c.Get("user_id")
-		// 		email, _ := c.Get("email")
-		// 		role, _ := c.Get("role")
-
-		// 		c.JSON(http.StatusOK, gin.H{
-		// 			"message": "Welcome to your dashboard!",
-		// 			"user": gin.H{
-		// 				"id":    userID,
-		// 				"email": email,
-		// 				"role":  role,
-		// 			},
-		// 		})
-		// 	})
-		// }
-
-		// Admin-only routes example
+		// Admin-only routes
 		admin := v1.Group("/admin")
-		admin.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		admin.Use(middleware.AuthMiddleware(jwtKeys))
 		admin.Use(middleware.RequireRole("master_admin"))
 		{
-			admin.GET("/stats", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{
-					"message": "Admin statistics",
-				})
-			})
+			admin.GET("/stats", adminHandler.GetStats)
+
+			// System-wide bill search
+			admin.GET("/bills", adminHandler.SearchBills)
+			admin.GET("/verifications/suspicious", adminHandler.ListSuspiciousVerifications)
+			admin.GET("/verifiers", adminHandler.ListVerifiers)
+			admin.GET("/audit", adminHandler.ListAuditLog)
+			admin.POST("/users/:id/active", adminHandler.SetUserActive)
+			admin.POST("/users/:id/role", adminHandler.UpdateUserRole)
+			admin.POST("/invite", adminHandler.InviteUser)
+			admin.POST("/users/:id/plan", adminHandler.SetVerificationPlan)
+			admin.POST("/users/:id/wallet/adjust", adminHandler.AdjustWallet)
+
+			// KYC review
+			admin.GET("/kyc/pending", kycHandler.ListPendingKYC)
+			admin.POST("/kyc/:user_id/review", kycHandler.ReviewKYC)
 		}
 	}
 
@@ -343,6 +679,8 @@ func setupRoutes(
 			"docs":    "/api/v1/health",
 			"endpoints": gin.H{
 				"health":  "/api/v1/health",
+				"livez":   "/api/v1/livez",
+				"readyz":  "/api/v1/readyz",
 				"signup":  "POST /api/v1/auth/signup",
 				"login":   "POST /api/v1/auth/login",
 				"refresh": "POST /api/v1/auth/refresh",
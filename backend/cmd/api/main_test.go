@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/handlers"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// unreachableRedis connects to a port nothing listens on, the way main does, and returns the degraded client
+func unreachableRedis(t *testing.T) *database.RedisClient {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	client, err := database.NewRedisClient(database.RedisConfig{Host: "127.0.0.1", Port: port})
+	if err == nil {
+		client.Close()
+		t.Fatal("expected connecting to an unreachable Redis to fail")
+	}
+	return client
+}
+
+// unreachableDB returns a database handle for a port nothing listens on; every query fails
+func unreachableDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	// sqlx.Open doesn't connect, so this only fails once the probe queries it
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port="+port+" user=test dbname=test sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("failed to open database handle: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &database.DB{DB: db}
+}
+
+// newRouter builds the router with main's global middleware and routes
+// Handlers are left nil; the routes exercised here don't reach them
+func newRouter(t *testing.T, db *database.DB, redis *database.RedisClient) *gin.Engine {
+	t.Helper()
+	return newRouterWithConfig(t, testutil.Config(t), db, redis)
+}
+
+// newRouterWithConfig is newRouter with a config the test has adjusted
+func newRouterWithConfig(t *testing.T, cfg *config.Config, db *database.DB, redis *database.RedisClient) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	jwtKeys := utils.NewJWTKeySet(cfg.JWT.SigningMethod, cfg.JWT.KeyID, cfg.JWT.Secret, cfg.JWT.PreviousKeys)
+
+	router := gin.New()
+	if err := useGlobalMiddleware(router, cfg, jwtKeys, redis); err != nil {
+		t.Fatalf("useGlobalMiddleware failed: %v", err)
+	}
+
+	setupRoutes(router, db, redis, cfg, jwtKeys, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	return router
+}
+
+func TestServesPingWithoutRedis(t *testing.T) {
+	router := newRouter(t, nil, unreachableRedis(t))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+func TestHealthReportsRedisUnavailable(t *testing.T) {
+	db := testutil.DB(t)
+	router := newRouter(t, db, unreachableRedis(t))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+
+	// Losing Redis degrades the API but keeps it in service
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var body struct {
+		Status   string `json:"status"`
+		Services struct {
+			Redis struct {
+				Status string `json:"status"`
+			} `json:"redis"`
+		} `json:"services"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if body.Status != "degraded" {
+		t.Errorf("status = %q, want degraded", body.Status)
+	}
+	if body.Services.Redis.Status != "unavailable" {
+		t.Errorf("redis status = %q, want unavailable", body.Services.Redis.Status)
+	}
+}
+
+func TestHealthReportsMissingSchemaObjects(t *testing.T) {
+	db := testutil.DB(t)
+	testutil.HideFunction(t, db, "generate_bill_number(bill_type, text)")
+	router := newRouter(t, db, testutil.Redis(t))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+
+	// The database still answers, so the API stays in service but degraded
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var body struct {
+		Status   string `json:"status"`
+		Services struct {
+			Database struct {
+				Schema struct {
+					Status string                `json:"status"`
+					Detail database.SchemaStatus `json:"detail"`
+				} `json:"schema"`
+			} `json:"database"`
+		} `json:"services"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if body.Status != "degraded" {
+		t.Errorf("status = %q, want degraded", body.Status)
+	}
+	if schema := body.Services.Database.Schema; schema.Status == "healthy" {
+		t.Error("schema reported healthy without generate_bill_number")
+	} else if len(schema.Detail.MissingFunctions) != 1 || schema.Detail.MissingFunctions[0] != "generate_bill_number" {
+		t.Errorf("missing functions = %v, want [generate_bill_number]", schema.Detail.MissingFunctions)
+	}
+}
+
+// probe requests path and returns the status code and the decoded checks
+func probe(t *testing.T, router *gin.Engine, path string) (int, map[string]string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body struct {
+		Checks map[string]string `json:"checks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	return w.Code, body.Checks
+}
+
+func TestLivezIgnoresDependencies(t *testing.T) {
+	router := newRouter(t, unreachableDB(t), unreachableRedis(t))
+
+	if status, _ := probe(t, router, "/api/v1/livez"); status != http.StatusOK {
+		t.Errorf("status = %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestReadyzWithUnreachableDatabase(t *testing.T) {
+	router := newRouter(t, unreachableDB(t), testutil.Redis(t))
+
+	status, checks := probe(t, router, "/api/v1/readyz")
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", status, http.StatusServiceUnavailable)
+	}
+	if checks["database"] == "ok" || checks["schema"] != "skipped" || checks["redis"] != "ok" {
+		t.Errorf("checks = %v, want the database failing and the schema skipped", checks)
+	}
+}
+
+func TestReadyzWithHealthyDatabase(t *testing.T) {
+	db := testutil.DB(t)
+
+	t.Run("all dependencies up", func(t *testing.T) {
+		status, checks := probe(t, newRouter(t, db, testutil.Redis(t)), "/api/v1/readyz")
+		if status != http.StatusOK {
+			t.Errorf("status = %d, want %d: %v", status, http.StatusOK, checks)
+		}
+	})
+
+	t.Run("livez", func(t *testing.T) {
+		if status, _ := probe(t, newRouter(t, db, testutil.Redis(t)), "/api/v1/livez"); status != http.StatusOK {
+			t.Errorf("status = %d, want %d", status, http.StatusOK)
+		}
+	})
+
+	t.Run("schema incomplete", func(t *testing.T) {
+		testutil.HideFunction(t, db, "generate_bill_number(bill_type, text)")
+		status, checks := probe(t, newRouter(t, db, testutil.Redis(t)), "/api/v1/readyz")
+		if status != http.StatusServiceUnavailable || checks["database"] != "ok" || checks["schema"] == "ok" {
+			t.Errorf("status = %d, checks = %v; want 503 with the schema failing", status, checks)
+		}
+	})
+
+	t.Run("redis down in degraded mode", func(t *testing.T) {
+		status, checks := probe(t, newRouter(t, db, unreachableRedis(t)), "/api/v1/readyz")
+		if status != http.StatusOK || checks["redis"] == "ok" {
+			t.Errorf("status = %d, checks = %v; want ready with Redis reported degraded", status, checks)
+		}
+	})
+
+	t.Run("redis down when required", func(t *testing.T) {
+		cfg := testutil.Config(t)
+		cfg.Redis.Required = true
+		status, checks := probe(t, newRouterWithConfig(t, cfg, db, unreachableRedis(t)), "/api/v1/readyz")
+		if status != http.StatusServiceUnavailable || checks["redis"] == "ok" {
+			t.Errorf("status = %d, checks = %v; want 503 with Redis failing", status, checks)
+		}
+	})
+}
+
+// pingFrom requests /api/v1/ping from peer, claiming to forward for forwardedFor
+func pingFrom(router *gin.Engine, peer, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	req.RemoteAddr = peer + ":1234"
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	const rpm = 3
+
+	t.Run("untrusted peer", func(t *testing.T) {
+		cfg := testutil.Config(t)
+		cfg.App.RateLimitRPM = rpm
+		cfg.App.TrustedProxies = nil
+		router := newRouterWithConfig(t, cfg, nil, testutil.Redis(t))
+
+		// A new X-Forwarded-For on every request still counts against the connecting address
+		for i := 1; i <= rpm; i++ {
+			if code := pingFrom(router, "192.0.2.1", fmt.Sprintf("198.51.100.%d", i)); code != http.StatusOK {
+				t.Fatalf("request %d: status = %d, want 200", i, code)
+			}
+		}
+		if code := pingFrom(router, "192.0.2.1", "198.51.100.99"); code != http.StatusTooManyRequests {
+			t.Errorf("request %d: status = %d, want 429", rpm+1, code)
+		}
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		cfg := testutil.Config(t)
+		cfg.App.RateLimitRPM = rpm
+		cfg.App.TrustedProxies = []string{"10.0.0.1"}
+		router := newRouterWithConfig(t, cfg, nil, testutil.Redis(t))
+
+		// Behind a trusted proxy each forwarded client has its own window
+		for i := 1; i <= rpm+1; i++ {
+			if code := pingFrom(router, "10.0.0.1", fmt.Sprintf("198.51.100.%d", i)); code != http.StatusOK {
+				t.Fatalf("client %d: status = %d, want 200", i, code)
+			}
+		}
+	})
+}
+
+// verifyFrom posts body to /api/v1/verify from peer, claiming to forward for forwardedFor
+func verifyFrom(router *gin.Engine, peer, forwardedFor string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/verify", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = peer + ":1234"
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnonymousVerifyLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	const rpm = 2
+	cfg := testutil.Config(t)
+	cfg.App.RateLimitRPM = 100
+	cfg.App.VerifyRateLimitRPM = 100
+	cfg.App.AnonymousVerifyRateLimitRPM = rpm
+	cfg.App.TrustedProxies = nil
+	router := newRouterWithConfig(t, cfg, nil, testutil.Redis(t))
+
+	// An empty body is rejected by the handler once the limit lets it through
+	for i := 1; i <= rpm; i++ {
+		if w := verifyFrom(router, "192.0.2.1", fmt.Sprintf("198.51.100.%d", i), nil); w.Code != http.StatusBadRequest {
+			t.Fatalf("request %d: status = %d, want 400: %s", i, w.Code, w.Body.String())
+		}
+	}
+	if w := verifyFrom(router, "192.0.2.1", "198.51.100.99", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("request %d: status = %d, want 429", rpm+1, w.Code)
+	}
+}
+
+func TestAnonymousDailyAllowanceIgnoresSpoofedForwardedFor(t *testing.T) {
+	db := testutil.DB(t)
+	redis := testutil.Redis(t)
+	cfg := testutil.Config(t)
+	cfg.App.RateLimitRPM = 100
+	cfg.App.VerifyRateLimitRPM = 100
+	cfg.App.AnonymousVerifyRateLimitRPM = 100
+	cfg.App.AnonymousDailyFreeVerifications = 2
+	cfg.App.TrustedProxies = nil
+
+	verifications := repository.NewVerificationRepository(db.DB)
+	bills := repository.NewBillRepository(db.DB, nil, 0)
+	outbox := repository.NewOutboxRepository(db.DB)
+	counters := repository.NewActivityCounterRepository(redis)
+	verificationService := services.NewVerificationService(db, verifications, bills, repository.NewBillAliasRepository(db.DB),
+		repository.NewUserRepository(db.DB), repository.NewWalletTransactionRepository(db.DB), outbox, counters,
+		services.NewSuspiciousActivityDetector(counters, cfg), services.NewFakeBillAlerter(db, counters, outbox, cfg), nil, cfg)
+	verificationHandler := handlers.NewVerificationHandler(verificationService, nil, cfg.Location())
+
+	gin.SetMode(gin.TestMode)
+	jwtKeys := utils.NewJWTKeySet(cfg.JWT.SigningMethod, cfg.JWT.KeyID, cfg.JWT.Secret, cfg.JWT.PreviousKeys)
+	router := gin.New()
+	if err := useGlobalMiddleware(router, cfg, jwtKeys, redis); err != nil {
+		t.Fatalf("useGlobalMiddleware failed: %v", err)
+	}
+	setupRoutes(router, db, redis, cfg, jwtKeys, nil, nil, verificationHandler, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	issuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	bill := testutil.CreateBill(t, db, issuer, 100, nil)
+	body, _ := json.Marshal(map[string]string{"bill_number": bill.BillNumber})
+
+	for i := 1; i <= cfg.App.AnonymousDailyFreeVerifications; i++ {
+		if w := verifyFrom(router, "192.0.2.1", fmt.Sprintf("198.51.100.%d", i), body); w.Code != http.StatusOK {
+			t.Fatalf("free verification %d: status = %d, want 200: %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	// A new X-Forwarded-For doesn't buy a new allowance
+	w := verifyFrom(router, "192.0.2.1", "198.51.100.99", body)
+	if w.Code != http.StatusTooManyRequests || !bytes.Contains(w.Body.Bytes(), []byte(utils.CodeAnonymousQuotaExceeded)) {
+		t.Errorf("status = %d, want 429 %s: %s", w.Code, utils.CodeAnonymousQuotaExceeded, w.Body.String())
+	}
+}
+
+func TestBillRoutesUseSpecPaths(t *testing.T) {
+	router := newRouter(t, nil, testutil.Redis(t))
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	for _, route := range []string{
+		"POST /api/v1/bills/:id/restore",
+		"POST /api/v1/bills/:id/short-link",
+		"POST /api/v1/bills/:id/transfer",
+		"GET /api/v1/bills/:id/revisions",
+		"PUT /api/v1/bills/:id",
+	} {
+		if !registered[route] {
+			t.Errorf("%s is not registered", route)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/seed"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/migrations"
+)
+
+// Seeds a development database with users, bills and verifications
+// Usage: go run ./cmd/seed [-institutions 2] [-bills 10] [-verifications 25] [-migrate]
+func main() {
+	defaults := seed.DefaultOptions()
+	institutions := flag.Int("institutions", defaults.Institutions, "institution accounts to create")
+	bills := flag.Int("bills", defaults.BillsPerInstitution, "bills to create per institution")
+	verifications := flag.Int("verifications", defaults.Verifications, "verifications to record")
+	password := flag.String("password", defaults.Password, "password for every seeded account")
+	migrate := flag.Bool("migrate", false, "apply pending migrations first")
+	flag.Parse()
+
+	if *institutions < 0 || *bills < 0 || *verifications < 0 {
+		log.Fatalf("❌ -institutions, -bills and -verifications must not be negative")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Failed to load config: %v", err)
+	}
+	if cfg.IsProduction() {
+		log.Fatalf("❌ Refusing to seed a production database")
+	}
+
+	db, err := database.NewPostgresDB(database.Config{
+		Host:             cfg.Database.Host,
+		Port:             cfg.Database.Port,
+		User:             cfg.Database.User,
+		Password:         cfg.Database.Password,
+		DBName:           cfg.Database.DBName,
+		SSLMode:          cfg.Database.SSLMode,
+		MaxConnections:   cfg.Database.MaxConnections,
+		MaxIdleConns:     cfg.Database.MaxIdleConns,
+		ConnMaxLifetime:  cfg.Database.ConnMaxLifetime,
+		StatementTimeout: cfg.Database.StatementTimeout,
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if *migrate {
+		if _, err := db.Migrate(ctx, migrations.FS); err != nil {
+			log.Fatalf("❌ Failed to apply migrations: %v", err)
+		}
+	}
+
+	// No Redis: bill caching, rate limits and fraud counters are skipped, as in degraded mode
+	userRepo := repository.NewUserRepository(db.DB)
+	billRepo := repository.NewBillRepository(db.DB, nil, cfg.Redis.BillCacheTTL)
+	billAliasRepo := repository.NewBillAliasRepository(db.DB)
+	verificationRepo := repository.NewVerificationRepository(db.DB)
+	walletTxRepo := repository.NewWalletTransactionRepository(db.DB)
+	activityCounterRepo := repository.NewActivityCounterRepository(nil)
+	idempotencyRepo := repository.NewIdempotencyKeyRepository(db.DB)
+	outboxRepo := repository.NewOutboxRepository(db.DB)
+	auditRepo := repository.NewAuditRepository(db.DB)
+
+	walletService := services.NewWalletService(db, userRepo, walletTxRepo)
+	pdfService := services.NewPDFService(cfg.App.FrontendURL)
+	emailService := services.NewEmailService(cfg, billRepo, billAliasRepo, userRepo, pdfService)
+	lowBalanceNotifier := services.NewLowBalanceNotifier(userRepo, emailService, cfg)
+	billService := services.NewBillService(db, billRepo, billAliasRepo, userRepo, verificationRepo, walletTxRepo, idempotencyRepo, outboxRepo, auditRepo, lowBalanceNotifier, cfg)
+	suspiciousDetector := services.NewSuspiciousActivityDetector(activityCounterRepo, cfg)
+	fakeBillAlerter := services.NewFakeBillAlerter(db, activityCounterRepo, outboxRepo, cfg)
+	verificationService := services.NewVerificationService(db, verificationRepo, billRepo, billAliasRepo, userRepo, walletTxRepo, outboxRepo, activityCounterRepo, suspiciousDetector, fakeBillAlerter, lowBalanceNotifier, cfg)
+	kycService := services.NewKYCService(db, userRepo, auditRepo, emailService)
+
+	opts := defaults
+	opts.Institutions = *institutions
+	opts.BillsPerInstitution = *bills
+	opts.Verifications = *verifications
+	opts.Password = *password
+
+	seeder := seed.NewSeeder(seed.Services{
+		UserRepo:     userRepo,
+		Wallet:       walletService,
+		KYC:          kycService,
+		Bills:        billService,
+		Verification: verificationService,
+	}, cfg, opts)
+
+	result, err := seeder.Run(ctx)
+	if err != nil {
+		log.Fatalf("❌ Seeding failed: %v", err)
+	}
+	if result.Skipped {
+		log.Printf("✅ Database already seeded (%s exists), nothing to do", seed.AdminEmail)
+		return
+	}
+
+	log.Printf("✅ Seeded %d users, %d bills and %d verifications", result.Users, result.Bills, result.Verifications)
+	log.Printf("🔑 Log in as %s (or verifier@, public@, institution1@seed.epr.local) with password %q", seed.AdminEmail, opts.Password)
+}
@@ -1,10 +1,17 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+	_ "time/tzdata" // VERIFICATION_QUOTA_TIMEZONE must resolve even on hosts without zoneinfo
 
 	"github.com/joho/godotenv"
 )
@@ -29,8 +36,33 @@ type Config struct {
 	// Pricing settings
 	Pricing PricingConfig
 
+	// Payment gateway settings
+	Payment PaymentConfig
+
+	// Blockchain commitment worker settings
+	Blockchain BlockchainConfig
+
+	// Notification outbox dispatcher settings
+	Outbox OutboxConfig
+
+	// Verification log retention settings
+	Retention RetentionConfig
+
+	// Suspicious verification detection settings
+	Fraud FraudConfig
+
+	// What limited-access verifiers see of a bill, keyed by bill access level (e.g. "restricted")
+	// Loaded from VERIFICATION_REDACTION as JSON
+	Redaction map[string]RedactionPolicy
+
+	// Verification QR code settings
+	QR QRConfig
+
 	// Application settings
 	App AppConfig
+
+	// APP_TIMEZONE, loaded once by Load
+	location *time.Location
 }
 
 // ServerConfig holds HTTP server configuration
@@ -38,6 +70,8 @@ type ServerConfig struct {
 	Port        string // Port to run on (e.g., "8080")
 	Host        string // Host address (e.g., "localhost")
 	Environment string // "development", "staging", "production"
+
+	WorkerDrainTimeout time.Duration // How long shutdown waits for background workers after the HTTP server stops
 }
 
 // DatabaseConfig holds PostgreSQL configuration
@@ -51,14 +85,20 @@ type DatabaseConfig struct {
 	MaxConnections  int    // Maximum number of open connections
 	MaxIdleConns    int    // Maximum number of idle connections
 	ConnMaxLifetime time.Duration
+
+	StatementTimeout   time.Duration // Postgres cancels any statement running longer than this (0 disables)
+	SlowQueryThreshold time.Duration // Log repository queries slower than this (0 disables)
+	AutoMigrate        bool          // Apply pending migrations on startup; outside production only, use -migrate there
 }
 
 // RedisConfig holds Redis cache configuration
 type RedisConfig struct {
-	Host     string
-	Port     string
-	Password string
-	DB       int // Redis database number (0-15)
+	Host         string
+	Port         string
+	Password     string
+	DB           int           // Redis database number (0-15)
+	BillCacheTTL time.Duration // How long bills are cached by bill number (0 disables)
+	Required     bool          // Refuse to start, and report not ready, without Redis instead of running degraded
 }
 
 // JWTConfig holds JWT token configuration
@@ -66,15 +106,46 @@ type JWTConfig struct {
 	Secret             string
 	AccessTokenExpiry  time.Duration
 	RefreshTokenExpiry time.Duration
+
+	// Per-role overrides of the expiries above, keyed by role (e.g. "master_admin")
+	RoleAccessTokenExpiry  map[string]time.Duration
+	RoleRefreshTokenExpiry map[string]time.Duration
+
+	SigningMethod string            // HS256, HS384 or HS512
+	KeyID         string            // kid stamped on new tokens; set it when rotating keys
+	PreviousKeys  map[string]string // kid -> secret of retired keys that still validate during a rotation
+
+	// Base64 of the 32-byte key that encrypts stored TOTP secrets; outside production it defaults to one derived from Secret
+	TwoFactorEncryptionKey string
 }
 
 // PricingConfig holds billing and pricing rules
+// All fees are in the platform base currency (Payment.Currency); the verification percentage
+// is applied to the bill's amount as issued, without converting it from the bill's currency
 type PricingConfig struct {
 	BillGenerationFee      float64 // Fee to generate a bill (e.g., 0.50)
 	VerificationMinFee     float64 // Minimum verification fee (e.g., 1.00)
 	VerificationMaxFee     float64 // Maximum verification fee (e.g., 10.00)
 	VerificationPercentage float64 // Percentage of bill amount (e.g., 0.01 for 1%)
 	LoyaltyFreeEveryN      int     // Free verification every N verifications
+
+	MaxBillAmount    float64 // Bills above this amount are rejected (0 disables)
+	ReviewBillAmount float64 // Bills above this amount are issued but flagged for admin review (0 disables)
+
+	LowBalanceThreshold      float64       // Warn by email when the wallet drops below this (0 disables)
+	LowBalanceNotifyCooldown time.Duration // Minimum time between low balance emails per user
+
+	// Per-bill-type verification pricing, keyed by bill type (e.g. "medical_bill")
+	// Loaded from VERIFICATION_PRICING_OVERRIDES as JSON
+	BillTypeOverrides map[string]BillTypePricing
+}
+
+// BillTypePricing overrides verification pricing for one bill type
+// Omitted fields fall back to the default PricingConfig values
+type BillTypePricing struct {
+	Percentage *float64 `json:"percentage"`
+	MinFee     *float64 `json:"min_fee"`
+	MaxFee     *float64 `json:"max_fee"`
 }
 type EmailConfig struct {
 	SMTPHost     string
@@ -82,12 +153,137 @@ type EmailConfig struct {
 	SMTPUser     string
 	SMTPPassword string
 	FromEmail    string
+	SMTPTimeout  time.Duration // Limit on connecting to and talking with the SMTP server for one send
+
+	RetryMaxAttempts int           // Send attempts for transient failures, including the first
+	RetryBaseDelay   time.Duration // Wait before the first retry; doubles for each later one
+
+	DailySummaryEnabled bool // Email institutions a summary of the bills they issued each day
+	DailySummaryHour    int  // Hour of day (0-23, in APP_TIMEZONE) the summary is sent
+}
+
+// PaymentConfig holds payment gateway settings for wallet top-ups
+type PaymentConfig struct {
+	Gateway   string // "mock" (only option until a real gateway is integrated; development and staging only)
+	KeySecret string // Secret used to verify payment signatures
+	Currency  string // e.g. "INR"
+}
+
+// BlockchainConfig holds settings for the background blockchain commitment worker
+type BlockchainConfig struct {
+	Enabled      bool          // Run the worker at all; off unless set, since no real ledger is integrated yet
+	Client       string        // "noop" (only option until Hyperledger Fabric is integrated; never in production)
+	PollInterval time.Duration // How often to look for pending bills
+	BatchSize    int           // Max bills committed per poll
+	MaxAttempts  int           // Commit attempts before a bill is marked failed
+}
+
+// OutboxConfig holds settings for the background dispatcher that delivers queued emails and webhooks
+type OutboxConfig struct {
+	PollInterval time.Duration // How often to look for due messages
+	BatchSize    int           // Max messages delivered per poll
+	MaxAttempts  int           // Delivery attempts before a message is marked failed
+	Lease        time.Duration // How long a claimed batch is reserved; must outlast delivering a whole batch
+}
+
+// RetentionConfig holds settings for the background job that prunes old verification records
+// Pruned records are rolled up into daily totals first, so stats and bill verification counts don't change
+type RetentionConfig struct {
+	VerificationRetention time.Duration // Verifications older than this are pruned (0 keeps them forever)
+	SuspiciousRetention   time.Duration // Same for suspicious verifications, usually longer (0 keeps them forever)
+	Archive               bool          // Copy pruned rows to verifications_archive instead of dropping them
+	Interval              time.Duration // How often the job runs
+	BatchSize             int           // Max rows pruned per statement, to keep locks short
+}
+
+// FraudConfig holds thresholds for flagging suspicious verification activity
+// Each limit applies within Window; 0 disables that rule
+type FraudConfig struct {
+	Window                  time.Duration // Sliding window the limits below apply to
+	MaxDistinctBillsPerIP   int           // Distinct bills one IP may look up
+	MaxNotFoundPerIP        int           // Lookups of unknown bill numbers from one IP (enumeration)
+	MaxVerificationsPerBill int           // Verifications of a single bill
+	DowngradeResponse       bool          // Withhold bill details from flagged verifications
+
+	// Alert the master admins once unknown numbers under one bill-number prefix are looked up FakeBillAlertThreshold
+	// times within FakeBillAlertWindow; 0 disables. Each prefix alerts at most once per window
+	FakeBillAlertThreshold int
+	FakeBillAlertWindow    time.Duration
+
+	// Verifier reputation (GET /admin/verifiers): history considered, and when a low score flags a verifier for review
+	VerifierScoreWindow   time.Duration // Verifications older than this don't count towards the score
+	VerifierFlagScore     float64       // Verifiers scoring below this are flagged
+	VerifierFlagMinVolume int           // ...once they have at least this many verifications in the window
+}
+
+// RedactionPolicy controls which bill_data fields a limited-access verifier sees
+// Fields in neither list are hidden; amount and currency are always shown
+type RedactionPolicy struct {
+	Show []string `json:"show"` // Shown as-is
+	Mask []string `json:"mask"` // String fields shown partially masked, e.g. "Rahul S*****"
+}
+
+// QRConfig holds defaults for generated verification QR codes
+// Printed bills that get faxed or photocopied scan better with larger images and higher recovery levels
+type QRConfig struct {
+	DefaultSize   int    // Image size in pixels when the caller doesn't ask for one
+	RecoveryLevel string // Error correction: "low", "medium", "high" or "highest"
 }
 
 // AppConfig holds general application settings
 type AppConfig struct {
-	FrontendURL  string // Frontend URL for CORS
-	RateLimitRPM int    // Rate limit: requests per minute
+	FrontendURL                 string   // Frontend URL used in emails and QR links
+	CORSAllowedOrigins          []string // Origins allowed to make credentialed cross-origin requests; "*" only works in development
+	RateLimitRPM                int      // Rate limit: requests per minute
+	VerifyRateLimitRPM          int      // Stricter rate limit for the /verify endpoints
+	AnonymousVerifyRateLimitRPM int      // Even stricter limit for verifications made without logging in
+
+	// Reverse proxies (IPs or CIDRs) whose X-Forwarded-For header is believed. Empty trusts none, so the
+	// client IP that rate limits and anonymous quotas count against is always the connecting address
+	TrustedProxies []string
+
+	// Timezone (an IANA name) that day, week and month boundaries are computed in: daily summaries,
+	// "this month" stats, trend buckets and date filters. Timestamps are still stored in UTC
+	Timezone string
+
+	// Free verifications per IP per day for callers who aren't logged in; 0 means unlimited
+	// The count resets at midnight in VerificationQuotaTimezone (an IANA name, e.g. Asia/Kolkata)
+	AnonymousDailyFreeVerifications int
+	VerificationQuotaTimezone       string
+
+	// Roles anyone can pick at signup; other roles need an admin invite
+	SignupAllowedRoles []string
+	InviteExpiry       time.Duration // How long admin signup invites stay valid
+
+	// Reject institution signups whose GSTIN another institution account already uses
+	// Off by default, since colleagues at one organisation share its GSTIN
+	UniqueInstitutionGSTIN bool
+
+	BillTransferExpiry time.Duration // How long the receiving account has to accept a bill transfer
+
+	// Reject a bill identical to one the issuer created this recently, unless the request sets allow_duplicate; 0 disables
+	DuplicateBillWindow time.Duration
+
+	EmailVerificationExpiry time.Duration // How long email verification links stay valid
+	PasswordResetExpiry     time.Duration // How long password reset links stay valid
+	RequireVerifiedEmail    bool          // Users must verify their email before generating bills or topping up (ENFORCE_EMAIL_VERIFICATION, formerly REQUIRE_VERIFIED_EMAIL)
+	IdempotencyKeyTTL       time.Duration // How long an Idempotency-Key on bill creation is remembered
+	BulkBillMaxItems        int           // Maximum bills accepted by one bulk creation request
+	BillExportMaxBills      int           // Maximum bills in one ZIP export of bill PDFs
+
+	// page_size used by list endpoints when a request omits it, and the largest one accepted
+	DefaultPageSize int
+	MaxPageSize     int
+
+	// Request body size limits in bytes; larger bodies are rejected with 413
+	MaxBodyBytes     int // Default for every route
+	AuthMaxBodyBytes int // Auth routes only carry credentials, so they get a tight limit
+	BulkMaxBodyBytes int // Bulk bill creation carries up to BulkBillMaxItems bills
+
+	// ISO 4217 codes bills may be issued in, and the one used when a request omits currency
+	// Only affects how the bill amount is displayed; fees are always charged in Payment.Currency
+	SupportedCurrencies []string
+	DefaultCurrency     string
 }
 
 // Load reads configuration from environment variables
@@ -106,6 +302,8 @@ func Load() (*Config, error) {
 			Port:        getEnv("SERVER_PORT", "8080"),
 			Host:        getEnv("SERVER_HOST", "localhost"),
 			Environment: getEnv("ENVIRONMENT", "development"),
+
+			WorkerDrainTimeout: parseDuration(getEnv("WORKER_DRAIN_TIMEOUT", "15s"), 15*time.Second),
 		},
 		Database: DatabaseConfig{
 			Host:            getEnv("DB_HOST", "localhost"),
@@ -117,6 +315,10 @@ func Load() (*Config, error) {
 			MaxConnections:  getEnvAsInt("DB_MAX_CONNECTIONS", 25),
 			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNECTIONS", 5),
 			ConnMaxLifetime: time.Hour,
+
+			StatementTimeout:   parseDuration(getEnv("DB_STATEMENT_TIMEOUT", "30s"), 30*time.Second),
+			SlowQueryThreshold: parseDuration(getEnv("DB_SLOW_QUERY_THRESHOLD", "0"), 0),
+			AutoMigrate:        getEnvAsBool("DB_AUTO_MIGRATE", false),
 		},
 		Email: EmailConfig{
 			SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
@@ -124,17 +326,30 @@ func Load() (*Config, error) {
 			SMTPUser:     getEnv("SMTP_USER", "@gmail.com"),
 			SMTPPassword: getEnv("SMTP_PASSWORD", " "),
 			FromEmail:    getEnv("FromEmail", "no-reply-epr@epr.com"),
+			SMTPTimeout:  parseDuration(getEnv("SMTP_TIMEOUT", "30s"), 30*time.Second),
+
+			RetryMaxAttempts: getEnvAsInt("SMTP_RETRY_MAX_ATTEMPTS", 3),
+			RetryBaseDelay:   parseDuration(getEnv("SMTP_RETRY_BASE_DELAY", "1s"), time.Second),
+
+			DailySummaryEnabled: getEnvAsBool("DAILY_SUMMARY_ENABLED", false),
+			DailySummaryHour:    getEnvAsInt("DAILY_SUMMARY_HOUR", 20),
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnv("REDIS_PORT", "6379"),
-			Password: getEnv("REDIS_PASSWORD", "redispass123"),
-			DB:       getEnvAsInt("REDIS_DB", 0),
+			Host:         getEnv("REDIS_HOST", "localhost"),
+			Port:         getEnv("REDIS_PORT", "6379"),
+			Password:     getEnv("REDIS_PASSWORD", "redispass123"),
+			DB:           getEnvAsInt("REDIS_DB", 0),
+			BillCacheTTL: parseDuration(getEnv("REDIS_BILL_CACHE_TTL", "10m"), 10*time.Minute),
+			Required:     getEnvAsBool("REDIS_REQUIRED", false),
 		},
 		JWT: JWTConfig{
 			Secret:             getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production"),
 			AccessTokenExpiry:  parseDuration(getEnv("JWT_ACCESS_TOKEN_EXPIRY", "15m"), 15*time.Minute),
 			RefreshTokenExpiry: parseDuration(getEnv("JWT_REFRESH_TOKEN_EXPIRY", "7d"), 7*24*time.Hour),
+			SigningMethod:      getEnv("JWT_SIGNING_METHOD", "HS256"),
+			KeyID:              getEnv("JWT_KEY_ID", ""),
+
+			TwoFactorEncryptionKey: getEnv("TWO_FACTOR_ENCRYPTION_KEY", ""),
 		},
 		Pricing: PricingConfig{
 			BillGenerationFee:      getEnvAsFloat("BILL_GENERATION_FEE", 0.50),
@@ -142,17 +357,138 @@ func Load() (*Config, error) {
 			VerificationMaxFee:     getEnvAsFloat("VERIFICATION_MAX_FEE", 10.00),
 			VerificationPercentage: getEnvAsFloat("VERIFICATION_PERCENTAGE", 0.01),
 			LoyaltyFreeEveryN:      getEnvAsInt("LOYALTY_FREE_EVERY_N_VERIFICATIONS", 10),
+
+			MaxBillAmount:    getEnvAsFloat("MAX_BILL_AMOUNT", 100000000),
+			ReviewBillAmount: getEnvAsFloat("REVIEW_BILL_AMOUNT", 10000000),
+
+			LowBalanceThreshold:      getEnvAsFloat("LOW_BALANCE_THRESHOLD", 10.00),
+			LowBalanceNotifyCooldown: parseDuration(getEnv("LOW_BALANCE_NOTIFY_COOLDOWN", "24h"), 24*time.Hour),
+		},
+		Payment: PaymentConfig{
+			Gateway:   getEnv("PAYMENT_GATEWAY", ""),
+			KeySecret: getEnv("PAYMENT_KEY_SECRET", "mock-payment-secret"),
+			Currency:  getEnv("PAYMENT_CURRENCY", "INR"),
+		},
+		Blockchain: BlockchainConfig{
+			Enabled:      getEnvAsBool("BLOCKCHAIN_WORKER_ENABLED", false),
+			Client:       getEnv("BLOCKCHAIN_CLIENT", "noop"),
+			PollInterval: parseDuration(getEnv("BLOCKCHAIN_POLL_INTERVAL", "10s"), 10*time.Second),
+			BatchSize:    getEnvAsInt("BLOCKCHAIN_BATCH_SIZE", 50),
+			MaxAttempts:  getEnvAsInt("BLOCKCHAIN_MAX_ATTEMPTS", 5),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: parseDuration(getEnv("OUTBOX_POLL_INTERVAL", "5s"), 5*time.Second),
+			BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 50),
+			MaxAttempts:  getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 8),
+			Lease:        parseDuration(getEnv("OUTBOX_LEASE", "10m"), 10*time.Minute),
+		},
+		Retention: RetentionConfig{
+			VerificationRetention: parseDuration(getEnv("VERIFICATION_RETENTION", "0"), 0),
+			SuspiciousRetention:   parseDuration(getEnv("VERIFICATION_SUSPICIOUS_RETENTION", "0"), 0),
+			Archive:               getEnvAsBool("VERIFICATION_ARCHIVE", true),
+			Interval:              parseDuration(getEnv("VERIFICATION_PRUNE_INTERVAL", "24h"), 24*time.Hour),
+			BatchSize:             getEnvAsInt("VERIFICATION_PRUNE_BATCH_SIZE", 1000),
+		},
+		Fraud: FraudConfig{
+			Window:                  parseDuration(getEnv("FRAUD_WINDOW", "10m"), 10*time.Minute),
+			MaxDistinctBillsPerIP:   getEnvAsInt("FRAUD_MAX_DISTINCT_BILLS_PER_IP", 20),
+			MaxNotFoundPerIP:        getEnvAsInt("FRAUD_MAX_NOT_FOUND_PER_IP", 5),
+			MaxVerificationsPerBill: getEnvAsInt("FRAUD_MAX_VERIFICATIONS_PER_BILL", 50),
+			DowngradeResponse:       getEnvAsBool("FRAUD_DOWNGRADE_RESPONSE", false),
+
+			FakeBillAlertThreshold: getEnvAsInt("FRAUD_FAKE_BILL_ALERT_THRESHOLD", 10),
+			FakeBillAlertWindow:    parseDuration(getEnv("FRAUD_FAKE_BILL_ALERT_WINDOW", "1h"), time.Hour),
+
+			VerifierScoreWindow:   parseDuration(getEnv("VERIFIER_SCORE_WINDOW", "90d"), 90*24*time.Hour),
+			VerifierFlagScore:     getEnvAsFloat("VERIFIER_FLAG_SCORE", 50),
+			VerifierFlagMinVolume: getEnvAsInt("VERIFIER_FLAG_MIN_VOLUME", 20),
+		},
+		QR: QRConfig{
+			DefaultSize:   getEnvAsInt("QR_DEFAULT_SIZE", 256),
+			RecoveryLevel: getEnv("QR_RECOVERY_LEVEL", "medium"),
 		},
 		App: AppConfig{
-			FrontendURL:  getEnv("FRONTEND_URL", "http://localhost:3000"),
-			RateLimitRPM: getEnvAsInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 60),
+			FrontendURL:                 getEnv("FRONTEND_URL", "http://localhost:3000"),
+			CORSAllowedOrigins:          getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{getEnv("FRONTEND_URL", "http://localhost:3000")}),
+			RateLimitRPM:                getEnvAsInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 60),
+			VerifyRateLimitRPM:          getEnvAsInt("VERIFY_RATE_LIMIT_REQUESTS_PER_MINUTE", 20),
+			AnonymousVerifyRateLimitRPM: getEnvAsInt("ANONYMOUS_VERIFY_RATE_LIMIT_REQUESTS_PER_MINUTE", 5),
+			TrustedProxies:              getEnvAsSlice("TRUSTED_PROXIES", nil),
+			Timezone:                    getEnv("APP_TIMEZONE", "Asia/Kolkata"),
+
+			AnonymousDailyFreeVerifications: getEnvAsInt("ANONYMOUS_DAILY_FREE_VERIFICATIONS", 20),
+			VerificationQuotaTimezone:       getEnv("VERIFICATION_QUOTA_TIMEZONE", getEnv("APP_TIMEZONE", "Asia/Kolkata")),
+
+			SignupAllowedRoles: getEnvAsSlice("SIGNUP_ALLOWED_ROLES", []string{"public", "institution_user", "institution_admin"}),
+			InviteExpiry:       parseDuration(getEnv("INVITE_EXPIRY", "168h"), 7*24*time.Hour),
+
+			UniqueInstitutionGSTIN: getEnvAsBool("UNIQUE_INSTITUTION_GSTIN", false),
+			BillTransferExpiry:     parseDuration(getEnv("BILL_TRANSFER_EXPIRY", "72h"), 72*time.Hour),
+			DuplicateBillWindow:    parseDuration(getEnv("DUPLICATE_BILL_WINDOW", "10m"), 10*time.Minute),
+
+			EmailVerificationExpiry: parseDuration(getEnv("EMAIL_VERIFICATION_EXPIRY", "24h"), 24*time.Hour),
+			PasswordResetExpiry:     parseDuration(getEnv("PASSWORD_RESET_EXPIRY", "1h"), time.Hour),
+			RequireVerifiedEmail:    getEnvAsBool("ENFORCE_EMAIL_VERIFICATION", getEnvAsBool("REQUIRE_VERIFIED_EMAIL", false)),
+			IdempotencyKeyTTL:       parseDuration(getEnv("IDEMPOTENCY_KEY_TTL", "24h"), 24*time.Hour),
+			BulkBillMaxItems:        getEnvAsInt("BULK_BILL_MAX_ITEMS", 500),
+			BillExportMaxBills:      getEnvAsInt("BILL_EXPORT_MAX_BILLS", 500),
+
+			DefaultPageSize: getEnvAsInt("DEFAULT_PAGE_SIZE", 10),
+			MaxPageSize:     getEnvAsInt("MAX_PAGE_SIZE", 100),
+
+			MaxBodyBytes:     getEnvAsInt("MAX_REQUEST_BODY_BYTES", 1<<20),
+			AuthMaxBodyBytes: getEnvAsInt("AUTH_MAX_REQUEST_BODY_BYTES", 16<<10),
+			BulkMaxBodyBytes: getEnvAsInt("BULK_MAX_REQUEST_BODY_BYTES", 20<<20),
+
+			SupportedCurrencies: getEnvAsSlice("SUPPORTED_CURRENCIES", []string{"INR"}),
+			DefaultCurrency:     getEnv("DEFAULT_BILL_CURRENCY", "INR"),
 		},
 	}
 
+	// Per-bill-type pricing, e.g. {"medical_bill":{"percentage":0.02,"max_fee":20}}
+	if overrides := getEnv("VERIFICATION_PRICING_OVERRIDES", ""); overrides != "" {
+		if err := json.Unmarshal([]byte(overrides), &cfg.Pricing.BillTypeOverrides); err != nil {
+			return nil, fmt.Errorf("invalid VERIFICATION_PRICING_OVERRIDES: %w", err)
+		}
+	}
+
+	// Limited-access redaction, e.g. {"restricted":{"show":["description"],"mask":["recipient_name"]}}
+	redaction := getEnv("VERIFICATION_REDACTION", `{"restricted":{"mask":["recipient_name"]}}`)
+	if err := json.Unmarshal([]byte(redaction), &cfg.Redaction); err != nil {
+		return nil, fmt.Errorf("invalid VERIFICATION_REDACTION: %w", err)
+	}
+
+	// Only development falls back to the mock gateway; anywhere else it has to be chosen explicitly
+	if cfg.Payment.Gateway == "" && cfg.IsDevelopment() {
+		cfg.Payment.Gateway = "mock"
+	}
+
+	// Retired JWT keys, e.g. "2024-01:old-secret,2023-07:older-secret"
+	if previous := getEnvAsSlice("JWT_PREVIOUS_KEYS", nil); len(previous) > 0 {
+		cfg.JWT.PreviousKeys = make(map[string]string, len(previous))
+		for _, entry := range previous {
+			kid, secret, ok := strings.Cut(entry, ":")
+			if !ok || kid == "" || secret == "" {
+				return nil, fmt.Errorf("invalid JWT_PREVIOUS_KEYS entry %q: expected kid:secret", entry)
+			}
+			cfg.JWT.PreviousKeys[kid] = secret
+		}
+	}
+
+	// Per-role token lifetimes, e.g. "master_admin=5m,institution_admin=1h"
+	var err error
+	if cfg.JWT.RoleAccessTokenExpiry, err = parseRoleDurations("JWT_ROLE_ACCESS_TOKEN_EXPIRY"); err != nil {
+		return nil, err
+	}
+	if cfg.JWT.RoleRefreshTokenExpiry, err = parseRoleDurations("JWT_ROLE_REFRESH_TOKEN_EXPIRY"); err != nil {
+		return nil, err
+	}
+
 	// Validate critical settings
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
+	cfg.location, _ = cfg.loadLocation()
 
 	return cfg, nil
 }
@@ -165,6 +501,199 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("JWT_SECRET must be changed in production")
 	}
 
+	// Check the JWT signing setup
+	switch c.JWT.SigningMethod {
+	case "HS256", "HS384", "HS512":
+	default:
+		return fmt.Errorf("JWT_SIGNING_METHOD must be one of HS256, HS384, HS512")
+	}
+	if _, ok := c.JWT.PreviousKeys[c.JWT.KeyID]; ok && c.JWT.KeyID != "" {
+		return fmt.Errorf("JWT_KEY_ID %q is also listed in JWT_PREVIOUS_KEYS", c.JWT.KeyID)
+	}
+
+	// Check per-bill-type pricing makes sense on its own and against the defaults
+	for billType, override := range c.Pricing.BillTypeOverrides {
+		minFee, maxFee := c.Pricing.VerificationMinFee, c.Pricing.VerificationMaxFee
+		if override.MinFee != nil {
+			minFee = *override.MinFee
+		}
+		if override.MaxFee != nil {
+			maxFee = *override.MaxFee
+		}
+		if minFee < 0 || maxFee < minFee {
+			return fmt.Errorf("pricing override for %s: fees must satisfy 0 <= min_fee <= max_fee", billType)
+		}
+		if override.Percentage != nil && *override.Percentage < 0 {
+			return fmt.Errorf("pricing override for %s: percentage must not be negative", billType)
+		}
+	}
+
+	// Check the bill amount limits
+	if c.Pricing.MaxBillAmount < 0 || c.Pricing.ReviewBillAmount < 0 {
+		return fmt.Errorf("MAX_BILL_AMOUNT and REVIEW_BILL_AMOUNT must not be negative")
+	}
+	if c.Pricing.MaxBillAmount > 0 && c.Pricing.ReviewBillAmount >= c.Pricing.MaxBillAmount {
+		return fmt.Errorf("REVIEW_BILL_AMOUNT must be below MAX_BILL_AMOUNT")
+	}
+
+	// A wildcard origin together with credentials would let any site act as the user
+	if c.Server.Environment == "production" {
+		for _, origin := range c.App.CORSAllowedOrigins {
+			if origin == "*" {
+				return fmt.Errorf("CORS_ALLOWED_ORIGINS must not contain * in production")
+			}
+		}
+	}
+
+	// Check trusted proxies are addresses, so a typo can't silently trust nobody or everybody
+	for _, proxy := range c.App.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("TRUSTED_PROXIES: %q is not an IP address or CIDR", proxy)
+			}
+		}
+	}
+
+	if c.Server.WorkerDrainTimeout <= 0 {
+		return fmt.Errorf("WORKER_DRAIN_TIMEOUT must be positive")
+	}
+
+	// Suspicious verifications are evidence - never prune them sooner than ordinary ones
+	if c.Retention.VerificationRetention < 0 || c.Retention.SuspiciousRetention < 0 {
+		return fmt.Errorf("VERIFICATION_RETENTION and VERIFICATION_SUSPICIOUS_RETENTION must not be negative")
+	}
+	if c.Retention.SuspiciousRetention > 0 &&
+		(c.Retention.VerificationRetention == 0 || c.Retention.SuspiciousRetention < c.Retention.VerificationRetention) {
+		return fmt.Errorf("VERIFICATION_SUSPICIOUS_RETENTION must be at least VERIFICATION_RETENTION")
+	}
+	if c.Retention.Interval <= 0 || c.Retention.BatchSize < 1 {
+		return fmt.Errorf("VERIFICATION_PRUNE_INTERVAL and VERIFICATION_PRUNE_BATCH_SIZE must be positive")
+	}
+
+	// Check the daily summary runs at a real hour
+	if c.Email.DailySummaryHour < 0 || c.Email.DailySummaryHour > 23 {
+		return fmt.Errorf("DAILY_SUMMARY_HOUR must be between 0 and 23")
+	}
+	if c.Email.SMTPTimeout <= 0 {
+		return fmt.Errorf("SMTP_TIMEOUT must be positive")
+	}
+	if c.Email.RetryMaxAttempts < 1 {
+		return fmt.Errorf("SMTP_RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+
+	// Check verifier reputation settings
+	if c.Fraud.VerifierScoreWindow <= 0 {
+		return fmt.Errorf("VERIFIER_SCORE_WINDOW must be positive")
+	}
+	if c.Fraud.VerifierFlagScore < 0 || c.Fraud.VerifierFlagScore > 100 {
+		return fmt.Errorf("VERIFIER_FLAG_SCORE must be between 0 and 100")
+	}
+	if c.Fraud.VerifierFlagMinVolume < 0 {
+		return fmt.Errorf("VERIFIER_FLAG_MIN_VOLUME must not be negative")
+	}
+
+	// Check fake bill alert settings
+	if c.Fraud.FakeBillAlertThreshold < 0 {
+		return fmt.Errorf("FRAUD_FAKE_BILL_ALERT_THRESHOLD must not be negative")
+	}
+	if c.Fraud.FakeBillAlertWindow <= 0 {
+		return fmt.Errorf("FRAUD_FAKE_BILL_ALERT_WINDOW must be positive")
+	}
+
+	// Check QR defaults are within what utils.GenerateQRCodeWithOptions accepts
+	if c.QR.DefaultSize < 128 || c.QR.DefaultSize > 1024 {
+		return fmt.Errorf("QR_DEFAULT_SIZE must be between 128 and 1024")
+	}
+	switch c.QR.RecoveryLevel {
+	case "low", "medium", "high", "highest":
+	default:
+		return fmt.Errorf("QR_RECOVERY_LEVEL must be one of low, medium, high, highest")
+	}
+
+	if c.App.BillExportMaxBills < 1 {
+		return fmt.Errorf("BILL_EXPORT_MAX_BILLS must be at least 1")
+	}
+
+	// Check page size limits
+	if c.App.MaxPageSize < 1 {
+		return fmt.Errorf("MAX_PAGE_SIZE must be at least 1")
+	}
+	if c.App.DefaultPageSize < 1 || c.App.DefaultPageSize > c.App.MaxPageSize {
+		return fmt.Errorf("DEFAULT_PAGE_SIZE must be between 1 and MAX_PAGE_SIZE")
+	}
+
+	// Check bill currencies are ISO 4217 codes and the default is one of them
+	defaultSupported := false
+	for _, code := range c.App.SupportedCurrencies {
+		if !isCurrencyCode(code) {
+			return fmt.Errorf("SUPPORTED_CURRENCIES: %q is not an ISO 4217 currency code", code)
+		}
+		if code == c.App.DefaultCurrency {
+			defaultSupported = true
+		}
+	}
+	if !defaultSupported {
+		return fmt.Errorf("DEFAULT_BILL_CURRENCY %q must be listed in SUPPORTED_CURRENCIES", c.App.DefaultCurrency)
+	}
+
+	if c.App.MaxBodyBytes <= 0 || c.App.AuthMaxBodyBytes <= 0 || c.App.BulkMaxBodyBytes <= 0 {
+		return fmt.Errorf("MAX_REQUEST_BODY_BYTES, AUTH_MAX_REQUEST_BODY_BYTES and BULK_MAX_REQUEST_BODY_BYTES must be positive")
+	}
+
+	// Check self-registration roles; master admins are only ever appointed by another admin
+	for _, role := range c.App.SignupAllowedRoles {
+		switch role {
+		case "public", "institution_user", "institution_admin", "verifier":
+		default:
+			return fmt.Errorf("SIGNUP_ALLOWED_ROLES: %q can't be self-registered", role)
+		}
+	}
+	if c.App.InviteExpiry <= 0 {
+		return fmt.Errorf("INVITE_EXPIRY must be positive")
+	}
+	if c.App.BillTransferExpiry <= 0 {
+		return fmt.Errorf("BILL_TRANSFER_EXPIRY must be positive")
+	}
+	if c.App.DuplicateBillWindow < 0 {
+		return fmt.Errorf("DUPLICATE_BILL_WINDOW must not be negative")
+	}
+
+	if _, err := c.loadLocation(); err != nil {
+		return err
+	}
+
+	// Check the anonymous verification allowance
+	if c.App.AnonymousDailyFreeVerifications < 0 {
+		return fmt.Errorf("ANONYMOUS_DAILY_FREE_VERIFICATIONS must not be negative")
+	}
+	if _, err := time.LoadLocation(c.App.VerificationQuotaTimezone); err != nil {
+		return fmt.Errorf("VERIFICATION_QUOTA_TIMEZONE: %w", err)
+	}
+
+	if c.Database.StatementTimeout < 0 || c.Database.SlowQueryThreshold < 0 {
+		return fmt.Errorf("DB_STATEMENT_TIMEOUT and DB_SLOW_QUERY_THRESHOLD must not be negative")
+	}
+	if _, err := c.TwoFactorKey(); err != nil {
+		return err
+	}
+
+	// The noop client marks bills confirmed without anchoring them anywhere
+	if c.Blockchain.Enabled && c.Blockchain.Client == "noop" && c.IsProduction() {
+		return fmt.Errorf("BLOCKCHAIN_CLIENT=noop is not allowed in production; leave BLOCKCHAIN_WORKER_ENABLED off until a real ledger is configured")
+	}
+
+	// The mock gateway confirms payments nobody made, so it must never take real top-ups
+	if c.Payment.Gateway == "" {
+		return fmt.Errorf("PAYMENT_GATEWAY must be set")
+	}
+	if c.Payment.Gateway == "mock" && c.IsProduction() {
+		return fmt.Errorf("PAYMENT_GATEWAY=mock is not allowed in production")
+	}
+
+	if c.Database.AutoMigrate && c.IsProduction() {
+		return fmt.Errorf("DB_AUTO_MIGRATE is for development; run the API with -migrate to apply migrations in production")
+	}
+
 	// Check if database credentials are set
 	if c.Database.User == "" || c.Database.Password == "" {
 		return fmt.Errorf("database credentials not set")
@@ -173,6 +702,19 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// isCurrencyCode reports whether code looks like an ISO 4217 code: three uppercase letters
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
 // GetDatabaseDSN returns PostgreSQL connection string
 // DSN = Data Source Name (connection string format)
 func (c *Config) GetDatabaseDSN() string {
@@ -187,6 +729,79 @@ func (c *Config) GetDatabaseDSN() string {
 	)
 }
 
+// TwoFactorKey returns the key TOTP secrets are encrypted with
+// Changing it makes existing 2FA enrollments unreadable, so those users must use a recovery code
+func (c *Config) TwoFactorKey() ([]byte, error) {
+	if c.JWT.TwoFactorEncryptionKey == "" {
+		if c.IsProduction() {
+			return nil, fmt.Errorf("TWO_FACTOR_ENCRYPTION_KEY must be set in production")
+		}
+		key := sha256.Sum256([]byte("two-factor:" + c.JWT.Secret))
+		return key[:], nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(c.JWT.TwoFactorEncryptionKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("TWO_FACTOR_ENCRYPTION_KEY must be a base64-encoded 32-byte key")
+	}
+	return key, nil
+}
+
+// Location returns the APP_TIMEZONE location day and month boundaries are computed in
+// Validate has already checked the zone loads; a Config built without Load falls back to UTC if it doesn't
+func (c *Config) Location() *time.Location {
+	if c.location != nil {
+		return c.location
+	}
+	loc, err := c.loadLocation()
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// loadLocation loads APP_TIMEZONE
+// "Local" is refused: the database is told the zone by name, and the server's zone has none
+func (c *Config) loadLocation() (*time.Location, error) {
+	if c.App.Timezone == "Local" {
+		return nil, fmt.Errorf("APP_TIMEZONE must be an IANA name such as Asia/Kolkata, not Local")
+	}
+	loc, err := time.LoadLocation(c.App.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("APP_TIMEZONE: %w", err)
+	}
+	return loc, nil
+}
+
+// tokenRoles are the user roles token expiry can be overridden for (models.UserRole values)
+var tokenRoles = []string{"public", "institution_user", "institution_admin", "verifier", "master_admin"}
+
+// AccessTokenExpiryFor returns the access token lifetime for a role, falling back to AccessTokenExpiry
+func (j JWTConfig) AccessTokenExpiryFor(role string) time.Duration {
+	if expiry, ok := j.RoleAccessTokenExpiry[role]; ok {
+		return expiry
+	}
+	return j.AccessTokenExpiry
+}
+
+// RefreshTokenExpiryFor returns the refresh token lifetime for a role, falling back to RefreshTokenExpiry
+func (j JWTConfig) RefreshTokenExpiryFor(role string) time.Duration {
+	if expiry, ok := j.RoleRefreshTokenExpiry[role]; ok {
+		return expiry
+	}
+	return j.RefreshTokenExpiry
+}
+
+// MaxRefreshTokenExpiry is the longest any refresh token can live, across all roles
+// Revoking every token of a user has to be remembered at least this long
+func (j JWTConfig) MaxRefreshTokenExpiry() time.Duration {
+	longest := j.RefreshTokenExpiry
+	for _, expiry := range j.RoleRefreshTokenExpiry {
+		longest = max(longest, expiry)
+	}
+	return longest
+}
+
 // GetRedisAddr returns Redis connection address
 func (c *Config) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%s", c.Redis.Host, c.Redis.Port)
@@ -242,6 +857,67 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 	return value
 }
 
+// getEnvAsBool reads an environment variable as bool or returns default
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsSlice reads a comma-separated environment variable or returns default
+// Empty entries and surrounding whitespace are dropped
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+
+	return values
+}
+
+// parseRoleDurations reads "role=duration" pairs, e.g. "master_admin=5m,verifier=30m"
+// Returns nil when the variable is unset
+func parseRoleDurations(key string) (map[string]time.Duration, error) {
+	entries := getEnvAsSlice(key, nil)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	durations := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		role, value, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || !slices.Contains(tokenRoles, role) {
+			return nil, fmt.Errorf("invalid %s entry %q: expected role=duration with a role from %v", key, entry, tokenRoles)
+		}
+		duration := parseDuration(strings.TrimSpace(value), 0)
+		if duration <= 0 {
+			return nil, fmt.Errorf("invalid %s entry %q: duration must be positive", key, entry)
+		}
+		durations[role] = duration
+	}
+
+	return durations, nil
+}
+
 // parseDuration parses duration string (e.g., "15m", "7d") or returns default
 func parseDuration(durationStr string, defaultDuration time.Duration) time.Duration {
 	// Handle special case for days (Go doesn't support "d" suffix)
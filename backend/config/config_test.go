@@ -0,0 +1,103 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRoleDurations(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{"unset", "", nil, false},
+		{"several roles", "master_admin=5m, institution_admin=2h", map[string]time.Duration{"master_admin": 5 * time.Minute, "institution_admin": 2 * time.Hour}, false},
+		{"days", "public=7d", map[string]time.Duration{"public": 7 * 24 * time.Hour}, false},
+		{"unknown role", "superuser=5m", nil, true},
+		{"missing duration", "verifier", nil, true},
+		{"zero duration", "verifier=0s", nil, true},
+		{"invalid duration", "verifier=soon", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_ROLE_EXPIRY", tt.value)
+
+			got, err := parseRoleDurations("TEST_ROLE_EXPIRY")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRoleDurations error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRoleDurations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenExpiryForRole(t *testing.T) {
+	jwt := JWTConfig{
+		AccessTokenExpiry:      time.Hour,
+		RefreshTokenExpiry:     7 * 24 * time.Hour,
+		RoleAccessTokenExpiry:  map[string]time.Duration{"master_admin": 5 * time.Minute, "institution_admin": 4 * time.Hour},
+		RoleRefreshTokenExpiry: map[string]time.Duration{"master_admin": 12 * time.Hour, "institution_admin": 30 * 24 * time.Hour},
+	}
+
+	tests := []struct {
+		role        string
+		wantAccess  time.Duration
+		wantRefresh time.Duration
+	}{
+		{"master_admin", 5 * time.Minute, 12 * time.Hour},
+		{"institution_admin", 4 * time.Hour, 30 * 24 * time.Hour},
+		{"verifier", time.Hour, 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := jwt.AccessTokenExpiryFor(tt.role); got != tt.wantAccess {
+			t.Errorf("AccessTokenExpiryFor(%s) = %v, want %v", tt.role, got, tt.wantAccess)
+		}
+		if got := jwt.RefreshTokenExpiryFor(tt.role); got != tt.wantRefresh {
+			t.Errorf("RefreshTokenExpiryFor(%s) = %v, want %v", tt.role, got, tt.wantRefresh)
+		}
+	}
+
+	if got := jwt.MaxRefreshTokenExpiry(); got != 30*24*time.Hour {
+		t.Errorf("MaxRefreshTokenExpiry = %v, want the institution admin's 30 days", got)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		timezone string
+		wantErr  bool
+	}{
+		{"Asia/Kolkata", false},
+		{"UTC", false},
+		{"Local", true},
+		{"Mars/Olympus_Mons", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			cfg := &Config{App: AppConfig{Timezone: tt.timezone}}
+
+			loc, err := cfg.loadLocation()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadLocation() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				// A Config that skipped Validate still gets a usable zone
+				if got := cfg.Location(); got != time.UTC {
+					t.Errorf("Location() = %s, want the UTC fallback", got)
+				}
+				return
+			}
+			if loc.String() != tt.timezone || cfg.Location().String() != tt.timezone {
+				t.Errorf("location = %s, want %s", loc, tt.timezone)
+			}
+		})
+	}
+}
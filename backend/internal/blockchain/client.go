@@ -0,0 +1,40 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+// Client commits bill hashes to a blockchain ledger
+// The real implementation will talk to Hyperledger Fabric; until then the
+// worker runs against NoopClient so bills still move out of "pending".
+type Client interface {
+	// CommitHash records a bill's data hash on-chain and returns the transaction ID
+	CommitHash(ctx context.Context, billID, dataHash string) (string, error)
+}
+
+// NoopClient pretends to commit hashes without talking to any ledger
+// Transaction IDs are derived from the bill so repeated commits are stable
+type NoopClient struct{}
+
+// NewNoopClient creates a client that doesn't touch a real blockchain
+func NewNoopClient() *NoopClient {
+	return &NoopClient{}
+}
+
+// CommitHash returns a deterministic fake transaction ID
+func (c *NoopClient) CommitHash(ctx context.Context, billID, dataHash string) (string, error) {
+	if dataHash == "" {
+		return "", fmt.Errorf("empty data hash for bill %s", billID)
+	}
+
+	sum := sha256.Sum256([]byte(billID + ":" + dataHash))
+	txID := "noop-" + hex.EncodeToString(sum[:16])
+
+	log.Printf("⛓️  [noop] Committed bill %s with tx %s", billID, txID)
+
+	return txID, nil
+}
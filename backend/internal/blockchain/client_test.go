@@ -0,0 +1,34 @@
+package blockchain
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNoopClientCommitHash(t *testing.T) {
+	client := NewNoopClient()
+	ctx := context.Background()
+
+	first, err := client.CommitHash(ctx, "bill-1", "hash-1")
+	if err != nil {
+		t.Fatalf("CommitHash failed: %v", err)
+	}
+	if !strings.HasPrefix(first, "noop-") {
+		t.Errorf("tx ID = %q, want a noop- prefix", first)
+	}
+
+	again, _ := client.CommitHash(ctx, "bill-1", "hash-1")
+	if again != first {
+		t.Errorf("repeated commit gave tx %q, want %q", again, first)
+	}
+
+	other, _ := client.CommitHash(ctx, "bill-2", "hash-1")
+	if other == first {
+		t.Error("different bills got the same tx ID")
+	}
+
+	if _, err := client.CommitHash(ctx, "bill-1", ""); err == nil {
+		t.Error("CommitHash accepted an empty hash")
+	}
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationLockID keeps two instances starting at once from running the same migration twice
+const migrationLockID = 727_001
+
+// Migration is a numbered SQL file, e.g. 017_create_outbox_table.sql
+type Migration struct {
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string // SHA-256 of the file, recorded when it is applied
+}
+
+// ErrUnmanagedSchema means the database has tables but no schema_migrations, so the runner can't tell what was applied
+var ErrUnmanagedSchema = errors.New("database has tables but no schema_migrations table; apply migrations up to 016 by hand first")
+
+// MigrationChangedError reports migrations whose files were edited after they were applied
+// Write a new migration instead of changing one that already ran
+type MigrationChangedError struct {
+	Names []string
+}
+
+func (e *MigrationChangedError) Error() string {
+	return fmt.Sprintf("applied migrations were modified afterwards: %s", strings.Join(e.Names, ", "))
+}
+
+// LoadMigrations reads the numbered .sql files in fsys, ordered by version
+// Files without a leading version number (old one-off scripts) are skipped
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	seen := make(map[int]string)
+	var migrations []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".sql" {
+			continue
+		}
+
+		prefix, _, found := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version <= 0 {
+			continue
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version %d", other, name, version)
+		}
+		seen[version] = name
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sum := sha256.Sum256(content)
+
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     name,
+			SQL:      string(content),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrate applies every migration in fsys that isn't in schema_migrations yet, oldest first
+// Each migration runs in its own transaction and is recorded with its checksum. Already applied
+// migrations are checked against their recorded checksum and a MigrationChangedError is returned,
+// before anything runs, if one was edited. Versions recorded before checksums existed (e.g. by the
+// docker-compose init scripts) adopt the current file's checksum. Returns the versions it applied.
+func (db *DB) Migrate(ctx context.Context, fsys fs.FS) ([]int, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	// A dedicated connection holds the advisory lock for the whole run
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return nil, fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if err := prepareMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	recorded, err := recordedChecksums(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, m := range migrations {
+		checksum, ok := recorded[m.Version]
+		if ok && checksum.Valid && checksum.String != m.Checksum {
+			changed = append(changed, m.Name)
+		}
+	}
+	if len(changed) > 0 {
+		return nil, &MigrationChangedError{Names: changed}
+	}
+
+	var applied []int
+	for _, m := range migrations {
+		checksum, ok := recorded[m.Version]
+		if ok {
+			if !checksum.Valid {
+				if err := recordMigration(ctx, conn, m); err != nil {
+					return applied, err
+				}
+			}
+			continue
+		}
+
+		if err := applyMigration(ctx, conn, m); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m.Version)
+		log.Printf("🗄️  Applied migration %s", m.Name)
+	}
+
+	return applied, nil
+}
+
+// prepareMigrationsTable creates schema_migrations on an empty database and adds the checksum column
+// Migration 016 creates the same table, so it is safe to run afterwards
+func prepareMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	var tracked, hasTables bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&tracked); err != nil {
+		return fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+	if !tracked {
+		if err := conn.QueryRowContext(ctx, "SELECT to_regclass('users') IS NOT NULL").Scan(&hasTables); err != nil {
+			return fmt.Errorf("failed to check for existing tables: %w", err)
+		}
+		if hasTables {
+			return ErrUnmanagedSchema
+		}
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT NOW()
+		);
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);
+	`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to prepare schema_migrations: %w", err)
+	}
+
+	return nil
+}
+
+// recordedChecksums returns every applied version; the checksum is NULL for versions recorded by the SQL files alone
+func recordedChecksums(ctx context.Context, conn *sql.Conn) (map[int]sql.NullString, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	recorded := make(map[int]sql.NullString)
+	for rows.Next() {
+		var version int
+		var checksum sql.NullString
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		recorded[version] = checksum
+	}
+
+	return recorded, rows.Err()
+}
+
+// applyMigration runs one migration and records it in the same transaction
+func applyMigration(ctx context.Context, conn *sql.Conn, m Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %s: %w", m.Name, err)
+	}
+	defer tx.Rollback()
+
+	// No bind parameters, so lib/pq sends the file as one multi-statement query
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return fmt.Errorf("migration %s failed: %w", m.Name, err)
+	}
+
+	// Most migrations insert their own version too; this adds the checksum
+	query := `
+		INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)
+		ON CONFLICT (version) DO UPDATE SET checksum = EXCLUDED.checksum
+	`
+	if _, err := tx.ExecContext(ctx, query, m.Version, m.Checksum); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", m.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", m.Name, err)
+	}
+
+	return nil
+}
+
+// recordMigration stores the checksum of a migration that was applied without one
+func recordMigration(ctx context.Context, conn *sql.Conn, m Migration) error {
+	_, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET checksum = $2 WHERE version = $1 AND checksum IS NULL", m.Version, m.Checksum)
+	if err != nil {
+		return fmt.Errorf("failed to record checksum of %s: %w", m.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestLoadMigrationsOrdersNumberedFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_add_index.sql":      {Data: []byte("CREATE INDEX ...")},
+		"002_create_widgets.sql": {Data: []byte("CREATE TABLE ...")},
+		"seed_dev_data.sql":      {Data: []byte("INSERT ...")},
+		"README.md":              {Data: []byte("docs")},
+		"000_placeholder.sql":    {Data: []byte("SELECT 1")},
+	}
+
+	migrations, err := database.LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+	var names []string
+	for _, m := range migrations {
+		names = append(names, m.Name)
+		if len(m.Checksum) != 64 {
+			t.Errorf("checksum of %s = %q, want a SHA-256 hex digest", m.Name, m.Checksum)
+		}
+	}
+	if want := []string{"002_create_widgets.sql", "010_add_index.sql"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("migrations = %v, want %v", names, want)
+	}
+
+	fsys["002_other.sql"] = &fstest.MapFile{Data: []byte("SELECT 1")}
+	if _, err := database.LoadMigrations(fsys); err == nil {
+		t.Error("LoadMigrations accepted two migrations with version 2")
+	}
+}
+
+// emptySchemaDB returns a connection whose search_path is a new, empty schema on the TEST_DATABASE_URL database
+// The shared test schema already has schema_migrations, so the runner needs a clean slate of its own
+func emptySchemaDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	raw := os.Getenv("TEST_DATABASE_URL")
+	if raw == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	admin, err := sqlx.Connect("postgres", raw)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	admin.MustExec("CREATE SCHEMA " + schema)
+	t.Cleanup(func() { admin.MustExec("DROP SCHEMA " + schema + " CASCADE") })
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	db, err := sqlx.Connect("postgres", u.String())
+	if err != nil {
+		t.Fatalf("failed to connect to schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &database.DB{DB: db}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := emptySchemaDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"001_create_widgets.sql": {Data: []byte("CREATE TABLE widgets (id SERIAL PRIMARY KEY, name TEXT);")},
+		"002_seed_widgets.sql":   {Data: []byte("INSERT INTO widgets (name) VALUES ('first');")},
+	}
+
+	applied, err := db.Migrate(ctx, fsys)
+	if err != nil {
+		t.Fatalf("first Migrate failed: %v", err)
+	}
+	if !reflect.DeepEqual(applied, []int{1, 2}) {
+		t.Errorf("first run applied %v, want [1 2]", applied)
+	}
+
+	applied, err = db.Migrate(ctx, fsys)
+	if err != nil {
+		t.Fatalf("second Migrate failed: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("second run applied %v, want nothing", applied)
+	}
+
+	var widgets int
+	if err := db.Get(&widgets, "SELECT COUNT(*) FROM widgets"); err != nil || widgets != 1 {
+		t.Errorf("widgets = %d, %v; want the seed inserted once", widgets, err)
+	}
+
+	// A new file is picked up on the next run
+	fsys["003_seed_more.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO widgets (name) VALUES ('second');")}
+	if applied, err := db.Migrate(ctx, fsys); err != nil || !reflect.DeepEqual(applied, []int{3}) {
+		t.Errorf("third run applied %v, %v; want [3]", applied, err)
+	}
+}
+
+func TestMigrateDetectsModifiedMigration(t *testing.T) {
+	db := emptySchemaDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"001_create_widgets.sql": {Data: []byte("CREATE TABLE widgets (id SERIAL PRIMARY KEY, name TEXT);")},
+	}
+	if _, err := db.Migrate(ctx, fsys); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	fsys["001_create_widgets.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE widgets (id SERIAL PRIMARY KEY, name TEXT NOT NULL);")}
+	fsys["002_seed_widgets.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO widgets (name) VALUES ('first');")}
+
+	_, err := db.Migrate(ctx, fsys)
+	var changed *database.MigrationChangedError
+	if !errors.As(err, &changed) {
+		t.Fatalf("Migrate error = %v, want a MigrationChangedError", err)
+	}
+	if !reflect.DeepEqual(changed.Names, []string{"001_create_widgets.sql"}) {
+		t.Errorf("changed = %v, want [001_create_widgets.sql]", changed.Names)
+	}
+
+	// Nothing runs once an edit is detected
+	var version2 int
+	if err := db.Get(&version2, "SELECT COUNT(*) FROM schema_migrations WHERE version = 2"); err != nil || version2 != 0 {
+		t.Errorf("migration 2 recorded %d times, %v; want it left pending", version2, err)
+	}
+}
+
+func TestMigrateAdoptsChecksumsOfMigrationsAppliedByHand(t *testing.T) {
+	db := emptySchemaDB(t)
+	ctx := context.Background()
+
+	// As the docker-compose init scripts leave it: the table and version, but no checksum column
+	db.MustExec("CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TIMESTAMP DEFAULT NOW())")
+	db.MustExec("CREATE TABLE widgets (id SERIAL PRIMARY KEY)")
+	db.MustExec("INSERT INTO schema_migrations (version) VALUES (1)")
+
+	fsys := fstest.MapFS{
+		"001_create_widgets.sql": {Data: []byte("CREATE TABLE widgets (id SERIAL PRIMARY KEY);")},
+	}
+	if applied, err := db.Migrate(ctx, fsys); err != nil || len(applied) != 0 {
+		t.Fatalf("Migrate = %v, %v; want nothing applied", applied, err)
+	}
+
+	// From now on the file is held to that checksum
+	fsys["001_create_widgets.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE widgets (id BIGSERIAL PRIMARY KEY);")}
+	var changed *database.MigrationChangedError
+	if _, err := db.Migrate(ctx, fsys); !errors.As(err, &changed) {
+		t.Errorf("Migrate error = %v, want a MigrationChangedError", err)
+	}
+}
@@ -2,12 +2,14 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // PostgreSQL driver (imported for side effects)
+	"github.com/lib/pq" // PostgreSQL driver
 )
 
 // DB wraps the database connection
@@ -18,15 +20,16 @@ type DB struct {
 
 // Config holds database connection configuration
 type Config struct {
-	Host            string
-	Port            string
-	User            string
-	Password        string
-	DBName          string
-	SSLMode         string
-	MaxConnections  int
-	MaxIdleConns    int
-	ConnMaxLifetime time.Duration
+	Host             string
+	Port             string
+	User             string
+	Password         string
+	DBName           string
+	SSLMode          string
+	MaxConnections   int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	StatementTimeout time.Duration // Per-statement limit set on every new connection; 0 leaves the server default
 }
 
 // NewPostgresDB creates a new PostgreSQL connection
@@ -44,11 +47,15 @@ func NewPostgresDB(cfg Config) (*DB, error) {
 	)
 
 	// Open database connection
-	// "postgres" is the driver name
-	db, err := sqlx.Open("postgres", dsn)
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	// "postgres" is the driver name, which tells sqlx which bind variables to use
+	db := sqlx.NewDb(sql.OpenDB(&sessionConnector{
+		Connector:        connector,
+		statementTimeout: cfg.StatementTimeout,
+	}), "postgres")
 
 	// Configure connection pool
 	// Connection pool reuses database connections for efficiency
@@ -69,6 +76,47 @@ func NewPostgresDB(cfg Config) (*DB, error) {
 	return &DB{DB: db}, nil
 }
 
+// sessionConnector sets per-session settings on each new connection before the pool hands it out
+// Timestamps are TIMESTAMPTZ; the session time zone is UTC so values and date functions that don't take
+// an explicit zone behave the same whatever the server's zone is.
+// With a statement timeout, a runaway query fails with "canceling statement due to statement timeout"
+// instead of holding its connection for minutes
+type sessionConnector struct {
+	driver.Connector
+	statementTimeout time.Duration
+}
+
+// Connect opens a connection and applies the session settings
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("driver connection can't execute statements")
+	}
+
+	if _, err := execer.ExecContext(ctx, "SET TIME ZONE 'UTC'", nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set time zone: %w", err)
+	}
+	if c.statementTimeout <= 0 {
+		return conn, nil
+	}
+
+	// SET doesn't accept bind parameters; the value is an integer we formatted ourselves
+	setTimeout := fmt.Sprintf("SET statement_timeout = %d", c.statementTimeout.Milliseconds())
+	if _, err := execer.ExecContext(ctx, setTimeout, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set statement_timeout: %w", err)
+	}
+
+	return conn, nil
+}
+
 // Close closes the database connection
 // Always call this when your application shuts down
 func (db *DB) Close() error {
@@ -107,6 +155,79 @@ func (db *DB) HealthCheck() error {
 	return nil
 }
 
+// SchemaVersion is the latest numbered migration this build expects
+const SchemaVersion = 38
+
+// requiredTables are the tables the API queries directly
+var requiredTables = []string{
+	"users",
+	"bills",
+	"verifications",
+	"wallet_transactions",
+	"payment_orders",
+	"bill_revisions",
+	"idempotency_keys",
+	"webhook_deliveries",
+	"outbox",
+	"login_history",
+	"schema_migrations",
+}
+
+// requiredFunctions are database functions the API calls
+var requiredFunctions = []string{
+	"generate_bill_number",
+}
+
+// SchemaStatus describes how far the database schema matches what the API expects
+type SchemaStatus struct {
+	Version          int      `json:"version"`
+	ExpectedVersion  int      `json:"expected_version"`
+	MissingTables    []string `json:"missing_tables,omitempty"`
+	MissingFunctions []string `json:"missing_functions,omitempty"`
+}
+
+// SchemaCheck verifies the required tables and functions exist and reads the applied migration version
+// A partially migrated database connects fine but fails on the first real request, so /health reports this
+func (db *DB) SchemaCheck() (*SchemaStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	status := &SchemaStatus{ExpectedVersion: SchemaVersion}
+
+	for _, table := range requiredTables {
+		var exists bool
+		if err := db.GetContext(ctx, &exists, "SELECT to_regclass($1) IS NOT NULL", table); err != nil {
+			return status, fmt.Errorf("failed to check table %s: %w", table, err)
+		}
+		if !exists {
+			status.MissingTables = append(status.MissingTables, table)
+		}
+	}
+
+	for _, function := range requiredFunctions {
+		var exists bool
+		if err := db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pg_proc WHERE proname = $1)", function); err != nil {
+			return status, fmt.Errorf("failed to check function %s: %w", function, err)
+		}
+		if !exists {
+			status.MissingFunctions = append(status.MissingFunctions, function)
+		}
+	}
+
+	if len(status.MissingTables) > 0 || len(status.MissingFunctions) > 0 {
+		return status, fmt.Errorf("schema incomplete: missing tables %v, missing functions %v", status.MissingTables, status.MissingFunctions)
+	}
+
+	if err := db.GetContext(ctx, &status.Version, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations"); err != nil {
+		return status, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if status.Version < SchemaVersion {
+		return status, fmt.Errorf("schema version %d is behind expected version %d", status.Version, SchemaVersion)
+	}
+
+	return status, nil
+}
+
 // Stats returns database connection pool statistics
 // Useful for monitoring and debugging
 func (db *DB) Stats() map[string]interface{} {
@@ -0,0 +1,127 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/lib/pq"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	db := testutil.DB(t)
+
+	status, err := db.SchemaCheck()
+	if err != nil {
+		t.Fatalf("SchemaCheck on a migrated database failed: %v", err)
+	}
+	if status.Version != database.SchemaVersion {
+		t.Errorf("version = %d, want %d", status.Version, database.SchemaVersion)
+	}
+	if len(status.MissingTables) > 0 || len(status.MissingFunctions) > 0 {
+		t.Errorf("missing tables %v and functions %v on a migrated database", status.MissingTables, status.MissingFunctions)
+	}
+}
+
+func TestSchemaCheckReportsMissingFunction(t *testing.T) {
+	db := testutil.DB(t)
+	testutil.HideFunction(t, db, "generate_bill_number(bill_type, text)")
+
+	status, err := db.SchemaCheck()
+	if err == nil {
+		t.Fatal("SchemaCheck passed without generate_bill_number")
+	}
+	if len(status.MissingFunctions) != 1 || status.MissingFunctions[0] != "generate_bill_number" {
+		t.Errorf("missing functions = %v, want [generate_bill_number]", status.MissingFunctions)
+	}
+	if len(status.MissingTables) > 0 {
+		t.Errorf("missing tables = %v, want none", status.MissingTables)
+	}
+}
+
+// connectWithStatementTimeout opens a pool the way main does, on the TEST_DATABASE_URL database
+func connectWithStatementTimeout(t *testing.T, timeout time.Duration) *database.DB {
+	t.Helper()
+
+	raw := os.Getenv("TEST_DATABASE_URL")
+	if raw == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_URL: %v", err)
+	}
+	password, _ := u.User.Password()
+	port := u.Port()
+	if port == "" {
+		port = "5432"
+	}
+	sslMode := u.Query().Get("sslmode")
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	db, err := database.NewPostgresDB(database.Config{
+		Host:             u.Hostname(),
+		Port:             port,
+		User:             u.User.Username(),
+		Password:         password,
+		DBName:           strings.TrimPrefix(u.Path, "/"),
+		SSLMode:          sslMode,
+		MaxConnections:   2,
+		MaxIdleConns:     1,
+		ConnMaxLifetime:  time.Minute,
+		StatementTimeout: timeout,
+	})
+	if err != nil {
+		t.Fatalf("NewPostgresDB failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestStatementTimeoutCancelsRunawayQuery(t *testing.T) {
+	db := connectWithStatementTimeout(t, 200*time.Millisecond)
+
+	start := time.Now()
+	_, err := db.ExecContext(context.Background(), "SELECT pg_sleep(10)")
+	elapsed := time.Since(start)
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code.Name() != "query_canceled" {
+		t.Fatalf("error = %v, want the statement canceled by statement_timeout", err)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("query ran for %v, want it canceled after about 200ms", elapsed)
+	}
+
+	// The connection stays usable for the next statement
+	var one int
+	if err := db.Get(&one, "SELECT 1"); err != nil || one != 1 {
+		t.Errorf("SELECT 1 after a timeout = %d, %v", one, err)
+	}
+}
+
+func TestSessionSettings(t *testing.T) {
+	db := connectWithStatementTimeout(t, 1500*time.Millisecond)
+
+	var timeout, zone string
+	if err := db.Get(&timeout, "SHOW statement_timeout"); err != nil {
+		t.Fatalf("SHOW statement_timeout failed: %v", err)
+	}
+	if err := db.Get(&zone, "SHOW TIME ZONE"); err != nil {
+		t.Fatalf("SHOW TIME ZONE failed: %v", err)
+	}
+	if timeout != "1500ms" {
+		t.Errorf("statement_timeout = %q, want 1500ms", timeout)
+	}
+	if zone != "UTC" {
+		t.Errorf("time zone = %q, want UTC", zone)
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -14,6 +15,9 @@ type RedisClient struct {
 	*redis.Client
 }
 
+// ErrRedisUnavailable is returned when the API is running without Redis
+var ErrRedisUnavailable = errors.New("Redis unavailable")
+
 // RedisConfig holds Redis connection configuration
 type RedisConfig struct {
 	Host     string
@@ -52,9 +56,16 @@ func NewRedisClient(cfg RedisConfig) (*RedisClient, error) {
 	return &RedisClient{Client: client}, nil
 }
 
+// Available reports whether Redis is connected
+// A nil *RedisClient means the API started without Redis and runs degraded:
+// caching, rate limiting and token revocation are skipped
+func (r *RedisClient) Available() bool {
+	return r != nil && r.Client != nil
+}
+
 // Close closes the Redis connection
 func (r *RedisClient) Close() error {
-	if r.Client != nil {
+	if r.Available() {
 		log.Println("🔌 Closing Redis connection...")
 		return r.Client.Close()
 	}
@@ -63,6 +74,10 @@ func (r *RedisClient) Close() error {
 
 // HealthCheck verifies Redis is healthy
 func (r *RedisClient) HealthCheck() error {
+	if !r.Available() {
+		return ErrRedisUnavailable
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
@@ -75,6 +90,10 @@ func (r *RedisClient) HealthCheck() error {
 
 // GetStats returns Redis connection statistics
 func (r *RedisClient) GetStats() map[string]interface{} {
+	if !r.Available() {
+		return nil
+	}
+
 	stats := r.PoolStats()
 	
 	return map[string]interface{}{
@@ -0,0 +1,92 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/jmoiron/sqlx"
+)
+
+// txTestTable creates a scratch table for the test and returns a function counting its rows
+func txTestTable(t *testing.T, db *database.DB) func() int {
+	t.Helper()
+
+	db.MustExec(`CREATE TABLE tx_test_items (name TEXT PRIMARY KEY)`)
+	t.Cleanup(func() { db.MustExec(`DROP TABLE IF EXISTS tx_test_items`) })
+
+	return func() int {
+		return testutil.Count(t, db, "tx_test_items", "")
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	db := testutil.DB(t)
+	count := txTestTable(t, db)
+	ctx := context.Background()
+
+	insert := func(tx *sqlx.Tx, name string) {
+		t.Helper()
+		if _, err := tx.ExecContext(ctx, `INSERT INTO tx_test_items (name) VALUES ($1)`, name); err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+	}
+
+	t.Run("commits on success", func(t *testing.T) {
+		err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+			insert(tx, "committed")
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithTx failed: %v", err)
+		}
+		if n := count(); n != 1 {
+			t.Errorf("rows = %d, want 1", n)
+		}
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		errBusiness := errors.New("insufficient balance")
+		err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+			insert(tx, "rolled back")
+			return errBusiness
+		})
+		// The error comes back unchanged so callers can match sentinels
+		if !errors.Is(err, errBusiness) {
+			t.Fatalf("WithTx error = %v, want %v", err, errBusiness)
+		}
+		if n := count(); n != 1 {
+			t.Errorf("rows = %d, want only the committed one", n)
+		}
+	})
+
+	t.Run("rolls back and re-panics on panic", func(t *testing.T) {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the original panic", p)
+			}
+			if n := count(); n != 1 {
+				t.Errorf("rows = %d, want only the committed one", n)
+			}
+		}()
+
+		db.WithTx(ctx, func(tx *sqlx.Tx) error {
+			insert(tx, "panicked")
+			panic("boom")
+		})
+		t.Error("WithTx returned instead of re-raising the panic")
+	})
+
+	t.Run("failed commit is reported", func(t *testing.T) {
+		err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+			// A duplicate key aborts the transaction; ignoring the error leaves COMMIT to fail
+			tx.ExecContext(ctx, `INSERT INTO tx_test_items (name) VALUES ('committed')`)
+			return nil
+		})
+		if err == nil {
+			t.Error("WithTx succeeded although the transaction was aborted")
+		}
+	})
+}
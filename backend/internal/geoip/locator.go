@@ -0,0 +1,45 @@
+package geoip
+
+import (
+	"context"
+	"strings"
+)
+
+// Location is a coarse, city-level position for an IP address
+type Location struct {
+	City    string
+	Region  string
+	Country string
+}
+
+// String formats the location for display, skipping unknown parts
+func (l *Location) String() string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{l.City, l.Region, l.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Locator looks up where an IP address is
+// Login notifications use it to tell users roughly where a sign-in came from
+type Locator interface {
+	// Locate returns the location of ip, or nil if it isn't known
+	Locate(ctx context.Context, ip string) (*Location, error)
+}
+
+// NoopLocator doesn't know any locations
+// Used until a GeoIP database or service is configured
+type NoopLocator struct{}
+
+// NewNoopLocator creates a locator that never finds a location
+func NewNoopLocator() *NoopLocator {
+	return &NoopLocator{}
+}
+
+// Locate always reports the location as unknown
+func (l *NoopLocator) Locate(ctx context.Context, ip string) (*Location, error) {
+	return nil, nil
+}
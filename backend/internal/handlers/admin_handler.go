@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles master admin requests
+type AdminHandler struct {
+	billService   *services.BillService
+	adminService  *services.AdminService
+	inviteService *services.InviteService
+	loc           *time.Location // APP_TIMEZONE, which date filters are read in
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(billService *services.BillService, adminService *services.AdminService, inviteService *services.InviteService, loc *time.Location) *AdminHandler {
+	return &AdminHandler{
+		billService:   billService,
+		adminService:  adminService,
+		inviteService: inviteService,
+		loc:           loc,
+	}
+}
+
+// GetStats returns platform-wide metrics
+// GET /api/v1/admin/stats
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	stats, err := h.adminService.GetPlatformStats(ctx)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve platform statistics")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, stats)
+}
+
+// SearchBills searches bills across all issuers
+// GET /api/v1/admin/bills?issuer_id=&bill_type=&blockchain_status=&start_date=&end_date=&needs_review=&include_deleted=&page=&page_size=
+func (h *AdminHandler) SearchBills(c *gin.Context) {
+	page, pageSize, _ := utils.ParsePaginationWithDefault(c, 20)
+
+	var filter models.AdminBillFilter
+
+	if issuerID := c.Query("issuer_id"); issuerID != "" {
+		filter.IssuerID = &issuerID
+	}
+
+	if billTypeStr := c.Query("bill_type"); billTypeStr != "" {
+		billType := models.BillType(billTypeStr)
+		if !billType.IsValid() {
+			utils.ValidationErrorResponse(c, "Invalid bill_type")
+			return
+		}
+		filter.BillType = &billType
+	}
+
+	if statusStr := c.Query("blockchain_status"); statusStr != "" {
+		status := models.BlockchainStatus(statusStr)
+		if status != models.BlockchainPending && status != models.BlockchainConfirmed && status != models.BlockchainFailed {
+			utils.ValidationErrorResponse(c, "Invalid blockchain_status. Use pending, confirmed or failed")
+			return
+		}
+		filter.BlockchainStatus = &status
+	}
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		sd, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid start_date. Use YYYY-MM-DD")
+			return
+		}
+		filter.StartDate = &sd
+	}
+
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		ed, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid end_date. Use YYYY-MM-DD")
+			return
+		}
+		filter.EndDate = &ed
+	}
+
+	if needsReview := c.Query("needs_review"); needsReview != "" {
+		value, err := strconv.ParseBool(needsReview)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid needs_review. Use true or false")
+			return
+		}
+		filter.NeedsReview = &value
+	}
+
+	if includeDeleted := c.Query("include_deleted"); includeDeleted != "" {
+		value, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid include_deleted. Use true or false")
+			return
+		}
+		filter.IncludeDeleted = value
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	bills, total, err := h.billService.AdminSearchBills(ctx, filter, page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to search bills")
+		return
+	}
+
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
+
+	// Full bill detail, including deletion info - the caller is a master admin
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"bills":      bills,
+		"pagination": pagination,
+	})
+}
+
+// SetUserActive activates or deactivates a user account
+// POST /api/v1/admin/users/:id/active
+func (h *AdminHandler) SetUserActive(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+	userID := c.Param("id")
+
+	var req struct {
+		Active *bool `json:"active" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.adminService.SetUserActive(ctx, adminID.(string), userID, *req.Active)
+	if err != nil {
+		if errors.Is(err, services.ErrCannotDeactivateSelf) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeCannotDeactivateSelf, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update account status")
+		return
+	}
+
+	message := "Account reactivated"
+	if !user.IsActive {
+		message = "Account deactivated"
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": message,
+		"user":    user.PublicUser(),
+	})
+}
+
+// UpdateUserRole changes any user's role, adjusting their KYC status to match
+// POST /api/v1/admin/users/:id/role
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+	userID := c.Param("id")
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.adminService.UpdateUserRole(ctx, adminID.(string), userID, req.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrLastMasterAdmin) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeLastMasterAdmin, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Role updated",
+		"user":    user.PublicUser(),
+	})
+}
+
+// InviteUser emails a one-time signup link for a role, including ones that can't be self-registered
+// POST /api/v1/admin/invite
+func (h *AdminHandler) InviteUser(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+
+	var req models.CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	invite, err := h.inviteService.CreateInvite(ctx, adminID.(string), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyRegistered) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeEmailAlreadyRegistered, "Email already registered")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create invite")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Invite sent",
+		"invite":  invite,
+	})
+}
+
+// AdjustWallet credits or debits any user's wallet with an audited ledger entry
+// POST /api/v1/admin/users/:id/wallet/adjust
+func (h *AdminHandler) AdjustWallet(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+	userID := c.Param("id")
+
+	var req models.AdjustWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	entry, err := h.adminService.AdjustWallet(ctx, adminID.(string), userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrAdjustmentReasonRequired) {
+			utils.ValidationErrorResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInsufficientBalance) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInsufficientBalance, err.Error()+". Set allow_negative to overdraw the wallet")
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to adjust wallet")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":        "Wallet adjusted",
+		"transaction":    entry,
+		"wallet_balance": entry.BalanceAfter,
+	})
+}
+
+// SetVerificationPlan assigns or removes a user's prepaid verification plan
+// POST /api/v1/admin/users/:id/plan
+func (h *AdminHandler) SetVerificationPlan(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+	userID := c.Param("id")
+
+	var req models.SetVerificationPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.adminService.SetVerificationPlan(ctx, adminID.(string), userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update verification plan")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":            "Verification plan updated",
+		"user_id":            user.ID,
+		"plan_type":          user.PlanType,
+		"plan_monthly_quota": user.PlanMonthlyQuota,
+		"plan_resets_at":     user.PlanResetsAt,
+	})
+}
+
+// ListVerifiers lists verifier accounts with their reputation scores, lowest score first by default
+// GET /api/v1/admin/verifiers?sort=score|volume|not_found|suspicious|last_verified&order=asc|desc&flagged=true&page=&page_size=
+func (h *AdminHandler) ListVerifiers(c *gin.Context) {
+	page, pageSize, _ := utils.ParsePaginationWithDefault(c, 20)
+
+	filter := models.VerifierScoreFilter{Sort: c.DefaultQuery("sort", models.VerifierSortScore)}
+	switch filter.Sort {
+	case models.VerifierSortScore:
+		// Worst first - that's who admins are looking for
+	case models.VerifierSortVolume, models.VerifierSortNotFound, models.VerifierSortSuspicious, models.VerifierSortLastVerified:
+		filter.Descending = true
+	default:
+		utils.ValidationErrorResponse(c, "Invalid sort. Use score, volume, not_found, suspicious or last_verified")
+		return
+	}
+
+	switch c.Query("order") {
+	case "":
+	case "asc":
+		filter.Descending = false
+	case "desc":
+		filter.Descending = true
+	default:
+		utils.ValidationErrorResponse(c, "Invalid order. Use asc or desc")
+		return
+	}
+
+	if flagged := c.Query("flagged"); flagged != "" {
+		value, err := strconv.ParseBool(flagged)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid flagged. Use true or false")
+			return
+		}
+		filter.FlaggedOnly = value
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	verifiers, total, err := h.adminService.ListVerifierScores(ctx, filter, page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verifiers")
+		return
+	}
+
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"verifiers":  verifiers,
+		"pagination": pagination,
+	})
+}
+
+// ListAuditLog searches the audit log of privileged actions, newest first
+// GET /api/v1/admin/audit?actor_id=&action=&target_type=&target_id=&start_date=&end_date=&page=&page_size=
+func (h *AdminHandler) ListAuditLog(c *gin.Context) {
+	page, pageSize, _ := utils.ParsePaginationWithDefault(c, 20)
+
+	var filter models.AuditFilter
+
+	if actorID := c.Query("actor_id"); actorID != "" {
+		filter.ActorID = &actorID
+	}
+
+	if actionStr := c.Query("action"); actionStr != "" {
+		action := models.AuditAction(actionStr)
+		filter.Action = &action
+	}
+
+	if targetTypeStr := c.Query("target_type"); targetTypeStr != "" {
+		targetType := models.AuditTargetType(targetTypeStr)
+		if targetType != models.AuditTargetUser && targetType != models.AuditTargetBill && targetType != models.AuditTargetInvite {
+			utils.ValidationErrorResponse(c, "Invalid target_type. Use user, bill or invite")
+			return
+		}
+		filter.TargetType = &targetType
+	}
+
+	if targetID := c.Query("target_id"); targetID != "" {
+		filter.TargetID = &targetID
+	}
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		sd, err := utils.ParseDate(startDateStr, h.loc)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid start_date. Use YYYY-MM-DD")
+			return
+		}
+		filter.StartDate = &sd
+	}
+
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		ed, err := utils.ParseDate(endDateStr, h.loc)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid end_date. Use YYYY-MM-DD")
+			return
+		}
+		filter.EndDate = &ed
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	entries, total, err := h.adminService.ListAuditLog(ctx, filter, page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve audit log")
+		return
+	}
+
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"entries":    entries,
+		"pagination": pagination,
+	})
+}
+
+// ListSuspiciousVerifications lists verifications flagged by suspicious activity detection
+// GET /api/v1/admin/verifications/suspicious
+func (h *AdminHandler) ListSuspiciousVerifications(c *gin.Context) {
+	page, pageSize, _ := utils.ParsePaginationWithDefault(c, 20)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	verifications, total, err := h.adminService.ListSuspiciousVerifications(ctx, page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve suspicious verifications")
+		return
+	}
+
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"verifications": verifications,
+		"pagination":    pagination,
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/middleware"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+func TestAdminSearchBills(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewAdminHandler(env.billService, nil, nil, time.UTC)
+	requireAdmin := middleware.RequireRole(string(models.RoleMasterAdmin))
+
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	otherIssuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+
+	testutil.CreateBill(t, env.db, issuer, 100, nil)
+	testutil.CreateBill(t, env.db, otherIssuer, 200, nil)
+	deleted := testutil.CreateBill(t, env.db, issuer, 300, nil)
+	if err := env.bills.SoftDelete(context.Background(), deleted.ID, "test"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+
+	// search returns the bill IDs an admin search with query finds
+	search := func(t *testing.T, query string) map[string]bool {
+		t.Helper()
+		w := serve(t, admin, http.MethodGet, "/admin/bills", "/admin/bills"+query, nil, requireAdmin, h.SearchBills)
+		expectStatus(t, w, http.StatusOK, "")
+
+		ids := map[string]bool{}
+		for _, b := range decode(t, w)["data"].(map[string]interface{})["bills"].([]interface{}) {
+			ids[b.(map[string]interface{})["id"].(string)] = true
+		}
+		return ids
+	}
+
+	t.Run("non-admin is rejected", func(t *testing.T) {
+		w := serve(t, issuer, http.MethodGet, "/admin/bills", "/admin/bills", nil, requireAdmin, h.SearchBills)
+		expectStatus(t, w, http.StatusForbidden, string(utils.CodeInsufficientPermissions))
+	})
+
+	t.Run("across issuers without deleted bills", func(t *testing.T) {
+		if ids := search(t, ""); len(ids) != 2 || ids[deleted.ID] {
+			t.Errorf("found %d bills (deleted included: %v), want the 2 live ones", len(ids), ids[deleted.ID])
+		}
+	})
+
+	t.Run("include deleted", func(t *testing.T) {
+		if ids := search(t, "?include_deleted=true"); len(ids) != 3 || !ids[deleted.ID] {
+			t.Errorf("found %d bills (deleted included: %v), want all 3", len(ids), ids[deleted.ID])
+		}
+	})
+
+	t.Run("by issuer", func(t *testing.T) {
+		if ids := search(t, "?issuer_id="+otherIssuer.ID); len(ids) != 1 {
+			t.Errorf("found %d bills for the other issuer, want 1", len(ids))
+		}
+	})
+
+	t.Run("flagged for review", func(t *testing.T) {
+		flagged := testutil.CreateBill(t, env.db, otherIssuer, 50000000, nil)
+		env.db.MustExec("UPDATE bills SET needs_review = true WHERE id = $1", flagged.ID)
+
+		if ids := search(t, "?needs_review=true"); len(ids) != 1 || !ids[flagged.ID] {
+			t.Errorf("found %d bills needing review (flagged one included: %v), want just the flagged one", len(ids), ids[flagged.ID])
+		}
+		if ids := search(t, "?needs_review=false"); len(ids) != 2 || ids[flagged.ID] {
+			t.Errorf("found %d bills not needing review (flagged one included: %v), want the 2 others", len(ids), ids[flagged.ID])
+		}
+
+		w := serve(t, admin, http.MethodGet, "/admin/bills", "/admin/bills?needs_review=maybe", nil, requireAdmin, h.SearchBills)
+		expectStatus(t, w, http.StatusBadRequest, "")
+	})
+}
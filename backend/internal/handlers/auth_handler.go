@@ -2,27 +2,62 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/ezhilnn/epr-backend/config"
 	"github.com/ezhilnn/epr-backend/internal/models"
 	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/services"
 	"github.com/ezhilnn/epr-backend/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // AuthHandler handles authentication related requests
 type AuthHandler struct {
-	userRepo *repository.UserRepository
-	cfg      *config.Config
+	userRepo      *repository.UserRepository
+	walletService *services.WalletService
+	emailService  *services.EmailService
+	loginActivity *services.LoginActivityService
+	tokenDenylist *repository.TokenDenylistRepository
+	accountData   *services.AccountDataService
+	invites       *services.InviteService
+	panChecks     *services.PANVerificationService
+	twoFactor     *services.TwoFactorService
+	jwtKeys       utils.JWTKeySet
+	cfg           *config.Config
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(userRepo *repository.UserRepository, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(
+	userRepo *repository.UserRepository,
+	walletService *services.WalletService,
+	emailService *services.EmailService,
+	loginActivity *services.LoginActivityService,
+	tokenDenylist *repository.TokenDenylistRepository,
+	accountData *services.AccountDataService,
+	invites *services.InviteService,
+	panChecks *services.PANVerificationService,
+	twoFactor *services.TwoFactorService,
+	jwtKeys utils.JWTKeySet,
+	cfg *config.Config,
+) *AuthHandler {
 	return &AuthHandler{
-		userRepo: userRepo,
-		cfg:      cfg,
+		userRepo:      userRepo,
+		walletService: walletService,
+		emailService:  emailService,
+		loginActivity: loginActivity,
+		tokenDenylist: tokenDenylist,
+		accountData:   accountData,
+		invites:       invites,
+		panChecks:     panChecks,
+		twoFactor:     twoFactor,
+		jwtKeys:       jwtKeys,
+		cfg:           cfg,
 	}
 }
 
@@ -33,12 +68,18 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 
 	// Bind JSON request to struct
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, err.Error())
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	// Privileged roles such as verifier need an admin invite
+	if !slices.Contains(h.cfg.App.SignupAllowedRoles, string(req.Role)) {
+		utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeRoleRequiresInvite, fmt.Sprintf("Signing up as %s requires an invite from an administrator", req.Role))
 		return
 	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Check if email already exists
@@ -49,10 +90,23 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 	}
 
 	if exists {
-		utils.ErrorResponse(c, http.StatusConflict, "Email already registered")
+		utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeEmailAlreadyRegistered, "Email already registered")
 		return
 	}
 
+	// Optionally allow one institution account per GSTIN
+	if h.cfg.App.UniqueInstitutionGSTIN && req.Role.IsInstitution() && req.GSTIN != "" {
+		taken, err := h.userRepo.InstitutionGSTINExists(ctx, req.GSTIN)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check GSTIN availability")
+			return
+		}
+		if taken {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeGSTINAlreadyRegistered, "GSTIN is already registered to another institution")
+			return
+		}
+	}
+
 	// Hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
@@ -61,10 +115,7 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 	}
 
 	// Determine KYC status based on role
-	kycStatus := models.KYCNotNeeded
-	if req.Role == models.RoleInstitutionUser || req.Role == models.RoleInstitutionAdmin {
-		kycStatus = models.KYCPending
-	}
+	kycStatus := models.InitialKYCStatus(req.Role)
 
 	// Create user object
 	user := &models.User{
@@ -77,6 +128,8 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 		WalletBalance:    0.0,
 		IsActive:         true,
 		IsEmailVerified:  false,
+
+		NotificationPreferences: models.DefaultNotificationPreferences(),
 	}
 
 	// Set optional fields
@@ -96,13 +149,231 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 		return
 	}
 
+	// Issue an email verification token and send the link
+	// Failures here don't fail signup - the account exists, only verification is pending
+	h.sendVerificationEmail(ctx, user)
+	h.panChecks.VerifyInBackground(user)
+
 	// Return success response (don't auto-login, require email verification)
 	utils.SuccessResponse(c, http.StatusCreated, gin.H{
-		"message": "Account created successfully. Please login to continue.",
+		"message": "Account created successfully. Please check your email to verify your account, then login to continue.",
 		"user":    user.PublicUser(),
 	})
 }
 
+// SignupWithInvite creates an account from an admin invite, with the invite's email and role
+// The email counts as verified, since the invite link was sent to it
+// POST /api/v1/auth/signup/invite
+func (h *AuthHandler) SignupWithInvite(c *gin.Context) {
+	var req models.InviteSignupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.invites.SignupWithInvite(ctx, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInviteInvalid) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInviteInvalid, "Invalid or already used invite link")
+			return
+		}
+		if errors.Is(err, services.ErrInviteExpired) {
+			utils.ErrorResponseWithCode(c, http.StatusGone, utils.CodeInviteExpired, "Invite link has expired")
+			return
+		}
+		if errors.Is(err, services.ErrEmailAlreadyRegistered) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeEmailAlreadyRegistered, "Email already registered")
+			return
+		}
+		if errors.Is(err, services.ErrGSTINAlreadyRegistered) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeGSTINAlreadyRegistered, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create user account")
+		return
+	}
+
+	h.panChecks.VerifyInBackground(user)
+
+	utils.SuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Account created successfully. You can now log in.",
+		"user":    user.PublicUser(),
+	})
+}
+
+// VerifyEmail confirms a user's email address using the token from the verification link
+// GET /api/v1/auth/verify-email?token=...
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Verification token is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	// Tokens are cleared once used, so an already-used token is simply not found
+	user, err := h.userRepo.GetByEmailVerificationToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInvalidLink, "Invalid or already used verification link")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	if user.EmailVerificationExpiresAt != nil && time.Now().After(*user.EmailVerificationExpiresAt) {
+		utils.ErrorResponseWithCode(c, http.StatusGone, utils.CodeLinkExpired, "Verification link has expired")
+		return
+	}
+
+	if err := h.userRepo.MarkEmailVerified(ctx, user.ID, token); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			// Another request used the token first
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInvalidLink, "Invalid or already used verification link")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Email verified successfully",
+	})
+}
+
+// ForgotPassword starts a password reset by emailing a reset link
+// Always responds 200 so the endpoint can't be used to discover registered emails
+// POST /api/v1/auth/forgot-password
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	response := gin.H{
+		"message": "If an account exists for this email, a password reset link has been sent.",
+	}
+
+	user, err := h.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil || !user.IsActive {
+		if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+			log.Printf("⚠️  Failed to look up %s for password reset: %v", req.Email, err)
+		}
+		utils.SuccessResponse(c, http.StatusOK, response)
+		return
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start password reset")
+		return
+	}
+
+	expiresAt := time.Now().Add(h.cfg.App.PasswordResetExpiry)
+	if err := h.userRepo.SetPasswordResetToken(ctx, user.ID, token, expiresAt); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start password reset")
+		return
+	}
+
+	// Send in the background so response timing doesn't reveal whether the email exists
+	go func(user models.User) {
+		sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := h.emailService.SendPasswordResetEmail(sendCtx, &user, token); err != nil {
+			log.Printf("⚠️  Failed to send password reset email to %s: %v", user.Email, err)
+		}
+	}(*user)
+
+	utils.SuccessResponse(c, http.StatusOK, response)
+}
+
+// ResetPassword sets a new password using a token from a reset link
+// POST /api/v1/auth/reset-password
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	// Tokens are cleared once used, so an already-used token is simply not found
+	user, err := h.userRepo.GetByPasswordResetToken(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInvalidLink, "Invalid or already used reset link")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	if user.PasswordResetExpiresAt == nil || time.Now().After(*user.PasswordResetExpiresAt) {
+		utils.ErrorResponseWithCode(c, http.StatusGone, utils.CodeLinkExpired, "Reset link has expired. Please request a new one.")
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process password")
+		return
+	}
+
+	if err := h.userRepo.ResetPassword(ctx, user.ID, req.Token, hashedPassword); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			// Another request used the token first
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInvalidLink, "Invalid or already used reset link")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Password reset successfully. Please login with your new password.",
+	})
+}
+
+// sendVerificationEmail stores a fresh verification token for the user and emails the link
+func (h *AuthHandler) sendVerificationEmail(ctx context.Context, user *models.User) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		log.Printf("⚠️  Failed to generate verification token for %s: %v", user.Email, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(h.cfg.App.EmailVerificationExpiry)
+	if err := h.userRepo.SetEmailVerificationToken(ctx, user.ID, token, expiresAt); err != nil {
+		log.Printf("⚠️  Failed to store verification token for %s: %v", user.Email, err)
+		return
+	}
+
+	// SMTP can be slow - don't hold up the signup response
+	go func(user models.User) {
+		sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := h.emailService.SendVerificationEmail(sendCtx, &user, token); err != nil {
+			log.Printf("⚠️  Failed to send verification email to %s: %v", user.Email, err)
+		}
+	}(*user)
+}
+
 // Login handles user authentication
 // POST /api/v1/auth/login
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -110,40 +381,59 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// Bind JSON request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, err.Error())
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Get user by email
 	user, err := h.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid email or password")
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Invalid email or password")
 		return
 	}
 
 	// Check if account is active
 	if !user.IsActive {
-		utils.ErrorResponse(c, http.StatusForbidden, "Account is deactivated. Please contact support.")
+		utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeAccountDeactivated, "Account is deactivated. Please contact support.")
 		return
 	}
 
 	// Verify password
 	if !utils.CheckPassword(user.PasswordHash, req.Password) {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid email or password")
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Invalid email or password")
+		return
+	}
+
+	// Accounts with two-factor authentication need a code before any tokens are issued
+	if err := h.twoFactor.CheckLogin(ctx, user, req.TOTPCode, req.RecoveryCode); err != nil {
+		if errors.Is(err, services.ErrTwoFactorRequired) {
+			utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeTwoFactorRequired, "Enter the code from your authenticator app, or a recovery code")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidTwoFactorCode, "Invalid two-factor authentication code")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check two-factor authentication code")
 		return
 	}
 
+	// Token lifetimes can be configured per role
+	accessExpiry := h.cfg.JWT.AccessTokenExpiryFor(string(user.Role))
+
 	// Generate access token
 	accessToken, err := utils.GenerateAccessToken(
 		user.ID,
 		user.Email,
 		string(user.Role),
-		h.cfg.JWT.Secret,
-		h.cfg.JWT.AccessTokenExpiry,
+		user.IsEmailVerified,
+		h.jwtKeys,
+		accessExpiry,
 	)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate access token")
@@ -153,8 +443,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Generate refresh token
 	refreshToken, err := utils.GenerateRefreshToken(
 		user.ID,
-		h.cfg.JWT.Secret,
-		h.cfg.JWT.RefreshTokenExpiry,
+		h.jwtKeys,
+		h.cfg.JWT.RefreshTokenExpiryFor(string(user.Role)),
 	)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate refresh token")
@@ -167,12 +457,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.Error(err)
 	}
 
+	// Record the sign-in and send the login alert in the background
+	h.loginActivity.RecordLogin(user, c.ClientIP(), c.GetHeader("User-Agent"))
+
 	// Return tokens and user info
 	response := models.LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		User:         user.PublicUser(),
-		ExpiresIn:    int64(h.cfg.JWT.AccessTokenExpiry.Seconds()),
+		ExpiresIn:    int64(accessExpiry.Seconds()),
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, response)
@@ -185,41 +478,70 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 
 	// Bind JSON request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, err.Error())
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 
 	// Validate refresh token
-	userID, err := utils.ValidateRefreshToken(req.RefreshToken, h.cfg.JWT.Secret)
+	claims, err := utils.ParseRefreshToken(req.RefreshToken, h.jwtKeys)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidToken, "Invalid or expired refresh token")
 		return
 	}
+	userID := claims.Subject
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
+	// Reject tokens revoked by logout
+	revoked, err := h.tokenDenylist.IsRevoked(ctx, utils.RefreshTokenID(claims, req.RefreshToken))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to validate refresh token")
+		return
+	}
+	if revoked {
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidToken, "Invalid or expired refresh token")
+		return
+	}
+
+	// Reject tokens revoked by account deactivation
+	if claims.IssuedAt != nil {
+		revoked, err = h.tokenDenylist.IsRevokedForUser(ctx, userID, claims.IssuedAt.Time)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to validate refresh token")
+			return
+		}
+		if revoked {
+			utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidToken, "Invalid or expired refresh token")
+			return
+		}
+	}
+
 	// Get user from database
 	user, err := h.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not found")
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeUserNotFound, "User not found")
 		return
 	}
 
 	// Check if account is still active
 	if !user.IsActive {
-		utils.ErrorResponse(c, http.StatusForbidden, "Account is deactivated")
+		utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeAccountDeactivated, "Account is deactivated")
 		return
 	}
 
+	// Use the user's current role, in case it changed since login
+	accessExpiry := h.cfg.JWT.AccessTokenExpiryFor(string(user.Role))
+
 	// Generate new access token
 	accessToken, err := utils.GenerateAccessToken(
 		user.ID,
 		user.Email,
 		string(user.Role),
-		h.cfg.JWT.Secret,
-		h.cfg.JWT.AccessTokenExpiry,
+		user.IsEmailVerified,
+		h.jwtKeys,
+		accessExpiry,
 	)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate access token")
@@ -229,7 +551,365 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// Return new access token
 	utils.SuccessResponse(c, http.StatusOK, gin.H{
 		"access_token": accessToken,
-		"expires_in":   int64(h.cfg.JWT.AccessTokenExpiry.Seconds()),
+		"expires_in":   int64(accessExpiry.Seconds()),
+	})
+}
+
+// Logout revokes a refresh token so it can no longer mint access tokens
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	claims, err := utils.ParseRefreshToken(req.RefreshToken, h.jwtKeys)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidToken, "Invalid or expired refresh token")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	// Keep the token denied for the rest of its natural lifetime
+	var ttl time.Duration
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+
+	if err := h.tokenDenylist.Revoke(ctx, utils.RefreshTokenID(claims, req.RefreshToken), ttl); err != nil {
+		if errors.Is(err, repository.ErrTokenRevocationUnavailable) {
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, "Logout is temporarily unavailable. Please try again later.")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// Deactivate lets users close their own account after re-entering their password
+// POST /api/v1/auth/deactivate
+func (h *AuthHandler) Deactivate(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByID(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+		return
+	}
+
+	if !utils.CheckPassword(user.PasswordHash, req.Password) {
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Incorrect password")
+		return
+	}
+
+	if err := h.userRepo.SetActive(ctx, user.ID, false); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to deactivate account")
+		return
+	}
+
+	// Refresh also checks is_active, so a revocation failure doesn't leave the account usable
+	if err := h.tokenDenylist.RevokeAllForUser(ctx, user.ID, h.cfg.JWT.MaxRefreshTokenExpiry()); err != nil &&
+		!errors.Is(err, repository.ErrTokenRevocationUnavailable) {
+		log.Printf("⚠️  Failed to revoke tokens for deactivated user %s: %v", user.ID, err)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Account deactivated. Contact support to reactivate it.",
+	})
+}
+
+// ExportData downloads everything stored about the user as a single JSON file
+// GET /api/v1/auth/export
+func (h *AuthHandler) ExportData(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	// Exports can be long - give them more time than a normal request
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	export, err := h.accountData.Export(ctx, userID.(string))
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			return
+		}
+		log.Printf("❌ Failed to export data for user %s: %v", userID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export account data")
+		return
+	}
+
+	filename := fmt.Sprintf("epr-account-data-%s.json", time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+// EraseData scrubs the user's personal data and closes the account after re-entering their password
+// Issued bills keep their content and hashes so they can still be verified
+// POST /api/v1/auth/erase
+func (h *AuthHandler) EraseData(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByID(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+		return
+	}
+
+	if !utils.CheckPassword(user.PasswordHash, req.Password) {
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Incorrect password")
+		return
+	}
+
+	if err := h.accountData.Erase(ctx, user.ID); err != nil {
+		log.Printf("❌ Failed to erase data for user %s: %v", user.ID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to erase account data")
+		return
+	}
+
+	// Refresh also checks is_active, so a revocation failure doesn't leave the account usable
+	if err := h.tokenDenylist.RevokeAllForUser(ctx, user.ID, h.cfg.JWT.MaxRefreshTokenExpiry()); err != nil &&
+		!errors.Is(err, repository.ErrTokenRevocationUnavailable) {
+		log.Printf("⚠️  Failed to revoke tokens for erased user %s: %v", user.ID, err)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Your personal data has been erased and your account closed.",
+	})
+}
+
+// ListSessions returns the user's recent sign-ins
+// GET /api/v1/auth/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := h.loginActivity.ListSessions(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve sessions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// GetPreferences returns the user's email notification preferences
+// GET /api/v1/auth/preferences
+func (h *AuthHandler) GetPreferences(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByID(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, user.NotificationPreferences)
+}
+
+// UpdatePreferences changes the user's email notification preferences
+// PUT /api/v1/auth/preferences
+func (h *AuthHandler) UpdatePreferences(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByID(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+		return
+	}
+
+	prefs := req.Apply(user.NotificationPreferences)
+	if err := h.userRepo.SetNotificationPreferences(ctx, user.ID, prefs); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update preferences")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":     "Preferences updated",
+		"preferences": prefs,
+	})
+}
+
+// EnableTwoFactor starts two-factor setup and returns the secret to add to an authenticator app
+// Nothing changes at login until the setup is confirmed with VerifyTwoFactor
+// POST /api/v1/auth/2fa/enable
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.EnableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByID(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+		return
+	}
+
+	if !utils.CheckPassword(user.PasswordHash, req.Password) {
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Incorrect password")
+		return
+	}
+
+	setup, err := h.twoFactor.Enable(ctx, user)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorNotAvailable) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeTwoFactorNotAvailable, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeTwoFactorAlreadyEnabled, err.Error())
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start two-factor setup")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, setup)
+}
+
+// VerifyTwoFactor confirms two-factor setup with a code from the authenticator app and turns it on
+// Responds with the recovery codes, which are only shown this once
+// POST /api/v1/auth/2fa/verify
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.VerifyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByID(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+		return
+	}
+
+	codes, err := h.twoFactor.Verify(ctx, user, req.Code)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeTwoFactorAlreadyEnabled, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrTwoFactorNotStarted) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeTwoFactorNotStarted, "Start two-factor setup with POST /api/v1/auth/2fa/enable first")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInvalidTwoFactorCode, "Invalid code. Check your device's clock and try the current code.")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled. Store these recovery codes somewhere safe; each works once.",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor turns two-factor authentication off with the password and a current or recovery code
+// POST /api/v1/auth/2fa/disable
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userRepo.GetByID(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+		return
+	}
+
+	if !utils.CheckPassword(user.PasswordHash, req.Password) {
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Incorrect password")
+		return
+	}
+
+	if err := h.twoFactor.Disable(ctx, user, req.Code); err != nil {
+		if errors.Is(err, services.ErrTwoFactorNotEnabled) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeTwoFactorNotEnabled, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInvalidTwoFactorCode, "Invalid two-factor authentication code")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
 	})
 }
 
@@ -239,27 +919,40 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeAuthRequired, "User not authenticated")
 		return
 	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Get user from database
 	user, err := h.userRepo.GetByID(ctx, userID.(string))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
 		return
 	}
 
+	// Add loyalty progress so the frontend can show "N more until a free one"
+	profile := user.PublicUser()
+	freeEveryN := h.cfg.Pricing.LoyaltyFreeEveryN
+	untilFree := 0
+	if freeEveryN > 0 {
+		untilFree = freeEveryN - user.VerificationCount%freeEveryN
+	}
+	profile["loyalty"] = gin.H{
+		"free_every_n":                 freeEveryN,
+		"verifications_until_free":     untilFree,
+		"free_verifications_available": user.FreeVerificationsEarned,
+	}
+
 	// Return user information
-	utils.SuccessResponse(c, http.StatusOK, user.PublicUser())
+	utils.SuccessResponse(c, http.StatusOK, profile)
 }
 
 // TopupWallet adds balance to user's wallet (FOR TESTING ONLY)
-// In production, this would integrate with payment gateway
+// Not registered in production - real top-ups go through POST /api/v1/wallet/order and /confirm
 // POST /api/v1/auth/wallet/topup
 func (h *AuthHandler) TopupWallet(c *gin.Context) {
 	userID, _ := c.Get("user_id")
@@ -269,23 +962,20 @@ func (h *AuthHandler) TopupWallet(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, err.Error())
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	// Get current user
-	user, err := h.userRepo.GetByID(ctx, userID.(string))
+	// Credit wallet and record the ledger entry
+	newBalance, err := h.walletService.Topup(ctx, userID.(string), req.Amount)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
-		return
-	}
-
-	// Update wallet balance
-	newBalance := user.WalletBalance + req.Amount
-	if err := h.userRepo.UpdateWalletBalance(ctx, user.ID, newBalance); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update wallet")
 		return
 	}
@@ -0,0 +1,603 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/geoip"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := &AuthHandler{userRepo: env.users, cfg: env.cfg}
+	ctx := context.Background()
+
+	// unverifiedUser creates a user holding a verification token that expires at expiresAt
+	unverifiedUser := func(t *testing.T, token string, expiresAt time.Time) *models.User {
+		t.Helper()
+		user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+		env.db.MustExec("UPDATE users SET is_email_verified = false WHERE id = $1", user.ID)
+		if err := env.users.SetEmailVerificationToken(ctx, user.ID, token, expiresAt); err != nil {
+			t.Fatalf("failed to set verification token: %v", err)
+		}
+		return user
+	}
+	verify := func(t *testing.T, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(t, nil, http.MethodGet, "/verify-email", "/verify-email?token="+token, nil, h.VerifyEmail)
+	}
+	isVerified := func(t *testing.T, user *models.User) bool {
+		t.Helper()
+		reloaded, err := env.users.GetByIDAny(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("failed to reload user: %v", err)
+		}
+		return reloaded.IsEmailVerified
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		user := unverifiedUser(t, "valid-token", time.Now().Add(time.Hour))
+
+		expectStatus(t, verify(t, "valid-token"), http.StatusOK, "")
+		if !isVerified(t, user) {
+			t.Error("email is not verified after using a valid token")
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		user := unverifiedUser(t, "expired-token", time.Now().Add(-time.Minute))
+
+		expectStatus(t, verify(t, "expired-token"), http.StatusGone, string(utils.CodeLinkExpired))
+		if isVerified(t, user) {
+			t.Error("email was verified with an expired token")
+		}
+	})
+
+	t.Run("already used token", func(t *testing.T) {
+		unverifiedUser(t, "used-token", time.Now().Add(time.Hour))
+
+		expectStatus(t, verify(t, "used-token"), http.StatusOK, "")
+		expectStatus(t, verify(t, "used-token"), http.StatusBadRequest, string(utils.CodeInvalidLink))
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		expectStatus(t, verify(t, "no-such-token"), http.StatusBadRequest, string(utils.CodeInvalidLink))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		expectStatus(t, verify(t, ""), http.StatusBadRequest, "")
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := &AuthHandler{userRepo: env.users, cfg: env.cfg}
+	ctx := context.Background()
+
+	// userWithResetToken creates a user holding a reset token that expires at expiresAt
+	userWithResetToken := func(t *testing.T, token string, expiresAt time.Time) *models.User {
+		t.Helper()
+		user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+		if err := env.users.SetPasswordResetToken(ctx, user.ID, token, expiresAt); err != nil {
+			t.Fatalf("failed to set reset token: %v", err)
+		}
+		return user
+	}
+	reset := func(t *testing.T, token, password string) *httptest.ResponseRecorder {
+		t.Helper()
+		body := models.ResetPasswordRequest{Token: token, NewPassword: password}
+		return serve(t, nil, http.MethodPost, "/reset-password", "/reset-password", body, h.ResetPassword)
+	}
+	passwordIs := func(t *testing.T, user *models.User, password string) bool {
+		t.Helper()
+		reloaded, err := env.users.GetByIDAny(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("failed to reload user: %v", err)
+		}
+		return utils.CheckPassword(reloaded.PasswordHash, password)
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		user := userWithResetToken(t, "valid-reset", time.Now().Add(time.Hour))
+
+		expectStatus(t, reset(t, "valid-reset", "N3w-Passw0rd!"), http.StatusOK, "")
+		if !passwordIs(t, user, "N3w-Passw0rd!") {
+			t.Error("password was not changed")
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		user := userWithResetToken(t, "expired-reset", time.Now().Add(-time.Minute))
+
+		expectStatus(t, reset(t, "expired-reset", "N3w-Passw0rd!"), http.StatusGone, string(utils.CodeLinkExpired))
+		if passwordIs(t, user, "N3w-Passw0rd!") {
+			t.Error("password was changed with an expired token")
+		}
+	})
+
+	t.Run("reused token", func(t *testing.T) {
+		user := userWithResetToken(t, "reused-reset", time.Now().Add(time.Hour))
+
+		expectStatus(t, reset(t, "reused-reset", "F1rst-Passw0rd!"), http.StatusOK, "")
+		expectStatus(t, reset(t, "reused-reset", "S3cond-Passw0rd!"), http.StatusBadRequest, string(utils.CodeInvalidLink))
+		if !passwordIs(t, user, "F1rst-Passw0rd!") {
+			t.Error("reusing the token changed the password again")
+		}
+	})
+}
+
+func TestForgotPasswordUnknownEmailLooksLikeSuccess(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := &AuthHandler{userRepo: env.users, cfg: env.cfg}
+
+	body := models.ForgotPasswordRequest{Email: "nobody@example.com"}
+	w := serve(t, nil, http.MethodPost, "/forgot-password", "/forgot-password", body, h.ForgotPassword)
+
+	expectStatus(t, w, http.StatusOK, "")
+}
+
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	env := newHandlerEnv(t)
+	keys := utils.NewJWTKeySet("HS256", "", "test-secret", nil)
+	h := &AuthHandler{
+		userRepo:      env.users,
+		tokenDenylist: repository.NewTokenDenylistRepository(testutil.Redis(t)),
+		jwtKeys:       keys,
+		cfg:           env.cfg,
+	}
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+
+	newRefreshToken := func(t *testing.T) string {
+		t.Helper()
+		token, err := utils.GenerateRefreshToken(user.ID, keys, time.Hour)
+		if err != nil {
+			t.Fatalf("failed to sign refresh token: %v", err)
+		}
+		return token
+	}
+	post := func(t *testing.T, handler gin.HandlerFunc, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(t, nil, http.MethodPost, "/", "/", models.RefreshTokenRequest{RefreshToken: token}, handler)
+	}
+
+	token := newRefreshToken(t)
+	other := newRefreshToken(t)
+
+	expectStatus(t, post(t, h.RefreshToken, token), http.StatusOK, "")
+	expectStatus(t, post(t, h.Logout, token), http.StatusOK, "")
+
+	// The logged-out token can't mint access tokens any more
+	expectStatus(t, post(t, h.RefreshToken, token), http.StatusUnauthorized, string(utils.CodeInvalidToken))
+
+	// Other sessions of the same user are unaffected
+	expectStatus(t, post(t, h.RefreshToken, other), http.StatusOK, "")
+}
+
+func TestGetMeIncludesLoyaltyProgress(t *testing.T) {
+	env := newHandlerEnv(t)
+	env.cfg.Pricing.LoyaltyFreeEveryN = 5
+	h := &AuthHandler{userRepo: env.users, cfg: env.cfg}
+
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 42)
+	env.db.MustExec("UPDATE users SET verification_count = 3, free_verifications_earned = 1 WHERE id = $1", user.ID)
+
+	w := serve(t, user, http.MethodGet, "/me", "/me", nil, h.GetMe)
+	expectStatus(t, w, http.StatusOK, "")
+
+	profile := decode(t, w)["data"].(map[string]interface{})
+	for field, want := range map[string]interface{}{
+		"wallet_balance":            float64(42),
+		"verification_count":        float64(3),
+		"free_verifications_earned": float64(1),
+	} {
+		if profile[field] != want {
+			t.Errorf("%s = %v, want %v", field, profile[field], want)
+		}
+	}
+
+	loyalty, ok := profile["loyalty"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("profile has no loyalty block: %v", profile)
+	}
+	for field, want := range map[string]float64{
+		"free_every_n":                 5,
+		"verifications_until_free":     2,
+		"free_verifications_available": 1,
+	} {
+		if loyalty[field] != want {
+			t.Errorf("loyalty.%s = %v, want %v", field, loyalty[field], want)
+		}
+	}
+
+	for _, secret := range []string{"password_hash", "email_verification_token", "password_reset_token", "two_factor_secret"} {
+		if _, leaked := profile[secret]; leaked {
+			t.Errorf("profile exposes %s", secret)
+		}
+	}
+}
+
+func TestLoginRejectedAfterDeactivation(t *testing.T) {
+	env := newHandlerEnv(t)
+	keys := utils.NewJWTKeySet("HS256", "", "test-secret", nil)
+	denylist := repository.NewTokenDenylistRepository(testutil.Redis(t))
+	h := &AuthHandler{userRepo: env.users, tokenDenylist: denylist, jwtKeys: keys, cfg: env.cfg}
+	admin := NewAdminHandler(env.billService, services.NewAdminService(env.db, env.users, env.bills, env.verifications,
+		repository.NewWalletTransactionRepository(env.db.DB), denylist, repository.NewAuditRepository(env.db.DB), env.cfg), nil, time.UTC)
+
+	const password = "correct horse battery"
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	// newUser creates an active user who can log in with password
+	newUser := func(t *testing.T) *models.User {
+		t.Helper()
+		user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+		env.db.MustExec("UPDATE users SET password_hash = $1 WHERE id = $2", hash, user.ID)
+		return user
+	}
+	// login tries the wrong password: an active account answers with invalid credentials, a deactivated one
+	// is refused before the password is checked
+	login := func(t *testing.T, user *models.User) *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(t, nil, http.MethodPost, "/login", "/login", models.LoginRequest{Email: user.Email, Password: "wrong password"}, h.Login)
+	}
+	// session returns a refresh token for user, standing in for a session opened before deactivation
+	session := func(t *testing.T, user *models.User) string {
+		t.Helper()
+		token, err := utils.GenerateRefreshToken(user.ID, keys, time.Hour)
+		if err != nil {
+			t.Fatalf("failed to sign refresh token: %v", err)
+		}
+		return token
+	}
+	refresh := func(t *testing.T, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(t, nil, http.MethodPost, "/refresh", "/refresh", models.RefreshTokenRequest{RefreshToken: token}, h.RefreshToken)
+	}
+
+	t.Run("self-service", func(t *testing.T) {
+		user := newUser(t)
+		token := session(t, user)
+		deactivate := func(password string) *httptest.ResponseRecorder {
+			return serve(t, user, http.MethodPost, "/deactivate", "/deactivate", map[string]string{"password": password}, h.Deactivate)
+		}
+
+		expectStatus(t, login(t, user), http.StatusUnauthorized, string(utils.CodeInvalidCredentials))
+
+		expectStatus(t, deactivate("wrong password"), http.StatusUnauthorized, string(utils.CodeInvalidCredentials))
+		expectStatus(t, login(t, user), http.StatusUnauthorized, string(utils.CodeInvalidCredentials))
+
+		expectStatus(t, deactivate(password), http.StatusOK, "")
+		expectStatus(t, login(t, user), http.StatusForbidden, string(utils.CodeAccountDeactivated))
+		expectStatus(t, refresh(t, token), http.StatusUnauthorized, string(utils.CodeInvalidToken))
+	})
+
+	t.Run("by an admin", func(t *testing.T) {
+		user := newUser(t)
+		token := session(t, user)
+		adminUser := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+		setActive := func(active bool) *httptest.ResponseRecorder {
+			return serve(t, adminUser, http.MethodPost, "/admin/users/:id/active", "/admin/users/"+user.ID+"/active", map[string]bool{"active": active}, admin.SetUserActive)
+		}
+
+		expectStatus(t, setActive(false), http.StatusOK, "")
+		expectStatus(t, login(t, user), http.StatusForbidden, string(utils.CodeAccountDeactivated))
+		expectStatus(t, refresh(t, token), http.StatusUnauthorized, string(utils.CodeInvalidToken))
+
+		// Reactivated accounts are back to the normal password check
+		expectStatus(t, setActive(true), http.StatusOK, "")
+		expectStatus(t, login(t, user), http.StatusUnauthorized, string(utils.CodeInvalidCredentials))
+	})
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := &AuthHandler{userRepo: env.users, cfg: env.cfg}
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+
+	get := func(t *testing.T) map[string]interface{} {
+		t.Helper()
+		w := serve(t, user, http.MethodGet, "/auth/preferences", "/auth/preferences", nil, h.GetPreferences)
+		expectStatus(t, w, http.StatusOK, "")
+		return decode(t, w)["data"].(map[string]interface{})
+	}
+
+	// Everything starts enabled
+	for key, enabled := range get(t) {
+		if enabled != true {
+			t.Errorf("new user's %s = %v, want true", key, enabled)
+		}
+	}
+
+	w := serve(t, user, http.MethodPut, "/auth/preferences", "/auth/preferences", map[string]bool{"login_alerts": false}, h.UpdatePreferences)
+	expectStatus(t, w, http.StatusOK, "")
+
+	prefs := get(t)
+	if prefs["login_alerts"] != false {
+		t.Errorf("login_alerts = %v after turning it off, want false", prefs["login_alerts"])
+	}
+	if prefs["daily_summary"] != true || prefs["low_balance"] != true {
+		t.Errorf("preferences = %v, want the others left enabled", prefs)
+	}
+}
+
+func TestLoginAlertOnlyOnSuccess(t *testing.T) {
+	env := newHandlerEnv(t)
+	cfg := *env.cfg
+	cfg.Email.SMTPHost = "127.0.0.1"
+	cfg.Email.SMTPPort = 1
+	cfg.Email.SMTPTimeout = time.Second
+	cfg.Email.RetryMaxAttempts = 1
+	emails := services.NewEmailService(&cfg, env.bills, nil, env.users, nil)
+	activity := services.NewLoginActivityService(repository.NewLoginHistoryRepository(env.db.DB), emails, geoip.NewNoopLocator())
+	h := &AuthHandler{userRepo: env.users, loginActivity: activity, jwtKeys: utils.NewJWTKeySet("HS256", "", "test-secret", nil), cfg: env.cfg}
+
+	const password = "correct horse battery"
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	env.db.MustExec("UPDATE users SET password_hash = $1 WHERE id = $2", hash, user.ID)
+
+	// logins counts the sign-ins recorded for user, each of which sends a login alert, once they settle
+	logins := func(t *testing.T, want int) int {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			n := testutil.Count(t, env.db, "login_history", "user_id = $1", user.ID)
+			if n >= want || time.Now().After(deadline) {
+				return n
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	w := serve(t, nil, http.MethodPost, "/login", "/login", models.LoginRequest{Email: user.Email, Password: "wrong password"}, h.Login)
+	expectStatus(t, w, http.StatusUnauthorized, string(utils.CodeInvalidCredentials))
+	if n := logins(t, 1); n != 0 {
+		t.Errorf("sign-ins recorded after a failed login = %d, want 0", n)
+	}
+
+	w = serve(t, nil, http.MethodPost, "/login", "/login", models.LoginRequest{Email: user.Email, Password: password}, h.Login)
+	expectStatus(t, w, http.StatusOK, "")
+	if n := logins(t, 1); n != 1 {
+		t.Errorf("sign-ins recorded after a successful login = %d, want 1", n)
+	}
+}
+
+func TestSignupValidationErrors(t *testing.T) {
+	h := &AuthHandler{}
+
+	w := serve(t, nil, http.MethodPost, "/auth/signup", "/auth/signup", map[string]interface{}{
+		"email":    "not-an-email",
+		"password": "short",
+		"role":     "superuser",
+	}, h.Signup)
+	expectStatus(t, w, http.StatusBadRequest, string(utils.CodeValidationFailed))
+
+	want := map[string]interface{}{
+		"full_name":         "is required",
+		"email":             "must be a valid email address",
+		"password":          "must be at least 8 characters long",
+		"organization_name": "is required",
+		"role":              "must be one of: public, institution_user, institution_admin, verifier",
+	}
+	if details := decode(t, w)["details"]; !reflect.DeepEqual(details, want) {
+		t.Errorf("details = %v, want %v", details, want)
+	}
+	if body := w.Body.String(); strings.Contains(body, "CreateUserRequest") || strings.Contains(body, "Error:Field") {
+		t.Errorf("response leaks the raw binding error: %s", body)
+	}
+}
+
+func TestSignupRejectsInvalidPAN(t *testing.T) {
+	h := &AuthHandler{}
+
+	w := serve(t, nil, http.MethodPost, "/auth/signup", "/auth/signup", map[string]interface{}{
+		"full_name":         "Asha Rao",
+		"email":             "asha@example.com",
+		"password":          "correct horse battery",
+		"organization_name": "Rao Audits",
+		"role":              "institution_admin",
+		"pan":               "AAPFU09A9F",
+	}, h.Signup)
+	expectStatus(t, w, http.StatusBadRequest, string(utils.CodeValidationFailed))
+
+	want := map[string]interface{}{"pan": "is not a valid PAN: it must have digits in characters 6-9"}
+	if details := decode(t, w)["details"]; !reflect.DeepEqual(details, want) {
+		t.Errorf("details = %v, want %v", details, want)
+	}
+}
+
+func TestSignupRejectsRolesThatNeedAnInvite(t *testing.T) {
+	h := &AuthHandler{cfg: testutil.Config(t)}
+	h.cfg.App.SignupAllowedRoles = []string{"public", "institution_user", "institution_admin"}
+
+	w := serve(t, nil, http.MethodPost, "/auth/signup", "/auth/signup", map[string]interface{}{
+		"full_name":         "Asha Rao",
+		"email":             "asha@example.com",
+		"password":          "correct horse battery",
+		"organization_name": "Rao Audits",
+		"role":              "verifier",
+	}, h.Signup)
+	expectStatus(t, w, http.StatusForbidden, string(utils.CodeRoleRequiresInvite))
+}
+
+func TestTwoFactorEnableVerifyAndLogin(t *testing.T) {
+	env := newHandlerEnv(t)
+	cfg := *env.cfg
+	cfg.Email.SMTPHost = "127.0.0.1"
+	cfg.Email.SMTPPort = 1
+	cfg.Email.SMTPTimeout = time.Second
+	cfg.Email.RetryMaxAttempts = 1
+	emails := services.NewEmailService(&cfg, env.bills, nil, env.users, nil)
+	h := &AuthHandler{
+		userRepo:      env.users,
+		loginActivity: services.NewLoginActivityService(repository.NewLoginHistoryRepository(env.db.DB), emails, geoip.NewNoopLocator()),
+		twoFactor:     services.NewTwoFactorService(env.db, env.users, repository.NewRecoveryCodeRepository(env.db.DB), bytes.Repeat([]byte{7}, 32)),
+		jwtKeys:       utils.NewJWTKeySet("HS256", "", "test-secret", nil),
+		cfg:           env.cfg,
+	}
+
+	const password = "correct horse battery"
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	env.db.MustExec("UPDATE users SET password_hash = $1 WHERE id = $2", hash, user.ID)
+
+	login := func(t *testing.T, totpCode, recoveryCode string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := models.LoginRequest{Email: user.Email, Password: password, TOTPCode: totpCode, RecoveryCode: recoveryCode}
+		return serve(t, nil, http.MethodPost, "/login", "/login", req, h.Login)
+	}
+	code := func(t *testing.T, secret string, step int64) string {
+		t.Helper()
+		c, err := utils.TOTPCode(secret, step)
+		if err != nil {
+			t.Fatalf("TOTPCode failed: %v", err)
+		}
+		return c
+	}
+
+	// Enable needs the password
+	w := serve(t, user, http.MethodPost, "/2fa/enable", "/2fa/enable", map[string]string{"password": "wrong password"}, h.EnableTwoFactor)
+	expectStatus(t, w, http.StatusUnauthorized, string(utils.CodeInvalidCredentials))
+
+	w = serve(t, user, http.MethodPost, "/2fa/enable", "/2fa/enable", map[string]string{"password": password}, h.EnableTwoFactor)
+	expectStatus(t, w, http.StatusOK, "")
+	setup := decode(t, w)["data"].(map[string]interface{})
+	secret, _ := setup["secret"].(string)
+	if secret == "" || !strings.HasPrefix(setup["otpauth_url"].(string), "otpauth://totp/") || !strings.HasPrefix(setup["qr_code"].(string), "data:image/png;base64,") {
+		t.Fatalf("setup = %v, want a secret, otpauth URL and QR code", setup)
+	}
+	var stored string
+	if err := env.db.Get(&stored, "SELECT two_factor_secret FROM users WHERE id = $1", user.ID); err != nil || stored == secret {
+		t.Errorf("stored secret = %q, %v; want it encrypted", stored, err)
+	}
+
+	// Not active until verified
+	expectStatus(t, login(t, "", ""), http.StatusOK, "")
+
+	w = serve(t, user, http.MethodPost, "/2fa/verify", "/2fa/verify", map[string]string{"code": "000000"}, h.VerifyTwoFactor)
+	expectStatus(t, w, http.StatusBadRequest, string(utils.CodeInvalidTwoFactorCode))
+
+	step := utils.TOTPStep(time.Now())
+	w = serve(t, user, http.MethodPost, "/2fa/verify", "/2fa/verify", map[string]string{"code": code(t, secret, step)}, h.VerifyTwoFactor)
+	expectStatus(t, w, http.StatusOK, "")
+	recoveryCodes, _ := decode(t, w)["data"].(map[string]interface{})["recovery_codes"].([]interface{})
+	if len(recoveryCodes) == 0 {
+		t.Fatal("no recovery codes returned")
+	}
+
+	expectStatus(t, login(t, "", ""), http.StatusUnauthorized, string(utils.CodeTwoFactorRequired))
+	expectStatus(t, login(t, "000000", ""), http.StatusUnauthorized, string(utils.CodeInvalidTwoFactorCode))
+	// The code used to verify can't be replayed
+	expectStatus(t, login(t, code(t, secret, step), ""), http.StatusUnauthorized, string(utils.CodeInvalidTwoFactorCode))
+	expectStatus(t, login(t, code(t, secret, step+1), ""), http.StatusOK, "")
+
+	// Each recovery code works once
+	recovery := recoveryCodes[0].(string)
+	expectStatus(t, login(t, "", recovery), http.StatusOK, "")
+	expectStatus(t, login(t, "", recovery), http.StatusUnauthorized, string(utils.CodeInvalidTwoFactorCode))
+}
+
+func TestTwoFactorNotAvailableForInstitutions(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := &AuthHandler{
+		userRepo:  env.users,
+		twoFactor: services.NewTwoFactorService(env.db, env.users, repository.NewRecoveryCodeRepository(env.db.DB), bytes.Repeat([]byte{7}, 32)),
+		cfg:       env.cfg,
+	}
+
+	const password = "correct horse battery"
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	user := testutil.CreateUser(t, env.db, models.RoleInstitutionAdmin, 0)
+	env.db.MustExec("UPDATE users SET password_hash = $1 WHERE id = $2", hash, user.ID)
+
+	w := serve(t, user, http.MethodPost, "/2fa/enable", "/2fa/enable", map[string]string{"password": password}, h.EnableTwoFactor)
+	expectStatus(t, w, http.StatusForbidden, string(utils.CodeTwoFactorNotAvailable))
+}
+
+func TestLoginTokenExpiryPerRole(t *testing.T) {
+	env := newHandlerEnv(t)
+	cfg := *env.cfg
+	cfg.Email.SMTPHost = "127.0.0.1"
+	cfg.Email.SMTPPort = 1
+	cfg.Email.SMTPTimeout = time.Second
+	cfg.Email.RetryMaxAttempts = 1
+	cfg.JWT.AccessTokenExpiry = time.Hour
+	cfg.JWT.RoleAccessTokenExpiry = map[string]time.Duration{"master_admin": 5 * time.Minute, "institution_admin": 4 * time.Hour}
+	cfg.JWT.RoleRefreshTokenExpiry = map[string]time.Duration{"master_admin": 12 * time.Hour}
+	keys := utils.NewJWTKeySet("HS256", "", "test-secret", nil)
+	emails := services.NewEmailService(&cfg, env.bills, nil, env.users, nil)
+	h := &AuthHandler{
+		userRepo:      env.users,
+		loginActivity: services.NewLoginActivityService(repository.NewLoginHistoryRepository(env.db.DB), emails, geoip.NewNoopLocator()),
+		jwtKeys:       keys,
+		cfg:           &cfg,
+	}
+
+	const password = "correct horse battery"
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	// login signs in as a new user with role and returns expires_in and when each token expires
+	login := func(t *testing.T, role models.UserRole) (expiresIn time.Duration, access, refresh time.Time) {
+		t.Helper()
+		user := testutil.CreateUser(t, env.db, role, 0)
+		env.db.MustExec("UPDATE users SET password_hash = $1 WHERE id = $2", hash, user.ID)
+
+		w := serve(t, nil, http.MethodPost, "/login", "/login", models.LoginRequest{Email: user.Email, Password: password}, h.Login)
+		expectStatus(t, w, http.StatusOK, "")
+		data := decode(t, w)["data"].(map[string]interface{})
+
+		claims, err := utils.ValidateToken(data["access_token"].(string), keys)
+		if err != nil {
+			t.Fatalf("invalid access token: %v", err)
+		}
+		refreshClaims, err := utils.ParseRefreshToken(data["refresh_token"].(string), keys)
+		if err != nil {
+			t.Fatalf("invalid refresh token: %v", err)
+		}
+		return time.Duration(data["expires_in"].(float64)) * time.Second, claims.ExpiresAt.Time, refreshClaims.ExpiresAt.Time
+	}
+
+	adminExpiresIn, adminAccess, adminRefresh := login(t, models.RoleMasterAdmin)
+	institutionExpiresIn, institutionAccess, institutionRefresh := login(t, models.RoleInstitutionAdmin)
+	verifierExpiresIn, _, _ := login(t, models.RoleVerifier)
+
+	if adminExpiresIn != 5*time.Minute || institutionExpiresIn != 4*time.Hour || verifierExpiresIn != time.Hour {
+		t.Errorf("expires_in = %v admin, %v institution, %v verifier; want 5m, 4h and the 1h default",
+			adminExpiresIn, institutionExpiresIn, verifierExpiresIn)
+	}
+	if !adminAccess.Before(institutionAccess) {
+		t.Errorf("admin access token expires at %v, want before the institution's %v", adminAccess, institutionAccess)
+	}
+	if until := time.Until(adminAccess); until > 5*time.Minute || until < 4*time.Minute {
+		t.Errorf("admin access token expires in %v, want about 5m", until)
+	}
+	if !adminRefresh.Before(institutionRefresh) {
+		t.Errorf("admin refresh token expires at %v, want before the institution's %v", adminRefresh, institutionRefresh)
+	}
+}
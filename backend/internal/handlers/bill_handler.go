@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ezhilnn/epr-backend/internal/models"
@@ -14,13 +17,17 @@ import (
 
 // BillHandler handles bill-related requests
 type BillHandler struct {
-	billService *services.BillService
+	billService     *services.BillService
+	transferService *services.BillTransferService
+	loc             *time.Location // APP_TIMEZONE
 }
 
 // NewBillHandler creates a new bill handler
-func NewBillHandler(billService *services.BillService) *BillHandler {
+func NewBillHandler(billService *services.BillService, transferService *services.BillTransferService, loc *time.Location) *BillHandler {
 	return &BillHandler{
-		billService: billService,
+		billService:     billService,
+		transferService: transferService,
+		loc:             loc,
 	}
 }
 
@@ -31,27 +38,84 @@ func (h *BillHandler) CreateBill(c *gin.Context) {
 
 	var req models.CreateBillRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, err.Error())
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Optional Idempotency-Key lets clients safely retry after a timeout
+	idempotencyKey := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if len(idempotencyKey) > 255 {
+		utils.ValidationErrorResponse(c, "Idempotency-Key must be at most 255 characters")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	// Create bill
-	bill, err := h.billService.CreateBill(ctx, userID.(string), &req)
+	var (
+		bill     *models.Bill
+		replayed bool
+		err      error
+	)
+	if idempotencyKey != "" {
+		bill, replayed, err = h.billService.CreateBillIdempotent(ctx, userID.(string), idempotencyKey, &req)
+	} else {
+		bill, err = h.billService.CreateBill(ctx, userID.(string), &req)
+	}
 	if err != nil {
 		// Check for specific errors
-		if err.Error() == "only institutions can generate bills" {
-			utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+		var invalidData *services.BillDataValidationError
+		if errors.As(err, &invalidData) {
+			utils.ValidationErrorResponseWithCode(c, utils.CodeInvalidBillData, invalidData.Fields)
+			return
+		}
+		if errors.Is(err, services.ErrUnsupportedCurrency) {
+			utils.ValidationErrorResponseWithCode(c, utils.CodeUnsupportedCurrency, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrBillAmountTooLarge) {
+			utils.ValidationErrorResponseWithCode(c, utils.CodeBillAmountTooLarge, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrPreviewNotApplicable) {
+			utils.ValidationErrorResponseWithCode(c, utils.CodePreviewNotApplicable, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrIdempotencyKeyInUse) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeIdempotencyKeyInUse, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrIdempotencyKeyMismatch) {
+			utils.ErrorResponseWithCode(c, http.StatusUnprocessableEntity, utils.CodeIdempotencyMismatch, err.Error())
+			return
+		}
+		var duplicate *services.DuplicateBillError
+		if errors.As(err, &duplicate) {
+			// Include the existing bill so the client can show it instead of retrying
+			c.JSON(http.StatusConflict, gin.H{
+				"success":              false,
+				"code":                 utils.CodeDuplicateBill,
+				"error":                duplicate.Error(),
+				"existing_bill_id":     duplicate.BillID,
+				"existing_bill_number": duplicate.BillNumber,
+			})
+			return
+		}
+		if errors.Is(err, services.ErrNotInstitution) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotInstitution, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrKYCRequired) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeKYCRequired, "Your KYC verification is pending. Please complete KYC to generate bills.")
 			return
 		}
-		if err.Error() == "KYC verification required to generate bills" {
-			utils.ErrorResponse(c, http.StatusForbidden, "Your KYC verification is pending. Please complete KYC to generate bills.")
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeEmailNotVerified, "Please verify your email address to generate bills.")
 			return
 		}
-		if err.Error()[:20] == "insufficient wallet" {
-			utils.ErrorResponse(c, http.StatusPaymentRequired, err.Error())
+		if errors.Is(err, services.ErrInsufficientBalance) {
+			utils.ErrorResponseWithCode(c, http.StatusPaymentRequired, utils.CodeInsufficientBalance, err.Error())
 			return
 		}
 
@@ -62,10 +126,89 @@ func (h *BillHandler) CreateBill(c *gin.Context) {
 	// Convert to response
 	response := h.billService.ConvertToResponse(bill, "full")
 
-	utils.SuccessResponse(c, http.StatusCreated, gin.H{
+	// Retried request - return the original bill without charging again
+	if replayed {
+		utils.SuccessResponse(c, http.StatusOK, gin.H{
+			"message": "Bill already generated for this Idempotency-Key",
+			"bill":    response,
+		})
+		return
+	}
+
+	result := gin.H{
 		"message": "Bill generated successfully",
 		"bill":    response,
-	})
+	}
+	if bill.NotificationStatus != models.NotificationNotRequested {
+		result["notification_status"] = bill.NotificationStatus
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, result)
+}
+
+// BulkCreateBills generates many bills in one request with a single wallet charge
+// POST /api/v1/bills/bulk
+func (h *BillHandler) BulkCreateBills(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.BulkCreateBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	// Large batches insert hundreds of rows in one transaction
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	result, err := h.billService.CreateBillsBulk(ctx, userID.(string), req.Bills)
+	if err != nil {
+		var tooLarge *services.BulkBatchTooLargeError
+		if errors.As(err, &tooLarge) {
+			utils.ValidationErrorResponseWithCode(c, utils.CodeBulkBatchTooLarge, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrNotInstitution) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotInstitution, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrKYCRequired) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeKYCRequired, "Your KYC verification is pending. Please complete KYC to generate bills.")
+			return
+		}
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeEmailNotVerified, "Please verify your email address to generate bills.")
+			return
+		}
+		if errors.Is(err, services.ErrInsufficientBalance) {
+			utils.ErrorResponseWithCode(c, http.StatusPaymentRequired, utils.CodeInsufficientBalance, err.Error())
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate bills")
+		return
+	}
+
+	// Nothing valid to create - report the per-item errors
+	if result.Created == 0 {
+		utils.ValidationErrorResponse(c, result)
+		return
+	}
+
+	// Partial success still returns 201; per-item errors are in results
+	utils.SuccessResponse(c, http.StatusCreated, result)
+}
+
+// GetBillSchema returns the bill_data fields expected for a bill type
+// GET /api/v1/bills/schema/:bill_type
+func (h *BillHandler) GetBillSchema(c *gin.Context) {
+	schema, ok := models.SchemaFor(models.BillType(c.Param("bill_type")))
+	if !ok {
+		utils.ErrorResponse(c, http.StatusNotFound, "Unknown bill type")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, schema)
 }
 
 // GetBill retrieves a single bill
@@ -75,18 +218,18 @@ func (h *BillHandler) GetBill(c *gin.Context) {
 	role, _ := c.Get("role")
 	billID := c.Param("id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Get bill
 	bill, err := h.billService.GetBillByID(ctx, userID.(string), billID, models.UserRole(role.(string)))
 	if err != nil {
-		if err.Error() == "bill not found" {
-			utils.ErrorResponse(c, http.StatusNotFound, "Bill not found")
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
 			return
 		}
-		if err.Error() == "access denied to this bill" {
-			utils.ErrorResponse(c, http.StatusForbidden, "You don't have permission to view this bill")
+		if errors.Is(err, services.ErrBillAccessDenied) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeBillAccessDenied, "You don't have permission to view this bill")
 			return
 		}
 
@@ -105,58 +248,124 @@ func (h *BillHandler) GetBill(c *gin.Context) {
 }
 
 // ListBills lists bills for the current user
-// GET /api/v1/bills
+// GET /api/v1/bills?page=1 or ?cursor=<next_cursor>, plus &include_deleted=true for deleted bills
 func (h *BillHandler) ListBills(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	// Get pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	// Validate pagination
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
+	includeDeleted, ok := parseIncludeDeleted(c)
+	if !ok {
+		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Get pagination parameters
+	page, pageSize, _ := utils.ParsePagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
+	// Passing cursor (empty for the first page) switches to keyset pagination
+	if cursorParam, useCursor := c.GetQuery("cursor"); useCursor {
+		cursor, ok := parseCursorParam(c, cursorParam)
+		if !ok {
+			return
+		}
+
+		bills, next, err := h.billService.ListUserBillsAfter(ctx, userID.(string), includeDeleted, cursor, pageSize)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve bills")
+			return
+		}
+
+		billResponses, err := h.billService.ConvertToListResponses(ctx, bills)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification counts")
+			return
+		}
+
+		utils.SuccessResponse(c, http.StatusOK, gin.H{
+			"bills":      billResponses,
+			"pagination": cursorPagination(pageSize, next),
+		})
+		return
+	}
+
 	// Get bills
-	bills, total, err := h.billService.ListUserBills(ctx, userID.(string), page, pageSize)
+	bills, total, err := h.billService.ListUserBills(ctx, userID.(string), includeDeleted, page, pageSize)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve bills")
 		return
 	}
 
 	// Convert to list response
-	billResponses := make([]*models.BillListResponse, len(bills))
-	for i, bill := range bills {
-		billResponses[i] = h.billService.ConvertToListResponse(bill)
+	billResponses, err := h.billService.ConvertToListResponses(ctx, bills)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification counts")
+		return
 	}
 
-	// Calculate pagination metadata
-	totalPages := (total + pageSize - 1) / pageSize
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, gin.H{
-		"bills": billResponses,
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       total,
-			"total_pages": totalPages,
-		},
+		"bills":      billResponses,
+		"pagination": pagination,
 	})
 }
 
+// parseIncludeDeleted reads the include_deleted query flag, writing a validation error if it's malformed
+// Listings are always scoped to the caller's own bills, so only issuers see their deleted bills
+func parseIncludeDeleted(c *gin.Context) (bool, bool) {
+	param := c.Query("include_deleted")
+	if param == "" {
+		return false, true
+	}
+
+	value, err := strconv.ParseBool(param)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid include_deleted. Use true or false")
+		return false, false
+	}
+	return value, true
+}
+
+// parseCursorParam decodes the cursor query param, writing a validation error if it's malformed
+// An empty param means the first page
+func parseCursorParam(c *gin.Context, param string) (*models.PageCursor, bool) {
+	if param == "" {
+		return nil, true
+	}
+
+	cursor, err := models.DecodePageCursor(param)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid cursor")
+		return nil, false
+	}
+	return cursor, true
+}
+
+// cursorPagination builds the pagination metadata for a keyset-paginated response
+func cursorPagination(pageSize int, next *models.PageCursor) gin.H {
+	pagination := gin.H{
+		"page_size":     pageSize,
+		"max_page_size": utils.MaxPageSize,
+		"has_more":      next != nil,
+		"next_cursor":   nil,
+	}
+	if next != nil {
+		pagination["next_cursor"] = next.Encode()
+	}
+	return pagination
+}
+
 // GetBillStats retrieves statistics for user's bills
 // GET /api/v1/bills/stats
 func (h *BillHandler) GetBillStats(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	stats, err := h.billService.GetUserStats(ctx, userID.(string))
@@ -168,31 +377,80 @@ func (h *BillHandler) GetBillStats(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, stats)
 }
 
+// GetBillTrends returns how many bills the caller generated per day or week, and their total value
+// GET /api/v1/bills/trends?interval=week&weeks=12&running=true
+func (h *BillHandler) GetBillTrends(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	interval := c.DefaultQuery("interval", models.TrendIntervalDay)
+	var periods int
+	var err error
+	switch interval {
+	case models.TrendIntervalDay:
+		periods, err = strconv.Atoi(c.DefaultQuery("days", "30"))
+		if err != nil || periods < 1 || periods > maxTrendDays {
+			utils.ValidationErrorResponse(c, fmt.Sprintf("days must be between 1 and %d", maxTrendDays))
+			return
+		}
+	case models.TrendIntervalWeek:
+		periods, err = strconv.Atoi(c.DefaultQuery("weeks", "12"))
+		if err != nil || periods < 1 || periods > maxTrendDays/7 {
+			utils.ValidationErrorResponse(c, fmt.Sprintf("weeks must be between 1 and %d", maxTrendDays/7))
+			return
+		}
+	default:
+		utils.ValidationErrorResponse(c, "interval must be day or week")
+		return
+	}
+
+	running := c.Query("running") == "true"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	buckets, err := h.billService.GetBillTrends(ctx, userID.(string), interval, periods, running)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve bill trends")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"interval": interval,
+		"buckets":  buckets,
+	})
+}
+
 // DeleteBill soft deletes a bill
 // DELETE /api/v1/bills/:id
 func (h *BillHandler) DeleteBill(c *gin.Context) {
 	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
 	billID := c.Param("id")
+	force := c.Query("force") == "true" // Only honoured for master admins
 
 	var req struct {
 		Reason string `json:"reason" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, "Deletion reason is required")
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := h.billService.DeleteBill(ctx, userID.(string), billID, req.Reason); err != nil {
-		if err.Error() == "bill not found" {
-			utils.ErrorResponse(c, http.StatusNotFound, "Bill not found")
+	if err := h.billService.DeleteBill(ctx, userID.(string), models.UserRole(role.(string)), billID, req.Reason, force); err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
 			return
 		}
-		if err.Error() == "you can only delete your own bills" {
-			utils.ErrorResponse(c, http.StatusForbidden, "You don't have permission to delete this bill")
+		if errors.Is(err, services.ErrNotBillOwner) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotBillOwner, "You don't have permission to delete this bill")
+			return
+		}
+		if errors.Is(err, services.ErrBillVerifiedNoDelete) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeBillVerifiedNoDelete, "This bill has already been verified and can't be deleted. Contact support if it must be removed.")
 			return
 		}
 
@@ -205,25 +463,322 @@ func (h *BillHandler) DeleteBill(c *gin.Context) {
 	})
 }
 
+// RestoreBill undoes a soft delete
+// POST /api/v1/bills/:id/restore
+func (h *BillHandler) RestoreBill(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	billID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	bill, err := h.billService.RestoreBill(ctx, userID.(string), models.UserRole(role.(string)), billID)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
+			return
+		}
+		if errors.Is(err, services.ErrNotBillOwner) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotBillOwner, "You don't have permission to restore this bill")
+			return
+		}
+		if errors.Is(err, services.ErrBillNotDeleted) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeBillNotDeleted, "Bill is not deleted")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to restore bill")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Bill restored successfully",
+		"bill":    h.billService.ConvertToResponse(bill, "full"),
+	})
+}
+
+// VerifyDataHash checks a copy of a bill's data against its registered hash
+// Free, unlike a full verification - see BillService.VerifyDataHash
+// POST /api/v1/bills/:bill_number/verify-hash
+func (h *BillHandler) VerifyDataHash(c *gin.Context) {
+	billNumber := c.Param("id")
+
+	var req models.VerifyHashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	result, err := h.billService.VerifyDataHash(ctx, billNumber, req.BillData)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check bill data")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, result)
+}
+
+// CreateShortLink generates a new short verification link for a bill, replacing any previous one
+// POST /api/v1/bills/:id/short-link
+func (h *BillHandler) CreateShortLink(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	billID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	link, err := h.billService.CreateShortLink(ctx, userID.(string), billID)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
+			return
+		}
+		if errors.Is(err, services.ErrNotBillOwner) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotBillOwner, "You don't have permission to create a link for this bill")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create short link")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, link)
+}
+
+// UpdateBill corrects a bill, keeping the previous version as a revision
+// PUT /api/v1/bills/:id?force=true
+func (h *BillHandler) UpdateBill(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	billID := c.Param("id")
+	force := c.Query("force") == "true"
+
+	var req models.UpdateBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	if req.BillData == nil && req.Amount == nil {
+		utils.ValidationErrorResponse(c, "Provide bill_data and/or amount to update")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	bill, err := h.billService.UpdateBill(ctx, userID.(string), billID, &req, force)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
+			return
+		}
+		if errors.Is(err, services.ErrNotBillOwner) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotBillOwner, "You don't have permission to update this bill")
+			return
+		}
+		var invalidData *services.BillDataValidationError
+		if errors.As(err, &invalidData) {
+			utils.ValidationErrorResponseWithCode(c, utils.CodeInvalidBillData, invalidData.Fields)
+			return
+		}
+		if errors.Is(err, services.ErrBillAmountTooLarge) {
+			utils.ValidationErrorResponseWithCode(c, utils.CodeBillAmountTooLarge, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrBillHasVerifications) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeBillHasVerifications, "This bill has already been verified. Pass force=true to change its amount.")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update bill")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Bill updated successfully",
+		"bill":    h.billService.ConvertToResponse(bill, "full"),
+	})
+}
+
+// ListBillRevisions returns the previous versions of a bill
+// GET /api/v1/bills/:id/revisions
+func (h *BillHandler) ListBillRevisions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	billID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	revisions, err := h.billService.ListBillRevisions(ctx, userID.(string), billID)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
+			return
+		}
+		if errors.Is(err, services.ErrNotBillOwner) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotBillOwner, "Access denied")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve bill revisions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"revisions": revisions,
+		"total":     len(revisions),
+	})
+}
+
+// TransferBill moves a bill to another institution account
+// Master admins transfer at once (200); the issuer's request waits for the target to accept it (202)
+// POST /api/v1/bills/:id/transfer
+func (h *BillHandler) TransferBill(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	billID := c.Param("id")
+
+	var req models.TransferBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	transfer, err := h.transferService.RequestTransfer(ctx, userID.(string), models.UserRole(role.(string)), billID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
+			return
+		}
+		if errors.Is(err, services.ErrNotBillOwner) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotBillOwner, "You don't have permission to transfer this bill")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTransferTarget) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInvalidTransferTarget, err.Error())
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to transfer bill")
+		return
+	}
+
+	if transfer.Status == models.BillTransferPending {
+		utils.SuccessResponse(c, http.StatusAccepted, gin.H{
+			"message":  "Transfer requested. The receiving account has been emailed a link to accept it.",
+			"transfer": transfer,
+		})
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":  "Bill transferred successfully",
+		"transfer": transfer,
+	})
+}
+
+// ConfirmBillTransfer accepts a pending transfer sent to the logged-in account
+// POST /api/v1/bills/transfers/confirm
+func (h *BillHandler) ConfirmBillTransfer(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.ConfirmBillTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	transfer, err := h.transferService.ConfirmTransfer(ctx, userID.(string), req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrBillTransferInvalid) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeTransferInvalid, "Invalid or already used transfer link")
+			return
+		}
+		if errors.Is(err, services.ErrBillTransferExpired) {
+			utils.ErrorResponseWithCode(c, http.StatusGone, utils.CodeTransferExpired, "Transfer link has expired. Ask the issuer to send a new one.")
+			return
+		}
+		if errors.Is(err, services.ErrNotTransferRecipient) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotTransferRecipient, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTransferTarget) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeInvalidTransferTarget, err.Error())
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to accept bill transfer")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":  "Bill transferred to your account",
+		"transfer": transfer,
+	})
+}
+
+// ListBillTransfers returns a bill's ownership transfers, newest first
+// GET /api/v1/bills/id/:id/transfers
+func (h *BillHandler) ListBillTransfers(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	billID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	transfers, err := h.transferService.ListTransfers(ctx, userID.(string), models.UserRole(role.(string)), billID)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
+			return
+		}
+		if errors.Is(err, services.ErrNotBillOwner) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeNotBillOwner, "Access denied")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve bill transfers")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"transfers": transfers,
+		"total":     len(transfers),
+	})
+}
+
 // SearchBills searches bills with filters
 // GET /api/v1/bills/search
 func (h *BillHandler) SearchBills(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
+	includeDeleted, ok := parseIncludeDeleted(c)
+	if !ok {
+		return
+	}
+
 	// Get query parameters
+	text := strings.TrimSpace(c.Query("q"))
 	billTypeStr := c.Query("bill_type")
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	// Validate pagination
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
-	}
+	page, pageSize, _ := utils.ParsePagination(c)
 
 	// Parse bill type
 	var billType *models.BillType
@@ -245,25 +800,34 @@ func (h *BillHandler) SearchBills(c *gin.Context) {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Search bills
-	bills, err := h.billService.SearchBills(ctx, userID.(string), billType, startDate, endDate, page, pageSize)
+	bills, total, err := h.billService.SearchBills(ctx, userID.(string), text, billType, startDate, endDate, includeDeleted, page, pageSize)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to search bills")
 		return
 	}
 
 	// Convert to list response
-	billResponses := make([]*models.BillListResponse, len(bills))
-	for i, bill := range bills {
-		billResponses[i] = h.billService.ConvertToListResponse(bill)
+	billResponses, err := h.billService.ConvertToListResponses(ctx, bills)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification counts")
+		return
+	}
+
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, gin.H{
-		"bills": billResponses,
+		"bills":      billResponses,
+		"pagination": pagination,
 		"filters": gin.H{
+			"q":          text,
 			"bill_type":  billTypeStr,
 			"start_date": startDateStr,
 			"end_date":   endDateStr,
@@ -276,13 +840,13 @@ func (h *BillHandler) SearchBills(c *gin.Context) {
 func (h *BillHandler) VerifyBill(c *gin.Context) {
 	billNumber := c.Param("bill_number")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Get bill
 	bill, err := h.billService.GetBillByNumber(ctx, billNumber)
 	if err != nil {
-		if err.Error() == "bill not found" {
+		if errors.Is(err, services.ErrBillNotFound) {
 			utils.SuccessResponse(c, http.StatusOK, gin.H{
 				"exists": false,
 				"status": "not_found",
@@ -294,10 +858,21 @@ func (h *BillHandler) VerifyBill(c *gin.Context) {
 		return
 	}
 
+	status := "valid"
+	if bill.IsExpired(time.Now().In(h.loc)) {
+		status = "expired"
+	}
+	var validUntil *string
+	if bill.ValidUntil != nil {
+		vu := bill.ValidUntil.Format("2006-01-02")
+		validUntil = &vu
+	}
+
 	// Return limited public information
 	utils.SuccessResponse(c, http.StatusOK, gin.H{
 		"exists":            true,
-		"status":            "valid",
+		"status":            status,
+		"valid_until":       validUntil,
 		"bill_number":       bill.BillNumber,
 		"bill_type":         string(bill.BillType),
 		"issuer_name":       bill.IssuerName,
@@ -312,12 +887,12 @@ func (h *BillHandler) VerifyBill(c *gin.Context) {
 func (h *BillHandler) GetBillByNumber(c *gin.Context) {
 	billNumber := c.Param("bill_number")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	bill, err := h.billService.GetBillByNumber(ctx, billNumber)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Bill not found")
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
 		return
 	}
 
@@ -326,27 +901,51 @@ func (h *BillHandler) GetBillByNumber(c *gin.Context) {
 }
 
 // DownloadBillQR returns QR code for a bill
-// GET /api/v1/bills/:id/qrcode
+// GET /api/v1/bills/:id/qrcode?format=png&size=512
+// format=png returns the image as a file; the default is a base64 data URL in JSON
 func (h *BillHandler) DownloadBillQR(c *gin.Context) {
 	billID := c.Param("id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "png" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "format must be json or png")
+		return
+	}
+
+	size := 0 // Configured default
+	if sizeStr := c.Query("size"); sizeStr != "" {
+		parsed, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "size must be a number of pixels")
+			return
+		}
+		size = parsed // Clamped when the image is generated
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	bill, err := h.billService.GetBillByID(ctx, "", billID, models.RoleMasterAdmin)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Bill not found")
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
 		return
 	}
 
-	qrCode, err := h.billService.GenerateQRCode(bill.BillNumber)
+	// Prefer the bill's short code so the QR doesn't expose the bill number
+	qrCode, err := h.billService.GenerateQRCodePNG(h.billService.VerificationRef(ctx, bill), size)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate QR")
 		return
 	}
 
+	if format == "png" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-qr.png", bill.BillNumber))
+		c.Data(http.StatusOK, "image/png", qrCode)
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, gin.H{
 		"bill_number": bill.BillNumber,
-		"qr_code":     qrCode,
+		"qr_code":     utils.QRCodeDataURL(qrCode),
 	})
 }
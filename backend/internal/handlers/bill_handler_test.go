@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// unknownID is a well-formed UUID no row has
+const unknownID = "00000000-0000-0000-0000-000000000000"
+
+func TestBillHandlersMapSentinelErrors(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewBillHandler(env.billService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	public := testutil.CreateUser(t, env.db, models.RolePublic, 100)
+	broke := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	pendingKYC := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	env.db.MustExec("UPDATE users SET kyc_status = 'pending' WHERE id = $1", pendingKYC.ID)
+
+	restricted := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	env.db.MustExec("UPDATE bills SET access_level = 'restricted' WHERE id = $1", restricted.ID)
+
+	createBody := map[string]interface{}{
+		"bill_type":    "other",
+		"access_level": "public",
+		"amount":       250,
+		"issue_date":   time.Now().Format("2006-01-02"),
+		"bill_data":    map[string]interface{}{"recipient_name": "Asha Rao"},
+	}
+	deleteBody := map[string]interface{}{"reason": "issued by mistake"}
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		method string
+		route  string
+		path   string
+		body   interface{}
+		status int
+		code   utils.ErrorCode
+	}{
+		{"create as non-institution", public, http.MethodPost, "/bills", "/bills", createBody, http.StatusForbidden, utils.CodeNotInstitution},
+		{"create with pending KYC", pendingKYC, http.MethodPost, "/bills", "/bills", createBody, http.StatusForbidden, utils.CodeKYCRequired},
+		{"create with empty wallet", broke, http.MethodPost, "/bills", "/bills", createBody, http.StatusPaymentRequired, utils.CodeInsufficientBalance},
+		{"get unknown bill", issuer, http.MethodGet, "/bills/id/:id", "/bills/id/" + unknownID, nil, http.StatusNotFound, utils.CodeBillNotFound},
+		{"get restricted bill as public", public, http.MethodGet, "/bills/id/:id", "/bills/id/" + restricted.ID, nil, http.StatusForbidden, utils.CodeBillAccessDenied},
+		{"delete unknown bill", issuer, http.MethodDelete, "/bills/id/:id", "/bills/id/" + unknownID, deleteBody, http.StatusNotFound, utils.CodeBillNotFound},
+		{"delete another issuer's bill", other, http.MethodDelete, "/bills/id/:id", "/bills/id/" + restricted.ID, deleteBody, http.StatusForbidden, utils.CodeNotBillOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := h.CreateBill
+			switch tt.method {
+			case http.MethodGet:
+				handler = h.GetBill
+			case http.MethodDelete:
+				handler = h.DeleteBill
+			}
+
+			w := serve(t, tt.user, tt.method, tt.route, tt.path, tt.body, handler)
+			expectStatus(t, w, tt.status, string(tt.code))
+		})
+	}
+}
+
+func TestVerifyBillPublicNotFound(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewBillHandler(env.billService, nil, time.UTC)
+
+	w := serve(t, nil, http.MethodGet, "/bills/verify/:bill_number", "/bills/verify/NOPE-000000", nil, h.VerifyBill)
+	expectStatus(t, w, http.StatusOK, "")
+	if exists := decode(t, w)["data"].(map[string]interface{})["exists"]; exists != false {
+		t.Errorf("exists = %v, want false", exists)
+	}
+}
+
+func TestVerificationHandlerMapsInsufficientBalance(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewVerificationHandler(env.verificationService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+
+	w := serve(t, verifier, http.MethodPost, "/verify", "/verify", map[string]interface{}{"bill_number": bill.BillNumber}, h.VerifyBill)
+	expectStatus(t, w, http.StatusPaymentRequired, string(utils.CodeInsufficientBalance))
+}
+
+func TestDeleteAndRestoreBill(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewBillHandler(env.billService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+
+	deleteBill := func(t *testing.T) {
+		t.Helper()
+		w := serve(t, issuer, http.MethodDelete, "/bills/id/:id", "/bills/id/"+bill.ID, map[string]interface{}{"reason": "issued by mistake"}, h.DeleteBill)
+		expectStatus(t, w, http.StatusOK, "")
+	}
+	restore := func(user *models.User) *httptest.ResponseRecorder {
+		return serve(t, user, http.MethodPost, "/bills/:id/restore", "/bills/"+bill.ID+"/restore", nil, h.RestoreBill)
+	}
+	expectVerifiable := func(t *testing.T, want bool) {
+		t.Helper()
+		w := serve(t, nil, http.MethodGet, "/bills/verify/:bill_number", "/bills/verify/"+bill.BillNumber, nil, h.VerifyBill)
+		expectStatus(t, w, http.StatusOK, "")
+		if exists := decode(t, w)["data"].(map[string]interface{})["exists"]; exists != want {
+			t.Errorf("exists = %v, want %v", exists, want)
+		}
+	}
+
+	// A bill that isn't deleted can't be restored
+	expectStatus(t, restore(issuer), http.StatusConflict, string(utils.CodeBillNotDeleted))
+
+	deleteBill(t)
+	expectVerifiable(t, false)
+
+	// Only the issuer or a master admin can restore
+	expectStatus(t, restore(other), http.StatusForbidden, string(utils.CodeNotBillOwner))
+	expectVerifiable(t, false)
+
+	expectStatus(t, restore(issuer), http.StatusOK, "")
+	expectVerifiable(t, true)
+
+	deleteBill(t)
+	expectStatus(t, restore(admin), http.StatusOK, "")
+	expectVerifiable(t, true)
+	if n := testutil.Count(t, env.db, "audit_log", "target_id = $1 AND action = $2", bill.ID, models.AuditBillRestore); n != 1 {
+		t.Errorf("audit entries for the admin restore = %d, want 1", n)
+	}
+}
+
+func TestDeleteVerifiedBill(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewBillHandler(env.billService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	testutil.CreateVerification(t, env.db, bill, verifier)
+
+	deleteAs := func(user *models.User, query string) *httptest.ResponseRecorder {
+		return serve(t, user, http.MethodDelete, "/bills/id/:id", "/bills/id/"+bill.ID+query, map[string]interface{}{"reason": "issued by mistake"}, h.DeleteBill)
+	}
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		query  string
+		status int
+		code   utils.ErrorCode
+	}{
+		{"issuer", issuer, "", http.StatusConflict, utils.CodeBillVerifiedNoDelete},
+		{"issuer can't force", issuer, "?force=true", http.StatusConflict, utils.CodeBillVerifiedNoDelete},
+		{"master admin without force", admin, "", http.StatusConflict, utils.CodeBillVerifiedNoDelete},
+		{"master admin with force", admin, "?force=true", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, deleteAs(tt.user, tt.query), tt.status, string(tt.code))
+		})
+	}
+
+	if n := testutil.Count(t, env.db, "bills", "id = $1 AND is_deleted", bill.ID); n != 1 {
+		t.Errorf("forced delete left the bill undeleted")
+	}
+	if n := testutil.Count(t, env.db, "audit_log", "target_id = $1 AND action = $2", bill.ID, models.AuditBillDelete); n != 1 {
+		t.Errorf("audit entries for the forced delete = %d, want 1", n)
+	}
+}
+
+func TestSearchBillsPaginatesWithTotal(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewBillHandler(env.billService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	want := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		want[testutil.CreateBill(t, env.db, issuer, 100, nil).BillNumber] = true
+	}
+	// None of these match: another type, a deleted bill and another issuer's bill
+	for i := 0; i < 2; i++ {
+		medical := testutil.CreateBill(t, env.db, issuer, 100, nil)
+		env.db.MustExec("UPDATE bills SET bill_type = 'medical_bill' WHERE id = $1", medical.ID)
+	}
+	deleted := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	env.db.MustExec("UPDATE bills SET is_deleted = true, deleted_at = NOW() WHERE id = $1", deleted.ID)
+	testutil.CreateBill(t, env.db, other, 100, nil)
+
+	seen := map[string]bool{}
+	for page, wantCount := range []int{2, 2, 1} {
+		path := fmt.Sprintf("/bills/search?bill_type=other&page_size=2&page=%d", page+1)
+		w := serve(t, issuer, http.MethodGet, "/bills/search", path, nil, h.SearchBills)
+		expectStatus(t, w, http.StatusOK, "")
+
+		data := decode(t, w)["data"].(map[string]interface{})
+		pagination := data["pagination"].(map[string]interface{})
+		if pagination["total"] != float64(5) || pagination["total_pages"] != float64(3) || pagination["page"] != float64(page+1) {
+			t.Errorf("page %d pagination = %v, want total 5 over 3 pages", page+1, pagination)
+		}
+
+		bills := data["bills"].([]interface{})
+		if len(bills) != wantCount {
+			t.Errorf("page %d has %d bills, want %d", page+1, len(bills), wantCount)
+		}
+		for _, b := range bills {
+			seen[b.(map[string]interface{})["bill_number"].(string)] = true
+		}
+	}
+
+	if len(seen) != len(want) {
+		t.Errorf("pages returned %d distinct bills, want %d", len(seen), len(want))
+	}
+	for number := range seen {
+		if !want[number] {
+			t.Errorf("search returned unexpected bill %s", number)
+		}
+	}
+}
+
+func TestDownloadBillQR(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewBillHandler(env.billService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	link := utils.GenerateVerificationLink(bill.BillNumber, env.cfg.App.FrontendURL)
+
+	download := func(query string) *httptest.ResponseRecorder {
+		return serve(t, issuer, http.MethodGet, "/bills/id/:id/qrcode", "/bills/id/"+bill.ID+"/qrcode"+query, nil, h.DownloadBillQR)
+	}
+
+	t.Run("png", func(t *testing.T) {
+		w := download("?format=png&size=512")
+		expectStatus(t, w, http.StatusOK, "")
+
+		if got := w.Header().Get("Content-Type"); got != "image/png" {
+			t.Errorf("Content-Type = %q, want image/png", got)
+		}
+		if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment;") {
+			t.Errorf("Content-Disposition = %q, want an attachment", got)
+		}
+
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("body is not an image: %v", err)
+		}
+		if cfg.Width != 512 {
+			t.Errorf("image width = %d, want 512", cfg.Width)
+		}
+
+		decoded, err := utils.DecodeQRCode(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to decode QR code: %v", err)
+		}
+		if decoded != link {
+			t.Errorf("QR code links to %q, want %q", decoded, link)
+		}
+	})
+
+	t.Run("json by default", func(t *testing.T) {
+		w := download("")
+		expectStatus(t, w, http.StatusOK, "")
+
+		dataURL, _ := decode(t, w)["data"].(map[string]interface{})["qr_code"].(string)
+		png, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(dataURL, "data:image/png;base64,"))
+		if err != nil {
+			t.Fatalf("qr_code is not a base64 PNG data URL: %v", err)
+		}
+		decoded, err := utils.DecodeQRCode(bytes.NewReader(png))
+		if err != nil {
+			t.Fatalf("failed to decode QR code: %v", err)
+		}
+		if decoded != link {
+			t.Errorf("QR code links to %q, want %q", decoded, link)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		expectStatus(t, download("?format=gif"), http.StatusBadRequest, "")
+		expectStatus(t, download("?format=png&size=big"), http.StatusBadRequest, "")
+	})
+}
+
+func TestGetBillSchema(t *testing.T) {
+	h := &BillHandler{}
+
+	w := serve(t, nil, http.MethodGet, "/bills/schema/:bill_type", "/bills/schema/medical_bill", nil, h.GetBillSchema)
+	expectStatus(t, w, http.StatusOK, "")
+	data := decode(t, w)["data"].(map[string]interface{})
+	if data["bill_type"] != "medical_bill" {
+		t.Errorf("bill_type = %v, want medical_bill", data["bill_type"])
+	}
+	required := map[string]bool{}
+	for _, f := range data["fields"].([]interface{}) {
+		field := f.(map[string]interface{})
+		if field["required"] == true {
+			required[field["name"].(string)] = true
+		}
+	}
+	if len(required) != 1 || !required["patient_name"] {
+		t.Errorf("required fields = %v, want patient_name", required)
+	}
+
+	w = serve(t, nil, http.MethodGet, "/bills/schema/:bill_type", "/bills/schema/utility_bill", nil, h.GetBillSchema)
+	expectStatus(t, w, http.StatusNotFound, "")
+}
+
+func TestCreateBillValidationErrors(t *testing.T) {
+	h := &BillHandler{}
+	issuer := &models.User{ID: unknownID, Role: models.RoleInstitutionUser}
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			"missing and out of range fields",
+			map[string]interface{}{"bill_type": "other", "amount": -5},
+			map[string]interface{}{
+				"access_level": "is required",
+				"amount":       "must be greater than 0",
+				"issue_date":   "is required",
+				"bill_data":    "is required",
+			},
+		},
+		{
+			"wrong JSON type",
+			map[string]interface{}{"bill_type": "other", "amount": "ten"},
+			map[string]interface{}{"amount": "must be a number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, issuer, http.MethodPost, "/bills", "/bills", tt.body, h.CreateBill)
+			expectStatus(t, w, http.StatusBadRequest, string(utils.CodeValidationFailed))
+
+			details, _ := decode(t, w)["details"].(map[string]interface{})
+			for field, message := range tt.want {
+				if details[field] != message {
+					t.Errorf("details[%q] = %v, want %q", field, details[field], message)
+				}
+			}
+			if strings.Contains(w.Body.String(), "CreateBillRequest") {
+				t.Errorf("response leaks the request struct name: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListBillsIncludeDeleted(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewBillHandler(env.billService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	active := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	deleted := testutil.CreateBill(t, env.db, issuer, 200, nil)
+	othersDeleted := testutil.CreateBill(t, env.db, other, 300, nil)
+	for _, bill := range []*models.Bill{deleted, othersDeleted} {
+		if err := env.bills.SoftDelete(context.Background(), bill.ID, "issued in error"); err != nil {
+			t.Fatalf("SoftDelete failed: %v", err)
+		}
+	}
+
+	// list returns the bills a listing or search finds, by ID
+	list := func(t *testing.T, user *models.User, route, query string, handler gin.HandlerFunc) map[string]map[string]interface{} {
+		t.Helper()
+		w := serve(t, user, http.MethodGet, route, route+query, nil, handler)
+		expectStatus(t, w, http.StatusOK, "")
+
+		found := map[string]map[string]interface{}{}
+		for _, b := range decode(t, w)["data"].(map[string]interface{})["bills"].([]interface{}) {
+			bill := b.(map[string]interface{})
+			found[bill["id"].(string)] = bill
+		}
+		return found
+	}
+
+	for _, tt := range []struct {
+		route   string
+		handler gin.HandlerFunc
+	}{
+		{"/bills", h.ListBills},
+		{"/bills/search", h.SearchBills},
+	} {
+		t.Run(tt.route, func(t *testing.T) {
+			if found := list(t, issuer, tt.route, "", tt.handler); len(found) != 1 || found[active.ID] == nil {
+				t.Errorf("default listing found %d bills, want only the active one", len(found))
+			}
+
+			found := list(t, issuer, tt.route, "?include_deleted=true", tt.handler)
+			if len(found) != 2 || found[active.ID] == nil || found[deleted.ID] == nil {
+				t.Fatalf("listing with deleted bills found %d bills, want the issuer's 2", len(found))
+			}
+			if bill := found[deleted.ID]; bill["status"] != "deleted" || bill["deletion_reason"] != "issued in error" || bill["deleted_at"] == nil {
+				t.Errorf("deleted bill = %v, want its status, deletion reason and time", bill)
+			}
+			if bill := found[active.ID]; bill["deletion_reason"] != nil || bill["deleted_at"] != nil {
+				t.Errorf("active bill has deletion details: %v", bill)
+			}
+
+			// Another issuer only ever sees their own deleted bills
+			if found := list(t, other, tt.route, "?include_deleted=true", tt.handler); len(found) != 1 || found[othersDeleted.ID] == nil {
+				t.Errorf("other issuer found %d bills, want only their own deleted one", len(found))
+			}
+
+			w := serve(t, issuer, http.MethodGet, tt.route, tt.route+"?include_deleted=maybe", nil, tt.handler)
+			expectStatus(t, w, http.StatusBadRequest, string(utils.CodeValidationFailed))
+		})
+	}
+}
+
+func TestVerifyDataHash(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewBillHandler(env.billService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	billData := map[string]interface{}{"recipient_name": "Asha Rao", "description": "Consulting", "hours": 12.0}
+	bill := testutil.CreateBill(t, env.db, issuer, 100, billData)
+
+	tampered := map[string]interface{}{}
+	for k, v := range billData {
+		tampered[k] = v
+	}
+	tampered["hours"] = 120.0
+
+	tests := []struct {
+		name      string
+		billData  map[string]interface{}
+		wantMatch bool
+	}{
+		{"matching data", billData, true},
+		{"tampered data", tampered, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Anyone may check, without signing in or paying
+			w := serve(t, nil, http.MethodPost, "/bills/:id/verify-hash", "/bills/"+bill.BillNumber+"/verify-hash",
+				map[string]interface{}{"bill_data": tt.billData}, h.VerifyDataHash)
+			expectStatus(t, w, http.StatusOK, "")
+
+			data := decode(t, w)["data"].(map[string]interface{})
+			if data["match"] != tt.wantMatch {
+				t.Errorf("match = %v, want %v", data["match"], tt.wantMatch)
+			}
+			if data["stored_hash"] != bill.DataHash {
+				t.Errorf("stored_hash = %v, want %s", data["stored_hash"], bill.DataHash)
+			}
+			if matched := data["computed_hash"] == bill.DataHash; matched != tt.wantMatch {
+				t.Errorf("computed_hash = %v, stored %s", data["computed_hash"], bill.DataHash)
+			}
+		})
+	}
+
+	t.Run("unknown bill", func(t *testing.T) {
+		w := serve(t, nil, http.MethodPost, "/bills/:id/verify-hash", "/bills/EPR-OTH-1999-999999/verify-hash",
+			map[string]interface{}{"bill_data": billData}, h.VerifyDataHash)
+		expectStatus(t, w, http.StatusNotFound, "")
+	})
+}
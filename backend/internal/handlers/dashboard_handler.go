@@ -32,7 +32,7 @@ func NewDashboardHandler(
 func (h *DashboardHandler) GetPublicDashboard(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Get verification stats
@@ -69,7 +69,7 @@ func (h *DashboardHandler) GetPublicDashboard(c *gin.Context) {
 func (h *DashboardHandler) GetInstitutionDashboard(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Get bill stats (PRIMARY FOCUS)
@@ -80,16 +80,17 @@ func (h *DashboardHandler) GetInstitutionDashboard(c *gin.Context) {
 	}
 
 	// Get recent bills (last 5)
-	recentBills, _, err := h.billService.ListUserBills(ctx, userID.(string), 1, 5)
+	recentBills, _, err := h.billService.ListUserBills(ctx, userID.(string), false, 1, 5)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve recent bills")
 		return
 	}
 
 	// Convert bills to list response format
-	recentBillsResponse := make([]interface{}, len(recentBills))
-	for i, bill := range recentBills {
-		recentBillsResponse[i] = h.billService.ConvertToListResponse(bill)
+	recentBillsResponse, err := h.billService.ConvertToListResponses(ctx, recentBills)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve recent bills")
+		return
 	}
 
 	// Calculate additional metrics
@@ -98,10 +99,15 @@ func (h *DashboardHandler) GetInstitutionDashboard(c *gin.Context) {
 		averageBillAmount = billStats.TotalAmount / float64(billStats.TotalBills)
 	}
 
-	// Most generated bill type (simplified - returns first bill type found)
+	// Most generated bill type and per-type breakdown for the chart
+	topType, billTypeBreakdown, err := h.billService.GetBillTypeBreakdown(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve dashboard data")
+		return
+	}
 	mostGeneratedType := "N/A"
-	if len(recentBills) > 0 {
-		mostGeneratedType = string(recentBills[0].BillType)
+	if topType != "" {
+		mostGeneratedType = string(topType)
 	}
 
 	// Calculate generation fee paid (₹0.50 per bill)
@@ -120,7 +126,8 @@ func (h *DashboardHandler) GetInstitutionDashboard(c *gin.Context) {
 			// Verification count is secondary (how many times their bills were verified)
 			"total_verifications": billStats.TotalVerifications,
 		},
-		"recent_bills": recentBillsResponse,
+		"bill_type_breakdown": billTypeBreakdown,
+		"recent_bills":        recentBillsResponse,
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, response)
@@ -131,7 +138,7 @@ func (h *DashboardHandler) GetInstitutionDashboard(c *gin.Context) {
 func (h *DashboardHandler) GetVerifierDashboard(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Get verification stats
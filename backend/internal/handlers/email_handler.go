@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -22,27 +23,45 @@ func NewEmailHandler(emailService *services.EmailService) *EmailHandler {
 	}
 }
 
+// SendDailySummary emails the caller today's bill summary immediately
+// POST /api/v1/bills/summary/send
+func (h *EmailHandler) SendDailySummary(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	if err := h.emailService.SendDailyBillSummary(ctx, userID.(string)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to send daily summary. Please try again.")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Daily summary sent. No email is sent if no bills were issued today.",
+	})
+}
+
 // SendBillEmail sends a bill via email
 // POST /api/v1/bills/:bill_number/email
 func (h *EmailHandler) SendBillEmail(c *gin.Context) {
-	billNumber := c.Param("bill_number")
+	billNumber := c.Param("id")
 	
 	var req struct {
 		Email string `json:"email" binding:"required,email"`
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, "Valid email address is required")
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 	
 	// Send email with bill attachment
 	if err := h.emailService.SendBillEmail(ctx, billNumber, req.Email); err != nil {
-		if err.Error() == "bill not found: bill not found" {
-			utils.ErrorResponse(c, http.StatusNotFound, "Bill not found")
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
 			return
 		}
 		
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestSendDailySummaryTrigger(t *testing.T) {
+	env := newHandlerEnv(t)
+
+	// The SMTP server refuses connections, so the trigger fails exactly when it tries to send
+	cfg := *env.cfg
+	cfg.Email.SMTPHost = "127.0.0.1"
+	cfg.Email.SMTPPort = 1
+	cfg.Email.SMTPTimeout = time.Second
+	cfg.Email.RetryMaxAttempts = 1
+	h := NewEmailHandler(services.NewEmailService(&cfg, env.bills, repository.NewBillAliasRepository(env.db.DB), env.users, nil))
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	trigger := func() int {
+		return serve(t, issuer, http.MethodPost, "/bills/summary/send", "/bills/summary/send", nil, h.SendDailySummary).Code
+	}
+
+	// Nothing issued today, so there's nothing to send
+	if status := trigger(); status != http.StatusOK {
+		t.Errorf("status without bills = %d, want %d", status, http.StatusOK)
+	}
+
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	env.db.MustExec("UPDATE bills SET issue_date = $1 WHERE id = $2", time.Now().In(env.cfg.Location()).Format("2006-01-02"), bill.ID)
+	if status := trigger(); status != http.StatusInternalServerError {
+		t.Errorf("status with a bill and no mail server = %d, want %d", status, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+// handlerEnv wires real services against the test database, without Redis or email
+type handlerEnv struct {
+	db  *database.DB
+	cfg *config.Config
+
+	users         *repository.UserRepository
+	bills         *repository.BillRepository
+	verifications *repository.VerificationRepository
+
+	billService         *services.BillService
+	verificationService *services.VerificationService
+}
+
+// newHandlerEnv returns a handler test environment on an empty database, or skips the test without one
+func newHandlerEnv(t *testing.T) *handlerEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.DB(t)
+	cfg := testutil.Config(t)
+
+	users := repository.NewUserRepository(db.DB)
+	bills := repository.NewBillRepository(db.DB, nil, 0)
+	aliases := repository.NewBillAliasRepository(db.DB)
+	verifications := repository.NewVerificationRepository(db.DB)
+	walletTxs := repository.NewWalletTransactionRepository(db.DB)
+	outbox := repository.NewOutboxRepository(db.DB)
+	audit := repository.NewAuditRepository(db.DB)
+	counters := repository.NewActivityCounterRepository(nil)
+
+	return &handlerEnv{
+		db:            db,
+		cfg:           cfg,
+		users:         users,
+		bills:         bills,
+		verifications: verifications,
+		billService: services.NewBillService(db, bills, aliases, users, verifications, walletTxs,
+			repository.NewIdempotencyKeyRepository(db.DB), outbox, audit, nil, cfg),
+		verificationService: services.NewVerificationService(db, verifications, bills, aliases, users, walletTxs, outbox, counters,
+			services.NewSuspiciousActivityDetector(counters, cfg), services.NewFakeBillAlerter(db, counters, outbox, cfg), nil, cfg),
+	}
+}
+
+// asUser stands in for AuthMiddleware, identifying the caller as user; nil leaves the request anonymous
+func asUser(user *models.User) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user == nil {
+			return
+		}
+		c.Set("user_id", user.ID)
+		c.Set("email", user.Email)
+		c.Set("role", string(user.Role))
+		c.Set("email_verified", user.IsEmailVerified)
+	}
+}
+
+// serve registers handlers (middleware first) on route, sends one request to path as user and returns the recorded response
+// body is encoded as JSON unless nil
+func serve(t *testing.T, user *models.User, method, route, path string, body interface{}, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+
+	router := gin.New()
+	router.Handle(method, route, append([]gin.HandlerFunc{asUser(user)}, handlers...)...)
+
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.RemoteAddr = "192.0.2.10:4321"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decode parses a JSON response body
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, w.Body.String())
+	}
+	return body
+}
+
+// expectStatus fails the test unless w has the given status and, when code is set, error code
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	if code == "" {
+		return
+	}
+	if got := decode(t, w)["code"]; got != code {
+		t.Errorf("code = %v, want %s", got, code)
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// KYCHandler handles KYC submission and review requests
+type KYCHandler struct {
+	kycService *services.KYCService
+}
+
+// NewKYCHandler creates a new KYC handler
+func NewKYCHandler(kycService *services.KYCService) *KYCHandler {
+	return &KYCHandler{
+		kycService: kycService,
+	}
+}
+
+// SubmitKYC stores the institution's KYC documents for review
+// POST /api/v1/kyc/submit
+func (h *KYCHandler) SubmitKYC(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.SubmitKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.kycService.Submit(ctx, userID.(string), req.Documents)
+	if err != nil {
+		if errors.Is(err, services.ErrKYCNotApplicable) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeKYCNotApplicable, "KYC is only required for institutions")
+			return
+		}
+		if errors.Is(err, services.ErrKYCAlreadyApproved) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeKYCAlreadyApproved, "Your KYC is already approved")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to submit KYC")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":    "KYC documents submitted. You will be notified by email once they are reviewed.",
+		"kyc_status": user.KYCStatus,
+	})
+}
+
+// ListPendingKYC returns KYC submissions awaiting review
+// GET /api/v1/admin/kyc/pending
+func (h *KYCHandler) ListPendingKYC(c *gin.Context) {
+	page, pageSize, _ := utils.ParsePaginationWithDefault(c, 20)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	users, total, err := h.kycService.ListPending(ctx, page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve pending KYC submissions")
+		return
+	}
+
+	submissions := make([]map[string]interface{}, len(users))
+	for i, user := range users {
+		submissions[i] = kycSubmissionResponse(user)
+	}
+
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"submissions": submissions,
+		"pagination":  pagination,
+	})
+}
+
+// ReviewKYC approves or rejects a pending KYC submission
+// POST /api/v1/admin/kyc/:user_id/review
+func (h *KYCHandler) ReviewKYC(c *gin.Context) {
+	reviewerID, _ := c.Get("user_id")
+	userID := c.Param("user_id")
+
+	var req models.ReviewKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.kycService.Review(ctx, reviewerID.(string), userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrKYCReasonRequired) {
+			utils.ValidationErrorResponse(c, "reason is required when rejecting")
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			return
+		}
+		if errors.Is(err, services.ErrKYCNotPending) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodeKYCNotPending, "No pending KYC submission for this user")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to review KYC")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":    "KYC review saved",
+		"submission": kycSubmissionResponse(user),
+	})
+}
+
+// kycSubmissionResponse formats a user's KYC details for admins
+func kycSubmissionResponse(user *models.User) map[string]interface{} {
+	response := user.PublicUser()
+	response["organization_type"] = user.OrganizationType
+	response["gstin"] = user.GSTIN
+	response["pan"] = user.PAN
+	response["kyc_verified_at"] = user.KYCVerifiedAt
+	response["kyc_rejection_reason"] = user.KYCRejectionReason
+
+	var documents []models.KYCDocument
+	if user.KYCDocuments != nil {
+		_ = json.Unmarshal([]byte(*user.KYCDocuments), &documents)
+	}
+	response["kyc_documents"] = documents
+
+	return response
+}
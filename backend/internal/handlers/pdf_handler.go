@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"archive/zip"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ezhilnn/epr-backend/internal/models"
@@ -16,45 +21,47 @@ import (
 // PDFHandler handles PDF generation requests
 type PDFHandler struct {
 	billRepo    *repository.BillRepository
+	billService *services.BillService
 	pdfService  *services.PDFService
 }
 
 // NewPDFHandler creates a new PDF handler
-func NewPDFHandler(billRepo *repository.BillRepository, pdfService *services.PDFService) *PDFHandler {
+func NewPDFHandler(billRepo *repository.BillRepository, billService *services.BillService, pdfService *services.PDFService) *PDFHandler {
 	return &PDFHandler{
-		billRepo:   billRepo,
-		pdfService: pdfService,
+		billRepo:    billRepo,
+		billService: billService,
+		pdfService:  pdfService,
 	}
 }
 
 // DownloadBillPDF generates and downloads PDF for a bill
 // GET /api/v1/bills/:bill_number/pdf
 func (h *PDFHandler) DownloadBillPDF(c *gin.Context) {
-	billNumber := c.Param("bill_number")
+	billNumber := c.Param("id")
 	
 	// Get user info from auth middleware (if authenticated)
 	userID, userExists := c.Get("user_id")
 	role, _ := c.Get("role")
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 	
 	// Fetch bill from database
 	bill, err := h.billRepo.GetByBillNumber(ctx, billNumber)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Bill not found")
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
 		return
 	}
 	
 	// Check access permissions
 	canAccess := h.canAccessBillPDF(userID, role, bill, userExists)
 	if !canAccess {
-		utils.ErrorResponse(c, http.StatusForbidden, "You don't have permission to download this bill")
+		utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeBillAccessDenied, "You don't have permission to download this bill")
 		return
 	}
 	
 	// Generate PDF
-	pdfBytes, err := h.pdfService.GenerateBillPDF(bill)
+	pdfBytes, err := h.pdfService.GenerateBillPDF(bill, h.billService.VerificationRef(ctx, bill))
 	if err != nil {
 		fmt.Printf("Error generating PDF: %v\n", err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate PDF")
@@ -70,6 +77,104 @@ func (h *PDFHandler) DownloadBillPDF(c *gin.Context) {
 	c.Data(http.StatusOK, "application/pdf", pdfBytes)
 }
 
+// ExportBillsZip streams a ZIP of PDFs for the caller's own bills issued in a date range
+// Each PDF is generated and written before the next, so memory stays flat however many bills there are
+// GET /api/v1/bills/export/zip?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD
+func (h *PDFHandler) ExportBillsZip(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var startDate, endDate *time.Time
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		sd, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid start_date. Use YYYY-MM-DD")
+			return
+		}
+		startDate = &sd
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		ed, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid end_date. Use YYYY-MM-DD")
+			return
+		}
+		endDate = &ed
+	}
+	if startDate != nil && endDate != nil && endDate.Before(*startDate) {
+		utils.ValidationErrorResponse(c, "end_date can't be before start_date")
+		return
+	}
+
+	// Exports can be long - give them more time than a normal request
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	defer cancel()
+
+	// Only the caller's bills are listed, so ownership needs no further check
+	bills, err := h.billService.ListBillsForExport(ctx, userID.(string), startDate, endDate)
+	if err != nil {
+		var tooLarge *services.BillExportTooLargeError
+		if errors.As(err, &tooLarge) {
+			utils.ValidationErrorResponseWithCode(c, utils.CodeExportTooLarge, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list bills")
+		return
+	}
+
+	filename := fmt.Sprintf("bills-%s.zip", time.Now().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	c.Stream(func(w io.Writer) bool {
+		archive := zip.NewWriter(w)
+		err := h.writeBillPDFs(ctx, archive, bills)
+		if closeErr := archive.Close(); err == nil {
+			err = closeErr
+		}
+
+		// Headers are already sent, so a failure can only truncate the archive
+		if err != nil {
+			log.Printf("⚠️  Bill ZIP export failed for user %s: %v", userID, err)
+		}
+
+		return false
+	})
+}
+
+// writeBillPDFs adds one <bill number>.pdf entry per bill to the archive
+func (h *PDFHandler) writeBillPDFs(ctx context.Context, archive *zip.Writer, bills []*models.Bill) error {
+	for _, bill := range bills {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pdfBytes, err := h.pdfService.GenerateBillPDF(bill, h.billService.VerificationRef(ctx, bill))
+		if err != nil {
+			return fmt.Errorf("failed to generate PDF for %s: %w", bill.BillNumber, err)
+		}
+
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     billPDFFilename(bill.BillNumber),
+			Method:   zip.Deflate,
+			Modified: bill.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(pdfBytes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// billPDFFilename names a bill's PDF inside an export; separators are replaced so entries stay at the top level
+func billPDFFilename(billNumber string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(billNumber) + ".pdf"
+}
+
 // canAccessBillPDF checks if user can access the bill PDF
 func (h *PDFHandler) canAccessBillPDF(userID interface{}, role interface{}, bill *models.Bill, userExists bool) bool {
 	// If bill is public, anyone can download (no auth required)
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+func TestDownloadBillPDF(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewPDFHandler(env.bills, env.billService, services.NewPDFService(env.cfg.App.FrontendURL))
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	public := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	restricted := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	env.db.MustExec("UPDATE bills SET access_level = 'restricted' WHERE id = $1", restricted.ID)
+
+	download := func(t *testing.T, user *models.User, billNumber string) {
+		t.Helper()
+		w := serve(t, user, http.MethodGet, "/bills/:id/pdf", "/bills/"+billNumber+"/pdf", nil, h.DownloadBillPDF)
+		expectStatus(t, w, http.StatusOK, "")
+
+		if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+			t.Errorf("Content-Type = %q, want application/pdf", ct)
+		}
+		if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")) {
+			t.Error("response is not a PDF")
+		}
+	}
+
+	t.Run("public bill without a token", func(t *testing.T) {
+		download(t, nil, public.BillNumber)
+	})
+
+	t.Run("restricted bill without a token", func(t *testing.T) {
+		w := serve(t, nil, http.MethodGet, "/bills/:id/pdf", "/bills/"+restricted.BillNumber+"/pdf", nil, h.DownloadBillPDF)
+		expectStatus(t, w, http.StatusForbidden, string(utils.CodeBillAccessDenied))
+	})
+
+	t.Run("restricted bill as a verifier", func(t *testing.T) {
+		download(t, verifier, restricted.BillNumber)
+	})
+
+	t.Run("unknown bill", func(t *testing.T) {
+		w := serve(t, nil, http.MethodGet, "/bills/:id/pdf", "/bills/OTH209901999999/pdf", nil, h.DownloadBillPDF)
+		expectStatus(t, w, http.StatusNotFound, string(utils.CodeBillNotFound))
+	})
+}
+
+func TestExportBillsZip(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewPDFHandler(env.bills, env.billService, services.NewPDFService(env.cfg.App.FrontendURL))
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	otherIssuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+
+	// issued creates a bill for owner issued on date
+	issued := func(owner *models.User, date string) *models.Bill {
+		bill := testutil.CreateBill(t, env.db, owner, 100, nil)
+		env.db.MustExec("UPDATE bills SET issue_date = $1 WHERE id = $2", date, bill.ID)
+		return bill
+	}
+	march := []*models.Bill{issued(issuer, "2026-03-10"), issued(issuer, "2026-03-20")}
+	issued(issuer, "2026-04-02")
+	deleted := issued(issuer, "2026-03-15")
+	env.db.MustExec("UPDATE bills SET is_deleted = true WHERE id = $1", deleted.ID)
+	issued(otherIssuer, "2026-03-15")
+
+	export := func(t *testing.T, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(t, issuer, http.MethodGet, "/bills/export/zip", "/bills/export/zip"+query, nil, h.ExportBillsZip)
+	}
+
+	t.Run("entries are the issuer's bills in range", func(t *testing.T) {
+		w := export(t, "?start_date=2026-03-01&end_date=2026-03-31")
+		expectStatus(t, w, http.StatusOK, "")
+		if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+			t.Errorf("Content-Type = %q, want application/zip", ct)
+		}
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("response is not a ZIP: %v", err)
+		}
+
+		var names, want []string
+		for _, f := range archive.File {
+			names = append(names, f.Name)
+
+			r, err := f.Open()
+			if err != nil {
+				t.Fatalf("failed to open %s: %v", f.Name, err)
+			}
+			content, err := io.ReadAll(r)
+			r.Close()
+			if err != nil || !bytes.HasPrefix(content, []byte("%PDF")) {
+				t.Errorf("%s is not a PDF (%v)", f.Name, err)
+			}
+		}
+		for _, bill := range march {
+			want = append(want, bill.BillNumber+".pdf")
+		}
+		sort.Strings(names)
+		sort.Strings(want)
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("ZIP entries = %v, want %v", names, want)
+		}
+	})
+
+	t.Run("more bills than the cap", func(t *testing.T) {
+		previous := env.cfg.App.BillExportMaxBills
+		env.cfg.App.BillExportMaxBills = 1
+		defer func() { env.cfg.App.BillExportMaxBills = previous }()
+
+		w := export(t, "?start_date=2026-03-01&end_date=2026-03-31")
+		expectStatus(t, w, http.StatusBadRequest, string(utils.CodeExportTooLarge))
+	})
+
+	t.Run("end before start", func(t *testing.T) {
+		w := export(t, "?start_date=2026-03-31&end_date=2026-03-01")
+		expectStatus(t, w, http.StatusBadRequest, "")
+	})
+}
+
+func TestBillPDFFilename(t *testing.T) {
+	tests := map[string]string{
+		"OTH202603000001": "OTH202603000001.pdf",
+		"INV/2026/7":      "INV-2026-7.pdf",
+		`..\evil`:         "..-evil.pdf",
+	}
+	for billNumber, want := range tests {
+		if got := billPDFFilename(billNumber); got != want {
+			t.Errorf("billPDFFilename(%q) = %q, want %q", billNumber, got, want)
+		}
+	}
+}
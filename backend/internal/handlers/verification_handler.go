@@ -2,6 +2,12 @@ package handlers
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,28 +21,82 @@ import (
 // VerificationHandler handles verification-related requests
 type VerificationHandler struct {
 	verificationService *services.VerificationService
+	pdfService          *services.PDFService
+	loc                 *time.Location // APP_TIMEZONE, which date filters are read in
 }
 
 // NewVerificationHandler creates a new verification handler
-func NewVerificationHandler(verificationService *services.VerificationService) *VerificationHandler {
+func NewVerificationHandler(verificationService *services.VerificationService, pdfService *services.PDFService, loc *time.Location) *VerificationHandler {
 	return &VerificationHandler{
 		verificationService: verificationService,
+		pdfService:          pdfService,
+		loc:                 loc,
 	}
 }
 
 // VerifyBill handles bill verification request
 // POST /api/v1/verify
 func (h *VerificationHandler) VerifyBill(c *gin.Context) {
-	// Get user info (optional - public can verify too)
-	userID, userExists := c.Get("user_id")
-	role, _ := c.Get("role")
-
 	var req models.VerifyBillRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, err.Error())
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	h.verify(c, req.BillNumber, req.Explain || c.Query("explain") == "true")
+}
+
+// MaxQRUploadSize caps uploaded QR images; a 256px code is a few KB
+const MaxQRUploadSize = 5 << 20
+
+// VerifyBillByQR verifies a bill from an uploaded QR code image
+// POST /api/v1/verify/qr (multipart form field "image")
+func (h *VerificationHandler) VerifyBillByQR(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxQRUploadSize)
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "QR code image is too large. Maximum size is 5MB")
+			return
+		}
+		utils.ValidationErrorResponse(c, "A QR code image (PNG or JPEG, max 5MB) is required in the 'image' field")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Could not read the uploaded image")
+		return
+	}
+	defer file.Close()
+
+	billNumber, err := h.verificationService.BillNumberFromQR(file)
+	if err != nil {
+		if errors.Is(err, services.ErrUnreadableQR) {
+			utils.ErrorResponseWithCode(c, http.StatusUnprocessableEntity, utils.CodeUnreadableQR, "Could not read a QR code from the image. Please upload a clear PNG or JPEG of the bill's QR code.")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidQRLink) {
+			utils.ErrorResponseWithCode(c, http.StatusUnprocessableEntity, utils.CodeInvalidQRLink, services.ErrInvalidQRLink.Error())
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Verification failed. Please try again.")
 		return
 	}
 
+	h.verify(c, billNumber, c.Query("explain") == "true")
+}
+
+// verify runs a verification for the caller and writes the response
+// Shared by the bill number and QR upload endpoints; explain adds the access rules behind the result
+func (h *VerificationHandler) verify(c *gin.Context, billNumber string, explain bool) {
+	// Get user info (optional - public can verify too)
+	userID, userExists := c.Get("user_id")
+	role, _ := c.Get("role")
+
 	// Get client info
 	ip := c.ClientIP()
 	userAgent := c.Request.UserAgent()
@@ -47,7 +107,7 @@ func (h *VerificationHandler) VerifyBill(c *gin.Context) {
 		userRole = models.UserRole(role.(string))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	// Verify bill
@@ -57,11 +117,18 @@ func (h *VerificationHandler) VerifyBill(c *gin.Context) {
 		userIDPtr = &userIDStr
 	}
 
-	result, err := h.verificationService.VerifyBill(ctx, userIDPtr, req.BillNumber, ip, userAgent, userRole)
+	result, err := h.verificationService.VerifyBill(ctx, userIDPtr, billNumber, ip, userAgent, userRole, explain)
 	if err != nil {
 		// Check for specific errors
-		if err.Error()[:20] == "insufficient wallet" {
-			utils.ErrorResponse(c, http.StatusPaymentRequired, err.Error())
+		if errors.Is(err, services.ErrInsufficientBalance) {
+			utils.ErrorResponseWithCode(c, http.StatusPaymentRequired, utils.CodeInsufficientBalance, err.Error())
+			return
+		}
+		var quotaErr *services.AnonymousQuotaExceededError
+		if errors.As(err, &quotaErr) {
+			retryAfter := int(math.Ceil(time.Until(quotaErr.ResetsAt).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			utils.ErrorResponseWithCode(c, http.StatusTooManyRequests, utils.CodeAnonymousQuotaExceeded, quotaErr.Error())
 			return
 		}
 
@@ -72,26 +139,74 @@ func (h *VerificationHandler) VerifyBill(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, result)
 }
 
+// EstimatePrice is a dry run of a verification: fee, free credit and access level, with no charge
+// GET /api/v1/verify/price-estimate?bill_number=...
+func (h *VerificationHandler) EstimatePrice(c *gin.Context) {
+	billNumber := c.Query("bill_number")
+	if billNumber == "" {
+		utils.ValidationErrorResponse(c, "bill_number is required")
+		return
+	}
+
+	// Loyalty credits and role-based access only apply to logged-in users
+	var userIDPtr *string
+	userRole := models.RolePublic
+	if userID, exists := c.Get("user_id"); exists {
+		userIDStr := userID.(string)
+		userIDPtr = &userIDStr
+		if role, ok := c.Get("role"); ok && role != nil {
+			userRole = models.UserRole(role.(string))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	estimate, err := h.verificationService.EstimatePrice(ctx, userIDPtr, userRole, billNumber)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to estimate price")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, estimate)
+}
+
 // GetVerificationHistory retrieves user's verification history
-// GET /api/v1/verify/history
+// GET /api/v1/verify/history?page=1 or ?cursor=<next_cursor>
 func (h *VerificationHandler) GetVerificationHistory(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
 	// Get pagination and filter parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	// Validate pagination
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
-	}
+	page, pageSize, _ := utils.ParsePagination(c)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
+	// Passing cursor (empty for the first page) switches to keyset pagination
+	if cursorParam, useCursor := c.GetQuery("cursor"); useCursor {
+		cursor, ok := parseCursorParam(c, cursorParam)
+		if !ok {
+			return
+		}
+
+		history, next, err := h.verificationService.GetVerificationHistoryAfter(ctx, userID.(string), cursor, pageSize)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification history")
+			return
+		}
+
+		utils.SuccessResponse(c, http.StatusOK, gin.H{
+			"verifications": history,
+			"pagination":    cursorPagination(pageSize, next),
+		})
+		return
+	}
+
 	// Get history
 	history, total, err := h.verificationService.GetVerificationHistory(ctx, userID.(string), page, pageSize)
 	if err != nil {
@@ -99,26 +214,163 @@ func (h *VerificationHandler) GetVerificationHistory(c *gin.Context) {
 		return
 	}
 
-	// Calculate pagination metadata
-	totalPages := (total + pageSize - 1) / pageSize
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, gin.H{
 		"verifications": history,
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       total,
-			"total_pages": totalPages,
-		},
+		"pagination":    pagination,
 	})
 }
 
+// ExportVerificationHistory streams the user's verification history as a CSV download
+// GET /api/v1/verify/history/export?format=csv&start_date=YYYY-MM-DD&end_date=YYYY-MM-DD
+func (h *VerificationHandler) ExportVerificationHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		utils.ValidationErrorResponse(c, "Unsupported export format. Supported formats: csv")
+		return
+	}
+
+	// Same date filters as search
+	var startDate, endDate *time.Time
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		sd, err := utils.ParseDate(startDateStr, h.loc)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid start_date. Use YYYY-MM-DD")
+			return
+		}
+		startDate = &sd
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		ed, err := utils.ParseDate(endDateStr, h.loc)
+		if err != nil {
+			utils.ValidationErrorResponse(c, "Invalid end_date. Use YYYY-MM-DD")
+			return
+		}
+		// Include verifications made on the end date itself
+		ed = ed.Add(24*time.Hour - time.Nanosecond)
+		endDate = &ed
+	}
+
+	// Exports can be long - give them more time than a normal request
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	filename := fmt.Sprintf("verification-history-%s.csv", time.Now().Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	c.Stream(func(w io.Writer) bool {
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"bill_number", "issuer", "bill_type", "verification_date", "result", "fee", "was_free"})
+
+		err := h.verificationService.ExportVerificationHistory(ctx, userID.(string), startDate, endDate, func(row *models.VerificationHistoryResponse) error {
+			writer.Write([]string{
+				row.BillNumber,
+				row.IssuerName,
+				row.BillType,
+				row.Date,
+				row.Result,
+				strconv.FormatFloat(row.Fee, 'f', 2, 64),
+				strconv.FormatBool(row.WasFree),
+			})
+			return writer.Error()
+		})
+		writer.Flush()
+
+		// Headers are already sent, so a failure can only truncate the file
+		if err != nil {
+			log.Printf("⚠️  Verification history export failed for user %s: %v", userID, err)
+		}
+
+		return false
+	})
+}
+
+// DownloadVerificationCertificate returns a PDF certificate for a valid verification
+// GET /api/v1/verify/:id/certificate
+func (h *VerificationHandler) DownloadVerificationCertificate(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	verificationID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	verification, bill, verifier, err := h.verificationService.GetVerificationCertificate(
+		ctx, userID.(string), models.UserRole(role.(string)), verificationID,
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrVerificationNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeVerificationNotFound, "Verification not found")
+			return
+		}
+		if errors.Is(err, services.ErrVerificationAccessDenied) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeVerificationAccessDenied, "You don't have permission to download this certificate")
+			return
+		}
+		if errors.Is(err, services.ErrCertificateUnavailable) {
+			utils.ErrorResponseWithCode(c, http.StatusUnprocessableEntity, utils.CodeCertificateUnavailable, err.Error())
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate certificate")
+		return
+	}
+
+	pdfBytes, err := h.pdfService.GenerateVerificationCertificate(verification, bill, verifier)
+	if err != nil {
+		log.Printf("⚠️  Failed to generate certificate for verification %s: %v", verificationID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate certificate")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=verification-%s.pdf", bill.BillNumber))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// GetVerification retrieves a single verification record
+// GET /api/v1/verify/:id
+func (h *VerificationHandler) GetVerification(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	verificationID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	verification, err := h.verificationService.GetVerification(
+		ctx, userID.(string), models.UserRole(role.(string)), verificationID,
+	)
+	if err != nil {
+		if errors.Is(err, services.ErrVerificationNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeVerificationNotFound, "Verification not found")
+			return
+		}
+		if errors.Is(err, services.ErrVerificationAccessDenied) {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeVerificationAccessDenied, "You don't have permission to view this verification")
+			return
+		}
+
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, verification)
+}
+
 // GetVerificationStats retrieves verification statistics
 // GET /api/v1/verify/stats
 func (h *VerificationHandler) GetVerificationStats(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	stats, err := h.verificationService.GetVerificationStats(ctx, userID.(string))
@@ -130,6 +382,59 @@ func (h *VerificationHandler) GetVerificationStats(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, stats)
 }
 
+// maxTrendDays is the longest range GetVerificationTrends covers
+const maxTrendDays = 365
+
+// GetVerificationTrends returns the caller's verification counts and spend per day or week
+// GET /api/v1/verify/trends?interval=day&days=30
+func (h *VerificationHandler) GetVerificationTrends(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	interval := c.DefaultQuery("interval", models.TrendIntervalDay)
+	if interval != models.TrendIntervalDay && interval != models.TrendIntervalWeek {
+		utils.ValidationErrorResponse(c, "interval must be day or week")
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > maxTrendDays {
+		utils.ValidationErrorResponse(c, fmt.Sprintf("days must be between 1 and %d", maxTrendDays))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	buckets, err := h.verificationService.GetVerificationTrends(ctx, userID.(string), interval, days)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification trends")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"interval": interval,
+		"days":     days,
+		"buckets":  buckets,
+	})
+}
+
+// GetVerificationQuota returns the caller's remaining prepaid plan quota
+// GET /api/v1/verify/quota
+func (h *VerificationHandler) GetVerificationQuota(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	quota, err := h.verificationService.GetVerificationQuota(ctx, userID.(string))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification quota")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, quota)
+}
+
 // SearchVerifications searches verifications with filters
 // GET /api/v1/verify/search
 func (h *VerificationHandler) SearchVerifications(c *gin.Context) {
@@ -140,16 +445,7 @@ func (h *VerificationHandler) SearchVerifications(c *gin.Context) {
 	statusStr := c.Query("status")
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	// Validate pagination
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
-	}
+	page, pageSize, _ := utils.ParsePagination(c)
 
 	// Parse status
 	var status *models.VerificationStatus
@@ -161,12 +457,12 @@ func (h *VerificationHandler) SearchVerifications(c *gin.Context) {
 	// Parse dates
 	var startDate, endDate *time.Time
 	if startDateStr != "" {
-		if sd, err := time.Parse("2006-01-02", startDateStr); err == nil {
+		if sd, err := utils.ParseDate(startDateStr, h.loc); err == nil {
 			startDate = &sd
 		}
 	}
 	if endDateStr != "" {
-		if ed, err := time.Parse("2006-01-02", endDateStr); err == nil {
+		if ed, err := utils.ParseDate(endDateStr, h.loc); err == nil {
 			endDate = &ed
 		}
 	}
@@ -174,7 +470,7 @@ func (h *VerificationHandler) SearchVerifications(c *gin.Context) {
 	_ = startDate
 	_ = endDate
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 	_ = ctx
 	// Get verification repository (we'll need to expose this through service)
@@ -186,6 +482,7 @@ func (h *VerificationHandler) SearchVerifications(c *gin.Context) {
 			"start_date": startDateStr,
 			"end_date":   endDateStr,
 		},
-		"message": "Search functionality coming soon",
+		"pagination": utils.BuildPagination(page, pageSize, 0),
+		"message":    "Search functionality coming soon",
 	})
 }
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+func TestExportVerificationHistoryCSV(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewVerificationHandler(env.verificationService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	testutil.CreateVerification(t, env.db, bill, verifier)
+
+	// Someone else's verification must not leak into the export
+	other := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	testutil.CreateVerification(t, env.db, testutil.CreateBill(t, env.db, issuer, 200, nil), other)
+
+	export := func(t *testing.T, query string) [][]string {
+		t.Helper()
+		w := serve(t, verifier, http.MethodGet, "/export", "/export"+query, nil, h.ExportVerificationHistory)
+		expectStatus(t, w, http.StatusOK, "")
+
+		if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") {
+			t.Errorf("Content-Disposition = %q, want an attachment", cd)
+		}
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("response is not CSV: %v", err)
+		}
+		return rows
+	}
+
+	rows := export(t, "")
+	wantHeader := []string{"bill_number", "issuer", "bill_type", "verification_date", "result", "fee", "was_free"}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(wantHeader, ",") {
+		t.Fatalf("header = %v, want %v", rows, wantHeader)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d data rows, want 1", len(rows)-1)
+	}
+	row := rows[1]
+	if row[0] != bill.BillNumber || row[1] != issuer.OrganizationName || row[2] != string(models.BillTypeOther) || row[6] != "true" {
+		t.Errorf("data row = %v", row)
+	}
+
+	// The same date filters as search
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	if rows := export(t, "?start_date="+tomorrow); len(rows) != 1 {
+		t.Errorf("export from tomorrow has %d data rows, want 0", len(rows)-1)
+	}
+}
+
+func TestDownloadVerificationCertificateAccess(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewVerificationHandler(env.verificationService, services.NewPDFService(env.cfg.App.FrontendURL), time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	otherVerifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	verification := testutil.CreateVerification(t, env.db, testutil.CreateBill(t, env.db, issuer, 100, nil), verifier)
+
+	download := func(t *testing.T, user *models.User, id string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(t, user, http.MethodGet, "/verify/:id/certificate", "/verify/"+id+"/certificate", nil, h.DownloadVerificationCertificate)
+	}
+
+	t.Run("a different user", func(t *testing.T) {
+		w := download(t, otherVerifier, verification.ID)
+		expectStatus(t, w, http.StatusForbidden, string(utils.CodeVerificationAccessDenied))
+	})
+
+	t.Run("the issuer", func(t *testing.T) {
+		w := download(t, issuer, verification.ID)
+		expectStatus(t, w, http.StatusForbidden, string(utils.CodeVerificationAccessDenied))
+	})
+
+	for _, tt := range []struct {
+		name string
+		user *models.User
+	}{
+		{"the verifier", verifier},
+		{"a master admin", admin},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := download(t, tt.user, verification.ID)
+			expectStatus(t, w, http.StatusOK, "")
+			if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")) {
+				t.Error("response is not a PDF")
+			}
+		})
+	}
+
+	t.Run("unknown verification", func(t *testing.T) {
+		w := download(t, verifier, unknownID)
+		expectStatus(t, w, http.StatusNotFound, string(utils.CodeVerificationNotFound))
+	})
+}
+
+func TestGetVerificationOwnership(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewVerificationHandler(env.verificationService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	other := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+	owned := testutil.CreateVerification(t, env.db, bill, verifier)
+	anonymous := testutil.CreateVerification(t, env.db, bill, nil)
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		id         string
+		wantStatus int
+		wantCode   string
+	}{
+		{"verifier reads their own", verifier, owned.ID, http.StatusOK, ""},
+		{"another verifier", other, owned.ID, http.StatusForbidden, string(utils.CodeVerificationAccessDenied)},
+		{"the bill's issuer", issuer, owned.ID, http.StatusForbidden, string(utils.CodeVerificationAccessDenied)},
+		{"master admin", admin, owned.ID, http.StatusOK, ""},
+		{"anonymous verification", verifier, anonymous.ID, http.StatusForbidden, string(utils.CodeVerificationAccessDenied)},
+		{"anonymous verification as admin", admin, anonymous.ID, http.StatusOK, ""},
+		{"unknown verification", verifier, "00000000-0000-4000-8000-000000000000", http.StatusNotFound, string(utils.CodeVerificationNotFound)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, tt.user, http.MethodGet, "/verify/:id", "/verify/"+tt.id, nil, h.GetVerification)
+			expectStatus(t, w, tt.wantStatus, tt.wantCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			data := decode(t, w)["data"].(map[string]interface{})
+			if data["id"] != tt.id {
+				t.Errorf("id = %v, want %s", data["id"], tt.id)
+			}
+			if _, ok := data["data_revealed"].(map[string]interface{}); !ok {
+				t.Errorf("data_revealed = %v, want the stored JSON object", data["data_revealed"])
+			}
+			if info, _ := data["bill"].(map[string]interface{}); info == nil || info["bill_number"] != bill.BillNumber {
+				t.Errorf("bill = %v, want %s", data["bill"], bill.BillNumber)
+			}
+		})
+	}
+}
+
+func TestGetVerificationHistoryPagination(t *testing.T) {
+	env := newHandlerEnv(t)
+	h := NewVerificationHandler(env.verificationService, nil, time.UTC)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	for i := 0; i < 3; i++ {
+		testutil.CreateVerification(t, env.db, bill, verifier)
+	}
+
+	history := func(t *testing.T, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serve(t, verifier, http.MethodGet, "/verify/history", "/verify/history"+query, nil, h.GetVerificationHistory)
+	}
+
+	w := history(t, "?page=1&page_size=2")
+	expectStatus(t, w, http.StatusOK, "")
+	pagination := decode(t, w)["data"].(map[string]interface{})["pagination"].(map[string]interface{})
+	if pagination["total_pages"] != 2.0 || pagination["has_next"] != true || pagination["has_prev"] != false {
+		t.Errorf("pagination = %v, want page 1 of 2 with a next page", pagination)
+	}
+	if pagination["max_page_size"] != float64(utils.MaxPageSize) {
+		t.Errorf("max_page_size = %v, want %d", pagination["max_page_size"], utils.MaxPageSize)
+	}
+
+	expectStatus(t, history(t, "?page=3&page_size=2"), http.StatusBadRequest, string(utils.CodePageOutOfRange))
+}
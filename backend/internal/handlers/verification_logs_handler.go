@@ -6,12 +6,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ezhilnn/epr-backend/internal/models"
 	"github.com/ezhilnn/epr-backend/internal/repository"
 	"github.com/ezhilnn/epr-backend/internal/utils"
 )
 
 // GetBillVerificationLogs retrieves verification logs for a specific bill
-// GET /api/v1/bills/:id/verifications
+// GET /api/v1/bills/id/:id/verifications?page=1&page_size=50
 func GetBillVerificationLogs(
 	c *gin.Context,
 	billRepo *repository.BillRepository,
@@ -21,56 +22,47 @@ func GetBillVerificationLogs(
 	userID, _ := c.Get("user_id")
 	billID := c.Param("id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// Get bill and check ownership
 	bill, err := billRepo.GetByID(ctx, billID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Bill not found")
+		utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodeBillNotFound, "Bill not found")
 		return
 	}
 
 	// Check if user owns the bill
 	if bill.IssuerID != userID.(string) {
-		utils.ErrorResponse(c, http.StatusForbidden, "Access denied")
+		utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeBillAccessDenied, "Access denied")
 		return
 	}
 
-	// Get all verifications for this bill (simplified query)
-	query := `
-		SELECT v.*, u.organization_name as verifier_name 
-		FROM verifications v 
-		LEFT JOIN users u ON v.verifier_id = u.id 
-		WHERE v.bill_id = $1 
-		ORDER BY v.verified_at DESC 
-		LIMIT 50
-	`
-	_= query
+	// Parse pagination
+	page, pageSize, offset := utils.ParsePaginationWithDefault(c, 50)
 
-	type VerificationLog struct {
-		ID           string    `db:"id"`
-		VerifierName *string   `db:"verifier_name"`
-		VerifierIP   *string   `db:"verifier_ip"`
-		VerifiedAt   time.Time `db:"verified_at"`
-		Result       string    `db:"verification_status"`
-		AccessLevel  string    `db:"access_level_used"`
+	// Get verifications for this bill, newest first
+	logs, err := verificationRepo.ListByBillWithVerifier(ctx, billID, pageSize, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification logs")
+		return
 	}
 
-	var logs []VerificationLog
-	// Note: This is simplified - in production use proper repository method
-	// For now, return empty array
-	logs = []VerificationLog{}
+	total, err := verificationRepo.CountLogsByBill(ctx, billID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve verification logs")
+		return
+	}
 
 	// Format response to match frontend
-	response := make([]map[string]interface{}, 0)
+	response := make([]map[string]interface{}, 0, len(logs))
 	for _, log := range logs {
 		verifierName := "Public User"
 		verifierType := "public"
 		
 		if log.VerifierName != nil {
 			verifierName = *log.VerifierName
-			if log.AccessLevel == "government" || log.AccessLevel == "financial" {
+			if log.AccessLevelUsed == models.AccessLevelGovernment || log.AccessLevelUsed == models.AccessLevelFinancial {
 				verifierType = "government"
 			} else {
 				verifierType = "institutional"
@@ -81,13 +73,20 @@ func GetBillVerificationLogs(
 			"id":           log.ID,
 			"verified_by":  verifierName,
 			"verified_at":  log.VerifiedAt.Format(time.RFC3339),
-			"result":       log.Result,
+			"result":       log.VerificationStatus,
 			"verifier_type": verifierType,
 		})
 	}
 
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, gin.H{
 		"verification_logs": response,
-		"total": len(response),
+		"total": total,
+		"pagination": pagination,
 	})
 }
\ No newline at end of file
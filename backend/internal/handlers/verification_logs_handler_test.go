@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetBillVerificationLogs(t *testing.T) {
+	env := newHandlerEnv(t)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	bank := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	employer := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+	// Seed oldest first; the handler lists newest first
+	seeded := []struct {
+		verifier    *models.User
+		accessLevel models.AccessLevel
+		minutesAgo  int
+	}{
+		{nil, models.AccessLevelPublic, 30},
+		{employer, models.AccessLevelRestricted, 20},
+		{bank, models.AccessLevelFinancial, 10},
+	}
+	for _, s := range seeded {
+		v := testutil.CreateVerification(t, env.db, bill, s.verifier)
+		env.db.MustExec(`UPDATE verifications SET access_level_used = $1, verified_at = NOW() - make_interval(mins => $2) WHERE id = $3`,
+			s.accessLevel, s.minutesAgo, v.ID)
+	}
+
+	handler := func(c *gin.Context) {
+		GetBillVerificationLogs(c, env.bills, env.verifications, env.users)
+	}
+	route := "/bills/id/:id/verifications"
+	path := "/bills/id/" + bill.ID + "/verifications"
+
+	w := serve(t, issuer, http.MethodGet, route, path, nil, handler)
+	expectStatus(t, w, http.StatusOK, "")
+
+	data := decode(t, w)["data"].(map[string]interface{})
+	if total := data["total"]; total != float64(3) {
+		t.Errorf("total = %v, want 3", total)
+	}
+
+	want := []struct{ name, verifierType string }{
+		{bank.OrganizationName, "government"},
+		{employer.OrganizationName, "institutional"},
+		{"Public User", "public"},
+	}
+	logs := data["verification_logs"].([]interface{})
+	if len(logs) != len(want) {
+		t.Fatalf("got %d logs, want %d", len(logs), len(want))
+	}
+	for i, expected := range want {
+		log := logs[i].(map[string]interface{})
+		if log["verified_by"] != expected.name || log["verifier_type"] != expected.verifierType {
+			t.Errorf("log %d = %v / %v, want %s / %s", i, log["verified_by"], log["verifier_type"], expected.name, expected.verifierType)
+		}
+	}
+
+	t.Run("paginated", func(t *testing.T) {
+		w := serve(t, issuer, http.MethodGet, route, path+"?page=2&page_size=2", nil, handler)
+		expectStatus(t, w, http.StatusOK, "")
+
+		logs := decode(t, w)["data"].(map[string]interface{})["verification_logs"].([]interface{})
+		if len(logs) != 1 || logs[0].(map[string]interface{})["verified_by"] != "Public User" {
+			t.Errorf("page 2 = %v, want only the anonymous verification", logs)
+		}
+	})
+
+	t.Run("other institution", func(t *testing.T) {
+		other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+		w := serve(t, other, http.MethodGet, route, path, nil, handler)
+		expectStatus(t, w, http.StatusForbidden, string(utils.CodeBillAccessDenied))
+	})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// WalletHandler handles wallet statement requests
+type WalletHandler struct {
+	walletService  *services.WalletService
+	paymentService *services.PaymentService
+}
+
+// NewWalletHandler creates a new wallet handler
+func NewWalletHandler(walletService *services.WalletService, paymentService *services.PaymentService) *WalletHandler {
+	return &WalletHandler{
+		walletService:  walletService,
+		paymentService: paymentService,
+	}
+}
+
+// CreateOrder starts a wallet top-up by creating a payment gateway order
+// POST /api/v1/wallet/order
+func (h *WalletHandler) CreateOrder(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.CreatePaymentOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	order, err := h.paymentService.CreateOrder(ctx, userID.(string), req.Amount)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create payment order")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, order)
+}
+
+// ConfirmPayment verifies the gateway's signed payment callback and credits the wallet
+// POST /api/v1/wallet/confirm
+func (h *WalletHandler) ConfirmPayment(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.ConfirmPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	newBalance, err := h.paymentService.ConfirmPayment(ctx, userID.(string), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPaymentSignature) {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, utils.CodeInvalidPaymentSignature, "Payment signature verification failed")
+			return
+		}
+		if errors.Is(err, services.ErrPaymentOrderNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotFound, utils.CodePaymentOrderNotFound, "Payment order not found")
+			return
+		}
+		if errors.Is(err, services.ErrPaymentAlreadyProcessed) {
+			utils.ErrorResponseWithCode(c, http.StatusConflict, utils.CodePaymentAlreadyProcessed, "Payment has already been processed")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to confirm payment")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":     "Wallet topped up successfully",
+		"new_balance": newBalance,
+	})
+}
+
+// ListTransactions returns the user's wallet statement
+// GET /api/v1/wallet/transactions
+func (h *WalletHandler) ListTransactions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	// Get pagination parameters
+	page, pageSize, _ := utils.ParsePaginationWithDefault(c, 20)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	txns, total, err := h.walletService.ListTransactions(ctx, userID.(string), page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve wallet transactions")
+		return
+	}
+
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"transactions": txns,
+		"pagination":   pagination,
+	})
+}
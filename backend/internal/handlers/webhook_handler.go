@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles webhook configuration and delivery logs
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// SetWebhook sets or clears the caller's webhook URL
+// PUT /api/v1/webhooks
+func (h *WebhookHandler) SetWebhook(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.SetWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	secret, err := h.webhookService.SetWebhook(ctx, userID.(string), req.URL)
+	if errors.Is(err, services.ErrWebhookURLNotAllowed) {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update webhook")
+		return
+	}
+
+	if req.URL == "" {
+		utils.SuccessResponse(c, http.StatusOK, gin.H{
+			"message": "Webhook disabled",
+		})
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"message":          "Webhook updated",
+		"url":              req.URL,
+		"secret":           secret,
+		"signature_header": services.WebhookSignatureHeader,
+	})
+}
+
+// ListDeliveries lists the caller's webhook deliveries
+// GET /api/v1/webhooks/deliveries
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	page, pageSize, _ := utils.ParsePaginationWithDefault(c, 20)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	deliveries, total, err := h.webhookService.ListDeliveries(ctx, userID.(string), page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve webhook deliveries")
+		return
+	}
+
+	pagination := utils.BuildPagination(page, pageSize, total)
+	if pagination.OutOfRange() {
+		utils.PageOutOfRangeResponse(c, pagination)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"pagination": pagination,
+	})
+}
@@ -1,23 +1,25 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ezhilnn/epr-backend/internal/repository"
 	"github.com/ezhilnn/epr-backend/internal/utils"
 )
 
 // AuthMiddleware creates a middleware that validates JWT tokens
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+func AuthMiddleware(jwtKeys utils.JWTKeySet) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"error":   "Authorization header required",
-			})
+			utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeAuthRequired, "Authorization header required")
 			c.Abort() // Stop processing
 			return
 		}
@@ -25,10 +27,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		// Check if header starts with "Bearer "
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"error":   "Invalid authorization header format. Use: Bearer <token>",
-			})
+			utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeAuthRequired, "Invalid authorization header format. Use: Bearer <token>")
 			c.Abort()
 			return
 		}
@@ -37,12 +36,9 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		token := parts[1]
 
 		// Validate token
-		claims, err := utils.ValidateToken(token, jwtSecret)
+		claims, err := utils.ValidateToken(token, jwtKeys)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"error":   "Invalid or expired token",
-			})
+			utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeInvalidToken, "Invalid or expired token")
 			c.Abort()
 			return
 		}
@@ -51,6 +47,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		c.Set("email_verified", claims.EmailVerified)
 
 		// Continue to next handler
 		c.Next()
@@ -63,10 +60,7 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 		// Get role from context (set by AuthMiddleware)
 		role, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"error":   "User not authenticated",
-			})
+			utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeAuthRequired, "User not authenticated")
 			c.Abort()
 			return
 		}
@@ -83,10 +77,7 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 		}
 
 		if !allowed {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"error":   "Insufficient permissions",
-			})
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeInsufficientPermissions, "Insufficient permissions")
 			c.Abort()
 			return
 		}
@@ -94,4 +85,46 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 		// Continue to next handler
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// EmailVerificationChecker looks up whether a user has verified their email, e.g. *repository.UserRepository
+type EmailVerificationChecker interface {
+	IsEmailVerified(ctx context.Context, userID string) (bool, error)
+}
+
+// RequireVerifiedEmail creates a middleware that rejects users who haven't verified their email
+// It must run after AuthMiddleware. The token's email_verified claim is trusted when true; otherwise
+// the database is checked, since the user may have verified after the token was issued.
+// Master admins are exempt, and the middleware does nothing when enforce is false
+func RequireVerifiedEmail(users EmailVerificationChecker, enforce bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enforce || c.GetString("role") == "master_admin" || c.GetBool("email_verified") {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		userID := c.GetString("user_id")
+		verified, err := users.IsEmailVerified(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				utils.ErrorResponseWithCode(c, http.StatusUnauthorized, utils.CodeUserNotFound, "User not found")
+			} else {
+				log.Printf("⚠️  Failed to check email verification for %s: %v", userID, err)
+				utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check email verification")
+			}
+			c.Abort()
+			return
+		}
+
+		if !verified {
+			utils.ErrorResponseWithCode(c, http.StatusForbidden, utils.CodeEmailNotVerified, "Please verify your email address to continue")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddlewareErrorCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := utils.NewJWTKeySet("HS256", "", "test-secret", nil)
+	otherKeys := utils.NewJWTKeySet("HS256", "", "other-secret", nil)
+
+	verifier, err := utils.GenerateAccessToken("user-1", "a@example.com", "verifier", true, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	forged, err := utils.GenerateAccessToken("user-2", "b@example.com", "master_admin", true, otherKeys, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	expired, err := utils.GenerateAccessToken("user-1", "a@example.com", "verifier", true, keys, -time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantCode   utils.ErrorCode
+	}{
+		{"no header", "", http.StatusUnauthorized, utils.CodeAuthRequired},
+		{"not a bearer token", "Token " + verifier, http.StatusUnauthorized, utils.CodeAuthRequired},
+		{"token signed with another key", "Bearer " + forged, http.StatusUnauthorized, utils.CodeInvalidToken},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized, utils.CodeInvalidToken},
+		{"role not allowed", "Bearer " + verifier, http.StatusForbidden, utils.CodeInsufficientPermissions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", AuthMiddleware(keys), RequireRole("master_admin"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var body struct {
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body.Code != string(tt.wantCode) {
+				t.Errorf("code = %q, want %s", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// verifiedEmails is an EmailVerificationChecker backed by a map; users missing from it don't exist
+type verifiedEmails map[string]bool
+
+func (v verifiedEmails) IsEmailVerified(ctx context.Context, userID string) (bool, error) {
+	verified, ok := v[userID]
+	if !ok {
+		return false, repository.ErrUserNotFound
+	}
+	return verified, nil
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := utils.NewJWTKeySet("HS256", "", "test-secret", nil)
+	users := verifiedEmails{"verified": true, "unverified": false, "verified-since-login": true}
+
+	tests := []struct {
+		name          string
+		userID        string
+		role          string
+		claimVerified bool
+		enforce       bool
+		wantStatus    int
+		wantCode      utils.ErrorCode
+	}{
+		{"verified claim", "verified", "institution_user", true, true, http.StatusOK, ""},
+		{"unverified", "unverified", "institution_user", false, true, http.StatusForbidden, utils.CodeEmailNotVerified},
+		{"verified after the token was issued", "verified-since-login", "institution_user", false, true, http.StatusOK, ""},
+		{"user no longer exists", "deleted", "institution_user", false, true, http.StatusUnauthorized, utils.CodeUserNotFound},
+		{"master admin is exempt", "unverified", "master_admin", false, true, http.StatusOK, ""},
+		{"not enforced", "unverified", "institution_user", false, false, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := utils.GenerateAccessToken(tt.userID, tt.userID+"@example.com", tt.role, tt.claimVerified, keys, time.Minute)
+			if err != nil {
+				t.Fatalf("failed to sign token: %v", err)
+			}
+
+			router := gin.New()
+			router.POST("/bills", AuthMiddleware(keys), RequireVerifiedEmail(users, tt.enforce), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/bills", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var body struct {
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body.Code != string(tt.wantCode) {
+				t.Errorf("code = %q, want %s", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
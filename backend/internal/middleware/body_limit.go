@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unlimitedBodyKey keeps the request body as it arrived, before any BodyLimit wrapped it
+const unlimitedBodyKey = "unlimited_body"
+
+// BodyLimit caps request bodies at maxBytes
+// Reading past the limit fails with *http.MaxBytesError, which utils.BindingErrorResponse turns into 413.
+// A route-level BodyLimit replaces the global one rather than stacking, so routes can raise the default
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := c.Request.Body
+		if original, ok := c.Get(unlimitedBodyKey); ok {
+			body = original.(io.ReadCloser)
+		} else {
+			c.Set(unlimitedBodyKey, body)
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, body, maxBytes)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// bindJSON binds the request body the way handlers do
+func bindJSON(c *gin.Context) {
+	var body map[string]interface{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// jsonBody returns a JSON object of about size bytes
+func jsonBody(size int) string {
+	return `{"note":"` + strings.Repeat("x", size) + `"}`
+}
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimit(256))
+	router.POST("/default", bindJSON)
+	router.POST("/raised", BodyLimit(4096), bindJSON)
+	router.POST("/tight", BodyLimit(32), bindJSON)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   utils.ErrorCode
+	}{
+		{"within the default limit", "/default", jsonBody(100), http.StatusOK, ""},
+		{"over the default limit", "/default", jsonBody(1000), http.StatusRequestEntityTooLarge, utils.CodePayloadTooLarge},
+		{"route raises the limit", "/raised", jsonBody(1000), http.StatusOK, ""},
+		{"over the raised limit", "/raised", jsonBody(5000), http.StatusRequestEntityTooLarge, utils.CodePayloadTooLarge},
+		{"route tightens the limit", "/tight", jsonBody(100), http.StatusRequestEntityTooLarge, utils.CodePayloadTooLarge},
+		{"malformed JSON", "/default", `{"note": }`, http.StatusBadRequest, utils.CodeMalformedJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+
+			var body struct {
+				Code  utils.ErrorCode `json:"code"`
+				Error string          `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body.Code != tt.wantCode || body.Error == "" {
+				t.Errorf("response = %s %q, want %s with a message", body.Code, body.Error, tt.wantCode)
+			}
+		})
+	}
+}
@@ -1,37 +1,59 @@
 package middleware
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 )
 
 // CORSMiddleware handles Cross-Origin Resource Sharing
-func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
+// The request Origin is echoed back only if it is in allowedOrigins. A "*" entry matches any
+// origin but is ignored unless allowWildcard is set, since it is only meant for development.
+func CORSMiddleware(allowedOrigins []string, allowWildcard bool) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	wildcard := false
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			wildcard = allowWildcard
+			continue
+		}
+		allowed[origin] = true
+	}
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
 
-		// Check if origin is allowed
-		allowed := false
-		for _, allowedOrigin := range allowedOrigins {
-			if origin == allowedOrigin || allowedOrigin == "*" {
-				allowed = true
-				break
-			}
+		// Responses differ per origin, so caches must key on it
+		c.Writer.Header().Add("Vary", "Origin")
+
+		// Same-origin and non-browser requests carry no Origin header
+		if origin == "" {
+			c.Next()
+			return
 		}
 
-		if allowed {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-			c.Writer.Header().Set("Access-Control-Max-Age", "86400")
+		if !allowed[origin] && !wildcard {
+			// Reject preflights outright; for simple requests the missing headers make the browser block the response
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
 		}
 
+		c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+
 		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if c.Request.Method == http.MethodOptions {
+			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Idempotency-Key")
+			c.Writer.Header().Set("Access-Control-Max-Age", "86400")
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
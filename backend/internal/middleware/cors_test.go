@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const allowed = "https://app.example.com"
+	const disallowed = "https://evil.example.net"
+
+	tests := []struct {
+		name          string
+		origins       []string
+		allowWildcard bool
+		method        string
+		origin        string
+		wantStatus    int
+		wantOrigin    string
+	}{
+		{"allowed origin is reflected", []string{allowed}, false, http.MethodGet, allowed, http.StatusOK, allowed},
+		{"disallowed origin is not reflected", []string{allowed}, false, http.MethodGet, disallowed, http.StatusOK, ""},
+		{"no origin", []string{allowed}, false, http.MethodGet, "", http.StatusOK, ""},
+		{"allowed preflight", []string{allowed}, false, http.MethodOptions, allowed, http.StatusNoContent, allowed},
+		{"disallowed preflight is rejected", []string{allowed}, false, http.MethodOptions, disallowed, http.StatusForbidden, ""},
+		{"wildcard in development", []string{allowed, "*"}, true, http.MethodGet, disallowed, http.StatusOK, disallowed},
+		{"wildcard ignored outside development", []string{allowed, "*"}, false, http.MethodGet, disallowed, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(CORSMiddleware(tt.origins, tt.allowWildcard))
+			router.Handle(tt.method, "/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+
+			wantCredentials := ""
+			if tt.wantOrigin != "" {
+				wantCredentials = "true"
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, wantCredentials)
+			}
+			if tt.wantStatus == http.StatusNoContent && w.Header().Get("Access-Control-Allow-Methods") == "" {
+				t.Error("preflight response has no Access-Control-Allow-Methods")
+			}
+			if got := w.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+		})
+	}
+}
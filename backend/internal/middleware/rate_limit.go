@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimitWindow is the sliding window the per-minute limits apply to
+const rateLimitWindow = time.Minute
+
+// rateLimitUserKey holds the user ID IdentifyCaller found in the request's bearer token
+const rateLimitUserKey = "rate_limit_user_id"
+
+// IdentifyCaller records who sent the request, if it carries a valid bearer token, so rate limits
+// that run before AuthMiddleware can count it per user rather than per IP
+// It never rejects a request: routes that need a login still check the token themselves
+func IdentifyCaller(jwtKeys utils.JWTKeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if ok && token != "" {
+			if claims, err := utils.ValidateToken(token, jwtKeys); err == nil && claims.UserID != "" {
+				c.Set(rateLimitUserKey, claims.UserID)
+			}
+		}
+		c.Next()
+	}
+}
+
+// RateLimit creates a middleware that allows at most rpm requests per minute per client
+// Clients are identified by user ID when authenticated, otherwise by IP address; run IdentifyCaller first
+// so logged-in users are recognised on routes where AuthMiddleware hasn't run yet
+func RateLimit(redisClient *database.RedisClient, rpm int) gin.HandlerFunc {
+	return RateLimitScoped(redisClient, "global", rpm)
+}
+
+// RateLimitScoped is like RateLimit but counts requests in a separate bucket
+// Use it on route groups that need their own (usually stricter) limit, e.g. /verify
+func RateLimitScoped(redisClient *database.RedisClient, scope string, rpm int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Limiting disabled or Redis unavailable - fail open
+		if redisClient == nil || rpm <= 0 {
+			c.Next()
+			return
+		}
+
+		key := fmt.Sprintf("ratelimit:%s:%s", scope, rateLimitClientID(c))
+
+		allowed, retryAfter, err := slidingWindowAllow(c.Request.Context(), redisClient, key, rpm)
+		if err != nil {
+			// Never block traffic because Redis is having a bad day
+			log.Printf("⚠️  Rate limiter error for %s: %v", key, err)
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			utils.ErrorResponseWithCode(c, http.StatusTooManyRequests, utils.CodeRateLimited, "Too many requests. Please try again later.")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimitAnonymous is like RateLimitScoped but only counts requests without a logged-in user
+// Run it after optional authentication so signed-in callers keep the normal limit
+func RateLimitAnonymous(redisClient *database.RedisClient, scope string, rpm int) gin.HandlerFunc {
+	limit := RateLimitScoped(redisClient, scope, rpm)
+	return func(c *gin.Context) {
+		if _, authenticated := c.Get("user_id"); authenticated {
+			c.Next()
+			return
+		}
+		limit(c)
+	}
+}
+
+// rateLimitClientID identifies the caller for rate limiting
+func rateLimitClientID(c *gin.Context) string {
+	// user_id is only present when AuthMiddleware already ran for this route; IdentifyCaller covers the rest
+	for _, key := range []string{"user_id", rateLimitUserKey} {
+		if id := c.GetString(key); id != "" {
+			return "user:" + id
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// slidingWindowAllow records a request and reports whether it fits in the window
+// Each request is a member of a sorted set scored by its timestamp; entries older
+// than the window are trimmed before counting.
+// Returns the number of seconds until a slot frees up when the request is rejected.
+func slidingWindowAllow(ctx context.Context, redisClient *database.RedisClient, key string, limit int) (bool, int, error) {
+	now := time.Now()
+	windowStart := now.Add(-rateLimitWindow)
+	member := strconv.FormatInt(now.UnixNano(), 10)
+
+	pipe := redisClient.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "0", strconv.FormatInt(windowStart.UnixNano(), 10))
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixNano()), Member: member})
+	countCmd := pipe.ZCard(ctx, key)
+	pipe.PExpire(ctx, key, rateLimitWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, 0, err
+	}
+
+	if countCmd.Val() <= int64(limit) {
+		return true, 0, nil
+	}
+
+	// Over the limit - don't let rejected requests eat into the window
+	redisClient.ZRem(ctx, key, member)
+
+	retryAfter := int(rateLimitWindow.Seconds())
+	oldest, err := redisClient.ZRangeWithScores(ctx, key, 0, 0).Result()
+	if err == nil && len(oldest) > 0 {
+		freesAt := time.Unix(0, int64(oldest[0].Score)).Add(rateLimitWindow)
+		retryAfter = int(math.Ceil(time.Until(freesAt).Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+	}
+
+	return false, retryAfter, nil
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitClientIDUsesBearerTokenBeforeAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := utils.NewJWTKeySet("HS256", "", "test-secret", nil)
+	otherKeys := utils.NewJWTKeySet("HS256", "", "other-secret", nil)
+
+	valid, err := utils.GenerateAccessToken("user-1", "a@example.com", "verifier", true, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	forged, err := utils.GenerateAccessToken("user-2", "b@example.com", "verifier", true, otherKeys, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"no token", "", "ip:192.0.2.1"},
+		{"valid token", "Bearer " + valid, "user:user-1"},
+		{"token signed with another key", "Bearer " + forged, "ip:192.0.2.1"},
+		{"malformed header", "Token " + valid, "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			router := gin.New()
+			router.Use(IdentifyCaller(keys))
+			router.GET("/", func(c *gin.Context) {
+				got = rateLimitClientID(c)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("rateLimitClientID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitClientIDIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		trusted []string
+		peer    string
+		want    string
+	}{
+		{"untrusted peer", nil, "192.0.2.1", "ip:192.0.2.1"},
+		{"peer isn't one of the trusted proxies", []string{"10.0.0.1"}, "192.0.2.1", "ip:192.0.2.1"},
+		{"trusted proxy", []string{"10.0.0.1"}, "10.0.0.1", "ip:198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			router := gin.New()
+			if err := router.SetTrustedProxies(tt.trusted); err != nil {
+				t.Fatalf("SetTrustedProxies failed: %v", err)
+			}
+			router.GET("/", func(c *gin.Context) {
+				got = rateLimitClientID(c)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer + ":1234"
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("rateLimitClientID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// limitedRouter serves GET / behind the given middleware and returns a function that calls it from ip
+func limitedRouter(middleware ...gin.HandlerFunc) func(ip string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware...)
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	return func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func TestRateLimitRejectsRequestOverLimit(t *testing.T) {
+	const rpm = 5
+	get := limitedRouter(RateLimit(testutil.Redis(t), rpm))
+
+	for i := 1; i <= rpm; i++ {
+		if w := get("192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i, w.Code)
+		}
+	}
+
+	w := get("192.0.2.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request %d: status = %d, want 429", rpm+1, w.Code)
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("Retry-After = %q, want 1-60 seconds", w.Header().Get("Retry-After"))
+	}
+
+	// Other clients have their own window
+	if w := get("192.0.2.2"); w.Code != http.StatusOK {
+		t.Errorf("other client: status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitScopesAreCountedSeparately(t *testing.T) {
+	redisClient := testutil.Redis(t)
+	global := limitedRouter(RateLimit(redisClient, 10))
+	verify := limitedRouter(RateLimit(redisClient, 10), RateLimitScoped(redisClient, "verify", 2))
+
+	for i := 1; i <= 2; i++ {
+		if w := verify("192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("verify request %d: status = %d, want 200", i, w.Code)
+		}
+	}
+	if w := verify("192.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("verify request 3: status = %d, want 429", w.Code)
+	}
+
+	// The stricter verify limit doesn't spill over to the global bucket
+	if w := global("192.0.2.1"); w.Code != http.StatusOK {
+		t.Errorf("global request: status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitFailsOpenWithoutRedis(t *testing.T) {
+	get := limitedRouter(RateLimit(nil, 1))
+
+	for i := 1; i <= 3; i++ {
+		if w := get("192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i, w.Code)
+		}
+	}
+}
+
+func TestRateLimitAnonymousSkipsSignedInCallers(t *testing.T) {
+	redisClient := testutil.Redis(t)
+	signIn := func(c *gin.Context) {
+		if c.GetHeader("X-Test-User") != "" {
+			c.Set("user_id", c.GetHeader("X-Test-User"))
+		}
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(signIn, RateLimitAnonymous(redisClient, "verify_anonymous", 2))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 1; i <= 2; i++ {
+		if code := get(""); code != http.StatusOK {
+			t.Fatalf("anonymous request %d: status = %d, want 200", i, code)
+		}
+	}
+	if code := get(""); code != http.StatusTooManyRequests {
+		t.Errorf("anonymous request 3: status = %d, want 429", code)
+	}
+
+	// A signed-in caller from the same address isn't held to the anonymous limit
+	for i := 1; i <= 3; i++ {
+		if code := get("user-1"); code != http.StatusOK {
+			t.Errorf("signed-in request %d: status = %d, want 200", i, code)
+		}
+	}
+}
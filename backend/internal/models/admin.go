@@ -0,0 +1,31 @@
+package models
+
+// PlatformStats holds platform-wide metrics for the master admin dashboard
+type PlatformStats struct {
+	TotalUsers  int              `json:"total_users"`
+	UsersByRole map[UserRole]int `json:"users_by_role"`
+
+	TotalBills              int                      `json:"total_bills"`
+	BillsByBlockchainStatus map[BlockchainStatus]int `json:"bills_by_blockchain_status"`
+
+	TotalVerifications int `json:"total_verifications"`
+
+	Revenue PlatformRevenue `json:"revenue"`
+
+	SignupsLast30Days int          `json:"signups_last_30_days"`
+	SignupsByDay      []DailyCount `json:"signups_by_day"`
+}
+
+// PlatformRevenue is fee income summed from the wallet ledger
+type PlatformRevenue struct {
+	BillGenerationFees float64 `json:"bill_generation_fees"`
+	VerificationFees   float64 `json:"verification_fees"`
+	Refunds            float64 `json:"refunds"`
+	Total              float64 `json:"total"` // Fees minus refunds
+}
+
+// DailyCount is a count for one calendar day (YYYY-MM-DD)
+type DailyCount struct {
+	Date  string `db:"date" json:"date"`
+	Count int    `db:"count" json:"count"`
+}
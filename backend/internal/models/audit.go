@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction names a privileged action recorded in the audit log
+type AuditAction string
+
+const (
+	AuditUserActivate   AuditAction = "user.activate"
+	AuditUserDeactivate AuditAction = "user.deactivate"
+	AuditUserRoleChange AuditAction = "user.role_change"
+	AuditUserPlanChange AuditAction = "user.plan_change"
+	AuditWalletAdjust   AuditAction = "wallet.adjust"
+	AuditKYCReview      AuditAction = "kyc.review"
+	AuditInviteCreate   AuditAction = "invite.create"
+	AuditBillDelete     AuditAction = "bill.delete"
+	AuditBillRestore    AuditAction = "bill.restore"
+	AuditBillTransfer   AuditAction = "bill.transfer"
+)
+
+// AuditTargetType is the kind of record an audited action changed
+type AuditTargetType string
+
+const (
+	AuditTargetUser   AuditTargetType = "user"
+	AuditTargetBill   AuditTargetType = "bill"
+	AuditTargetInvite AuditTargetType = "invite"
+)
+
+// AuditEntry is one row of the audit log
+type AuditEntry struct {
+	ID         string          `db:"id" json:"id"`
+	ActorID    *string         `db:"actor_id" json:"actor_id"` // NULL once the actor's account is gone
+	Action     AuditAction     `db:"action" json:"action"`
+	TargetType AuditTargetType `db:"target_type" json:"target_type"`
+	TargetID   string          `db:"target_id" json:"target_id"`
+	RequestID  *string         `db:"request_id" json:"request_id,omitempty"`
+	Metadata   json.RawMessage `db:"metadata" json:"metadata"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+}
+
+// AuditFilter narrows an audit log search
+// Nil fields are not filtered on
+type AuditFilter struct {
+	ActorID    *string
+	Action     *AuditAction
+	TargetType *AuditTargetType
+	TargetID   *string
+	StartDate  *time.Time
+	EndDate    *time.Time // Inclusive: entries from the whole day are included
+}
+
+// AuditChange is the metadata of an action that changed a value
+func AuditChange(before, after interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"before": before,
+		"after":  after,
+	}
+}
@@ -49,6 +49,7 @@ type Bill struct {
 	BillNumber   string           `db:"bill_number" json:"bill_number"`
 	BillType     BillType         `db:"bill_type" json:"bill_type"`
 	AccessLevel  AccessLevel      `db:"access_level" json:"access_level"`
+	AllowPreview bool             `db:"allow_preview" json:"allow_preview"` // Public verifiers may confirm existence for the minimum fee
 	
 	// Issuer information
 	IssuerID     string           `db:"issuer_id" json:"issuer_id"`
@@ -63,32 +64,208 @@ type Bill struct {
 	
 	// Date
 	IssueDate    time.Time        `db:"issue_date" json:"issue_date"`
+	ValidUntil   *time.Time       `db:"valid_until" json:"valid_until,omitempty"` // Last valid day, nil if it never expires
 	
 	// Blockchain
 	DataHash              string           `db:"data_hash" json:"data_hash"`
 	BlockchainTxID        *string          `db:"blockchain_tx_id" json:"blockchain_tx_id,omitempty"`
 	BlockchainStatus      BlockchainStatus `db:"blockchain_status" json:"blockchain_status"`
 	BlockchainConfirmedAt *time.Time       `db:"blockchain_confirmed_at" json:"blockchain_confirmed_at,omitempty"`
+
+	// Hash of the submitted content only, for spotting double-submits; nil for older bills
+	ContentHash *string `db:"content_hash" json:"-"`
 	
 	// Metadata
 	IsActive     bool             `db:"is_active" json:"is_active"`
 	IsDeleted    bool             `db:"is_deleted" json:"is_deleted"`
+	NeedsReview  bool             `db:"needs_review" json:"needs_review"` // Amount above the review threshold
 	DeletionReason *string        `db:"deletion_reason" json:"deletion_reason,omitempty"`
 	DeletedAt    *time.Time       `db:"deleted_at" json:"deleted_at,omitempty"`
 	
 	// Timestamps
 	CreatedAt    time.Time        `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time        `db:"updated_at" json:"updated_at"`
+
+	// Outcome of the recipient notification requested on creation - not persisted
+	NotificationStatus RecipientNotificationStatus `db:"-" json:"-"`
 }
 
+// RecipientNotificationStatus reports whether the recipient was emailed about a new bill
+type RecipientNotificationStatus string
+
+const (
+	NotificationQueued       RecipientNotificationStatus = "queued"
+	NotificationMissingEmail RecipientNotificationStatus = "missing_recipient_email"
+	NotificationInvalidEmail RecipientNotificationStatus = "invalid_recipient_email"
+	NotificationNotRequested RecipientNotificationStatus = ""
+)
+
 // CreateBillRequest represents the request to create a new bill
 type CreateBillRequest struct {
 	BillType    BillType               `json:"bill_type" binding:"required"`
 	AccessLevel AccessLevel            `json:"access_level" binding:"required"`
-	IssuerGSTIN string                 `json:"issuer_gstin"`
+	IssuerGSTIN string                 `json:"issuer_gstin" binding:"omitempty,gstin"`
 	Amount      float64                `json:"amount" binding:"required,gt=0"`
+	Currency    string                 `json:"currency"`                       // Optional ISO 4217 code, defaults to the platform default
 	IssueDate   string                 `json:"issue_date" binding:"required"` // Format: YYYY-MM-DD
+	ValidUntil  string                 `json:"valid_until"`                    // Optional, format: YYYY-MM-DD
 	BillData    map[string]interface{} `json:"bill_data" binding:"required"`
+
+	// Let public verifiers confirm a government/financial bill exists without seeing its data
+	AllowPreview bool `json:"allow_preview"`
+
+	// Email the bill to bill_data.recipient_email once it is created
+	NotifyRecipient bool `json:"notify_recipient"`
+
+	// Create the bill even if an identical one was just created (see App.DuplicateBillWindow)
+	AllowDuplicate bool `json:"allow_duplicate"`
+}
+
+// BulkCreateBillRequest represents a batch of bills generated in one request
+// Items are validated individually so one bad row doesn't reject the whole batch
+type BulkCreateBillRequest struct {
+	Bills []CreateBillRequest `json:"bills" binding:"required,min=1"`
+}
+
+// BulkBillResult is the outcome of one item in a bulk request
+type BulkBillResult struct {
+	Index      int    `json:"index"`
+	Success    bool   `json:"success"`
+	BillID     string `json:"bill_id,omitempty"`
+	BillNumber string `json:"bill_number,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BulkCreateBillResponse summarises a bulk request
+type BulkCreateBillResponse struct {
+	Total       int               `json:"total"`
+	Created     int               `json:"created"`
+	Failed      int               `json:"failed"`
+	FeeCharged  float64           `json:"fee_charged"`
+	BalanceLeft float64           `json:"wallet_balance"`
+	Results     []*BulkBillResult `json:"results"`
+}
+
+// AdminBillFilter narrows a system-wide bill search by master admins
+// Nil fields are not filtered on
+type AdminBillFilter struct {
+	IssuerID         *string
+	BillType         *BillType
+	BlockchainStatus *BlockchainStatus
+	StartDate        *time.Time
+	EndDate          *time.Time
+	NeedsReview      *bool
+	IncludeDeleted   bool
+}
+
+// UpdateBillRequest represents a correction to an existing bill
+// Omitted fields keep their current value
+type UpdateBillRequest struct {
+	BillData map[string]interface{} `json:"bill_data"`
+	Amount   *float64               `json:"amount" binding:"omitempty,gt=0"`
+	Reason   string                 `json:"reason" binding:"required"`
+}
+
+// BillRevision is an archived version of a bill's content
+type BillRevision struct {
+	ID             string          `db:"id" json:"id"`
+	BillID         string          `db:"bill_id" json:"bill_id"`
+	RevisionNumber int             `db:"revision_number" json:"revision_number"`
+	BillData       json.RawMessage `db:"bill_data" json:"bill_data"`
+	DataHash       string          `db:"data_hash" json:"data_hash"`
+	Amount         float64         `db:"amount" json:"amount"`
+	BlockchainTxID *string         `db:"blockchain_tx_id" json:"blockchain_tx_id,omitempty"`
+	EditedBy       *string         `db:"edited_by" json:"edited_by,omitempty"`
+	EditReason     *string         `db:"edit_reason" json:"edit_reason,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
+
+// BillTransferStatus is where a bill ownership transfer stands
+type BillTransferStatus string
+
+const (
+	BillTransferPending   BillTransferStatus = "pending"   // Waiting for the receiving account to accept
+	BillTransferCompleted BillTransferStatus = "completed" // The bill now belongs to ToIssuerID
+	BillTransferCancelled BillTransferStatus = "cancelled" // Replaced by a newer request, or the bill changed hands first
+)
+
+// BillTransfer moves a bill to another institution account, keeping its number, hash and verification history
+// Completed transfers are the bill's ownership audit trail
+type BillTransfer struct {
+	ID                 string             `db:"id" json:"id"`
+	BillID             string             `db:"bill_id" json:"bill_id"`
+	FromIssuerID       string             `db:"from_issuer_id" json:"from_issuer_id"`
+	ToIssuerID         string             `db:"to_issuer_id" json:"to_issuer_id"`
+	RequestedBy        string             `db:"requested_by" json:"requested_by"`
+	Status             BillTransferStatus `db:"status" json:"status"`
+	UpdateIssuerName   bool               `db:"update_issuer_name" json:"update_issuer_name"`
+	PreviousIssuerName string             `db:"previous_issuer_name" json:"previous_issuer_name"`
+	NewIssuerName      *string            `db:"new_issuer_name" json:"new_issuer_name,omitempty"`
+	ConfirmationToken  *string            `db:"confirmation_token" json:"-"` // Only ever sent to the receiving account
+	ExpiresAt          *time.Time         `db:"expires_at" json:"expires_at,omitempty"`
+	CreatedAt          time.Time          `db:"created_at" json:"created_at"`
+	CompletedAt        *time.Time         `db:"completed_at" json:"completed_at,omitempty"`
+}
+
+// TransferBillRequest asks to move a bill to another institution account
+// Master admins transfer immediately; issuers start a transfer the target account must accept
+type TransferBillRequest struct {
+	TargetUserID     string `json:"target_user_id" binding:"required,uuid"`
+	UpdateIssuerName bool   `json:"update_issuer_name"` // Show the target's organization name on the bill from now on
+}
+
+// ConfirmBillTransferRequest accepts a pending transfer with the token emailed to the receiving account
+type ConfirmBillTransferRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyHashRequest is a copy of a bill's data to check against its registered hash
+// BillData must be the bill_data exactly as issued, including its _metadata block
+type VerifyHashRequest struct {
+	BillData map[string]interface{} `json:"bill_data" binding:"required"`
+}
+
+// VerifyHashResponse reports whether submitted bill data matches the bill's registered hash
+type VerifyHashResponse struct {
+	BillNumber   string `json:"bill_number"`
+	Match        bool   `json:"match"`
+	ComputedHash string `json:"computed_hash"`
+	StoredHash   string `json:"stored_hash"`
+	Message      string `json:"message"`
+}
+
+// BillAlias maps a short code to a bill so verification links don't expose the bill number
+type BillAlias struct {
+	ShortCode string    `db:"short_code" json:"short_code"`
+	BillID    string    `db:"bill_id" json:"bill_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ShortLinkResponse is a bill's newly generated short verification link
+type ShortLinkResponse struct {
+	BillID           string    `json:"bill_id"`
+	BillNumber       string    `json:"bill_number"`
+	ShortCode        string    `json:"short_code"`
+	VerificationLink string    `json:"verification_link"`
+	QRCode           string    `json:"qr_code"` // Base64 PNG data URL encoding the short link
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// BillTrendRow is an issuer's bill count and total value in one time bucket, as aggregated in SQL
+type BillTrendRow struct {
+	Bucket time.Time `db:"bucket"`
+	Count  int       `db:"count"`
+	Amount float64   `db:"amount"`
+}
+
+// BillTrendBucket is how many bills an issuer generated in one day or week, and their total value
+// Running totals are only set when requested, and count from the start of the range
+type BillTrendBucket struct {
+	Start         string   `json:"start"` // YYYY-MM-DD; weeks start on Monday
+	Count         int      `json:"count"`
+	Amount        float64  `json:"amount"`
+	RunningCount  *int     `json:"running_count,omitempty"`
+	RunningAmount *float64 `json:"running_amount,omitempty"`
 }
 
 // BillResponse represents a bill in API responses
@@ -97,10 +274,12 @@ type BillResponse struct {
 	BillNumber      string                 `json:"bill_number"`
 	BillType        string                 `json:"bill_type"`
 	AccessLevel     string                 `json:"access_level"`
+	AllowPreview    bool                   `json:"allow_preview"`
 	IssuerName      string                 `json:"issuer_name"`
 	Amount          float64                `json:"amount"`
 	Currency        string                 `json:"currency"`
 	IssueDate       string                 `json:"issue_date"`
+	ValidUntil      string                 `json:"valid_until,omitempty"`
 	DataHash        string                 `json:"data_hash"`
 	BlockchainStatus string                `json:"blockchain_status"`
 	BillData        map[string]interface{} `json:"bill_data,omitempty"`
@@ -116,17 +295,76 @@ type BillListResponse struct {
 	Amount          float64 `json:"amount"`
 	IssueDate       string  `json:"issue_date"`
 	VerificationCount int   `json:"verification_count"` // Will be added later
-	Status          string  `json:"status"` // active/verified/pending
+	Status          string  `json:"status"` // active/verified/pending/deleted
 	CreatedAt       string  `json:"created_at"`
+
+	// Only set for soft-deleted bills, which are listed when include_deleted is requested
+	DeletionReason *string `json:"deletion_reason,omitempty"`
+	DeletedAt      string  `json:"deleted_at,omitempty"`
 }
 
 // BillStats represents statistics for dashboard
 type BillStats struct {
-	TotalBills       int     `json:"total_bills"`
-	ThisMonthBills   int     `json:"this_month_bills"`
-	TotalVerifications int   `json:"total_verifications"`
-	ActiveBills      int     `json:"active_bills"`
-	TotalAmount      float64 `json:"total_amount"`
+	TotalBills         int     `db:"total_bills" json:"total_bills"`
+	ThisMonthBills     int     `db:"this_month_bills" json:"this_month_bills"`
+	TotalVerifications int     `db:"total_verifications" json:"total_verifications"`
+	ActiveBills        int     `db:"active_bills" json:"active_bills"`
+	TotalAmount        float64 `db:"total_amount" json:"total_amount"`
+}
+
+// IsExpired reports whether the bill's validity window ended before now
+// ValidUntil is inclusive, so a bill expires at the start of the following day in now's location
+func (b *Bill) IsExpired(now time.Time) bool {
+	if b.ValidUntil == nil {
+		return false
+	}
+	year, month, day := b.ValidUntil.Date()
+	return !now.Before(time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()))
+}
+
+// BillNumberPrefixLength is the part of a generated bill number naming its type and month,
+// e.g. "INV202501" of "INV202501000042"
+const BillNumberPrefixLength = 9
+
+// BillNumberPrefix returns the type-and-month prefix of a number in the generated format
+// (three capital letters, YYYYMM, a six-digit sequence), or false for anything else such as a short code
+func BillNumberPrefix(number string) (string, bool) {
+	if len(number) != BillNumberPrefixLength+6 {
+		return "", false
+	}
+	for i := 0; i < len(number); i++ {
+		c := number[i]
+		if i < 3 && (c < 'A' || c > 'Z') || i >= 3 && (c < '0' || c > '9') {
+			return "", false
+		}
+	}
+	return number[:BillNumberPrefixLength], true
+}
+
+// IsValid reports whether the bill type is one of the known types
+func (b BillType) IsValid() bool {
+	switch b {
+	case BillTypeSalarySlip, BillTypeSalesInvoice, BillTypeMedicalBill, BillTypePurchaseInvoice,
+		BillTypeRentalAgreement, BillTypeEducationFee, BillTypeRentReceipt, BillTypeReimbursement,
+		BillTypeLoanStatement, BillTypeTaxReceipt, BillTypeInsurancePolicy, BillTypeOther:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether the access level is one of the known levels
+func (a AccessLevel) IsValid() bool {
+	switch a {
+	case AccessLevelPublic, AccessLevelRestricted, AccessLevelGovernment, AccessLevelFinancial:
+		return true
+	}
+	return false
+}
+
+// SupportsPreview reports whether bills at this level can offer a preview to users without full access
+// Lower levels already show at least limited details to everyone
+func (a AccessLevel) SupportsPreview() bool {
+	return a == AccessLevelGovernment || a == AccessLevelFinancial
 }
 
 // Value/Scan implementations for custom types
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BillFieldType is the JSON type a bill_data field must have
+type BillFieldType string
+
+const (
+	BillFieldString BillFieldType = "string"
+	BillFieldNumber BillFieldType = "number"
+	BillFieldObject BillFieldType = "object"
+	BillFieldArray  BillFieldType = "array"
+)
+
+// BillDataField describes one field of a bill type's bill_data
+type BillDataField struct {
+	Name     string        `json:"name"`
+	Label    string        `json:"label"`
+	Type     BillFieldType `json:"type"`
+	Required bool          `json:"required"`
+}
+
+// BillDataSchema lists the bill_data fields a bill type expects
+// Fields not listed are accepted as-is, so issuers can add their own
+type BillDataSchema struct {
+	BillType BillType        `json:"bill_type"`
+	Fields   []BillDataField `json:"fields"`
+}
+
+// BillDataFieldError describes why one bill_data field was rejected
+type BillDataFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// commonBillFields appear on the PDF for every bill type
+var commonBillFields = []BillDataField{
+	{Name: "recipient_name", Label: "Recipient Name", Type: BillFieldString},
+	{Name: "recipient_email", Label: "Recipient Email", Type: BillFieldString},
+	{Name: "recipient_phone", Label: "Recipient Phone", Type: BillFieldString},
+	{Name: "description", Label: "Description", Type: BillFieldString},
+}
+
+// billDataFields are the type-specific fields for each bill type
+// BillTypeOther has none and accepts any bill_data
+var billDataFields = map[BillType][]BillDataField{
+	BillTypeSalarySlip: {
+		{Name: "employee_name", Label: "Employee Name", Type: BillFieldString, Required: true},
+		{Name: "employee_id", Label: "Employee ID", Type: BillFieldString},
+		{Name: "designation", Label: "Designation", Type: BillFieldString},
+		{Name: "department", Label: "Department", Type: BillFieldString},
+		{Name: "month", Label: "Month", Type: BillFieldString, Required: true},
+		{Name: "earnings", Label: "Earnings", Type: BillFieldObject},
+		{Name: "deductions", Label: "Deductions", Type: BillFieldObject},
+	},
+	BillTypeSalesInvoice:    invoiceFields,
+	BillTypePurchaseInvoice: invoiceFields,
+	BillTypeMedicalBill: {
+		{Name: "patient_name", Label: "Patient Name", Type: BillFieldString, Required: true},
+		{Name: "patient_id", Label: "Patient ID", Type: BillFieldString},
+		{Name: "doctor_name", Label: "Doctor Name", Type: BillFieldString},
+		{Name: "treatment", Label: "Treatment", Type: BillFieldString},
+		{Name: "admission_date", Label: "Admission Date", Type: BillFieldString},
+		{Name: "discharge_date", Label: "Discharge Date", Type: BillFieldString},
+	},
+	BillTypeRentalAgreement: {
+		{Name: "landlord_name", Label: "Landlord Name", Type: BillFieldString, Required: true},
+		{Name: "tenant_name", Label: "Tenant Name", Type: BillFieldString, Required: true},
+		{Name: "property_address", Label: "Property Address", Type: BillFieldString, Required: true},
+		{Name: "monthly_rent", Label: "Monthly Rent", Type: BillFieldNumber},
+		{Name: "start_date", Label: "Start Date", Type: BillFieldString},
+		{Name: "end_date", Label: "End Date", Type: BillFieldString},
+	},
+	BillTypeEducationFee: {
+		{Name: "student_name", Label: "Student Name", Type: BillFieldString, Required: true},
+		{Name: "student_id", Label: "Student ID", Type: BillFieldString},
+		{Name: "course", Label: "Course", Type: BillFieldString},
+		{Name: "academic_year", Label: "Academic Year", Type: BillFieldString},
+	},
+	BillTypeRentReceipt: {
+		{Name: "tenant_name", Label: "Tenant Name", Type: BillFieldString, Required: true},
+		{Name: "property_address", Label: "Property Address", Type: BillFieldString, Required: true},
+		{Name: "month", Label: "Month", Type: BillFieldString, Required: true},
+		{Name: "payment_mode", Label: "Payment Mode", Type: BillFieldString},
+	},
+	BillTypeReimbursement: {
+		{Name: "employee_name", Label: "Employee Name", Type: BillFieldString, Required: true},
+		{Name: "employee_id", Label: "Employee ID", Type: BillFieldString},
+		{Name: "expense_category", Label: "Expense Category", Type: BillFieldString, Required: true},
+		{Name: "expense_date", Label: "Expense Date", Type: BillFieldString},
+	},
+	BillTypeLoanStatement: {
+		{Name: "borrower_name", Label: "Borrower Name", Type: BillFieldString, Required: true},
+		{Name: "loan_account_number", Label: "Loan Account Number", Type: BillFieldString, Required: true},
+		{Name: "statement_period", Label: "Statement Period", Type: BillFieldString},
+		{Name: "outstanding_amount", Label: "Outstanding Amount", Type: BillFieldNumber},
+	},
+	BillTypeTaxReceipt: {
+		{Name: "taxpayer_name", Label: "Taxpayer Name", Type: BillFieldString, Required: true},
+		{Name: "pan", Label: "PAN", Type: BillFieldString},
+		{Name: "assessment_year", Label: "Assessment Year", Type: BillFieldString, Required: true},
+	},
+	BillTypeInsurancePolicy: {
+		{Name: "policyholder_name", Label: "Policyholder Name", Type: BillFieldString, Required: true},
+		{Name: "policy_number", Label: "Policy Number", Type: BillFieldString, Required: true},
+		{Name: "sum_assured", Label: "Sum Assured", Type: BillFieldNumber},
+		{Name: "policy_term", Label: "Policy Term", Type: BillFieldString},
+	},
+}
+
+// invoiceFields are shared by sales and purchase invoices, which render the same way
+var invoiceFields = []BillDataField{
+	{Name: "invoice_number", Label: "Invoice Number", Type: BillFieldString, Required: true},
+	{Name: "customer_name", Label: "Customer Name", Type: BillFieldString, Required: true},
+	{Name: "customer_address", Label: "Customer Address", Type: BillFieldString},
+	{Name: "gstin", Label: "GSTIN", Type: BillFieldString},
+	{Name: "line_items", Label: "Line Items", Type: BillFieldArray},
+}
+
+// SchemaFor returns the bill_data schema for a bill type
+// Returns false for unknown bill types
+func SchemaFor(billType BillType) (*BillDataSchema, bool) {
+	if !billType.IsValid() {
+		return nil, false
+	}
+
+	schema := &BillDataSchema{BillType: billType}
+	if billType == BillTypeOther {
+		return schema, true
+	}
+
+	schema.Fields = append(schema.Fields, commonBillFields...)
+	schema.Fields = append(schema.Fields, billDataFields[billType]...)
+	return schema, true
+}
+
+// Validate checks bill_data against the schema and returns one error per bad field
+// An empty result means the data is valid
+func (s *BillDataSchema) Validate(data map[string]interface{}) []BillDataFieldError {
+	var fieldErrors []BillDataFieldError
+	for _, field := range s.Fields {
+		val, exists := data[field.Name]
+		if !exists || val == nil {
+			if field.Required {
+				fieldErrors = append(fieldErrors, BillDataFieldError{Field: field.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		if !field.Type.matches(val) {
+			fieldErrors = append(fieldErrors, BillDataFieldError{
+				Field:   field.Name,
+				Message: fmt.Sprintf("must be a %s", field.Type),
+			})
+			continue
+		}
+
+		if field.Required && field.Type == BillFieldString && strings.TrimSpace(val.(string)) == "" {
+			fieldErrors = append(fieldErrors, BillDataFieldError{Field: field.Name, Message: "is required"})
+		}
+	}
+	return fieldErrors
+}
+
+// matches reports whether a decoded JSON value has this type
+func (t BillFieldType) matches(val interface{}) bool {
+	switch t {
+	case BillFieldString:
+		_, ok := val.(string)
+		return ok
+	case BillFieldNumber:
+		switch val.(type) {
+		case float64, float32, int, int64:
+			return true
+		}
+		return false
+	case BillFieldObject:
+		_, ok := val.(map[string]interface{})
+		return ok
+	case BillFieldArray:
+		_, ok := val.([]interface{})
+		return ok
+	}
+	return true
+}
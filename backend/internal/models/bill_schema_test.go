@@ -0,0 +1,128 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBillDataSchemaRequiredFields(t *testing.T) {
+	tests := []struct {
+		billType BillType
+		required []string
+	}{
+		{BillTypeSalarySlip, []string{"employee_name", "month"}},
+		{BillTypeSalesInvoice, []string{"invoice_number", "customer_name"}},
+		{BillTypePurchaseInvoice, []string{"invoice_number", "customer_name"}},
+		{BillTypeMedicalBill, []string{"patient_name"}},
+		{BillTypeRentalAgreement, []string{"landlord_name", "tenant_name", "property_address"}},
+		{BillTypeEducationFee, []string{"student_name"}},
+		{BillTypeRentReceipt, []string{"tenant_name", "property_address", "month"}},
+		{BillTypeReimbursement, []string{"employee_name", "expense_category"}},
+		{BillTypeLoanStatement, []string{"borrower_name", "loan_account_number"}},
+		{BillTypeTaxReceipt, []string{"taxpayer_name", "assessment_year"}},
+		{BillTypeInsurancePolicy, []string{"policyholder_name", "policy_number"}},
+		{BillTypeOther, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.billType), func(t *testing.T) {
+			schema, ok := SchemaFor(tt.billType)
+			if !ok {
+				t.Fatalf("no schema for %s", tt.billType)
+			}
+
+			// Empty bill_data reports exactly the required fields
+			var missing []string
+			for _, fieldErr := range schema.Validate(map[string]interface{}{}) {
+				if fieldErr.Message != "is required" {
+					t.Errorf("%s: message = %q, want is required", fieldErr.Field, fieldErr.Message)
+				}
+				missing = append(missing, fieldErr.Field)
+			}
+			sort.Strings(missing)
+			want := append([]string(nil), tt.required...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(missing, want) {
+				t.Errorf("missing fields = %v, want %v", missing, want)
+			}
+
+			data := map[string]interface{}{}
+			for _, name := range tt.required {
+				data[name] = "filled in"
+			}
+			if fieldErrors := schema.Validate(data); len(fieldErrors) > 0 {
+				t.Fatalf("complete bill_data rejected: %v", fieldErrors)
+			}
+
+			// Blank strings don't satisfy a required field
+			for _, name := range tt.required {
+				data[name] = "  "
+				if fieldErrors := schema.Validate(data); len(fieldErrors) != 1 || fieldErrors[0].Field != name {
+					t.Errorf("blank %s: errors = %v, want one for %s", name, fieldErrors, name)
+				}
+				data[name] = "filled in"
+			}
+		})
+	}
+}
+
+func TestBillDataSchemaFieldTypes(t *testing.T) {
+	schema, _ := SchemaFor(BillTypeRentalAgreement)
+	base := map[string]interface{}{
+		"landlord_name":    "Meera Iyer",
+		"tenant_name":      "Arjun Das",
+		"property_address": "12 MG Road, Bengaluru",
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+		want  string
+	}{
+		{"number accepted", "monthly_rent", 25000.0, ""},
+		{"number given as string", "monthly_rent", "25000", "must be a number"},
+		{"string given as number", "tenant_name", 42.0, "must be a string"},
+		{"null optional field", "monthly_rent", nil, ""},
+		{"unlisted field accepted", "parking_slot", "B-12", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]interface{}{}
+			for k, v := range base {
+				data[k] = v
+			}
+			data[tt.field] = tt.value
+
+			fieldErrors := schema.Validate(data)
+			if tt.want == "" {
+				if len(fieldErrors) > 0 {
+					t.Errorf("errors = %v, want none", fieldErrors)
+				}
+				return
+			}
+			if len(fieldErrors) != 1 || fieldErrors[0].Field != tt.field || fieldErrors[0].Message != tt.want {
+				t.Errorf("errors = %v, want %s %s", fieldErrors, tt.field, tt.want)
+			}
+		})
+	}
+}
+
+func TestBillDataSchemaOtherIsPermissive(t *testing.T) {
+	schema, ok := SchemaFor(BillTypeOther)
+	if !ok {
+		t.Fatal("no schema for other")
+	}
+	data := map[string]interface{}{"recipient_name": 7.0, "anything": []interface{}{"goes"}}
+	if fieldErrors := schema.Validate(data); len(fieldErrors) > 0 {
+		t.Errorf("errors = %v, want none", fieldErrors)
+	}
+}
+
+func TestSchemaForUnknownBillType(t *testing.T) {
+	if _, ok := SchemaFor("utility_bill"); ok {
+		t.Error("SchemaFor returned a schema for an unknown bill type")
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillIsExpired(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	validUntil := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		validUntil *time.Time
+		now        time.Time
+		want       bool
+	}{
+		{"no validity window", nil, time.Date(2099, time.January, 1, 0, 0, 0, 0, time.UTC), false},
+		{"day before", &validUntil, time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC), false},
+		{"start of the last day", &validUntil, time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), false},
+		{"end of the last day", &validUntil, time.Date(2024, time.March, 31, 23, 59, 59, 0, time.UTC), false},
+		{"start of the next day", &validUntil, time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), true},
+		{"years later", &validUntil, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC), true},
+		// The day boundary falls at midnight in now's location, not UTC
+		{"last minute of the day in IST", &validUntil, time.Date(2024, time.March, 31, 23, 59, 0, 0, ist), false},
+		{"midnight in IST", &validUntil, time.Date(2024, time.April, 1, 0, 0, 0, 0, ist), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bill := &Bill{ValidUntil: tt.validUntil}
+			if got := bill.IsExpired(tt.now); got != tt.want {
+				t.Errorf("IsExpired(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
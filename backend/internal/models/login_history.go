@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LoginEvent records one successful sign-in
+type LoginEvent struct {
+	ID        string    `db:"id" json:"id"`
+	UserID    string    `db:"user_id" json:"-"`
+	IPAddress *string   `db:"ip_address" json:"ip_address,omitempty"`
+	UserAgent *string   `db:"user_agent" json:"user_agent,omitempty"`
+	Browser   *string   `db:"browser" json:"browser,omitempty"`
+	OS        *string   `db:"os" json:"os,omitempty"`
+	Device    *string   `db:"device" json:"device,omitempty"`
+	Location  *string   `db:"location" json:"location,omitempty"` // Coarse, e.g. "Chennai, Tamil Nadu, India"
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
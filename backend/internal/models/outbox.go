@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// OutboxKind identifies what an outbox message delivers
+type OutboxKind string
+
+const (
+	OutboxBillEmail           OutboxKind = "bill_email"
+	OutboxBillVerifiedWebhook OutboxKind = "bill_verified_webhook"
+	OutboxVerificationReceipt OutboxKind = "verification_receipt"
+	OutboxFakeBillAlertEmail  OutboxKind = "fake_bill_alert_email"
+)
+
+// OutboxStatus represents where an outbox message is in its lifecycle
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	OutboxFailed  OutboxStatus = "failed"
+)
+
+// OutboxMessage is a notification queued alongside the change that triggered it
+type OutboxMessage struct {
+	ID            string          `db:"id" json:"id"`
+	Kind          OutboxKind      `db:"kind" json:"kind"`
+	Payload       json.RawMessage `db:"payload" json:"payload"`
+	Status        OutboxStatus    `db:"status" json:"status"`
+	Attempts      int             `db:"attempts" json:"attempts"`
+	LastError     *string         `db:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	SentAt        *time.Time      `db:"sent_at" json:"sent_at,omitempty"`
+}
+
+// BillEmailMessage is the payload of an OutboxBillEmail message
+type BillEmailMessage struct {
+	BillNumber     string `json:"bill_number"`
+	RecipientEmail string `json:"recipient_email"`
+}
+
+// BillVerifiedWebhookMessage is the payload of an OutboxBillVerifiedWebhook message
+type BillVerifiedWebhookMessage struct {
+	IssuerID string              `json:"issuer_id"`
+	Payload  BillVerifiedPayload `json:"payload"`
+}
+
+// VerificationReceiptMessage is the payload of an OutboxVerificationReceipt message
+// It snapshots the charge so the receipt matches what was billed even if the wallet changes before it is sent
+type VerificationReceiptMessage struct {
+	UserID         string             `json:"user_id"`
+	VerificationID string             `json:"verification_id"`
+	BillNumber     string             `json:"bill_number"`
+	Result         VerificationStatus `json:"result"`
+	AmountCharged  float64            `json:"amount_charged"`
+	WasFree        bool               `json:"was_free"`
+	CoveredByPlan  bool               `json:"covered_by_plan"`
+	BalanceAfter   float64            `json:"balance_after"`
+	VerifiedAt     time.Time          `json:"verified_at"`
+}
+
+// FakeBillAlertMessage is the payload of an OutboxFakeBillAlertEmail message, which goes to every active master admin
+type FakeBillAlertMessage struct {
+	Payload FakeBillAlertPayload `json:"payload"`
+}
+
+// NewOutboxMessage builds a pending message with its payload encoded
+func NewOutboxMessage(kind OutboxKind, payload interface{}) (*OutboxMessage, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMessage{Kind: kind, Payload: body, Status: OutboxPending}, nil
+}
+
+// Value/Scan implementations
+
+func (k OutboxKind) Value() (driver.Value, error) {
+	return string(k), nil
+}
+
+func (k *OutboxKind) Scan(value interface{}) error {
+	if sv, ok := value.(string); ok {
+		*k = OutboxKind(sv)
+		return nil
+	}
+	if bv, ok := value.([]byte); ok {
+		*k = OutboxKind(string(bv))
+		return nil
+	}
+	return nil
+}
+
+func (s OutboxStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s *OutboxStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = OutboxPending
+		return nil
+	}
+	if sv, ok := value.(string); ok {
+		*s = OutboxStatus(sv)
+		return nil
+	}
+	if bv, ok := value.([]byte); ok {
+		*s = OutboxStatus(string(bv))
+		return nil
+	}
+	return nil
+}
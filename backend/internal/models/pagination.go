@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// PageCursor marks the last row of a page for keyset pagination
+// Rows are ordered by (timestamp, id) descending, so the next page starts strictly after this pair
+// and rows inserted between fetches can't shift later pages the way OFFSET does
+type PageCursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe string for clients to pass back
+func (c *PageCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodePageCursor parses a cursor produced by Encode
+func DecodePageCursor(s string) (*PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c PageCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" || c.Timestamp.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	cursor := &PageCursor{
+		Timestamp: time.Date(2026, 10, 17, 9, 30, 15, 123456000, time.UTC),
+		ID:        "7f9c2d1e-0000-4000-8000-000000000001",
+	}
+
+	decoded, err := DecodePageCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodePageCursor failed: %v", err)
+	}
+	// Microseconds must survive, or rows sharing a second would be skipped or repeated
+	if !decoded.Timestamp.Equal(cursor.Timestamp) || decoded.ID != cursor.ID {
+		t.Errorf("decoded %+v, want %+v", decoded, cursor)
+	}
+}
+
+func TestDecodePageCursorRejectsInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"not JSON", encode("hello")},
+		{"missing id", encode(`{"t":"2026-10-17T09:30:15Z"}`)},
+		{"missing timestamp", encode(`{"id":"abc"}`)},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodePageCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodePageCursor error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
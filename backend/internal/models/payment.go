@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// PaymentOrderStatus represents where a top-up order is in its lifecycle
+type PaymentOrderStatus string
+
+const (
+	PaymentOrderCreated PaymentOrderStatus = "created"
+	PaymentOrderPaid    PaymentOrderStatus = "paid"
+)
+
+// PaymentOrder represents a wallet top-up order at the payment gateway
+type PaymentOrder struct {
+	ID               string             `db:"id" json:"id"`
+	UserID           string             `db:"user_id" json:"user_id"`
+	Gateway          string             `db:"gateway" json:"gateway"`
+	GatewayOrderID   string             `db:"gateway_order_id" json:"gateway_order_id"`
+	GatewayPaymentID *string            `db:"gateway_payment_id" json:"gateway_payment_id,omitempty"`
+	Amount           float64            `db:"amount" json:"amount"`
+	Currency         string             `db:"currency" json:"currency"`
+	Status           PaymentOrderStatus `db:"status" json:"status"`
+	CreatedAt        time.Time          `db:"created_at" json:"created_at"`
+	PaidAt           *time.Time         `db:"paid_at" json:"paid_at,omitempty"`
+}
+
+// CreatePaymentOrderRequest represents the request to start a wallet top-up
+type CreatePaymentOrderRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// ConfirmPaymentRequest represents the signed payment callback from the gateway checkout
+type ConfirmPaymentRequest struct {
+	OrderID   string `json:"order_id" binding:"required"`
+	PaymentID string `json:"payment_id" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// Value/Scan implementations
+
+func (s PaymentOrderStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s *PaymentOrderStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = PaymentOrderCreated
+		return nil
+	}
+	if sv, ok := value.(string); ok {
+		*s = PaymentOrderStatus(sv)
+		return nil
+	}
+	if bv, ok := value.([]byte); ok {
+		*s = PaymentOrderStatus(string(bv))
+		return nil
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -35,12 +37,18 @@ type User struct {
 	Email            string    `db:"email" json:"email"`
 	PasswordHash     string    `db:"password_hash" json:"-"` // Never send password in JSON
 	Role             UserRole  `db:"role" json:"role"`
+	RoleChangedAt    *time.Time `db:"role_changed_at" json:"role_changed_at,omitempty"` // Last change by a master admin
+	RoleChangedBy    *string    `db:"role_changed_by" json:"role_changed_by,omitempty"`
 	
 	// Organization details
 	OrganizationName string    `db:"organization_name" json:"organization_name"`
 	OrganizationType *string   `db:"organization_type" json:"organization_type,omitempty"`
 	GSTIN            *string   `db:"gstin" json:"gstin,omitempty"`
 	PAN              *string   `db:"pan" json:"pan,omitempty"`
+
+	// Result of checking the PAN with a pan.Verifier ("unchecked" until a verifier has run)
+	PANVerificationStatus string     `db:"pan_verification_status" json:"pan_verification_status"`
+	PANVerifiedAt         *time.Time `db:"pan_verified_at" json:"pan_verified_at,omitempty"`
 	
 	// KYC verification
 	KYCStatus          KYCStatus  `db:"kyc_status" json:"kyc_status"`
@@ -56,19 +64,39 @@ type User struct {
 	VerificationCount        int     `db:"verification_count" json:"verification_count"`
 	FreeVerificationsEarned  int     `db:"free_verifications_earned" json:"free_verifications_earned"`
 	
+	// Prepaid verification plan (PlanType nil means pay per verification)
+	PlanType                 *string    `db:"plan_type" json:"plan_type,omitempty"`
+	PlanMonthlyQuota         int        `db:"plan_monthly_quota" json:"plan_monthly_quota"`
+	PlanQuotaUsed            int        `db:"plan_quota_used" json:"plan_quota_used"`
+	PlanResetsAt             *time.Time `db:"plan_resets_at" json:"plan_resets_at,omitempty"`
+	
 	// Account status
 	IsActive                 bool      `db:"is_active" json:"is_active"`
 	IsEmailVerified          bool      `db:"is_email_verified" json:"is_email_verified"`
 	EmailVerificationToken   *string   `db:"email_verification_token" json:"-"` // Don't expose in API
+	EmailVerificationExpiresAt *time.Time `db:"email_verification_expires_at" json:"-"`
 	
+	// Two-factor authentication (TOTP); the secret is encrypted and set from enable until disable
+	TwoFactorEnabled   bool       `db:"two_factor_enabled" json:"two_factor_enabled"`
+	TwoFactorSecret    *string    `db:"two_factor_secret" json:"-"`
+	TwoFactorEnabledAt *time.Time `db:"two_factor_enabled_at" json:"two_factor_enabled_at,omitempty"`
+	TwoFactorLastStep  *int64     `db:"two_factor_last_step" json:"-"` // Last accepted code's time step
+
 	// Password reset
 	PasswordResetToken       *string   `db:"password_reset_token" json:"-"`
 	PasswordResetExpiresAt   *time.Time `db:"password_reset_expires_at" json:"-"`
 	
+	// Notifications
+	LastLowBalanceNotifiedAt *time.Time `db:"last_low_balance_notified_at" json:"-"`
+	WebhookURL               *string    `db:"webhook_url" json:"webhook_url,omitempty"`
+	WebhookSecret            *string    `db:"webhook_secret" json:"-"` // Signs webhook payloads
+	NotificationPreferences  NotificationPreferences `db:"notification_preferences" json:"notification_preferences"`
+	
 	// Timestamps
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 	LastLoginAt *time.Time `db:"last_login_at" json:"last_login_at,omitempty"`
+	ErasedAt    *time.Time `db:"erased_at" json:"erased_at,omitempty"` // Personal data was scrubbed on request
 }
 
 // PublicUser returns a safe version of User without sensitive data
@@ -84,8 +112,79 @@ func (u *User) PublicUser() map[string]interface{} {
 		"wallet_balance":     u.WalletBalance,
 		"is_active":          u.IsActive,
 		"is_email_verified":  u.IsEmailVerified,
+		"two_factor_enabled": u.TwoFactorEnabled,
 		"created_at":         u.CreatedAt,
+
+		// Loyalty progress
+		"verification_count":        u.VerificationCount,
+		"free_verifications_earned": u.FreeVerificationsEarned,
+
+		// Organization and KYC details (nil when not provided)
+		"organization_type":    u.OrganizationType,
+		"gstin":                u.GSTIN,
+		"pan_verification_status": u.PANVerificationStatus,
+		"kyc_verified_at":      u.KYCVerifiedAt,
+		"kyc_rejection_reason": u.KYCRejectionReason,
+		"role_changed_at":      u.RoleChangedAt,
+	}
+}
+
+// NotificationPreferences controls which optional emails a user receives
+// Transactional emails (email verification, password reset, KYC decisions) are always sent
+type NotificationPreferences struct {
+	DailySummary         bool `json:"daily_summary"`         // Daily summary of bills issued
+	LoginAlerts          bool `json:"login_alerts"`          // Email on every sign-in
+	LowBalance           bool `json:"low_balance"`           // Wallet running low warnings
+	VerificationAlerts   bool `json:"verification_alerts"`   // Email when one of your bills is verified
+	VerificationReceipts bool `json:"verification_receipts"` // Receipt for each bill you verify
+}
+
+// DefaultNotificationPreferences returns the preferences new users start with - everything enabled
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{
+		DailySummary:         true,
+		LoginAlerts:          true,
+		LowBalance:           true,
+		VerificationAlerts:   true,
+		VerificationReceipts: true,
+	}
+}
+
+// UpdateNotificationPreferencesRequest changes a user's email preferences
+// Omitted fields keep their current value
+type UpdateNotificationPreferencesRequest struct {
+	DailySummary         *bool `json:"daily_summary"`
+	LoginAlerts          *bool `json:"login_alerts"`
+	LowBalance           *bool `json:"low_balance"`
+	VerificationAlerts   *bool `json:"verification_alerts"`
+	VerificationReceipts *bool `json:"verification_receipts"`
+}
+
+// Apply returns prefs with the fields set in the request changed
+func (r *UpdateNotificationPreferencesRequest) Apply(prefs NotificationPreferences) NotificationPreferences {
+	if r.DailySummary != nil {
+		prefs.DailySummary = *r.DailySummary
+	}
+	if r.LoginAlerts != nil {
+		prefs.LoginAlerts = *r.LoginAlerts
 	}
+	if r.LowBalance != nil {
+		prefs.LowBalance = *r.LowBalance
+	}
+	if r.VerificationAlerts != nil {
+		prefs.VerificationAlerts = *r.VerificationAlerts
+	}
+	if r.VerificationReceipts != nil {
+		prefs.VerificationReceipts = *r.VerificationReceipts
+	}
+	return prefs
+}
+
+// SetVerificationPlanRequest assigns or removes a user's prepaid verification plan
+// An empty plan_type removes the plan
+type SetVerificationPlanRequest struct {
+	PlanType     string `json:"plan_type"`
+	MonthlyQuota int    `json:"monthly_quota" binding:"gte=0"`
 }
 
 // CreateUserRequest represents the request body for user registration
@@ -95,8 +194,8 @@ type CreateUserRequest struct {
 	Password         string   `json:"password" binding:"required,min=8"`
 	OrganizationName string   `json:"organization_name" binding:"required"`
 	OrganizationType string   `json:"organization_type"`
-	GSTIN            string   `json:"gstin"`
-	PAN              string   `json:"pan"`
+	GSTIN            string   `json:"gstin" binding:"omitempty,gstin"`
+	PAN              string   `json:"pan" binding:"omitempty,pan"`
 	Role             UserRole `json:"role" binding:"required,oneof=public institution_user institution_admin verifier"`
 }
 
@@ -104,6 +203,35 @@ type CreateUserRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+
+	// Accounts with two-factor authentication need one of these
+	TOTPCode     string `json:"totp_code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// EnableTwoFactorRequest starts two-factor enrollment; the password is re-checked
+type EnableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// EnableTwoFactorResponse is what the user adds to their authenticator app
+// Two-factor authentication isn't active until a code is confirmed with VerifyTwoFactorRequest
+type EnableTwoFactorResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCode     string `json:"qr_code"` // PNG data URL of OTPAuthURL
+}
+
+// VerifyTwoFactorRequest confirms enrollment with a code from the authenticator app
+type VerifyTwoFactorRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest turns two-factor authentication off
+// Code may be an authenticator code or an unused recovery code
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
 }
 
 // LoginResponse represents the response after successful login
@@ -114,6 +242,62 @@ type LoginResponse struct {
 	ExpiresIn    int64                  `json:"expires_in"` // Seconds until access token expires
 }
 
+// UserInvite is a one-time signup link an admin sent for a role that can't be self-registered
+type UserInvite struct {
+	ID        string     `db:"id" json:"id"`
+	Email     string     `db:"email" json:"email"`
+	Role      UserRole   `db:"role" json:"role"`
+	Token     string     `db:"token" json:"-"` // Only ever sent in the invite email
+	InvitedBy string     `db:"invited_by" json:"invited_by"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at,omitempty"`
+	UsedBy    *string    `db:"used_by" json:"used_by,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// CreateInviteRequest is an admin's invite for someone to sign up with a given role
+type CreateInviteRequest struct {
+	Email string   `json:"email" binding:"required,email"`
+	Role  UserRole `json:"role" binding:"required,oneof=public institution_user institution_admin verifier"`
+}
+
+// InviteSignupRequest creates an account from an invite
+// The email and role come from the invite, not the request
+type InviteSignupRequest struct {
+	Token            string `json:"token" binding:"required"`
+	FullName         string `json:"full_name" binding:"required"`
+	Password         string `json:"password" binding:"required,min=8"`
+	OrganizationName string `json:"organization_name" binding:"required"`
+	OrganizationType string `json:"organization_type"`
+	GSTIN            string `json:"gstin" binding:"omitempty,gstin"`
+	PAN              string `json:"pan" binding:"omitempty,pan"`
+}
+
+// UpdateRoleRequest is a master admin's change to a user's role
+type UpdateRoleRequest struct {
+	Role UserRole `json:"role" binding:"required,oneof=public institution_user institution_admin verifier master_admin"`
+}
+
+// CanUseTwoFactor reports whether the role may enable two-factor authentication
+// Only privileged accounts for now: verifiers see restricted bills, master admins run the platform
+func (r UserRole) CanUseTwoFactor() bool {
+	return r == RoleVerifier || r == RoleMasterAdmin
+}
+
+// IsInstitution reports whether the role issues bills and therefore needs KYC
+func (r UserRole) IsInstitution() bool {
+	return r == RoleInstitutionUser || r == RoleInstitutionAdmin
+}
+
+// InitialKYCStatus is the KYC status a user starts with in the given role
+// Institutions must submit KYC before generating bills; everyone else doesn't need it
+func InitialKYCStatus(role UserRole) KYCStatus {
+	if role.IsInstitution() {
+		return KYCPending
+	}
+	return KYCNotNeeded
+}
+
 // RefreshTokenRequest represents the request to refresh access token
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
@@ -163,4 +347,63 @@ func (k *KYCStatus) Scan(value interface{}) error {
 		return nil
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// Value implements the driver.Valuer interface for NotificationPreferences (stored as JSONB)
+func (p NotificationPreferences) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface for NotificationPreferences
+// A missing value means the defaults
+func (p *NotificationPreferences) Scan(value interface{}) error {
+	*p = DefaultNotificationPreferences()
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	}
+	return fmt.Errorf("unsupported notification preferences type %T", value)
+}
+
+// ForgotPasswordRequest represents the request to start a password reset
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the request to set a new password using a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+// KYCDocument is a reference to an uploaded KYC document
+type KYCDocument struct {
+	Type   string `json:"type" binding:"required"` // e.g. "gst_certificate", "pan_card"
+	URL    string `json:"url" binding:"required,url"`
+	Number string `json:"number,omitempty"`
+}
+
+// SubmitKYCRequest represents an institution's KYC submission
+type SubmitKYCRequest struct {
+	Documents []KYCDocument `json:"documents" binding:"required,min=1,dive"`
+}
+
+// ReviewKYCRequest represents an admin's KYC decision
+type ReviewKYCRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject"`
+	Reason   string `json:"reason"` // Required when rejecting
+}
+
+// AccountDataExport is everything the API stores about a user, downloaded on request
+type AccountDataExport struct {
+	ExportedAt         time.Time            `json:"exported_at"`
+	Profile            *User                `json:"profile"`
+	Bills              []*Bill              `json:"bills"`
+	Verifications      []*Verification      `json:"verifications"`
+	WalletTransactions []*WalletTransaction `json:"wallet_transactions"`
+	LoginHistory       []*LoginEvent        `json:"login_history"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestNotificationPreferencesScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  NotificationPreferences
+	}{
+		{"null is the defaults", nil, DefaultNotificationPreferences()},
+		{"stored preferences", []byte(`{"daily_summary":false,"login_alerts":false,"low_balance":true,"verification_alerts":true,"verification_receipts":false}`),
+			NotificationPreferences{LowBalance: true, VerificationAlerts: true}},
+		// Preferences added after the row was written start out enabled
+		{"missing keys default to enabled", `{"login_alerts":false}`,
+			NotificationPreferences{DailySummary: true, LowBalance: true, VerificationAlerts: true, VerificationReceipts: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got NotificationPreferences
+			if err := got.Scan(tt.value); err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateNotificationPreferencesRequestApply(t *testing.T) {
+	off := false
+	req := UpdateNotificationPreferencesRequest{LoginAlerts: &off}
+
+	got := req.Apply(DefaultNotificationPreferences())
+	want := DefaultNotificationPreferences()
+	want.LoginAlerts = false
+	if got != want {
+		t.Errorf("Apply = %+v, want only login alerts turned off: %+v", got, want)
+	}
+}
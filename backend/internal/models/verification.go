@@ -15,58 +15,150 @@ const (
 	VerificationSuspicious VerificationStatus = "suspicious"
 	VerificationNotFound   VerificationStatus = "not_found"
 	VerificationRestricted VerificationStatus = "restricted"
+	VerificationExpired    VerificationStatus = "expired"
 )
 
 // Verification represents a bill verification record
 type Verification struct {
-	ID                string             `db:"id" json:"id"`
-	BillID            *string            `db:"bill_id" json:"bill_id,omitempty"`
-	BillNumber        string             `db:"bill_number" json:"bill_number"`
-	VerifierID        *string            `db:"verifier_id" json:"verifier_id,omitempty"`
-	VerifierIP        *string            `db:"verifier_ip" json:"verifier_ip,omitempty"`
-	VerifierUserAgent *string            `db:"verifier_user_agent" json:"verifier_user_agent,omitempty"`
-	AccessLevelUsed   AccessLevel        `db:"access_level_used" json:"access_level_used"`
-	DataRevealed      json.RawMessage    `db:"data_revealed" json:"data_revealed,omitempty"`
-	AmountCharged     float64            `db:"amount_charged" json:"amount_charged"`
-	WasFree           bool               `db:"was_free" json:"was_free"`
-	PricingRuleApplied string            `db:"pricing_rule_applied" json:"pricing_rule_applied"`
+	ID                 string             `db:"id" json:"id"`
+	BillID             *string            `db:"bill_id" json:"bill_id,omitempty"`
+	BillNumber         string             `db:"bill_number" json:"bill_number"`
+	VerifierID         *string            `db:"verifier_id" json:"verifier_id,omitempty"`
+	VerifierIP         *string            `db:"verifier_ip" json:"verifier_ip,omitempty"`
+	VerifierUserAgent  *string            `db:"verifier_user_agent" json:"verifier_user_agent,omitempty"`
+	AccessLevelUsed    AccessLevel        `db:"access_level_used" json:"access_level_used"`
+	DataRevealed       json.RawMessage    `db:"data_revealed" json:"data_revealed,omitempty"`
+	AmountCharged      float64            `db:"amount_charged" json:"amount_charged"`
+	WasFree            bool               `db:"was_free" json:"was_free"`
+	CoveredByPlan      bool               `db:"covered_by_plan" json:"covered_by_plan"`
+	PricingRuleApplied string             `db:"pricing_rule_applied" json:"pricing_rule_applied"`
 	VerificationStatus VerificationStatus `db:"verification_status" json:"verification_status"`
 	BlockchainVerified bool               `db:"blockchain_verified" json:"blockchain_verified"`
-	BlockchainTxID    *string            `db:"blockchain_tx_id" json:"blockchain_tx_id,omitempty"`
-	IsSuspicious      bool               `db:"is_suspicious" json:"is_suspicious"`
-	SuspiciousReason  *string            `db:"suspicious_reason" json:"suspicious_reason,omitempty"`
-	ResponseTimeMs    int                `db:"response_time_ms" json:"response_time_ms"`
-	VerifiedAt        time.Time          `db:"verified_at" json:"verified_at"`
+	BlockchainTxID     *string            `db:"blockchain_tx_id" json:"blockchain_tx_id,omitempty"`
+	IsSuspicious       bool               `db:"is_suspicious" json:"is_suspicious"`
+	SuspiciousReason   *string            `db:"suspicious_reason" json:"suspicious_reason,omitempty"`
+	ResponseTimeMs     int                `db:"response_time_ms" json:"response_time_ms"`
+	VerifiedAt         time.Time          `db:"verified_at" json:"verified_at"`
 }
 
 // VerifyBillRequest represents the request to verify a bill
 type VerifyBillRequest struct {
 	BillNumber string `json:"bill_number" binding:"required"`
+	Explain    bool   `json:"explain"` // Also say why access was limited; same as ?explain=true
 }
 
 // VerifyBillResponse represents the verification result
 type VerifyBillResponse struct {
 	Success    bool                   `json:"success"`
 	BillNumber string                 `json:"bill_number"`
-	Status     string                 `json:"status"` // valid, invalid, restricted
+	Status     string                 `json:"status"` // valid, invalid, restricted, expired, suspicious
 	IssuerName string                 `json:"issuer_name,omitempty"`
 	IssueDate  string                 `json:"issue_date,omitempty"`
+	ValidUntil string                 `json:"valid_until,omitempty"`
 	BillType   string                 `json:"bill_type,omitempty"`
 	Message    string                 `json:"message"`
 	Details    map[string]interface{} `json:"details,omitempty"`
 	Fee        float64                `json:"fee"`
+
+	// Integrity of the stored bill data against its recorded hash
+	BlockchainVerified bool   `json:"blockchain_verified"`
+	DataIntegrity      string `json:"data_integrity,omitempty"` // intact, tampered
+
+	// Only set when the caller asked for an explanation
+	Explanation *AccessExplanation `json:"explanation,omitempty"`
+}
+
+// AccessExplanation describes the access rules behind a verification response, never the bill's data
+type AccessExplanation struct {
+	AccessLevel     string   `json:"access_level"`                // What the caller got: full, limited, preview, none
+	BillAccessLevel string   `json:"bill_access_level"`           // public, restricted, government, financial
+	CurrentRole     string   `json:"current_role"`                // "anonymous" when not logged in
+	FullAccessRoles []string `json:"full_access_roles,omitempty"` // Empty when everyone gets full access
+	KYCRequired     bool     `json:"kyc_required"`                // Institution roles above need approved KYC
+	Reason          string   `json:"reason"`
+	FullAccessFee   float64  `json:"full_access_fee"` // List price of this verification with full access
+	FeeDifference   float64  `json:"fee_difference"`  // How much more that costs than this verification's list price
+}
+
+// VerificationQuotaResponse shows how much of a user's prepaid plan is left this month
+type VerificationQuotaResponse struct {
+	HasPlan      bool       `json:"has_plan"`
+	PlanType     string     `json:"plan_type,omitempty"`
+	MonthlyQuota int        `json:"monthly_quota"`
+	Used         int        `json:"used"`
+	Remaining    int        `json:"remaining"`
+	ResetsAt     *time.Time `json:"resets_at,omitempty"`
+}
+
+// PriceEstimateResponse is what a verification would cost the caller, without charging them
+type PriceEstimateResponse struct {
+	BillNumber  string   `json:"bill_number"`
+	BillType    BillType `json:"bill_type"`
+	Fee         float64  `json:"fee"`
+	IsFree      bool     `json:"is_free"`
+	PricingRule string   `json:"pricing_rule"`
+	AccessLevel string   `json:"access_level"` // What the caller would see: full, limited, preview, none
 }
 
 // VerificationHistoryResponse represents a verification in history list
 type VerificationHistoryResponse struct {
-	ID          string  `json:"id"`
-	BillNumber  string  `json:"bill_number"`
-	IssuerName  string  `json:"issuer_name"`
-	BillType    string  `json:"bill_type"`
-	Date        string  `json:"verification_date"`
-	Result      string  `json:"result"`
-	Fee         float64 `json:"fee"`
-	WasFree     bool    `json:"was_free"`
+	ID         string  `json:"id"`
+	BillNumber string  `json:"bill_number"`
+	IssuerName string  `json:"issuer_name"`
+	BillType   string  `json:"bill_type"`
+	Date       string  `json:"verification_date"`
+	Result     string  `json:"result"`
+	Fee        float64 `json:"fee"`
+	WasFree    bool    `json:"was_free"`
+}
+
+// VerificationDetailResponse is a single verification record with the bill it checked
+type VerificationDetailResponse struct {
+	*Verification
+	Bill *VerificationBillInfo `json:"bill,omitempty"` // nil when no bill matched or it has since been removed
+}
+
+// VerificationBillInfo summarizes the verified bill without exposing bill_data beyond data_revealed
+type VerificationBillInfo struct {
+	ID         string    `json:"id"`
+	BillNumber string    `json:"bill_number"`
+	BillType   BillType  `json:"bill_type"`
+	IssuerName string    `json:"issuer_name"`
+	IssueDate  time.Time `json:"issue_date"`
+}
+
+// BillVerificationLog is a verification of a bill joined with who verified it
+// Used for the issuer's per-bill verification log
+type BillVerificationLog struct {
+	ID                 string             `db:"id"`
+	VerifierName       *string            `db:"verifier_name"` // NULL for anonymous public verifications
+	VerifierIP         *string            `db:"verifier_ip"`
+	AccessLevelUsed    AccessLevel        `db:"access_level_used"`
+	VerificationStatus VerificationStatus `db:"verification_status"`
+	VerifiedAt         time.Time          `db:"verified_at"`
+}
+
+// Trend intervals a verifier's activity can be bucketed by
+const (
+	TrendIntervalDay  = "day"
+	TrendIntervalWeek = "week"
+)
+
+// VerificationTrendRow is one status's verifications within one time bucket, as aggregated in SQL
+type VerificationTrendRow struct {
+	Bucket time.Time          `db:"bucket"`
+	Status VerificationStatus `db:"verification_status"`
+	Count  int                `db:"count"`
+	Spent  float64            `db:"spent"`
+}
+
+// VerificationTrendBucket is a verifier's activity in one day or week
+// Buckets without activity are still returned, with zero counts, so charts are continuous
+type VerificationTrendBucket struct {
+	Start    string         `json:"start"` // YYYY-MM-DD; weeks start on Monday
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"`
+	Spent    float64        `json:"spent"`
 }
 
 // VerificationStats represents verification statistics
@@ -79,6 +171,53 @@ type VerificationStats struct {
 	SuccessRate        float64 `json:"success_rate"`
 }
 
+// Verifier score parameters; see VerifierScore
+const (
+	VerifierScorePrior            = 5 // Clean verifications every verifier starts with, so a few misses don't sink a new account
+	VerifierScoreSuspiciousWeight = 2 // A suspicious verification costs as much as this many not_found lookups
+)
+
+// VerifierScore is a verifier account's reputation, computed from its verifications within a window
+//
+//	score = 100 * max(0, total - not_found - 2*suspicious + 5) / (total + 5), rounded to one decimal
+//
+// 100 means no misses; unknown bill numbers and suspicious verifications pull it down, and the
+// five-verification prior keeps low-volume accounts near 100 until there is enough history to judge.
+// A verification that is both not_found and suspicious counts against the verifier in both terms.
+type VerifierScore struct {
+	VerifierID       string     `db:"verifier_id" json:"verifier_id"`
+	FullName         string     `db:"full_name" json:"full_name"`
+	Email            string     `db:"email" json:"email"`
+	OrganizationName string     `db:"organization_name" json:"organization_name"`
+	IsActive         bool       `db:"is_active" json:"is_active"`
+	Total            int        `db:"total" json:"total_verifications"`
+	ValidCount       int        `db:"valid_count" json:"valid_count"`
+	NotFoundCount    int        `db:"not_found_count" json:"not_found_count"`
+	SuspiciousCount  int        `db:"suspicious_count" json:"suspicious_count"` // Flagged by fraud detection or with status suspicious
+	LastVerifiedAt   *time.Time `db:"last_verified_at" json:"last_verified_at,omitempty"`
+	Score            float64    `db:"score" json:"score"`
+	Flagged          bool       `db:"flagged" json:"flagged"` // Score below the review threshold with enough volume to trust it
+}
+
+// Orders the admin verifier list accepts
+const (
+	VerifierSortScore        = "score"
+	VerifierSortVolume       = "volume"
+	VerifierSortNotFound     = "not_found"
+	VerifierSortSuspicious   = "suspicious"
+	VerifierSortLastVerified = "last_verified"
+)
+
+// VerifierScoreFilter selects and orders verifiers for the admin verifier list
+type VerifierScoreFilter struct {
+	Since         time.Time // Only verifications from here on count
+	FlagScore     float64   // Flag verifiers scoring below this...
+	FlagMinVolume int       // ...with at least this many verifications
+	FlaggedOnly   bool
+	Sort          string // One of the VerifierSort* values
+	Descending    bool
+}
+
 // Value/Scan implementations
 func (vs VerificationStatus) Value() (driver.Value, error) {
 	return string(vs), nil
@@ -98,4 +237,4 @@ func (vs *VerificationStatus) Scan(value interface{}) error {
 		return nil
 	}
 	return nil
-}
\ No newline at end of file
+}
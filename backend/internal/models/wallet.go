@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// WalletTransactionType represents the kind of wallet movement
+type WalletTransactionType string
+
+const (
+	WalletTxTopup           WalletTransactionType = "topup"
+	WalletTxBillFee         WalletTransactionType = "bill_fee"
+	WalletTxVerificationFee WalletTransactionType = "verification_fee"
+	WalletTxRefund          WalletTransactionType = "refund"
+	WalletTxAdminAdjustment WalletTransactionType = "admin_adjustment"
+)
+
+// WalletTransaction represents a single entry in a user's wallet statement
+type WalletTransaction struct {
+	ID           string                `db:"id" json:"id"`
+	UserID       string                `db:"user_id" json:"user_id"`
+	Type         WalletTransactionType `db:"transaction_type" json:"type"`
+	Amount       float64               `db:"amount" json:"amount"` // Signed: negative for debits
+	BalanceAfter float64               `db:"balance_after" json:"balance_after"`
+	ReferenceID  *string               `db:"reference_id" json:"reference_id,omitempty"` // Bill or verification ID
+	Description  *string               `db:"description" json:"description,omitempty"`
+	CreatedBy    *string               `db:"created_by" json:"created_by,omitempty"` // Admin who made an admin_adjustment
+	CreatedAt    time.Time             `db:"created_at" json:"created_at"`
+}
+
+// AdjustWalletRequest is a support correction to a user's wallet balance
+// A negative delta debits the wallet; it may only overdraw it when AllowNegative is set
+type AdjustWalletRequest struct {
+	Delta         float64 `json:"delta" binding:"required"`
+	Reason        string  `json:"reason" binding:"required"`
+	AllowNegative bool    `json:"allow_negative"`
+}
+
+// Value/Scan implementations
+
+func (t WalletTransactionType) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+func (t *WalletTransactionType) Scan(value interface{}) error {
+	if value == nil {
+		*t = ""
+		return nil
+	}
+	if sv, ok := value.(string); ok {
+		*t = WalletTransactionType(sv)
+		return nil
+	}
+	if bv, ok := value.([]byte); ok {
+		*t = WalletTransactionType(string(bv))
+		return nil
+	}
+	return nil
+}
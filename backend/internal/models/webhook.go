@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Webhook events sent to institutions
+const (
+	WebhookEventBillVerified = "bill.verified" // One of the institution's bills was verified
+)
+
+// WebhookDeliveryStatus represents where a webhook delivery is in its lifecycle
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery records one outbound webhook and its delivery attempts
+type WebhookDelivery struct {
+	ID             string                `db:"id" json:"id"`
+	OutboxID       *string               `db:"outbox_id" json:"-"` // Outbox message whose attempts this records
+	UserID         string                `db:"user_id" json:"user_id"`
+	Event          string                `db:"event" json:"event"`
+	URL            string                `db:"url" json:"url"`
+	Payload        json.RawMessage       `db:"payload" json:"payload"`
+	Status         WebhookDeliveryStatus `db:"status" json:"status"`
+	Attempts       int                   `db:"attempts" json:"attempts"`
+	LastStatusCode *int                  `db:"last_status_code" json:"last_status_code,omitempty"`
+	LastError      *string               `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt      time.Time             `db:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time            `db:"delivered_at" json:"delivered_at,omitempty"`
+}
+
+// BillVerifiedPayload is the body of a bill.verified webhook
+type BillVerifiedPayload struct {
+	Event        string    `json:"event"`
+	BillNumber   string    `json:"bill_number"`
+	VerifierType string    `json:"verifier_type"` // Verifier's role, or "anonymous"
+	Result       string    `json:"result"`
+	VerifiedAt   time.Time `json:"verified_at"`
+}
+
+// FakeBillAlertPayload describes a spike of lookups of unknown bill numbers, for the master admins' alert email
+// Attempts counts lookups of unknown numbers under Prefix within Window; the same prefix alerts at most once per Window
+type FakeBillAlertPayload struct {
+	Prefix         string    `json:"prefix"`
+	Attempts       int       `json:"attempts"`
+	Window         string    `json:"window"`
+	LastBillNumber string    `json:"last_bill_number"`
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+// SetWebhookRequest sets or clears an institution's webhook URL
+// An empty URL disables webhooks; otherwise it must be https
+type SetWebhookRequest struct {
+	URL string `json:"url" binding:"omitempty,url,startswith=https://"`
+}
+
+// Value/Scan implementations
+
+func (s WebhookDeliveryStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s *WebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		*s = WebhookDeliveryPending
+		return nil
+	}
+	if sv, ok := value.(string); ok {
+		*s = WebhookDeliveryStatus(sv)
+		return nil
+	}
+	if bv, ok := value.([]byte); ok {
+		*s = WebhookDeliveryStatus(string(bv))
+		return nil
+	}
+	return nil
+}
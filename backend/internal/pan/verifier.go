@@ -0,0 +1,39 @@
+package pan
+
+import "context"
+
+// Status is the outcome of checking a PAN, stored on the user as pan_verification_status
+type Status string
+
+const (
+	StatusUnchecked Status = "unchecked" // No verifier has confirmed or rejected the PAN
+	StatusVerified  Status = "verified"  // The PAN is registered to the organization
+	StatusMismatch  Status = "mismatch"  // The PAN exists but is registered to a different name
+	StatusNotFound  Status = "not_found" // No such PAN is registered
+)
+
+// Result is what a Verifier found out about a PAN
+type Result struct {
+	Status         Status
+	RegisteredName string // Name the PAN is registered to, when the verifier reports it
+}
+
+// Verifier confirms that a PAN belongs to the organization claiming it
+// Signup runs it in the background; a government PAN verification API can implement it
+type Verifier interface {
+	Verify(ctx context.Context, pan, organizationName string) (*Result, error)
+}
+
+// NoopVerifier doesn't check anything
+// Used until a PAN verification service is configured
+type NoopVerifier struct{}
+
+// NewNoopVerifier creates a verifier that leaves every PAN unchecked
+func NewNoopVerifier() *NoopVerifier {
+	return &NoopVerifier{}
+}
+
+// Verify always reports the PAN as unchecked
+func (v *NoopVerifier) Verify(ctx context.Context, pan, organizationName string) (*Result, error) {
+	return &Result{Status: StatusUnchecked}, nil
+}
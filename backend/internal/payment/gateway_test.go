@@ -0,0 +1,59 @@
+package payment
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMockGatewaySignatures(t *testing.T) {
+	gateway := NewMockGateway("test-secret", true)
+
+	order, err := gateway.CreateOrder(context.Background(), 500.004, "INR", "wallet_topup_user-1")
+	if err != nil {
+		t.Fatalf("CreateOrder failed: %v", err)
+	}
+	if order.Amount != 500 {
+		t.Errorf("amount = %v, want 500", order.Amount)
+	}
+
+	paymentID := order.Metadata["mock_payment_id"]
+	signature := order.Metadata["mock_signature"]
+
+	// Flip the last hex digit
+	tampered := signature[:len(signature)-1] + "0"
+	if signature[len(signature)-1] == '0' {
+		tampered = signature[:len(signature)-1] + "1"
+	}
+
+	tests := []struct {
+		name      string
+		orderID   string
+		paymentID string
+		signature string
+		want      bool
+	}{
+		{"valid", order.OrderID, paymentID, signature, true},
+		{"other payment", order.OrderID, "pay_other", signature, false},
+		{"other order", "order_other", paymentID, signature, false},
+		{"tampered signature", order.OrderID, paymentID, tampered, false},
+		{"other secret", order.OrderID, paymentID, NewMockGateway("other-secret", false).Sign(order.OrderID, paymentID), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gateway.VerifySignature(tt.orderID, tt.paymentID, tt.signature); got != tt.want {
+				t.Errorf("VerifySignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockGatewayWithoutTestPayments(t *testing.T) {
+	order, err := NewMockGateway("test-secret", false).CreateOrder(context.Background(), 100, "INR", "r")
+	if err != nil {
+		t.Fatalf("CreateOrder failed: %v", err)
+	}
+	if order.Metadata != nil {
+		t.Errorf("metadata = %v, want none without test payments", order.Metadata)
+	}
+}
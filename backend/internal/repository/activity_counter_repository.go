@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// ActivityCounterRepository keeps activity counters in Redis
+// Sliding-window counters are sorted sets scored by timestamp; entries older than the window are trimmed
+type ActivityCounterRepository struct {
+	redis *database.RedisClient
+}
+
+// NewActivityCounterRepository creates a new activity counter repository
+func NewActivityCounterRepository(redis *database.RedisClient) *ActivityCounterRepository {
+	return &ActivityCounterRepository{redis: redis}
+}
+
+// RecordEvent counts an occurrence and returns how many happened within the window
+// Returns 0 when Redis isn't configured
+func (r *ActivityCounterRepository) RecordEvent(ctx context.Context, key string, window time.Duration) (int, error) {
+	return r.record(ctx, key, strconv.FormatInt(time.Now().UnixNano(), 10), window)
+}
+
+// RecordDistinct records member and returns how many distinct members were seen within the window
+// Seeing a member again refreshes its timestamp rather than counting it twice
+func (r *ActivityCounterRepository) RecordDistinct(ctx context.Context, key, member string, window time.Duration) (int, error) {
+	return r.record(ctx, key, member, window)
+}
+
+// record adds member to the window at key and returns the window's size
+func (r *ActivityCounterRepository) record(ctx context.Context, key, member string, window time.Duration) (int, error) {
+	if r.redis == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-window)
+
+	pipe := r.redis.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "0", strconv.FormatInt(windowStart.UnixNano(), 10))
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixNano()), Member: member})
+	countCmd := pipe.ZCard(ctx, key)
+	pipe.PExpire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record activity: %w", err)
+	}
+
+	return int(countCmd.Val()), nil
+}
+
+// IncrementUntil adds one to the counter at key and returns its new value
+// The counter is deleted at expireAt, so a key per period gives a fixed-window count
+// Returns 0 when Redis isn't configured
+func (r *ActivityCounterRepository) IncrementUntil(ctx context.Context, key string, expireAt time.Time) (int, error) {
+	if r.redis == nil {
+		return 0, nil
+	}
+
+	pipe := r.redis.TxPipeline()
+	incrCmd := pipe.Incr(ctx, key)
+	pipe.ExpireAt(ctx, key, expireAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment counter: %w", err)
+	}
+
+	return int(incrCmd.Val()), nil
+}
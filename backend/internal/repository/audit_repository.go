@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// AuditRepository handles database operations for the admin audit log
+type AuditRepository struct {
+	db instrumentedDB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{db: instrumentedDB{db}}
+}
+
+// Record adds an audit log entry for an action taken outside a transaction
+// The request ID is taken from ctx
+func (r *AuditRepository) Record(ctx context.Context, actorID string, action models.AuditAction, targetType models.AuditTargetType, targetID string, metadata map[string]interface{}) error {
+	return r.record(ctx, r.db, actorID, action, targetType, targetID, metadata)
+}
+
+// RecordTx adds an audit log entry inside the transaction making the change, so one can't commit without the other
+func (r *AuditRepository) RecordTx(ctx context.Context, tx *sqlx.Tx, actorID string, action models.AuditAction, targetType models.AuditTargetType, targetID string, metadata map[string]interface{}) error {
+	return r.record(ctx, tx, actorID, action, targetType, targetID, metadata)
+}
+
+func (r *AuditRepository) record(ctx context.Context, q sqlx.ExecerContext, actorID string, action models.AuditAction, targetType models.AuditTargetType, targetID string, metadata map[string]interface{}) error {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit metadata: %w", err)
+	}
+
+	var requestID *string
+	if id := utils.RequestIDFromContext(ctx); id != "" {
+		requestID = &id
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_type, target_id, request_id, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if _, err := q.ExecContext(ctx, query, actorID, action, targetType, targetID, requestID, metadataJSON); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// List returns a page of audit entries matching the filter, newest first, with the total match count
+func (r *AuditRepository) List(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]*models.AuditEntry, int, error) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+
+	if filter.ActorID != nil {
+		args = append(args, *filter.ActorID)
+		where += fmt.Sprintf(" AND actor_id = $%d", len(args))
+	}
+
+	if filter.Action != nil {
+		args = append(args, *filter.Action)
+		where += fmt.Sprintf(" AND action = $%d", len(args))
+	}
+
+	if filter.TargetType != nil {
+		args = append(args, *filter.TargetType)
+		where += fmt.Sprintf(" AND target_type = $%d", len(args))
+	}
+
+	if filter.TargetID != nil {
+		args = append(args, *filter.TargetID)
+		where += fmt.Sprintf(" AND target_id = $%d", len(args))
+	}
+
+	if filter.StartDate != nil {
+		args = append(args, *filter.StartDate)
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+
+	if filter.EndDate != nil {
+		args = append(args, filter.EndDate.Add(24*time.Hour))
+		where += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM audit_log"+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	query := "SELECT * FROM audit_log" + where +
+		fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	var entries []*models.AuditEntry
+	if err := r.db.SelectContext(ctx, &entries, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// BillAliasRepository handles database operations for bill short-link codes
+type BillAliasRepository struct {
+	db instrumentedDB
+}
+
+// NewBillAliasRepository creates a new bill alias repository
+func NewBillAliasRepository(db *sqlx.DB) *BillAliasRepository {
+	return &BillAliasRepository{db: instrumentedDB{db}}
+}
+
+// Replace gives the bill a new short code, replacing any code it had
+// Returns ErrShortCodeTaken if another bill already uses shortCode
+func (r *BillAliasRepository) Replace(ctx context.Context, billID, shortCode string) (*models.BillAlias, error) {
+	query := `
+		INSERT INTO bill_aliases (short_code, bill_id)
+		VALUES ($1, $2)
+		ON CONFLICT (bill_id) DO UPDATE
+		SET short_code = EXCLUDED.short_code,
+		    created_at = NOW()
+		RETURNING *
+	`
+
+	var alias models.BillAlias
+	if err := r.db.GetContext(ctx, &alias, query, shortCode, billID); err != nil {
+		// short_code is the primary key - a collision with another bill's code
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrShortCodeTaken
+		}
+		return nil, fmt.Errorf("failed to save short code: %w", err)
+	}
+
+	return &alias, nil
+}
+
+// GetByBillID retrieves the bill's short code
+// Returns nil without an error when the bill has none
+func (r *BillAliasRepository) GetByBillID(ctx context.Context, billID string) (*models.BillAlias, error) {
+	var alias models.BillAlias
+	query := `SELECT * FROM bill_aliases WHERE bill_id = $1`
+
+	err := r.db.GetContext(ctx, &alias, query, billID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get short code: %w", err)
+	}
+
+	return &alias, nil
+}
+
+// GetBillByShortCode retrieves the bill a short code points at
+// Deleted bills are treated as not found, like BillRepository.GetByBillNumber
+func (r *BillAliasRepository) GetBillByShortCode(ctx context.Context, shortCode string) (*models.Bill, error) {
+	var bill models.Bill
+	query := `
+		SELECT b.* FROM bills b
+		JOIN bill_aliases a ON a.bill_id = b.id
+		WHERE a.short_code = $1 AND b.is_deleted = false
+	`
+
+	err := r.db.GetContext(ctx, &bill, query, shortCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBillNotFound
+		}
+		return nil, fmt.Errorf("failed to get bill: %w", err)
+	}
+
+	return &bill, nil
+}
@@ -0,0 +1,73 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestBillAliases(t *testing.T) {
+	db := testutil.DB(t)
+	repo := repository.NewBillAliasRepository(db.DB)
+	bills := repository.NewBillRepository(db.DB, nil, 0)
+	ctx := context.Background()
+
+	issuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	bill := testutil.CreateBill(t, db, issuer, 100, nil)
+	other := testutil.CreateBill(t, db, issuer, 200, nil)
+
+	if alias, err := repo.GetByBillID(ctx, bill.ID); err != nil || alias != nil {
+		t.Fatalf("GetByBillID before any code = %v, %v, want nil, nil", alias, err)
+	}
+
+	t.Run("create and resolve", func(t *testing.T) {
+		alias, err := repo.Replace(ctx, bill.ID, "abcdefgh23")
+		if err != nil {
+			t.Fatalf("Replace failed: %v", err)
+		}
+		if alias.ShortCode != "abcdefgh23" || alias.BillID != bill.ID {
+			t.Errorf("alias = %s -> %s, want abcdefgh23 -> %s", alias.ShortCode, alias.BillID, bill.ID)
+		}
+
+		resolved, err := repo.GetBillByShortCode(ctx, "abcdefgh23")
+		if err != nil || resolved.ID != bill.ID {
+			t.Errorf("GetBillByShortCode = %v, %v, want bill %s", resolved, err, bill.ID)
+		}
+	})
+
+	t.Run("codes are unique across bills", func(t *testing.T) {
+		if _, err := repo.Replace(ctx, other.ID, "abcdefgh23"); !errors.Is(err, repository.ErrShortCodeTaken) {
+			t.Errorf("reusing another bill's code error = %v, want %v", err, repository.ErrShortCodeTaken)
+		}
+	})
+
+	t.Run("a new code replaces the old one", func(t *testing.T) {
+		if _, err := repo.Replace(ctx, bill.ID, "zyxwvuts98"); err != nil {
+			t.Fatalf("Replace failed: %v", err)
+		}
+		if _, err := repo.GetBillByShortCode(ctx, "abcdefgh23"); !errors.Is(err, repository.ErrBillNotFound) {
+			t.Errorf("old code error = %v, want %v", err, repository.ErrBillNotFound)
+		}
+		if alias, err := repo.GetByBillID(ctx, bill.ID); err != nil || alias == nil || alias.ShortCode != "zyxwvuts98" {
+			t.Errorf("GetByBillID = %v, %v, want zyxwvuts98", alias, err)
+		}
+
+		// The freed code can go to another bill
+		if _, err := repo.Replace(ctx, other.ID, "abcdefgh23"); err != nil {
+			t.Errorf("reusing a replaced code failed: %v", err)
+		}
+	})
+
+	t.Run("deleted bills don't resolve", func(t *testing.T) {
+		if err := bills.SoftDelete(ctx, bill.ID, "test"); err != nil {
+			t.Fatalf("SoftDelete failed: %v", err)
+		}
+		if _, err := repo.GetBillByShortCode(ctx, "zyxwvuts98"); !errors.Is(err, repository.ErrBillNotFound) {
+			t.Errorf("deleted bill's code error = %v, want %v", err, repository.ErrBillNotFound)
+		}
+	})
+}
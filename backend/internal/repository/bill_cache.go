@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// Bill cache helpers
+// Bills are read far more often than they change (every public verification looks one up),
+// so GetByBillNumber keeps a serialized copy in Redis. Any write that changes what
+// GetByBillNumber would return must call invalidateBill. All helpers are best-effort:
+// a Redis failure is logged and the caller falls back to Postgres.
+
+// billCacheKey returns the Redis key for a bill number
+func billCacheKey(billNumber string) string {
+	return "bill:" + billNumber
+}
+
+// getCachedBill returns the cached bill, or nil on a miss or cache error
+func (r *BillRepository) getCachedBill(ctx context.Context, billNumber string) *models.Bill {
+	if r.cache == nil || r.cacheTTL <= 0 {
+		return nil
+	}
+
+	data, err := r.cache.Get(ctx, billCacheKey(billNumber)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("⚠️  Bill cache read failed for %s: %v", billNumber, err)
+		}
+		return nil
+	}
+
+	var bill models.Bill
+	if err := json.Unmarshal(data, &bill); err != nil {
+		log.Printf("⚠️  Bill cache entry for %s is corrupt: %v", billNumber, err)
+		return nil
+	}
+
+	return &bill
+}
+
+// cacheBill stores a bill under its bill number
+func (r *BillRepository) cacheBill(ctx context.Context, bill *models.Bill) {
+	if r.cache == nil || r.cacheTTL <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(bill)
+	if err != nil {
+		return
+	}
+
+	if err := r.cache.Set(ctx, billCacheKey(bill.BillNumber), data, r.cacheTTL).Err(); err != nil {
+		log.Printf("⚠️  Bill cache write failed for %s: %v", bill.BillNumber, err)
+	}
+}
+
+// invalidateBill drops the cached copy of a bill
+func (r *BillRepository) invalidateBill(ctx context.Context, billNumber string) {
+	if r.cache == nil {
+		return
+	}
+
+	if err := r.cache.Del(ctx, billCacheKey(billNumber)).Err(); err != nil {
+		log.Printf("⚠️  Bill cache invalidation failed for %s: %v", billNumber, err)
+	}
+}
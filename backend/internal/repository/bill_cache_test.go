@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// newCachedBillRepository returns a bill repository with no database behind it and a miniredis cache,
+// so any lookup that misses the cache panics instead of quietly going to Postgres
+func newCachedBillRepository(t *testing.T, ttl time.Duration) (*BillRepository, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return &BillRepository{cache: &database.RedisClient{Client: client}, cacheTTL: ttl}, mr
+}
+
+func cacheTestBill() *models.Bill {
+	return &models.Bill{
+		ID:          "8b0f3c1e-2f4a-4a7e-9d57-1c9d8f0e6a21",
+		BillNumber:  "OTH202401000001",
+		BillType:    models.BillTypeOther,
+		AccessLevel: models.AccessLevelPublic,
+		BillData:    []byte(`{"recipient_name":"Asha Rao"}`),
+		Amount:      1250,
+		Currency:    "INR",
+		DataHash:    "abc123",
+		IsActive:    true,
+	}
+}
+
+func TestGetByBillNumberServesCachedBill(t *testing.T) {
+	repo, mr := newCachedBillRepository(t, 10*time.Minute)
+	ctx := context.Background()
+	bill := cacheTestBill()
+
+	repo.cacheBill(ctx, bill)
+
+	if ttl := mr.TTL(billCacheKey(bill.BillNumber)); ttl != 10*time.Minute {
+		t.Errorf("cache TTL = %v, want 10m", ttl)
+	}
+
+	got, err := repo.GetByBillNumber(ctx, bill.BillNumber)
+	if err != nil {
+		t.Fatalf("GetByBillNumber failed: %v", err)
+	}
+	if got.ID != bill.ID || got.Amount != bill.Amount || string(got.BillData) != string(bill.BillData) {
+		t.Errorf("cached bill = %+v, want %+v", got, bill)
+	}
+}
+
+func TestInvalidateBillDropsCacheEntry(t *testing.T) {
+	repo, mr := newCachedBillRepository(t, 10*time.Minute)
+	ctx := context.Background()
+	bill := cacheTestBill()
+
+	repo.cacheBill(ctx, bill)
+	repo.invalidateBill(ctx, bill.BillNumber)
+
+	if mr.Exists(billCacheKey(bill.BillNumber)) {
+		t.Error("bill is still cached after invalidation")
+	}
+	if cached := repo.getCachedBill(ctx, bill.BillNumber); cached != nil {
+		t.Errorf("getCachedBill = %+v, want a miss", cached)
+	}
+}
+
+func TestBillCacheDisabledWithoutTTL(t *testing.T) {
+	repo, mr := newCachedBillRepository(t, 0)
+	ctx := context.Background()
+	bill := cacheTestBill()
+
+	repo.cacheBill(ctx, bill)
+
+	if mr.Exists(billCacheKey(bill.BillNumber)) {
+		t.Error("bill was cached although the TTL is 0")
+	}
+}
+
+func TestCorruptBillCacheEntryIsAMiss(t *testing.T) {
+	repo, mr := newCachedBillRepository(t, 10*time.Minute)
+
+	if err := mr.Set(billCacheKey("OTH202401000001"), "{not json"); err != nil {
+		t.Fatalf("failed to seed cache: %v", err)
+	}
+
+	if cached := repo.getCachedBill(context.Background(), "OTH202401000001"); cached != nil {
+		t.Errorf("getCachedBill = %+v, want a miss", cached)
+	}
+}
+
+func TestBillCacheDownIsAMiss(t *testing.T) {
+	repo, mr := newCachedBillRepository(t, 10*time.Minute)
+	mr.Close()
+
+	if cached := repo.getCachedBill(context.Background(), "OTH202401000001"); cached != nil {
+		t.Errorf("getCachedBill = %+v, want a miss", cached)
+	}
+}
@@ -4,35 +4,55 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/ezhilnn/epr-backend/internal/database"
 	"github.com/ezhilnn/epr-backend/internal/models"
 	"github.com/jmoiron/sqlx"
 )
 
 // BillRepository handles database operations for bills
 type BillRepository struct {
-	db *sqlx.DB
+	db       instrumentedDB
+	cache    *database.RedisClient // Optional - nil disables caching
+	cacheTTL time.Duration
 }
 
 // NewBillRepository creates a new bill repository
-func NewBillRepository(db *sqlx.DB) *BillRepository {
-	return &BillRepository{db: db}
+// Pass a nil cache (or zero TTL) to always read from Postgres
+func NewBillRepository(db *sqlx.DB, cache *database.RedisClient, cacheTTL time.Duration) *BillRepository {
+	return &BillRepository{
+		db:       instrumentedDB{db},
+		cache:    cache,
+		cacheTTL: cacheTTL,
+	}
 }
 
 // Create inserts a new bill into the database
 func (r *BillRepository) Create(ctx context.Context, bill *models.Bill) error {
+	return r.create(ctx, r.db, bill)
+}
+
+// CreateTx inserts a new bill inside an existing transaction
+func (r *BillRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, bill *models.Bill) error {
+	return r.create(ctx, tx, bill)
+}
+
+// create runs the bill insert on either the pool or a transaction
+func (r *BillRepository) create(ctx context.Context, q sqlx.QueryerContext, bill *models.Bill) error {
 	query := `
 		INSERT INTO bills (
 			bill_number, bill_type, access_level, issuer_id, issuer_name,
-			bill_data, amount, currency, issue_date, data_hash,
-			blockchain_status, is_active
+			bill_data, amount, currency, issue_date, valid_until, data_hash,
+			blockchain_status, is_active, needs_review, allow_preview, content_hash
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
 		) RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRowContext(
+	err := q.QueryRowxContext(
 		ctx,
 		query,
 		bill.BillNumber,
@@ -44,9 +64,13 @@ func (r *BillRepository) Create(ctx context.Context, bill *models.Bill) error {
 		bill.Amount,
 		bill.Currency,
 		bill.IssueDate,
+		bill.ValidUntil,
 		bill.DataHash,
 		bill.BlockchainStatus,
 		bill.IsActive,
+		bill.NeedsReview,
+		bill.AllowPreview,
+		bill.ContentHash,
 	).Scan(&bill.ID, &bill.CreatedAt, &bill.UpdatedAt)
 
 	if err != nil {
@@ -64,7 +88,24 @@ func (r *BillRepository) GetByID(ctx context.Context, id string) (*models.Bill,
 	err := r.db.GetContext(ctx, &bill, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("bill not found")
+			return nil, ErrBillNotFound
+		}
+		return nil, fmt.Errorf("failed to get bill: %w", err)
+	}
+
+	return &bill, nil
+}
+
+// GetByIDIncludingDeleted retrieves a bill by ID even if it has been soft-deleted
+// Only for paths that operate on deleted bills, such as restoring them
+func (r *BillRepository) GetByIDIncludingDeleted(ctx context.Context, id string) (*models.Bill, error) {
+	var bill models.Bill
+	query := `SELECT * FROM bills WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &bill, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBillNotFound
 		}
 		return nil, fmt.Errorf("failed to get bill: %w", err)
 	}
@@ -73,32 +114,69 @@ func (r *BillRepository) GetByID(ctx context.Context, id string) (*models.Bill,
 }
 
 // GetByBillNumber retrieves a bill by bill number
+// Served from the Redis cache when possible
 func (r *BillRepository) GetByBillNumber(ctx context.Context, billNumber string) (*models.Bill, error) {
+	if cached := r.getCachedBill(ctx, billNumber); cached != nil {
+		return cached, nil
+	}
+
 	var bill models.Bill
 	query := `SELECT * FROM bills WHERE bill_number = $1 AND is_deleted = false`
 
 	err := r.db.GetContext(ctx, &bill, query, billNumber)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("bill not found")
+			return nil, ErrBillNotFound
 		}
 		return nil, fmt.Errorf("failed to get bill: %w", err)
 	}
 
+	r.cacheBill(ctx, &bill)
+
 	return &bill, nil
 }
 
 // ListByIssuer retrieves bills by issuer ID with pagination
-func (r *BillRepository) ListByIssuer(ctx context.Context, issuerID string, limit, offset int) ([]*models.Bill, error) {
+// Soft-deleted bills are only included when includeDeleted is set
+func (r *BillRepository) ListByIssuer(ctx context.Context, issuerID string, includeDeleted bool, limit, offset int) ([]*models.Bill, error) {
 	var bills []*models.Bill
 	query := `
 		SELECT * FROM bills 
-		WHERE issuer_id = $1 AND is_deleted = false 
+		WHERE issuer_id = $1 AND ($2 OR is_deleted = false)
 		ORDER BY created_at DESC 
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
 
-	err := r.db.SelectContext(ctx, &bills, query, issuerID, limit, offset)
+	err := r.db.SelectContext(ctx, &bills, query, issuerID, includeDeleted, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bills: %w", err)
+	}
+
+	return bills, nil
+}
+
+// ListByIssuerAfter retrieves an issuer's bills created before cursor, newest first
+// A nil cursor starts from the newest bill. Soft-deleted bills are only included when includeDeleted is set
+func (r *BillRepository) ListByIssuerAfter(ctx context.Context, issuerID string, includeDeleted bool, cursor *models.PageCursor, limit int) ([]*models.Bill, error) {
+	var bills []*models.Bill
+	var err error
+	if cursor == nil {
+		query := `
+			SELECT * FROM bills
+			WHERE issuer_id = $1 AND ($2 OR is_deleted = false)
+			ORDER BY created_at DESC, id DESC
+			LIMIT $3
+		`
+		err = r.db.SelectContext(ctx, &bills, query, issuerID, includeDeleted, limit)
+	} else {
+		query := `
+			SELECT * FROM bills
+			WHERE issuer_id = $1 AND ($2 OR is_deleted = false) AND (created_at, id) < ($3, $4)
+			ORDER BY created_at DESC, id DESC
+			LIMIT $5
+		`
+		err = r.db.SelectContext(ctx, &bills, query, issuerID, includeDeleted, cursor.Timestamp, cursor.ID, limit)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list bills: %w", err)
 	}
@@ -106,12 +184,12 @@ func (r *BillRepository) ListByIssuer(ctx context.Context, issuerID string, limi
 	return bills, nil
 }
 
-// CountByIssuer counts total bills for an issuer
-func (r *BillRepository) CountByIssuer(ctx context.Context, issuerID string) (int, error) {
+// CountByIssuer counts total bills for an issuer, including soft-deleted ones when includeDeleted is set
+func (r *BillRepository) CountByIssuer(ctx context.Context, issuerID string, includeDeleted bool) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM bills WHERE issuer_id = $1 AND is_deleted = false`
+	query := `SELECT COUNT(*) FROM bills WHERE issuer_id = $1 AND ($2 OR is_deleted = false)`
 
-	err := r.db.GetContext(ctx, &count, query, issuerID)
+	err := r.db.GetContext(ctx, &count, query, issuerID, includeDeleted)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count bills: %w", err)
 	}
@@ -119,60 +197,322 @@ func (r *BillRepository) CountByIssuer(ctx context.Context, issuerID string) (in
 	return count, nil
 }
 
-// GetStatsByIssuer retrieves statistics for an issuer
-func (r *BillRepository) GetStatsByIssuer(ctx context.Context, issuerID string) (*models.BillStats, error) {
+// GetStatsByIssuer retrieves statistics for an issuer in a single pass over their bills
+// Relies on idx_bills_issuer_stats (issuer_id, is_deleted, created_at) to read only the issuer's live bills.
+// "This month" counts bills created since monthStart
+func (r *BillRepository) GetStatsByIssuer(ctx context.Context, issuerID string, monthStart time.Time) (*models.BillStats, error) {
 	stats := &models.BillStats{}
 
-	// Total bills
-	query := `SELECT COUNT(*) FROM bills WHERE issuer_id = $1 AND is_deleted = false`
-	err := r.db.GetContext(ctx, &stats.TotalBills, query, issuerID)
+	// Verification counts include pruned verifications kept in verification_rollups
+	query := `
+		WITH issuer_bills AS (
+			SELECT id, amount, is_active, created_at FROM bills
+			WHERE issuer_id = $1 
+			AND is_deleted = false
+		)
+		SELECT
+			COUNT(*) AS total_bills,
+			COUNT(*) FILTER (WHERE created_at >= $2) AS this_month_bills,
+			COUNT(*) FILTER (WHERE is_active = true) AS active_bills,
+			COALESCE(SUM(amount), 0) AS total_amount,
+			(SELECT COUNT(*) FROM verifications v
+			 WHERE v.bill_id IN (SELECT id FROM issuer_bills))
+			+ (SELECT COALESCE(SUM(vr.verification_count), 0) FROM verification_rollups vr
+			 WHERE vr.bill_id IN (SELECT id FROM issuer_bills)) AS total_verifications
+		FROM issuer_bills
+	`
+	err := r.db.GetContext(ctx, stats, query, issuerID, monthStart.UTC())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get total bills: %w", err)
+		return nil, fmt.Errorf("failed to get bill stats: %w", err)
 	}
 
-	// This month's bills
-	query = `
-		SELECT COUNT(*) FROM bills 
+	return stats, nil
+}
+
+// TopBillTypeByIssuer returns the issuer's most generated bill type and its count
+// Returns an empty type and 0 when the issuer has no bills
+func (r *BillRepository) TopBillTypeByIssuer(ctx context.Context, issuerID string) (models.BillType, int, error) {
+	var row struct {
+		BillType models.BillType `db:"bill_type"`
+		Count    int             `db:"count"`
+	}
+	query := `
+		SELECT bill_type, COUNT(*) AS count 
+		FROM bills 
 		WHERE issuer_id = $1 
-		AND is_deleted = false 
-		AND DATE_TRUNC('month', created_at) = DATE_TRUNC('month', NOW())
+		AND is_deleted = false
+		GROUP BY bill_type
+		ORDER BY COUNT(*) DESC, bill_type ASC
+		LIMIT 1
 	`
-	err = r.db.GetContext(ctx, &stats.ThisMonthBills, query, issuerID)
+
+	err := r.db.GetContext(ctx, &row, query, issuerID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get monthly bills: %w", err)
+		if err == sql.ErrNoRows {
+			return "", 0, nil
+		}
+		return "", 0, fmt.Errorf("failed to get top bill type: %w", err)
 	}
 
-	// Active bills
-	query = `
-		SELECT COUNT(*) FROM bills 
-		WHERE issuer_id = $1 
-		AND is_deleted = false 
-		AND is_active = true
+	return row.BillType, row.Count, nil
+}
+
+// TimeSeriesByIssuer counts an issuer's bills and sums their amounts in day or week buckets by creation time
+// interval must be a date_trunc field ("day" or "week"); from is inclusive and to exclusive.
+// Buckets start at midnight in loc. Deleted bills are left out, and buckets without bills are not returned
+func (r *BillRepository) TimeSeriesByIssuer(ctx context.Context, issuerID, interval string, from, to time.Time, loc *time.Location) ([]models.BillTrendRow, error) {
+	var rows []models.BillTrendRow
+	query := `
+		SELECT date_trunc($2, created_at AT TIME ZONE $5) AS bucket, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount
+		FROM bills
+		WHERE issuer_id = $1 AND is_deleted = false AND created_at >= $3 AND created_at < $4
+		GROUP BY bucket
+		ORDER BY bucket
 	`
-	err = r.db.GetContext(ctx, &stats.ActiveBills, query, issuerID)
+
+	err := r.db.SelectContext(ctx, &rows, query, issuerID, interval, from.UTC(), to.UTC(), loc.String())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active bills: %w", err)
+		return nil, fmt.Errorf("failed to get bill trends: %w", err)
 	}
 
-	// Total amount
-	query = `
-		SELECT COALESCE(SUM(amount), 0) FROM bills 
+	return rows, nil
+}
+
+// CountByTypeForIssuer returns how many bills the issuer generated of each type
+func (r *BillRepository) CountByTypeForIssuer(ctx context.Context, issuerID string) (map[models.BillType]int, error) {
+	var rows []struct {
+		BillType models.BillType `db:"bill_type"`
+		Count    int             `db:"count"`
+	}
+	query := `
+		SELECT bill_type, COUNT(*) AS count 
+		FROM bills 
 		WHERE issuer_id = $1 
 		AND is_deleted = false
+		GROUP BY bill_type
 	`
-	err = r.db.GetContext(ctx, &stats.TotalAmount, query, issuerID)
+
+	err := r.db.SelectContext(ctx, &rows, query, issuerID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get total amount: %w", err)
+		return nil, fmt.Errorf("failed to count bills by type: %w", err)
 	}
 
-	// Total verifications (will be implemented with verifications table)
-	stats.TotalVerifications = 0
+	counts := make(map[models.BillType]int, len(rows))
+	for _, row := range rows {
+		counts[row.BillType] = row.Count
+	}
 
-	return stats, nil
+	return counts, nil
+}
+
+// GetByHashAndIssuer finds the issuer's newest non-deleted bill with a content hash created since a time
+// Returns ErrBillNotFound if there is none
+func (r *BillRepository) GetByHashAndIssuer(ctx context.Context, issuerID, contentHash string, since time.Time) (*models.Bill, error) {
+	return r.getByHashAndIssuer(ctx, r.db, issuerID, contentHash, since)
+}
+
+// GetByHashAndIssuerTx is GetByHashAndIssuer inside an existing transaction
+func (r *BillRepository) GetByHashAndIssuerTx(ctx context.Context, tx *sqlx.Tx, issuerID, contentHash string, since time.Time) (*models.Bill, error) {
+	return r.getByHashAndIssuer(ctx, tx, issuerID, contentHash, since)
+}
+
+func (r *BillRepository) getByHashAndIssuer(ctx context.Context, q sqlx.QueryerContext, issuerID, contentHash string, since time.Time) (*models.Bill, error) {
+	var bill models.Bill
+	query := `
+		SELECT * FROM bills
+		WHERE issuer_id = $1 AND content_hash = $2 AND created_at >= $3 AND is_deleted = false
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	err := sqlx.GetContext(ctx, q, &bill, query, issuerID, contentHash, since)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBillNotFound
+		}
+		return nil, fmt.Errorf("failed to find duplicate bill: %w", err)
+	}
+
+	return &bill, nil
+}
+
+// GetByIDForUpdateTx retrieves a bill and locks the row until the transaction ends
+func (r *BillRepository) GetByIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, id string) (*models.Bill, error) {
+	var bill models.Bill
+	query := `SELECT * FROM bills WHERE id = $1 AND is_deleted = false FOR UPDATE`
+
+	err := tx.GetContext(ctx, &bill, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBillNotFound
+		}
+		return nil, fmt.Errorf("failed to get bill: %w", err)
+	}
+
+	return &bill, nil
+}
+
+// UpdateContentTx replaces a bill's data, amount and hash inside an existing transaction
+// The new content hasn't been committed on-chain, so blockchain status goes back to pending.
+// Call InvalidateCache after the transaction commits.
+func (r *BillRepository) UpdateContentTx(ctx context.Context, tx *sqlx.Tx, bill *models.Bill) error {
+	query := `
+		UPDATE bills 
+		SET bill_data = $2,
+		    amount = $3,
+		    data_hash = $4,
+		    needs_review = $5,
+		    content_hash = $6,
+		    blockchain_status = 'pending',
+		    blockchain_tx_id = NULL,
+		    blockchain_confirmed_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND is_deleted = false
+		RETURNING updated_at
+	`
+
+	err := tx.QueryRowxContext(ctx, query, bill.ID, bill.BillData, bill.Amount, bill.DataHash, bill.NeedsReview, bill.ContentHash).Scan(&bill.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBillNotFound
+		}
+		return fmt.Errorf("failed to update bill: %w", err)
+	}
+
+	bill.BlockchainStatus = models.BlockchainPending
+	bill.BlockchainTxID = nil
+	bill.BlockchainConfirmedAt = nil
+
+	return nil
+}
+
+// TransferOwnershipTx gives a bill to another issuer inside an existing transaction
+// Only issuer_id (and issuer_name, when issuerName is set) change; the bill number, data, hash
+// and verifications stay as they were. Call InvalidateCache after the transaction commits.
+func (r *BillRepository) TransferOwnershipTx(ctx context.Context, tx *sqlx.Tx, bill *models.Bill, toIssuerID string, issuerName *string) error {
+	query := `
+		UPDATE bills 
+		SET issuer_id = $2,
+		    issuer_name = COALESCE($3, issuer_name),
+		    updated_at = NOW()
+		WHERE id = $1 AND is_deleted = false
+		RETURNING issuer_name, updated_at
+	`
+
+	err := tx.QueryRowxContext(ctx, query, bill.ID, toIssuerID, issuerName).Scan(&bill.IssuerName, &bill.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBillNotFound
+		}
+		return fmt.Errorf("failed to transfer bill: %w", err)
+	}
+
+	bill.IssuerID = toIssuerID
+
+	return nil
+}
+
+// InvalidateCache drops the cached copy of a bill after it changed outside this repository's
+// own write methods (e.g. in a transaction)
+func (r *BillRepository) InvalidateCache(ctx context.Context, billNumber string) {
+	r.invalidateBill(ctx, billNumber)
+}
+
+// CreateRevision archives a version of a bill's content
+func (r *BillRepository) CreateRevision(ctx context.Context, revision *models.BillRevision) error {
+	return r.createRevision(ctx, r.db, revision)
+}
+
+// CreateRevisionTx archives a version of a bill's content inside an existing transaction
+func (r *BillRepository) CreateRevisionTx(ctx context.Context, tx *sqlx.Tx, revision *models.BillRevision) error {
+	return r.createRevision(ctx, tx, revision)
+}
+
+// createRevision runs the revision insert on either the pool or a transaction
+// RevisionNumber is assigned as one past the bill's latest revision
+func (r *BillRepository) createRevision(ctx context.Context, q sqlx.QueryerContext, revision *models.BillRevision) error {
+	query := `
+		INSERT INTO bill_revisions (
+			bill_id, revision_number, bill_data, data_hash, amount, blockchain_tx_id, edited_by, edit_reason
+		) VALUES (
+			$1,
+			(SELECT COALESCE(MAX(revision_number), 0) + 1 FROM bill_revisions WHERE bill_id = $1),
+			$2, $3, $4, $5, $6, $7
+		) RETURNING id, revision_number, created_at
+	`
+
+	err := q.QueryRowxContext(
+		ctx,
+		query,
+		revision.BillID,
+		revision.BillData,
+		revision.DataHash,
+		revision.Amount,
+		revision.BlockchainTxID,
+		revision.EditedBy,
+		revision.EditReason,
+	).Scan(&revision.ID, &revision.RevisionNumber, &revision.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create bill revision: %w", err)
+	}
+
+	return nil
+}
+
+// ListRevisions retrieves a bill's archived versions, newest first
+func (r *BillRepository) ListRevisions(ctx context.Context, billID string) ([]*models.BillRevision, error) {
+	var revisions []*models.BillRevision
+	query := `SELECT * FROM bill_revisions WHERE bill_id = $1 ORDER BY revision_number DESC`
+
+	err := r.db.SelectContext(ctx, &revisions, query, billID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bill revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// ListPendingBlockchain retrieves bills still waiting for blockchain commitment, oldest first
+func (r *BillRepository) ListPendingBlockchain(ctx context.Context, limit int) ([]*models.Bill, error) {
+	var bills []*models.Bill
+	query := `
+		SELECT * FROM bills 
+		WHERE blockchain_status = 'pending' 
+		AND is_deleted = false
+		ORDER BY created_at ASC
+		LIMIT $1
+	`
+
+	err := r.db.SelectContext(ctx, &bills, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending bills: %w", err)
+	}
+
+	return bills, nil
 }
 
 // SoftDelete marks a bill as deleted
 func (r *BillRepository) SoftDelete(ctx context.Context, id, reason string) error {
+	billNumber, err := r.softDelete(ctx, r.db, id, reason)
+	if err != nil {
+		return err
+	}
+
+	r.invalidateBill(ctx, billNumber)
+
+	return nil
+}
+
+// SoftDeleteTx marks a bill as deleted inside an existing transaction
+// Call InvalidateCache after the transaction commits.
+func (r *BillRepository) SoftDeleteTx(ctx context.Context, tx *sqlx.Tx, id, reason string) error {
+	_, err := r.softDelete(ctx, tx, id, reason)
+	return err
+}
+
+// softDelete marks a bill as deleted and returns its bill number
+func (r *BillRepository) softDelete(ctx context.Context, q sqlx.QueryerContext, id, reason string) (string, error) {
 	query := `
 		UPDATE bills 
 		SET is_deleted = true, 
@@ -180,59 +520,109 @@ func (r *BillRepository) SoftDelete(ctx context.Context, id, reason string) erro
 		    deleted_at = NOW(),
 		    updated_at = NOW()
 		WHERE id = $1
+		RETURNING bill_number
 	`
 
-	result, err := r.db.ExecContext(ctx, query, id, reason)
+	var billNumber string
+	err := q.QueryRowxContext(ctx, query, id, reason).Scan(&billNumber)
 	if err != nil {
-		return fmt.Errorf("failed to delete bill: %w", err)
+		if err == sql.ErrNoRows {
+			return "", ErrBillNotFound
+		}
+		return "", fmt.Errorf("failed to delete bill: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
+	return billNumber, nil
+}
+
+// Restore undoes a soft delete
+// Returns ErrBillNotFound if the bill doesn't exist or isn't deleted
+func (r *BillRepository) Restore(ctx context.Context, id string) error {
+	billNumber, err := r.restore(ctx, r.db, id)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("bill not found")
-	}
+	// Drop anything cached while the bill was deleted
+	r.invalidateBill(ctx, billNumber)
 
 	return nil
 }
 
+// RestoreTx undoes a soft delete inside an existing transaction
+// Call InvalidateCache after the transaction commits.
+func (r *BillRepository) RestoreTx(ctx context.Context, tx *sqlx.Tx, id string) error {
+	_, err := r.restore(ctx, tx, id)
+	return err
+}
+
+// restore undoes a soft delete and returns the bill number
+func (r *BillRepository) restore(ctx context.Context, q sqlx.QueryerContext, id string) (string, error) {
+	query := `
+		UPDATE bills 
+		SET is_deleted = false, 
+		    deletion_reason = NULL, 
+		    deleted_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND is_deleted = true
+		RETURNING bill_number
+	`
+
+	var billNumber string
+	err := q.QueryRowxContext(ctx, query, id).Scan(&billNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrBillNotFound
+		}
+		return "", fmt.Errorf("failed to restore bill: %w", err)
+	}
+
+	return billNumber, nil
+}
+
 // UpdateBlockchainStatus updates the blockchain status of a bill
 func (r *BillRepository) UpdateBlockchainStatus(ctx context.Context, id, txID string, status models.BlockchainStatus) error {
 	query := `
 		UPDATE bills 
-		SET blockchain_tx_id = $2, 
+		SET blockchain_tx_id = NULLIF($2, ''), 
 		    blockchain_status = $3,
 		    blockchain_confirmed_at = CASE WHEN $3 = 'confirmed' THEN NOW() ELSE NULL END,
 		    updated_at = NOW()
 		WHERE id = $1
+		RETURNING bill_number
 	`
 
-	result, err := r.db.ExecContext(ctx, query, id, txID, status)
+	var billNumber string
+	err := r.db.QueryRowContext(ctx, query, id, txID, status).Scan(&billNumber)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBillNotFound
+		}
 		return fmt.Errorf("failed to update blockchain status: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rows == 0 {
-		return fmt.Errorf("bill not found")
-	}
+	r.invalidateBill(ctx, billNumber)
 
 	return nil
 }
 
-// GenerateBillNumber generates a unique bill number
-func (r *BillRepository) GenerateBillNumber(ctx context.Context, billType models.BillType) (string, error) {
+// GenerateBillNumber generates a unique bill number, numbered in the current month in loc
+func (r *BillRepository) GenerateBillNumber(ctx context.Context, billType models.BillType, loc *time.Location) (string, error) {
+	return r.generateBillNumber(ctx, r.db, billType, loc)
+}
+
+// GenerateBillNumberTx generates a bill number inside an existing transaction
+// Numbers account for bills already inserted by the same transaction, so batches don't collide
+func (r *BillRepository) GenerateBillNumberTx(ctx context.Context, tx *sqlx.Tx, billType models.BillType, loc *time.Location) (string, error) {
+	return r.generateBillNumber(ctx, tx, billType, loc)
+}
+
+// generateBillNumber runs generate_bill_number on either the pool or a transaction
+func (r *BillRepository) generateBillNumber(ctx context.Context, q sqlx.QueryerContext, billType models.BillType, loc *time.Location) (string, error) {
 	var billNumber string
-	query := `SELECT generate_bill_number($1)`
+	query := `SELECT generate_bill_number($1, $2)`
 
-	err := r.db.GetContext(ctx, &billNumber, query, billType)
+	err := sqlx.GetContext(ctx, q, &billNumber, query, billType, loc.String())
 	if err != nil {
 		return "", fmt.Errorf("failed to generate bill number: %w", err)
 	}
@@ -240,38 +630,182 @@ func (r *BillRepository) GenerateBillNumber(ctx context.Context, billType models
 	return billNumber, nil
 }
 
-// Search bills by various criteria
-func (r *BillRepository) Search(ctx context.Context, issuerID string, billType *models.BillType, startDate, endDate *time.Time, limit, offset int) ([]*models.Bill, error) {
-	var bills []*models.Bill
+// CountAll counts all bills that haven't been deleted
+func (r *BillRepository) CountAll(ctx context.Context) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM bills WHERE is_deleted = false`
+
+	err := r.db.GetContext(ctx, &count, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count bills: %w", err)
+	}
 
+	return count, nil
+}
+
+// CountByBlockchainStatus counts bills that haven't been deleted, grouped by blockchain status
+func (r *BillRepository) CountByBlockchainStatus(ctx context.Context) (map[models.BlockchainStatus]int, error) {
+	var rows []struct {
+		Status models.BlockchainStatus `db:"blockchain_status"`
+		Count  int                     `db:"count"`
+	}
 	query := `
-		SELECT * FROM bills 
+		SELECT blockchain_status, COUNT(*) AS count 
+		FROM bills 
+		WHERE is_deleted = false
+		GROUP BY blockchain_status
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count bills by blockchain status: %w", err)
+	}
+
+	counts := make(map[models.BlockchainStatus]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
+// AdminSearch searches bills across all issuers and returns the page plus the total match count
+// Unlike Search it is not scoped to an issuer and can include soft-deleted bills
+func (r *BillRepository) AdminSearch(ctx context.Context, filter models.AdminBillFilter, limit, offset int) ([]*models.Bill, int, error) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+
+	if !filter.IncludeDeleted {
+		where += " AND is_deleted = false"
+	}
+
+	if filter.IssuerID != nil {
+		args = append(args, *filter.IssuerID)
+		where += fmt.Sprintf(" AND issuer_id = $%d", len(args))
+	}
+
+	if filter.BillType != nil {
+		args = append(args, *filter.BillType)
+		where += fmt.Sprintf(" AND bill_type = $%d", len(args))
+	}
+
+	if filter.BlockchainStatus != nil {
+		args = append(args, *filter.BlockchainStatus)
+		where += fmt.Sprintf(" AND blockchain_status = $%d", len(args))
+	}
+
+	if filter.StartDate != nil {
+		args = append(args, *filter.StartDate)
+		where += fmt.Sprintf(" AND issue_date >= $%d", len(args))
+	}
+
+	if filter.EndDate != nil {
+		args = append(args, *filter.EndDate)
+		where += fmt.Sprintf(" AND issue_date <= $%d", len(args))
+	}
+
+	if filter.NeedsReview != nil {
+		args = append(args, *filter.NeedsReview)
+		where += fmt.Sprintf(" AND needs_review = $%d", len(args))
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM bills"+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count bills: %w", err)
+	}
+
+	query := "SELECT * FROM bills" + where +
+		fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	var bills []*models.Bill
+	if err := r.db.SelectContext(ctx, &bills, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to search bills: %w", err)
+	}
+
+	return bills, total, nil
+}
+
+// billSearchVector is the document matched by text search
+// It must stay identical to the expression of idx_bills_text_search (migration 012) or the GIN index won't be used
+const billSearchVector = `to_tsvector('simple',
+		COALESCE(bill_data->>'recipient_name', '') || ' ' ||
+		COALESCE(bill_data->>'description', '') || ' ' ||
+		bill_number || ' ' ||
+		issuer_name)`
+
+// buildPrefixTSQuery turns free text into a tsquery matching every word as a prefix,
+// e.g. "ravi kum" becomes "ravi:* & kum:*"
+// Anything other than letters, digits and combining marks (e.g. Devanagari vowel signs) is dropped
+// so user input can't inject tsquery operators
+func buildPrefixTSQuery(text string) string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	}) {
+		terms = append(terms, strings.ToLower(word)+":*")
+	}
+	return strings.Join(terms, " & ")
+}
+
+// searchWhere builds the WHERE clause shared by Search, SearchByText and CountSearch
+// so the page and the total can't drift apart
+func searchWhere(issuerID, text string, billType *models.BillType, startDate, endDate *time.Time, includeDeleted bool) (string, []interface{}) {
+	where := `
 		WHERE issuer_id = $1 
-		AND is_deleted = false
+		AND ($2 OR is_deleted = false)
 	`
-	args := []interface{}{issuerID}
-	argCount := 1
+	args := []interface{}{issuerID, includeDeleted}
+	argCount := 2
+
+	if tsquery := buildPrefixTSQuery(text); tsquery != "" {
+		argCount++
+		where += fmt.Sprintf(" AND %s @@ to_tsquery('simple', $%d)", billSearchVector, argCount)
+		args = append(args, tsquery)
+	}
 
 	if billType != nil {
 		argCount++
-		query += fmt.Sprintf(" AND bill_type = $%d", argCount)
+		where += fmt.Sprintf(" AND bill_type = $%d", argCount)
 		args = append(args, *billType)
 	}
 
 	if startDate != nil {
 		argCount++
-		query += fmt.Sprintf(" AND issue_date >= $%d", argCount)
+		where += fmt.Sprintf(" AND issue_date >= $%d", argCount)
 		args = append(args, *startDate)
 	}
 
 	if endDate != nil {
 		argCount++
-		query += fmt.Sprintf(" AND issue_date <= $%d", argCount)
+		where += fmt.Sprintf(" AND issue_date <= $%d", argCount)
 		args = append(args, *endDate)
 	}
 
+	return where, args
+}
+
+// Search bills by various criteria
+// Soft-deleted bills are only included when includeDeleted is set
+func (r *BillRepository) Search(ctx context.Context, issuerID string, billType *models.BillType, startDate, endDate *time.Time, includeDeleted bool, limit, offset int) ([]*models.Bill, error) {
+	return r.search(ctx, issuerID, "", billType, startDate, endDate, includeDeleted, limit, offset)
+}
+
+// SearchByText searches an issuer's bills by recipient name, description, bill number or issuer name
+// Each word in text matches as a prefix, so partial names work. The query relies on the
+// idx_bills_text_search GIN index; without it Postgres falls back to a sequential scan.
+// The other filters behave as in Search
+func (r *BillRepository) SearchByText(ctx context.Context, issuerID, text string, billType *models.BillType, startDate, endDate *time.Time, includeDeleted bool, limit, offset int) ([]*models.Bill, error) {
+	return r.search(ctx, issuerID, text, billType, startDate, endDate, includeDeleted, limit, offset)
+}
+
+func (r *BillRepository) search(ctx context.Context, issuerID, text string, billType *models.BillType, startDate, endDate *time.Time, includeDeleted bool, limit, offset int) ([]*models.Bill, error) {
+	var bills []*models.Bill
+
+	where, args := searchWhere(issuerID, text, billType, startDate, endDate, includeDeleted)
+
+	query := "SELECT * FROM bills" + where
 	query += " ORDER BY created_at DESC"
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount+1, argCount+2)
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
 	args = append(args, limit, offset)
 
 	err := r.db.SelectContext(ctx, &bills, query, args...)
@@ -281,3 +815,17 @@ func (r *BillRepository) Search(ctx context.Context, issuerID string, billType *
 
 	return bills, nil
 }
+
+// CountSearch counts all bills matching the same criteria as Search, or SearchByText when text is set
+func (r *BillRepository) CountSearch(ctx context.Context, issuerID, text string, billType *models.BillType, startDate, endDate *time.Time, includeDeleted bool) (int, error) {
+	var count int
+
+	where, args := searchWhere(issuerID, text, billType, startDate, endDate, includeDeleted)
+
+	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM bills"+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count bills: %w", err)
+	}
+
+	return count, nil
+}
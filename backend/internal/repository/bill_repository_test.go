@@ -0,0 +1,239 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestGetByBillNumberCachesAndInvalidates(t *testing.T) {
+	db := testutil.DB(t)
+	cache := testutil.Redis(t)
+	ctx := context.Background()
+
+	repo := repository.NewBillRepository(db.DB, cache, time.Minute)
+	issuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 100)
+	bill := testutil.CreateBill(t, db, issuer, 500, nil)
+	key := "bill:" + bill.BillNumber
+
+	if _, err := repo.GetByBillNumber(ctx, bill.BillNumber); err != nil {
+		t.Fatalf("GetByBillNumber failed: %v", err)
+	}
+	if n, _ := cache.Exists(ctx, key).Result(); n != 1 {
+		t.Fatal("bill was not cached after the first lookup")
+	}
+
+	// Served from the cache: a change made behind the repository's back isn't seen
+	db.MustExec("UPDATE bills SET amount = 999 WHERE id = $1", bill.ID)
+	cached, err := repo.GetByBillNumber(ctx, bill.BillNumber)
+	if err != nil {
+		t.Fatalf("GetByBillNumber failed: %v", err)
+	}
+	if cached.Amount != 500 {
+		t.Errorf("amount = %.2f, want the cached 500", cached.Amount)
+	}
+
+	// Deleting through the repository invalidates the entry
+	if err := repo.SoftDelete(ctx, bill.ID, "test"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	if n, _ := cache.Exists(ctx, key).Result(); n != 0 {
+		t.Error("bill is still cached after being deleted")
+	}
+	if _, err := repo.GetByBillNumber(ctx, bill.BillNumber); !errors.Is(err, repository.ErrBillNotFound) {
+		t.Errorf("GetByBillNumber after delete = %v, want ErrBillNotFound", err)
+	}
+}
+
+func TestBillTypeCountsByIssuer(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewBillRepository(db.DB, nil, 0)
+
+	issuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	other := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+
+	// seed creates n bills of billType for issuer and returns the last one
+	seed := func(issuer *models.User, billType models.BillType, n int) *models.Bill {
+		var bill *models.Bill
+		for i := 0; i < n; i++ {
+			bill = testutil.CreateBill(t, db, issuer, 100, nil)
+			db.MustExec("UPDATE bills SET bill_type = $1 WHERE id = $2", billType, bill.ID)
+		}
+		return bill
+	}
+	seed(issuer, models.BillTypeSalarySlip, 3)
+	seed(issuer, models.BillTypeMedicalBill, 2)
+	seed(issuer, models.BillTypeRentReceipt, 1)
+	seed(other, models.BillTypeTaxReceipt, 5)
+
+	// Deleted bills don't count, even when they'd change the winner
+	for i := 0; i < 2; i++ {
+		deleted := seed(issuer, models.BillTypeMedicalBill, 1)
+		if err := repo.SoftDelete(ctx, deleted.ID, "test"); err != nil {
+			t.Fatalf("SoftDelete failed: %v", err)
+		}
+	}
+
+	topType, topCount, err := repo.TopBillTypeByIssuer(ctx, issuer.ID)
+	if err != nil {
+		t.Fatalf("TopBillTypeByIssuer failed: %v", err)
+	}
+	if topType != models.BillTypeSalarySlip || topCount != 3 {
+		t.Errorf("top type = %s (%d), want salary_slip (3)", topType, topCount)
+	}
+
+	counts, err := repo.CountByTypeForIssuer(ctx, issuer.ID)
+	if err != nil {
+		t.Fatalf("CountByTypeForIssuer failed: %v", err)
+	}
+	want := map[models.BillType]int{
+		models.BillTypeSalarySlip:  3,
+		models.BillTypeMedicalBill: 2,
+		models.BillTypeRentReceipt: 1,
+	}
+	if len(counts) != len(want) {
+		t.Errorf("breakdown = %v, want %v", counts, want)
+	}
+	for billType, n := range want {
+		if counts[billType] != n {
+			t.Errorf("%s count = %d, want %d", billType, counts[billType], n)
+		}
+	}
+
+	// An issuer without bills has no top type
+	empty := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	if topType, topCount, err := repo.TopBillTypeByIssuer(ctx, empty.ID); err != nil || topType != "" || topCount != 0 {
+		t.Errorf("TopBillTypeByIssuer(no bills) = %q, %d, %v; want empty", topType, topCount, err)
+	}
+}
+
+func TestSearchByText(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewBillRepository(db.DB, nil, 0)
+
+	issuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	other := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+
+	ravi := testutil.CreateBill(t, db, issuer, 100, map[string]interface{}{"recipient_name": "Ravi Kumar", "description": "Consulting fees"})
+	asha := testutil.CreateBill(t, db, issuer, 100, map[string]interface{}{"recipient_name": "Asha Rao", "description": "Annual rent"})
+	ram := testutil.CreateBill(t, db, issuer, 100, map[string]interface{}{"recipient_name": "राम शर्मा"})
+	// Another issuer's matching bill must never show up
+	testutil.CreateBill(t, db, other, 100, map[string]interface{}{"recipient_name": "Ravi Kumar"})
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"full recipient name", "Ravi Kumar", []string{ravi.BillNumber}},
+		{"partial tokens", "rav kum", []string{ravi.BillNumber}},
+		{"description", "annual", []string{asha.BillNumber}},
+		{"Devanagari name", "राम", []string{ram.BillNumber}},
+		{"bill number", ravi.BillNumber, []string{ravi.BillNumber}},
+		{"every word must match", "ravi rao", nil},
+		{"no match", "nobody", nil},
+		{"operators are ignored", "asha') | (ravi", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bills, err := repo.SearchByText(ctx, issuer.ID, tt.text, nil, nil, nil, false, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchByText(%q) failed: %v", tt.text, err)
+			}
+			var got []string
+			for _, bill := range bills {
+				got = append(got, bill.BillNumber)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SearchByText(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+
+			total, err := repo.CountSearch(ctx, issuer.ID, tt.text, nil, nil, nil, false)
+			if err != nil {
+				t.Fatalf("CountSearch(%q) failed: %v", tt.text, err)
+			}
+			if total != len(tt.want) {
+				t.Errorf("CountSearch(%q) = %d, want %d", tt.text, total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestGetStatsByIssuerMatchesPerMetricQueries(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	bills := repository.NewBillRepository(db.DB, nil, 0)
+
+	issuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+
+	current := testutil.CreateBill(t, db, issuer, 100, nil)
+	old := testutil.CreateBill(t, db, issuer, 250.50, nil)
+	inactive := testutil.CreateBill(t, db, issuer, 75, nil)
+	deleted := testutil.CreateBill(t, db, issuer, 1000, nil)
+	foreign := testutil.CreateBill(t, db, testutil.CreateUser(t, db, models.RoleInstitutionUser, 0), 500, nil)
+
+	db.MustExec("UPDATE bills SET created_at = NOW() - INTERVAL '60 days' WHERE id = $1", old.ID)
+	db.MustExec("UPDATE bills SET is_active = false WHERE id = $1", inactive.ID)
+	if err := bills.SoftDelete(ctx, deleted.ID, "test"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	for _, bill := range []*models.Bill{current, current, old, inactive, deleted, foreign} {
+		testutil.CreateVerification(t, db, bill, verifier)
+	}
+	// Pruned verifications count too, except for deleted bills
+	db.MustExec(`INSERT INTO verification_rollups (day, bill_id, verification_status, verification_count)
+		VALUES (CURRENT_DATE - 100, $1, 'valid', 4), (CURRENT_DATE - 100, $2, 'valid', 9)`, old.ID, deleted.ID)
+
+	monthStart := time.Now().AddDate(0, 0, -30)
+
+	// One query per metric, as GetStatsByIssuer used to run them
+	var want models.BillStats
+	for _, q := range []struct {
+		dest  interface{}
+		query string
+		args  []interface{}
+	}{
+		{&want.TotalBills, `SELECT COUNT(*) FROM bills WHERE issuer_id = $1 AND is_deleted = false`, nil},
+		{&want.ThisMonthBills, `SELECT COUNT(*) FROM bills WHERE issuer_id = $1 AND is_deleted = false AND created_at >= $2`, []interface{}{monthStart.UTC()}},
+		{&want.ActiveBills, `SELECT COUNT(*) FROM bills WHERE issuer_id = $1 AND is_deleted = false AND is_active = true`, nil},
+		{&want.TotalAmount, `SELECT COALESCE(SUM(amount), 0) FROM bills WHERE issuer_id = $1 AND is_deleted = false`, nil},
+		{&want.TotalVerifications, `
+			SELECT (
+				SELECT COUNT(*) FROM verifications v
+				JOIN bills b ON b.id = v.bill_id
+				WHERE b.issuer_id = $1 AND b.is_deleted = false
+			) + (
+				SELECT COALESCE(SUM(vr.verification_count), 0) FROM verification_rollups vr
+				JOIN bills b ON b.id = vr.bill_id
+				WHERE b.issuer_id = $1 AND b.is_deleted = false
+			)`, nil},
+	} {
+		if err := db.GetContext(ctx, q.dest, q.query, append([]interface{}{issuer.ID}, q.args...)...); err != nil {
+			t.Fatalf("reference query failed: %v", err)
+		}
+	}
+
+	got, err := bills.GetStatsByIssuer(ctx, issuer.ID, monthStart)
+	if err != nil {
+		t.Fatalf("GetStatsByIssuer failed: %v", err)
+	}
+	if *got != want {
+		t.Errorf("GetStatsByIssuer = %+v, want %+v", *got, want)
+	}
+
+	// Sanity check the seeded dataset against hand-counted values
+	expected := models.BillStats{TotalBills: 3, ThisMonthBills: 2, ActiveBills: 2, TotalAmount: 425.50, TotalVerifications: 8}
+	if want != expected {
+		t.Errorf("reference stats = %+v, want %+v", want, expected)
+	}
+}
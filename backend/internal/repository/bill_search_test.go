@@ -0,0 +1,29 @@
+package repository
+
+import "testing"
+
+func TestBuildPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"Ravi", "ravi:*"},
+		{"ravi kum", "ravi:* & kum:*"},
+		{"  Asha   RAO ", "asha:* & rao:*"},
+		{"OTH-2024-000001", "oth:* & 2024:* & 000001:*"},
+		// tsquery operators and quotes are dropped rather than passed through
+		{"ravi | !kumar", "ravi:* & kumar:*"},
+		{"x') OR 1=1 --", "x:* & or:* & 1:* & 1:*"},
+		{"a:* & b", "a:* & b:*"},
+		{"&|!()<>:*'\"", ""},
+		{"राम", "राम:*"},
+	}
+
+	for _, tt := range tests {
+		if got := buildPrefixTSQuery(tt.text); got != tt.want {
+			t.Errorf("buildPrefixTSQuery(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
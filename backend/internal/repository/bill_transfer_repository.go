@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// BillTransferRepository handles database operations for bill ownership transfers
+type BillTransferRepository struct {
+	db instrumentedDB
+}
+
+// NewBillTransferRepository creates a new bill transfer repository
+func NewBillTransferRepository(db *sqlx.DB) *BillTransferRepository {
+	return &BillTransferRepository{db: instrumentedDB{db}}
+}
+
+// CreateTx stores a transfer inside an existing transaction
+func (r *BillTransferRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, transfer *models.BillTransfer) error {
+	query := `
+		INSERT INTO bill_transfers (
+			bill_id, from_issuer_id, to_issuer_id, requested_by, status,
+			update_issuer_name, previous_issuer_name, new_issuer_name,
+			confirmation_token, expires_at, completed_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		) RETURNING id, created_at
+	`
+
+	err := tx.QueryRowxContext(
+		ctx,
+		query,
+		transfer.BillID,
+		transfer.FromIssuerID,
+		transfer.ToIssuerID,
+		transfer.RequestedBy,
+		transfer.Status,
+		transfer.UpdateIssuerName,
+		transfer.PreviousIssuerName,
+		transfer.NewIssuerName,
+		transfer.ConfirmationToken,
+		transfer.ExpiresAt,
+		transfer.CompletedAt,
+	).Scan(&transfer.ID, &transfer.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create bill transfer: %w", err)
+	}
+
+	return nil
+}
+
+// CancelPendingTx cancels the bill's pending transfers, so only the newest request can be accepted
+func (r *BillTransferRepository) CancelPendingTx(ctx context.Context, tx *sqlx.Tx, billID string) error {
+	query := `
+		UPDATE bill_transfers
+		SET status = 'cancelled', confirmation_token = NULL
+		WHERE bill_id = $1 AND status = 'pending'
+	`
+
+	if _, err := tx.ExecContext(ctx, query, billID); err != nil {
+		return fmt.Errorf("failed to cancel pending bill transfers: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingByTokenForUpdateTx retrieves a pending transfer by its confirmation token and locks it
+// Expired transfers are still returned so callers can say why they were rejected
+func (r *BillTransferRepository) GetPendingByTokenForUpdateTx(ctx context.Context, tx *sqlx.Tx, token string) (*models.BillTransfer, error) {
+	var transfer models.BillTransfer
+	query := `SELECT * FROM bill_transfers WHERE confirmation_token = $1 AND status = 'pending' FOR UPDATE`
+
+	err := tx.GetContext(ctx, &transfer, query, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBillTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to get bill transfer: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+// SetStatusTx completes or cancels a pending transfer and clears its confirmation token
+func (r *BillTransferRepository) SetStatusTx(ctx context.Context, tx *sqlx.Tx, transfer *models.BillTransfer, status models.BillTransferStatus) error {
+	query := `
+		UPDATE bill_transfers
+		SET status = $2,
+		    new_issuer_name = $3,
+		    confirmation_token = NULL,
+		    completed_at = CASE WHEN $2 = 'completed' THEN NOW() ELSE NULL END
+		WHERE id = $1 AND status = 'pending'
+		RETURNING completed_at
+	`
+
+	err := tx.QueryRowxContext(ctx, query, transfer.ID, status, transfer.NewIssuerName).Scan(&transfer.CompletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBillTransferNotFound
+		}
+		return fmt.Errorf("failed to update bill transfer: %w", err)
+	}
+
+	transfer.Status = status
+	transfer.ConfirmationToken = nil
+
+	return nil
+}
+
+// ListByBill retrieves a bill's transfers, newest first
+func (r *BillTransferRepository) ListByBill(ctx context.Context, billID string) ([]*models.BillTransfer, error) {
+	var transfers []*models.BillTransfer
+	query := `SELECT * FROM bill_transfers WHERE bill_id = $1 ORDER BY created_at DESC`
+
+	err := r.db.SelectContext(ctx, &transfers, query, billID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bill transfers: %w", err)
+	}
+
+	return transfers, nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import "errors"
+
+// Sentinel errors returned by repositories
+// Callers should compare with errors.Is rather than matching message text
+var (
+	ErrBillNotFound = errors.New("bill not found")
+	ErrUserNotFound = errors.New("user not found")
+
+	ErrShortCodeTaken = errors.New("short code already in use")
+
+	ErrInviteNotFound = errors.New("invite not found")
+
+	ErrBillTransferNotFound = errors.New("bill transfer not found")
+
+	ErrVerificationNotFound = errors.New("verification not found")
+
+	ErrPaymentOrderNotFound = errors.New("payment order not found")
+	ErrDuplicatePayment     = errors.New("payment already recorded")
+
+	ErrTokenRevocationUnavailable = errors.New("token revocation unavailable: Redis not configured")
+
+	ErrInsufficientFunds = errors.New("wallet balance would go negative")
+)
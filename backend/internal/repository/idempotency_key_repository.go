@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyKeyRepository handles database operations for Idempotency-Key headers
+// The row for a key doubles as its lock: while the transaction that claimed it is open,
+// a concurrent claim of the same key blocks until that transaction commits or rolls back
+type IdempotencyKeyRepository struct {
+	db instrumentedDB
+}
+
+// NewIdempotencyKeyRepository creates a new idempotency key repository
+func NewIdempotencyKeyRepository(db *sqlx.DB) *IdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{db: instrumentedDB{db}}
+}
+
+// Lookup returns the bill created with a key within the window and the hash of the request that created it
+// billID is empty if the key is unknown, expired or its bill was removed; requestHash is empty for unknown
+// or expired keys and for keys stored before request hashes were recorded
+func (r *IdempotencyKeyRepository) Lookup(ctx context.Context, userID, key string, window time.Duration) (billID, requestHash string, err error) {
+	var row struct {
+		BillID      sql.NullString `db:"bill_id"`
+		RequestHash sql.NullString `db:"request_hash"`
+	}
+	query := `
+		SELECT bill_id, request_hash FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2 AND created_at >= $3
+	`
+
+	err = r.db.GetContext(ctx, &row, query, userID, key, time.Now().Add(-window))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return row.BillID.String, row.RequestHash.String, nil
+}
+
+// ClaimTx reserves a key for the bill being created in tx, recording the hash of the request using it
+// Returns false if the key was already used within the window; expired keys are reclaimed
+func (r *IdempotencyKeyRepository) ClaimTx(ctx context.Context, tx *sqlx.Tx, userID, key, requestHash string, window time.Duration) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash)
+		VALUES ($1, $2, $4)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET bill_id = NULL, request_hash = EXCLUDED.request_hash, created_at = NOW()
+		WHERE idempotency_keys.created_at < $3
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID, key, time.Now().Add(-window), requestHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// SetBillIDTx records the bill created with a claimed key
+func (r *IdempotencyKeyRepository) SetBillIDTx(ctx context.Context, tx *sqlx.Tx, userID, key, billID string) error {
+	query := `UPDATE idempotency_keys SET bill_id = $3 WHERE user_id = $1 AND idempotency_key = $2`
+
+	if _, err := tx.ExecContext(ctx, query, userID, key, billID); err != nil {
+		return fmt.Errorf("failed to record idempotency key: %w", err)
+	}
+
+	return nil
+}
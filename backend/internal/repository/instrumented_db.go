@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// slowQueryThreshold is how long a query may run before it is logged; 0 disables logging
+var slowQueryThreshold time.Duration
+
+// SetSlowQueryThreshold sets how long a repository query may run before it is logged as slow
+// Call it once at startup, before any repository is used; 0 disables slow-query logging
+func SetSlowQueryThreshold(threshold time.Duration) {
+	slowQueryThreshold = threshold
+}
+
+// instrumentedDB is the connection pool repositories query through
+// It times GetContext, SelectContext and ExecContext and logs queries slower than slowQueryThreshold;
+// everything else, including transactions, passes straight through to sqlx
+type instrumentedDB struct {
+	*sqlx.DB
+}
+
+// GetContext runs a query expected to return one row and scans it into dest
+func (db instrumentedDB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	defer logSlowQuery(ctx, query, time.Now())
+	return db.DB.GetContext(ctx, dest, query, args...)
+}
+
+// SelectContext runs a query and scans every row into dest
+func (db instrumentedDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	defer logSlowQuery(ctx, query, time.Now())
+	return db.DB.SelectContext(ctx, dest, query, args...)
+}
+
+// ExecContext runs a statement that returns no rows
+func (db instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer logSlowQuery(ctx, query, time.Now())
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+// logSlowQuery logs query if it has been running longer than slowQueryThreshold since start
+// Arguments are left out since they can hold personal data
+func logSlowQuery(ctx context.Context, query string, start time.Time) {
+	if slowQueryThreshold <= 0 {
+		return
+	}
+
+	elapsed := time.Since(start)
+	if elapsed < slowQueryThreshold {
+		return
+	}
+
+	requestID := utils.RequestIDFromContext(ctx)
+	if requestID == "" {
+		requestID = "-"
+	}
+	log.Printf("🐢 Slow query (%s, request %s): %s", elapsed.Round(time.Millisecond), requestID, compactQuery(query))
+}
+
+// compactQuery collapses a multi-line query onto one line for logging
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
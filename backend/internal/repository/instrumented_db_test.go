@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+func TestLogSlowQuery(t *testing.T) {
+	var out bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&out)
+	t.Cleanup(func() { log.SetOutput(previous) })
+	t.Cleanup(func() { SetSlowQueryThreshold(0) })
+
+	query := `
+		SELECT * FROM bills
+		WHERE issuer_id = $1
+	`
+	ctx := utils.WithRequestID(context.Background(), "req-123")
+
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		ctx       context.Context
+		took      time.Duration
+		want      string // "" when nothing should be logged
+	}{
+		{"slow query", 100 * time.Millisecond, ctx, 250 * time.Millisecond, "request req-123): SELECT * FROM bills WHERE issuer_id = $1"},
+		{"outside a request", 100 * time.Millisecond, context.Background(), 250 * time.Millisecond, "request -): SELECT"},
+		{"fast query", 100 * time.Millisecond, ctx, 10 * time.Millisecond, ""},
+		{"logging disabled", 0, ctx, time.Minute, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			SetSlowQueryThreshold(tt.threshold)
+
+			logSlowQuery(tt.ctx, query, time.Now().Add(-tt.took))
+
+			if tt.want == "" {
+				if out.Len() > 0 {
+					t.Errorf("logged %q, want nothing", out.String())
+				}
+				return
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("logged %q, want it to contain %q", out.String(), tt.want)
+			}
+		})
+	}
+}
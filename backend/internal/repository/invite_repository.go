@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// InviteRepository handles database operations for admin signup invites
+type InviteRepository struct {
+	db instrumentedDB
+}
+
+// NewInviteRepository creates a new invite repository
+func NewInviteRepository(db *sqlx.DB) *InviteRepository {
+	return &InviteRepository{db: instrumentedDB{db}}
+}
+
+// Create stores a new invite
+func (r *InviteRepository) Create(ctx context.Context, invite *models.UserInvite) error {
+	return r.create(ctx, r.db, invite)
+}
+
+// CreateTx stores a new invite inside an existing transaction
+func (r *InviteRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, invite *models.UserInvite) error {
+	return r.create(ctx, tx, invite)
+}
+
+func (r *InviteRepository) create(ctx context.Context, q sqlx.QueryerContext, invite *models.UserInvite) error {
+	query := `
+		INSERT INTO user_invites (email, role, token, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := q.QueryRowxContext(
+		ctx,
+		query,
+		invite.Email,
+		invite.Role,
+		invite.Token,
+		invite.InvitedBy,
+		invite.ExpiresAt,
+	).Scan(&invite.ID, &invite.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	return nil
+}
+
+// GetUnusedByToken retrieves an invite that hasn't been used yet
+// Expired invites are still returned so callers can say why they were rejected
+func (r *InviteRepository) GetUnusedByToken(ctx context.Context, token string) (*models.UserInvite, error) {
+	var invite models.UserInvite
+	query := `SELECT * FROM user_invites WHERE token = $1 AND used_at IS NULL`
+
+	err := r.db.GetContext(ctx, &invite, query, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInviteNotFound
+		}
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	return &invite, nil
+}
+
+// MarkUsedTx records that an invite was used to create userID's account
+// Returns ErrInviteNotFound if the invite was already used, so each invite creates at most one account
+func (r *InviteRepository) MarkUsedTx(ctx context.Context, tx *sqlx.Tx, inviteID, userID string) error {
+	query := `
+		UPDATE user_invites
+		SET used_at = NOW(), used_by = $1
+		WHERE id = $2 AND used_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID, inviteID)
+	if err != nil {
+		return fmt.Errorf("failed to mark invite used: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrInviteNotFound
+	}
+
+	return nil
+}
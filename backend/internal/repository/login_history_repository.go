@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// LoginHistoryRepository handles database operations for users' recent sign-ins
+type LoginHistoryRepository struct {
+	db instrumentedDB
+}
+
+// NewLoginHistoryRepository creates a new login history repository
+func NewLoginHistoryRepository(db *sqlx.DB) *LoginHistoryRepository {
+	return &LoginHistoryRepository{db: instrumentedDB{db}}
+}
+
+// Create records a sign-in and drops the user's older events beyond keep
+func (r *LoginHistoryRepository) Create(ctx context.Context, event *models.LoginEvent, keep int) error {
+	query := `
+		INSERT INTO login_history (
+			user_id, ip_address, user_agent, browser, os, device, location
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(
+		ctx,
+		query,
+		event.UserID,
+		event.IPAddress,
+		event.UserAgent,
+		event.Browser,
+		event.OS,
+		event.Device,
+		event.Location,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+
+	prune := `
+		DELETE FROM login_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM login_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+		)
+	`
+	if _, err := r.db.ExecContext(ctx, prune, event.UserID, keep); err != nil {
+		return fmt.Errorf("failed to prune login history: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUser retrieves the user's recent sign-ins, newest first
+func (r *LoginHistoryRepository) ListByUser(ctx context.Context, userID string, limit int) ([]*models.LoginEvent, error) {
+	events := []*models.LoginEvent{}
+	query := `
+		SELECT * FROM login_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	err := r.db.SelectContext(ctx, &events, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login history: %w", err)
+	}
+
+	return events, nil
+}
+
+// DeleteByUserTx removes all of a user's recorded sign-ins inside a transaction
+func (r *LoginHistoryRepository) DeleteByUserTx(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	query := `DELETE FROM login_history WHERE user_id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete login history: %w", err)
+	}
+
+	return nil
+}
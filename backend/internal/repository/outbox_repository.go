@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// OutboxRepository handles database operations for queued notifications
+// Messages are written in the caller's transaction, so they exist if and only if the change that triggered them committed
+type OutboxRepository struct {
+	db instrumentedDB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{db: instrumentedDB{db}}
+}
+
+// Create queues a message outside of any transaction
+func (r *OutboxRepository) Create(ctx context.Context, msg *models.OutboxMessage) error {
+	return r.create(ctx, r.db, msg)
+}
+
+// CreateTx queues a message as part of tx
+func (r *OutboxRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, msg *models.OutboxMessage) error {
+	return r.create(ctx, tx, msg)
+}
+
+// create runs the message insert on either the pool or a transaction
+func (r *OutboxRepository) create(ctx context.Context, q sqlx.QueryerContext, msg *models.OutboxMessage) error {
+	query := `
+		INSERT INTO outbox (kind, payload, status)
+		VALUES ($1, $2, $3)
+		RETURNING id, next_attempt_at, created_at
+	`
+
+	err := q.QueryRowxContext(ctx, query, msg.Kind, msg.Payload, models.OutboxPending).
+		Scan(&msg.ID, &msg.NextAttemptAt, &msg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to queue outbox message: %w", err)
+	}
+
+	msg.Status = models.OutboxPending
+	return nil
+}
+
+// ClaimPending claims up to limit due messages, oldest first, and pushes their next attempt out to leaseUntil
+// Rows locked by another dispatcher are skipped, so concurrent callers never claim the same message.
+// A message that isn't marked sent or failed before leaseUntil (say its dispatcher crashed) becomes due again
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int, leaseUntil time.Time) ([]*models.OutboxMessage, error) {
+	var messages []*models.OutboxMessage
+	query := `
+		UPDATE outbox SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+
+	err := r.db.SelectContext(ctx, &messages, query, limit, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending outbox messages: %w", err)
+	}
+
+	// RETURNING doesn't keep the subquery's order
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	return messages, nil
+}
+
+// MarkSent records a successful delivery
+func (r *OutboxRepository) MarkSent(ctx context.Context, id string) error {
+	query := `
+		UPDATE outbox
+		SET status = 'sent', attempts = attempts + 1, last_error = NULL, sent_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message sent: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed delivery attempt
+// The message is retried at retryAt, or given up on when retryAt is nil
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id, lastError string, retryAt *time.Time) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2,
+			status = CASE WHEN $3::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($3, next_attempt_at)
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, lastError, retryAt); err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+
+	return nil
+}
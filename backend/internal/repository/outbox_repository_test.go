@@ -0,0 +1,178 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/jmoiron/sqlx"
+)
+
+// queueInTx queues a bill email in a transaction and commits it, or rolls it back when fail is set
+func queueInTx(t *testing.T, db *sqlx.DB, repo *repository.OutboxRepository, billNumber string, fail bool) {
+	t.Helper()
+
+	msg, err := models.NewOutboxMessage(models.OutboxBillEmail, models.BillEmailMessage{BillNumber: billNumber, RecipientEmail: "asha@example.com"})
+	if err != nil {
+		t.Fatalf("NewOutboxMessage failed: %v", err)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	if err := repo.CreateTx(context.Background(), tx, msg); err != nil {
+		tx.Rollback()
+		t.Fatalf("CreateTx failed: %v", err)
+	}
+	if fail {
+		if err := tx.Rollback(); err != nil {
+			t.Fatalf("rollback failed: %v", err)
+		}
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+}
+
+func TestOutboxMessageExistsOnlyIfTransactionCommits(t *testing.T) {
+	db := testutil.DB(t)
+	repo := repository.NewOutboxRepository(db.DB)
+
+	queueInTx(t, db.DB, repo, "OTH202610000001", false)
+	queueInTx(t, db.DB, repo, "OTH202610000002", true)
+
+	claimed, err := repo.ClaimPending(context.Background(), 10, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ClaimPending failed: %v", err)
+	}
+	if len(claimed) != 1 {
+		t.Fatalf("claimed %d messages, want only the committed one", len(claimed))
+	}
+
+	msg := claimed[0]
+	if msg.Kind != models.OutboxBillEmail || msg.Status != models.OutboxPending || msg.Attempts != 0 {
+		t.Errorf("claimed %s message with status %s and %d attempts, want a fresh pending bill_email", msg.Kind, msg.Status, msg.Attempts)
+	}
+	var payload models.BillEmailMessage
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.BillNumber != "OTH202610000001" {
+		t.Errorf("payload = %s, want the committed bill email", msg.Payload)
+	}
+}
+
+func TestOutboxClaimPendingLeasesMessages(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewOutboxRepository(db.DB)
+
+	for i := 0; i < 20; i++ {
+		queueInTx(t, db.DB, repo, "OTH202610000001", false)
+	}
+
+	// Concurrent dispatchers split the messages between them without overlap
+	var mu sync.Mutex
+	seen := map[string]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := repo.ClaimPending(ctx, 10, time.Now().Add(time.Minute))
+			if err != nil {
+				t.Errorf("ClaimPending failed: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, msg := range claimed {
+				seen[msg.ID]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 20 {
+		t.Errorf("claimed %d distinct messages, want 20", len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("message %s claimed %d times", id, n)
+		}
+	}
+
+	// Leased messages aren't due again until the lease runs out
+	claimed, err := repo.ClaimPending(ctx, 10, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ClaimPending failed: %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("claimed %d leased messages, want 0", len(claimed))
+	}
+}
+
+func TestOutboxMarkSentAndFailed(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewOutboxRepository(db.DB)
+
+	for i := 0; i < 3; i++ {
+		queueInTx(t, db.DB, repo, "OTH202610000001", false)
+	}
+	claimed, err := repo.ClaimPending(ctx, 10, time.Now().Add(time.Minute))
+	if err != nil || len(claimed) != 3 {
+		t.Fatalf("ClaimPending = %d messages, %v; want 3", len(claimed), err)
+	}
+	sent, retried, failed := claimed[0], claimed[1], claimed[2]
+
+	if err := repo.MarkSent(ctx, sent.ID); err != nil {
+		t.Fatalf("MarkSent failed: %v", err)
+	}
+	retryAt := time.Now().Add(-time.Second)
+	if err := repo.MarkFailed(ctx, retried.ID, "smtp: 421 busy", &retryAt); err != nil {
+		t.Fatalf("MarkFailed with retry failed: %v", err)
+	}
+	if err := repo.MarkFailed(ctx, failed.ID, "smtp: 550 no such user", nil); err != nil {
+		t.Fatalf("MarkFailed failed: %v", err)
+	}
+
+	tests := []struct {
+		msg        *models.OutboxMessage
+		wantStatus models.OutboxStatus
+		wantError  string
+		wantSentAt bool
+	}{
+		{sent, models.OutboxSent, "", true},
+		{retried, models.OutboxPending, "smtp: 421 busy", false},
+		{failed, models.OutboxFailed, "smtp: 550 no such user", false},
+	}
+	for _, tt := range tests {
+		var got models.OutboxMessage
+		if err := db.Get(&got, `SELECT * FROM outbox WHERE id = $1`, tt.msg.ID); err != nil {
+			t.Fatalf("failed to load outbox message: %v", err)
+		}
+		if got.Status != tt.wantStatus || got.Attempts != 1 {
+			t.Errorf("message status %s after %d attempts, want %s after 1", got.Status, got.Attempts, tt.wantStatus)
+		}
+		if lastError := got.LastError; (lastError == nil) != (tt.wantError == "") || (lastError != nil && *lastError != tt.wantError) {
+			t.Errorf("%s message last_error = %v, want %q", tt.wantStatus, lastError, tt.wantError)
+		}
+		if (got.SentAt != nil) != tt.wantSentAt {
+			t.Errorf("%s message sent_at = %v", tt.wantStatus, got.SentAt)
+		}
+	}
+
+	// Only the message due for a retry is claimed again
+	claimed, err = repo.ClaimPending(ctx, 10, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ClaimPending failed: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != retried.ID {
+		t.Errorf("claimed %d messages, want only the retried one", len(claimed))
+	}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PaymentOrderRepository handles database operations for wallet top-up orders
+type PaymentOrderRepository struct {
+	db instrumentedDB
+}
+
+// NewPaymentOrderRepository creates a new payment order repository
+func NewPaymentOrderRepository(db *sqlx.DB) *PaymentOrderRepository {
+	return &PaymentOrderRepository{db: instrumentedDB{db}}
+}
+
+// Create inserts a new payment order
+func (r *PaymentOrderRepository) Create(ctx context.Context, order *models.PaymentOrder) error {
+	query := `
+		INSERT INTO payment_orders (
+			user_id, gateway, gateway_order_id, amount, currency, status
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		) RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowxContext(
+		ctx,
+		query,
+		order.UserID,
+		order.Gateway,
+		order.GatewayOrderID,
+		order.Amount,
+		order.Currency,
+		order.Status,
+	).Scan(&order.ID, &order.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create payment order: %w", err)
+	}
+
+	return nil
+}
+
+// GetByGatewayOrderIDForUpdateTx retrieves an order and locks it until the transaction ends
+// Concurrent confirmations of the same order serialize on this lock
+func (r *PaymentOrderRepository) GetByGatewayOrderIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, gatewayOrderID string) (*models.PaymentOrder, error) {
+	var order models.PaymentOrder
+	query := `SELECT * FROM payment_orders WHERE gateway_order_id = $1 FOR UPDATE`
+
+	err := tx.GetContext(ctx, &order, query, gatewayOrderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPaymentOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment order: %w", err)
+	}
+
+	return &order, nil
+}
+
+// MarkPaidTx records the gateway payment against an order
+func (r *PaymentOrderRepository) MarkPaidTx(ctx context.Context, tx *sqlx.Tx, orderID, gatewayPaymentID string) error {
+	query := `
+		UPDATE payment_orders 
+		SET status = 'paid', 
+		    gateway_payment_id = $1,
+		    paid_at = NOW()
+		WHERE id = $2 AND status = 'created'
+	`
+
+	result, err := tx.ExecContext(ctx, query, gatewayPaymentID, orderID)
+	if err != nil {
+		// gateway_payment_id is UNIQUE - the same payment can't pay for two orders
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrDuplicatePayment
+		}
+		return fmt.Errorf("failed to mark payment order paid: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrPaymentOrderNotFound
+	}
+
+	return nil
+}
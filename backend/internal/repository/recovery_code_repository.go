@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RecoveryCodeRepository handles two-factor recovery codes
+// Only SHA-256 hashes are stored; callers hash codes before passing them in
+type RecoveryCodeRepository struct {
+	db instrumentedDB
+}
+
+// NewRecoveryCodeRepository creates a new recovery code repository
+func NewRecoveryCodeRepository(db *sqlx.DB) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{db: instrumentedDB{db}}
+}
+
+// ReplaceTx deletes the user's recovery codes and stores codeHashes in their place inside a transaction
+// Pass no hashes to just delete them
+func (r *RecoveryCodeRepository) ReplaceTx(ctx context.Context, tx *sqlx.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, codeHash := range codeHashes {
+		query := `INSERT INTO two_factor_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, query, userID, codeHash); err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Use marks an unused recovery code as used
+// Returns false if the user has no such unused code
+func (r *RecoveryCodeRepository) Use(ctx context.Context, userID, codeHash string) (bool, error) {
+	query := `
+		UPDATE two_factor_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// CountUnused returns how many recovery codes the user has left
+func (r *RecoveryCodeRepository) CountUnused(ctx context.Context, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM two_factor_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	if err := r.db.GetContext(ctx, &count, query, userID); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenDenylistRepository tracks revoked refresh tokens in Redis
+// Entries expire together with the token, so the denylist never grows unbounded
+type TokenDenylistRepository struct {
+	redis *database.RedisClient
+}
+
+// NewTokenDenylistRepository creates a new token denylist repository
+func NewTokenDenylistRepository(redis *database.RedisClient) *TokenDenylistRepository {
+	return &TokenDenylistRepository{redis: redis}
+}
+
+// denylistKey returns the Redis key for a revoked token ID
+func denylistKey(tokenID string) string {
+	return "revoked_token:" + tokenID
+}
+
+// userRevocationKey returns the Redis key holding when all of a user's tokens were revoked
+func userRevocationKey(userID string) string {
+	return "revoked_user:" + userID
+}
+
+// RevokeAllForUser revokes every refresh token issued to a user up to now
+// ttl should be the refresh token lifetime, after which no older token can still be valid
+func (r *TokenDenylistRepository) RevokeAllForUser(ctx context.Context, userID string, ttl time.Duration) error {
+	if r.redis == nil {
+		return ErrTokenRevocationUnavailable
+	}
+
+	if err := r.redis.Set(ctx, userRevocationKey(userID), time.Now().Unix(), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// IsRevokedForUser checks whether a token issued at issuedAt was revoked by RevokeAllForUser
+func (r *TokenDenylistRepository) IsRevokedForUser(ctx context.Context, userID string, issuedAt time.Time) (bool, error) {
+	if r.redis == nil {
+		return false, nil
+	}
+
+	revokedAt, err := r.redis.Get(ctx, userRevocationKey(userID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	// Token timestamps have second precision, so a token issued in the same second is revoked too
+	return issuedAt.Unix() <= revokedAt, nil
+}
+
+// Revoke adds a token ID to the denylist until the token would have expired anyway
+func (r *TokenDenylistRepository) Revoke(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if r.redis == nil {
+		return ErrTokenRevocationUnavailable
+	}
+
+	// Already expired - nothing to revoke
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := r.redis.Set(ctx, denylistKey(tokenID), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// IsRevoked checks whether a token ID has been revoked
+func (r *TokenDenylistRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if r.redis == nil {
+		return false, nil
+	}
+
+	err := r.redis.Get(ctx, denylistKey(tokenID)).Err()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	return true, nil
+}
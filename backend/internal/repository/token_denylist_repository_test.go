@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRevokedTokenStaysDeniedUntilExpiry(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	repo := NewTokenDenylistRepository(&database.RedisClient{Client: client})
+	ctx := context.Background()
+
+	if err := repo.Revoke(ctx, "jti-1", time.Hour); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+
+	for tokenID, want := range map[string]bool{"jti-1": true, "jti-2": false} {
+		revoked, err := repo.IsRevoked(ctx, tokenID)
+		if err != nil {
+			t.Fatalf("IsRevoked(%s) failed: %v", tokenID, err)
+		}
+		if revoked != want {
+			t.Errorf("IsRevoked(%s) = %v, want %v", tokenID, revoked, want)
+		}
+	}
+
+	// Once the token would have expired anyway the entry goes away
+	mr.FastForward(time.Hour)
+	if revoked, _ := repo.IsRevoked(ctx, "jti-1"); revoked {
+		t.Error("token is still denied after its lifetime")
+	}
+}
+
+func TestRevokeWithoutRedisFailsLoudly(t *testing.T) {
+	repo := NewTokenDenylistRepository(nil)
+
+	if err := repo.Revoke(context.Background(), "jti-1", time.Hour); err != ErrTokenRevocationUnavailable {
+		t.Errorf("Revoke = %v, want ErrTokenRevocationUnavailable", err)
+	}
+}
@@ -6,34 +6,47 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ezhilnn/epr-backend/internal/database"
 	"github.com/ezhilnn/epr-backend/internal/models"
 	"github.com/jmoiron/sqlx"
 )
 
 // UserRepository handles database operations for users
 type UserRepository struct {
-	db *sqlx.DB
+	db instrumentedDB
 }
 
 // NewUserRepository creates a new user repository
 func NewUserRepository(db *sqlx.DB) *UserRepository {
-	return &UserRepository{db: db}
+	return &UserRepository{db: instrumentedDB{db}}
 }
 
 // Create inserts a new user into the database
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.create(ctx, r.db, user)
+}
+
+// CreateTx inserts a new user inside an existing transaction
+func (r *UserRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, user *models.User) error {
+	return r.create(ctx, tx, user)
+}
+
+// create inserts a user using q, which is either the pool or a transaction
+func (r *UserRepository) create(ctx context.Context, q sqlx.QueryerContext, user *models.User) error {
 	query := `
 		INSERT INTO users (
 			full_name, email, password_hash, role, organization_name, organization_type,
-			gstin, pan, kyc_status, wallet_balance, is_active, is_email_verified
+			gstin, pan, kyc_status, wallet_balance, is_active, is_email_verified,
+			notification_preferences
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
-		) RETURNING id, created_at, updated_at
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+		) RETURNING id, pan_verification_status, created_at, updated_at
 	`
 
-	err := r.db.QueryRowContext(
+	err := q.QueryRowxContext(
 		ctx,
 		query,
+		user.FullName,
 		user.Email,
 		user.PasswordHash,
 		user.Role,
@@ -45,7 +58,8 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		user.WalletBalance,
 		user.IsActive,
 		user.IsEmailVerified,
-	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+		user.NotificationPreferences,
+	).Scan(&user.ID, &user.PANVerificationStatus, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -54,7 +68,9 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-// GetByID retrieves a user by ID
+// GetByID retrieves an active user by ID
+// Deactivated accounts are reported as ErrUserNotFound, which is what normal request flows
+// (auth, wallet charges, bill generation) want. Admin and historical lookups should use GetByIDAny
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	var user models.User
 	query := `SELECT * FROM users WHERE id = $1 AND is_active = true`
@@ -62,7 +78,24 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User,
 	err := r.db.GetContext(ctx, &user, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetByIDAny retrieves a user by ID whether or not the account is active
+// Use this on admin paths (KYC review, reactivation) and when showing who did something in the past
+func (r *UserRepository) GetByIDAny(ctx context.Context, id string) (*models.User, error) {
+	var user models.User
+	query := `SELECT * FROM users WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &user, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -70,6 +103,286 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User,
 	return &user, nil
 }
 
+// GetByIDAnyForUpdateTx retrieves a user regardless of active status and locks the row until the transaction ends
+func (r *UserRepository) GetByIDAnyForUpdateTx(ctx context.Context, tx *sqlx.Tx, id string) (*models.User, error) {
+	var user models.User
+	query := `SELECT * FROM users WHERE id = $1 FOR UPDATE`
+
+	err := tx.GetContext(ctx, &user, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// IsEmailVerified reports whether an active user has verified their email address
+func (r *UserRepository) IsEmailVerified(ctx context.Context, id string) (bool, error) {
+	var verified bool
+	query := `SELECT is_email_verified FROM users WHERE id = $1 AND is_active = true`
+
+	err := r.db.GetContext(ctx, &verified, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrUserNotFound
+		}
+		return false, fmt.Errorf("failed to get email verification status: %w", err)
+	}
+
+	return verified, nil
+}
+
+// SetActive activates or deactivates a user account
+// Inactive users can't log in and are hidden from GetByID
+func (r *UserRepository) SetActive(ctx context.Context, userID string, active bool) error {
+	return r.setActive(ctx, r.db, userID, active)
+}
+
+// SetActiveTx activates or deactivates a user inside an existing transaction
+func (r *UserRepository) SetActiveTx(ctx context.Context, tx *sqlx.Tx, userID string, active bool) error {
+	return r.setActive(ctx, tx, userID, active)
+}
+
+func (r *UserRepository) setActive(ctx context.Context, q sqlx.ExecerContext, userID string, active bool) error {
+	query := `UPDATE users SET is_active = $1, updated_at = NOW() WHERE id = $2`
+
+	result, err := q.ExecContext(ctx, query, active, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update active status: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateRoleTx changes a user's role and KYC status inside a transaction, recording which admin made the change
+// Moving to pending KYC clears the previous review so the user goes back through it
+func (r *UserRepository) UpdateRoleTx(ctx context.Context, tx *sqlx.Tx, userID, changedBy string, role models.UserRole, kycStatus models.KYCStatus) error {
+	query := `
+		UPDATE users SET
+			role = $1,
+			kyc_status = $2,
+			kyc_verified_at = CASE WHEN $2 = 'pending' THEN NULL ELSE kyc_verified_at END,
+			kyc_verified_by = CASE WHEN $2 = 'pending' THEN NULL ELSE kyc_verified_by END,
+			kyc_rejection_reason = CASE WHEN $2 = 'pending' THEN NULL ELSE kyc_rejection_reason END,
+			role_changed_at = NOW(),
+			role_changed_by = $3,
+			updated_at = NOW()
+		WHERE id = $4
+	`
+
+	result, err := tx.ExecContext(ctx, query, role, kycStatus, changedBy, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// LockMasterAdminsTx locks every active master admin row and returns how many there are
+// Holding the locks until the transaction ends stops two concurrent demotions from both
+// seeing another admin left
+func (r *UserRepository) LockMasterAdminsTx(ctx context.Context, tx *sqlx.Tx) (int, error) {
+	var ids []string
+	query := `SELECT id FROM users WHERE role = 'master_admin' AND is_active = true FOR UPDATE`
+
+	if err := tx.SelectContext(ctx, &ids, query); err != nil {
+		return 0, fmt.Errorf("failed to lock master admins: %w", err)
+	}
+
+	return len(ids), nil
+}
+
+// AnonymizeTx scrubs a user's personal fields and deactivates the account inside a transaction
+// The row itself stays so bills, verifications and the wallet ledger keep pointing at it.
+// The email becomes a unique placeholder and the password hash is cleared, so nobody can sign in again
+func (r *UserRepository) AnonymizeTx(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	query := `
+		UPDATE users SET
+			full_name = 'Erased user',
+			email = 'erased-' || id || '@erased.invalid',
+			password_hash = '',
+			organization_name = 'Erased user',
+			organization_type = NULL,
+			gstin = NULL,
+			pan = NULL,
+			pan_verification_status = 'unchecked',
+			pan_verified_at = NULL,
+			kyc_documents = NULL,
+			kyc_rejection_reason = NULL,
+			email_verification_token = NULL,
+			email_verification_expires_at = NULL,
+			password_reset_token = NULL,
+			password_reset_expires_at = NULL,
+			webhook_url = NULL,
+			webhook_secret = NULL,
+			two_factor_enabled = false,
+			two_factor_secret = NULL,
+			two_factor_enabled_at = NULL,
+			two_factor_last_step = NULL,
+			is_active = false,
+			erased_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND erased_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// InstitutionGSTINExists checks if an institution account is already registered with a GSTIN
+func (r *UserRepository) InstitutionGSTINExists(ctx context.Context, gstin string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM users
+			WHERE gstin = $1 AND role IN ('institution_user', 'institution_admin')
+		)
+	`
+
+	err := r.db.GetContext(ctx, &exists, query, gstin)
+	if err != nil {
+		return false, fmt.Errorf("failed to check GSTIN: %w", err)
+	}
+
+	return exists, nil
+}
+
+// SetPANVerification records the result of checking a user's PAN
+// Nothing is recorded if the user's PAN has changed since panNumber was checked
+func (r *UserRepository) SetPANVerification(ctx context.Context, userID, panNumber, status string) error {
+	query := `
+		UPDATE users
+		SET pan_verification_status = $1, pan_verified_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND pan = $3
+	`
+
+	_, err := r.db.ExecContext(ctx, query, status, userID, panNumber)
+	if err != nil {
+		return fmt.Errorf("failed to record PAN verification: %w", err)
+	}
+
+	return nil
+}
+
+// SetTwoFactorSecret stores a new encrypted TOTP secret for a user who hasn't enabled two-factor authentication yet
+// Returns ErrUserNotFound if the user is gone or already has it enabled
+func (r *UserRepository) SetTwoFactorSecret(ctx context.Context, userID, encryptedSecret string) error {
+	query := `
+		UPDATE users
+		SET two_factor_secret = $2, two_factor_last_step = NULL, updated_at = NOW()
+		WHERE id = $1 AND is_active = true AND two_factor_enabled = false
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, encryptedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to store two-factor secret: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// EnableTwoFactorTx turns on two-factor authentication with the stored secret inside a transaction
+// step is the time step of the code that confirmed it, so that code can't be used again to log in
+func (r *UserRepository) EnableTwoFactorTx(ctx context.Context, tx *sqlx.Tx, userID string, step int64) error {
+	query := `
+		UPDATE users
+		SET two_factor_enabled = true, two_factor_enabled_at = NOW(), two_factor_last_step = $2, updated_at = NOW()
+		WHERE id = $1 AND two_factor_enabled = false AND two_factor_secret IS NOT NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// DisableTwoFactorTx turns off two-factor authentication and forgets the secret inside a transaction
+func (r *UserRepository) DisableTwoFactorTx(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	query := `
+		UPDATE users
+		SET two_factor_enabled = false, two_factor_secret = NULL, two_factor_enabled_at = NULL,
+		    two_factor_last_step = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// UseTwoFactorStep records a TOTP time step as used
+// Returns false if that step or a later one was already accepted, i.e. the code is being replayed
+func (r *UserRepository) UseTwoFactorStep(ctx context.Context, userID string, step int64) (bool, error) {
+	query := `
+		UPDATE users
+		SET two_factor_last_step = $2
+		WHERE id = $1 AND (two_factor_last_step IS NULL OR two_factor_last_step < $2)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
@@ -78,7 +391,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 	err := r.db.GetContext(ctx, &user, query, email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -99,48 +412,495 @@ func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, e
 	return exists, nil
 }
 
-// UpdateLastLogin updates the last login timestamp
-func (r *UserRepository) UpdateLastLogin(ctx context.Context, userID string) error {
-	query := `UPDATE users SET last_login_at = $1, updated_at = NOW() WHERE id = $2`
+// SetEmailVerificationToken stores a pending email verification token for a user
+func (r *UserRepository) SetEmailVerificationToken(ctx context.Context, userID, token string, expiresAt time.Time) error {
+	query := `
+		UPDATE users 
+		SET email_verification_token = $1, 
+		    email_verification_expires_at = $2,
+		    updated_at = NOW()
+		WHERE id = $3
+	`
 
-	_, err := r.db.ExecContext(ctx, query, time.Now(), userID)
+	result, err := r.db.ExecContext(ctx, query, token, expiresAt, userID)
 	if err != nil {
-		return fmt.Errorf("failed to update last login: %w", err)
+		return fmt.Errorf("failed to set email verification token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetByEmailVerificationToken retrieves the user a verification token was issued to
+func (r *UserRepository) GetByEmailVerificationToken(ctx context.Context, token string) (*models.User, error) {
+	var user models.User
+	query := `SELECT * FROM users WHERE email_verification_token = $1`
+
+	err := r.db.GetContext(ctx, &user, query, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// MarkEmailVerified marks the user's email as verified and clears the token
+// The token must still match so a link can only be used once
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID, token string) error {
+	query := `
+		UPDATE users 
+		SET is_email_verified = true, 
+		    email_verification_token = NULL,
+		    email_verification_expires_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND email_verification_token = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, token)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetPasswordResetToken stores a time-limited password reset token for a user
+func (r *UserRepository) SetPasswordResetToken(ctx context.Context, userID, token string, expiresAt time.Time) error {
+	query := `
+		UPDATE users 
+		SET password_reset_token = $1, 
+		    password_reset_expires_at = $2,
+		    updated_at = NOW()
+		WHERE id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, token, expiresAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set password reset token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetByPasswordResetToken retrieves the user a password reset token was issued to
+func (r *UserRepository) GetByPasswordResetToken(ctx context.Context, token string) (*models.User, error) {
+	var user models.User
+	query := `SELECT * FROM users WHERE password_reset_token = $1`
+
+	err := r.db.GetContext(ctx, &user, query, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// ResetPassword sets a new password hash and clears the reset token
+// The token must still match so a reset link can only be used once
+func (r *UserRepository) ResetPassword(ctx context.Context, userID, token, passwordHash string) error {
+	query := `
+		UPDATE users 
+		SET password_hash = $1, 
+		    password_reset_token = NULL,
+		    password_reset_expires_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $2 AND password_reset_token = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, passwordHash, userID, token)
+	if err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SubmitKYC stores KYC document references and puts the user back in the review queue
+func (r *UserRepository) SubmitKYC(ctx context.Context, userID string, documents []byte) error {
+	query := `
+		UPDATE users 
+		SET kyc_documents = $1, 
+		    kyc_status = 'pending',
+		    kyc_rejection_reason = NULL,
+		    updated_at = NOW()
+		WHERE id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, string(documents), userID)
+	if err != nil {
+		return fmt.Errorf("failed to submit KYC: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
 	}
 
 	return nil
 }
 
-// UpdateWalletBalance updates the user's wallet balance
-func (r *UserRepository) UpdateWalletBalance(ctx context.Context, userID string, newBalance float64) error {
-	query := `UPDATE users SET wallet_balance = $1, updated_at = NOW() WHERE id = $2`
+// ListPendingKYC retrieves users with submitted KYC documents awaiting review, oldest first
+func (r *UserRepository) ListPendingKYC(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	var users []*models.User
+	query := `
+		SELECT * FROM users 
+		WHERE kyc_status = 'pending' 
+		AND kyc_documents IS NOT NULL
+		ORDER BY updated_at ASC
+		LIMIT $1 OFFSET $2
+	`
+
+	err := r.db.SelectContext(ctx, &users, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending KYC: %w", err)
+	}
+
+	return users, nil
+}
+
+// CountPendingKYC counts users with submitted KYC documents awaiting review
+func (r *UserRepository) CountPendingKYC(ctx context.Context) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM users WHERE kyc_status = 'pending' AND kyc_documents IS NOT NULL`
+
+	err := r.db.GetContext(ctx, &count, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending KYC: %w", err)
+	}
+
+	return count, nil
+}
+
+// UpdateKYCStatus records a KYC decision for a pending submission
+// Returns ErrUserNotFound if the user has no pending submission, including a pending status with no documents uploaded
+func (r *UserRepository) UpdateKYCStatus(ctx context.Context, userID, reviewerID string, status models.KYCStatus, rejectionReason *string) error {
+	return r.updateKYCStatus(ctx, r.db, userID, reviewerID, status, rejectionReason)
+}
+
+// UpdateKYCStatusTx records a KYC review decision inside an existing transaction
+func (r *UserRepository) UpdateKYCStatusTx(ctx context.Context, tx *sqlx.Tx, userID, reviewerID string, status models.KYCStatus, rejectionReason *string) error {
+	return r.updateKYCStatus(ctx, tx, userID, reviewerID, status, rejectionReason)
+}
+
+func (r *UserRepository) updateKYCStatus(ctx context.Context, q sqlx.ExecerContext, userID, reviewerID string, status models.KYCStatus, rejectionReason *string) error {
+	query := `
+		UPDATE users 
+		SET kyc_status = $1, 
+		    kyc_verified_by = $2,
+		    kyc_verified_at = NOW(),
+		    kyc_rejection_reason = $3,
+		    updated_at = NOW()
+		WHERE id = $4 AND kyc_status = 'pending' AND kyc_documents IS NOT NULL
+	`
+
+	result, err := q.ExecContext(ctx, query, status, reviewerID, rejectionReason, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update KYC status: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// MarkLowBalanceNotified records that a low balance warning is being sent
+// Returns false if one was already sent within the cooldown, in which case nothing is updated
+func (r *UserRepository) MarkLowBalanceNotified(ctx context.Context, userID string, cooldown time.Duration) (bool, error) {
+	query := `
+		UPDATE users 
+		SET last_low_balance_notified_at = NOW()
+		WHERE id = $1 
+		AND (last_low_balance_notified_at IS NULL OR last_low_balance_notified_at < $2)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, time.Now().Add(-cooldown))
+	if err != nil {
+		return false, fmt.Errorf("failed to mark low balance notified: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// SetVerificationPlan assigns a prepaid verification plan, starting with an unused quota
+// A nil planType removes the plan and the user goes back to paying per verification
+func (r *UserRepository) SetVerificationPlan(ctx context.Context, userID string, planType *string, monthlyQuota int, resetsAt *time.Time) error {
+	return r.setVerificationPlan(ctx, r.db, userID, planType, monthlyQuota, resetsAt)
+}
+
+// SetVerificationPlanTx assigns or removes a verification plan inside an existing transaction
+func (r *UserRepository) SetVerificationPlanTx(ctx context.Context, tx *sqlx.Tx, userID string, planType *string, monthlyQuota int, resetsAt *time.Time) error {
+	return r.setVerificationPlan(ctx, tx, userID, planType, monthlyQuota, resetsAt)
+}
+
+func (r *UserRepository) setVerificationPlan(ctx context.Context, q sqlx.ExecerContext, userID string, planType *string, monthlyQuota int, resetsAt *time.Time) error {
+	query := `
+		UPDATE users 
+		SET plan_type = $2, plan_monthly_quota = $3, plan_quota_used = 0, plan_resets_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := q.ExecContext(ctx, query, userID, planType, monthlyQuota, resetsAt)
+	if err != nil {
+		return fmt.Errorf("failed to set verification plan: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// ConsumePlanQuotaTx uses one verification from the user's plan quota inside an existing transaction
+// Returns false if the user has no plan or the quota for this period is used up
+func (r *UserRepository) ConsumePlanQuotaTx(ctx context.Context, tx *sqlx.Tx, userID string) (bool, error) {
+	query := `
+		UPDATE users 
+		SET plan_quota_used = plan_quota_used + 1, updated_at = NOW()
+		WHERE id = $1 
+		AND plan_type IS NOT NULL 
+		AND plan_quota_used < plan_monthly_quota
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume plan quota: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ResetDuePlanQuotas starts a new period for every plan whose reset time has passed
+// The next reset is the start of the following calendar month in loc. Returns how many plans were reset
+func (r *UserRepository) ResetDuePlanQuotas(ctx context.Context, loc *time.Location) (int64, error) {
+	query := `
+		UPDATE users 
+		SET plan_quota_used = 0, 
+			plan_resets_at = (date_trunc('month', NOW() AT TIME ZONE $1) + INTERVAL '1 month') AT TIME ZONE $1, 
+			updated_at = NOW()
+		WHERE plan_type IS NOT NULL 
+		AND plan_resets_at <= NOW()
+	`
+
+	result, err := r.db.ExecContext(ctx, query, loc.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset plan quotas: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
+// SetWebhook sets or clears the user's webhook URL and signing secret
+func (r *UserRepository) SetWebhook(ctx context.Context, userID string, url, secret *string) error {
+	query := `UPDATE users SET webhook_url = $2, webhook_secret = $3, updated_at = NOW() WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, newBalance, userID)
+	result, err := r.db.ExecContext(ctx, query, userID, url, secret)
 	if err != nil {
-		return fmt.Errorf("failed to update wallet balance: %w", err)
+		return fmt.Errorf("failed to set webhook: %w", err)
 	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetNotificationPreferences replaces the user's email preferences
+func (r *UserRepository) SetNotificationPreferences(ctx context.Context, userID string, prefs models.NotificationPreferences) error {
+	query := `UPDATE users SET notification_preferences = $2, updated_at = NOW() WHERE id = $1 AND is_active = true`
+
+	result, err := r.db.ExecContext(ctx, query, userID, prefs)
+	if err != nil {
+		return fmt.Errorf("failed to set notification preferences: %w", err)
+	}
 
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
 	if rows == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateLastLogin updates the last login timestamp
+func (r *UserRepository) UpdateLastLogin(ctx context.Context, userID string) error {
+	query := `UPDATE users SET last_login_at = $1, updated_at = NOW() WHERE id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update last login: %w", err)
 	}
 
 	return nil
 }
 
+// AdjustWalletBalance adds delta (negative for charges) to the user's wallet balance and returns the new balance
+// The change is applied in a single UPDATE, so concurrent adjustments can't overwrite each other.
+// It fails with ErrInsufficientFunds, together with the current balance, if the balance would go negative
+func (r *UserRepository) AdjustWalletBalance(ctx context.Context, userID string, delta float64) (float64, error) {
+	return r.adjustWalletBalance(ctx, r.db, userID, delta, false, false)
+}
+
+// GetByIDForUpdateTx retrieves an active user and locks the row until the transaction ends
+// Balance changes don't need it - use AdjustWalletBalanceTx, which is safe on its own
+func (r *UserRepository) GetByIDForUpdateTx(ctx context.Context, tx *sqlx.Tx, id string) (*models.User, error) {
+	var user models.User
+	query := `SELECT * FROM users WHERE id = $1 AND is_active = true FOR UPDATE`
+
+	err := tx.GetContext(ctx, &user, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// AdjustWalletBalanceTx adjusts the user's wallet balance inside an existing transaction
+func (r *UserRepository) AdjustWalletBalanceTx(ctx context.Context, tx *sqlx.Tx, userID string, delta float64) (float64, error) {
+	return r.adjustWalletBalance(ctx, tx, userID, delta, false, false)
+}
+
+// AdminAdjustWalletBalanceTx adjusts the wallet balance of any user, deactivated or not, for an admin correction
+// With allowNegative the balance may end up below zero. Only for admin corrections - every charge must go
+// through AdjustWalletBalanceTx
+func (r *UserRepository) AdminAdjustWalletBalanceTx(ctx context.Context, tx *sqlx.Tx, userID string, delta float64, allowNegative bool) (float64, error) {
+	return r.adjustWalletBalance(ctx, tx, userID, delta, allowNegative, true)
+}
+
+// adjustWalletBalance runs the balance adjustment on either the pool or a transaction
+// Deactivated users' wallets are only touched when includeInactive is set
+func (r *UserRepository) adjustWalletBalance(ctx context.Context, q sqlx.QueryerContext, userID string, delta float64, allowNegative, includeInactive bool) (float64, error) {
+	query := `
+		UPDATE users 
+		SET wallet_balance = wallet_balance + $2, updated_at = NOW() 
+		WHERE id = $1 AND ($4 OR is_active = true)
+		AND ($3 OR wallet_balance + $2 >= 0)
+		RETURNING wallet_balance
+	`
+
+	var newBalance float64
+	err := sqlx.GetContext(ctx, q, &newBalance, query, userID, delta, allowNegative, includeInactive)
+	if err == nil {
+		return newBalance, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to adjust wallet balance: %w", err)
+	}
+
+	// Either the user doesn't exist or the guard failed - tell the caller which
+	var balance float64
+	err = sqlx.GetContext(ctx, q, &balance, `SELECT wallet_balance FROM users WHERE id = $1 AND ($2 OR is_active = true)`, userID, includeInactive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+
+	return balance, ErrInsufficientFunds
+}
+
 // IncrementVerificationCount increments the verification count and checks for loyalty rewards
 func (r *UserRepository) IncrementVerificationCount(ctx context.Context, userID string) (bool, error) {
 	// Use a transaction to ensure atomicity
-	tx, err := r.db.BeginTxx(ctx, nil)
+	var earnedFree bool
+	err := database.WithTx(ctx, r.db.DB, func(tx *sqlx.Tx) error {
+		var err error
+		earnedFree, err = r.IncrementVerificationCountTx(ctx, tx, userID, 10)
+		return err
+	})
 	if err != nil {
-		return false, fmt.Errorf("failed to begin transaction: %w", err)
+		return false, err
 	}
-	defer tx.Rollback()
 
+	return earnedFree, nil
+}
+
+// IncrementVerificationCountTx increments the verification count inside an existing transaction
+// and grants a free verification every freeEveryN verifications
+func (r *UserRepository) IncrementVerificationCountTx(ctx context.Context, tx *sqlx.Tx, userID string, freeEveryN int) (bool, error) {
 	// Increment verification count
 	query := `
 		UPDATE users 
@@ -151,32 +911,128 @@ func (r *UserRepository) IncrementVerificationCount(ctx context.Context, userID
 	`
 
 	var newCount int
-	err = tx.QueryRowContext(ctx, query, userID).Scan(&newCount)
+	err := tx.QueryRowContext(ctx, query, userID).Scan(&newCount)
 	if err != nil {
 		return false, fmt.Errorf("failed to increment verification count: %w", err)
 	}
 
-	// Check if user earned a free verification (every 10th verification)
-	earnedFree := false
-	if newCount%10 == 0 {
-		query = `
-			UPDATE users 
-			SET free_verifications_earned = free_verifications_earned + 1
-			WHERE id = $1
-		`
-		_, err = tx.ExecContext(ctx, query, userID)
-		if err != nil {
-			return false, fmt.Errorf("failed to update free verifications: %w", err)
-		}
-		earnedFree = true
+	// Check if user earned a free verification
+	if freeEveryN <= 0 || newCount%freeEveryN != 0 {
+		return false, nil
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	query = `
+		UPDATE users 
+		SET free_verifications_earned = free_verifications_earned + 1
+		WHERE id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update free verifications: %w", err)
 	}
 
-	return earnedFree, nil
+	return true, nil
+}
+
+// ConsumeFreeVerificationTx spends one earned free verification inside an existing transaction
+// Returns false when the user has none left, so the caller can fall back to charging
+func (r *UserRepository) ConsumeFreeVerificationTx(ctx context.Context, tx *sqlx.Tx, userID string) (bool, error) {
+	query := `
+		UPDATE users 
+		SET free_verifications_earned = free_verifications_earned - 1,
+		    updated_at = NOW()
+		WHERE id = $1 AND free_verifications_earned > 0
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume free verification: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// CountByRole counts users grouped by role
+func (r *UserRepository) CountByRole(ctx context.Context) (map[models.UserRole]int, error) {
+	var rows []struct {
+		Role  models.UserRole `db:"role"`
+		Count int             `db:"count"`
+	}
+	query := `SELECT role, COUNT(*) AS count FROM users GROUP BY role`
+
+	err := r.db.SelectContext(ctx, &rows, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users by role: %w", err)
+	}
+
+	counts := make(map[models.UserRole]int, len(rows))
+	for _, row := range rows {
+		counts[row.Role] = row.Count
+	}
+
+	return counts, nil
+}
+
+// CountSignupsByDay counts new users per day since the given time, oldest day first
+// Days without signups are omitted
+func (r *UserRepository) CountSignupsByDay(ctx context.Context, since time.Time) ([]models.DailyCount, error) {
+	var counts []models.DailyCount
+	query := `
+		SELECT TO_CHAR(created_at, 'YYYY-MM-DD') AS date, COUNT(*) AS count
+		FROM users
+		WHERE created_at >= $1
+		GROUP BY date
+		ORDER BY date
+	`
+
+	err := r.db.SelectContext(ctx, &counts, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count signups: %w", err)
+	}
+
+	return counts, nil
+}
+
+// ListActiveInstitutions retrieves active institution accounts, oldest first so paging is stable
+func (r *UserRepository) ListActiveInstitutions(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	var users []*models.User
+	query := `
+		SELECT * FROM users 
+		WHERE is_active = true 
+		AND role IN ('institution_user', 'institution_admin')
+		ORDER BY created_at ASC 
+		LIMIT $1 OFFSET $2
+	`
+
+	err := r.db.SelectContext(ctx, &users, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list institutions: %w", err)
+	}
+
+	return users, nil
+}
+
+// ListActiveMasterAdmins retrieves every active master admin, oldest first
+func (r *UserRepository) ListActiveMasterAdmins(ctx context.Context) ([]*models.User, error) {
+	var users []*models.User
+	query := `
+		SELECT * FROM users
+		WHERE is_active = true
+		AND role = 'master_admin'
+		ORDER BY created_at ASC
+	`
+
+	err := r.db.SelectContext(ctx, &users, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list master admins: %w", err)
+	}
+
+	return users, nil
 }
 
 // List retrieves a paginated list of users
@@ -0,0 +1,144 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestDeactivatedUsersOnlyVisibleToAdminLookups(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewUserRepository(db.DB)
+
+	user := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	if err := repo.SetActive(ctx, user.ID, false); err != nil {
+		t.Fatalf("SetActive failed: %v", err)
+	}
+
+	// Normal flows treat a deactivated account as gone
+	if _, err := repo.GetByID(ctx, user.ID); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("GetByID = %v, want ErrUserNotFound", err)
+	}
+
+	// Admin and historical lookups still see it
+	found, err := repo.GetByIDAny(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetByIDAny failed: %v", err)
+	}
+	if found.ID != user.ID || found.IsActive {
+		t.Errorf("GetByIDAny = %s (active %v), want %s inactive", found.ID, found.IsActive, user.ID)
+	}
+
+	err = db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		locked, err := repo.GetByIDAnyForUpdateTx(ctx, tx, user.ID)
+		if err == nil && locked.ID != user.ID {
+			t.Errorf("GetByIDAnyForUpdateTx = %s, want %s", locked.ID, user.ID)
+		}
+		return err
+	})
+	if err != nil {
+		t.Errorf("GetByIDAnyForUpdateTx failed: %v", err)
+	}
+
+	// Neither variant invents users
+	if _, err := repo.GetByIDAny(ctx, "00000000-0000-0000-0000-000000000000"); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("GetByIDAny for an unknown ID = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestAdjustWalletBalanceConcurrentCharges(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewUserRepository(db.DB)
+
+	user := testutil.CreateUser(t, db, models.RoleVerifier, 100)
+
+	// charge fires n parallel charges of amount and returns how many were refused for lack of funds
+	charge := func(t *testing.T, n int, amount float64) int {
+		t.Helper()
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		refused := 0
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.AdjustWalletBalance(ctx, user.ID, -amount)
+				if errors.Is(err, repository.ErrInsufficientFunds) {
+					mu.Lock()
+					refused++
+					mu.Unlock()
+				} else if err != nil {
+					t.Errorf("AdjustWalletBalance failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		return refused
+	}
+	balance := func(t *testing.T) float64 {
+		t.Helper()
+		found, err := repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		return found.WalletBalance
+	}
+
+	// Every charge lands: none overwrites another
+	if refused := charge(t, 40, 1.25); refused != 0 {
+		t.Errorf("%d charges refused with funds available", refused)
+	}
+	if got := balance(t); got != 50 {
+		t.Errorf("balance = %.2f, want 50.00", got)
+	}
+
+	// Only as many charges as the balance covers succeed, and it never goes negative
+	if refused := charge(t, 60, 1); refused != 10 {
+		t.Errorf("charges refused = %d, want 10", refused)
+	}
+	if got := balance(t); got != 0 {
+		t.Errorf("balance = %.2f, want 0.00", got)
+	}
+}
+
+func TestInstitutionGSTINExists(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewUserRepository(db.DB)
+
+	institution := testutil.CreateUser(t, db, models.RoleInstitutionAdmin, 0)
+	verifier := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+	db.MustExec("UPDATE users SET gstin = '27AAPFU0939F1ZV' WHERE id = $1", institution.ID)
+	db.MustExec("UPDATE users SET gstin = '07AAGFF2194N1Z1' WHERE id = $1", verifier.ID)
+
+	tests := []struct {
+		name  string
+		gstin string
+		want  bool
+	}{
+		{"held by an institution", "27AAPFU0939F1ZV", true},
+		{"held only by a verifier", "07AAGFF2194N1Z1", false},
+		{"unused", "24AAACC1206D1ZM", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.InstitutionGSTINExists(ctx, tt.gstin)
+			if err != nil {
+				t.Fatalf("InstitutionGSTINExists failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("InstitutionGSTINExists(%s) = %v, want %v", tt.gstin, got, tt.want)
+			}
+		})
+	}
+}
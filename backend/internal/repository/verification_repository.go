@@ -2,37 +2,49 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/ezhilnn/epr-backend/internal/models"
 )
 
 // VerificationRepository handles database operations for verifications
 type VerificationRepository struct {
-	db *sqlx.DB
+	db instrumentedDB
 }
 
 // NewVerificationRepository creates a new verification repository
 func NewVerificationRepository(db *sqlx.DB) *VerificationRepository {
-	return &VerificationRepository{db: db}
+	return &VerificationRepository{db: instrumentedDB{db}}
 }
 
 // Create inserts a new verification record
 func (r *VerificationRepository) Create(ctx context.Context, verification *models.Verification) error {
+	return r.create(ctx, r.db, verification)
+}
+
+// CreateTx inserts a new verification record inside an existing transaction
+func (r *VerificationRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, verification *models.Verification) error {
+	return r.create(ctx, tx, verification)
+}
+
+// create runs the verification insert on either the pool or a transaction
+func (r *VerificationRepository) create(ctx context.Context, q sqlx.QueryerContext, verification *models.Verification) error {
 	query := `
 		INSERT INTO verifications (
 			bill_id, bill_number, verifier_id, verifier_ip, verifier_user_agent,
-			access_level_used, data_revealed, amount_charged, was_free,
+			access_level_used, data_revealed, amount_charged, was_free, covered_by_plan,
 			pricing_rule_applied, verification_status, blockchain_verified,
 			blockchain_tx_id, is_suspicious, suspicious_reason, response_time_ms
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
 		) RETURNING id, verified_at
 	`
 
-	err := r.db.QueryRowContext(
+	err := q.QueryRowxContext(
 		ctx,
 		query,
 		verification.BillID,
@@ -44,6 +56,7 @@ func (r *VerificationRepository) Create(ctx context.Context, verification *model
 		verification.DataRevealed,
 		verification.AmountCharged,
 		verification.WasFree,
+		verification.CoveredByPlan,
 		verification.PricingRuleApplied,
 		verification.VerificationStatus,
 		verification.BlockchainVerified,
@@ -60,6 +73,60 @@ func (r *VerificationRepository) Create(ctx context.Context, verification *model
 	return nil
 }
 
+// GetByID retrieves a verification by ID
+func (r *VerificationRepository) GetByID(ctx context.Context, id string) (*models.Verification, error) {
+	var verification models.Verification
+	query := `SELECT * FROM verifications WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &verification, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVerificationNotFound
+		}
+		return nil, fmt.Errorf("failed to get verification: %w", err)
+	}
+
+	return &verification, nil
+}
+
+// AnonymizeByVerifierTx clears the IP address and user agent recorded on a user's verifications
+// Results, fees and bill references are kept so bill verification counts stay accurate
+func (r *VerificationRepository) AnonymizeByVerifierTx(ctx context.Context, tx *sqlx.Tx, verifierID string) (int64, error) {
+	query := `
+		UPDATE verifications
+		SET verifier_ip = NULL, verifier_user_agent = NULL
+		WHERE verifier_id = $1 AND (verifier_ip IS NOT NULL OR verifier_user_agent IS NOT NULL)
+	`
+
+	result, err := tx.ExecContext(ctx, query, verifierID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize verifications: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	// Pruned verifications may still hold the same details in the archive
+	query = `
+		UPDATE verifications_archive
+		SET verifier_ip = NULL, verifier_user_agent = NULL
+		WHERE verifier_id = $1 AND (verifier_ip IS NOT NULL OR verifier_user_agent IS NOT NULL)
+	`
+	result, err = tx.ExecContext(ctx, query, verifierID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize archived verifications: %w", err)
+	}
+
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows + archived, nil
+}
+
 // ListByVerifier retrieves verifications by verifier with pagination
 func (r *VerificationRepository) ListByVerifier(ctx context.Context, verifierID string, limit, offset int) ([]*models.Verification, error) {
 	var verifications []*models.Verification
@@ -78,6 +145,35 @@ func (r *VerificationRepository) ListByVerifier(ctx context.Context, verifierID
 	return verifications, nil
 }
 
+// ListByVerifierAfter retrieves a verifier's verifications older than cursor, newest first
+// A nil cursor starts from the newest verification
+func (r *VerificationRepository) ListByVerifierAfter(ctx context.Context, verifierID string, cursor *models.PageCursor, limit int) ([]*models.Verification, error) {
+	var verifications []*models.Verification
+	var err error
+	if cursor == nil {
+		query := `
+			SELECT * FROM verifications
+			WHERE verifier_id = $1
+			ORDER BY verified_at DESC, id DESC
+			LIMIT $2
+		`
+		err = r.db.SelectContext(ctx, &verifications, query, verifierID, limit)
+	} else {
+		query := `
+			SELECT * FROM verifications
+			WHERE verifier_id = $1 AND (verified_at, id) < ($2, $3)
+			ORDER BY verified_at DESC, id DESC
+			LIMIT $4
+		`
+		err = r.db.SelectContext(ctx, &verifications, query, verifierID, cursor.Timestamp, cursor.ID, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list verifications: %w", err)
+	}
+
+	return verifications, nil
+}
+
 // CountByVerifier counts total verifications for a verifier
 func (r *VerificationRepository) CountByVerifier(ctx context.Context, verifierID string) (int, error) {
 	var count int
@@ -91,40 +187,206 @@ func (r *VerificationRepository) CountByVerifier(ctx context.Context, verifierID
 	return count, nil
 }
 
-// GetStatsByVerifier retrieves statistics for a verifier
+// CountAll counts every verification on the platform, including pruned ones
+func (r *VerificationRepository) CountAll(ctx context.Context) (int, error) {
+	var count int
+	query := `
+		SELECT (SELECT COUNT(*) FROM verifications)
+		     + (SELECT COALESCE(SUM(verification_count), 0) FROM verification_rollups)
+	`
+
+	err := r.db.GetContext(ctx, &count, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count verifications: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListSuspicious retrieves verifications flagged as suspicious, newest first
+func (r *VerificationRepository) ListSuspicious(ctx context.Context, limit, offset int) ([]*models.Verification, error) {
+	var verifications []*models.Verification
+	query := `
+		SELECT * FROM verifications 
+		WHERE is_suspicious = true 
+		ORDER BY verified_at DESC 
+		LIMIT $1 OFFSET $2
+	`
+
+	err := r.db.SelectContext(ctx, &verifications, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspicious verifications: %w", err)
+	}
+
+	return verifications, nil
+}
+
+// verifierSortColumns maps VerifierScoreFilter.Sort values to result columns
+var verifierSortColumns = map[string]string{
+	models.VerifierSortScore:        "score",
+	models.VerifierSortVolume:       "total",
+	models.VerifierSortNotFound:     "not_found_count",
+	models.VerifierSortSuspicious:   "suspicious_count",
+	models.VerifierSortLastVerified: "last_verified_at",
+}
+
+// VerifierScore scores verifier accounts from their verifications since filter.Since, as documented on
+// models.VerifierScore, and returns one page of them with the total matching the filter
+// Verifiers without verifications in the window are included with a perfect score
+func (r *VerificationRepository) VerifierScore(ctx context.Context, filter models.VerifierScoreFilter, limit, offset int) ([]*models.VerifierScore, int, error) {
+	column, ok := verifierSortColumns[filter.Sort]
+	if !ok {
+		column = verifierSortColumns[models.VerifierSortScore]
+	}
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	ranked := `
+		WITH activity AS (
+			SELECT u.id AS verifier_id, u.full_name, u.email, u.organization_name,
+			       COALESCE(u.is_active, true) AS is_active,
+			       COUNT(v.id) AS total,
+			       COUNT(v.id) FILTER (WHERE v.verification_status = 'valid') AS valid_count,
+			       COUNT(v.id) FILTER (WHERE v.verification_status = 'not_found') AS not_found_count,
+			       COUNT(v.id) FILTER (WHERE v.is_suspicious OR v.verification_status = 'suspicious') AS suspicious_count,
+			       MAX(v.verified_at) AS last_verified_at
+			FROM users u
+			LEFT JOIN verifications v ON v.verifier_id = u.id AND v.verified_at >= $1
+			WHERE u.role = 'verifier'
+			GROUP BY u.id
+		), scored AS (
+			SELECT *,
+			       ROUND(100.0 * GREATEST(0, total - not_found_count - $2 * suspicious_count + $3) / (total + $3), 1)::float8 AS score
+			FROM activity
+		), ranked AS (
+			SELECT *, (total >= $5 AND score < $4) AS flagged FROM scored
+		)`
+	args := []interface{}{
+		filter.Since,
+		models.VerifierScoreSuspiciousWeight,
+		models.VerifierScorePrior,
+		filter.FlagScore,
+		filter.FlagMinVolume,
+	}
+
+	where := ""
+	if filter.FlaggedOnly {
+		where = " WHERE flagged"
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, ranked+" SELECT COUNT(*) FROM ranked"+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count verifiers: %w", err)
+	}
+
+	// verifier_id breaks ties so pages don't overlap
+	query := ranked + " SELECT * FROM ranked" + where +
+		fmt.Sprintf(" ORDER BY %s %s NULLS LAST, verifier_id LIMIT $%d OFFSET $%d", column, direction, len(args)+1, len(args)+2)
+
+	var scores []*models.VerifierScore
+	if err := r.db.SelectContext(ctx, &scores, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to score verifiers: %w", err)
+	}
+
+	return scores, total, nil
+}
+
+// CountSuspicious counts verifications flagged as suspicious
+func (r *VerificationRepository) CountSuspicious(ctx context.Context) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM verifications WHERE is_suspicious = true`
+
+	err := r.db.GetContext(ctx, &count, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count suspicious verifications: %w", err)
+	}
+
+	return count, nil
+}
+
+// TimeSeriesByVerifier counts a verifier's verifications and spend per status in day or week buckets
+// interval must be a date_trunc field ("day" or "week"); from is inclusive and to exclusive.
+// Buckets start at midnight in loc. Buckets without verifications are not returned.
+// Pruned verifications are counted on their day
+func (r *VerificationRepository) TimeSeriesByVerifier(ctx context.Context, verifierID, interval string, from, to time.Time, loc *time.Location) ([]models.VerificationTrendRow, error) {
+	var rows []models.VerificationTrendRow
+	query := `
+		SELECT date_trunc($2, t.at) AS bucket, t.verification_status,
+		       SUM(t.n) AS count, COALESCE(SUM(t.spent), 0) AS spent
+		FROM (
+			SELECT verified_at AT TIME ZONE $5 AS at, verification_status, 1 AS n, amount_charged AS spent
+			FROM verifications
+			WHERE verifier_id = $1 AND verified_at >= $3 AND verified_at < $4
+			UNION ALL
+			SELECT day::timestamp, verification_status, verification_count, amount_charged
+			FROM verification_rollups
+			WHERE verifier_id = $1
+			AND day::timestamp >= ($3::timestamptz AT TIME ZONE $5)
+			AND day::timestamp < ($4::timestamptz AT TIME ZONE $5)
+		) t
+		GROUP BY bucket, t.verification_status
+		ORDER BY bucket
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query, verifierID, interval, from.UTC(), to.UTC(), loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verification trends: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetStatsByVerifier retrieves statistics for a verifier, including pruned verifications
 func (r *VerificationRepository) GetStatsByVerifier(ctx context.Context, verifierID string) (*models.VerificationStats, error) {
 	stats := &models.VerificationStats{}
 
 	// Total verifications
-	query := `SELECT COUNT(*) FROM verifications WHERE verifier_id = $1`
+	query := `
+		SELECT (SELECT COUNT(*) FROM verifications WHERE verifier_id = $1)
+		     + (SELECT COALESCE(SUM(verification_count), 0) FROM verification_rollups WHERE verifier_id = $1)
+	`
 	err := r.db.GetContext(ctx, &stats.TotalVerifications, query, verifierID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total verifications: %w", err)
 	}
 
 	// Total spent
-	query = `SELECT COALESCE(SUM(amount_charged), 0) FROM verifications WHERE verifier_id = $1`
+	query = `
+		SELECT (SELECT COALESCE(SUM(amount_charged), 0) FROM verifications WHERE verifier_id = $1)
+		     + (SELECT COALESCE(SUM(amount_charged), 0) FROM verification_rollups WHERE verifier_id = $1)
+	`
 	err = r.db.GetContext(ctx, &stats.TotalSpent, query, verifierID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total spent: %w", err)
 	}
 
 	// Valid count
-	query = `SELECT COUNT(*) FROM verifications WHERE verifier_id = $1 AND verification_status = 'valid'`
+	query = `
+		SELECT (SELECT COUNT(*) FROM verifications WHERE verifier_id = $1 AND verification_status = 'valid')
+		     + (SELECT COALESCE(SUM(verification_count), 0) FROM verification_rollups WHERE verifier_id = $1 AND verification_status = 'valid')
+	`
 	err = r.db.GetContext(ctx, &stats.ValidCount, query, verifierID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get valid count: %w", err)
 	}
 
 	// Invalid count
-	query = `SELECT COUNT(*) FROM verifications WHERE verifier_id = $1 AND verification_status = 'invalid'`
+	query = `
+		SELECT (SELECT COUNT(*) FROM verifications WHERE verifier_id = $1 AND verification_status = 'invalid')
+		     + (SELECT COALESCE(SUM(verification_count), 0) FROM verification_rollups WHERE verifier_id = $1 AND verification_status = 'invalid')
+	`
 	err = r.db.GetContext(ctx, &stats.InvalidCount, query, verifierID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get invalid count: %w", err)
 	}
 
 	// Restricted count
-	query = `SELECT COUNT(*) FROM verifications WHERE verifier_id = $1 AND verification_status = 'restricted'`
+	query = `
+		SELECT (SELECT COUNT(*) FROM verifications WHERE verifier_id = $1 AND verification_status = 'restricted')
+		     + (SELECT COALESCE(SUM(verification_count), 0) FROM verification_rollups WHERE verifier_id = $1 AND verification_status = 'restricted')
+	`
 	err = r.db.GetContext(ctx, &stats.RestrictedCount, query, verifierID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get restricted count: %w", err)
@@ -138,10 +400,13 @@ func (r *VerificationRepository) GetStatsByVerifier(ctx context.Context, verifie
 	return stats, nil
 }
 
-// CountVerificationsByBill counts how many times a bill has been verified
+// CountVerificationsByBill counts how many times a bill has been verified, including pruned verifications
 func (r *VerificationRepository) CountVerificationsByBill(ctx context.Context, billID string) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM verifications WHERE bill_id = $1`
+	query := `
+		SELECT (SELECT COUNT(*) FROM verifications WHERE bill_id = $1)
+		     + (SELECT COALESCE(SUM(verification_count), 0) FROM verification_rollups WHERE bill_id = $1)
+	`
 
 	err := r.db.GetContext(ctx, &count, query, billID)
 	if err != nil {
@@ -151,6 +416,74 @@ func (r *VerificationRepository) CountVerificationsByBill(ctx context.Context, b
 	return count, nil
 }
 
+// CountLogsByBill counts the verification records still held for a bill, i.e. what ListByBillWithVerifier pages through
+func (r *VerificationRepository) CountLogsByBill(ctx context.Context, billID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM verifications WHERE bill_id = $1`
+
+	err := r.db.GetContext(ctx, &count, query, billID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count bill verification logs: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListByBillWithVerifier retrieves a bill's verifications with the verifier's organization name
+func (r *VerificationRepository) ListByBillWithVerifier(ctx context.Context, billID string, limit, offset int) ([]*models.BillVerificationLog, error) {
+	var logs []*models.BillVerificationLog
+	query := `
+		SELECT v.id, u.organization_name AS verifier_name, v.verifier_ip,
+		       v.access_level_used, v.verification_status, v.verified_at
+		FROM verifications v 
+		LEFT JOIN users u ON v.verifier_id = u.id 
+		WHERE v.bill_id = $1 
+		ORDER BY v.verified_at DESC 
+		LIMIT $2 OFFSET $3
+	`
+
+	err := r.db.SelectContext(ctx, &logs, query, billID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bill verifications: %w", err)
+	}
+
+	return logs, nil
+}
+
+// CountByBillIDs counts verifications for several bills in one query, including pruned verifications
+// Bills with no verifications are absent from the returned map
+func (r *VerificationRepository) CountByBillIDs(ctx context.Context, billIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(billIDs))
+	if len(billIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `
+		SELECT bill_id, SUM(n) AS count
+		FROM (
+			SELECT bill_id, 1 AS n FROM verifications WHERE bill_id = ANY($1::uuid[])
+			UNION ALL
+			SELECT bill_id, verification_count FROM verification_rollups WHERE bill_id = ANY($1::uuid[])
+		) t
+		GROUP BY bill_id
+	`
+
+	var rows []struct {
+		BillID string `db:"bill_id"`
+		Count  int    `db:"count"`
+	}
+	err := r.db.SelectContext(ctx, &rows, query, pq.Array(billIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count bill verifications: %w", err)
+	}
+
+	for _, row := range rows {
+		counts[row.BillID] = row.Count
+	}
+
+	return counts, nil
+}
+
 // SearchVerifications searches verifications with filters
 func (r *VerificationRepository) SearchVerifications(
 	ctx context.Context,
@@ -196,4 +529,43 @@ func (r *VerificationRepository) SearchVerifications(
 	}
 
 	return verifications, nil
-}
\ No newline at end of file
+}
+
+// DeleteOlderThan prunes up to limit verifications made before cutoff, returning how many were removed
+// Suspicious verifications are only pruned before suspiciousCutoff, and never when it is nil.
+// Each pruned row is first added to the rollup for its day in loc (and copied to the archive when archive is set),
+// in the same statement, so totals never drop. Call repeatedly until it returns 0
+func (r *VerificationRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time, suspiciousCutoff *time.Time, archive bool, limit int, loc *time.Location) (int64, error) {
+	query := `
+		WITH doomed AS (
+			SELECT id FROM verifications
+			WHERE (is_suspicious IS NOT TRUE AND verified_at < $1)
+			   OR (is_suspicious = true AND verified_at < $2)
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		), deleted AS (
+			DELETE FROM verifications v
+			USING doomed d
+			WHERE v.id = d.id
+			RETURNING v.*
+		), archived AS (
+			INSERT INTO verifications_archive
+			SELECT * FROM deleted WHERE $4
+		), rolled_up AS (
+			INSERT INTO verification_rollups (day, verifier_id, bill_id, verification_status, verification_count, amount_charged)
+			SELECT (verified_at AT TIME ZONE $5)::date AS day, verifier_id, bill_id, verification_status,
+			       COUNT(*), COALESCE(SUM(amount_charged), 0)
+			FROM deleted
+			GROUP BY day, verifier_id, bill_id, verification_status
+		)
+		SELECT COUNT(*) FROM deleted
+	`
+
+	var deleted int64
+	err := r.db.GetContext(ctx, &deleted, query, cutoff, suspiciousCutoff, limit, archive, loc.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune verifications: %w", err)
+	}
+
+	return deleted, nil
+}
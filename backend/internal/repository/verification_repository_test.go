@@ -0,0 +1,186 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestVerificationCountsPerBillAndIssuer(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+
+	bills := repository.NewBillRepository(db.DB, nil, 0)
+	verifications := repository.NewVerificationRepository(db.DB)
+
+	issuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	otherIssuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+
+	popular := testutil.CreateBill(t, db, issuer, 100, nil)
+	unverified := testutil.CreateBill(t, db, issuer, 200, nil)
+	deleted := testutil.CreateBill(t, db, issuer, 300, nil)
+	foreign := testutil.CreateBill(t, db, otherIssuer, 400, nil)
+
+	for i := 0; i < 3; i++ {
+		testutil.CreateVerification(t, db, popular, verifier)
+	}
+	testutil.CreateVerification(t, db, deleted, verifier)
+	testutil.CreateVerification(t, db, foreign, verifier)
+
+	if err := bills.SoftDelete(ctx, deleted.ID, "test"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+
+	counts, err := verifications.CountByBillIDs(ctx, []string{popular.ID, unverified.ID, foreign.ID})
+	if err != nil {
+		t.Fatalf("CountByBillIDs failed: %v", err)
+	}
+	want := map[string]int{popular.ID: 3, foreign.ID: 1}
+	if len(counts) != len(want) {
+		t.Errorf("CountByBillIDs = %v, want %v", counts, want)
+	}
+	for id, n := range want {
+		if counts[id] != n {
+			t.Errorf("count for %s = %d, want %d", id, counts[id], n)
+		}
+	}
+
+	if counts, err := verifications.CountByBillIDs(ctx, nil); err != nil || len(counts) != 0 {
+		t.Errorf("CountByBillIDs(nil) = %v, %v; want an empty map", counts, err)
+	}
+
+	// The deleted bill and its verification are left out of the issuer's stats
+	stats, err := bills.GetStatsByIssuer(ctx, issuer.ID, time.Now().AddDate(0, -1, 0))
+	if err != nil {
+		t.Fatalf("GetStatsByIssuer failed: %v", err)
+	}
+	if stats.TotalBills != 2 || stats.TotalVerifications != 3 {
+		t.Errorf("stats = %d bills / %d verifications, want 2 / 3", stats.TotalBills, stats.TotalVerifications)
+	}
+}
+
+func TestVerifierScoreOverSeededHistories(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	verifications := repository.NewVerificationRepository(db.DB)
+
+	issuer := testutil.CreateUser(t, db, models.RoleInstitutionUser, 0)
+	bill := testutil.CreateBill(t, db, issuer, 100, nil)
+
+	// record gives verifier n verifications with the given status, optionally flagged as suspicious
+	record := func(verifier *models.User, n int, status models.VerificationStatus, suspicious bool) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			v := testutil.CreateVerification(t, db, bill, verifier)
+			db.MustExec("UPDATE verifications SET verification_status = $1, is_suspicious = $2 WHERE id = $3", status, suspicious, v.ID)
+		}
+	}
+
+	clean := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+	record(clean, 20, models.VerificationValid, false)
+	// Misses before the window don't count
+	old := testutil.CreateVerification(t, db, bill, clean)
+	db.MustExec("UPDATE verifications SET verification_status = 'not_found', verified_at = NOW() - INTERVAL '60 days' WHERE id = $1", old.ID)
+
+	misses := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+	record(misses, 5, models.VerificationValid, false)
+	record(misses, 5, models.VerificationNotFound, false)
+
+	suspicious := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+	record(suspicious, 8, models.VerificationValid, false)
+	record(suspicious, 1, models.VerificationValid, true)
+	record(suspicious, 1, models.VerificationSuspicious, false)
+
+	newcomer := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+	record(newcomer, 2, models.VerificationNotFound, false)
+
+	abuser := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+	record(abuser, 5, models.VerificationNotFound, false)
+	record(abuser, 5, models.VerificationNotFound, true)
+
+	idle := testutil.CreateUser(t, db, models.RoleVerifier, 0)
+
+	// Only verifier accounts are scored
+	record(issuer, 3, models.VerificationNotFound, false)
+
+	// score = 100 * max(0, total - not_found - 2*suspicious + 5) / (total + 5)
+	want := []struct {
+		name                               string
+		id                                 string
+		total, valid, notFound, suspicious int
+		score                              float64
+		flagged                            bool
+	}{
+		{"abuser", abuser.ID, 10, 0, 10, 5, 0, true},            // max(0, -15) / 15
+		{"misses", misses.ID, 10, 5, 5, 0, 66.7, true},          // 10 / 15
+		{"newcomer", newcomer.ID, 2, 0, 2, 0, 71.4, false},      // 5 / 7, too few to flag
+		{"suspicious", suspicious.ID, 10, 9, 0, 2, 73.3, false}, // 11 / 15
+		{"clean", clean.ID, 20, 20, 0, 0, 100, false},           // 25 / 25
+		{"idle", idle.ID, 0, 0, 0, 0, 100, false},               // 5 / 5
+	}
+
+	filter := models.VerifierScoreFilter{
+		Since:         time.Now().AddDate(0, 0, -30),
+		FlagScore:     70,
+		FlagMinVolume: 5,
+		Sort:          models.VerifierSortScore,
+	}
+	scores, total, err := verifications.VerifierScore(ctx, filter, 10, 0)
+	if err != nil {
+		t.Fatalf("VerifierScore failed: %v", err)
+	}
+	if total != len(want) || len(scores) != len(want) {
+		t.Fatalf("VerifierScore returned %d of %d verifiers, want %d", len(scores), total, len(want))
+	}
+
+	byID := map[string]*models.VerifierScore{}
+	for _, s := range scores {
+		byID[s.VerifierID] = s
+	}
+	for _, w := range want {
+		s := byID[w.id]
+		if s == nil {
+			t.Errorf("%s: missing from the results", w.name)
+			continue
+		}
+		if s.Total != w.total || s.ValidCount != w.valid || s.NotFoundCount != w.notFound || s.SuspiciousCount != w.suspicious {
+			t.Errorf("%s: counts total/valid/not_found/suspicious = %d/%d/%d/%d, want %d/%d/%d/%d", w.name,
+				s.Total, s.ValidCount, s.NotFoundCount, s.SuspiciousCount, w.total, w.valid, w.notFound, w.suspicious)
+		}
+		if s.Score != w.score || s.Flagged != w.flagged {
+			t.Errorf("%s: score %.1f flagged %v, want %.1f flagged %v", w.name, s.Score, s.Flagged, w.score, w.flagged)
+		}
+	}
+
+	// Ascending by score; the two perfect scores follow in either order
+	for i, w := range want[:4] {
+		if scores[i].VerifierID != w.id {
+			t.Errorf("position %d = %s, want %s", i, scores[i].VerifierID, w.name)
+		}
+	}
+
+	filter.FlaggedOnly = true
+	flagged, total, err := verifications.VerifierScore(ctx, filter, 10, 0)
+	if err != nil {
+		t.Fatalf("VerifierScore (flagged only) failed: %v", err)
+	}
+	if total != 2 || len(flagged) != 2 || flagged[0].VerifierID != abuser.ID || flagged[1].VerifierID != misses.ID {
+		t.Errorf("flagged verifiers = %d of %d, want the abuser and misses", len(flagged), total)
+	}
+
+	filter.FlaggedOnly = false
+	filter.Sort = models.VerifierSortVolume
+	filter.Descending = true
+	page, total, err := verifications.VerifierScore(ctx, filter, 1, 0)
+	if err != nil {
+		t.Fatalf("VerifierScore (by volume) failed: %v", err)
+	}
+	if total != len(want) || len(page) != 1 || page[0].VerifierID != clean.ID {
+		t.Errorf("busiest verifier page = %d of %d, want just the clean verifier", len(page), total)
+	}
+}
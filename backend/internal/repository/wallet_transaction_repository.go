@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// WalletTransactionRepository handles database operations for the wallet ledger
+type WalletTransactionRepository struct {
+	db instrumentedDB
+}
+
+// NewWalletTransactionRepository creates a new wallet transaction repository
+func NewWalletTransactionRepository(db *sqlx.DB) *WalletTransactionRepository {
+	return &WalletTransactionRepository{db: instrumentedDB{db}}
+}
+
+// Create inserts a ledger entry
+func (r *WalletTransactionRepository) Create(ctx context.Context, txn *models.WalletTransaction) error {
+	return r.create(ctx, r.db, txn)
+}
+
+// CreateTx inserts a ledger entry inside the transaction that changed the balance
+func (r *WalletTransactionRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, txn *models.WalletTransaction) error {
+	return r.create(ctx, tx, txn)
+}
+
+// create runs the ledger insert on either the pool or a transaction
+func (r *WalletTransactionRepository) create(ctx context.Context, q sqlx.QueryerContext, txn *models.WalletTransaction) error {
+	query := `
+		INSERT INTO wallet_transactions (
+			user_id, transaction_type, amount, balance_after, reference_id, description, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, created_at
+	`
+
+	err := q.QueryRowxContext(
+		ctx,
+		query,
+		txn.UserID,
+		txn.Type,
+		txn.Amount,
+		txn.BalanceAfter,
+		txn.ReferenceID,
+		txn.Description,
+		txn.CreatedBy,
+	).Scan(&txn.ID, &txn.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create wallet transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUser retrieves a user's wallet statement, newest first
+func (r *WalletTransactionRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*models.WalletTransaction, error) {
+	var txns []*models.WalletTransaction
+	query := `
+		SELECT * FROM wallet_transactions 
+		WHERE user_id = $1 
+		ORDER BY created_at DESC 
+		LIMIT $2 OFFSET $3
+	`
+
+	err := r.db.SelectContext(ctx, &txns, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallet transactions: %w", err)
+	}
+
+	return txns, nil
+}
+
+// CountByUser counts ledger entries for a user
+func (r *WalletTransactionRepository) CountByUser(ctx context.Context, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM wallet_transactions WHERE user_id = $1`
+
+	err := r.db.GetContext(ctx, &count, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count wallet transactions: %w", err)
+	}
+
+	return count, nil
+}
+
+// SumByType totals ledger amounts per transaction type across all users
+// Amounts keep their sign, so fee totals are negative
+func (r *WalletTransactionRepository) SumByType(ctx context.Context) (map[models.WalletTransactionType]float64, error) {
+	var rows []struct {
+		Type  models.WalletTransactionType `db:"transaction_type"`
+		Total float64                      `db:"total"`
+	}
+	query := `
+		SELECT transaction_type, COALESCE(SUM(amount), 0) AS total
+		FROM wallet_transactions
+		GROUP BY transaction_type
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum wallet transactions: %w", err)
+	}
+
+	totals := make(map[models.WalletTransactionType]float64, len(rows))
+	for _, row := range rows {
+		totals[row.Type] = row.Total
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// WebhookDeliveryRepository handles database operations for outbound webhook deliveries
+type WebhookDeliveryRepository struct {
+	db instrumentedDB
+}
+
+// NewWebhookDeliveryRepository creates a new webhook delivery repository
+func NewWebhookDeliveryRepository(db *sqlx.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: instrumentedDB{db}}
+}
+
+// CreateForOutbox inserts a pending delivery for an outbox message, or loads the one an earlier attempt created
+// An existing delivery keeps its attempt count and takes the issuer's current URL
+func (r *WebhookDeliveryRepository) CreateForOutbox(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (
+			outbox_id, user_id, event, url, payload, status
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+		ON CONFLICT (outbox_id) WHERE outbox_id IS NOT NULL
+		DO UPDATE SET url = EXCLUDED.url
+		RETURNING id, attempts, created_at
+	`
+
+	err := r.db.QueryRowxContext(
+		ctx,
+		query,
+		delivery.OutboxID,
+		delivery.UserID,
+		delivery.Event,
+		delivery.URL,
+		delivery.Payload,
+		delivery.Status,
+	).Scan(&delivery.ID, &delivery.Attempts, &delivery.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// RecordAttempt saves the outcome of a delivery attempt
+func (r *WebhookDeliveryRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries 
+		SET status = $2, attempts = $3, last_status_code = $4, last_error = $5,
+			delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() ELSE delivered_at END
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastStatusCode,
+		delivery.LastError,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUser retrieves a user's deliveries, newest first
+func (r *WebhookDeliveryRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	query := `
+		SELECT * FROM webhook_deliveries 
+		WHERE user_id = $1 
+		ORDER BY created_at DESC 
+		LIMIT $2 OFFSET $3
+	`
+
+	err := r.db.SelectContext(ctx, &deliveries, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// CountByUser counts a user's deliveries
+func (r *WebhookDeliveryRepository) CountByUser(ctx context.Context, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM webhook_deliveries WHERE user_id = $1`
+
+	err := r.db.GetContext(ctx, &count, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,298 @@
+// Package seed fills a development database with a small, working dataset
+// Everything is created through the same repositories and services the API uses
+package seed
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+// AdminEmail marks a seeded database; Run does nothing once this account exists
+const AdminEmail = "admin@seed.epr.local"
+
+// Options controls how much data Run creates
+type Options struct {
+	Institutions            int    // Institution accounts with approved KYC
+	BillsPerInstitution     int    // Bills of varied types and access levels per institution
+	Verifications           int    // Spread across the verifier, the public user and anonymous callers
+	Password                string // Shared by every seeded account
+	InstitutionWalletAmount float64
+}
+
+// DefaultOptions is a dataset big enough to click through every dashboard
+func DefaultOptions() Options {
+	return Options{
+		Institutions:            2,
+		BillsPerInstitution:     10,
+		Verifications:           25,
+		Password:                "SeedPassword@123",
+		InstitutionWalletAmount: 1000,
+	}
+}
+
+// Result counts what Run created
+type Result struct {
+	Skipped       bool // The database was already seeded
+	Users         int
+	Bills         int
+	Verifications int
+}
+
+// Services are the pieces of the API the seeder drives
+type Services struct {
+	UserRepo     *repository.UserRepository
+	Wallet       *services.WalletService
+	KYC          *services.KYCService
+	Bills        *services.BillService
+	Verification *services.VerificationService
+}
+
+// Seeder creates the development dataset
+type Seeder struct {
+	svc  Services
+	cfg  *config.Config
+	opts Options
+}
+
+// NewSeeder creates a new seeder
+func NewSeeder(svc Services, cfg *config.Config, opts Options) *Seeder {
+	return &Seeder{
+		svc:  svc,
+		cfg:  cfg,
+		opts: opts,
+	}
+}
+
+// Run creates the dataset, or returns a skipped Result if AdminEmail already exists
+func (s *Seeder) Run(ctx context.Context) (*Result, error) {
+	result := &Result{}
+
+	seeded, err := s.svc.UserRepo.EmailExists(ctx, AdminEmail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for seeded data: %w", err)
+	}
+	if seeded {
+		result.Skipped = true
+		return result, nil
+	}
+
+	passwordHash, err := utils.HashPassword(s.opts.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	admin, err := s.createUser(ctx, AdminEmail, "Seed Admin", "EPR", models.RoleMasterAdmin, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+	result.Users++
+
+	// Verifiers are charged per verification, so fund enough for all of them at the maximum fee
+	verificationBudget := float64(s.opts.Verifications+1) * s.cfg.Pricing.VerificationMaxFee
+	verifier, err := s.createUser(ctx, "verifier@seed.epr.local", "Seed Verifier", "Seed Verification Agency", models.RoleVerifier, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+	result.Users++
+	if err := s.fund(ctx, verifier, verificationBudget); err != nil {
+		return nil, err
+	}
+
+	public, err := s.createUser(ctx, "public@seed.epr.local", "Seed Public User", "Individual", models.RolePublic, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+	result.Users++
+	if err := s.fund(ctx, public, verificationBudget); err != nil {
+		return nil, err
+	}
+
+	var bills []*models.Bill
+	for i := 1; i <= s.opts.Institutions; i++ {
+		institution, err := s.createInstitution(ctx, i, admin.ID, passwordHash)
+		if err != nil {
+			return nil, err
+		}
+		result.Users++
+
+		for j := 0; j < s.opts.BillsPerInstitution; j++ {
+			bill, err := s.svc.Bills.CreateBill(ctx, institution.ID, sampleBill(i, j))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create bill %d for %s: %w", j+1, institution.Email, err)
+			}
+			bills = append(bills, bill)
+			result.Bills++
+		}
+	}
+
+	if len(bills) == 0 {
+		return result, nil
+	}
+
+	// Rotate through callers so every access level shows up in the verification history
+	callers := []*models.User{verifier, public, nil}
+	for i := 0; i < s.opts.Verifications; i++ {
+		bill := bills[i%len(bills)]
+		caller := callers[i%len(callers)]
+
+		var userID *string
+		role := models.RolePublic
+		if caller != nil {
+			userID = &caller.ID
+			role = caller.Role
+		}
+
+		ip := fmt.Sprintf("10.0.%d.%d", i/250, i%250+1)
+		if _, err := s.svc.Verification.VerifyBill(ctx, userID, bill.BillNumber, ip, "epr-seed", role, false); err != nil {
+			return nil, fmt.Errorf("failed to verify %s: %w", bill.BillNumber, err)
+		}
+		result.Verifications++
+	}
+
+	return result, nil
+}
+
+// createUser adds an active account with a verified email
+func (s *Seeder) createUser(ctx context.Context, email, fullName, organization string, role models.UserRole, passwordHash string) (*models.User, error) {
+	user := &models.User{
+		FullName:         fullName,
+		Email:            email,
+		PasswordHash:     passwordHash,
+		Role:             role,
+		OrganizationName: organization,
+		KYCStatus:        models.InitialKYCStatus(role),
+		IsActive:         true,
+		IsEmailVerified:  true,
+
+		NotificationPreferences: models.DefaultNotificationPreferences(),
+	}
+	if err := s.svc.UserRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", email, err)
+	}
+
+	log.Printf("👤 Created %s (%s)", email, role)
+	return user, nil
+}
+
+// createInstitution adds an institution admin, takes its KYC through submission and approval, and funds its wallet
+func (s *Seeder) createInstitution(ctx context.Context, n int, adminID, passwordHash string) (*models.User, error) {
+	email := fmt.Sprintf("institution%d@seed.epr.local", n)
+	institution, err := s.createUser(ctx, email, fmt.Sprintf("Seed Institution %d Admin", n), fmt.Sprintf("Seed Institution %d", n), models.RoleInstitutionAdmin, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := []models.KYCDocument{
+		{Type: "gst_certificate", URL: fmt.Sprintf("https://example.com/seed/institution%d/gst.pdf", n)},
+	}
+	if _, err := s.svc.KYC.Submit(ctx, institution.ID, documents); err != nil {
+		return nil, fmt.Errorf("failed to submit KYC for %s: %w", email, err)
+	}
+	institution, err = s.svc.KYC.Review(ctx, adminID, institution.ID, &models.ReviewKYCRequest{Decision: "approve"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve KYC for %s: %w", email, err)
+	}
+
+	// Enough for every bill plus the configured balance
+	amount := s.opts.InstitutionWalletAmount + float64(s.opts.BillsPerInstitution)*s.cfg.Pricing.BillGenerationFee
+	if err := s.fund(ctx, institution, amount); err != nil {
+		return nil, err
+	}
+
+	return institution, nil
+}
+
+// fund tops up a wallet the same way a completed payment does
+func (s *Seeder) fund(ctx context.Context, user *models.User, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	balance, err := s.svc.Wallet.Topup(ctx, user.ID, amount)
+	if err != nil {
+		return fmt.Errorf("failed to top up %s: %w", user.Email, err)
+	}
+	user.WalletBalance = balance
+
+	return nil
+}
+
+// sampleAccessLevels cycles so each institution has bills at every access level
+var sampleAccessLevels = []models.AccessLevel{
+	models.AccessLevelPublic,
+	models.AccessLevelRestricted,
+	models.AccessLevelGovernment,
+	models.AccessLevelFinancial,
+}
+
+// sampleBill returns the j-th bill for institution n, with bill_data that passes the type's schema
+func sampleBill(n, j int) *models.CreateBillRequest {
+	issueDate := time.Now().AddDate(0, 0, -(j*7)%90)
+	recipient := fmt.Sprintf("Recipient %d-%d", n, j+1)
+
+	billType, data := sampleBillData(j, recipient, issueDate)
+	data["recipient_name"] = recipient
+	data["description"] = fmt.Sprintf("Seed %s %d", billType, j+1)
+
+	accessLevel := sampleAccessLevels[j%len(sampleAccessLevels)]
+
+	return &models.CreateBillRequest{
+		BillType:     billType,
+		AccessLevel:  accessLevel,
+		Amount:       float64(500 + (n*997+j*1543)%49500),
+		IssueDate:    issueDate.Format("2006-01-02"),
+		BillData:     data,
+		AllowPreview: accessLevel == models.AccessLevelGovernment && j%2 == 0,
+	}
+}
+
+// sampleBillData picks a bill type for the j-th bill and fills in its required fields
+func sampleBillData(j int, recipient string, issueDate time.Time) (models.BillType, map[string]interface{}) {
+	month := issueDate.Format("January 2006")
+
+	switch j % 6 {
+	case 0:
+		return models.BillTypeSalesInvoice, map[string]interface{}{
+			"invoice_number": fmt.Sprintf("INV-%04d", j+1),
+			"customer_name":  recipient,
+			"line_items": []interface{}{
+				map[string]interface{}{"description": "Consulting", "quantity": 1, "rate": 1000},
+			},
+		}
+	case 1:
+		return models.BillTypeSalarySlip, map[string]interface{}{
+			"employee_name": recipient,
+			"month":         month,
+			"earnings":      map[string]interface{}{"basic": 30000, "hra": 12000},
+		}
+	case 2:
+		return models.BillTypeMedicalBill, map[string]interface{}{
+			"patient_name": recipient,
+			"treatment":    "General consultation",
+		}
+	case 3:
+		return models.BillTypeTaxReceipt, map[string]interface{}{
+			"taxpayer_name":   recipient,
+			"assessment_year": fmt.Sprintf("%d-%02d", issueDate.Year(), (issueDate.Year()+1)%100),
+		}
+	case 4:
+		return models.BillTypeRentReceipt, map[string]interface{}{
+			"tenant_name":      recipient,
+			"property_address": "12 MG Road, Bengaluru",
+			"month":            month,
+		}
+	default:
+		return models.BillTypeLoanStatement, map[string]interface{}{
+			"borrower_name":       recipient,
+			"loan_account_number": fmt.Sprintf("LN%08d", j+1),
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package seed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/services"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestRunCreatesDatasetOnce(t *testing.T) {
+	db := testutil.DB(t)
+	cfg := testutil.Config(t)
+	cfg.Email.SMTPHost, cfg.Email.SMTPPort = "127.0.0.1", 1 // Nothing listens, so emails fail fast
+	ctx := context.Background()
+
+	// Wired like cmd/seed, without Redis
+	userRepo := repository.NewUserRepository(db.DB)
+	billRepo := repository.NewBillRepository(db.DB, nil, 0)
+	billAliasRepo := repository.NewBillAliasRepository(db.DB)
+	verificationRepo := repository.NewVerificationRepository(db.DB)
+	walletTxRepo := repository.NewWalletTransactionRepository(db.DB)
+	activityCounterRepo := repository.NewActivityCounterRepository(nil)
+	outboxRepo := repository.NewOutboxRepository(db.DB)
+	auditRepo := repository.NewAuditRepository(db.DB)
+
+	emailService := services.NewEmailService(cfg, billRepo, billAliasRepo, userRepo, services.NewPDFService(cfg.App.FrontendURL))
+	seeder := NewSeeder(Services{
+		UserRepo: userRepo,
+		Wallet:   services.NewWalletService(db, userRepo, walletTxRepo),
+		KYC:      services.NewKYCService(db, userRepo, auditRepo, emailService),
+		Bills: services.NewBillService(db, billRepo, billAliasRepo, userRepo, verificationRepo, walletTxRepo,
+			repository.NewIdempotencyKeyRepository(db.DB), outboxRepo, auditRepo, nil, cfg),
+		Verification: services.NewVerificationService(db, verificationRepo, billRepo, billAliasRepo, userRepo, walletTxRepo, outboxRepo,
+			activityCounterRepo, services.NewSuspiciousActivityDetector(activityCounterRepo, cfg),
+			services.NewFakeBillAlerter(db, activityCounterRepo, outboxRepo, cfg), nil, cfg),
+	}, cfg, Options{
+		Institutions:            2,
+		BillsPerInstitution:     4,
+		Verifications:           9,
+		Password:                "SeedPassword@123",
+		InstitutionWalletAmount: 500,
+	})
+
+	result, err := seeder.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Skipped || result.Users != 5 || result.Bills != 8 || result.Verifications != 9 {
+		t.Errorf("result = %+v, want 5 users, 8 bills and 9 verifications", result)
+	}
+
+	minimums := []struct {
+		name  string
+		table string
+		where string
+		min   int
+	}{
+		{"users", "users", "TRUE", 5},
+		{"master admins", "users", "role = 'master_admin'", 1},
+		{"verifiers", "users", "role = 'verifier'", 1},
+		{"public users", "users", "role = 'public'", 1},
+		{"institutions with approved KYC", "users", "role IN ('institution_user', 'institution_admin') AND kyc_status = 'approved'", 2},
+		{"funded accounts", "users", "wallet_balance > 0", 3},
+		{"bills", "bills", "TRUE", 8},
+		{"verifications", "verifications", "TRUE", 9},
+		{"anonymous verifications", "verifications", "verifier_id IS NULL", 1},
+	}
+	for _, m := range minimums {
+		if n := testutil.Count(t, db, m.table, m.where); n < m.min {
+			t.Errorf("%s = %d, want at least %d", m.name, n, m.min)
+		}
+	}
+
+	var billTypes, accessLevels int
+	if err := db.Get(&billTypes, "SELECT COUNT(DISTINCT bill_type) FROM bills"); err != nil || billTypes < 2 {
+		t.Errorf("distinct bill types = %d, %v; want a variety", billTypes, err)
+	}
+	if err := db.Get(&accessLevels, "SELECT COUNT(DISTINCT access_level) FROM bills"); err != nil || accessLevels < 2 {
+		t.Errorf("distinct access levels = %d, %v; want a variety", accessLevels, err)
+	}
+
+	// A second run leaves the dataset alone
+	again, err := seeder.Run(ctx)
+	if err != nil {
+		t.Fatalf("second Run failed: %v", err)
+	}
+	if !again.Skipped {
+		t.Errorf("second run = %+v, want it skipped", again)
+	}
+	if n := testutil.Count(t, db, "bills", "TRUE"); n != 8 {
+		t.Errorf("bills after a second run = %d, want 8", n)
+	}
+}
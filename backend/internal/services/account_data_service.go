@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// exportBatchSize is how many rows are read per query while compiling an export
+const exportBatchSize = 500
+
+// AccountDataService exports and erases a user's personal data
+type AccountDataService struct {
+	db               *database.DB
+	userRepo         *repository.UserRepository
+	billRepo         *repository.BillRepository
+	verificationRepo *repository.VerificationRepository
+	walletTxRepo     *repository.WalletTransactionRepository
+	loginHistoryRepo *repository.LoginHistoryRepository
+}
+
+// NewAccountDataService creates a new account data service
+func NewAccountDataService(
+	db *database.DB,
+	userRepo *repository.UserRepository,
+	billRepo *repository.BillRepository,
+	verificationRepo *repository.VerificationRepository,
+	walletTxRepo *repository.WalletTransactionRepository,
+	loginHistoryRepo *repository.LoginHistoryRepository,
+) *AccountDataService {
+	return &AccountDataService{
+		db:               db,
+		userRepo:         userRepo,
+		billRepo:         billRepo,
+		verificationRepo: verificationRepo,
+		walletTxRepo:     walletTxRepo,
+		loginHistoryRepo: loginHistoryRepo,
+	}
+}
+
+// Export compiles the user's profile, bills, verifications, wallet statement and recent sign-ins
+func (s *AccountDataService) Export(ctx context.Context, userID string) (*models.AccountDataExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bills, err := collectPages(ctx, func(ctx context.Context, limit, offset int) ([]*models.Bill, error) {
+		return s.billRepo.ListByIssuer(ctx, userID, true, limit, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	verifications, err := collectPages(ctx, func(ctx context.Context, limit, offset int) ([]*models.Verification, error) {
+		return s.verificationRepo.ListByVerifier(ctx, userID, limit, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	walletTxns, err := collectPages(ctx, func(ctx context.Context, limit, offset int) ([]*models.WalletTransaction, error) {
+		return s.walletTxRepo.ListByUser(ctx, userID, limit, offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logins, err := s.loginHistoryRepo.ListByUser(ctx, userID, loginHistoryKeep)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AccountDataExport{
+		ExportedAt:         time.Now(),
+		Profile:            user,
+		Bills:              bills,
+		Verifications:      verifications,
+		WalletTransactions: walletTxns,
+		LoginHistory:       logins,
+	}, nil
+}
+
+// Erase scrubs the user's personal data and deactivates the account in one transaction
+// Bills keep their content and data hash, since verifiers still rely on them; verifications
+// and wallet entries keep their amounts so platform totals don't change
+func (s *AccountDataService) Erase(ctx context.Context, userID string) error {
+	return s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := s.userRepo.AnonymizeTx(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		if _, err := s.verificationRepo.AnonymizeByVerifierTx(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return s.loginHistoryRepo.DeleteByUserTx(ctx, tx, userID)
+	})
+}
+
+// collectPages reads every row from an offset-paginated list in exportBatchSize chunks
+func collectPages[T any](ctx context.Context, list func(ctx context.Context, limit, offset int) ([]T, error)) ([]T, error) {
+	all := []T{}
+	for offset := 0; ; offset += exportBatchSize {
+		page, err := list(ctx, exportBatchSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export account data: %w", err)
+		}
+
+		all = append(all, page...)
+		if len(page) < exportBatchSize {
+			return all, nil
+		}
+	}
+}
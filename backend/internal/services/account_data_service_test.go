@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+// accountDataService returns an AccountDataService on the test database
+func (e *testEnv) accountDataService() *AccountDataService {
+	return NewAccountDataService(e.db, e.users, e.bills, e.verifications, e.walletTxs, repository.NewLoginHistoryRepository(e.db.DB))
+}
+
+func TestEraseScrubsPersonalDataButKeepsBillHashes(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.accountDataService()
+	ctx := context.Background()
+
+	user := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	bill := testutil.CreateBill(t, env.db, user, 1000, nil)
+	verification := testutil.CreateVerification(t, env.db, testutil.CreateBill(t, env.db, user, 200, nil), user)
+	env.db.MustExec("UPDATE verifications SET verifier_ip = '192.0.2.1', verifier_user_agent = 'go-test' WHERE id = $1", verification.ID)
+	ip := "192.0.2.1"
+	if err := repository.NewLoginHistoryRepository(env.db.DB).Create(ctx, &models.LoginEvent{UserID: user.ID, IPAddress: &ip}, 10); err != nil {
+		t.Fatalf("failed to record login: %v", err)
+	}
+
+	// Someone else's data is untouched
+	other := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	otherVerification := testutil.CreateVerification(t, env.db, bill, other)
+	env.db.MustExec("UPDATE verifications SET verifier_ip = '192.0.2.2' WHERE id = $1", otherVerification.ID)
+
+	if err := svc.Erase(ctx, user.ID); err != nil {
+		t.Fatalf("Erase failed: %v", err)
+	}
+
+	erased, err := env.users.GetByIDAny(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to reload user: %v", err)
+	}
+	if erased.Email == user.Email || erased.FullName == user.FullName || erased.OrganizationName == user.OrganizationName {
+		t.Errorf("personal fields kept: email %q, name %q, organization %q", erased.Email, erased.FullName, erased.OrganizationName)
+	}
+	if erased.PasswordHash != "" || erased.IsActive || erased.ErasedAt == nil {
+		t.Errorf("account still usable: password set %v, active %v, erased at %v", erased.PasswordHash != "", erased.IsActive, erased.ErasedAt)
+	}
+
+	stored, err := env.bills.GetByID(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("failed to reload bill: %v", err)
+	}
+	if stored.DataHash != bill.DataHash || string(stored.BillData) != string(bill.BillData) {
+		t.Errorf("bill content changed: hash %s, want %s", stored.DataHash, bill.DataHash)
+	}
+
+	if n := testutil.Count(t, env.db, "verifications", "verifier_id = $1 AND (verifier_ip IS NOT NULL OR verifier_user_agent IS NOT NULL)", user.ID); n != 0 {
+		t.Errorf("%d verifications still carry the IP or user agent", n)
+	}
+	if n := testutil.Count(t, env.db, "verifications", "verifier_id = $1", user.ID); n != 1 {
+		t.Errorf("verifications = %d, want 1 kept for the totals", n)
+	}
+	if n := testutil.Count(t, env.db, "login_history", "user_id = $1", user.ID); n != 0 {
+		t.Errorf("login history = %d, want 0", n)
+	}
+	if n := testutil.Count(t, env.db, "verifications", "id = $1 AND verifier_ip IS NOT NULL", otherVerification.ID); n != 1 {
+		t.Error("another user's verification was anonymized")
+	}
+
+	if err := svc.Erase(ctx, user.ID); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("erasing twice error = %v, want %v", err, repository.ErrUserNotFound)
+	}
+}
+
+func TestExportCompilesAccountData(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.accountDataService()
+
+	user := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	bill := testutil.CreateBill(t, env.db, user, 1000, nil)
+	testutil.CreateVerification(t, env.db, bill, user)
+	testutil.CreateBill(t, env.db, testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0), 500, nil)
+
+	export, err := svc.Export(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if export.Profile == nil || export.Profile.ID != user.ID {
+		t.Fatalf("profile = %v, want user %s", export.Profile, user.ID)
+	}
+	if len(export.Bills) != 1 || export.Bills[0].ID != bill.ID {
+		t.Errorf("exported %d bills, want just the user's own", len(export.Bills))
+	}
+	if len(export.Verifications) != 1 {
+		t.Errorf("exported %d verifications, want 1", len(export.Verifications))
+	}
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// signupWindow is how far back the admin dashboard reports signups
+const signupWindow = 30 * 24 * time.Hour
+
+// AdminService handles platform-wide reporting for master admins
+type AdminService struct {
+	db               *database.DB
+	userRepo         *repository.UserRepository
+	billRepo         *repository.BillRepository
+	verificationRepo *repository.VerificationRepository
+	walletTxRepo     *repository.WalletTransactionRepository
+	tokenDenylist    *repository.TokenDenylistRepository
+	auditRepo        *repository.AuditRepository
+	cfg              *config.Config
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(
+	db *database.DB,
+	userRepo *repository.UserRepository,
+	billRepo *repository.BillRepository,
+	verificationRepo *repository.VerificationRepository,
+	walletTxRepo *repository.WalletTransactionRepository,
+	tokenDenylist *repository.TokenDenylistRepository,
+	auditRepo *repository.AuditRepository,
+	cfg *config.Config,
+) *AdminService {
+	return &AdminService{
+		db:               db,
+		userRepo:         userRepo,
+		billRepo:         billRepo,
+		verificationRepo: verificationRepo,
+		walletTxRepo:     walletTxRepo,
+		tokenDenylist:    tokenDenylist,
+		auditRepo:        auditRepo,
+		cfg:              cfg,
+	}
+}
+
+// SetVerificationPlan assigns a prepaid verification plan to a user, or removes it when PlanType is empty
+// A new plan starts with its full quota and resets at the start of next month in APP_TIMEZONE
+func (s *AdminService) SetVerificationPlan(ctx context.Context, adminID, userID string, req *models.SetVerificationPlanRequest) (*models.User, error) {
+	var planType *string
+	var resetsAt *time.Time
+	quota := 0
+
+	if trimmed := strings.TrimSpace(req.PlanType); trimmed != "" {
+		nextMonth := utils.StartOfMonth(time.Now(), s.cfg.Location()).AddDate(0, 1, 0).UTC()
+		planType = &trimmed
+		resetsAt = &nextMonth
+		quota = req.MonthlyQuota
+	}
+
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		user, err := s.userRepo.GetByIDAnyForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.SetVerificationPlanTx(ctx, tx, userID, planType, quota, resetsAt); err != nil {
+			return err
+		}
+
+		return s.auditRepo.RecordTx(ctx, tx, adminID, models.AuditUserPlanChange, models.AuditTargetUser, userID, models.AuditChange(
+			map[string]interface{}{"plan_type": user.PlanType, "monthly_quota": user.PlanMonthlyQuota},
+			map[string]interface{}{"plan_type": planType, "monthly_quota": quota},
+		))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByIDAny(ctx, userID)
+}
+
+// SetUserActive activates or deactivates any account except the admin's own
+// Deactivation also revokes the user's refresh tokens so existing sessions can't be renewed
+func (s *AdminService) SetUserActive(ctx context.Context, adminID, userID string, active bool) (*models.User, error) {
+	if adminID == userID {
+		return nil, ErrCannotDeactivateSelf
+	}
+
+	action := models.AuditUserActivate
+	if !active {
+		action = models.AuditUserDeactivate
+	}
+
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		user, err := s.userRepo.GetByIDAnyForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.SetActiveTx(ctx, tx, userID, active); err != nil {
+			return err
+		}
+
+		return s.auditRepo.RecordTx(ctx, tx, adminID, action, models.AuditTargetUser, userID, models.AuditChange(
+			map[string]interface{}{"is_active": user.IsActive},
+			map[string]interface{}{"is_active": active},
+		))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !active {
+		if err := s.tokenDenylist.RevokeAllForUser(ctx, userID, s.cfg.JWT.MaxRefreshTokenExpiry()); err != nil {
+			// Refresh also checks is_active, so the account is still locked out
+			if !errors.Is(err, repository.ErrTokenRevocationUnavailable) {
+				log.Printf("⚠️  Failed to revoke tokens for deactivated user %s: %v", userID, err)
+			}
+		}
+	}
+
+	return s.userRepo.GetByIDAny(ctx, userID)
+}
+
+// UpdateUserRole changes a user's role and brings their KYC status in line, recording which admin made the change
+// Moving into an institution role from outside one sets KYC to pending; moving between the two institution
+// roles keeps the existing review; any other role doesn't need KYC. The last active master admin can't be
+// demoted. The new role takes effect when the user's access token is next refreshed
+func (s *AdminService) UpdateUserRole(ctx context.Context, adminID, userID string, role models.UserRole) (*models.User, error) {
+	var oldRole models.UserRole
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Lock the admins before reading the user, so a concurrent demotion can't slip past the count
+		activeAdmins := 0
+		if role != models.RoleMasterAdmin {
+			var err error
+			activeAdmins, err = s.userRepo.LockMasterAdminsTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		user, err := s.userRepo.GetByIDAnyForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		oldRole = user.Role
+
+		// Nothing changes, so nothing is audited
+		if user.Role == role {
+			return nil
+		}
+		if user.Role == models.RoleMasterAdmin && user.IsActive && activeAdmins <= 1 {
+			return ErrLastMasterAdmin
+		}
+
+		kycStatus := user.KYCStatus
+		switch {
+		case role.IsInstitution() && !user.Role.IsInstitution():
+			kycStatus = models.KYCPending
+		case !role.IsInstitution():
+			kycStatus = models.KYCNotNeeded
+		}
+
+		if err := s.userRepo.UpdateRoleTx(ctx, tx, userID, adminID, role, kycStatus); err != nil {
+			return err
+		}
+
+		return s.auditRepo.RecordTx(ctx, tx, adminID, models.AuditUserRoleChange, models.AuditTargetUser, userID, models.AuditChange(
+			map[string]interface{}{"role": user.Role, "kyc_status": user.KYCStatus},
+			map[string]interface{}{"role": role, "kyc_status": kycStatus},
+		))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if oldRole != role {
+		log.Printf("👤 Admin %s changed role of %s from %s to %s", adminID, userID, oldRole, role)
+	}
+
+	return s.userRepo.GetByIDAny(ctx, userID)
+}
+
+// AdjustWallet credits or debits a user's wallet on an admin's behalf and records who did it and why
+// A debit that would overdraw the wallet fails with an InsufficientBalanceError unless allow_negative is set.
+// Deactivated users' wallets can be adjusted too, e.g. to refund them before their account is closed
+func (s *AdminService) AdjustWallet(ctx context.Context, adminID, userID string, req *models.AdjustWalletRequest) (*models.WalletTransaction, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, ErrAdjustmentReasonRequired
+	}
+
+	var entry *models.WalletTransaction
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		newBalance, err := s.userRepo.AdminAdjustWalletBalanceTx(ctx, tx, userID, req.Delta, req.AllowNegative)
+		if err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return err
+			}
+			return debitError(err, -req.Delta, newBalance)
+		}
+
+		entry = &models.WalletTransaction{
+			UserID:       userID,
+			Type:         models.WalletTxAdminAdjustment,
+			Amount:       req.Delta,
+			BalanceAfter: newBalance,
+			Description:  &reason,
+			CreatedBy:    &adminID,
+		}
+		if err := s.walletTxRepo.CreateTx(ctx, tx, entry); err != nil {
+			return err
+		}
+
+		return s.auditRepo.RecordTx(ctx, tx, adminID, models.AuditWalletAdjust, models.AuditTargetUser, userID, map[string]interface{}{
+			"delta":                 req.Delta,
+			"balance_after":         newBalance,
+			"reason":                reason,
+			"allow_negative":        req.AllowNegative,
+			"wallet_transaction_id": entry.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("💰 Admin %s adjusted wallet of %s by %.2f: %s", adminID, userID, req.Delta, reason)
+
+	return entry, nil
+}
+
+// GetPlatformStats aggregates users, bills, verifications and revenue across the platform
+func (s *AdminService) GetPlatformStats(ctx context.Context) (*models.PlatformStats, error) {
+	stats := &models.PlatformStats{}
+
+	usersByRole, err := s.userRepo.CountByRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.UsersByRole = usersByRole
+	for _, count := range usersByRole {
+		stats.TotalUsers += count
+	}
+
+	if stats.TotalBills, err = s.billRepo.CountAll(ctx); err != nil {
+		return nil, err
+	}
+	if stats.BillsByBlockchainStatus, err = s.billRepo.CountByBlockchainStatus(ctx); err != nil {
+		return nil, err
+	}
+
+	if stats.TotalVerifications, err = s.verificationRepo.CountAll(ctx); err != nil {
+		return nil, err
+	}
+
+	// Fees are recorded as negative ledger entries, refunds as positive ones
+	ledgerTotals, err := s.walletTxRepo.SumByType(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum revenue: %w", err)
+	}
+	stats.Revenue.BillGenerationFees = -ledgerTotals[models.WalletTxBillFee]
+	stats.Revenue.VerificationFees = -ledgerTotals[models.WalletTxVerificationFee]
+	stats.Revenue.Refunds = ledgerTotals[models.WalletTxRefund]
+	stats.Revenue.Total = stats.Revenue.BillGenerationFees + stats.Revenue.VerificationFees - stats.Revenue.Refunds
+
+	signups, err := s.userRepo.CountSignupsByDay(ctx, time.Now().Add(-signupWindow))
+	if err != nil {
+		return nil, err
+	}
+	if signups == nil {
+		signups = []models.DailyCount{}
+	}
+	stats.SignupsByDay = signups
+	for _, day := range signups {
+		stats.SignupsLast30Days += day.Count
+	}
+
+	return stats, nil
+}
+
+// ListVerifierScores returns a page of verifier accounts with their reputation scores and the total count
+// The score window and flag thresholds come from config; the caller picks the order and filter
+func (s *AdminService) ListVerifierScores(ctx context.Context, filter models.VerifierScoreFilter, page, pageSize int) ([]*models.VerifierScore, int, error) {
+	filter.Since = time.Now().Add(-s.cfg.Fraud.VerifierScoreWindow)
+	filter.FlagScore = s.cfg.Fraud.VerifierFlagScore
+	filter.FlagMinVolume = s.cfg.Fraud.VerifierFlagMinVolume
+
+	offset := (page - 1) * pageSize
+	return s.verificationRepo.VerifierScore(ctx, filter, pageSize, offset)
+}
+
+// ListAuditLog returns a page of audit log entries matching the filter, newest first, with the total count
+func (s *AdminService) ListAuditLog(ctx context.Context, filter models.AuditFilter, page, pageSize int) ([]*models.AuditEntry, int, error) {
+	offset := (page - 1) * pageSize
+	return s.auditRepo.List(ctx, filter, pageSize, offset)
+}
+
+// ListSuspiciousVerifications returns verifications flagged as suspicious with the total count
+func (s *AdminService) ListSuspiciousVerifications(ctx context.Context, page, pageSize int) ([]*models.Verification, int, error) {
+	offset := (page - 1) * pageSize
+
+	verifications, err := s.verificationRepo.ListSuspicious(ctx, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.verificationRepo.CountSuspicious(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return verifications, total, nil
+}
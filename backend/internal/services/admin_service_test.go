@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+func TestGetPlatformStats(t *testing.T) {
+	env := newTestEnv(t)
+	svc := NewAdminService(env.db, env.users, env.bills, env.verifications, env.walletTxs, nil, env.audit, env.cfg)
+	ctx := context.Background()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	oldUser := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	env.db.MustExec("UPDATE users SET created_at = NOW() - INTERVAL '60 days' WHERE id = $1", oldUser.ID)
+
+	confirmed := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	pending := testutil.CreateBill(t, env.db, issuer, 200, nil)
+	deleted := testutil.CreateBill(t, env.db, issuer, 300, nil)
+	if err := env.bills.UpdateBlockchainStatus(ctx, confirmed.ID, "tx-1", models.BlockchainConfirmed); err != nil {
+		t.Fatalf("UpdateBlockchainStatus failed: %v", err)
+	}
+	if err := env.bills.SoftDelete(ctx, deleted.ID, "test"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	testutil.CreateVerification(t, env.db, confirmed, verifier)
+	testutil.CreateVerification(t, env.db, pending, verifier)
+
+	for _, entry := range []struct {
+		user   *models.User
+		txType models.WalletTransactionType
+		amount float64
+	}{
+		{issuer, models.WalletTxBillFee, -10},
+		{issuer, models.WalletTxBillFee, -10},
+		{verifier, models.WalletTxVerificationFee, -5},
+		{verifier, models.WalletTxRefund, 5},
+		{verifier, models.WalletTxTopup, 500},
+	} {
+		if err := env.walletTxs.Create(ctx, &models.WalletTransaction{UserID: entry.user.ID, Type: entry.txType, Amount: entry.amount}); err != nil {
+			t.Fatalf("failed to record %s: %v", entry.txType, err)
+		}
+	}
+
+	stats, err := svc.GetPlatformStats(ctx)
+	if err != nil {
+		t.Fatalf("GetPlatformStats failed: %v", err)
+	}
+
+	if stats.TotalUsers != 4 || stats.UsersByRole[models.RoleInstitutionUser] != 2 || stats.UsersByRole[models.RoleVerifier] != 2 {
+		t.Errorf("users = %d %v, want 4 with 2 institutions and 2 verifiers", stats.TotalUsers, stats.UsersByRole)
+	}
+	if stats.TotalBills != 2 {
+		t.Errorf("total bills = %d, want 2 (deleted bills excluded)", stats.TotalBills)
+	}
+	if stats.BillsByBlockchainStatus[models.BlockchainConfirmed] != 1 || stats.BillsByBlockchainStatus[models.BlockchainPending] != 1 {
+		t.Errorf("bills by blockchain status = %v, want 1 confirmed and 1 pending", stats.BillsByBlockchainStatus)
+	}
+	if stats.TotalVerifications != 2 {
+		t.Errorf("total verifications = %d, want 2", stats.TotalVerifications)
+	}
+
+	revenue := stats.Revenue
+	if revenue.BillGenerationFees != 20 || revenue.VerificationFees != 5 || revenue.Refunds != 5 || revenue.Total != 20 {
+		t.Errorf("revenue = %+v, want 20 bill fees, 5 verification fees, 5 refunds, 20 total", revenue)
+	}
+
+	if stats.SignupsLast30Days != 3 {
+		t.Errorf("signups in the last 30 days = %d, want 3", stats.SignupsLast30Days)
+	}
+}
+
+func TestAdjustWallet(t *testing.T) {
+	env := newTestEnv(t)
+	svc := NewAdminService(env.db, env.users, env.bills, env.verifications, env.walletTxs, nil, env.audit, env.cfg)
+	ctx := context.Background()
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+
+	tests := []struct {
+		name          string
+		delta         float64
+		allowNegative bool
+		wantBalance   float64
+		wantErr       error
+	}{
+		{"credit", 25, false, 125, nil},
+		{"debit", -40, false, 60, nil},
+		{"debit to zero", -100, false, 0, nil},
+		{"overdraw rejected", -150, false, 100, ErrInsufficientBalance},
+		{"overdraw allowed", -150, true, -50, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+
+			entry, err := svc.AdjustWallet(ctx, admin.ID, user.ID, &models.AdjustWalletRequest{
+				Delta: tt.delta, Reason: " refund for ticket 42 ", AllowNegative: tt.allowNegative,
+			})
+			if balance := env.balanceOf(t, user.ID); balance != tt.wantBalance {
+				t.Errorf("balance = %.2f, want %.2f", balance, tt.wantBalance)
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("AdjustWallet error = %v, want %v", err, tt.wantErr)
+				}
+				if n := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1", user.ID); n != 0 {
+					t.Errorf("ledger entries = %d, want 0", n)
+				}
+				if n := testutil.Count(t, env.db, "audit_log", "target_id = $1", user.ID); n != 0 {
+					t.Errorf("audit entries = %d, want 0", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AdjustWallet failed: %v", err)
+			}
+
+			if entry.Type != models.WalletTxAdminAdjustment || entry.Amount != tt.delta || entry.BalanceAfter != tt.wantBalance {
+				t.Errorf("ledger entry = %s %.2f -> %.2f, want %s %.2f -> %.2f",
+					entry.Type, entry.Amount, entry.BalanceAfter, models.WalletTxAdminAdjustment, tt.delta, tt.wantBalance)
+			}
+			if entry.CreatedBy == nil || *entry.CreatedBy != admin.ID || entry.Description == nil || *entry.Description != "refund for ticket 42" {
+				t.Errorf("ledger entry created by %v for %v, want the admin and the trimmed reason", entry.CreatedBy, entry.Description)
+			}
+			if n := testutil.Count(t, env.db, "wallet_transactions", "id = $1 AND created_by = $2", entry.ID, admin.ID); n != 1 {
+				t.Errorf("stored ledger entries by the admin = %d, want 1", n)
+			}
+			if n := testutil.Count(t, env.db, "audit_log", "actor_id = $1 AND action = $2 AND target_id = $3 AND metadata->>'wallet_transaction_id' = $4",
+				admin.ID, models.AuditWalletAdjust, user.ID, entry.ID); n != 1 {
+				t.Errorf("audit entries = %d, want 1", n)
+			}
+		})
+	}
+
+	t.Run("reason required", func(t *testing.T) {
+		user := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+		_, err := svc.AdjustWallet(ctx, admin.ID, user.ID, &models.AdjustWalletRequest{Delta: 10, Reason: "  "})
+		if !errors.Is(err, ErrAdjustmentReasonRequired) {
+			t.Errorf("AdjustWallet error = %v, want %v", err, ErrAdjustmentReasonRequired)
+		}
+	})
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	env := newTestEnv(t)
+	svc := NewAdminService(env.db, env.users, env.bills, env.verifications, env.walletTxs, nil, env.audit, env.cfg)
+	ctx := context.Background()
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+
+	tests := []struct {
+		name    string
+		from    models.UserRole
+		to      models.UserRole
+		wantKYC models.KYCStatus
+	}{
+		{"public to institution needs KYC", models.RolePublic, models.RoleInstitutionUser, models.KYCPending},
+		{"institution to institution admin keeps KYC", models.RoleInstitutionUser, models.RoleInstitutionAdmin, models.KYCApproved},
+		{"institution to verifier drops KYC", models.RoleInstitutionUser, models.RoleVerifier, models.KYCNotNeeded},
+		{"verifier to public", models.RoleVerifier, models.RolePublic, models.KYCNotNeeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testutil.CreateUser(t, env.db, tt.from, 0)
+
+			updated, err := svc.UpdateUserRole(ctx, admin.ID, user.ID, tt.to)
+			if err != nil {
+				t.Fatalf("UpdateUserRole failed: %v", err)
+			}
+			if updated.Role != tt.to || updated.KYCStatus != tt.wantKYC {
+				t.Errorf("user = %s with KYC %s, want %s with KYC %s", updated.Role, updated.KYCStatus, tt.to, tt.wantKYC)
+			}
+			if updated.RoleChangedBy == nil || *updated.RoleChangedBy != admin.ID || updated.RoleChangedAt == nil {
+				t.Errorf("role changed by %v at %v, want the admin and a time", updated.RoleChangedBy, updated.RoleChangedAt)
+			}
+			if n := testutil.Count(t, env.db, "audit_log", "actor_id = $1 AND action = $2 AND target_id = $3", admin.ID, models.AuditUserRoleChange, user.ID); n != 1 {
+				t.Errorf("audit entries = %d, want 1", n)
+			}
+		})
+	}
+
+	t.Run("the last master admin can't be demoted", func(t *testing.T) {
+		if _, err := svc.UpdateUserRole(ctx, admin.ID, admin.ID, models.RoleVerifier); !errors.Is(err, ErrLastMasterAdmin) {
+			t.Fatalf("demoting the last admin error = %v, want %v", err, ErrLastMasterAdmin)
+		}
+		if n := testutil.Count(t, env.db, "users", "id = $1 AND role = 'master_admin'", admin.ID); n != 1 {
+			t.Error("the last master admin lost the role")
+		}
+
+		// With a second active admin, either may be demoted
+		second := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+		if _, err := svc.UpdateUserRole(ctx, admin.ID, second.ID, models.RoleVerifier); err != nil {
+			t.Fatalf("demoting one of two admins failed: %v", err)
+		}
+		if _, err := svc.UpdateUserRole(ctx, admin.ID, admin.ID, models.RoleVerifier); !errors.Is(err, ErrLastMasterAdmin) {
+			t.Errorf("demoting the now last admin error = %v, want %v", err, ErrLastMasterAdmin)
+		}
+	})
+}
+
+func TestAdminActionsRecordOneAuditEntry(t *testing.T) {
+	env := newTestEnv(t)
+	svc := NewAdminService(env.db, env.users, env.bills, env.verifications, env.walletTxs, repository.NewTokenDenylistRepository(nil), env.audit, env.cfg)
+	ctx := utils.WithRequestID(context.Background(), "req-audit-test")
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+
+	tests := []struct {
+		name   string
+		action models.AuditAction
+		run    func(userID string) error
+		before string // metadata->'before' as JSON
+		after  string // metadata->'after' as JSON
+	}{
+		{
+			"deactivate", models.AuditUserDeactivate,
+			func(userID string) error { _, err := svc.SetUserActive(ctx, admin.ID, userID, false); return err },
+			`{"is_active": true}`, `{"is_active": false}`,
+		},
+		{
+			"assign plan", models.AuditUserPlanChange,
+			func(userID string) error {
+				_, err := svc.SetVerificationPlan(ctx, admin.ID, userID, &models.SetVerificationPlanRequest{PlanType: "basic", MonthlyQuota: 100})
+				return err
+			},
+			`{"plan_type": null, "monthly_quota": 0}`, `{"plan_type": "basic", "monthly_quota": 100}`,
+		},
+		{
+			"change role", models.AuditUserRoleChange,
+			func(userID string) error {
+				_, err := svc.UpdateUserRole(ctx, admin.ID, userID, models.RolePublic)
+				return err
+			},
+			"", "",
+		},
+		{
+			"adjust wallet", models.AuditWalletAdjust,
+			func(userID string) error {
+				_, err := svc.AdjustWallet(ctx, admin.ID, userID, &models.AdjustWalletRequest{Delta: 10, Reason: "goodwill"})
+				return err
+			},
+			"", "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+			if err := tt.run(user.ID); err != nil {
+				t.Fatalf("%s failed: %v", tt.name, err)
+			}
+
+			if n := testutil.Count(t, env.db, "audit_log", "target_id = $1", user.ID); n != 1 {
+				t.Fatalf("audit entries = %d, want exactly 1", n)
+			}
+			if n := testutil.Count(t, env.db, "audit_log", "target_id = $1 AND actor_id = $2 AND action = $3 AND target_type = $4 AND request_id = $5",
+				user.ID, admin.ID, tt.action, models.AuditTargetUser, "req-audit-test"); n != 1 {
+				t.Errorf("no %s entry by the admin carrying the request ID", tt.action)
+			}
+			if tt.before != "" {
+				if n := testutil.Count(t, env.db, "audit_log", "target_id = $1 AND metadata->'before' = $2::jsonb AND metadata->'after' = $3::jsonb",
+					user.ID, tt.before, tt.after); n != 1 {
+					t.Errorf("audit metadata doesn't record the change from %s to %s", tt.before, tt.after)
+				}
+			}
+		})
+	}
+
+	t.Run("listed with filters", func(t *testing.T) {
+		action := models.AuditUserDeactivate
+		entries, total, err := svc.ListAuditLog(context.Background(), models.AuditFilter{ActorID: &admin.ID, Action: &action}, 1, 10)
+		if err != nil {
+			t.Fatalf("ListAuditLog failed: %v", err)
+		}
+		if total != 1 || len(entries) != 1 || entries[0].Action != action {
+			t.Errorf("ListAuditLog = %d of %d entries, want the one deactivation", len(entries), total)
+		}
+	})
+}
+
+func TestAdminActionRolledBackWhenAuditFails(t *testing.T) {
+	env := newTestEnv(t)
+	svc := NewAdminService(env.db, env.users, env.bills, env.verifications, env.walletTxs, repository.NewTokenDenylistRepository(nil), env.audit, env.cfg)
+	ctx := context.Background()
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+
+	env.db.MustExec(`
+		CREATE FUNCTION test_fail_audit() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'simulated audit failure';
+		END
+		$$ LANGUAGE plpgsql;
+		CREATE TRIGGER test_fail_audit BEFORE INSERT ON audit_log
+		FOR EACH ROW EXECUTE FUNCTION test_fail_audit();
+	`)
+	t.Cleanup(func() {
+		env.db.MustExec(`
+			DROP TRIGGER IF EXISTS test_fail_audit ON audit_log;
+			DROP FUNCTION IF EXISTS test_fail_audit();
+		`)
+	})
+
+	if _, err := svc.SetUserActive(ctx, admin.ID, user.ID, false); err == nil {
+		t.Error("SetUserActive succeeded although the audit entry couldn't be written")
+	}
+	if n := testutil.Count(t, env.db, "users", "id = $1 AND is_active = true", user.ID); n != 1 {
+		t.Error("user was deactivated without an audit entry")
+	}
+
+	if _, err := svc.AdjustWallet(ctx, admin.ID, user.ID, &models.AdjustWalletRequest{Delta: 10, Reason: "goodwill"}); err == nil {
+		t.Error("AdjustWallet succeeded although the audit entry couldn't be written")
+	}
+	if balance := env.balanceOf(t, user.ID); balance != 100 {
+		t.Errorf("balance = %.2f, want 100.00", balance)
+	}
+	if n := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1", user.ID); n != 0 {
+		t.Errorf("ledger entries = %d, want 0", n)
+	}
+}
@@ -2,72 +2,493 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
 	"github.com/ezhilnn/epr-backend/internal/models"
 	"github.com/ezhilnn/epr-backend/internal/repository"
 	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// Short codes are 10 characters from a 32-character alphabet, i.e. 50 random bits
+const (
+	shortCodeLength   = 10
+	shortCodeAttempts = 3
 )
 
 // BillService handles business logic for bills
 type BillService struct {
-	billRepo *repository.BillRepository
-	userRepo *repository.UserRepository
-	cfg      *config.Config
+	db               *database.DB
+	billRepo         *repository.BillRepository
+	aliasRepo        *repository.BillAliasRepository
+	userRepo         *repository.UserRepository
+	verificationRepo *repository.VerificationRepository
+	walletTxRepo     *repository.WalletTransactionRepository
+	idempotencyRepo  *repository.IdempotencyKeyRepository
+	outboxRepo       *repository.OutboxRepository
+	auditRepo        *repository.AuditRepository
+	notifier         *LowBalanceNotifier
+	cfg              *config.Config
 }
 
 // NewBillService creates a new bill service
 func NewBillService(
+	db *database.DB,
 	billRepo *repository.BillRepository,
+	aliasRepo *repository.BillAliasRepository,
 	userRepo *repository.UserRepository,
+	verificationRepo *repository.VerificationRepository,
+	walletTxRepo *repository.WalletTransactionRepository,
+	idempotencyRepo *repository.IdempotencyKeyRepository,
+	outboxRepo *repository.OutboxRepository,
+	auditRepo *repository.AuditRepository,
+	notifier *LowBalanceNotifier,
 	cfg *config.Config,
 ) *BillService {
 	return &BillService{
-		billRepo: billRepo,
-		userRepo: userRepo,
-		cfg:      cfg,
+		db:               db,
+		billRepo:         billRepo,
+		aliasRepo:        aliasRepo,
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		walletTxRepo:     walletTxRepo,
+		idempotencyRepo:  idempotencyRepo,
+		outboxRepo:       outboxRepo,
+		auditRepo:        auditRepo,
+		notifier:         notifier,
+		cfg:              cfg,
 	}
 }
 
 // CreateBill generates a new bill
 func (s *BillService) CreateBill(ctx context.Context, userID string, req *models.CreateBillRequest) (*models.Bill, error) {
-	// Get user details
-	user, err := s.userRepo.GetByID(ctx, userID)
+	return s.createBill(ctx, userID, req, "", "")
+}
+
+// CreateBillIdempotent generates a new bill unless the user already created one with the same key
+// Returns the original bill and replayed=true when the key was seen within the configured window,
+// so clients retrying after a timeout are neither charged twice nor given a duplicate bill.
+// Reusing a key with a different request fails with ErrIdempotencyKeyMismatch
+func (s *BillService) CreateBillIdempotent(ctx context.Context, userID, idempotencyKey string, req *models.CreateBillRequest) (*models.Bill, bool, error) {
+	requestHash, err := idempotencyRequestHash(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, false, err
 	}
 
-	// Check if user has permission to generate bills
-	if user.Role != models.RoleInstitutionUser && user.Role != models.RoleInstitutionAdmin && user.Role != models.RoleMasterAdmin {
-		return nil, fmt.Errorf("only institutions can generate bills")
+	if bill, err := s.getIdempotentBill(ctx, userID, idempotencyKey, requestHash); bill != nil || err != nil {
+		return bill, bill != nil, err
 	}
 
-	// Check KYC status for institutions
-	if (user.Role == models.RoleInstitutionUser || user.Role == models.RoleInstitutionAdmin) && user.KYCStatus != models.KYCApproved {
-		return nil, fmt.Errorf("KYC verification required to generate bills")
+	bill, err := s.createBill(ctx, userID, req, idempotencyKey, requestHash)
+	if errors.Is(err, errIdempotencyKeyUsed) {
+		// A concurrent request with the same key won the claim - hand back its bill
+		bill, err = s.getIdempotentBill(ctx, userID, idempotencyKey, requestHash)
+		if err == nil && bill == nil {
+			err = ErrIdempotencyKeyInUse
+		}
+		return bill, bill != nil, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return bill, false, nil
+}
+
+// idempotencyRequestHash fingerprints a bill creation request so a replayed key can be matched to it
+// encoding/json writes struct fields in declaration order and map keys sorted, so equal requests hash equally
+func idempotencyRequestHash(req *models.CreateBillRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// getIdempotentBill returns the bill already created with a key, or nil if there is none
+// Keys stored before request hashes were recorded replay without the comparison
+func (s *BillService) getIdempotentBill(ctx context.Context, userID, idempotencyKey, requestHash string) (*models.Bill, error) {
+	billID, storedHash, err := s.idempotencyRepo.Lookup(ctx, userID, idempotencyKey, s.cfg.App.IdempotencyKeyTTL)
+	if err != nil {
+		return nil, err
 	}
+	if storedHash != "" && storedHash != requestHash {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+	if billID == "" {
+		return nil, nil
+	}
+
+	bill, err := s.billRepo.GetByID(ctx, billID)
+	if err != nil {
+		if errors.Is(err, ErrBillNotFound) {
+			// Original bill was deleted - the key can't be reused until it expires
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return nil, fmt.Errorf("failed to get bill: %w", err)
+	}
+
+	return bill, nil
+}
 
-	// Check wallet balance
+// createBill generates a new bill, claiming idempotencyKey for the request with requestHash in the same transaction when set
+func (s *BillService) createBill(ctx context.Context, userID string, req *models.CreateBillRequest, idempotencyKey, requestHash string) (*models.Bill, error) {
+	user, err := s.getBillIssuer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check wallet balance (early exit - re-checked under lock below)
 	generationFee := s.cfg.Pricing.BillGenerationFee
 	if user.WalletBalance < generationFee {
-		return nil, fmt.Errorf("insufficient wallet balance. Required: ₹%.2f, Available: ₹%.2f", generationFee, user.WalletBalance)
+		return nil, &InsufficientBalanceError{Required: generationFee, Available: user.WalletBalance}
+	}
+
+	bill, err := s.buildBill(user, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject accidental double-submits (early exit - re-checked under lock below)
+	// Retries with an idempotency key skip this so they replay the original bill instead
+	checkDuplicate := !req.AllowDuplicate && s.cfg.App.DuplicateBillWindow > 0
+	duplicateSince := time.Now().Add(-s.cfg.App.DuplicateBillWindow)
+	if checkDuplicate && idempotencyKey == "" {
+		if err := duplicateBillError(s.billRepo.GetByHashAndIssuer(ctx, user.ID, *bill.ContentHash, duplicateSince)); err != nil {
+			return nil, err
+		}
 	}
 
 	// Generate bill number
-	billNumber, err := s.billRepo.GenerateBillNumber(ctx, req.BillType)
+	billNumber, err := s.billRepo.GenerateBillNumber(ctx, req.BillType, s.cfg.Location())
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate bill number: %w", err)
 	}
+	bill.BillNumber = billNumber
+
+	// Queue the recipient email with the bill so it is sent if and only if the bill is created
+	var notification *models.OutboxMessage
+	if req.NotifyRecipient {
+		bill.NotificationStatus, notification, err = recipientNotification(bill.BillNumber, req.BillData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Save the bill and deduct the fee atomically - either both commit or neither does
+	var newBalance float64
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Claim the idempotency key first - a concurrent request with the same key
+		// blocks here until this transaction finishes, then sees the key as used
+		if idempotencyKey != "" {
+			claimed, err := s.idempotencyRepo.ClaimTx(ctx, tx, user.ID, idempotencyKey, requestHash, s.cfg.App.IdempotencyKeyTTL)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				return errIdempotencyKeyUsed
+			}
+		}
+
+		if checkDuplicate {
+			// Lock the issuer so an identical concurrent request waits here, then sees this bill
+			if _, err := s.userRepo.GetByIDForUpdateTx(ctx, tx, user.ID); err != nil {
+				return err
+			}
+			if err := duplicateBillError(s.billRepo.GetByHashAndIssuerTx(ctx, tx, user.ID, *bill.ContentHash, duplicateSince)); err != nil {
+				return err
+			}
+		}
+
+		// Save bill to database
+		if err := s.billRepo.CreateTx(ctx, tx, bill); err != nil {
+			return fmt.Errorf("failed to save bill: %w", err)
+		}
+
+		if idempotencyKey != "" {
+			if err := s.idempotencyRepo.SetBillIDTx(ctx, tx, user.ID, idempotencyKey, bill.ID); err != nil {
+				return err
+			}
+		}
+
+		if notification != nil {
+			if err := s.outboxRepo.CreateTx(ctx, tx, notification); err != nil {
+				return err
+			}
+		}
+
+		// Deduct wallet balance - the guarded update can't double-spend under concurrent creations
+		var err error
+		newBalance, err = s.userRepo.AdjustWalletBalanceTx(ctx, tx, user.ID, -generationFee)
+		if err != nil {
+			return debitError(err, generationFee, newBalance)
+		}
+
+		// Record the fee in the wallet statement
+		ledgerEntry := &models.WalletTransaction{
+			UserID:       user.ID,
+			Type:         models.WalletTxBillFee,
+			Amount:       -generationFee,
+			BalanceAfter: newBalance,
+			ReferenceID:  &bill.ID,
+		}
+		if err := s.walletTxRepo.CreateTx(ctx, tx, ledgerEntry); err != nil {
+			return fmt.Errorf("failed to record wallet transaction: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Warn the issuer if this charge left the wallet running low
+	s.notifier.Check(user.ID, newBalance)
+
+	// Bill stays pending until BlockchainWorker commits its hash
+
+	return bill, nil
+}
+
+// duplicateBillError turns the result of a recent identical bill lookup into a DuplicateBillError
+// Returns nil when there is no such bill
+func duplicateBillError(existing *models.Bill, err error) error {
+	if errors.Is(err, repository.ErrBillNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &DuplicateBillError{BillID: existing.ID, BillNumber: existing.BillNumber}
+}
+
+// billContentHash fingerprints what the issuer submitted: bill type, amount and bill_data without the
+// _metadata and _revision blocks added here, so two identical requests hash the same
+func billContentHash(billType models.BillType, amount float64, billData map[string]interface{}) (string, error) {
+	content := make(map[string]interface{}, len(billData))
+	for key, value := range billData {
+		if key != "_metadata" && key != "_revision" {
+			content[key] = value
+		}
+	}
+
+	return utils.GenerateBillHash(map[string]interface{}{
+		"bill_type": string(billType),
+		"amount":    amount,
+		"bill_data": content,
+	})
+}
 
+// recipientNotification builds the outbox message that emails a new bill to the recipient_email in its data
+// Returns no message when the address is missing or invalid; the bill is still created
+func recipientNotification(billNumber string, billData map[string]interface{}) (models.RecipientNotificationStatus, *models.OutboxMessage, error) {
+	email, _ := billData["recipient_email"].(string)
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return models.NotificationMissingEmail, nil, nil
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return models.NotificationInvalidEmail, nil, nil
+	}
+
+	notification, err := models.NewOutboxMessage(models.OutboxBillEmail, models.BillEmailMessage{
+		BillNumber:     billNumber,
+		RecipientEmail: email,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode recipient email: %w", err)
+	}
+
+	return models.NotificationQueued, notification, nil
+}
+
+// CreateBillsBulk generates a batch of bills in a single transaction
+// Invalid items are reported and skipped; the valid ones are created together with a single
+// wallet debit and ledger entry, or not at all if the wallet can't cover the whole batch
+func (s *BillService) CreateBillsBulk(ctx context.Context, userID string, reqs []models.CreateBillRequest) (*models.BulkCreateBillResponse, error) {
+	if maxItems := s.cfg.App.BulkBillMaxItems; maxItems > 0 && len(reqs) > maxItems {
+		return nil, &BulkBatchTooLargeError{Max: maxItems, Got: len(reqs)}
+	}
+
+	user, err := s.getBillIssuer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.BulkCreateBillResponse{
+		Total:       len(reqs),
+		Results:     make([]*models.BulkBillResult, len(reqs)),
+		BalanceLeft: user.WalletBalance,
+	}
+
+	// Validate every item up front so nothing is charged for rows that can't be created
+	bills := make([]*models.Bill, 0, len(reqs))
+	billIndexes := make([]int, 0, len(reqs))
+	for i := range reqs {
+		response.Results[i] = &models.BulkBillResult{Index: i}
+
+		if err := validateCreateBillRequest(&reqs[i]); err != nil {
+			response.Results[i].Error = err.Error()
+			response.Failed++
+			continue
+		}
+
+		bill, err := s.buildBill(user, &reqs[i])
+		if err != nil {
+			response.Results[i].Error = err.Error()
+			response.Failed++
+			continue
+		}
+
+		bills = append(bills, bill)
+		billIndexes = append(billIndexes, i)
+	}
+
+	if len(bills) == 0 {
+		return response, nil
+	}
+
+	// Check wallet balance for the whole batch (early exit - re-checked under lock below)
+	totalFee := s.cfg.Pricing.BillGenerationFee * float64(len(bills))
+	if user.WalletBalance < totalFee {
+		return nil, &InsufficientBalanceError{Required: totalFee, Available: user.WalletBalance}
+	}
+
+	var newBalance float64
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		for _, bill := range bills {
+			// Numbered inside the transaction so each sees the bills inserted before it
+			billNumber, err := s.billRepo.GenerateBillNumberTx(ctx, tx, bill.BillType, s.cfg.Location())
+			if err != nil {
+				return fmt.Errorf("failed to generate bill number: %w", err)
+			}
+			bill.BillNumber = billNumber
+
+			if err := s.billRepo.CreateTx(ctx, tx, bill); err != nil {
+				return fmt.Errorf("failed to save bill %s: %w", billNumber, err)
+			}
+		}
+
+		// Deduct the whole batch at once
+		var err error
+		newBalance, err = s.userRepo.AdjustWalletBalanceTx(ctx, tx, user.ID, -totalFee)
+		if err != nil {
+			return debitError(err, totalFee, newBalance)
+		}
+
+		description := fmt.Sprintf("Bulk generation of %d bills", len(bills))
+		ledgerEntry := &models.WalletTransaction{
+			UserID:       user.ID,
+			Type:         models.WalletTxBillFee,
+			Amount:       -totalFee,
+			BalanceAfter: newBalance,
+			Description:  &description,
+		}
+		if err := s.walletTxRepo.CreateTx(ctx, tx, ledgerEntry); err != nil {
+			return fmt.Errorf("failed to record wallet transaction: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, bill := range bills {
+		result := response.Results[billIndexes[i]]
+		result.Success = true
+		result.BillID = bill.ID
+		result.BillNumber = bill.BillNumber
+	}
+	response.Created = len(bills)
+	response.FeeCharged = totalFee
+	response.BalanceLeft = newBalance
+
+	s.notifier.Check(user.ID, newBalance)
+
+	return response, nil
+}
+
+// getBillIssuer loads the user and checks they are allowed to generate bills
+func (s *BillService) getBillIssuer(ctx context.Context, userID string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Check if user has permission to generate bills
+	if user.Role != models.RoleInstitutionUser && user.Role != models.RoleInstitutionAdmin && user.Role != models.RoleMasterAdmin {
+		return nil, ErrNotInstitution
+	}
+
+	// Check KYC status for institutions
+	if (user.Role == models.RoleInstitutionUser || user.Role == models.RoleInstitutionAdmin) && user.KYCStatus != models.KYCApproved {
+		return nil, ErrKYCRequired
+	}
+
+	// Optionally require a verified email for institutions
+	if s.cfg.App.RequireVerifiedEmail && user.Role != models.RoleMasterAdmin && !user.IsEmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	return user, nil
+}
+
+// buildBill turns a request into an unsaved bill with metadata and hash filled in
+// BillNumber is left empty for the caller to assign
+func (s *BillService) buildBill(user *models.User, req *models.CreateBillRequest) (*models.Bill, error) {
 	// Parse issue date
 	issueDate, err := time.Parse("2006-01-02", req.IssueDate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid date format. Use YYYY-MM-DD")
 	}
 
+	// Parse optional validity window
+	var validUntil *time.Time
+	if req.ValidUntil != "" {
+		vu, err := time.Parse("2006-01-02", req.ValidUntil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid valid_until format. Use YYYY-MM-DD")
+		}
+		if vu.Before(issueDate) {
+			return nil, fmt.Errorf("valid_until can't be before issue_date")
+		}
+		validUntil = &vu
+	}
+
+	currency, err := s.resolveCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	needsReview, err := s.checkBillAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AllowPreview && !req.AccessLevel.SupportsPreview() {
+		return nil, ErrPreviewNotApplicable
+	}
+
+	// Check the type-specific fields before the data is hashed and stored
+	if err := validateBillData(req.BillType, req.BillData); err != nil {
+		return nil, err
+	}
+
+	contentHash, err := billContentHash(req.BillType, req.Amount, req.BillData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate content hash: %w", err)
+	}
+
 	// Add metadata to bill data
 	enrichedBillData := req.BillData
 	enrichedBillData["_metadata"] = map[string]interface{}{
@@ -89,43 +510,95 @@ func (s *BillService) CreateBill(ctx context.Context, userID string, req *models
 		return nil, fmt.Errorf("failed to generate hash: %w", err)
 	}
 
-	// Create bill object
-	bill := &models.Bill{
-		BillNumber:       billNumber,
+	return &models.Bill{
 		BillType:         req.BillType,
 		AccessLevel:      req.AccessLevel,
+		AllowPreview:     req.AllowPreview,
 		IssuerID:         user.ID,
 		IssuerName:       user.OrganizationName,
 		BillData:         billDataJSON,
 		Amount:           req.Amount,
-		Currency:         "INR",
+		Currency:         currency,
+		NeedsReview:      needsReview,
 		IssueDate:        issueDate,
+		ValidUntil:       validUntil,
 		DataHash:         dataHash,
+		ContentHash:      &contentHash,
 		BlockchainStatus: models.BlockchainPending,
 		IsActive:         true,
 		IsDeleted:        false,
+	}, nil
+}
+
+// checkBillAmount rejects amounts above Pricing.MaxBillAmount and reports whether an amount is
+// above Pricing.ReviewBillAmount, so a mistyped amount is caught before it skews fees and dashboards
+func (s *BillService) checkBillAmount(amount float64) (needsReview bool, err error) {
+	if limit := s.cfg.Pricing.MaxBillAmount; limit > 0 && amount > limit {
+		return false, fmt.Errorf("%w (%.2f)", ErrBillAmountTooLarge, limit)
 	}
 
-	// Start transaction
-	// Note: In production, you'd use proper transaction handling
-	// For now, we'll do operations sequentially
+	review := s.cfg.Pricing.ReviewBillAmount
+	return review > 0 && amount > review, nil
+}
 
-	// Save bill to database
-	if err := s.billRepo.Create(ctx, bill); err != nil {
-		return nil, fmt.Errorf("failed to save bill: %w", err)
+// resolveCurrency normalizes a requested bill currency, falling back to the configured default
+// The currency is only stored for display; verification fees stay in the platform base currency
+func (s *BillService) resolveCurrency(requested string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(requested))
+	if code == "" {
+		return s.cfg.App.DefaultCurrency, nil
 	}
 
-	// Deduct wallet balance
-	newBalance := user.WalletBalance - generationFee
-	if err := s.userRepo.UpdateWalletBalance(ctx, user.ID, newBalance); err != nil {
-		// In production, you'd rollback the bill creation here
-		return nil, fmt.Errorf("failed to deduct wallet balance: %w", err)
+	for _, supported := range s.cfg.App.SupportedCurrencies {
+		if code == supported {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q. Supported currencies: %s", ErrUnsupportedCurrency, requested, strings.Join(s.cfg.App.SupportedCurrencies, ", "))
+}
+
+// validateCreateBillRequest checks a bill request that didn't go through request binding
+// Mirrors the binding tags on CreateBillRequest plus the enum values the database accepts
+func validateCreateBillRequest(req *models.CreateBillRequest) error {
+	if !req.BillType.IsValid() {
+		return fmt.Errorf("invalid bill_type %q", req.BillType)
+	}
+	if !req.AccessLevel.IsValid() {
+		return fmt.Errorf("invalid access_level %q", req.AccessLevel)
+	}
+	if req.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	if req.IssueDate == "" {
+		return fmt.Errorf("issue_date is required")
+	}
+	if req.BillData == nil {
+		return fmt.Errorf("bill_data is required")
 	}
+	if req.IssuerGSTIN != "" {
+		if err := utils.ValidateGSTIN(req.IssuerGSTIN); err != nil {
+			return fmt.Errorf("issuer_gstin is not a valid GSTIN: it %w", err)
+		}
+	}
+	return nil
+}
 
-	// TODO: Queue blockchain commitment (will implement with RabbitMQ later)
-	// For now, we'll mark it as pending
+// validateBillData checks bill_data against the schema for its bill type
+func validateBillData(billType models.BillType, billData map[string]interface{}) error {
+	schema, ok := models.SchemaFor(billType)
+	if !ok {
+		return fmt.Errorf("invalid bill_type %q", billType)
+	}
+	if fieldErrors := schema.Validate(billData); len(fieldErrors) > 0 {
+		return &BillDataValidationError{BillType: billType, Fields: fieldErrors}
+	}
+	return nil
+}
 
-	return bill, nil
+// AdminSearchBills searches bills across all issuers for master admins
+func (s *BillService) AdminSearchBills(ctx context.Context, filter models.AdminBillFilter, page, pageSize int) ([]*models.Bill, int, error) {
+	offset := (page - 1) * pageSize
+	return s.billRepo.AdminSearch(ctx, filter, pageSize, offset)
 }
 
 // GetBillByID retrieves a bill by ID
@@ -138,27 +611,149 @@ func (s *BillService) GetBillByID(ctx context.Context, userID, billID string, us
 	// Check access permissions
 	canAccess := s.canAccessBill(userID, userRole, bill)
 	if !canAccess {
-		return nil, fmt.Errorf("access denied to this bill")
+		return nil, ErrBillAccessDenied
 	}
 
 	return bill, nil
 }
 
 // GetBillByNumber retrieves a bill by bill number
+// billNumber may also be the bill's short code
 func (s *BillService) GetBillByNumber(ctx context.Context, billNumber string) (*models.Bill, error) {
-	return s.billRepo.GetByBillNumber(ctx, billNumber)
+	return findBillByReference(ctx, s.billRepo, s.aliasRepo, billNumber)
+}
+
+// VerifyDataHash checks a copy of a bill's data against the bill's registered hash, the same check
+// as utils.VerifyBillHash. It's free: the caller supplies the data, and the only thing revealed is
+// the hash, which is already printed on the bill's PDF
+func (s *BillService) VerifyDataHash(ctx context.Context, billNumber string, billData map[string]interface{}) (*models.VerifyHashResponse, error) {
+	bill, err := s.GetBillByNumber(ctx, billNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	computedHash, err := utils.GenerateBillHash(billData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash bill data: %w", err)
+	}
+
+	response := &models.VerifyHashResponse{
+		BillNumber:   bill.BillNumber,
+		Match:        computedHash == bill.DataHash,
+		ComputedHash: computedHash,
+		StoredHash:   bill.DataHash,
+		Message:      "The bill data matches the registered hash. It has not been altered.",
+	}
+	if !response.Match {
+		response.Message = "The bill data does not match the registered hash. It may have been altered."
+	}
+
+	return response, nil
+}
+
+// CreateShortLink gives the issuer's bill a new random short code and returns its short verification link
+// Any previous short code stops working
+func (s *BillService) CreateShortLink(ctx context.Context, userID, billID string) (*models.ShortLinkResponse, error) {
+	bill, err := s.billRepo.GetByID(ctx, billID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bill.IssuerID != userID {
+		return nil, ErrNotBillOwner
+	}
+
+	// Collisions are vanishingly rare at this length, but retry a few times rather than fail
+	var alias *models.BillAlias
+	for attempt := 1; ; attempt++ {
+		code, err := utils.GenerateShortCode(shortCodeLength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate short code: %w", err)
+		}
+
+		alias, err = s.aliasRepo.Replace(ctx, bill.ID, code)
+		if errors.Is(err, repository.ErrShortCodeTaken) && attempt < shortCodeAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	qrCode, err := s.GenerateQRCode(alias.ShortCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	return &models.ShortLinkResponse{
+		BillID:           bill.ID,
+		BillNumber:       bill.BillNumber,
+		ShortCode:        alias.ShortCode,
+		VerificationLink: utils.GenerateVerificationLink(alias.ShortCode, s.cfg.App.FrontendURL),
+		QRCode:           qrCode,
+		CreatedAt:        alias.CreatedAt,
+	}, nil
+}
+
+// VerificationRef returns what the bill's verification link and QR code should carry:
+// its short code when it has one, otherwise its bill number
+func (s *BillService) VerificationRef(ctx context.Context, bill *models.Bill) string {
+	return verificationRef(ctx, s.aliasRepo, bill)
+}
+
+// verificationRef returns the bill's short code, or its bill number when it has none
+// Lookup errors are logged and fall back to the bill number
+func verificationRef(ctx context.Context, aliasRepo *repository.BillAliasRepository, bill *models.Bill) string {
+	alias, err := aliasRepo.GetByBillID(ctx, bill.ID)
+	if err != nil {
+		log.Printf("⚠️  Failed to look up short code for bill %s: %v", bill.BillNumber, err)
+		return bill.BillNumber
+	}
+	if alias == nil {
+		return bill.BillNumber
+	}
+	return alias.ShortCode
+}
+
+// findBillByReference looks a bill up by bill number, falling back to treating ref as a short code
+func findBillByReference(ctx context.Context, billRepo *repository.BillRepository, aliasRepo *repository.BillAliasRepository, ref string) (*models.Bill, error) {
+	bill, err := billRepo.GetByBillNumber(ctx, ref)
+	if errors.Is(err, repository.ErrBillNotFound) {
+		return aliasRepo.GetBillByShortCode(ctx, ref)
+	}
+	return bill, err
+}
+
+// ListUserBillsAfter lists a page of the user's bills using keyset pagination
+// Returns the cursor for the next page, or nil when this is the last page
+func (s *BillService) ListUserBillsAfter(ctx context.Context, userID string, includeDeleted bool, cursor *models.PageCursor, pageSize int) ([]*models.Bill, *models.PageCursor, error) {
+	// Fetch one extra row to learn whether another page follows
+	bills, err := s.billRepo.ListByIssuerAfter(ctx, userID, includeDeleted, cursor, pageSize+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list bills: %w", err)
+	}
+
+	if len(bills) <= pageSize {
+		return bills, nil, nil
+	}
+
+	bills = bills[:pageSize]
+	last := bills[len(bills)-1]
+	return bills, &models.PageCursor{Timestamp: last.CreatedAt, ID: last.ID}, nil
 }
 
 // ListUserBills lists bills for a user with pagination
-func (s *BillService) ListUserBills(ctx context.Context, userID string, page, pageSize int) ([]*models.Bill, int, error) {
+// includeDeleted adds the user's soft-deleted bills, so issuers can review what they deleted and why
+func (s *BillService) ListUserBills(ctx context.Context, userID string, includeDeleted bool, page, pageSize int) ([]*models.Bill, int, error) {
 	offset := (page - 1) * pageSize
 	
-	bills, err := s.billRepo.ListByIssuer(ctx, userID, pageSize, offset)
+	bills, err := s.billRepo.ListByIssuer(ctx, userID, includeDeleted, pageSize, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list bills: %w", err)
 	}
 
-	total, err := s.billRepo.CountByIssuer(ctx, userID)
+	total, err := s.billRepo.CountByIssuer(ctx, userID, includeDeleted)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count bills: %w", err)
 	}
@@ -166,41 +761,318 @@ func (s *BillService) ListUserBills(ctx context.Context, userID string, page, pa
 	return bills, total, nil
 }
 
+// GetBillTrends buckets the bills the user generated over the last periods days or weeks, oldest first
+// With running set, each bucket also carries the totals so far
+func (s *BillService) GetBillTrends(ctx context.Context, userID, interval string, periods int, running bool) ([]*models.BillTrendBucket, error) {
+	// A window of (periods-1) weeks plus a day starts in the right week once aligned to Monday
+	days := periods
+	if interval == models.TrendIntervalWeek {
+		days = (periods-1)*7 + 1
+	}
+	from, to, step := trendWindow(interval, days, s.cfg.Location())
+
+	rows, err := s.billRepo.TimeSeriesByIssuer(ctx, userID, interval, from, to, s.cfg.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	byStart := make(map[string]models.BillTrendRow, len(rows))
+	for _, row := range rows {
+		byStart[row.Bucket.Format("2006-01-02")] = row
+	}
+
+	var buckets []*models.BillTrendBucket
+	runningCount, runningAmount := 0, 0.0
+	for _, start := range trendBucketStarts(from, to, step) {
+		row := byStart[start]
+		bucket := &models.BillTrendBucket{
+			Start:  start,
+			Count:  row.Count,
+			Amount: row.Amount,
+		}
+		if running {
+			runningCount += row.Count
+			runningAmount += row.Amount
+			count, amount := runningCount, runningAmount
+			bucket.RunningCount = &count
+			bucket.RunningAmount = &amount
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, nil
+}
+
 // GetUserStats retrieves statistics for a user's bills
 func (s *BillService) GetUserStats(ctx context.Context, userID string) (*models.BillStats, error) {
-	return s.billRepo.GetStatsByIssuer(ctx, userID)
+	return s.billRepo.GetStatsByIssuer(ctx, userID, utils.StartOfMonth(time.Now(), s.cfg.Location()))
+}
+
+// GetBillTypeBreakdown returns the user's most generated bill type and counts for every type
+func (s *BillService) GetBillTypeBreakdown(ctx context.Context, userID string) (models.BillType, map[models.BillType]int, error) {
+	topType, _, err := s.billRepo.TopBillTypeByIssuer(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	breakdown, err := s.billRepo.CountByTypeForIssuer(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return topType, breakdown, nil
+}
+
+// UpdateBill corrects a bill's data or amount, archiving the previous version
+// The bill keeps its bill_number so shared verification links keep working.
+// Changing the amount of a bill that has already been verified requires force.
+func (s *BillService) UpdateBill(ctx context.Context, userID, billID string, req *models.UpdateBillRequest, force bool) (*models.Bill, error) {
+	var bill *models.Bill
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Lock the bill so concurrent edits produce a clean revision chain
+		var err error
+		bill, err = s.billRepo.GetByIDForUpdateTx(ctx, tx, billID)
+		if err != nil {
+			return err
+		}
+
+		if bill.IssuerID != userID {
+			return ErrNotBillOwner
+		}
+
+		amountChanged := req.Amount != nil && *req.Amount != bill.Amount
+		if amountChanged && !force {
+			verifications, err := s.verificationRepo.CountVerificationsByBill(ctx, bill.ID)
+			if err != nil {
+				return err
+			}
+			if verifications > 0 {
+				return ErrBillHasVerifications
+			}
+		}
+
+		// Archive the current version before overwriting it
+		revision := &models.BillRevision{
+			BillID:         bill.ID,
+			BillData:       bill.BillData,
+			DataHash:       bill.DataHash,
+			Amount:         bill.Amount,
+			BlockchainTxID: bill.BlockchainTxID,
+			EditedBy:       &userID,
+			EditReason:     &req.Reason,
+		}
+		if err := s.billRepo.CreateRevisionTx(ctx, tx, revision); err != nil {
+			return err
+		}
+
+		// Build the new content, carrying over the original issuance metadata
+		var currentData map[string]interface{}
+		if err := json.Unmarshal(bill.BillData, &currentData); err != nil {
+			return fmt.Errorf("failed to read bill data: %w", err)
+		}
+
+		newData := currentData
+		if req.BillData != nil {
+			if err := validateBillData(bill.BillType, req.BillData); err != nil {
+				return err
+			}
+			newData = req.BillData
+			if metadata, ok := currentData["_metadata"]; ok {
+				newData["_metadata"] = metadata
+			}
+		}
+		newData["_revision"] = map[string]interface{}{
+			"number":     revision.RevisionNumber + 1,
+			"revised_at": time.Now().UTC(),
+			"revised_by": userID,
+			"reason":     req.Reason,
+		}
+
+		billDataJSON, err := json.Marshal(newData)
+		if err != nil {
+			return fmt.Errorf("failed to marshal bill data: %w", err)
+		}
+
+		dataHash, err := utils.GenerateBillHash(newData)
+		if err != nil {
+			return fmt.Errorf("failed to generate hash: %w", err)
+		}
+
+		bill.BillData = billDataJSON
+		bill.DataHash = dataHash
+		if req.Amount != nil {
+			needsReview, err := s.checkBillAmount(*req.Amount)
+			if err != nil {
+				return err
+			}
+			bill.Amount = *req.Amount
+			bill.NeedsReview = needsReview
+		}
+
+		contentHash, err := billContentHash(bill.BillType, bill.Amount, newData)
+		if err != nil {
+			return fmt.Errorf("failed to generate content hash: %w", err)
+		}
+		bill.ContentHash = &contentHash
+
+		return s.billRepo.UpdateContentTx(ctx, tx, bill)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.billRepo.InvalidateCache(ctx, bill.BillNumber)
+
+	return bill, nil
+}
+
+// ListBillsForExport returns the issuer's non-deleted bills issued between the dates (inclusive, nil for open)
+// Fails with BillExportTooLargeError rather than truncating when more than App.BillExportMaxBills match
+func (s *BillService) ListBillsForExport(ctx context.Context, userID string, startDate, endDate *time.Time) ([]*models.Bill, error) {
+	count, err := s.billRepo.CountSearch(ctx, userID, "", nil, startDate, endDate, false)
+	if err != nil {
+		return nil, err
+	}
+	if count > s.cfg.App.BillExportMaxBills {
+		return nil, &BillExportTooLargeError{Max: s.cfg.App.BillExportMaxBills, Got: count}
+	}
+
+	return s.billRepo.Search(ctx, userID, nil, startDate, endDate, false, s.cfg.App.BillExportMaxBills, 0)
+}
+
+// ListBillRevisions retrieves the archived versions of a bill (issuer only)
+func (s *BillService) ListBillRevisions(ctx context.Context, userID, billID string) ([]*models.BillRevision, error) {
+	bill, err := s.billRepo.GetByID(ctx, billID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bill.IssuerID != userID {
+		return nil, ErrNotBillOwner
+	}
+
+	return s.billRepo.ListRevisions(ctx, billID)
+}
+
+// RestoreBill undoes a soft delete so the bill is verifiable again
+// Only the issuer or a master admin may restore a bill
+func (s *BillService) RestoreBill(ctx context.Context, userID string, userRole models.UserRole, billID string) (*models.Bill, error) {
+	bill, err := s.billRepo.GetByIDIncludingDeleted(ctx, billID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bill.IssuerID != userID && userRole != models.RoleMasterAdmin {
+		return nil, ErrNotBillOwner
+	}
+
+	if !bill.IsDeleted {
+		return nil, ErrBillNotDeleted
+	}
+
+	if userRole == models.RoleMasterAdmin {
+		err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+			if err := s.billRepo.RestoreTx(ctx, tx, billID); err != nil {
+				return err
+			}
+			return s.auditRepo.RecordTx(ctx, tx, userID, models.AuditBillRestore, models.AuditTargetBill, billID, map[string]interface{}{
+				"bill_number": bill.BillNumber,
+				"issuer_id":   bill.IssuerID,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.billRepo.InvalidateCache(ctx, bill.BillNumber)
+	} else if err := s.billRepo.Restore(ctx, billID); err != nil {
+		return nil, err
+	}
+
+	return s.billRepo.GetByID(ctx, billID)
 }
 
 // DeleteBill soft deletes a bill
-func (s *BillService) DeleteBill(ctx context.Context, userID, billID, reason string) error {
+// Verified bills may have been relied on by third parties, so they can only be deleted
+// by a master admin passing force
+func (s *BillService) DeleteBill(ctx context.Context, userID string, userRole models.UserRole, billID, reason string, force bool) error {
 	// Get bill
 	bill, err := s.billRepo.GetByID(ctx, billID)
 	if err != nil {
 		return err
 	}
 
+	isMasterAdmin := userRole == models.RoleMasterAdmin
+
 	// Check if user owns the bill
-	if bill.IssuerID != userID {
-		return fmt.Errorf("you can only delete your own bills")
+	if bill.IssuerID != userID && !isMasterAdmin {
+		return ErrNotBillOwner
 	}
 
 	// Check if bill has been verified
-	// TODO: Check verifications count when verification system is implemented
-	// For now, we'll allow deletion
+	if !(force && isMasterAdmin) {
+		count, err := s.verificationRepo.CountVerificationsByBill(ctx, billID)
+		if err != nil {
+			return fmt.Errorf("failed to count verifications: %w", err)
+		}
+		if count > 0 {
+			return ErrBillVerifiedNoDelete
+		}
+	}
 
-	return s.billRepo.SoftDelete(ctx, billID, reason)
+	if !isMasterAdmin {
+		return s.billRepo.SoftDelete(ctx, billID, reason)
+	}
+
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := s.billRepo.SoftDeleteTx(ctx, tx, billID, reason); err != nil {
+			return err
+		}
+		return s.auditRepo.RecordTx(ctx, tx, userID, models.AuditBillDelete, models.AuditTargetBill, billID, map[string]interface{}{
+			"bill_number": bill.BillNumber,
+			"issuer_id":   bill.IssuerID,
+			"reason":      reason,
+			"force":       force,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.billRepo.InvalidateCache(ctx, bill.BillNumber)
+
+	return nil
 }
 
-// SearchBills searches bills with filters
+// SearchBills searches bills with filters and returns the page plus the total match count
+// A non-empty text also matches recipient name, description, bill number and issuer name
 func (s *BillService) SearchBills(
 	ctx context.Context,
 	userID string,
+	text string,
 	billType *models.BillType,
 	startDate, endDate *time.Time,
+	includeDeleted bool,
 	page, pageSize int,
-) ([]*models.Bill, error) {
+) ([]*models.Bill, int, error) {
 	offset := (page - 1) * pageSize
-	return s.billRepo.Search(ctx, userID, billType, startDate, endDate, pageSize, offset)
+
+	var bills []*models.Bill
+	var err error
+	if text != "" {
+		bills, err = s.billRepo.SearchByText(ctx, userID, text, billType, startDate, endDate, includeDeleted, pageSize, offset)
+	} else {
+		bills, err = s.billRepo.Search(ctx, userID, billType, startDate, endDate, includeDeleted, pageSize, offset)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.billRepo.CountSearch(ctx, userID, text, billType, startDate, endDate, includeDeleted)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bills, total, nil
 }
 
 // canAccessBill checks if a user can access a bill
@@ -254,6 +1126,7 @@ func (s *BillService) ConvertToResponse(bill *models.Bill, accessLevel string) *
 		BillNumber:       bill.BillNumber,
 		BillType:         string(bill.BillType),
 		AccessLevel:      string(bill.AccessLevel),
+		AllowPreview:     bill.AllowPreview,
 		IssuerName:       bill.IssuerName,
 		Amount:           bill.Amount,
 		Currency:         bill.Currency,
@@ -263,6 +1136,10 @@ func (s *BillService) ConvertToResponse(bill *models.Bill, accessLevel string) *
 		CreatedAt:        bill.CreatedAt.Format(time.RFC3339),
 	}
 
+	if bill.ValidUntil != nil {
+		response.ValidUntil = bill.ValidUntil.Format("2006-01-02")
+	}
+
 	// Include bill data only if user has appropriate access
 	if accessLevel == "full" {
 		var billData map[string]interface{}
@@ -275,23 +1152,56 @@ func (s *BillService) ConvertToResponse(bill *models.Bill, accessLevel string) *
 }
 func (s *BillService) ConvertToListResponse(bill *models.Bill) *models.BillListResponse {
 	status := "pending"
-	if bill.BlockchainStatus == models.BlockchainConfirmed {
+	if bill.IsDeleted {
+		status = "deleted"
+	} else if bill.BlockchainStatus == models.BlockchainConfirmed {
 		status = "verified"
 	} else if bill.IsActive {
 		status = "active"
 	}
 
-	return &models.BillListResponse{
+	response := &models.BillListResponse{
 		ID:                bill.ID,
 		BillNumber:        bill.BillNumber,
 		BillType:          string(bill.BillType),
 		IssuerName:        bill.IssuerName,
 		Amount:            bill.Amount,
 		IssueDate:         bill.IssueDate.Format("2006-01-02"),
-		VerificationCount: 0, // TODO: Get from verifications table
+		VerificationCount: 0, // Filled in by ConvertToListResponses
 		Status:            status,
 		CreatedAt:         bill.CreatedAt.Format(time.RFC3339),
 	}
+
+	if bill.IsDeleted {
+		response.DeletionReason = bill.DeletionReason
+		if bill.DeletedAt != nil {
+			response.DeletedAt = bill.DeletedAt.Format(time.RFC3339)
+		}
+	}
+
+	return response
+}
+
+// ConvertToListResponses converts bills to list responses with their verification counts
+// Counts are fetched in a single query for the whole page
+func (s *BillService) ConvertToListResponses(ctx context.Context, bills []*models.Bill) ([]*models.BillListResponse, error) {
+	billIDs := make([]string, len(bills))
+	for i, bill := range bills {
+		billIDs[i] = bill.ID
+	}
+
+	counts, err := s.verificationRepo.CountByBillIDs(ctx, billIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*models.BillListResponse, len(bills))
+	for i, bill := range bills {
+		responses[i] = s.ConvertToListResponse(bill)
+		responses[i].VerificationCount = counts[bill.ID]
+	}
+
+	return responses, nil
 }
 
 // ConvertToDetailedResponse converts a Bill to detailed response (for bill details page)
@@ -310,6 +1220,10 @@ func (s *BillService) ConvertToDetailedResponse(bill *models.Bill, accessLevel s
 		"created_at":   bill.CreatedAt.Format(time.RFC3339),
 	}
 
+	if bill.ValidUntil != nil {
+		response["valid_until"] = bill.ValidUntil.Format("2006-01-02")
+	}
+
 	// Add bill data if user has full access
 	if accessLevel == "full" {
 		var billData map[string]interface{}
@@ -326,7 +1240,7 @@ func (s *BillService) ConvertToDetailedResponse(bill *models.Bill, accessLevel s
 	}
 
 	// Generate QR code
-	qrCode, err := utils.GenerateQRCode(bill.BillNumber, s.cfg.App.FrontendURL)
+	qrCode, err := s.GenerateQRCode(bill.BillNumber)
 	if err == nil {
 		response["qr_code"] = qrCode
 	}
@@ -337,13 +1251,39 @@ func (s *BillService) ConvertToDetailedResponse(bill *models.Bill, accessLevel s
 	return response
 }
 
-// GenerateQRCode generates QR code for a bill
+// GenerateQRCode generates QR code for a bill as a base64 data URL, using the configured defaults
+// billNumber may also be the bill's short code
 func (s *BillService) GenerateQRCode(billNumber string) (string, error) {
-	return utils.GenerateQRCode(billNumber, s.cfg.App.FrontendURL)
+	qrCode, err := s.GenerateQRCodePNG(billNumber, 0)
+	if err != nil {
+		return "", err
+	}
+	return utils.QRCodeDataURL(qrCode), nil
+}
+
+// GenerateQRCodePNG generates a bill's QR code as PNG bytes
+// billNumber may also be the bill's short code; see VerificationRef. A size of 0 uses the configured default
+func (s *BillService) GenerateQRCodePNG(billNumber string, size int) ([]byte, error) {
+	level, err := utils.ParseQRRecoveryLevel(s.cfg.QR.RecoveryLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	if size == 0 {
+		size = s.cfg.QR.DefaultSize
+	}
+
+	return utils.GenerateQRCodeWithOptions(billNumber, s.cfg.App.FrontendURL, utils.QRCodeOptions{
+		Size:          size,
+		RecoveryLevel: level,
+	})
 }
 
 // getBillStatus determines bill status
 func (s *BillService) getBillStatus(bill *models.Bill) string {
+	if bill.IsExpired(time.Now().In(s.cfg.Location())) {
+		return "expired"
+	}
 	if bill.BlockchainStatus == models.BlockchainConfirmed {
 		return "verified"
 	}
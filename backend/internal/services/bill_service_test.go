@@ -0,0 +1,793 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+func TestCreateBillDeductionFailureLeavesNoBill(t *testing.T) {
+	env := newTestEnv(t)
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	// Fail the wallet debit inside the transaction, after the bill row has been inserted
+	env.db.MustExec(`
+		CREATE FUNCTION test_fail_wallet_debit() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'simulated wallet failure';
+		END
+		$$ LANGUAGE plpgsql;
+		CREATE TRIGGER test_fail_wallet_debit BEFORE UPDATE OF wallet_balance ON users
+		FOR EACH ROW EXECUTE FUNCTION test_fail_wallet_debit();
+	`)
+	t.Cleanup(func() {
+		env.db.MustExec(`
+			DROP TRIGGER IF EXISTS test_fail_wallet_debit ON users;
+			DROP FUNCTION IF EXISTS test_fail_wallet_debit();
+		`)
+	})
+
+	if _, err := env.billService().CreateBill(context.Background(), issuer.ID, billRequest(500)); err == nil {
+		t.Fatal("CreateBill succeeded although the wallet debit failed")
+	}
+
+	if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 0 {
+		t.Errorf("bills after failed debit = %d, want 0", n)
+	}
+	if n := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1", issuer.ID); n != 0 {
+		t.Errorf("wallet transactions after failed debit = %d, want 0", n)
+	}
+	if balance := env.balanceOf(t, issuer.ID); balance != 100 {
+		t.Errorf("balance = %.2f, want 100.00", balance)
+	}
+}
+
+func TestCreateBillChargesFeeWithBill(t *testing.T) {
+	env := newTestEnv(t)
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	bill, err := env.billService().CreateBill(context.Background(), issuer.ID, billRequest(500))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+
+	if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 1 {
+		t.Errorf("bills = %d, want 1", n)
+	}
+	if n := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1 AND reference_id = $2 AND transaction_type = $3",
+		issuer.ID, bill.ID, models.WalletTxBillFee); n != 1 {
+		t.Errorf("fee ledger entries for the bill = %d, want 1", n)
+	}
+	if want, balance := 100-env.cfg.Pricing.BillGenerationFee, env.balanceOf(t, issuer.ID); balance != want {
+		t.Errorf("balance = %.2f, want %.2f", balance, want)
+	}
+}
+
+func TestUpdateBillBuildsRevisionChain(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billService()
+	ctx := context.Background()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	original := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	if err := env.bills.UpdateBlockchainStatus(ctx, original.ID, "tx-original", models.BlockchainConfirmed); err != nil {
+		t.Fatalf("UpdateBlockchainStatus failed: %v", err)
+	}
+
+	first, err := svc.UpdateBill(ctx, issuer.ID, original.ID, &models.UpdateBillRequest{
+		BillData: map[string]interface{}{"recipient_name": "Asha Rao"},
+		Reason:   "typo in name",
+	}, false)
+	if err != nil {
+		t.Fatalf("first UpdateBill failed: %v", err)
+	}
+
+	amount := 1200.0
+	second, err := svc.UpdateBill(ctx, issuer.ID, original.ID, &models.UpdateBillRequest{Amount: &amount, Reason: "wrong amount"}, false)
+	if err != nil {
+		t.Fatalf("second UpdateBill failed: %v", err)
+	}
+
+	current, err := env.bills.GetByID(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if current.BillNumber != original.BillNumber || current.Amount != 1200 || current.BlockchainStatus != models.BlockchainPending {
+		t.Errorf("current bill = %s / %.2f / %s, want %s / 1200 / pending",
+			current.BillNumber, current.Amount, current.BlockchainStatus, original.BillNumber)
+	}
+	if current.DataHash != second.DataHash || current.DataHash == first.DataHash || first.DataHash == original.DataHash {
+		t.Error("each revision should get a new data hash")
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(current.BillData, &data); err != nil {
+		t.Fatalf("bill data is not JSON: %v", err)
+	}
+	if ok, err := utils.VerifyBillHash(data, current.DataHash); err != nil || !ok {
+		t.Errorf("stored hash doesn't match the stored data (%v)", err)
+	}
+
+	revisions, err := svc.ListBillRevisions(ctx, issuer.ID, original.ID)
+	if err != nil {
+		t.Fatalf("ListBillRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("got %d revisions, want 2", len(revisions))
+	}
+
+	// Newest first: revision 2 archived the first edit, revision 1 the original bill
+	want := []struct {
+		number int
+		hash   string
+		amount float64
+		reason string
+	}{
+		{2, first.DataHash, 1000, "wrong amount"},
+		{1, original.DataHash, 1000, "typo in name"},
+	}
+	for i, w := range want {
+		r := revisions[i]
+		if r.RevisionNumber != w.number || r.DataHash != w.hash || r.Amount != w.amount || r.EditReason == nil || *r.EditReason != w.reason {
+			t.Errorf("revision %d = #%d %s %.2f %v, want #%d %s %.2f %s",
+				i, r.RevisionNumber, r.DataHash, r.Amount, r.EditReason, w.number, w.hash, w.amount, w.reason)
+		}
+	}
+	if revisions[1].BlockchainTxID == nil || *revisions[1].BlockchainTxID != "tx-original" {
+		t.Errorf("original revision tx = %v, want tx-original", revisions[1].BlockchainTxID)
+	}
+
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	if _, err := svc.ListBillRevisions(ctx, other.ID, original.ID); !errors.Is(err, ErrNotBillOwner) {
+		t.Errorf("ListBillRevisions by another institution = %v, want ErrNotBillOwner", err)
+	}
+}
+
+func TestUpdateBillAmountAfterVerification(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billService()
+	ctx := context.Background()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	testutil.CreateVerification(t, env.db, bill, verifier)
+
+	amount := 900.0
+	req := &models.UpdateBillRequest{Amount: &amount, Reason: "discount"}
+
+	if _, err := svc.UpdateBill(ctx, issuer.ID, bill.ID, req, false); !errors.Is(err, ErrBillHasVerifications) {
+		t.Errorf("UpdateBill without force = %v, want ErrBillHasVerifications", err)
+	}
+	if n := testutil.Count(t, env.db, "bill_revisions", "bill_id = $1", bill.ID); n != 0 {
+		t.Errorf("revisions after a refused edit = %d, want 0", n)
+	}
+
+	updated, err := svc.UpdateBill(ctx, issuer.ID, bill.ID, req, true)
+	if err != nil {
+		t.Fatalf("UpdateBill with force failed: %v", err)
+	}
+	if updated.Amount != 900 {
+		t.Errorf("amount = %.2f, want 900", updated.Amount)
+	}
+}
+
+func TestCreateBillIdempotentReplaysTheSameBill(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billService()
+	ctx := context.Background()
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	first, replayed, err := svc.CreateBillIdempotent(ctx, issuer.ID, "key-1", billRequest(500))
+	if err != nil || replayed {
+		t.Fatalf("first CreateBillIdempotent = replayed %v, %v; want a new bill", replayed, err)
+	}
+
+	again, replayed, err := svc.CreateBillIdempotent(ctx, issuer.ID, "key-1", billRequest(500))
+	if err != nil || !replayed {
+		t.Fatalf("second CreateBillIdempotent = replayed %v, %v; want the original bill", replayed, err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("replayed bill %s, want %s", again.ID, first.ID)
+	}
+
+	if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 1 {
+		t.Errorf("bills = %d, want 1", n)
+	}
+	if n := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1 AND transaction_type = $2", issuer.ID, models.WalletTxBillFee); n != 1 {
+		t.Errorf("fee charges = %d, want 1", n)
+	}
+	if want, balance := 100-env.cfg.Pricing.BillGenerationFee, env.balanceOf(t, issuer.ID); balance != want {
+		t.Errorf("balance = %.2f, want %.2f", balance, want)
+	}
+
+	// Reusing the key for a different request is an error rather than a silent replay
+	if _, _, err := svc.CreateBillIdempotent(ctx, issuer.ID, "key-1", billRequest(750)); !errors.Is(err, ErrIdempotencyKeyMismatch) {
+		t.Errorf("different request with the same key = %v, want ErrIdempotencyKeyMismatch", err)
+	}
+
+	// Keys are scoped per user
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	otherBill, replayed, err := svc.CreateBillIdempotent(ctx, other.ID, "key-1", billRequest(500))
+	if err != nil || replayed || otherBill.ID == first.ID {
+		t.Errorf("same key for another user = replayed %v, %v; want its own bill", replayed, err)
+	}
+}
+
+func TestCreateBillIdempotentConcurrentRequests(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billService()
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	const requests = 5
+	billIDs := make(chan string, requests)
+	errs := make(chan error, requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bill, _, err := svc.CreateBillIdempotent(context.Background(), issuer.ID, "same-key", billRequest(500))
+			if err != nil {
+				errs <- err
+				return
+			}
+			billIDs <- bill.ID
+		}()
+	}
+	wg.Wait()
+	close(billIDs)
+	close(errs)
+
+	// Losers of the race either get the winner's bill or are told the key is still in use
+	for err := range errs {
+		if !errors.Is(err, ErrIdempotencyKeyInUse) {
+			t.Errorf("concurrent request failed: %v", err)
+		}
+	}
+	seen := map[string]bool{}
+	for id := range billIDs {
+		seen[id] = true
+	}
+	if len(seen) != 1 {
+		t.Errorf("requests returned %d different bills, want 1", len(seen))
+	}
+
+	if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 1 {
+		t.Errorf("bills = %d, want 1", n)
+	}
+	if n := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1 AND transaction_type = $2", issuer.ID, models.WalletTxBillFee); n != 1 {
+		t.Errorf("fee charges = %d, want 1", n)
+	}
+}
+
+func TestCreateBillsBulk(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Pricing.BillGenerationFee = 10
+	env.cfg.App.BulkBillMaxItems = 3
+	svc := env.billService()
+	ctx := context.Background()
+
+	invalid := *billRequest(300)
+	invalid.Amount = 0
+
+	t.Run("partial validation failure", func(t *testing.T) {
+		issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+		result, err := svc.CreateBillsBulk(ctx, issuer.ID, []models.CreateBillRequest{*billRequest(100), invalid, *billRequest(200)})
+		if err != nil {
+			t.Fatalf("CreateBillsBulk failed: %v", err)
+		}
+		if result.Created != 2 || result.Failed != 1 || result.FeeCharged != 20 || result.BalanceLeft != 80 {
+			t.Errorf("summary = %d created, %d failed, %.2f charged, %.2f left; want 2, 1, 20, 80",
+				result.Created, result.Failed, result.FeeCharged, result.BalanceLeft)
+		}
+		for i, r := range result.Results {
+			wantSuccess := i != 1
+			if r.Success != wantSuccess || (r.Error != "") == wantSuccess {
+				t.Errorf("result %d = success %v, error %q", i, r.Success, r.Error)
+			}
+		}
+
+		if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 2 {
+			t.Errorf("bills = %d, want 2", n)
+		}
+		// One aggregate debit for the batch
+		if n := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1 AND transaction_type = $2 AND amount = -20", issuer.ID, models.WalletTxBillFee); n != 1 {
+			t.Errorf("aggregate fee entries = %d, want 1", n)
+		}
+		if balance := env.balanceOf(t, issuer.ID); balance != 80 {
+			t.Errorf("balance = %.2f, want 80", balance)
+		}
+	})
+
+	t.Run("insufficient funds for the whole batch", func(t *testing.T) {
+		issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 25)
+
+		_, err := svc.CreateBillsBulk(ctx, issuer.ID, []models.CreateBillRequest{*billRequest(100), *billRequest(200), *billRequest(300)})
+		var balanceErr *InsufficientBalanceError
+		if !errors.As(err, &balanceErr) || balanceErr.Required != 30 {
+			t.Fatalf("CreateBillsBulk = %v, want InsufficientBalanceError for 30", err)
+		}
+
+		if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 0 {
+			t.Errorf("bills = %d, want none", n)
+		}
+		if balance := env.balanceOf(t, issuer.ID); balance != 25 {
+			t.Errorf("balance = %.2f, want 25", balance)
+		}
+	})
+
+	t.Run("batch too large", func(t *testing.T) {
+		issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+		reqs := []models.CreateBillRequest{*billRequest(1), *billRequest(2), *billRequest(3), *billRequest(4)}
+
+		var tooLarge *BulkBatchTooLargeError
+		if _, err := svc.CreateBillsBulk(ctx, issuer.ID, reqs); !errors.As(err, &tooLarge) {
+			t.Errorf("CreateBillsBulk = %v, want BulkBatchTooLargeError", err)
+		}
+	})
+}
+
+func TestCreateBillNotifiesRecipient(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billService()
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		notify     bool
+		email      interface{}
+		wantStatus models.RecipientNotificationStatus
+	}{
+		{"not requested", false, "asha@example.com", models.NotificationNotRequested},
+		{"missing email", true, nil, models.NotificationMissingEmail},
+		{"blank email", true, "   ", models.NotificationMissingEmail},
+		{"email isn't a string", true, 42, models.NotificationMissingEmail},
+		{"invalid email", true, "asha.example.com", models.NotificationInvalidEmail},
+		{"display name form", true, "Asha <asha@example.com>", models.NotificationInvalidEmail},
+		{"valid email", true, "asha@example.com", models.NotificationQueued},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+			req := billRequest(1000)
+			req.NotifyRecipient = tt.notify
+			if tt.email != nil {
+				req.BillData["recipient_email"] = tt.email
+			}
+
+			bill, err := svc.CreateBill(ctx, issuer.ID, req)
+			if err != nil {
+				t.Fatalf("CreateBill failed: %v", err)
+			}
+			if bill.NotificationStatus != tt.wantStatus {
+				t.Errorf("notification status = %q, want %q", bill.NotificationStatus, tt.wantStatus)
+			}
+
+			wantQueued := 0
+			if tt.wantStatus == models.NotificationQueued {
+				wantQueued = 1
+			}
+			queued := testutil.Count(t, env.db, "outbox", "kind = $1 AND payload->>'bill_number' = $2 AND payload->>'recipient_email' = $3",
+				models.OutboxBillEmail, bill.BillNumber, "asha@example.com")
+			if queued != wantQueued {
+				t.Errorf("recipient emails queued = %d, want %d", queued, wantQueued)
+			}
+		})
+	}
+}
+
+func TestCreateBillRejectsIncompleteBillData(t *testing.T) {
+	env := newTestEnv(t)
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	req := billRequest(500)
+	req.BillType = models.BillTypeSalarySlip
+	req.BillData = map[string]interface{}{"month": "2026-09", "earnings": "lots"}
+
+	_, err := env.billService().CreateBill(context.Background(), issuer.ID, req)
+	if !errors.Is(err, ErrInvalidBillData) {
+		t.Fatalf("CreateBill error = %v, want ErrInvalidBillData", err)
+	}
+	var invalid *BillDataValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("CreateBill error = %T, want *BillDataValidationError", err)
+	}
+	got := map[string]string{}
+	for _, f := range invalid.Fields {
+		got[f.Field] = f.Message
+	}
+	want := map[string]string{"employee_name": "is required", "earnings": "must be a object"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("field errors = %v, want %v", got, want)
+	}
+
+	// Nothing is issued or charged for rejected data
+	if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 0 {
+		t.Errorf("bills = %d, want 0", n)
+	}
+	if balance := env.balanceOf(t, issuer.ID); balance != 100 {
+		t.Errorf("balance = %.2f, want 100.00", balance)
+	}
+}
+
+func TestListUserBillsAfterWithInsertsBetweenPages(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billService()
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+
+	want := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		want[testutil.CreateBill(t, env.db, issuer, 100, nil).ID] = true
+	}
+	// Rows sharing a timestamp are told apart by id
+	env.db.MustExec(`UPDATE bills SET created_at = date_trunc('second', NOW()) - INTERVAL '1 hour' WHERE issuer_id = $1`, issuer.ID)
+
+	seen := map[string]int{}
+	var cursor *models.PageCursor
+	for page := 1; ; page++ {
+		bills, next, err := svc.ListUserBillsAfter(context.Background(), issuer.ID, false, cursor, 3)
+		if err != nil {
+			t.Fatalf("page %d: ListUserBillsAfter failed: %v", page, err)
+		}
+		for _, b := range bills {
+			seen[b.ID]++
+		}
+		if next == nil {
+			break
+		}
+
+		// A bill issued while the client pages lands before the first page, not in a later one
+		testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+		// Clients only ever see the opaque cursor
+		if cursor, err = models.DecodePageCursor(next.Encode()); err != nil {
+			t.Fatalf("page %d: cursor doesn't round-trip: %v", page, err)
+		}
+	}
+
+	for id := range want {
+		if seen[id] != 1 {
+			t.Errorf("bill %s listed %d times, want once", id, seen[id])
+		}
+	}
+	if len(seen) != len(want) {
+		t.Errorf("listed %d bills, want the %d that existed when paging started", len(seen), len(want))
+	}
+}
+
+func TestCreateBillCurrency(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.App.SupportedCurrencies = []string{"INR", "USD"}
+	env.cfg.App.DefaultCurrency = "INR"
+	svc := env.billService()
+
+	tests := []struct {
+		name     string
+		currency string
+		want     string
+		wantErr  error
+	}{
+		{"omitted defaults", "", "INR", nil},
+		{"supported", "USD", "USD", nil},
+		{"lowercase is normalized", " usd ", "USD", nil},
+		{"unsupported", "EUR", "", ErrUnsupportedCurrency},
+		{"not a code", "RUPEES", "", ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+			req := billRequest(500)
+			req.Currency = tt.currency
+
+			bill, err := svc.CreateBill(context.Background(), issuer.ID, req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateBill error = %v, want %v", err, tt.wantErr)
+				}
+				if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 0 {
+					t.Errorf("bills = %d, want 0", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateBill failed: %v", err)
+			}
+
+			stored, err := env.bills.GetByID(context.Background(), bill.ID)
+			if err != nil {
+				t.Fatalf("failed to reload bill: %v", err)
+			}
+			if stored.Currency != tt.want {
+				t.Errorf("currency = %q, want %q", stored.Currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateBillAmountLimits(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Pricing.MaxBillAmount = 1000
+	env.cfg.Pricing.ReviewBillAmount = 100
+	svc := env.billService()
+
+	tests := []struct {
+		name            string
+		amount          float64
+		wantNeedsReview bool
+		wantErr         error
+	}{
+		{"below the review threshold", 99.99, false, nil},
+		{"at the review threshold", 100, false, nil},
+		{"just above the review threshold", 100.01, true, nil},
+		{"at the maximum", 1000, true, nil},
+		{"just above the maximum", 1000.01, false, ErrBillAmountTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+			bill, err := svc.CreateBill(context.Background(), issuer.ID, billRequest(tt.amount))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateBill error = %v, want %v", err, tt.wantErr)
+				}
+				if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 0 {
+					t.Errorf("bills = %d, want 0", n)
+				}
+				if balance := env.balanceOf(t, issuer.ID); balance != 100 {
+					t.Errorf("balance = %.2f, want 100.00", balance)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateBill failed: %v", err)
+			}
+
+			stored, err := env.bills.GetByID(context.Background(), bill.ID)
+			if err != nil {
+				t.Fatalf("failed to reload bill: %v", err)
+			}
+			if stored.NeedsReview != tt.wantNeedsReview {
+				t.Errorf("needs_review = %v, want %v", stored.NeedsReview, tt.wantNeedsReview)
+			}
+		})
+	}
+
+	t.Run("limits disabled", func(t *testing.T) {
+		env.cfg.Pricing.MaxBillAmount = 0
+		env.cfg.Pricing.ReviewBillAmount = 0
+		issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+		bill, err := env.billService().CreateBill(context.Background(), issuer.ID, billRequest(1e9))
+		if err != nil {
+			t.Fatalf("CreateBill failed: %v", err)
+		}
+		if bill.NeedsReview {
+			t.Error("bill flagged for review with review flagging disabled")
+		}
+	})
+}
+
+func TestCreateBillRejectsPreviewOnLowerAccessLevels(t *testing.T) {
+	env := newTestEnv(t)
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	req := billRequest(100)
+	req.AllowPreview = true
+	if _, err := env.billService().CreateBill(context.Background(), issuer.ID, req); !errors.Is(err, ErrPreviewNotApplicable) {
+		t.Fatalf("CreateBill error = %v, want %v", err, ErrPreviewNotApplicable)
+	}
+
+	req.AccessLevel = models.AccessLevelFinancial
+	bill, err := env.billService().CreateBill(context.Background(), issuer.ID, req)
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	if stored, err := env.bills.GetByID(context.Background(), bill.ID); err != nil || !stored.AllowPreview {
+		t.Errorf("stored allow_preview = %v (err %v), want true", stored != nil && stored.AllowPreview, err)
+	}
+}
+
+func TestGetBillTrendsOverSeveralWeeks(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billService()
+	ctx := context.Background()
+	loc := env.cfg.Location()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+
+	today := utils.StartOfDay(time.Now(), loc)
+	thisMonday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+	// createdWeeksAgo creates a bill at noon on the Monday weeks before this one
+	createdWeeksAgo := func(user *models.User, weeks int, amount float64) *models.Bill {
+		bill := testutil.CreateBill(t, env.db, user, amount, nil)
+		env.db.MustExec("UPDATE bills SET created_at = $2 WHERE id = $1", bill.ID, thisMonday.AddDate(0, 0, -7*weeks).Add(12*time.Hour))
+		return bill
+	}
+	createdWeeksAgo(issuer, 0, 100)
+	createdWeeksAgo(issuer, 0, 200)
+	createdWeeksAgo(issuer, 2, 50)
+	createdWeeksAgo(issuer, 20, 1000) // Outside the range
+	createdWeeksAgo(other, 0, 5000)   // Someone else's
+	deleted := createdWeeksAgo(issuer, 1, 700)
+	if err := env.bills.SoftDelete(ctx, deleted.ID, "test"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+
+	buckets, err := svc.GetBillTrends(ctx, issuer.ID, models.TrendIntervalWeek, 4, true)
+	if err != nil {
+		t.Fatalf("GetBillTrends failed: %v", err)
+	}
+	if len(buckets) != 4 {
+		t.Fatalf("got %d buckets, want 4", len(buckets))
+	}
+
+	want := []struct {
+		count, runningCount   int
+		amount, runningAmount float64
+	}{
+		{0, 0, 0, 0},
+		{1, 1, 50, 50},
+		{0, 1, 0, 50}, // The deleted bill isn't counted
+		{2, 3, 300, 350},
+	}
+	for i, bucket := range buckets {
+		if wantStart := thisMonday.AddDate(0, 0, -7*(3-i)).Format(utils.DateLayout); bucket.Start != wantStart {
+			t.Errorf("bucket %d starts %s, want %s", i, bucket.Start, wantStart)
+		}
+		if bucket.Count != want[i].count || bucket.Amount != want[i].amount {
+			t.Errorf("bucket %s = %d bills worth %.2f, want %d worth %.2f", bucket.Start, bucket.Count, bucket.Amount, want[i].count, want[i].amount)
+		}
+		if bucket.RunningCount == nil || *bucket.RunningCount != want[i].runningCount ||
+			bucket.RunningAmount == nil || *bucket.RunningAmount != want[i].runningAmount {
+			t.Errorf("bucket %s running totals = %v, %v, want %d, %.2f", bucket.Start, bucket.RunningCount, bucket.RunningAmount, want[i].runningCount, want[i].runningAmount)
+		}
+	}
+
+	// Running totals are only included on request
+	buckets, err = svc.GetBillTrends(ctx, issuer.ID, models.TrendIntervalWeek, 4, false)
+	if err != nil {
+		t.Fatalf("GetBillTrends failed: %v", err)
+	}
+	if buckets[3].RunningCount != nil || buckets[3].RunningAmount != nil {
+		t.Error("running totals set without running")
+	}
+}
+
+func TestCreateShortLinkResolvesOnVerification(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billService()
+	ctx := context.Background()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+	if _, err := svc.CreateShortLink(ctx, verifier.ID, bill.ID); !errors.Is(err, ErrNotBillOwner) {
+		t.Fatalf("CreateShortLink by another user error = %v, want %v", err, ErrNotBillOwner)
+	}
+
+	link, err := svc.CreateShortLink(ctx, issuer.ID, bill.ID)
+	if err != nil {
+		t.Fatalf("CreateShortLink failed: %v", err)
+	}
+	if len(link.ShortCode) != shortCodeLength || !strings.HasSuffix(link.VerificationLink, link.ShortCode) {
+		t.Errorf("short link = %q (%s), want a %d character code in the link", link.VerificationLink, link.ShortCode, shortCodeLength)
+	}
+	if ref := svc.VerificationRef(ctx, bill); ref != link.ShortCode {
+		t.Errorf("VerificationRef = %q, want the short code %q", ref, link.ShortCode)
+	}
+
+	result := verifyAs(t, env.verificationService(), verifier, link.ShortCode)
+	if result.BillNumber != bill.BillNumber || result.Status != "valid" {
+		t.Errorf("verifying by short code = %s %s, want %s valid", result.BillNumber, result.Status, bill.BillNumber)
+	}
+
+	// Regenerating retires the old code
+	again, err := svc.CreateShortLink(ctx, issuer.ID, bill.ID)
+	if err != nil {
+		t.Fatalf("CreateShortLink failed: %v", err)
+	}
+	if again.ShortCode == link.ShortCode {
+		t.Error("regenerated short code is unchanged")
+	}
+	if _, err := findBillByReference(ctx, env.bills, env.aliases, link.ShortCode); !errors.Is(err, ErrBillNotFound) {
+		t.Errorf("old short code error = %v, want %v", err, ErrBillNotFound)
+	}
+}
+
+func TestCreateBillRejectsDuplicatesWithinWindow(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.App.DuplicateBillWindow = 10 * time.Minute
+	svc := env.billService()
+	ctx := context.Background()
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	first, err := svc.CreateBill(ctx, issuer.ID, billRequest(500))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	balance := env.balanceOf(t, issuer.ID)
+
+	_, err = svc.CreateBill(ctx, issuer.ID, billRequest(500))
+	var duplicate *DuplicateBillError
+	if !errors.As(err, &duplicate) || !errors.Is(err, ErrDuplicateBill) {
+		t.Fatalf("identical bill error = %v, want a DuplicateBillError", err)
+	}
+	if duplicate.BillID != first.ID || duplicate.BillNumber != first.BillNumber {
+		t.Errorf("duplicate points at %s (%s), want %s (%s)", duplicate.BillNumber, duplicate.BillID, first.BillNumber, first.ID)
+	}
+	if n := testutil.Count(t, env.db, "bills", "issuer_id = $1", issuer.ID); n != 1 {
+		t.Errorf("bills = %d, want 1", n)
+	}
+	if got := env.balanceOf(t, issuer.ID); got != balance {
+		t.Errorf("balance = %.2f, want %.2f (no fee for a rejected duplicate)", got, balance)
+	}
+
+	// Different content, or the same content from another issuer, isn't a duplicate
+	if _, err := svc.CreateBill(ctx, issuer.ID, billRequest(501)); err != nil {
+		t.Errorf("bill with a different amount: %v", err)
+	}
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	if _, err := svc.CreateBill(ctx, other.ID, billRequest(500)); err != nil {
+		t.Errorf("identical bill from another issuer: %v", err)
+	}
+
+	// allow_duplicate creates it anyway
+	req := billRequest(500)
+	req.AllowDuplicate = true
+	override, err := svc.CreateBill(ctx, issuer.ID, req)
+	if err != nil {
+		t.Fatalf("CreateBill with allow_duplicate failed: %v", err)
+	}
+	if override.ID == first.ID || override.BillNumber == first.BillNumber {
+		t.Error("allow_duplicate returned the existing bill instead of a new one")
+	}
+
+	// Once the identical bills are older than the window, the same content is accepted again
+	env.db.MustExec(`UPDATE bills SET created_at = NOW() - INTERVAL '11 minutes' WHERE issuer_id = $1 AND amount = 500`, issuer.ID)
+	if _, err := svc.CreateBill(ctx, issuer.ID, billRequest(500)); err != nil {
+		t.Errorf("identical bill after the window: %v", err)
+	}
+}
+
+func TestCreateBillDuplicateCheckSkipsDeletedBillsAndCanBeDisabled(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.App.DuplicateBillWindow = 10 * time.Minute
+	ctx := context.Background()
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+
+	first, err := env.billService().CreateBill(ctx, issuer.ID, billRequest(500))
+	if err != nil {
+		t.Fatalf("CreateBill failed: %v", err)
+	}
+	env.db.MustExec(`UPDATE bills SET is_deleted = true WHERE id = $1`, first.ID)
+	if _, err := env.billService().CreateBill(ctx, issuer.ID, billRequest(500)); err != nil {
+		t.Errorf("identical to a deleted bill: %v", err)
+	}
+
+	env.cfg.App.DuplicateBillWindow = 0
+	if _, err := env.billService().CreateBill(ctx, issuer.ID, billRequest(500)); err != nil {
+		t.Errorf("identical bill with the check disabled: %v", err)
+	}
+	if n := testutil.Count(t, env.db, "bills", "issuer_id = $1 AND is_deleted = false", issuer.ID); n != 2 {
+		t.Errorf("bills = %d, want 2", n)
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// BillTransferService moves bills between institution accounts, e.g. after a merger or account split
+// A bill keeps its number, hash and verification history; only its issuer changes
+type BillTransferService struct {
+	db           *database.DB
+	billRepo     *repository.BillRepository
+	userRepo     *repository.UserRepository
+	transferRepo *repository.BillTransferRepository
+	auditRepo    *repository.AuditRepository
+	emailService *EmailService
+	cfg          *config.Config
+}
+
+// NewBillTransferService creates a new bill transfer service
+func NewBillTransferService(
+	db *database.DB,
+	billRepo *repository.BillRepository,
+	userRepo *repository.UserRepository,
+	transferRepo *repository.BillTransferRepository,
+	auditRepo *repository.AuditRepository,
+	emailService *EmailService,
+	cfg *config.Config,
+) *BillTransferService {
+	return &BillTransferService{
+		db:           db,
+		billRepo:     billRepo,
+		userRepo:     userRepo,
+		transferRepo: transferRepo,
+		auditRepo:    auditRepo,
+		emailService: emailService,
+		cfg:          cfg,
+	}
+}
+
+// RequestTransfer moves a bill to req.TargetUserID
+// Master admins transfer immediately. The bill's issuer starts a pending transfer instead,
+// and the target account accepts it with the token emailed to it (see ConfirmTransfer).
+func (s *BillTransferService) RequestTransfer(ctx context.Context, userID string, userRole models.UserRole, billID string, req *models.TransferBillRequest) (*models.BillTransfer, error) {
+	target, err := s.getTransferTarget(ctx, req.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	isMasterAdmin := userRole == models.RoleMasterAdmin
+
+	var bill *models.Bill
+	var transfer *models.BillTransfer
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		bill, err = s.billRepo.GetByIDForUpdateTx(ctx, tx, billID)
+		if err != nil {
+			return err
+		}
+
+		if bill.IssuerID != userID && !isMasterAdmin {
+			return ErrNotBillOwner
+		}
+		if bill.IssuerID == target.ID {
+			return ErrInvalidTransferTarget
+		}
+
+		// Only the newest request for a bill can be accepted
+		if err := s.transferRepo.CancelPendingTx(ctx, tx, bill.ID); err != nil {
+			return err
+		}
+
+		transfer = &models.BillTransfer{
+			BillID:             bill.ID,
+			FromIssuerID:       bill.IssuerID,
+			ToIssuerID:         target.ID,
+			RequestedBy:        userID,
+			UpdateIssuerName:   req.UpdateIssuerName,
+			PreviousIssuerName: bill.IssuerName,
+		}
+
+		if !isMasterAdmin {
+			token, err := utils.GenerateSecureToken(32)
+			if err != nil {
+				return fmt.Errorf("failed to generate transfer token: %w", err)
+			}
+			expiresAt := time.Now().Add(s.cfg.App.BillTransferExpiry)
+
+			transfer.Status = models.BillTransferPending
+			transfer.ConfirmationToken = &token
+			transfer.ExpiresAt = &expiresAt
+			return s.transferRepo.CreateTx(ctx, tx, transfer)
+		}
+
+		if err := s.billRepo.TransferOwnershipTx(ctx, tx, bill, target.ID, newIssuerName(target, req.UpdateIssuerName)); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transfer.Status = models.BillTransferCompleted
+		transfer.NewIssuerName = &bill.IssuerName
+		transfer.CompletedAt = &now
+		if err := s.transferRepo.CreateTx(ctx, tx, transfer); err != nil {
+			return err
+		}
+
+		return s.auditRepo.RecordTx(ctx, tx, userID, models.AuditBillTransfer, models.AuditTargetBill, bill.ID, map[string]interface{}{
+			"bill_number":    bill.BillNumber,
+			"from_issuer_id": transfer.FromIssuerID,
+			"to_issuer_id":   transfer.ToIssuerID,
+			"transfer_id":    transfer.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer.Status == models.BillTransferCompleted {
+		s.billRepo.InvalidateCache(ctx, bill.BillNumber)
+		log.Printf("🔁 Admin %s transferred bill %s from %s to %s", userID, bill.BillNumber, transfer.FromIssuerID, transfer.ToIssuerID)
+		return transfer, nil
+	}
+
+	// SMTP can be slow - don't hold up the issuer's response
+	go func(target models.User, bill models.Bill, transfer models.BillTransfer) {
+		sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := s.emailService.SendBillTransferEmail(sendCtx, &target, &bill, &transfer); err != nil {
+			log.Printf("⚠️  Failed to send bill transfer request to %s: %v", target.Email, err)
+		}
+	}(*target, *bill, *transfer)
+
+	return transfer, nil
+}
+
+// ConfirmTransfer completes a pending transfer for the account it was sent to
+// The transfer is cancelled if the bill changed hands after it was requested
+func (s *BillTransferService) ConfirmTransfer(ctx context.Context, userID, token string) (*models.BillTransfer, error) {
+	target, err := s.getTransferTarget(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var bill *models.Bill
+	var transfer *models.BillTransfer
+	var stale bool
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		transfer, err = s.transferRepo.GetPendingByTokenForUpdateTx(ctx, tx, token)
+		if err != nil {
+			if errors.Is(err, repository.ErrBillTransferNotFound) {
+				return ErrBillTransferInvalid
+			}
+			return err
+		}
+
+		if transfer.ToIssuerID != userID {
+			return ErrNotTransferRecipient
+		}
+		if transfer.ExpiresAt != nil && time.Now().After(*transfer.ExpiresAt) {
+			return ErrBillTransferExpired
+		}
+
+		bill, err = s.billRepo.GetByIDForUpdateTx(ctx, tx, transfer.BillID)
+		if err != nil {
+			return err
+		}
+
+		// The bill was moved or deleted by someone else since the request
+		if bill.IssuerID != transfer.FromIssuerID {
+			stale = true
+			return s.transferRepo.SetStatusTx(ctx, tx, transfer, models.BillTransferCancelled)
+		}
+
+		if err := s.billRepo.TransferOwnershipTx(ctx, tx, bill, target.ID, newIssuerName(target, transfer.UpdateIssuerName)); err != nil {
+			return err
+		}
+
+		transfer.NewIssuerName = &bill.IssuerName
+		return s.transferRepo.SetStatusTx(ctx, tx, transfer, models.BillTransferCompleted)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrBillNotFound) {
+			return nil, ErrBillTransferInvalid
+		}
+		return nil, err
+	}
+	if stale {
+		return nil, ErrBillTransferInvalid
+	}
+
+	s.billRepo.InvalidateCache(ctx, bill.BillNumber)
+	log.Printf("🔁 Bill %s transferred from %s to %s", bill.BillNumber, transfer.FromIssuerID, transfer.ToIssuerID)
+
+	return transfer, nil
+}
+
+// ListTransfers returns a bill's transfer history for its current issuer or a master admin
+func (s *BillTransferService) ListTransfers(ctx context.Context, userID string, userRole models.UserRole, billID string) ([]*models.BillTransfer, error) {
+	bill, err := s.billRepo.GetByID(ctx, billID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bill.IssuerID != userID && userRole != models.RoleMasterAdmin {
+		return nil, ErrNotBillOwner
+	}
+
+	return s.transferRepo.ListByBill(ctx, bill.ID)
+}
+
+// getTransferTarget loads an account that can receive bills: an active institution with approved KYC
+func (s *BillTransferService) getTransferTarget(ctx context.Context, userID string) (*models.User, error) {
+	target, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidTransferTarget
+		}
+		return nil, err
+	}
+
+	if !target.Role.IsInstitution() || target.KYCStatus != models.KYCApproved {
+		return nil, ErrInvalidTransferTarget
+	}
+
+	return target, nil
+}
+
+// newIssuerName is the issuer name to put on a transferred bill, or nil to keep the current one
+func newIssuerName(target *models.User, update bool) *string {
+	if !update || target.OrganizationName == "" {
+		return nil
+	}
+	return &target.OrganizationName
+}
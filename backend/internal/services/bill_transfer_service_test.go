@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+// billTransferService returns a BillTransferService whose request emails fail fast
+func (e *testEnv) billTransferService() *BillTransferService {
+	return NewBillTransferService(e.db, e.bills, e.users, repository.NewBillTransferRepository(e.db.DB), e.audit, e.emailService(), e.cfg)
+}
+
+// transferredBill reloads bill and checks that only its issuer changed
+func transferredBill(t *testing.T, env *testEnv, bill *models.Bill, to *models.User) *models.Bill {
+	t.Helper()
+
+	stored, err := env.bills.GetByID(context.Background(), bill.ID)
+	if err != nil {
+		t.Fatalf("failed to reload bill: %v", err)
+	}
+	if stored.IssuerID != to.ID {
+		t.Errorf("issuer = %s, want %s", stored.IssuerID, to.ID)
+	}
+	if stored.BillNumber != bill.BillNumber || stored.DataHash != bill.DataHash {
+		t.Errorf("bill number or hash changed: %s / %s, want %s / %s", stored.BillNumber, stored.DataHash, bill.BillNumber, bill.DataHash)
+	}
+	return stored
+}
+
+func TestAdminTransfersBillImmediately(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billTransferService()
+	bills := env.billService()
+	ctx := context.Background()
+
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	from := testutil.CreateUser(t, env.db, models.RoleInstitutionAdmin, 0)
+	to := testutil.CreateUser(t, env.db, models.RoleInstitutionAdmin, 0)
+	bill := testutil.CreateBill(t, env.db, from, 100, nil)
+	env.db.MustExec("UPDATE bills SET access_level = 'financial' WHERE id = $1", bill.ID)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	testutil.CreateVerification(t, env.db, bill, verifier)
+	testutil.CreateVerification(t, env.db, bill, nil)
+
+	transfer, err := svc.RequestTransfer(ctx, admin.ID, admin.Role, bill.ID, &models.TransferBillRequest{TargetUserID: to.ID, UpdateIssuerName: true})
+	if err != nil {
+		t.Fatalf("RequestTransfer failed: %v", err)
+	}
+	if transfer.Status != models.BillTransferCompleted {
+		t.Errorf("status = %s, want %s", transfer.Status, models.BillTransferCompleted)
+	}
+
+	stored := transferredBill(t, env, bill, to)
+	if stored.IssuerName != to.OrganizationName {
+		t.Errorf("issuer name = %q, want %q", stored.IssuerName, to.OrganizationName)
+	}
+	if count, err := env.verifications.CountVerificationsByBill(ctx, bill.ID); err != nil || count != 2 {
+		t.Errorf("verifications = %d, %v; want the 2 made before the transfer", count, err)
+	}
+	if n := testutil.Count(t, env.db, "audit_log", "actor_id = $1 AND action = $2 AND target_id = $3", admin.ID, models.AuditBillTransfer, bill.ID); n != 1 {
+		t.Errorf("audit entries = %d, want 1", n)
+	}
+
+	// Access now follows the new owner
+	if _, err := bills.GetBillByID(ctx, from.ID, bill.ID, from.Role); !errors.Is(err, ErrBillAccessDenied) {
+		t.Errorf("previous owner GetBillByID error = %v, want %v", err, ErrBillAccessDenied)
+	}
+	if _, err := bills.GetBillByID(ctx, to.ID, bill.ID, to.Role); err != nil {
+		t.Errorf("new owner GetBillByID failed: %v", err)
+	}
+	if _, err := svc.ListTransfers(ctx, from.ID, from.Role, bill.ID); !errors.Is(err, ErrNotBillOwner) {
+		t.Errorf("previous owner ListTransfers error = %v, want %v", err, ErrNotBillOwner)
+	}
+	if history, err := svc.ListTransfers(ctx, to.ID, to.Role, bill.ID); err != nil || len(history) != 1 {
+		t.Errorf("new owner ListTransfers = %d transfers, %v; want 1", len(history), err)
+	}
+}
+
+func TestIssuerTransferNeedsTargetConfirmation(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billTransferService()
+	ctx := context.Background()
+
+	from := testutil.CreateUser(t, env.db, models.RoleInstitutionAdmin, 0)
+	to := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	bill := testutil.CreateBill(t, env.db, from, 100, nil)
+
+	transfer, err := svc.RequestTransfer(ctx, from.ID, from.Role, bill.ID, &models.TransferBillRequest{TargetUserID: to.ID})
+	if err != nil {
+		t.Fatalf("RequestTransfer failed: %v", err)
+	}
+	if transfer.Status != models.BillTransferPending || transfer.ConfirmationToken == nil {
+		t.Fatalf("transfer = %s with token %v, want pending with a token", transfer.Status, transfer.ConfirmationToken)
+	}
+	transferredBill(t, env, bill, from)
+
+	if _, err := svc.ConfirmTransfer(ctx, from.ID, *transfer.ConfirmationToken); !errors.Is(err, ErrNotTransferRecipient) {
+		t.Errorf("confirming as the sender error = %v, want %v", err, ErrNotTransferRecipient)
+	}
+
+	confirmed, err := svc.ConfirmTransfer(ctx, to.ID, *transfer.ConfirmationToken)
+	if err != nil {
+		t.Fatalf("ConfirmTransfer failed: %v", err)
+	}
+	if confirmed.Status != models.BillTransferCompleted {
+		t.Errorf("status = %s, want %s", confirmed.Status, models.BillTransferCompleted)
+	}
+	stored := transferredBill(t, env, bill, to)
+	if stored.IssuerName != bill.IssuerName {
+		t.Errorf("issuer name = %q, want it kept as %q", stored.IssuerName, bill.IssuerName)
+	}
+
+	if _, err := svc.ConfirmTransfer(ctx, to.ID, *transfer.ConfirmationToken); !errors.Is(err, ErrBillTransferInvalid) {
+		t.Errorf("reusing the token error = %v, want %v", err, ErrBillTransferInvalid)
+	}
+}
+
+func TestTransferRequestIsRefused(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.billTransferService()
+	ctx := context.Background()
+
+	owner := testutil.CreateUser(t, env.db, models.RoleInstitutionAdmin, 0)
+	other := testutil.CreateUser(t, env.db, models.RoleInstitutionAdmin, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, owner, 100, nil)
+
+	tests := []struct {
+		name   string
+		caller *models.User
+		target string
+		want   error
+	}{
+		{"to a verifier", owner, verifier.ID, ErrInvalidTransferTarget},
+		{"to the current owner", owner, owner.ID, ErrInvalidTransferTarget},
+		{"to an unknown account", owner, "00000000-0000-4000-8000-000000000000", ErrInvalidTransferTarget},
+		{"by someone else", other, other.ID, ErrNotBillOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.RequestTransfer(ctx, tt.caller.ID, tt.caller.Role, bill.ID, &models.TransferBillRequest{TargetUserID: tt.target})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("RequestTransfer error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("expired confirmation", func(t *testing.T) {
+		transfer, err := svc.RequestTransfer(ctx, owner.ID, owner.Role, bill.ID, &models.TransferBillRequest{TargetUserID: other.ID})
+		if err != nil {
+			t.Fatalf("RequestTransfer failed: %v", err)
+		}
+		env.db.MustExec("UPDATE bill_transfers SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", transfer.ID)
+
+		if _, err := svc.ConfirmTransfer(ctx, other.ID, *transfer.ConfirmationToken); !errors.Is(err, ErrBillTransferExpired) {
+			t.Errorf("ConfirmTransfer error = %v, want %v", err, ErrBillTransferExpired)
+		}
+		transferredBill(t, env, bill, owner)
+	})
+}
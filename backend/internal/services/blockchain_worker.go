@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/blockchain"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// maxBlockchainBackoff caps how long the worker waits after repeated errors
+const maxBlockchainBackoff = 5 * time.Minute
+
+// BlockchainWorker commits pending bills to the blockchain in the background
+type BlockchainWorker struct {
+	billRepo *repository.BillRepository
+	client   blockchain.Client
+	cfg      config.BlockchainConfig
+
+	// attempts counts failed commits per bill since the worker started
+	attempts map[string]int
+}
+
+// NewBlockchainWorker creates a new blockchain commitment worker
+func NewBlockchainWorker(billRepo *repository.BillRepository, client blockchain.Client, cfg *config.Config) *BlockchainWorker {
+	workerCfg := cfg.Blockchain
+	if workerCfg.PollInterval <= 0 {
+		workerCfg.PollInterval = 10 * time.Second
+	}
+	if workerCfg.BatchSize <= 0 {
+		workerCfg.BatchSize = 50
+	}
+	if workerCfg.MaxAttempts <= 0 {
+		workerCfg.MaxAttempts = 1
+	}
+
+	return &BlockchainWorker{
+		billRepo: billRepo,
+		client:   client,
+		cfg:      workerCfg,
+		attempts: make(map[string]int),
+	}
+}
+
+// Run polls for pending bills until ctx is cancelled
+// Call it in its own goroutine
+func (w *BlockchainWorker) Run(ctx context.Context) {
+	log.Printf("⛓️  Blockchain worker started (interval %s, batch %d)", w.cfg.PollInterval, w.cfg.BatchSize)
+
+	wait := w.cfg.PollInterval
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("⛓️  Blockchain worker stopped")
+			return
+		case <-time.After(wait):
+		}
+
+		if err := w.processBatch(ctx); err != nil {
+			// Back off exponentially on transient errors (DB down, etc.)
+			wait *= 2
+			if wait > maxBlockchainBackoff {
+				wait = maxBlockchainBackoff
+			}
+			log.Printf("⚠️  Blockchain worker error, retrying in %s: %v", wait, err)
+			continue
+		}
+
+		wait = w.cfg.PollInterval
+	}
+}
+
+// processBatch commits one batch of pending bills
+func (w *BlockchainWorker) processBatch(ctx context.Context) error {
+	bills, err := w.billRepo.ListPendingBlockchain(ctx, w.cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, bill := range bills {
+		if ctx.Err() != nil {
+			return nil
+		}
+		w.commitBill(ctx, bill)
+	}
+
+	return nil
+}
+
+// commitBill submits a single bill and records the outcome
+// A bill is only marked failed after MaxAttempts consecutive commit errors
+func (w *BlockchainWorker) commitBill(ctx context.Context, bill *models.Bill) {
+	txID, err := w.client.CommitHash(ctx, bill.ID, bill.DataHash)
+	if err != nil {
+		w.attempts[bill.ID]++
+		if w.attempts[bill.ID] < w.cfg.MaxAttempts {
+			log.Printf("⚠️  Blockchain commit failed for %s (attempt %d/%d): %v", bill.BillNumber, w.attempts[bill.ID], w.cfg.MaxAttempts, err)
+			return
+		}
+
+		log.Printf("❌ Blockchain commit failed for %s after %d attempts: %v", bill.BillNumber, w.attempts[bill.ID], err)
+		if err := w.billRepo.UpdateBlockchainStatus(ctx, bill.ID, "", models.BlockchainFailed); err != nil {
+			log.Printf("⚠️  Failed to mark %s as failed: %v", bill.BillNumber, err)
+			return
+		}
+		delete(w.attempts, bill.ID)
+		return
+	}
+
+	if err := w.billRepo.UpdateBlockchainStatus(ctx, bill.ID, txID, models.BlockchainConfirmed); err != nil {
+		// Left pending - the next poll commits it again
+		log.Printf("⚠️  Failed to confirm %s: %v", bill.BillNumber, err)
+		return
+	}
+	delete(w.attempts, bill.ID)
+}
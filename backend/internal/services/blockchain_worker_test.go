@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/blockchain"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+// flakyClient fails every commit until failures reaches zero, then commits like NoopClient
+type flakyClient struct {
+	failures int
+	commits  int
+}
+
+func (c *flakyClient) CommitHash(ctx context.Context, billID, dataHash string) (string, error) {
+	c.commits++
+	if c.failures > 0 {
+		c.failures--
+		return "", errors.New("ledger unavailable")
+	}
+	return blockchain.NewNoopClient().CommitHash(ctx, billID, dataHash)
+}
+
+func TestListPendingBlockchain(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+
+	oldest := testutil.CreateBill(t, env.db, issuer, 100, nil)
+	next := testutil.CreateBill(t, env.db, issuer, 200, nil)
+	confirmed := testutil.CreateBill(t, env.db, issuer, 300, nil)
+	deleted := testutil.CreateBill(t, env.db, issuer, 400, nil)
+	testutil.CreateBill(t, env.db, issuer, 500, nil)
+
+	env.db.MustExec("UPDATE bills SET created_at = NOW() - INTERVAL '1 hour' WHERE id = $1", oldest.ID)
+	env.db.MustExec("UPDATE bills SET created_at = NOW() - INTERVAL '30 minutes' WHERE id = $1", next.ID)
+	if err := env.bills.UpdateBlockchainStatus(ctx, confirmed.ID, "tx-1", models.BlockchainConfirmed); err != nil {
+		t.Fatalf("UpdateBlockchainStatus failed: %v", err)
+	}
+	if err := env.bills.SoftDelete(ctx, deleted.ID, "test"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+
+	pending, err := env.bills.ListPendingBlockchain(ctx, 2)
+	if err != nil {
+		t.Fatalf("ListPendingBlockchain failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].ID != oldest.ID || pending[1].ID != next.ID {
+		t.Errorf("ListPendingBlockchain(2) returned %d bills, want the two oldest pending ones", len(pending))
+	}
+}
+
+func TestBlockchainWorkerConfirmsPendingBills(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+	worker := NewBlockchainWorker(env.bills, blockchain.NewNoopClient(), env.cfg)
+	if err := worker.processBatch(ctx); err != nil {
+		t.Fatalf("processBatch failed: %v", err)
+	}
+
+	committed, err := env.bills.GetByID(ctx, bill.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	want, _ := blockchain.NewNoopClient().CommitHash(ctx, bill.ID, bill.DataHash)
+	if committed.BlockchainStatus != models.BlockchainConfirmed || committed.BlockchainTxID == nil || *committed.BlockchainTxID != want {
+		t.Errorf("bill is %s with tx %v, want confirmed with %s", committed.BlockchainStatus, committed.BlockchainTxID, want)
+	}
+}
+
+func TestBlockchainWorkerRetriesBeforeFailing(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	// statusAfter polls a fresh pending bill the given number of times and returns its status
+	statusAfter := func(t *testing.T, client blockchain.Client, polls int) models.BlockchainStatus {
+		t.Helper()
+		testutil.Truncate(t, env.db)
+		issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+		bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+		cfg := *env.cfg
+		cfg.Blockchain.MaxAttempts = 3
+		worker := NewBlockchainWorker(env.bills, client, &cfg)
+		for i := 0; i < polls; i++ {
+			if err := worker.processBatch(ctx); err != nil {
+				t.Fatalf("processBatch failed: %v", err)
+			}
+		}
+
+		reloaded, err := env.bills.GetByID(ctx, bill.ID)
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		return reloaded.BlockchainStatus
+	}
+
+	t.Run("transient failures", func(t *testing.T) {
+		client := &flakyClient{failures: 2}
+		if status := statusAfter(t, client, 3); status != models.BlockchainConfirmed {
+			t.Errorf("status = %s, want confirmed after recovering", status)
+		}
+	})
+
+	t.Run("still pending while retrying", func(t *testing.T) {
+		client := &flakyClient{failures: 10}
+		if status := statusAfter(t, client, 2); status != models.BlockchainPending {
+			t.Errorf("status = %s, want pending before MaxAttempts", status)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		client := &flakyClient{failures: 10}
+		if status := statusAfter(t, client, 3); status != models.BlockchainFailed {
+			t.Errorf("status = %s, want failed", status)
+		}
+		if client.commits != 3 {
+			t.Errorf("commits = %d, want 3", client.commits)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// dailySummaryPageSize is how many institutions are loaded at a time during a run
+const dailySummaryPageSize = 100
+
+// dailySummaryUserTimeout bounds how long one user's summary may take
+const dailySummaryUserTimeout = 30 * time.Second
+
+// DailySummaryScheduler emails every active institution a summary of the bills they issued that day
+type DailySummaryScheduler struct {
+	userRepo     *repository.UserRepository
+	emailService *EmailService
+	hour         int
+	loc          *time.Location
+}
+
+// NewDailySummaryScheduler creates a new daily summary scheduler
+func NewDailySummaryScheduler(userRepo *repository.UserRepository, emailService *EmailService, cfg *config.Config) *DailySummaryScheduler {
+	return &DailySummaryScheduler{
+		userRepo:     userRepo,
+		emailService: emailService,
+		hour:         cfg.Email.DailySummaryHour,
+		loc:          cfg.Location(),
+	}
+}
+
+// Run sends the summaries once a day at the configured hour until ctx is cancelled
+// Call it in its own goroutine
+func (s *DailySummaryScheduler) Run(ctx context.Context) {
+	log.Printf("📧 Daily summary scheduler started (runs at %02d:00 %s)", s.hour, s.loc)
+
+	for {
+		next := s.nextRun(time.Now())
+		select {
+		case <-ctx.Done():
+			log.Println("📧 Daily summary scheduler stopped")
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		processed, failed := s.runOnce(ctx)
+		log.Printf("📧 Daily summaries processed for %d institutions (%d failed)", processed, failed)
+	}
+}
+
+// nextRun returns the next time the configured hour comes around after now, in APP_TIMEZONE
+func (s *DailySummaryScheduler) nextRun(now time.Time) time.Time {
+	now = now.In(s.loc)
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.hour, 0, 0, 0, s.loc)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// runOnce sends the summary to every active institution that hasn't opted out
+// A failure for one user is logged and doesn't stop the rest
+func (s *DailySummaryScheduler) runOnce(ctx context.Context) (processed, failed int) {
+	for offset := 0; ; offset += dailySummaryPageSize {
+		users, err := s.userRepo.ListActiveInstitutions(ctx, dailySummaryPageSize, offset)
+		if err != nil {
+			log.Printf("⚠️  Daily summary: failed to list institutions: %v", err)
+			return processed, failed
+		}
+
+		for _, user := range users {
+			if ctx.Err() != nil {
+				return processed, failed
+			}
+			if !user.NotificationPreferences.DailySummary {
+				continue
+			}
+
+			userCtx, cancel := context.WithTimeout(ctx, dailySummaryUserTimeout)
+			if err := s.emailService.SendDailyBillSummary(userCtx, user.ID); err != nil {
+				log.Printf("⚠️  Daily summary failed for user %s: %v", user.ID, err)
+				failed++
+			}
+			cancel()
+			processed++
+		}
+
+		if len(users) < dailySummaryPageSize {
+			return processed, failed
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestDailySummaryNextRun(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	s := &DailySummaryScheduler{hour: 18, loc: ist}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"earlier the same day", time.Date(2024, time.March, 10, 9, 0, 0, 0, ist), time.Date(2024, time.March, 10, 18, 0, 0, 0, ist)},
+		{"exactly at the hour", time.Date(2024, time.March, 10, 18, 0, 0, 0, ist), time.Date(2024, time.March, 11, 18, 0, 0, 0, ist)},
+		{"later the same day", time.Date(2024, time.March, 10, 20, 0, 0, 0, ist), time.Date(2024, time.March, 11, 18, 0, 0, 0, ist)},
+		{"month rollover", time.Date(2024, time.March, 31, 19, 0, 0, 0, ist), time.Date(2024, time.April, 1, 18, 0, 0, 0, ist)},
+		// 13:00 UTC is already 18:30 in IST
+		{"now given in UTC", time.Date(2024, time.March, 10, 13, 0, 0, 0, time.UTC), time.Date(2024, time.March, 11, 18, 0, 0, 0, ist)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.nextRun(tt.now); !got.Equal(tt.want) {
+				t.Errorf("nextRun(%s) = %s, want %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+// issuedToday moves bill's issue date to today in APP_TIMEZONE, which is the day the summary covers
+func (e *testEnv) issuedToday(bill *models.Bill) {
+	e.db.MustExec("UPDATE bills SET issue_date = $1 WHERE id = $2", time.Now().In(e.cfg.Location()).Format("2006-01-02"), bill.ID)
+}
+
+func TestSendDailyBillSummary(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	t.Run("no bills today returns nil without sending", func(t *testing.T) {
+		issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+		yesterday := testutil.CreateBill(t, env.db, issuer, 100, nil)
+		env.db.MustExec("UPDATE bills SET issue_date = $1 WHERE id = $2",
+			time.Now().In(env.cfg.Location()).AddDate(0, 0, -1).Format("2006-01-02"), yesterday.ID)
+
+		// The SMTP server refuses connections, so any attempt to send would fail
+		if err := env.emailService().SendDailyBillSummary(ctx, issuer.ID); err != nil {
+			t.Errorf("SendDailyBillSummary = %v, want nil", err)
+		}
+	})
+
+	t.Run("bills today are summarised", func(t *testing.T) {
+		smtp := newSMTPRecorder(t)
+		issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+		bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+		env.issuedToday(bill)
+
+		if err := env.emailServiceVia(smtp.Addr()).SendDailyBillSummary(ctx, issuer.ID); err != nil {
+			t.Fatalf("SendDailyBillSummary failed: %v", err)
+		}
+		sent := smtp.SentTo(issuer.Email)
+		if len(sent) != 1 {
+			t.Fatalf("emails sent = %d, want 1", len(sent))
+		}
+		if !strings.Contains(sent[0].Text(), "Daily Bill Summary") || !strings.Contains(sent[0].Text(), bill.BillNumber) {
+			t.Error("summary doesn't mention the bill issued today")
+		}
+	})
+}
+
+func TestDailySummaryRunSkipsOptedOutInstitutions(t *testing.T) {
+	env := newTestEnv(t)
+	smtp := newSMTPRecorder(t)
+	scheduler := NewDailySummaryScheduler(env.users, env.emailServiceVia(smtp.Addr()), env.cfg)
+
+	optedIn := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	optedOut := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	env.db.MustExec(`UPDATE users SET notification_preferences = jsonb_set(notification_preferences, '{daily_summary}', 'false') WHERE id = $1`, optedOut.ID)
+	for _, issuer := range []*models.User{optedIn, optedOut} {
+		env.issuedToday(testutil.CreateBill(t, env.db, issuer, 100, nil))
+	}
+
+	processed, failed := scheduler.runOnce(context.Background())
+	if processed != 1 || failed != 0 {
+		t.Errorf("runOnce = %d processed, %d failed, want 1, 0", processed, failed)
+	}
+	if n := len(smtp.SentTo(optedIn.Email)); n != 1 {
+		t.Errorf("summaries sent to the opted-in institution = %d, want 1", n)
+	}
+	if n := len(smtp.SentTo(optedOut.Email)); n != 0 {
+		t.Errorf("summaries sent to the opted-out institution = %d, want 0", n)
+	}
+}
@@ -2,13 +2,19 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"io"
+	"math/rand"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/ezhilnn/epr-backend/config"
 	"github.com/ezhilnn/epr-backend/internal/models"
 	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
 	"gopkg.in/gomail.v2"
 )
 
@@ -16,32 +22,63 @@ import (
 type EmailService struct {
 	cfg        *config.Config
 	billRepo   *repository.BillRepository
+	aliasRepo  *repository.BillAliasRepository
 	userRepo   *repository.UserRepository
 	pdfService *PDFService
-	dialer     *gomail.Dialer
+	dialer     mailDialer
+	retry      emailRetryPolicy
+}
+
+// mailDialer sends messages over SMTP
+// smtpDialer is the real implementation; tests swap in a fake
+type mailDialer interface {
+	DialAndSend(msgs ...*gomail.Message) error
+}
+
+// emailRetryPolicy controls how transient send failures are retried
+// Attempt n waits baseDelay*2^(n-1) plus up to half that again as jitter
+type emailRetryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// backoff returns how long to wait after the given failed attempt (1-based)
+func (p emailRetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseDelay << (attempt - 1)
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
 }
 
 // NewEmailService creates a new email service
 func NewEmailService(
 	cfg *config.Config,
 	billRepo *repository.BillRepository,
+	aliasRepo *repository.BillAliasRepository,
 	userRepo *repository.UserRepository,
 	pdfService *PDFService,
 ) *EmailService {
 	// Create SMTP dialer
-	dialer := gomail.NewDialer(
+	dialer := newSMTPDialer(
 		cfg.Email.SMTPHost,
 		cfg.Email.SMTPPort,
 		cfg.Email.SMTPUser,
 		cfg.Email.SMTPPassword,
+		cfg.Email.SMTPTimeout,
 	)
 
 	return &EmailService{
 		cfg:        cfg,
 		billRepo:   billRepo,
+		aliasRepo:  aliasRepo,
 		userRepo:   userRepo,
 		pdfService: pdfService,
 		dialer:     dialer,
+		retry: emailRetryPolicy{
+			maxAttempts: cfg.Email.RetryMaxAttempts,
+			baseDelay:   cfg.Email.RetryBaseDelay,
+		},
 	}
 }
 
@@ -50,17 +87,17 @@ func (s *EmailService) SendBillEmail(ctx context.Context, billNumber, recipientE
 	// Fetch bill
 	bill, err := s.billRepo.GetByBillNumber(ctx, billNumber)
 	if err != nil {
-		return fmt.Errorf("bill not found: %w", err)
+		return fmt.Errorf("failed to get bill: %w", err)
 	}
 
 	// Generate PDF
-	pdfBytes, err := s.pdfService.GenerateBillPDF(bill)
+	pdfBytes, err := s.pdfService.GenerateBillPDF(bill, verificationRef(ctx, s.aliasRepo, bill))
 	if err != nil {
 		return fmt.Errorf("failed to generate PDF: %w", err)
 	}
 
-	// Get issuer details
-	issuer, err := s.userRepo.GetByID(ctx, bill.IssuerID)
+	// Get issuer details - bills stay valid if the issuer's account is later deactivated
+	issuer, err := s.userRepo.GetByIDAny(ctx, bill.IssuerID)
 	if err != nil {
 		return fmt.Errorf("failed to get issuer: %w", err)
 	}
@@ -88,13 +125,47 @@ func (s *EmailService) SendBillEmail(ctx context.Context, billNumber, recipientE
 		}),
 	)
 	// Send email
-	if err := s.dialer.DialAndSend(m); err != nil {
+	if err := s.send(ctx, m); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
 	return nil
 }
 
+// send delivers a message, retrying transient failures with backoff
+// Gives up once ctx is done, even mid-attempt or while waiting to retry
+func (s *EmailService) send(ctx context.Context, m *gomail.Message) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.sendOnce(ctx, m)
+		if err == nil || ctx.Err() != nil || !isRetryableEmailError(err) || attempt >= s.retry.maxAttempts {
+			return err
+		}
+
+		select {
+		case <-time.After(s.retry.backoff(attempt)):
+		case <-ctx.Done():
+			return fmt.Errorf("gave up retrying email after %d attempts: %w", attempt, err)
+		}
+	}
+}
+
+// sendOnce makes a single delivery attempt, giving up once ctx is done even if the SMTP server is still hanging
+// The dialer's own timeout ends the abandoned send, so the goroutine doesn't leak
+func (s *EmailService) sendOnce(ctx context.Context, m *gomail.Message) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- s.dialer.DialAndSend(m)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for SMTP server: %w", ctx.Err())
+	}
+}
+
 // SendWelcomeEmail sends welcome email after signup
 func (s *EmailService) SendWelcomeEmail(ctx context.Context, user *models.User) error {
 	m := gomail.NewMessage()
@@ -105,24 +176,127 @@ func (s *EmailService) SendWelcomeEmail(ctx context.Context, user *models.User)
 	body := s.buildWelcomeEmailBody(user)
 	m.SetBody("text/html", body)
 
-	if err := s.dialer.DialAndSend(m); err != nil {
+	if err := s.send(ctx, m); err != nil {
 		return fmt.Errorf("failed to send welcome email: %w", err)
 	}
 
 	return nil
 }
 
+// SendVerificationEmail sends the email verification link after signup
+func (s *EmailService) SendVerificationEmail(ctx context.Context, user *models.User, token string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.cfg.Email.FromEmail)
+	m.SetHeader("To", user.Email)
+	m.SetHeader("Subject", "Verify your email - EPR")
+
+	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", s.cfg.App.FrontendURL, url.QueryEscape(token))
+	body := s.buildVerificationEmailBody(user, verifyURL)
+	m.SetBody("text/html", body)
+
+	if err := s.send(ctx, m); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// SendPasswordResetEmail sends the password reset link
+func (s *EmailService) SendPasswordResetEmail(ctx context.Context, user *models.User, token string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.cfg.Email.FromEmail)
+	m.SetHeader("To", user.Email)
+	m.SetHeader("Subject", "Reset your password - EPR")
+
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", s.cfg.App.FrontendURL, url.QueryEscape(token))
+	body := s.buildPasswordResetEmailBody(user, resetURL)
+	m.SetBody("text/html", body)
+
+	if err := s.send(ctx, m); err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+// SendInviteEmail sends an admin's one-time signup link
+func (s *EmailService) SendInviteEmail(ctx context.Context, invite *models.UserInvite) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.cfg.Email.FromEmail)
+	m.SetHeader("To", invite.Email)
+	m.SetHeader("Subject", "You're invited to EPR")
+
+	signupURL := fmt.Sprintf("%s/signup/invite?token=%s", s.cfg.App.FrontendURL, url.QueryEscape(invite.Token))
+	body := s.buildInviteEmailBody(invite, signupURL)
+	m.SetBody("text/html", body)
+
+	if err := s.send(ctx, m); err != nil {
+		return fmt.Errorf("failed to send invite email: %w", err)
+	}
+
+	return nil
+}
+
+// SendBillTransferEmail asks the receiving institution to accept a bill transfer
+func (s *EmailService) SendBillTransferEmail(ctx context.Context, to *models.User, bill *models.Bill, transfer *models.BillTransfer) error {
+	if transfer.ConfirmationToken == nil {
+		return fmt.Errorf("bill transfer %s has no confirmation token", transfer.ID)
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.cfg.Email.FromEmail)
+	m.SetHeader("To", to.Email)
+	m.SetHeader("Subject", fmt.Sprintf("Bill %s is being transferred to you - EPR", bill.BillNumber))
+
+	confirmURL := fmt.Sprintf("%s/bills/transfers/confirm?token=%s", s.cfg.App.FrontendURL, url.QueryEscape(*transfer.ConfirmationToken))
+	body := s.buildBillTransferEmailBody(to, bill, confirmURL)
+	m.SetBody("text/html", body)
+
+	if err := s.send(ctx, m); err != nil {
+		return fmt.Errorf("failed to send bill transfer email: %w", err)
+	}
+
+	return nil
+}
+
+// SendKYCStatusEmail notifies an institution that its KYC was approved or rejected
+func (s *EmailService) SendKYCStatusEmail(ctx context.Context, user *models.User) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.cfg.Email.FromEmail)
+	m.SetHeader("To", user.Email)
+
+	subject := "KYC Approved - EPR"
+	if user.KYCStatus == models.KYCRejected {
+		subject = "KYC Rejected - EPR"
+	}
+	m.SetHeader("Subject", subject)
+
+	body := s.buildKYCStatusEmailBody(user)
+	m.SetBody("text/html", body)
+
+	if err := s.send(ctx, m); err != nil {
+		return fmt.Errorf("failed to send KYC status email: %w", err)
+	}
+
+	return nil
+}
+
 // SendLoginNotification sends login notification email
-func (s *EmailService) SendLoginNotification(ctx context.Context, user *models.User, ipAddress string) error {
+// Does nothing if the user turned off login alerts
+func (s *EmailService) SendLoginNotification(ctx context.Context, user *models.User, event *models.LoginEvent) error {
+	if !user.NotificationPreferences.LoginAlerts {
+		return nil
+	}
+
 	m := gomail.NewMessage()
 	m.SetHeader("From", s.cfg.Email.FromEmail)
 	m.SetHeader("To", user.Email)
 	m.SetHeader("Subject", "New Login to Your EPR Account")
 
-	body := s.buildLoginEmailBody(user, ipAddress)
+	body := s.buildLoginEmailBody(user, event)
 	m.SetBody("text/html", body)
 
-	if err := s.dialer.DialAndSend(m); err != nil {
+	if err := s.send(ctx, m); err != nil {
 		return fmt.Errorf("failed to send login notification: %w", err)
 	}
 
@@ -130,7 +304,12 @@ func (s *EmailService) SendLoginNotification(ctx context.Context, user *models.U
 }
 
 // SendLowBalanceWarning sends low balance warning email
+// Does nothing if the user turned off low balance warnings
 func (s *EmailService) SendLowBalanceWarning(ctx context.Context, user *models.User) error {
+	if !user.NotificationPreferences.LowBalance {
+		return nil
+	}
+
 	m := gomail.NewMessage()
 	m.SetHeader("From", s.cfg.Email.FromEmail)
 	m.SetHeader("To", user.Email)
@@ -139,13 +318,68 @@ func (s *EmailService) SendLowBalanceWarning(ctx context.Context, user *models.U
 	body := s.buildLowBalanceEmailBody(user)
 	m.SetBody("text/html", body)
 
-	if err := s.dialer.DialAndSend(m); err != nil {
+	if err := s.send(ctx, m); err != nil {
 		return fmt.Errorf("failed to send low balance warning: %w", err)
 	}
 
 	return nil
 }
 
+// SendVerificationReceipt emails a verifier the receipt for one of their verifications
+// Users who turned receipts off, or whose account is gone, are skipped without error
+func (s *EmailService) SendVerificationReceipt(ctx context.Context, receipt *models.VerificationReceiptMessage) error {
+	user, err := s.userRepo.GetByID(ctx, receipt.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.NotificationPreferences.VerificationReceipts {
+		return nil
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.cfg.Email.FromEmail)
+	m.SetHeader("To", user.Email)
+	m.SetHeader("Subject", fmt.Sprintf("Verification Receipt - %s", receipt.BillNumber))
+
+	body := s.buildVerificationReceiptEmailBody(user, receipt)
+	m.SetBody("text/html", body)
+
+	if err := s.send(ctx, m); err != nil {
+		return fmt.Errorf("failed to send verification receipt: %w", err)
+	}
+
+	return nil
+}
+
+// SendFakeBillAlert warns every active master admin that unknown bill numbers under one prefix are being looked up
+// It is a security alert, so notification preferences don't turn it off
+func (s *EmailService) SendFakeBillAlert(ctx context.Context, alert *models.FakeBillAlertMessage) error {
+	admins, err := s.userRepo.ListActiveMasterAdmins(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range admins {
+		m := gomail.NewMessage()
+		m.SetHeader("From", s.cfg.Email.FromEmail)
+		m.SetHeader("To", user.Email)
+		m.SetHeader("Subject", "Possible Fake Bills - EPR")
+
+		body := s.buildFakeBillAlertEmailBody(user, &alert.Payload)
+		m.SetBody("text/html", body)
+
+		if err := s.send(ctx, m); err != nil {
+			return fmt.Errorf("failed to send fake bill alert: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // SendDailyBillSummary sends daily consolidated bill summary to issuer
 func (s *EmailService) SendDailyBillSummary(ctx context.Context, userID string) error {
 	// Get user
@@ -154,12 +388,12 @@ func (s *EmailService) SendDailyBillSummary(ctx context.Context, userID string)
 		return fmt.Errorf("user not found: %w", err)
 	}
 
-	// Get today's bills
-	today := time.Now()
-	startOfDay := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	// Get today's bills - today is the date in APP_TIMEZONE, wherever the server runs
+	// issue_date is a DATE and Search's end date is inclusive, so both bounds are today's date
+	today := time.Now().In(s.cfg.Location())
+	date := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
 
-	bills, err := s.billRepo.Search(ctx, userID, nil, &startOfDay, &endOfDay, 100, 0)
+	bills, err := s.billRepo.Search(ctx, userID, nil, &date, &date, false, 100, 0)
 	if err != nil {
 		return fmt.Errorf("failed to fetch bills: %w", err)
 	}
@@ -177,7 +411,7 @@ func (s *EmailService) SendDailyBillSummary(ctx context.Context, userID string)
 	body := s.buildDailySummaryEmailBody(user, bills, today)
 	m.SetBody("text/html", body)
 
-	if err := s.dialer.DialAndSend(m); err != nil {
+	if err := s.send(ctx, m); err != nil {
 		return fmt.Errorf("failed to send daily summary: %w", err)
 	}
 
@@ -282,7 +516,226 @@ func (s *EmailService) buildWelcomeEmailBody(user *models.User) string {
 	`, user.FullName, user.WalletBalance, s.cfg.App.FrontendURL)
 }
 
-func (s *EmailService) buildLoginEmailBody(user *models.User, ipAddress string) string {
+func (s *EmailService) buildVerificationEmailBody(user *models.User, verifyURL string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #1f4e78; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .button { display: inline-block; padding: 12px 24px; background-color: #1f4e78; color: white; text-decoration: none; border-radius: 4px; }
+        .footer { text-align: center; padding: 20px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Verify Your Email</h1>
+        </div>
+        <div class="content">
+            <p>Hello %s,</p>
+            <p>Thanks for signing up to <strong>Electronic Public Records (EPR)</strong>. Please confirm your email address by clicking the button below:</p>
+            
+            <p style="text-align: center;"><a class="button" href="%s">Verify Email</a></p>
+            
+            <p>This link expires in %s. If you did not create an account, you can safely ignore this email.</p>
+        </div>
+        <div class="footer">
+            <p>© 2025 EPR. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, user.FullName, verifyURL, s.cfg.App.EmailVerificationExpiry)
+}
+
+func (s *EmailService) buildInviteEmailBody(invite *models.UserInvite, signupURL string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #1f4e78; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .button { display: inline-block; padding: 12px 24px; background-color: #1f4e78; color: white; text-decoration: none; border-radius: 4px; }
+        .footer { text-align: center; padding: 20px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>You're Invited</h1>
+        </div>
+        <div class="content">
+            <p>Hello,</p>
+            <p>You've been invited to join <strong>Electronic Public Records (EPR)</strong> as a <strong>%s</strong>. Click the button below to create your account:</p>
+            
+            <p style="text-align: center;"><a class="button" href="%s">Create Account</a></p>
+            
+            <p>This link expires in %s and can only be used once. If you weren't expecting this invite, you can safely ignore this email.</p>
+        </div>
+        <div class="footer">
+            <p>© 2025 EPR. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, strings.ReplaceAll(string(invite.Role), "_", " "), signupURL, s.cfg.App.InviteExpiry)
+}
+
+func (s *EmailService) buildBillTransferEmailBody(to *models.User, bill *models.Bill, confirmURL string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #1f4e78; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .button { display: inline-block; padding: 12px 24px; background-color: #1f4e78; color: white; text-decoration: none; border-radius: 4px; }
+        .footer { text-align: center; padding: 20px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Bill Transfer Request</h1>
+        </div>
+        <div class="content">
+            <p>Hello %s,</p>
+            <p><strong>%s</strong> wants to transfer bill <strong>%s</strong> to your account. The bill number, contents and verification history stay the same; your institution becomes its issuer.</p>
+            
+            <p style="text-align: center;"><a class="button" href="%s">Accept Transfer</a></p>
+            
+            <p>You need to be logged in as %s to accept. This link expires in %s. If you weren't expecting this transfer, you can safely ignore this email.</p>
+        </div>
+        <div class="footer">
+            <p>© 2025 EPR. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, html.EscapeString(to.FullName), html.EscapeString(bill.IssuerName), bill.BillNumber, confirmURL, html.EscapeString(to.Email), s.cfg.App.BillTransferExpiry)
+}
+
+func (s *EmailService) buildPasswordResetEmailBody(user *models.User, resetURL string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #1f4e78; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .button { display: inline-block; padding: 12px 24px; background-color: #1f4e78; color: white; text-decoration: none; border-radius: 4px; }
+        .alert { background-color: #fff3cd; padding: 15px; border-left: 4px solid #ffc107; margin: 15px 0; }
+        .footer { text-align: center; padding: 20px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Password Reset</h1>
+        </div>
+        <div class="content">
+            <p>Hello %s,</p>
+            <p>We received a request to reset the password for your EPR account. Click the button below to choose a new password:</p>
+            
+            <p style="text-align: center;"><a class="button" href="%s">Reset Password</a></p>
+            
+            <div class="alert">
+                <p>This link expires in %s and can only be used once.</p>
+            </div>
+            
+            <p>If you did not request a password reset, you can safely ignore this email. Your password will not change.</p>
+        </div>
+        <div class="footer">
+            <p>© 2025 EPR. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, user.FullName, resetURL, s.cfg.App.PasswordResetExpiry)
+}
+
+func (s *EmailService) buildKYCStatusEmailBody(user *models.User) string {
+	title := "KYC Approved"
+	message := "<p>Your KYC verification has been <strong>approved</strong>. You can now generate bills on EPR.</p>"
+	if user.KYCStatus == models.KYCRejected {
+		reason := "No reason provided"
+		if user.KYCRejectionReason != nil {
+			reason = html.EscapeString(*user.KYCRejectionReason)
+		}
+		title = "KYC Rejected"
+		message = fmt.Sprintf(`<p>Unfortunately your KYC verification was <strong>rejected</strong>.</p>
+            <div class="alert">
+                <p><strong>Reason:</strong> %s</p>
+            </div>
+            <p>Please correct the issue and resubmit your documents from your dashboard.</p>`, reason)
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #1f4e78; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .alert { background-color: #fff3cd; padding: 15px; border-left: 4px solid #ffc107; margin: 15px 0; }
+        .footer { text-align: center; padding: 20px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+        </div>
+        <div class="content">
+            <p>Dear %s,</p>
+            %s
+        </div>
+        <div class="footer">
+            <p>© 2025 EPR. All rights reserved.</p>
+            <p><a href="%s">Visit EPR Dashboard</a></p>
+        </div>
+    </div>
+</body>
+</html>
+	`, title, user.FullName, message, s.cfg.App.FrontendURL)
+}
+
+func (s *EmailService) buildLoginEmailBody(user *models.User, event *models.LoginEvent) string {
+	ipAddress := "Unknown"
+	if event.IPAddress != nil {
+		ipAddress = html.EscapeString(*event.IPAddress)
+	}
+
+	device := utils.DeviceInfo{}
+	if event.Browser != nil {
+		device.Browser = *event.Browser
+	}
+	if event.OS != nil {
+		device.OS = *event.OS
+	}
+	if event.Device != nil {
+		device.Device = *event.Device
+	}
+
+	// Location is only shown when the IP lookup found one
+	location := ""
+	if event.Location != nil {
+		location = fmt.Sprintf("<p><strong>Approximate Location:</strong> %s</p>", html.EscapeString(*event.Location))
+	}
+
 	return fmt.Sprintf(`
 <!DOCTYPE html>
 <html>
@@ -307,7 +760,9 @@ func (s *EmailService) buildLoginEmailBody(user *models.User, ipAddress string)
             
             <div class="alert">
                 <p><strong>Time:</strong> %s</p>
+                <p><strong>Device:</strong> %s</p>
                 <p><strong>IP Address:</strong> %s</p>
+                %s
             </div>
             
             <p>If this was you, you can safely ignore this email.</p>
@@ -319,7 +774,7 @@ func (s *EmailService) buildLoginEmailBody(user *models.User, ipAddress string)
     </div>
 </body>
 </html>
-	`, user.FullName, time.Now().Format("02 Jan 2006 15:04:05 MST"), ipAddress)
+	`, user.FullName, event.CreatedAt.Format("02 Jan 2006 15:04:05 MST"), html.EscapeString(device.String()), ipAddress, location)
 }
 
 func (s *EmailService) buildLowBalanceEmailBody(user *models.User) string {
@@ -370,6 +825,105 @@ func (s *EmailService) buildLowBalanceEmailBody(user *models.User) string {
 		s.cfg.Pricing.BillGenerationFee, s.cfg.Pricing.VerificationMinFee, s.cfg.Pricing.VerificationMaxFee)
 }
 
+func (s *EmailService) buildVerificationReceiptEmailBody(user *models.User, receipt *models.VerificationReceiptMessage) string {
+	fee := fmt.Sprintf("₹%.2f", receipt.AmountCharged)
+	switch {
+	case receipt.WasFree:
+		fee = "Free (loyalty credit)"
+	case receipt.CoveredByPlan:
+		fee = "Free (covered by your plan)"
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #1f4e78; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .receipt { background-color: white; padding: 15px; border-left: 4px solid #1f4e78; margin: 15px 0; }
+        .footer { text-align: center; padding: 20px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Verification Receipt</h1>
+        </div>
+        <div class="content">
+            <p>Dear %s,</p>
+            <p>Here is the receipt for your recent bill verification:</p>
+            
+            <div class="receipt">
+                <p><strong>Bill Number:</strong> %s</p>
+                <p><strong>Result:</strong> %s</p>
+                <p><strong>Fee Charged:</strong> %s</p>
+                <p><strong>Wallet Balance:</strong> ₹%.2f</p>
+                <p><strong>Verified On:</strong> %s</p>
+                <p><strong>Reference:</strong> %s</p>
+            </div>
+            
+            <p>You can view all your verifications in your <a href="%s/dashboard/verifications">verification history</a>.</p>
+        </div>
+        <div class="footer">
+            <p>You can turn off verification receipts in your notification preferences.</p>
+            <p>© 2025 EPR. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, html.EscapeString(user.FullName), html.EscapeString(receipt.BillNumber), receipt.Result, fee,
+		receipt.BalanceAfter, receipt.VerifiedAt.Format("02 Jan 2006 15:04:05 MST"), receipt.VerificationID, s.cfg.App.FrontendURL)
+}
+
+func (s *EmailService) buildFakeBillAlertEmailBody(user *models.User, alert *models.FakeBillAlertPayload) string {
+	summary := "The prefix is a bill type and month shared by every issuer, so no institution has been alerted."
+	advice := "Check the verification logs for the source of the lookups before contacting any issuer."
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #dc3545; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .warning { background-color: #f8d7da; padding: 15px; border-left: 4px solid #dc3545; margin: 15px 0; }
+        .footer { text-align: center; padding: 20px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>🚨 Possible Fake Bills</h1>
+        </div>
+        <div class="content">
+            <p>Dear %s,</p>
+            
+            <div class="warning">
+                <p><strong>Bill numbers that don't exist are being checked.</strong></p>
+                <p>%s</p>
+                <p>Lookups: <strong>%d</strong> within %s</p>
+                <p>Numbers starting with: <strong>%s</strong></p>
+                <p>Latest number checked: <strong>%s</strong></p>
+                <p>Detected: %s</p>
+            </div>
+            
+            <p>%s</p>
+        </div>
+        <div class="footer">
+            <p>© 2025 EPR. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+	`, html.EscapeString(user.FullName), summary, alert.Attempts, alert.Window, alert.Prefix,
+		html.EscapeString(alert.LastBillNumber), alert.DetectedAt.In(s.cfg.Location()).Format("02 Jan 2006 15:04:05 MST"), advice)
+}
+
 func (s *EmailService) buildDailySummaryEmailBody(user *models.User, bills []*models.Bill, date time.Time) string {
 	// Build bill list HTML
 	billListHTML := ""
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"gopkg.in/gomail.v2"
+)
+
+// stallingSMTPServer accepts connections but never sends the SMTP greeting, like a hung mail server
+func stallingSMTPServer(t *testing.T) (host string, port int) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start stalling SMTP server: %v", err)
+	}
+
+	var conns []net.Conn
+	accepted := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		<-accepted
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	host, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ = strconv.Atoi(portStr)
+	return host, port
+}
+
+// stallingEmailService sends through a stalling SMTP server with the given dialer timeout and no retries
+func stallingEmailService(t *testing.T, timeout time.Duration) *EmailService {
+	t.Helper()
+
+	cfg := testutil.Config(t)
+	cfg.Email.SMTPHost, cfg.Email.SMTPPort = stallingSMTPServer(t)
+	cfg.Email.SMTPTimeout = timeout
+	cfg.Email.RetryMaxAttempts = 1
+	return NewEmailService(cfg, nil, nil, nil, nil)
+}
+
+func TestSendEmailHonorsContextTimeout(t *testing.T) {
+	// The dialer would wait far longer than the caller is willing to
+	svc := stallingEmailService(t, time.Minute)
+	user := &models.User{FullName: "Asha Rao", Email: "asha@example.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := svc.SendWelcomeEmail(ctx, user)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SendWelcomeEmail error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SendWelcomeEmail returned after %v, want soon after the 200ms context timeout", elapsed)
+	}
+}
+
+func TestSendEmailDialerTimeout(t *testing.T) {
+	// Without a caller deadline the dialer's own timeout ends the stalled conversation
+	svc := stallingEmailService(t, 200*time.Millisecond)
+	user := &models.User{FullName: "Asha Rao", Email: "asha@example.com"}
+
+	start := time.Now()
+	err := svc.SendWelcomeEmail(context.Background(), user)
+	if err == nil {
+		t.Fatal("SendWelcomeEmail succeeded against a server that never answers")
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("SendWelcomeEmail error = %v, want a network timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SendWelcomeEmail returned after %v, want soon after the 200ms dialer timeout", elapsed)
+	}
+}
+
+// fakeDialer fails with errs in order, then succeeds, counting every attempt
+type fakeDialer struct {
+	errs     []error
+	attempts int
+}
+
+func (d *fakeDialer) DialAndSend(msgs ...*gomail.Message) error {
+	d.attempts++
+	if d.attempts <= len(d.errs) {
+		return d.errs[d.attempts-1]
+	}
+	return nil
+}
+
+// fakeEmailService sends through dialer, retrying up to maxAttempts times with millisecond backoff
+func fakeEmailService(t *testing.T, dialer mailDialer, maxAttempts int) *EmailService {
+	t.Helper()
+	return &EmailService{
+		cfg:    testutil.Config(t),
+		dialer: dialer,
+		retry:  emailRetryPolicy{maxAttempts: maxAttempts, baseDelay: time.Millisecond},
+	}
+}
+
+func TestSendEmailRetries(t *testing.T) {
+	busy := &textproto.Error{Code: 421, Msg: "Service not available, try again later"}
+	unknownRecipient := &textproto.Error{Code: 550, Msg: "No such user"}
+	timeout := &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{"fails twice then succeeds", []error{busy, timeout}, 3, nil},
+		{"succeeds first time", nil, 1, nil},
+		{"permanent failure is not retried", []error{unknownRecipient}, 1, unknownRecipient},
+		{"gives up after max attempts", []error{busy, busy, busy, busy}, 3, busy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := &fakeDialer{errs: tt.errs}
+			svc := fakeEmailService(t, dialer, 3)
+
+			err := svc.SendWelcomeEmail(context.Background(), &models.User{FullName: "Asha Rao", Email: "asha@example.com"})
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("SendWelcomeEmail failed: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("SendWelcomeEmail error = %v, want %v", err, tt.wantErr)
+			}
+			if dialer.attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", dialer.attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestSendEmailStopsRetryingWhenContextEnds(t *testing.T) {
+	dialer := &fakeDialer{errs: []error{&textproto.Error{Code: 451, Msg: "Try again"}}}
+	svc := fakeEmailService(t, dialer, 5)
+	svc.retry.baseDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := svc.SendWelcomeEmail(ctx, &models.User{FullName: "Asha Rao", Email: "asha@example.com"})
+	if err == nil {
+		t.Fatal("SendWelcomeEmail succeeded, want it to give up while waiting to retry")
+	}
+	if dialer.attempts != 1 {
+		t.Errorf("attempts = %d, want 1", dialer.attempts)
+	}
+}
+
+func TestIsRetryableEmailError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"4xx reply", &textproto.Error{Code: 451, Msg: "Local error"}, true},
+		{"5xx reply", &textproto.Error{Code: 535, Msg: "Authentication failed"}, false},
+		{"wrapped 4xx reply", fmt.Errorf("failed to send message: %w", &textproto.Error{Code: 450, Msg: "Mailbox busy"}), true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"connection dropped", io.EOF, true},
+		{"other error", errors.New("gomail: invalid address"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableEmailError(tt.err); got != tt.want {
+				t.Errorf("isRetryableEmailError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmailRetryBackoff(t *testing.T) {
+	policy := emailRetryPolicy{maxAttempts: 5, baseDelay: 100 * time.Millisecond}
+
+	for attempt := 1; attempt <= 4; attempt++ {
+		base := policy.baseDelay << (attempt - 1)
+		for i := 0; i < 20; i++ {
+			if got := policy.backoff(attempt); got < base || got > base+base/2 {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, got, base, base+base/2)
+			}
+		}
+	}
+
+	if got := (emailRetryPolicy{maxAttempts: 3}).backoff(1); got != 0 {
+		t.Errorf("backoff without a base delay = %v, want 0", got)
+	}
+}
+
+func TestOptionalEmailsRespectPreferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		disable func(*models.NotificationPreferences)
+		send    func(*EmailService, *models.User) error
+	}{
+		{
+			"login alerts",
+			func(p *models.NotificationPreferences) { p.LoginAlerts = false },
+			func(s *EmailService, u *models.User) error {
+				return s.SendLoginNotification(context.Background(), u, &models.LoginEvent{CreatedAt: time.Now()})
+			},
+		},
+		{
+			"low balance warnings",
+			func(p *models.NotificationPreferences) { p.LowBalance = false },
+			func(s *EmailService, u *models.User) error { return s.SendLowBalanceWarning(context.Background(), u) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{FullName: "Asha Rao", Email: "asha@example.com", NotificationPreferences: models.DefaultNotificationPreferences()}
+
+			dialer := &fakeDialer{}
+			if err := tt.send(fakeEmailService(t, dialer, 1), user); err != nil {
+				t.Fatalf("send failed: %v", err)
+			}
+			if dialer.attempts != 1 {
+				t.Errorf("sent %d emails with the default preferences, want 1", dialer.attempts)
+			}
+
+			tt.disable(&user.NotificationPreferences)
+			dialer = &fakeDialer{}
+			if err := tt.send(fakeEmailService(t, dialer, 1), user); err != nil {
+				t.Fatalf("send failed: %v", err)
+			}
+			if dialer.attempts != 0 {
+				t.Errorf("sent %d emails after opting out, want 0", dialer.attempts)
+			}
+		})
+	}
+}
+
+func TestVerificationReceiptFee(t *testing.T) {
+	svc := &EmailService{cfg: testutil.Config(t)}
+	user := &models.User{FullName: "Asha Rao"}
+
+	tests := []struct {
+		name    string
+		receipt models.VerificationReceiptMessage
+		wantFee string
+	}{
+		{"paid", models.VerificationReceiptMessage{AmountCharged: 7.5, BalanceAfter: 92.5}, "₹7.50"},
+		{"loyalty credit", models.VerificationReceiptMessage{WasFree: true, BalanceAfter: 100}, "Free (loyalty credit)"},
+		{"covered by plan", models.VerificationReceiptMessage{CoveredByPlan: true, BalanceAfter: 100}, "Free (covered by your plan)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := tt.receipt
+			receipt.BillNumber = "EPR-OTH-2026-000001"
+			receipt.Result = models.VerificationValid
+			receipt.VerifiedAt = time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+
+			body := svc.buildVerificationReceiptEmailBody(user, &receipt)
+			for _, want := range []string{
+				"<strong>Fee Charged:</strong> " + tt.wantFee + "<",
+				fmt.Sprintf("<strong>Wallet Balance:</strong> ₹%.2f", receipt.BalanceAfter),
+				receipt.BillNumber,
+				"01 Mar 2026 10:30:00 UTC",
+			} {
+				if !strings.Contains(body, want) {
+					t.Errorf("receipt body does not contain %q", want)
+				}
+			}
+		})
+	}
+}
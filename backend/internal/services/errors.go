@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// Sentinel errors returned by services
+// Handlers map these to HTTP status codes with errors.Is
+var (
+	ErrInsufficientBalance      = errors.New("insufficient wallet balance")
+	ErrKYCRequired              = errors.New("KYC verification required to generate bills")
+	ErrEmailNotVerified         = errors.New("email verification required to generate bills")
+	ErrNotInstitution           = errors.New("only institutions can generate bills")
+	ErrBillAccessDenied         = errors.New("access denied to this bill")
+	ErrNotBillOwner             = errors.New("only the issuer can modify this bill")
+	ErrBillHasVerifications     = errors.New("bill amount can't change after it has been verified")
+	ErrBillNotDeleted           = errors.New("bill is not deleted")
+	ErrBillVerifiedNoDelete     = errors.New("bill has been verified and can't be deleted")
+	ErrCannotDeactivateSelf     = errors.New("you can't change the active status of your own account")
+	ErrLastMasterAdmin          = errors.New("the last active master admin can't be demoted")
+	ErrEmailAlreadyRegistered   = errors.New("email already registered")
+	ErrGSTINAlreadyRegistered   = errors.New("GSTIN is already registered to another institution")
+	ErrInviteInvalid            = errors.New("invalid or already used invite")
+	ErrInviteExpired            = errors.New("invite has expired")
+	ErrTwoFactorNotAvailable    = errors.New("two-factor authentication is only available for verifier and admin accounts")
+	ErrTwoFactorAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotStarted      = errors.New("start two-factor setup before confirming it")
+	ErrTwoFactorNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequired        = errors.New("a two-factor authentication code is required")
+	ErrInvalidTwoFactorCode     = errors.New("invalid two-factor authentication code")
+	ErrInvalidTransferTarget    = errors.New("bills can only be transferred to another active institution account with approved KYC")
+	ErrBillTransferInvalid      = errors.New("invalid or already used bill transfer link")
+	ErrBillTransferExpired      = errors.New("bill transfer link has expired")
+	ErrNotTransferRecipient     = errors.New("this bill transfer was sent to a different account")
+	ErrKYCNotApplicable         = errors.New("KYC is only required for institutions")
+	ErrKYCAlreadyApproved       = errors.New("KYC is already approved")
+	ErrKYCNotPending            = errors.New("no pending KYC submission for this user")
+	ErrKYCReasonRequired        = errors.New("a reason is required when rejecting KYC")
+	ErrAdjustmentReasonRequired = errors.New("a reason is required for wallet adjustments")
+	ErrInvalidPaymentSignature  = errors.New("payment signature verification failed")
+	ErrPaymentAlreadyProcessed  = errors.New("payment has already been processed")
+	ErrIdempotencyKeyInUse      = errors.New("this Idempotency-Key has already been used for another request")
+	ErrIdempotencyKeyMismatch   = errors.New("this Idempotency-Key was first used with a different request body")
+	ErrUnreadableQR             = errors.New("could not read a QR code from the image")
+	ErrInvalidQRLink            = errors.New("QR code is not an EPR verification link")
+	ErrVerificationAccessDenied = errors.New("access denied to this verification")
+	ErrCertificateUnavailable   = errors.New("certificates are only available for valid verifications of existing bills")
+	ErrInvalidBillData          = errors.New("bill_data does not match the schema for this bill type")
+	ErrUnsupportedCurrency      = errors.New("unsupported currency")
+	ErrBillAmountTooLarge       = errors.New("bill amount exceeds the maximum allowed")
+	ErrPreviewNotApplicable     = errors.New("allow_preview is only available for government and financial bills")
+	ErrDuplicateBill            = errors.New("an identical bill was created recently")
+	ErrAnonymousQuotaExceeded   = errors.New("daily free verification allowance used up")
+	ErrWebhookURLNotAllowed     = errors.New("webhook URL must use https and resolve to a public address")
+
+	// Re-exported from the repository so handlers only depend on services
+	ErrBillNotFound         = repository.ErrBillNotFound
+	ErrUserNotFound         = repository.ErrUserNotFound
+	ErrPaymentOrderNotFound = repository.ErrPaymentOrderNotFound
+	ErrVerificationNotFound = repository.ErrVerificationNotFound
+)
+
+// errIdempotencyKeyUsed signals that another request claimed the key first
+// CreateBillIdempotent resolves it to the original bill, so it never reaches handlers
+var errIdempotencyKeyUsed = errors.New("idempotency key already claimed")
+
+// BulkBatchTooLargeError is returned when a bulk request exceeds the configured batch size
+type BulkBatchTooLargeError struct {
+	Max int
+	Got int
+}
+
+// Error implements the error interface
+func (e *BulkBatchTooLargeError) Error() string {
+	return fmt.Sprintf("too many bills in one request: %d (maximum %d)", e.Got, e.Max)
+}
+
+// BillExportTooLargeError is returned when more bills match an export than App.BillExportMaxBills allows
+type BillExportTooLargeError struct {
+	Max int
+	Got int
+}
+
+// Error implements the error interface
+func (e *BillExportTooLargeError) Error() string {
+	return fmt.Sprintf("too many bills to export: %d (maximum %d); narrow the date range", e.Got, e.Max)
+}
+
+// BillDataValidationError lists the bill_data fields that failed schema validation
+// errors.Is(err, ErrInvalidBillData) matches it
+type BillDataValidationError struct {
+	BillType models.BillType
+	Fields   []models.BillDataFieldError
+}
+
+// Error implements the error interface
+func (e *BillDataValidationError) Error() string {
+	problems := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		problems[i] = f.Field + " " + f.Message
+	}
+	return fmt.Sprintf("invalid bill_data for %s: %s", e.BillType, strings.Join(problems, ", "))
+}
+
+// Is lets errors.Is match the ErrInvalidBillData sentinel
+func (e *BillDataValidationError) Is(target error) bool {
+	return target == ErrInvalidBillData
+}
+
+// InsufficientBalanceError carries the amounts involved in a failed charge
+// errors.Is(err, ErrInsufficientBalance) matches it
+type InsufficientBalanceError struct {
+	Required  float64
+	Available float64
+}
+
+// Error implements the error interface
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("insufficient wallet balance. Required: ₹%.2f, Available: ₹%.2f", e.Required, e.Available)
+}
+
+// Is lets errors.Is match the ErrInsufficientBalance sentinel
+func (e *InsufficientBalanceError) Is(target error) bool {
+	return target == ErrInsufficientBalance
+}
+
+// DuplicateBillError is returned when the issuer created a bill with the same content within App.DuplicateBillWindow
+type DuplicateBillError struct {
+	BillID     string
+	BillNumber string
+}
+
+// Error implements the error interface
+func (e *DuplicateBillError) Error() string {
+	return fmt.Sprintf("an identical bill (%s) was created recently; set allow_duplicate to create it anyway", e.BillNumber)
+}
+
+// Is lets errors.Is match the ErrDuplicateBill sentinel
+func (e *DuplicateBillError) Is(target error) bool {
+	return target == ErrDuplicateBill
+}
+
+// AnonymousQuotaExceededError is returned when an IP has used up its free verifications for the day
+// errors.Is(err, ErrAnonymousQuotaExceeded) matches it
+type AnonymousQuotaExceededError struct {
+	Limit    int
+	ResetsAt time.Time
+}
+
+// Error implements the error interface
+func (e *AnonymousQuotaExceededError) Error() string {
+	return fmt.Sprintf("the free allowance of %d verifications per day has been used. Log in to keep verifying", e.Limit)
+}
+
+// Is lets errors.Is match the ErrAnonymousQuotaExceeded sentinel
+func (e *AnonymousQuotaExceededError) Is(target error) bool {
+	return target == ErrAnonymousQuotaExceeded
+}
+
+// debitError converts a failed wallet debit into an InsufficientBalanceError when the balance was too low
+func debitError(err error, required, balance float64) error {
+	if errors.Is(err, repository.ErrInsufficientFunds) {
+		return &InsufficientBalanceError{Required: required, Available: balance}
+	}
+	return fmt.Errorf("failed to deduct wallet balance: %w", err)
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// FakeBillAlerter raises an alert when someone keeps looking up bill numbers under one prefix that don't exist,
+// which usually means forged bills are circulating. A prefix is only a bill type and month shared by every
+// issuer, so an unknown number can't be pinned on anyone: the alert goes to the master admins, never to an
+// issuer an anonymous caller could otherwise target. Counters live in Redis; without Redis no alert is ever sent
+type FakeBillAlerter struct {
+	db         *database.DB
+	counters   *repository.ActivityCounterRepository
+	outboxRepo *repository.OutboxRepository
+	cfg        config.FraudConfig
+}
+
+// NewFakeBillAlerter creates a new fake bill alerter
+func NewFakeBillAlerter(
+	db *database.DB,
+	counters *repository.ActivityCounterRepository,
+	outboxRepo *repository.OutboxRepository,
+	cfg *config.Config,
+) *FakeBillAlerter {
+	return &FakeBillAlerter{
+		db:         db,
+		counters:   counters,
+		outboxRepo: outboxRepo,
+		cfg:        cfg.Fraud,
+	}
+}
+
+// RecordNotFound counts a lookup of an unknown bill number and queues an alert the first time its prefix
+// reaches FakeBillAlertThreshold lookups within FakeBillAlertWindow. Errors are logged and never affect the verification
+func (a *FakeBillAlerter) RecordNotFound(ctx context.Context, billNumber string) {
+	if a.cfg.FakeBillAlertThreshold <= 0 {
+		return
+	}
+
+	// Short codes and free text don't belong to any prefix
+	prefix, ok := models.BillNumberPrefix(billNumber)
+	if !ok {
+		return
+	}
+
+	count, err := a.counters.RecordEvent(ctx, "fraud:fake_bill:"+prefix, a.cfg.FakeBillAlertWindow)
+	if err != nil {
+		log.Printf("⚠️  Fake bill alert check failed for %s: %v", billNumber, err)
+		return
+	}
+	if count < a.cfg.FakeBillAlertThreshold {
+		return
+	}
+
+	// Only the lookup that claims the window sends an alert; the rest of the burst is already covered
+	claimed, err := a.counters.IncrementUntil(ctx, "fraud:fake_bill_alerted:"+prefix, time.Now().Add(a.cfg.FakeBillAlertWindow))
+	if err != nil {
+		log.Printf("⚠️  Fake bill alert check failed for %s: %v", billNumber, err)
+		return
+	}
+	if claimed != 1 {
+		return
+	}
+
+	if err := a.queueAlert(ctx, prefix, billNumber, count); err != nil {
+		log.Printf("⚠️  Failed to queue fake bill alert for %s: %v", prefix, err)
+		return
+	}
+
+	log.Printf("🚨 %d lookups of unknown %s bill numbers within %s - alerting master admins", count, prefix, a.cfg.FakeBillAlertWindow)
+}
+
+// queueAlert adds the alert's email to the outbox
+func (a *FakeBillAlerter) queueAlert(ctx context.Context, prefix, billNumber string, count int) error {
+	msg, err := models.NewOutboxMessage(models.OutboxFakeBillAlertEmail, models.FakeBillAlertMessage{
+		Payload: models.FakeBillAlertPayload{
+			Prefix:         prefix,
+			Attempts:       count,
+			Window:         a.cfg.FakeBillAlertWindow.String(),
+			LastBillNumber: billNumber,
+			DetectedAt:     time.Now().UTC(),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode fake bill alert: %w", err)
+	}
+
+	return a.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		return a.outboxRepo.CreateTx(ctx, tx, msg)
+	})
+}
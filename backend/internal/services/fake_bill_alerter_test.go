@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+// fakeBillAlerter returns a FakeBillAlerter counting in a fresh Redis that alerts after threshold lookups within an hour
+func (e *testEnv) fakeBillAlerter(t *testing.T, threshold int) *FakeBillAlerter {
+	t.Helper()
+
+	e.cfg.Fraud.FakeBillAlertThreshold = threshold
+	e.cfg.Fraud.FakeBillAlertWindow = time.Hour
+	return NewFakeBillAlerter(e.db, repository.NewActivityCounterRepository(testutil.Redis(t)), e.outbox, e.cfg)
+}
+
+// numberedBill creates a bill for issuer and renumbers it to number
+func (e *testEnv) numberedBill(t *testing.T, issuer *models.User, number string) {
+	t.Helper()
+
+	bill := testutil.CreateBill(t, e.db, issuer, 100, nil)
+	e.db.MustExec("UPDATE bills SET bill_number = $1 WHERE id = $2", number, bill.ID)
+}
+
+// lookUpUnknown reports n lookups of unknown numbers under prefix, all at once
+func lookUpUnknown(alerter *FakeBillAlerter, prefix string, n int) {
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			alerter.RecordNotFound(context.Background(), fmt.Sprintf("%s%06d", prefix, 900000+i))
+		}(i)
+	}
+	wg.Wait()
+}
+
+// queuedFakeBillAlerts returns the fake bill alerts queued for prefix
+func (e *testEnv) queuedFakeBillAlerts(t *testing.T, prefix string) []models.FakeBillAlertMessage {
+	t.Helper()
+
+	var payloads []json.RawMessage
+	if err := e.db.Select(&payloads, "SELECT payload FROM outbox WHERE kind = $1 AND payload->'payload'->>'prefix' = $2",
+		models.OutboxFakeBillAlertEmail, prefix); err != nil {
+		t.Fatalf("failed to read outbox: %v", err)
+	}
+
+	alerts := make([]models.FakeBillAlertMessage, len(payloads))
+	for i, payload := range payloads {
+		if err := json.Unmarshal(payload, &alerts[i]); err != nil {
+			t.Fatalf("invalid alert payload: %v", err)
+		}
+	}
+	return alerts
+}
+
+func TestFakeBillBurstAlertsAdminsOnce(t *testing.T) {
+	env := newTestEnv(t)
+	alerter := env.fakeBillAlerter(t, 5)
+
+	// Below the threshold nothing is queued
+	lookUpUnknown(alerter, "SAL209901", 4)
+	if alerts := env.queuedFakeBillAlerts(t, "SAL209901"); len(alerts) != 0 {
+		t.Fatalf("alerts below the threshold = %d, want 0", len(alerts))
+	}
+
+	// Only one lookup of the burst may claim the alert
+	lookUpUnknown(alerter, "SAL209901", 20)
+	alerts := env.queuedFakeBillAlerts(t, "SAL209901")
+	if len(alerts) != 1 {
+		t.Fatalf("alerts = %d, want exactly 1", len(alerts))
+	}
+	if alerts[0].Payload.Attempts < 5 {
+		t.Errorf("alert reports %d attempts, want at least the threshold", alerts[0].Payload.Attempts)
+	}
+
+	// Numbers that aren't in the generated format are never counted
+	for i := 0; i < 10; i++ {
+		alerter.RecordNotFound(context.Background(), "ABC123")
+	}
+	if n := testutil.Count(t, env.db, "outbox", "kind = $1", models.OutboxFakeBillAlertEmail); n != 1 {
+		t.Errorf("alert messages = %d, want still 1", n)
+	}
+}
+
+func TestFakeBillAlertsNeverTargetIssuers(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		issuers int
+	}{
+		{"two issuers share a type and month", "MED209902", 2},
+		// The only issuer of a month so far must not become the target of anyone flooding it
+		{"attacker floods a month with a single issuer", "INV209903", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			alerter := env.fakeBillAlerter(t, 3)
+			admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+
+			var issuers []*models.User
+			for i := 1; i <= tt.issuers; i++ {
+				issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+				env.db.MustExec("UPDATE users SET webhook_url = 'https://example.com/hook', webhook_secret = 'secret' WHERE id = $1", issuer.ID)
+				env.numberedBill(t, issuer, fmt.Sprintf("%s%06d", tt.prefix, i))
+				issuers = append(issuers, issuer)
+			}
+
+			lookUpUnknown(alerter, tt.prefix, 10)
+
+			alerts := env.queuedFakeBillAlerts(t, tt.prefix)
+			if len(alerts) != 1 {
+				t.Fatalf("alerts = %d, want exactly 1", len(alerts))
+			}
+			if n := testutil.Count(t, env.db, "outbox", "kind <> $1", models.OutboxFakeBillAlertEmail); n != 0 {
+				t.Errorf("other outbox messages = %d, want 0 (no issuer webhook)", n)
+			}
+
+			smtp := newSMTPRecorder(t)
+			if err := env.emailServiceVia(smtp.Addr()).SendFakeBillAlert(context.Background(), &alerts[0]); err != nil {
+				t.Fatalf("SendFakeBillAlert failed: %v", err)
+			}
+			if n := len(smtp.SentTo(admin.Email)); n != 1 {
+				t.Errorf("alert emails to the master admin = %d, want 1", n)
+			}
+			for _, issuer := range issuers {
+				if n := len(smtp.SentTo(issuer.Email)); n != 0 {
+					t.Errorf("alert emails to issuer %s = %d, want 0", issuer.ID, n)
+				}
+			}
+		})
+	}
+}
+
+func TestFakeBillAlertsDisabled(t *testing.T) {
+	env := newTestEnv(t)
+	alerter := env.fakeBillAlerter(t, 0)
+
+	lookUpUnknown(alerter, "SAL209901", 20)
+	if n := testutil.Count(t, env.db, "outbox", "kind = $1", models.OutboxFakeBillAlertEmail); n != 0 {
+		t.Errorf("alert messages with alerts disabled = %d, want 0", n)
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+// testEnv wires the repositories service tests need against the test database
+type testEnv struct {
+	db            *database.DB
+	cfg           *config.Config
+	users         *repository.UserRepository
+	bills         *repository.BillRepository
+	aliases       *repository.BillAliasRepository
+	verifications *repository.VerificationRepository
+	walletTxs     *repository.WalletTransactionRepository
+	idempotency   *repository.IdempotencyKeyRepository
+	outbox        *repository.OutboxRepository
+	audit         *repository.AuditRepository
+}
+
+// newTestEnv returns a test environment on an empty database, or skips the test without one
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	db := testutil.DB(t)
+	return &testEnv{
+		db:            db,
+		cfg:           testutil.Config(t),
+		users:         repository.NewUserRepository(db.DB),
+		bills:         repository.NewBillRepository(db.DB, nil, 0),
+		aliases:       repository.NewBillAliasRepository(db.DB),
+		verifications: repository.NewVerificationRepository(db.DB),
+		walletTxs:     repository.NewWalletTransactionRepository(db.DB),
+		idempotency:   repository.NewIdempotencyKeyRepository(db.DB),
+		outbox:        repository.NewOutboxRepository(db.DB),
+		audit:         repository.NewAuditRepository(db.DB),
+	}
+}
+
+// billService returns a BillService without a low balance notifier, so tests never send email
+func (e *testEnv) billService() *BillService {
+	return NewBillService(e.db, e.bills, e.aliases, e.users, e.verifications, e.walletTxs, e.idempotency, e.outbox, e.audit, nil, e.cfg)
+}
+
+// verificationService returns a VerificationService without Redis or a low balance notifier
+// Its pricing is read from e.cfg now, so adjust the config first
+func (e *testEnv) verificationService() *VerificationService {
+	return e.verificationServiceNotifying(nil)
+}
+
+// verificationServiceNotifying is like verificationService but warns about low balances through notifier
+func (e *testEnv) verificationServiceNotifying(notifier *LowBalanceNotifier) *VerificationService {
+	counters := repository.NewActivityCounterRepository(nil)
+	return NewVerificationService(e.db, e.verifications, e.bills, e.aliases, e.users, e.walletTxs, e.outbox, counters,
+		NewSuspiciousActivityDetector(counters, e.cfg), NewFakeBillAlerter(e.db, counters, e.outbox, e.cfg), notifier, e.cfg)
+}
+
+// billRequest returns a valid request for an "other" bill, which accepts any bill_data
+func billRequest(amount float64) *models.CreateBillRequest {
+	return &models.CreateBillRequest{
+		BillType:    models.BillTypeOther,
+		AccessLevel: models.AccessLevelPublic,
+		Amount:      amount,
+		IssueDate:   time.Now().Format("2006-01-02"),
+		BillData:    map[string]interface{}{"recipient_name": "Asha Rao", "description": "Consulting"},
+	}
+}
+
+// balanceOf reads a user's current wallet balance
+func (e *testEnv) balanceOf(t *testing.T, userID string) float64 {
+	t.Helper()
+
+	var balance float64
+	if err := e.db.Get(&balance, "SELECT wallet_balance FROM users WHERE id = $1", userID); err != nil {
+		t.Fatalf("failed to read wallet balance: %v", err)
+	}
+	return balance
+}
+
+// emailService returns an EmailService whose SMTP server refuses connections, so background sends fail fast
+func (e *testEnv) emailService() *EmailService {
+	return e.emailServiceVia("127.0.0.1:1")
+}
+
+// emailServiceVia returns an EmailService that sends through the SMTP server at addr, without retries
+func (e *testEnv) emailServiceVia(addr string) *EmailService {
+	host, port, _ := net.SplitHostPort(addr)
+
+	cfg := *e.cfg
+	cfg.Email.SMTPHost = host
+	cfg.Email.SMTPPort, _ = strconv.Atoi(port)
+	cfg.Email.SMTPTimeout = time.Second
+	cfg.Email.RetryMaxAttempts = 1
+	return NewEmailService(&cfg, e.bills, e.aliases, e.users, nil)
+}
+
+// sentEmail is a message received by an smtpRecorder
+type sentEmail struct {
+	To   []string
+	Data string
+}
+
+// Text returns the message with quoted-printable encoding undone, so long body lines can be searched
+func (e sentEmail) Text() string {
+	decoded, _ := io.ReadAll(quotedprintable.NewReader(strings.NewReader(e.Data)))
+	return string(decoded)
+}
+
+// smtpRecorder is a minimal SMTP server that accepts every message and keeps it
+type smtpRecorder struct {
+	listener net.Listener
+
+	mu   sync.Mutex
+	sent []sentEmail
+}
+
+// newSMTPRecorder starts an SMTP server on a free local port until the test ends
+func newSMTPRecorder(t *testing.T) *smtpRecorder {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start SMTP recorder: %v", err)
+	}
+	r := &smtpRecorder{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	return r
+}
+
+// Addr is the host:port to send to
+func (r *smtpRecorder) Addr() string {
+	return r.listener.Addr().String()
+}
+
+// serve speaks just enough SMTP for net/smtp: no extensions, every command accepted
+func (r *smtpRecorder) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+
+	var to []string
+	text.PrintfLine("220 smtp-recorder ready")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "RCPT":
+			address := strings.TrimSuffix(strings.TrimPrefix(line[len("RCPT TO:"):], "<"), ">")
+			to = append(to, address)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 Go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			r.mu.Lock()
+			r.sent = append(r.sent, sentEmail{To: to, Data: string(data)})
+			r.mu.Unlock()
+			to = nil
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+// SentTo returns the messages received for address so far
+func (r *smtpRecorder) SentTo(address string) []sentEmail {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sent []sentEmail
+	for _, email := range r.sent {
+		for _, to := range email.To {
+			if to == address {
+				sent = append(sent, email)
+				break
+			}
+		}
+	}
+	return sent
+}
+
+// WaitForSent waits up to wait for at least n messages to address and returns what arrived
+func (r *smtpRecorder) WaitForSent(address string, n int, wait time.Duration) []sentEmail {
+	deadline := time.Now().Add(wait)
+	for {
+		sent := r.SentTo(address)
+		if len(sent) >= n || time.Now().After(deadline) {
+			return sent
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
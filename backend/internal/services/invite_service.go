@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// InviteService issues admin signup invites and creates accounts from them
+// Invites are how users get roles that SIGNUP_ALLOWED_ROLES doesn't let them pick themselves
+type InviteService struct {
+	db           *database.DB
+	userRepo     *repository.UserRepository
+	inviteRepo   *repository.InviteRepository
+	auditRepo    *repository.AuditRepository
+	emailService *EmailService
+	cfg          *config.Config
+}
+
+// NewInviteService creates a new invite service
+func NewInviteService(
+	db *database.DB,
+	userRepo *repository.UserRepository,
+	inviteRepo *repository.InviteRepository,
+	auditRepo *repository.AuditRepository,
+	emailService *EmailService,
+	cfg *config.Config,
+) *InviteService {
+	return &InviteService{
+		db:           db,
+		userRepo:     userRepo,
+		inviteRepo:   inviteRepo,
+		auditRepo:    auditRepo,
+		emailService: emailService,
+		cfg:          cfg,
+	}
+}
+
+// CreateInvite stores a one-time signup link for req.Role and emails it to req.Email
+// The email is sent in the background; a failed send is logged and the admin can invite again
+func (s *InviteService) CreateInvite(ctx context.Context, adminID string, req *models.CreateInviteRequest) (*models.UserInvite, error) {
+	exists, err := s.userRepo.EmailExists(ctx, req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email availability: %w", err)
+	}
+	if exists {
+		return nil, ErrEmailAlreadyRegistered
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+
+	invite := &models.UserInvite{
+		Email:     req.Email,
+		Role:      req.Role,
+		Token:     token,
+		InvitedBy: adminID,
+		ExpiresAt: time.Now().Add(s.cfg.App.InviteExpiry),
+	}
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := s.inviteRepo.CreateTx(ctx, tx, invite); err != nil {
+			return err
+		}
+		return s.auditRepo.RecordTx(ctx, tx, adminID, models.AuditInviteCreate, models.AuditTargetInvite, invite.ID, map[string]interface{}{
+			"email":      invite.Email,
+			"role":       invite.Role,
+			"expires_at": invite.ExpiresAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// SMTP can be slow - don't hold up the admin's response
+	go func(invite models.UserInvite) {
+		sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := s.emailService.SendInviteEmail(sendCtx, &invite); err != nil {
+			log.Printf("⚠️  Failed to send invite to %s: %v", invite.Email, err)
+		}
+	}(*invite)
+
+	log.Printf("✉️  Admin %s invited %s as %s", adminID, invite.Email, invite.Role)
+
+	return invite, nil
+}
+
+// SignupWithInvite creates an account with the invite's email and role and marks the invite used
+// The email is already verified, since the invite link was delivered to it
+func (s *InviteService) SignupWithInvite(ctx context.Context, req *models.InviteSignupRequest) (*models.User, error) {
+	invite, err := s.inviteRepo.GetUnusedByToken(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, repository.ErrInviteNotFound) {
+			return nil, ErrInviteInvalid
+		}
+		return nil, err
+	}
+
+	if time.Now().After(invite.ExpiresAt) {
+		return nil, ErrInviteExpired
+	}
+
+	exists, err := s.userRepo.EmailExists(ctx, invite.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email availability: %w", err)
+	}
+	if exists {
+		return nil, ErrEmailAlreadyRegistered
+	}
+
+	// Optionally allow one institution account per GSTIN
+	if s.cfg.App.UniqueInstitutionGSTIN && invite.Role.IsInstitution() && req.GSTIN != "" {
+		taken, err := s.userRepo.InstitutionGSTINExists(ctx, req.GSTIN)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrGSTINAlreadyRegistered
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &models.User{
+		FullName:         req.FullName,
+		Email:            invite.Email,
+		PasswordHash:     hashedPassword,
+		Role:             invite.Role,
+		OrganizationName: req.OrganizationName,
+		KYCStatus:        models.InitialKYCStatus(invite.Role),
+		IsActive:         true,
+		IsEmailVerified:  true,
+
+		NotificationPreferences: models.DefaultNotificationPreferences(),
+	}
+	if req.OrganizationType != "" {
+		user.OrganizationType = &req.OrganizationType
+	}
+	if req.GSTIN != "" {
+		user.GSTIN = &req.GSTIN
+	}
+	if req.PAN != "" {
+		user.PAN = &req.PAN
+	}
+
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := s.userRepo.CreateTx(ctx, tx, user); err != nil {
+			return err
+		}
+
+		// Another request may have used the invite since we read it
+		if err := s.inviteRepo.MarkUsedTx(ctx, tx, invite.ID, user.ID); err != nil {
+			if errors.Is(err, repository.ErrInviteNotFound) {
+				return ErrInviteInvalid
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
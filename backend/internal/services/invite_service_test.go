@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestSignupWithInvite(t *testing.T) {
+	env := newTestEnv(t)
+	invites := repository.NewInviteRepository(env.db.DB)
+	svc := NewInviteService(env.db, env.users, invites, env.audit, env.emailService(), env.cfg)
+	ctx := context.Background()
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+
+	signup := func(token string) (*models.User, error) {
+		return svc.SignupWithInvite(ctx, &models.InviteSignupRequest{
+			Token:            token,
+			FullName:         "Asha Rao",
+			Password:         "correct horse battery",
+			OrganizationName: "Rao Audits",
+		})
+	}
+
+	invite, err := svc.CreateInvite(ctx, admin.ID, &models.CreateInviteRequest{Email: "asha@example.com", Role: models.RoleVerifier})
+	if err != nil {
+		t.Fatalf("CreateInvite failed: %v", err)
+	}
+	if n := testutil.Count(t, env.db, "audit_log", "actor_id = $1 AND action = $2", admin.ID, models.AuditInviteCreate); n != 1 {
+		t.Errorf("audit entries = %d, want 1", n)
+	}
+
+	user, err := signup(invite.Token)
+	if err != nil {
+		t.Fatalf("SignupWithInvite failed: %v", err)
+	}
+	if user.Role != models.RoleVerifier || user.Email != "asha@example.com" || !user.IsEmailVerified {
+		t.Errorf("user = %s %s (email verified %v), want a verified verifier for the invited email", user.Role, user.Email, user.IsEmailVerified)
+	}
+
+	t.Run("invites are single use", func(t *testing.T) {
+		if _, err := signup(invite.Token); !errors.Is(err, ErrInviteInvalid) {
+			t.Errorf("reusing the invite error = %v, want %v", err, ErrInviteInvalid)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		if _, err := signup("not-a-token"); !errors.Is(err, ErrInviteInvalid) {
+			t.Errorf("unknown token error = %v, want %v", err, ErrInviteInvalid)
+		}
+	})
+
+	t.Run("expired invite", func(t *testing.T) {
+		expired, err := svc.CreateInvite(ctx, admin.ID, &models.CreateInviteRequest{Email: "late@example.com", Role: models.RoleVerifier})
+		if err != nil {
+			t.Fatalf("CreateInvite failed: %v", err)
+		}
+		env.db.MustExec("UPDATE user_invites SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", expired.ID)
+
+		if _, err := signup(expired.Token); !errors.Is(err, ErrInviteExpired) {
+			t.Errorf("expired invite error = %v, want %v", err, ErrInviteExpired)
+		}
+	})
+
+	t.Run("already registered email", func(t *testing.T) {
+		if _, err := svc.CreateInvite(ctx, admin.ID, &models.CreateInviteRequest{Email: "asha@example.com", Role: models.RoleVerifier}); !errors.Is(err, ErrEmailAlreadyRegistered) {
+			t.Errorf("inviting a registered email error = %v, want %v", err, ErrEmailAlreadyRegistered)
+		}
+	})
+}
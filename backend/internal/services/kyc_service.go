@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// KYCService handles institution KYC submission and review
+type KYCService struct {
+	db           *database.DB
+	userRepo     *repository.UserRepository
+	auditRepo    *repository.AuditRepository
+	emailService *EmailService
+}
+
+// NewKYCService creates a new KYC service
+func NewKYCService(
+	db *database.DB,
+	userRepo *repository.UserRepository,
+	auditRepo *repository.AuditRepository,
+	emailService *EmailService,
+) *KYCService {
+	return &KYCService{
+		db:           db,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		emailService: emailService,
+	}
+}
+
+// Submit stores an institution's KYC documents for admin review
+// Rejected institutions can resubmit; approved ones can't
+func (s *KYCService) Submit(ctx context.Context, userID string, documents []models.KYCDocument) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.Role != models.RoleInstitutionUser && user.Role != models.RoleInstitutionAdmin {
+		return nil, ErrKYCNotApplicable
+	}
+
+	if user.KYCStatus == models.KYCApproved {
+		return nil, ErrKYCAlreadyApproved
+	}
+
+	documentsJSON, err := json.Marshal(documents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal KYC documents: %w", err)
+	}
+
+	if err := s.userRepo.SubmitKYC(ctx, userID, documentsJSON); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+// ListPending retrieves KYC submissions awaiting review
+func (s *KYCService) ListPending(ctx context.Context, page, pageSize int) ([]*models.User, int, error) {
+	offset := (page - 1) * pageSize
+
+	users, err := s.userRepo.ListPendingKYC(ctx, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.userRepo.CountPendingKYC(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// Review approves or rejects a pending KYC submission and notifies the institution
+// Returns ErrKYCNotPending unless the user has submitted documents that are awaiting review
+func (s *KYCService) Review(ctx context.Context, reviewerID, userID string, req *models.ReviewKYCRequest) (*models.User, error) {
+	status := models.KYCApproved
+	var reason *string
+	if req.Decision == "reject" {
+		trimmed := strings.TrimSpace(req.Reason)
+		if trimmed == "" {
+			return nil, ErrKYCReasonRequired
+		}
+		status = models.KYCRejected
+		reason = &trimmed
+	}
+
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		before, err := s.userRepo.GetByIDAnyForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.UpdateKYCStatusTx(ctx, tx, userID, reviewerID, status, reason); err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return ErrKYCNotPending
+			}
+			return err
+		}
+
+		return s.auditRepo.RecordTx(ctx, tx, reviewerID, models.AuditKYCReview, models.AuditTargetUser, userID, models.AuditChange(
+			map[string]interface{}{"kyc_status": before.KYCStatus},
+			map[string]interface{}{"kyc_status": status, "reason": reason},
+		))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The account may have been deactivated while its KYC was pending
+	user, err := s.userRepo.GetByIDAny(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Notify in the background - the decision is already saved
+	go func(user models.User) {
+		if err := s.emailService.SendKYCStatusEmail(context.Background(), &user); err != nil {
+			log.Printf("⚠️  Failed to send KYC status email to %s: %v", user.Email, err)
+		}
+	}(*user)
+
+	return user, nil
+}
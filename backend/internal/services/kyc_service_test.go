@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestKYCReviewTransitions(t *testing.T) {
+	env := newTestEnv(t)
+	svc := NewKYCService(env.db, env.users, env.audit, env.emailService())
+	ctx := context.Background()
+
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	documents := []models.KYCDocument{{Type: "gst_certificate", URL: "https://example.com/gst.pdf", Number: "29ABCDE1234F1Z5"}}
+
+	// submitted creates an institution that hasn't been through KYC yet and submits its documents
+	submitted := func(t *testing.T) *models.User {
+		t.Helper()
+		institution := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+		env.db.MustExec("UPDATE users SET kyc_status = 'pending', kyc_verified_at = NULL WHERE id = $1", institution.ID)
+
+		user, err := svc.Submit(ctx, institution.ID, documents)
+		if err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		if user.KYCStatus != models.KYCPending || user.KYCDocuments == nil {
+			t.Fatalf("after submit: status %s, documents %v; want pending with documents", user.KYCStatus, user.KYCDocuments)
+		}
+		return user
+	}
+
+	t.Run("pending to approved", func(t *testing.T) {
+		institution := submitted(t)
+
+		pending, total, err := svc.ListPending(ctx, 1, 20)
+		if err != nil {
+			t.Fatalf("ListPending failed: %v", err)
+		}
+		found := false
+		for _, u := range pending {
+			found = found || u.ID == institution.ID
+		}
+		if !found || total < 1 {
+			t.Errorf("submission missing from the pending list (%d of %d)", len(pending), total)
+		}
+
+		user, err := svc.Review(ctx, admin.ID, institution.ID, &models.ReviewKYCRequest{Decision: "approve"})
+		if err != nil {
+			t.Fatalf("Review failed: %v", err)
+		}
+		if user.KYCStatus != models.KYCApproved || user.KYCVerifiedBy == nil || *user.KYCVerifiedBy != admin.ID {
+			t.Errorf("after approval: status %s, verified by %v; want approved by %s", user.KYCStatus, user.KYCVerifiedBy, admin.ID)
+		}
+		if n := testutil.Count(t, env.db, "audit_log", "action = $1 AND target_id = $2", models.AuditKYCReview, institution.ID); n != 1 {
+			t.Errorf("audit entries = %d, want 1", n)
+		}
+
+		// Approved institutions can neither be reviewed again nor resubmit
+		if _, err := svc.Review(ctx, admin.ID, institution.ID, &models.ReviewKYCRequest{Decision: "reject", Reason: "late"}); !errors.Is(err, ErrKYCNotPending) {
+			t.Errorf("second review = %v, want ErrKYCNotPending", err)
+		}
+		if _, err := svc.Submit(ctx, institution.ID, documents); !errors.Is(err, ErrKYCAlreadyApproved) {
+			t.Errorf("resubmit = %v, want ErrKYCAlreadyApproved", err)
+		}
+	})
+
+	t.Run("pending to rejected", func(t *testing.T) {
+		institution := submitted(t)
+
+		if _, err := svc.Review(ctx, admin.ID, institution.ID, &models.ReviewKYCRequest{Decision: "reject", Reason: "  "}); !errors.Is(err, ErrKYCReasonRequired) {
+			t.Errorf("reject without reason = %v, want ErrKYCReasonRequired", err)
+		}
+
+		user, err := svc.Review(ctx, admin.ID, institution.ID, &models.ReviewKYCRequest{Decision: "reject", Reason: "GST certificate is illegible"})
+		if err != nil {
+			t.Fatalf("Review failed: %v", err)
+		}
+		if user.KYCStatus != models.KYCRejected || user.KYCRejectionReason == nil || *user.KYCRejectionReason != "GST certificate is illegible" {
+			t.Errorf("after rejection: status %s, reason %v", user.KYCStatus, user.KYCRejectionReason)
+		}
+
+		// Rejected institutions can resubmit, which clears the reason
+		resubmitted, err := svc.Submit(ctx, institution.ID, documents)
+		if err != nil {
+			t.Fatalf("resubmit failed: %v", err)
+		}
+		if resubmitted.KYCStatus != models.KYCPending || resubmitted.KYCRejectionReason != nil {
+			t.Errorf("after resubmit: status %s, reason %v; want pending without a reason", resubmitted.KYCStatus, resubmitted.KYCRejectionReason)
+		}
+	})
+
+	t.Run("not an institution", func(t *testing.T) {
+		verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+		if _, err := svc.Submit(ctx, verifier.ID, documents); !errors.Is(err, ErrKYCNotApplicable) {
+			t.Errorf("Submit = %v, want ErrKYCNotApplicable", err)
+		}
+	})
+}
+
+func TestKYCReviewOfDeactivatedInstitution(t *testing.T) {
+	env := newTestEnv(t)
+	svc := NewKYCService(env.db, env.users, env.audit, env.emailService())
+	ctx := context.Background()
+
+	admin := testutil.CreateUser(t, env.db, models.RoleMasterAdmin, 0)
+	institution := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	env.db.MustExec("UPDATE users SET kyc_status = 'pending', is_active = false WHERE id = $1", institution.ID)
+
+	// Admin actions reach accounts that normal flows can't see
+	user, err := svc.Review(ctx, admin.ID, institution.ID, &models.ReviewKYCRequest{Decision: "reject", Reason: "Account closed"})
+	if err != nil {
+		t.Fatalf("Review of a deactivated institution failed: %v", err)
+	}
+	if user.KYCStatus != models.KYCRejected || user.IsActive {
+		t.Errorf("after review: kyc %s, active %v; want rejected and still inactive", user.KYCStatus, user.IsActive)
+	}
+}
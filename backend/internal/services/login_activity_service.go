@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/geoip"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+// loginHistoryKeep is how many recent sign-ins are kept per user
+const loginHistoryKeep = 10
+
+// LoginActivityService records sign-ins and alerts users about them
+type LoginActivityService struct {
+	historyRepo  *repository.LoginHistoryRepository
+	emailService *EmailService
+	locator      geoip.Locator
+}
+
+// NewLoginActivityService creates a new login activity service
+func NewLoginActivityService(historyRepo *repository.LoginHistoryRepository, emailService *EmailService, locator geoip.Locator) *LoginActivityService {
+	return &LoginActivityService{
+		historyRepo:  historyRepo,
+		emailService: emailService,
+		locator:      locator,
+	}
+}
+
+// RecordLogin stores a successful sign-in and emails the user about it, if they want login alerts
+// Runs in the background so a slow lookup or SMTP server doesn't delay the login response
+func (s *LoginActivityService) RecordLogin(user *models.User, ip, userAgent string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		event := s.newLoginEvent(ctx, user.ID, ip, userAgent)
+		if err := s.historyRepo.Create(ctx, event, loginHistoryKeep); err != nil {
+			log.Printf("⚠️  Failed to record login for %s: %v", user.ID, err)
+		}
+
+		if err := s.emailService.SendLoginNotification(ctx, user, event); err != nil {
+			log.Printf("⚠️  Failed to send login notification to %s: %v", user.Email, err)
+		}
+	}()
+}
+
+// ListSessions returns the user's recent sign-ins, newest first
+func (s *LoginActivityService) ListSessions(ctx context.Context, userID string) ([]*models.LoginEvent, error) {
+	return s.historyRepo.ListByUser(ctx, userID, loginHistoryKeep)
+}
+
+// newLoginEvent describes a sign-in with the device and coarse location it came from
+// A failed location lookup only leaves the location out
+func (s *LoginActivityService) newLoginEvent(ctx context.Context, userID, ip, userAgent string) *models.LoginEvent {
+	event := &models.LoginEvent{
+		UserID:    userID,
+		IPAddress: optionalString(ip),
+		UserAgent: optionalString(userAgent),
+		CreatedAt: time.Now(),
+	}
+
+	device := utils.ParseUserAgent(userAgent)
+	event.Browser = optionalString(device.Browser)
+	event.OS = optionalString(device.OS)
+	event.Device = optionalString(device.Device)
+
+	location, err := s.locator.Locate(ctx, ip)
+	if err != nil {
+		log.Printf("⚠️  Failed to locate login IP %s: %v", ip, err)
+	} else if location != nil {
+		event.Location = optionalString(location.String())
+	}
+
+	return event
+}
+
+// optionalString returns nil for an empty string so it is stored as NULL
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/geoip"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+// fixedLocator places every IP at the same location
+type fixedLocator struct {
+	location *geoip.Location
+}
+
+func (l fixedLocator) Locate(ctx context.Context, ip string) (*geoip.Location, error) {
+	return l.location, nil
+}
+
+func TestRecordLoginSendsAlertWithDeviceAndLocation(t *testing.T) {
+	env := newTestEnv(t)
+	smtp := newSMTPRecorder(t)
+	history := repository.NewLoginHistoryRepository(env.db.DB)
+	locator := fixedLocator{&geoip.Location{City: "Chennai", Region: "Tamil Nadu", Country: "India"}}
+	svc := NewLoginActivityService(history, env.emailServiceVia(smtp.Addr()), locator)
+
+	const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	svc.RecordLogin(user, "203.0.113.7", userAgent)
+
+	sent := smtp.WaitForSent(user.Email, 1, 5*time.Second)
+	if len(sent) != 1 {
+		t.Fatalf("login alerts sent = %d, want 1", len(sent))
+	}
+	for _, want := range []string{"203.0.113.7", "Chrome on Windows (Desktop)", "Chennai, Tamil Nadu, India"} {
+		if !strings.Contains(sent[0].Text(), want) {
+			t.Errorf("login alert doesn't mention %q", want)
+		}
+	}
+
+	sessions, err := svc.ListSessions(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("sessions = %d, want 1", len(sessions))
+	}
+	if s := sessions[0]; s.Browser == nil || *s.Browser != "Chrome" || s.Location == nil || *s.Location != "Chennai, Tamil Nadu, India" {
+		t.Errorf("session = %+v, want a Chrome sign-in from Chennai", s)
+	}
+}
+
+func TestRecordLoginWithoutLoginAlerts(t *testing.T) {
+	env := newTestEnv(t)
+	smtp := newSMTPRecorder(t)
+	history := repository.NewLoginHistoryRepository(env.db.DB)
+	svc := NewLoginActivityService(history, env.emailServiceVia(smtp.Addr()), geoip.NewNoopLocator())
+
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	user.NotificationPreferences.LoginAlerts = false
+	svc.RecordLogin(user, "203.0.113.7", "curl/8.4.0")
+
+	// The sign-in is still recorded, just without an email
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.Count(t, env.db, "login_history", "user_id = $1", user.ID) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("login was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sent := smtp.WaitForSent(user.Email, 1, 200*time.Millisecond); len(sent) != 0 {
+		t.Errorf("login alerts sent = %d, want 0 after opting out", len(sent))
+	}
+}
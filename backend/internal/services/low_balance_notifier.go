@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// LowBalanceNotifier emails users whose wallet drops below the configured threshold
+// At most one warning is sent per user per cooldown window
+type LowBalanceNotifier struct {
+	userRepo     *repository.UserRepository
+	emailService *EmailService
+	threshold    float64
+	cooldown     time.Duration
+}
+
+// NewLowBalanceNotifier creates a new low balance notifier
+func NewLowBalanceNotifier(userRepo *repository.UserRepository, emailService *EmailService, cfg *config.Config) *LowBalanceNotifier {
+	return &LowBalanceNotifier{
+		userRepo:     userRepo,
+		emailService: emailService,
+		threshold:    cfg.Pricing.LowBalanceThreshold,
+		cooldown:     cfg.Pricing.LowBalanceNotifyCooldown,
+	}
+}
+
+// Check queues a warning email if newBalance is below the threshold
+// Call after a charge has been committed; it never blocks or fails the caller
+func (n *LowBalanceNotifier) Check(userID string, newBalance float64) {
+	if n == nil || n.threshold <= 0 || newBalance >= n.threshold {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		// Claim the notification slot first so concurrent charges send only one email
+		claimed, err := n.userRepo.MarkLowBalanceNotified(ctx, userID, n.cooldown)
+		if err != nil {
+			log.Printf("⚠️  Failed to record low balance notification for %s: %v", userID, err)
+			return
+		}
+		if !claimed {
+			return
+		}
+
+		user, err := n.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			log.Printf("⚠️  Failed to load user %s for low balance warning: %v", userID, err)
+			return
+		}
+
+		if err := n.emailService.SendLowBalanceWarning(ctx, user); err != nil {
+			log.Printf("⚠️  Failed to send low balance warning to %s: %v", user.Email, err)
+		}
+	}()
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// maxOutboxBackoff caps how long the dispatcher waits between attempts at one message
+const maxOutboxBackoff = time.Hour
+
+// OutboxAttempt identifies one delivery attempt at an outbox message
+type OutboxAttempt struct {
+	MessageID string
+	Final     bool // No retry follows if this attempt fails
+}
+
+// OutboxDispatcher delivers queued notifications in the background
+// Each poll claims a batch with a lease, so several API replicas can run dispatchers without sending a message twice.
+// Messages are only marked sent after delivery succeeds, so a crash mid-send means they are sent again once the
+// lease runs out (at-least-once). Failed attempts are rescheduled rather than retried in place
+type OutboxDispatcher struct {
+	outboxRepo   *repository.OutboxRepository
+	emailService *EmailService
+	webhooks     *WebhookService
+	cfg          config.OutboxConfig
+}
+
+// NewOutboxDispatcher creates a new outbox dispatcher
+func NewOutboxDispatcher(outboxRepo *repository.OutboxRepository, emailService *EmailService, webhooks *WebhookService, cfg *config.Config) *OutboxDispatcher {
+	dispatcherCfg := cfg.Outbox
+	if dispatcherCfg.PollInterval <= 0 {
+		dispatcherCfg.PollInterval = 5 * time.Second
+	}
+	if dispatcherCfg.BatchSize <= 0 {
+		dispatcherCfg.BatchSize = 50
+	}
+	if dispatcherCfg.MaxAttempts <= 0 {
+		dispatcherCfg.MaxAttempts = 1
+	}
+	if dispatcherCfg.Lease <= 0 {
+		dispatcherCfg.Lease = 10 * time.Minute
+	}
+
+	return &OutboxDispatcher{
+		outboxRepo:   outboxRepo,
+		emailService: emailService,
+		webhooks:     webhooks,
+		cfg:          dispatcherCfg,
+	}
+}
+
+// Run polls for pending messages until ctx is cancelled
+// Call it in its own goroutine
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	log.Printf("📬 Outbox dispatcher started (interval %s, batch %d)", d.cfg.PollInterval, d.cfg.BatchSize)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("📬 Outbox dispatcher stopped")
+			return
+		case <-time.After(d.cfg.PollInterval):
+		}
+
+		if err := d.processBatch(ctx); err != nil {
+			log.Printf("⚠️  Outbox dispatcher error: %v", err)
+		}
+	}
+}
+
+// processBatch claims and delivers one batch of due messages
+func (d *OutboxDispatcher) processBatch(ctx context.Context) error {
+	messages, err := d.outboxRepo.ClaimPending(ctx, d.cfg.BatchSize, time.Now().Add(d.cfg.Lease))
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			return nil
+		}
+		d.dispatchMessage(ctx, msg)
+	}
+
+	return nil
+}
+
+// dispatchMessage delivers a single message and records the outcome
+// Failed messages are retried with exponential backoff until MaxAttempts is reached
+func (d *OutboxDispatcher) dispatchMessage(ctx context.Context, msg *models.OutboxMessage) {
+	attempt := msg.Attempts + 1
+	err := d.deliver(ctx, msg, OutboxAttempt{MessageID: msg.ID, Final: attempt >= d.cfg.MaxAttempts})
+	if err == nil {
+		if err := d.outboxRepo.MarkSent(ctx, msg.ID); err != nil {
+			// Left pending - the next poll delivers it again
+			log.Printf("⚠️  Failed to mark outbox message %s sent: %v", msg.ID, err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if attempt < d.cfg.MaxAttempts {
+		backoff := d.cfg.PollInterval << attempt
+		if backoff <= 0 || backoff > maxOutboxBackoff {
+			backoff = maxOutboxBackoff
+		}
+		next := time.Now().Add(backoff)
+		retryAt = &next
+		log.Printf("⚠️  Outbox %s delivery failed (attempt %d/%d): %v", msg.Kind, attempt, d.cfg.MaxAttempts, err)
+	} else {
+		log.Printf("❌ Outbox %s delivery failed after %d attempts: %v", msg.Kind, attempt, err)
+	}
+
+	if err := d.outboxRepo.MarkFailed(ctx, msg.ID, err.Error(), retryAt); err != nil {
+		log.Printf("⚠️  Failed to record outbox attempt %s: %v", msg.ID, err)
+	}
+}
+
+// deliver sends a message through the service for its kind
+func (d *OutboxDispatcher) deliver(ctx context.Context, msg *models.OutboxMessage, attempt OutboxAttempt) error {
+	switch msg.Kind {
+	case models.OutboxBillEmail:
+		var payload models.BillEmailMessage
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid bill email payload: %w", err)
+		}
+		return d.emailService.SendBillEmail(ctx, payload.BillNumber, payload.RecipientEmail)
+
+	case models.OutboxBillVerifiedWebhook:
+		var payload models.BillVerifiedWebhookMessage
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid webhook payload: %w", err)
+		}
+		return d.webhooks.SendBillVerified(ctx, attempt, payload.IssuerID, payload.Payload)
+
+	case models.OutboxVerificationReceipt:
+		var payload models.VerificationReceiptMessage
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid verification receipt payload: %w", err)
+		}
+		return d.emailService.SendVerificationReceipt(ctx, &payload)
+
+	case models.OutboxFakeBillAlertEmail:
+		var payload models.FakeBillAlertMessage
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid fake bill alert payload: %w", err)
+		}
+		return d.emailService.SendFakeBillAlert(ctx, &payload)
+	}
+
+	return fmt.Errorf("unknown outbox message kind %q", msg.Kind)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/pan"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// PANVerificationService checks the PAN users give at signup and records the result on the user
+type PANVerificationService struct {
+	userRepo *repository.UserRepository
+	verifier pan.Verifier
+}
+
+// NewPANVerificationService creates a new PAN verification service
+func NewPANVerificationService(userRepo *repository.UserRepository, verifier pan.Verifier) *PANVerificationService {
+	return &PANVerificationService{
+		userRepo: userRepo,
+		verifier: verifier,
+	}
+}
+
+// VerifyInBackground checks the user's PAN, if they gave one, without delaying the caller
+// A failed or inconclusive check leaves the user's status as it was
+func (s *PANVerificationService) VerifyInBackground(user *models.User) {
+	if user.PAN == nil {
+		return
+	}
+
+	go func(userID, panNumber, organizationName string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		result, err := s.verifier.Verify(ctx, panNumber, organizationName)
+		if err != nil {
+			log.Printf("⚠️  Failed to verify PAN for %s: %v", userID, err)
+			return
+		}
+		if result == nil || result.Status == pan.StatusUnchecked {
+			return
+		}
+
+		if err := s.userRepo.SetPANVerification(ctx, userID, panNumber, string(result.Status)); err != nil {
+			log.Printf("⚠️  Failed to record PAN verification for %s: %v", userID, err)
+			return
+		}
+		if result.Status != pan.StatusVerified {
+			log.Printf("🪪 PAN for %s did not verify: %s", userID, result.Status)
+		}
+	}(user.ID, *user.PAN, user.OrganizationName)
+}
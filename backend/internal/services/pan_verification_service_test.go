@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/pan"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+// fixedPANVerifier reports the same status for every PAN
+type fixedPANVerifier pan.Status
+
+func (v fixedPANVerifier) Verify(ctx context.Context, panNumber, organizationName string) (*pan.Result, error) {
+	return &pan.Result{Status: pan.Status(v)}, nil
+}
+
+func TestPANVerificationIsRecordedOnTheUser(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, status := range []pan.Status{pan.StatusVerified, pan.StatusMismatch} {
+		t.Run(string(status), func(t *testing.T) {
+			user := testutil.CreateUser(t, env.db, models.RoleInstitutionAdmin, 0)
+			panNumber := "AAPFU0939F"
+			env.db.MustExec("UPDATE users SET pan = $2 WHERE id = $1", user.ID, panNumber)
+			user.PAN = &panNumber
+
+			NewPANVerificationService(env.users, fixedPANVerifier(status)).VerifyInBackground(user)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				stored, err := env.users.GetByID(context.Background(), user.ID)
+				if err != nil {
+					t.Fatalf("failed to reload user: %v", err)
+				}
+				if stored.PANVerificationStatus == string(status) {
+					if stored.PANVerifiedAt == nil {
+						t.Error("pan_verified_at not set")
+					}
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("pan_verification_status = %q, want %q", stored.PANVerificationStatus, status)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestPANVerificationIgnoresAChangedPAN(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	user := testutil.CreateUser(t, env.db, models.RoleInstitutionAdmin, 0)
+	env.db.MustExec("UPDATE users SET pan = 'ABCPE1234Z' WHERE id = $1", user.ID)
+
+	// The check finished for the PAN the user had before
+	if err := env.users.SetPANVerification(ctx, user.ID, "AAPFU0939F", string(pan.StatusVerified)); err != nil {
+		t.Fatalf("SetPANVerification failed: %v", err)
+	}
+
+	stored, err := env.users.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to reload user: %v", err)
+	}
+	if stored.PANVerificationStatus != string(pan.StatusUnchecked) {
+		t.Errorf("pan_verification_status = %q, want %q", stored.PANVerificationStatus, pan.StatusUnchecked)
+	}
+}
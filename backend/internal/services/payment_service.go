@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/payment"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// PaymentService handles wallet top-ups through the payment gateway
+type PaymentService struct {
+	db            *database.DB
+	gateway       payment.Gateway
+	orderRepo     *repository.PaymentOrderRepository
+	walletService *WalletService
+	currency      string
+}
+
+// NewPaymentService creates a new payment service
+func NewPaymentService(
+	db *database.DB,
+	gateway payment.Gateway,
+	orderRepo *repository.PaymentOrderRepository,
+	walletService *WalletService,
+	currency string,
+) *PaymentService {
+	return &PaymentService{
+		db:            db,
+		gateway:       gateway,
+		orderRepo:     orderRepo,
+		walletService: walletService,
+		currency:      currency,
+	}
+}
+
+// CreateOrder creates a gateway order for a wallet top-up
+func (s *PaymentService) CreateOrder(ctx context.Context, userID string, amount float64) (*payment.Order, error) {
+	gatewayOrder, err := s.gateway.CreateOrder(ctx, amount, s.currency, fmt.Sprintf("wallet_topup_%s", userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gateway order: %w", err)
+	}
+
+	order := &models.PaymentOrder{
+		UserID:         userID,
+		Gateway:        s.gateway.Name(),
+		GatewayOrderID: gatewayOrder.OrderID,
+		Amount:         gatewayOrder.Amount,
+		Currency:       gatewayOrder.Currency,
+		Status:         models.PaymentOrderCreated,
+	}
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return gatewayOrder, nil
+}
+
+// ConfirmPayment verifies a signed payment callback and credits the wallet exactly once
+// Returns the new wallet balance
+func (s *PaymentService) ConfirmPayment(ctx context.Context, userID string, req *models.ConfirmPaymentRequest) (float64, error) {
+	if !s.gateway.VerifySignature(req.OrderID, req.PaymentID, req.Signature) {
+		return 0, ErrInvalidPaymentSignature
+	}
+
+	var newBalance float64
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Lock the order so a replayed callback waits and then sees it already paid
+		order, err := s.orderRepo.GetByGatewayOrderIDForUpdateTx(ctx, tx, req.OrderID)
+		if err != nil {
+			return err
+		}
+
+		// Orders can only be confirmed by the user who created them
+		if order.UserID != userID {
+			return ErrPaymentOrderNotFound
+		}
+
+		if order.Status != models.PaymentOrderCreated {
+			return ErrPaymentAlreadyProcessed
+		}
+
+		if err := s.orderRepo.MarkPaidTx(ctx, tx, order.ID, req.PaymentID); err != nil {
+			if errors.Is(err, repository.ErrDuplicatePayment) {
+				return ErrPaymentAlreadyProcessed
+			}
+			return err
+		}
+
+		description := fmt.Sprintf("Top-up via %s (payment %s)", order.Gateway, req.PaymentID)
+		newBalance, err = s.walletService.CreditTx(ctx, tx, userID, order.Amount, &order.ID, &description)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return newBalance, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/payment"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestConfirmPayment(t *testing.T) {
+	env := newTestEnv(t)
+	gateway := payment.NewMockGateway("test-secret", false)
+	svc := NewPaymentService(env.db, gateway, repository.NewPaymentOrderRepository(env.db.DB),
+		NewWalletService(env.db, env.users, env.walletTxs), "INR")
+	ctx := context.Background()
+
+	user := testutil.CreateUser(t, env.db, models.RoleVerifier, 10)
+
+	// confirmation creates a 250 order and returns a correctly signed confirmation for it
+	confirmation := func(t *testing.T, paymentID string) *models.ConfirmPaymentRequest {
+		t.Helper()
+		order, err := svc.CreateOrder(ctx, user.ID, 250)
+		if err != nil {
+			t.Fatalf("CreateOrder failed: %v", err)
+		}
+		return &models.ConfirmPaymentRequest{
+			OrderID:   order.OrderID,
+			PaymentID: paymentID,
+			Signature: gateway.Sign(order.OrderID, paymentID),
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		req := confirmation(t, "pay_success")
+
+		balance, err := svc.ConfirmPayment(ctx, user.ID, req)
+		if err != nil {
+			t.Fatalf("ConfirmPayment failed: %v", err)
+		}
+		if balance != 260 || env.balanceOf(t, user.ID) != 260 {
+			t.Errorf("balance = %.2f, want 260", balance)
+		}
+		if n := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1 AND amount = 250", user.ID); n != 1 {
+			t.Errorf("ledger entries = %d, want 1", n)
+		}
+
+		// Replaying the same callback doesn't credit twice
+		if _, err := svc.ConfirmPayment(ctx, user.ID, req); !errors.Is(err, ErrPaymentAlreadyProcessed) {
+			t.Errorf("replay = %v, want ErrPaymentAlreadyProcessed", err)
+		}
+		if got := env.balanceOf(t, user.ID); got != 260 {
+			t.Errorf("balance after replay = %.2f, want 260", got)
+		}
+	})
+
+	t.Run("signature mismatch", func(t *testing.T) {
+		before := env.balanceOf(t, user.ID)
+		req := confirmation(t, "pay_forged")
+		req.Signature = payment.NewMockGateway("other-secret", false).Sign(req.OrderID, req.PaymentID)
+
+		if _, err := svc.ConfirmPayment(ctx, user.ID, req); !errors.Is(err, ErrInvalidPaymentSignature) {
+			t.Errorf("ConfirmPayment = %v, want ErrInvalidPaymentSignature", err)
+		}
+		if got := env.balanceOf(t, user.ID); got != before {
+			t.Errorf("balance = %.2f, want %.2f", got, before)
+		}
+	})
+
+	t.Run("someone else's order", func(t *testing.T) {
+		req := confirmation(t, "pay_other_user")
+		other := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+
+		if _, err := svc.ConfirmPayment(ctx, other.ID, req); !errors.Is(err, ErrPaymentOrderNotFound) {
+			t.Errorf("ConfirmPayment = %v, want ErrPaymentOrderNotFound", err)
+		}
+	})
+}
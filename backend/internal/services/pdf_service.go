@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ezhilnn/epr-backend/internal/models"
@@ -24,7 +26,8 @@ func NewPDFService(frontendURL string) *PDFService {
 }
 
 // GenerateBillPDF generates a PDF for a bill and returns the PDF bytes
-func (s *PDFService) GenerateBillPDF(bill *models.Bill) ([]byte, error) {
+// The QR code links to verificationRef, the bill's short code or bill number
+func (s *PDFService) GenerateBillPDF(bill *models.Bill, verificationRef string) ([]byte, error) {
 	// Create new PDF
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.AddPage()
@@ -55,7 +58,7 @@ func (s *PDFService) GenerateBillPDF(bill *models.Bill) ([]byte, error) {
 	s.addAmountSection(pdf, bill)
 
 	// Add QR code for verification
-	if err := s.addQRCode(pdf, bill.BillNumber); err != nil {
+	if err := s.addQRCode(pdf, verificationRef); err != nil {
 		// Log error but don't fail - QR is optional
 		fmt.Printf("Warning: Failed to add QR code: %v\n", err)
 	}
@@ -83,6 +86,117 @@ if err := pdf.Output(&buf); err != nil {
 return buf.Bytes(), nil
 }
 
+// GenerateVerificationCertificate generates a certificate proving a bill was verified as authentic
+// verifier may be nil for anonymous verifications
+func (s *PDFService) GenerateVerificationCertificate(verification *models.Verification, bill *models.Bill, verifier *models.User) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+
+	s.addWatermark(pdf)
+
+	// Title
+	pdf.SetY(20)
+	pdf.SetFont("Arial", "B", 18)
+	pdf.SetTextColor(31, 78, 120)
+	pdf.CellFormat(0, 10, "CERTIFICATE OF VERIFICATION", "", 1, "C", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(2)
+
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(0, 6, "Electronic Public Records (EPR)", "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.Line(20, pdf.GetY(), 190, pdf.GetY())
+	pdf.Ln(8)
+
+	// Statement
+	pdf.SetFont("Arial", "", 11)
+	pdf.MultiCell(0, 6, fmt.Sprintf(
+		"This certifies that the bill below was checked against the EPR registry on %s "+
+			"and its contents matched the record registered by the issuer.",
+		verification.VerifiedAt.UTC().Format("02 January 2006 at 15:04:05 MST"),
+	), "", "L", false)
+	pdf.Ln(5)
+
+	// Bill
+	s.addCertificateSection(pdf, "Bill")
+	s.addCertificateField(pdf, "Bill Number:", bill.BillNumber)
+	s.addCertificateField(pdf, "Bill Type:", s.formatBillType(bill.BillType))
+	s.addCertificateField(pdf, "Issued By:", bill.IssuerName)
+	s.addCertificateField(pdf, "Issue Date:", bill.IssueDate.Format("02 January 2006"))
+	if bill.ValidUntil != nil {
+		s.addCertificateField(pdf, "Valid Until:", bill.ValidUntil.Format("02 January 2006"))
+	}
+	s.addCertificateField(pdf, "Amount:", fmt.Sprintf("%s %.2f", bill.Currency, bill.Amount))
+	pdf.Ln(4)
+
+	// Verification
+	s.addCertificateSection(pdf, "Verification")
+	s.addCertificateField(pdf, "Verification ID:", verification.ID)
+	s.addCertificateField(pdf, "Verified At:", verification.VerifiedAt.UTC().Format(time.RFC3339))
+	s.addCertificateField(pdf, "Result:", strings.ToUpper(string(verification.VerificationStatus)))
+	organization := "N/A"
+	if verifier != nil {
+		organization = verifier.OrganizationName
+		if organization == "" {
+			organization = verifier.FullName
+		}
+	}
+	s.addCertificateField(pdf, "Verified By:", organization)
+	pdf.Ln(4)
+
+	// Integrity
+	s.addCertificateSection(pdf, "Integrity")
+	s.addCertificateField(pdf, "Data Hash (SHA-256):", bill.DataHash)
+	blockchainTxID := "Not yet committed"
+	if verification.BlockchainTxID != nil {
+		blockchainTxID = *verification.BlockchainTxID
+	} else if bill.BlockchainTxID != nil {
+		blockchainTxID = *bill.BlockchainTxID
+	}
+	s.addCertificateField(pdf, "Blockchain Tx ID:", blockchainTxID)
+	pdf.Ln(6)
+
+	// Footer
+	pdf.SetFont("Arial", "I", 8)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.MultiCell(0, 4, fmt.Sprintf(
+		"Re-verify this bill at any time at: %s\n"+
+			"Certificate generated on: %s",
+		utils.GenerateVerificationLink(bill.BillNumber, s.frontendURL),
+		time.Now().Format("02 Jan 2006 15:04:05 MST"),
+	), "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF output: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// addCertificateSection adds a section heading to a certificate
+func (s *PDFService) addCertificateSection(pdf *gofpdf.Fpdf, title string) {
+	pdf.SetFont("Arial", "B", 12)
+	pdf.SetTextColor(31, 78, 120)
+	pdf.Cell(0, 8, title)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(8)
+}
+
+// addCertificateField adds a label/value row to a certificate
+func (s *PDFService) addCertificateField(pdf *gofpdf.Fpdf, label, value string) {
+	pdf.SetFont("Arial", "B", 10)
+	pdf.Cell(50, 6, label)
+	pdf.SetFont("Arial", "", 10)
+	pdf.MultiCell(0, 6, value, "", "L", false)
+}
+
 // addWatermark adds EPR watermark at the bottom
 func (s *PDFService) addWatermark(pdf *gofpdf.Fpdf) {
 	pdf.SetY(-15)
@@ -133,6 +247,15 @@ func (s *PDFService) addBillInfo(pdf *gofpdf.Fpdf, bill *models.Bill) {
 	pdf.Cell(0, 6, bill.IssueDate.Format("02 January 2006"))
 	pdf.Ln(6)
 	
+	// Valid Until (if the bill expires)
+	if bill.ValidUntil != nil {
+		pdf.SetFont("Arial", "B", 10)
+		pdf.Cell(50, 6, "Valid Until:")
+		pdf.SetFont("Arial", "", 10)
+		pdf.Cell(0, 6, bill.ValidUntil.Format("02 January 2006"))
+		pdf.Ln(6)
+	}
+	
 	// Access Level (if not public)
 	if bill.AccessLevel != models.AccessLevelPublic {
 		pdf.SetFont("Arial", "B", 10)
@@ -172,12 +295,12 @@ func (s *PDFService) addBillDetails(pdf *gofpdf.Fpdf, billType models.BillType,
 		s.addMedicalBillDetails(pdf, data)
 	case models.BillTypeRentReceipt:
 		s.addRentReceiptDetails(pdf, data)
-	default:
-		// For other types, just show all data as key-value pairs
-		s.addGenericDetails(pdf, data)
 	}
 	
 	pdf.Ln(5)
+
+	// Every field that went into the data hash, so the printed copy can be checked against it
+	s.addBillDataTable(pdf, data)
 }
 
 // addFieldIfExists adds a field if it exists in data
@@ -261,28 +384,52 @@ func (s *PDFService) addRentReceiptDetails(pdf *gofpdf.Fpdf, data map[string]int
 	s.addFieldIfExists(pdf, "Payment Mode", data, "payment_mode")
 }
 
-// addGenericDetails adds all data as key-value pairs
-func (s *PDFService) addGenericDetails(pdf *gofpdf.Fpdf, data map[string]interface{}) {
-	// Skip metadata
-	for key, val := range data {
+// addBillDataTable adds every bill_data field as a key/value table, sorted by key
+func (s *PDFService) addBillDataTable(pdf *gofpdf.Fpdf, data map[string]interface{}) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		// Skip metadata
 		if key == "_metadata" {
 			continue
 		}
-		
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.CellFormat(0, 8, "Bill Data", "", 1, "L", true, 0, "")
+	pdf.Ln(3)
+
+	const keyWidth, valueWidth, lineHeight = 60.0, 110.0, 6.0
+	for _, key := range keys {
 		// Handle nested objects as JSON string
 		valStr := ""
-		switch v := val.(type) {
+		switch v := data[key].(type) {
 		case map[string]interface{}, []interface{}:
 			jsonBytes, _ := json.MarshalIndent(v, "", "  ")
 			valStr = string(jsonBytes)
+		case nil:
+			valStr = "-"
 		default:
 			valStr = fmt.Sprintf("%v", v)
 		}
-		
-		pdf.SetFont("Arial", "B", 10)
-		pdf.Cell(60, 6, s.formatFieldName(key)+":")
-		pdf.SetFont("Arial", "", 10)
-		pdf.MultiCell(0, 6, valStr, "", "L", false)
+
+		// Size the key cell to the wrapped value so the row borders line up
+		pdf.SetFont("Arial", "", 9)
+		lines := pdf.SplitLines([]byte(valStr), valueWidth-2)
+		rowHeight := lineHeight * float64(len(lines))
+		if rowHeight == 0 {
+			rowHeight = lineHeight
+		}
+
+		pdf.SetFont("Arial", "B", 9)
+		pdf.CellFormat(keyWidth, rowHeight, s.formatFieldName(key), "1", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 9)
+		pdf.MultiCell(valueWidth, lineHeight, valStr, "1", "L", false)
 	}
 }
 
@@ -333,62 +480,81 @@ func (s *PDFService) addAmountSection(pdf *gofpdf.Fpdf, bill *models.Bill) {
 	pdf.Ln(5)
 }
 
-// addQRCode adds QR code for verification
+// qrCodeImageSize is the printed width and height of the verification QR code in mm
+const qrCodeImageSize = 35.0
+
+// addQRCode adds a scannable QR code linking to the bill's verification page
+// billNumber may also be the bill's short code
 func (s *PDFService) addQRCode(pdf *gofpdf.Fpdf, billNumber string) error {
-	// Generate QR code using existing utility
-	qrCodeBase64, err := utils.GenerateQRCode(billNumber, s.frontendURL)
+	qrCode, err := utils.GenerateQRCodePNG(billNumber, s.frontendURL)
 	if err != nil {
 		return err
 	}
-	
-	// Decode base64 to image
-	// Note: gofpdf needs image file or []byte in specific format
-	// For simplicity, we'll add QR as text. In production, decode base64 and add as image
-	
+
+	// Keep the heading, code and link together on one page
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottomMargin := pdf.GetMargins()
+	if pdf.GetY()+qrCodeImageSize+20 > pageHeight-bottomMargin {
+		pdf.AddPage()
+	}
+
+	imageName := "qr-" + billNumber
+	pdf.RegisterImageOptionsReader(imageName, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qrCode))
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("failed to embed QR code: %w", err)
+	}
+
 	pdf.Ln(3)
 	pdf.SetFont("Arial", "B", 11)
 	pdf.Cell(0, 6, "Scan to Verify:")
-	pdf.Ln(6)
-	
+	pdf.Ln(7)
+
+	pdf.ImageOptions(imageName, 20, pdf.GetY(), qrCodeImageSize, qrCodeImageSize, true, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
 	pdf.SetFont("Arial", "", 8)
-	verifyURL := fmt.Sprintf("%s/verify/%s", s.frontendURL, billNumber)
-	pdf.Cell(0, 5, verifyURL)
+	pdf.Cell(0, 5, utils.GenerateVerificationLink(billNumber, s.frontendURL))
 	pdf.Ln(8)
-	
-	// TODO: Actually render QR code image when we have proper image handling
-	// For now, we're showing the URL
-	_ = qrCodeBase64 // Use the variable to avoid unused error
-	
+
 	return nil
 }
 
 // addFooter adds footer with verification info
+// The full data hash and, once confirmed, the blockchain transaction are printed so a reader
+// can match this copy against what the verification page reports
 func (s *PDFService) addFooter(pdf *gofpdf.Fpdf, bill *models.Bill) {
 	pdf.Ln(5)
-	
+
+	lines := []string{
+		"This bill is registered in the Electronic Public Records (EPR) system.",
+		"Verify authenticity at " + utils.GenerateVerificationLink(bill.BillNumber, s.frontendURL),
+		"Data Hash (SHA-256): " + bill.DataHash,
+	}
+	if bill.BlockchainStatus == models.BlockchainConfirmed && bill.BlockchainTxID != nil {
+		lines = append(lines, "Blockchain Tx ID: "+*bill.BlockchainTxID)
+	}
+	lines = append(lines, "Generated on: "+time.Now().Format("02 Jan 2006 15:04:05 MST"))
+
 	// Verification info box
 	pdf.SetDrawColor(200, 200, 200)
 	pdf.SetFillColor(250, 250, 250)
-	
+
+	boxHeight := 12 + 4*float64(len(lines))
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottomMargin := pdf.GetMargins()
+	if pdf.GetY()+boxHeight > pageHeight-bottomMargin {
+		pdf.AddPage()
+	}
+
 	currentY := pdf.GetY()
-	pdf.Rect(20, currentY, 170, 25, "FD")
-	
+	pdf.Rect(20, currentY, 170, boxHeight, "FD")
+
 	pdf.SetY(currentY + 3)
 	pdf.SetFont("Arial", "B", 10)
 	pdf.Cell(0, 6, "Bill Verification Information")
 	pdf.Ln(6)
-	
+
 	pdf.SetFont("Arial", "", 8)
-	pdf.MultiCell(0, 4, fmt.Sprintf(
-		"This bill is registered in the Electronic Public Records (EPR) system.\n"+
-		"Verify authenticity at: %s/verify/%s\n"+
-		"Bill Hash: %s\n"+
-		"Generated on: %s",
-		s.frontendURL,
-		bill.BillNumber,
-		bill.DataHash[:16]+"...", // Show first 16 chars of hash
-		time.Now().Format("02 Jan 2006 15:04:05 MST"),
-	), "", "L", false)
+	pdf.MultiCell(0, 4, strings.Join(lines, "\n"), "", "L", false)
 }
 
 // Helper functions
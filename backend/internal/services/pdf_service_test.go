@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+var (
+	pdfStream   = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfShowText = regexp.MustCompile(`\(((?:[^()\\]|\\.)*)\)\s*Tj`)
+)
+
+// pdfText extracts the text drawn on the pages of a PDF, one string per text operator
+// It only understands what gofpdf writes with the core fonts: Flate-compressed content streams and literal strings
+func pdfText(t *testing.T, pdf []byte) string {
+	t.Helper()
+
+	var text []string
+	for _, m := range pdfStream.FindAllSubmatch(pdf, -1) {
+		content := m[1]
+		if r, err := zlib.NewReader(bytes.NewReader(content)); err == nil {
+			inflated, err := io.ReadAll(r)
+			if err != nil {
+				continue // An image or font, not page content
+			}
+			content = inflated
+		}
+
+		for _, s := range pdfShowText.FindAllSubmatch(content, -1) {
+			unescaped := strings.NewReplacer(`\\`, `\`, `\(`, `(`, `\)`, `)`).Replace(string(s[1]))
+			text = append(text, unescaped)
+		}
+	}
+	return strings.Join(text, "\n")
+}
+
+// pdfTestBill is a confirmed sales invoice with a real data hash
+func pdfTestBill(t *testing.T) *models.Bill {
+	t.Helper()
+
+	billData := map[string]interface{}{
+		"invoice_number": "INV-2026-0042",
+		"customer_name":  "Kaveri Traders",
+		"gstin":          "29ABCDE1234F1Z5",
+		"payment_terms":  "Net 30",
+	}
+	data, err := json.Marshal(billData)
+	if err != nil {
+		t.Fatalf("failed to encode bill data: %v", err)
+	}
+	hash, err := utils.GenerateBillHash(billData)
+	if err != nil {
+		t.Fatalf("failed to hash bill data: %v", err)
+	}
+
+	txID := "0x5f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+	return &models.Bill{
+		BillNumber:       "INV202610000042",
+		BillType:         models.BillTypeSalesInvoice,
+		AccessLevel:      models.AccessLevelPublic,
+		IssuerName:       "Test Org",
+		BillData:         data,
+		Amount:           1180,
+		Currency:         "INR",
+		IssueDate:        time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+		DataHash:         hash,
+		BlockchainStatus: models.BlockchainConfirmed,
+		BlockchainTxID:   &txID,
+	}
+}
+
+func TestGenerateBillPDFPrintsTamperEvidence(t *testing.T) {
+	svc := NewPDFService("https://epr.example.com")
+	bill := pdfTestBill(t)
+
+	pdf, err := svc.GenerateBillPDF(bill, bill.BillNumber)
+	if err != nil {
+		t.Fatalf("GenerateBillPDF failed: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Fatal("output is not a PDF")
+	}
+
+	text := pdfText(t, pdf)
+	for _, want := range []string{
+		bill.DataHash,
+		*bill.BlockchainTxID,
+		"Verify authenticity at " + utils.GenerateVerificationLink(bill.BillNumber, "https://epr.example.com"),
+		// Every bill_data field is printed, including ones the invoice layout doesn't know
+		"INV-2026-0042",
+		"Kaveri Traders",
+		"29ABCDE1234F1Z5",
+		"Net 30",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("PDF text doesn't contain %q", want)
+		}
+	}
+}
+
+func TestGenerateBillPDFOmitsUnconfirmedTransaction(t *testing.T) {
+	svc := NewPDFService("https://epr.example.com")
+	bill := pdfTestBill(t)
+	bill.BlockchainStatus = models.BlockchainPending
+
+	pdf, err := svc.GenerateBillPDF(bill, bill.BillNumber)
+	if err != nil {
+		t.Fatalf("GenerateBillPDF failed: %v", err)
+	}
+
+	text := pdfText(t, pdf)
+	if !strings.Contains(text, bill.DataHash) {
+		t.Error("PDF text doesn't contain the data hash")
+	}
+	if strings.Contains(text, *bill.BlockchainTxID) {
+		t.Error("PDF prints a blockchain transaction that isn't confirmed yet")
+	}
+}
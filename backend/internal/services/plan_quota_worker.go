@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// planQuotaCheckInterval is how often the worker looks for plans due a reset
+const planQuotaCheckInterval = time.Hour
+
+// PlanQuotaWorker resets prepaid verification plan quotas at the start of each month
+type PlanQuotaWorker struct {
+	userRepo *repository.UserRepository
+	loc      *time.Location
+}
+
+// NewPlanQuotaWorker creates a new plan quota reset worker
+// Months start in loc (APP_TIMEZONE)
+func NewPlanQuotaWorker(userRepo *repository.UserRepository, loc *time.Location) *PlanQuotaWorker {
+	return &PlanQuotaWorker{userRepo: userRepo, loc: loc}
+}
+
+// Run resets due quotas on start and then every planQuotaCheckInterval until ctx is cancelled
+// Call it in its own goroutine
+func (w *PlanQuotaWorker) Run(ctx context.Context) {
+	for {
+		reset, err := w.userRepo.ResetDuePlanQuotas(ctx, w.loc)
+		if err != nil {
+			log.Printf("⚠️  Plan quota reset failed: %v", err)
+		} else if reset > 0 {
+			log.Printf("📊 Reset verification quota for %d plans", reset)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(planQuotaCheckInterval):
+		}
+	}
+}
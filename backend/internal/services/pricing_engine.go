@@ -0,0 +1,112 @@
+package services
+
+import (
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/models"
+)
+
+// percentageRateFactor scales the configured verification percentage.
+// The fee is half the configured rate of the bill amount, so the default
+// VerificationPercentage of 0.01 charges 0.5% before min/max clamping.
+const percentageRateFactor = 0.5
+
+// restrictedPremiumFactor is the markup for verifying restricted bills
+const restrictedPremiumFactor = 1.5
+
+// PricingEngine computes verification fees from plain inputs
+// It has no database access, so every pricing rule can be reasoned about in isolation
+type PricingEngine struct {
+	cfg config.PricingConfig
+}
+
+// NewPricingEngine creates a new pricing engine
+func NewPricingEngine(cfg config.PricingConfig) *PricingEngine {
+	return &PricingEngine{cfg: cfg}
+}
+
+// Calculate returns the fee for verifying a bill, whether it is free, and the pricing rule applied
+//
+// Rules, in order:
+//  1. A free loyalty credit makes the verification free
+//  2. Fee is billAmount * percentage * percentageRateFactor, clamped to [minFee, maxFee]
+//  3. Restricted bills cost restrictedPremiumFactor times that, capped at maxFee
+//  4. Government/financial bills always cost maxFee
+//
+// Percentage and fee bounds come from the bill type's override when one is configured
+func (e *PricingEngine) Calculate(billAmount float64, accessLevel models.AccessLevel, billType models.BillType, hasFreeCredit bool) (float64, bool, string) {
+	// Free credit is consumed by the caller when the verification is settled
+	if hasFreeCredit {
+		return 0, true, "loyalty_free"
+	}
+
+	percentage, minFee, maxFee := e.pricingFor(billType)
+
+	percentagePrice := billAmount * percentage * percentageRateFactor
+
+	// "percentage_1_percent" predates the rate factor; kept as-is since it is stored on verifications
+	finalPrice := percentagePrice
+	pricingRule := "percentage_1_percent"
+
+	if percentagePrice < minFee {
+		finalPrice = minFee
+		pricingRule = "minimum_fee"
+	} else if percentagePrice > maxFee {
+		finalPrice = maxFee
+		pricingRule = "maximum_fee_capped"
+	}
+
+	// Adjust based on access level
+	switch accessLevel {
+	case models.AccessLevelRestricted:
+		finalPrice = finalPrice * restrictedPremiumFactor
+		pricingRule = "restricted_access_premium"
+	case models.AccessLevelGovernment, models.AccessLevelFinancial:
+		finalPrice = maxFee
+		pricingRule = "government_financial_premium"
+	}
+
+	// Ensure within bounds
+	if finalPrice < minFee {
+		finalPrice = minFee
+	}
+	if finalPrice > maxFee {
+		finalPrice = maxFee
+	}
+
+	return finalPrice, false, pricingRule
+}
+
+// CalculatePreview returns the fee for a preview of a bill the caller can't see in full
+// A preview only confirms the bill exists, so it costs the bill type's minimum fee regardless of amount
+func (e *PricingEngine) CalculatePreview(billType models.BillType, hasFreeCredit bool) (float64, bool, string) {
+	if hasFreeCredit {
+		return 0, true, "loyalty_free"
+	}
+
+	_, minFee, _ := e.pricingFor(billType)
+	return minFee, false, "preview_minimum_fee"
+}
+
+// pricingFor returns the verification percentage and fee bounds for a bill type
+// Falls back to the default pricing for anything the type doesn't override
+func (e *PricingEngine) pricingFor(billType models.BillType) (percentage, minFee, maxFee float64) {
+	percentage = e.cfg.VerificationPercentage
+	minFee = e.cfg.VerificationMinFee
+	maxFee = e.cfg.VerificationMaxFee
+
+	override, ok := e.cfg.BillTypeOverrides[string(billType)]
+	if !ok {
+		return percentage, minFee, maxFee
+	}
+	if override.Percentage != nil {
+		percentage = *override.Percentage
+	}
+	if override.MinFee != nil {
+		minFee = *override.MinFee
+	}
+	if override.MaxFee != nil {
+		maxFee = *override.MaxFee
+	}
+
+	return percentage, minFee, maxFee
+}
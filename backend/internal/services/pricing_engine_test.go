@@ -0,0 +1,116 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/models"
+)
+
+// testPricing is the default pricing used by these tests: 0.5% of the amount, between 1 and 10
+func testPricing() config.PricingConfig {
+	return config.PricingConfig{
+		VerificationPercentage: 0.01,
+		VerificationMinFee:     1,
+		VerificationMaxFee:     10,
+	}
+}
+
+func TestPricingBillTypeOverrides(t *testing.T) {
+	pricing := testPricing()
+	// Medical bills override every field, rent receipts only the minimum fee
+	overrides := `{"medical_bill":{"percentage":0.02,"min_fee":2,"max_fee":20},"rent_receipt":{"min_fee":3}}`
+	if err := json.Unmarshal([]byte(overrides), &pricing.BillTypeOverrides); err != nil {
+		t.Fatalf("failed to parse overrides: %v", err)
+	}
+	engine := NewPricingEngine(pricing)
+
+	tests := []struct {
+		name     string
+		amount   float64
+		billType models.BillType
+		wantFee  float64
+		wantRule string
+	}{
+		{"override percentage", 1000, models.BillTypeMedicalBill, 10, "percentage_1_percent"},
+		{"override maximum above the default", 5000, models.BillTypeMedicalBill, 20, "maximum_fee_capped"},
+		{"override minimum", 10, models.BillTypeMedicalBill, 2, "minimum_fee"},
+		{"partial override keeps the default percentage", 1000, models.BillTypeRentReceipt, 5, "percentage_1_percent"},
+		{"partial override keeps the default maximum", 5000, models.BillTypeRentReceipt, 10, "maximum_fee_capped"},
+		{"partial override minimum", 10, models.BillTypeRentReceipt, 3, "minimum_fee"},
+		{"type without an override uses the defaults", 5000, models.BillTypeOther, 10, "maximum_fee_capped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, wasFree, rule := engine.Calculate(tt.amount, models.AccessLevelPublic, tt.billType, false)
+			if fee != tt.wantFee || wasFree || rule != tt.wantRule {
+				t.Errorf("Calculate(%v, %s) = %v, %v, %q, want %v, false, %q",
+					tt.amount, tt.billType, fee, wasFree, rule, tt.wantFee, tt.wantRule)
+			}
+		})
+	}
+
+	// The access-level premium still applies on top of the override
+	if fee, _, rule := engine.Calculate(1000, models.AccessLevelRestricted, models.BillTypeMedicalBill, false); fee != 15 || rule != "restricted_access_premium" {
+		t.Errorf("restricted medical bill = %v, %q, want 15, restricted_access_premium", fee, rule)
+	}
+	if fee, _, _ := engine.Calculate(10, models.AccessLevelGovernment, models.BillTypeMedicalBill, false); fee != 20 {
+		t.Errorf("government medical bill = %v, want the override maximum 20", fee)
+	}
+}
+
+func TestPricingEngineCalculate(t *testing.T) {
+	engine := NewPricingEngine(testPricing())
+
+	tests := []struct {
+		name        string
+		amount      float64
+		accessLevel models.AccessLevel
+		freeCredit  bool
+		wantFee     float64
+		wantFree    bool
+		wantRule    string
+	}{
+		{"free credit", 1000, models.AccessLevelPublic, true, 0, true, "loyalty_free"},
+		{"free credit beats the government cap", 1000, models.AccessLevelGovernment, true, 0, true, "loyalty_free"},
+
+		{"zero amount is clamped to the minimum", 0, models.AccessLevelPublic, false, 1, false, "minimum_fee"},
+		{"below the minimum", 100, models.AccessLevelPublic, false, 1, false, "minimum_fee"},
+		{"exactly the minimum", 200, models.AccessLevelPublic, false, 1, false, "percentage_1_percent"},
+		// The configured percentage is halved, so 0.01 charges 0.5%
+		{"percentage rate", 1000, models.AccessLevelPublic, false, 5, false, "percentage_1_percent"},
+		{"exactly the maximum", 2000, models.AccessLevelPublic, false, 10, false, "percentage_1_percent"},
+		{"above the maximum", 100000, models.AccessLevelPublic, false, 10, false, "maximum_fee_capped"},
+
+		{"restricted premium", 1000, models.AccessLevelRestricted, false, 7.5, false, "restricted_access_premium"},
+		{"restricted premium on the minimum", 100, models.AccessLevelRestricted, false, 1.5, false, "restricted_access_premium"},
+		{"restricted premium capped at the maximum", 1600, models.AccessLevelRestricted, false, 10, false, "restricted_access_premium"},
+
+		{"government always costs the maximum", 100, models.AccessLevelGovernment, false, 10, false, "government_financial_premium"},
+		{"government above the maximum", 100000, models.AccessLevelGovernment, false, 10, false, "government_financial_premium"},
+		{"financial always costs the maximum", 100, models.AccessLevelFinancial, false, 10, false, "government_financial_premium"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, wasFree, rule := engine.Calculate(tt.amount, tt.accessLevel, models.BillTypeOther, tt.freeCredit)
+			if fee != tt.wantFee || wasFree != tt.wantFree || rule != tt.wantRule {
+				t.Errorf("Calculate(%v, %s, free=%v) = %v, %v, %q, want %v, %v, %q",
+					tt.amount, tt.accessLevel, tt.freeCredit, fee, wasFree, rule, tt.wantFee, tt.wantFree, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestPricingEngineCalculatePreview(t *testing.T) {
+	engine := NewPricingEngine(testPricing())
+
+	if fee, wasFree, rule := engine.CalculatePreview(models.BillTypeOther, false); fee != 1 || wasFree || rule != "preview_minimum_fee" {
+		t.Errorf("CalculatePreview = %v, %v, %q, want 1, false, preview_minimum_fee", fee, wasFree, rule)
+	}
+	if fee, wasFree, rule := engine.CalculatePreview(models.BillTypeOther, true); fee != 0 || !wasFree || rule != "loyalty_free" {
+		t.Errorf("CalculatePreview with a free credit = %v, %v, %q, want 0, true, loyalty_free", fee, wasFree, rule)
+	}
+}
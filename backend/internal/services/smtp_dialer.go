@@ -0,0 +1,135 @@
+package services
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// smtpDialer sends messages over SMTP like gomail.Dialer, but with a configurable timeout
+// The timeout covers the whole conversation, so a server that accepts the connection and
+// then stalls can't hold the sending goroutine forever
+type smtpDialer struct {
+	host     string
+	port     int
+	username string
+	password string
+	ssl      bool // Implicit TLS; otherwise STARTTLS is used when the server offers it
+	timeout  time.Duration
+}
+
+// newSMTPDialer creates an SMTP dialer, using implicit TLS on port 465 as gomail does
+func newSMTPDialer(host string, port int, username, password string, timeout time.Duration) *smtpDialer {
+	return &smtpDialer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		ssl:      port == 465,
+		timeout:  timeout,
+	}
+}
+
+// DialAndSend opens a connection to the SMTP server, sends the messages and closes the connection
+func (d *smtpDialer) DialAndSend(msgs ...*gomail.Message) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(d.host, strconv.Itoa(d.port)), d.timeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(d.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	if d.ssl {
+		conn = tls.Client(conn, &tls.Config{ServerName: d.host})
+	}
+
+	c, err := smtp.NewClient(conn, d.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !d.ssl {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: d.host}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.username != "" {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(smtp.PlainAuth("", d.username, d.password, d.host)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// gomail.Send flattens errors into strings, so keep the SMTP error for isRetryableEmailError
+	var sendErr error
+	send := gomail.SendFunc(func(from string, to []string, msg io.WriterTo) error {
+		sendErr = sendMessage(c, from, to, msg)
+		return sendErr
+	})
+	if err := gomail.Send(send, msgs...); err != nil {
+		if sendErr != nil {
+			return fmt.Errorf("failed to send message: %w", sendErr)
+		}
+		return err
+	}
+
+	if err := c.Quit(); err != nil {
+		return fmt.Errorf("failed to close SMTP session: %w", err)
+	}
+	return nil
+}
+
+// sendMessage runs one MAIL/RCPT/DATA exchange on an open SMTP session
+func sendMessage(c *smtp.Client, from string, to []string, msg io.WriterTo) error {
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := msg.WriteTo(w); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// isRetryableEmailError reports whether a failed send may succeed if tried again
+// Network failures and 4xx SMTP replies are transient; 5xx replies such as an unknown
+// recipient or rejected credentials will fail the same way every time
+func isRetryableEmailError(err error) bool {
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 400 && smtpErr.Code < 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// SuspiciousActivityDetector flags verification patterns that look like scraping or fraud:
+// one IP checking many different bills, one IP guessing bill numbers, or one bill being checked
+// over and over. Counters live in Redis; without Redis nothing is ever flagged
+type SuspiciousActivityDetector struct {
+	counters *repository.ActivityCounterRepository
+	cfg      config.FraudConfig
+}
+
+// NewSuspiciousActivityDetector creates a new suspicious activity detector
+func NewSuspiciousActivityDetector(counters *repository.ActivityCounterRepository, cfg *config.Config) *SuspiciousActivityDetector {
+	return &SuspiciousActivityDetector{
+		counters: counters,
+		cfg:      cfg.Fraud,
+	}
+}
+
+// Check records a verification attempt and returns why it looks suspicious, or "" if it doesn't
+// found reports whether billNumber exists. Counter errors are logged and never block a verification
+func (d *SuspiciousActivityDetector) Check(ctx context.Context, ip, billNumber string, found bool) string {
+	var reasons []string
+
+	// Many distinct bills from one IP
+	if d.cfg.MaxDistinctBillsPerIP > 0 {
+		count, err := d.counters.RecordDistinct(ctx, "fraud:ip_bills:"+ip, billNumber, d.cfg.Window)
+		if err != nil {
+			log.Printf("⚠️  Suspicious activity check failed for IP %s: %v", ip, err)
+		} else if count > d.cfg.MaxDistinctBillsPerIP {
+			reasons = append(reasons, fmt.Sprintf("IP verified %d different bills within %s", count, d.cfg.Window))
+		}
+	}
+
+	// Repeated lookups of bills that don't exist - likely enumeration
+	if !found && d.cfg.MaxNotFoundPerIP > 0 {
+		count, err := d.counters.RecordEvent(ctx, "fraud:ip_not_found:"+ip, d.cfg.Window)
+		if err != nil {
+			log.Printf("⚠️  Suspicious activity check failed for IP %s: %v", ip, err)
+		} else if count > d.cfg.MaxNotFoundPerIP {
+			reasons = append(reasons, fmt.Sprintf("IP looked up %d unknown bill numbers within %s", count, d.cfg.Window))
+		}
+	}
+
+	// One bill verified unusually often
+	if found && d.cfg.MaxVerificationsPerBill > 0 {
+		count, err := d.counters.RecordEvent(ctx, "fraud:bill:"+billNumber, d.cfg.Window)
+		if err != nil {
+			log.Printf("⚠️  Suspicious activity check failed for bill %s: %v", billNumber, err)
+		} else if count > d.cfg.MaxVerificationsPerBill {
+			reasons = append(reasons, fmt.Sprintf("bill verified %d times within %s", count, d.cfg.Window))
+		}
+	}
+
+	return strings.Join(reasons, "; ")
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestSuspiciousActivityRules(t *testing.T) {
+	ctx := context.Background()
+
+	// newDetector returns a detector on an empty Redis where each rule trips on its fourth event
+	newDetector := func(t *testing.T) *SuspiciousActivityDetector {
+		t.Helper()
+		cfg := testutil.Config(t)
+		cfg.Fraud.Window = time.Minute
+		cfg.Fraud.MaxDistinctBillsPerIP = 3
+		cfg.Fraud.MaxNotFoundPerIP = 3
+		cfg.Fraud.MaxVerificationsPerBill = 3
+		return NewSuspiciousActivityDetector(repository.NewActivityCounterRepository(testutil.Redis(t)), cfg)
+	}
+
+	tests := []struct {
+		name       string
+		check      func(d *SuspiciousActivityDetector, i int) string
+		wantReason string
+	}{
+		{
+			name: "many distinct bills from one IP",
+			check: func(d *SuspiciousActivityDetector, i int) string {
+				return d.Check(ctx, "198.51.100.1", fmt.Sprintf("OTH20240100000%d", i), true)
+			},
+			wantReason: "different bills",
+		},
+		{
+			name: "repeated unknown bill numbers from one IP",
+			check: func(d *SuspiciousActivityDetector, i int) string {
+				// The same guessed number keeps the distinct-bills rule quiet
+				return d.Check(ctx, "198.51.100.2", "OTH209901999999", false)
+			},
+			wantReason: "unknown bill numbers",
+		},
+		{
+			name: "one bill verified from many IPs",
+			check: func(d *SuspiciousActivityDetector, i int) string {
+				return d.Check(ctx, fmt.Sprintf("198.51.100.%d", 10+i), "OTH202401000001", true)
+			},
+			wantReason: "bill verified",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDetector(t)
+
+			for i := 1; i <= 3; i++ {
+				if reason := tt.check(d, i); reason != "" {
+					t.Fatalf("event %d flagged before the threshold: %s", i, reason)
+				}
+			}
+			if reason := tt.check(d, 4); !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("event 4 reason = %q, want it to mention %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSuspiciousActivityWithoutRedis(t *testing.T) {
+	cfg := testutil.Config(t)
+	cfg.Fraud.MaxNotFoundPerIP = 1
+	d := NewSuspiciousActivityDetector(repository.NewActivityCounterRepository(nil), cfg)
+
+	for i := 0; i < 5; i++ {
+		if reason := d.Check(context.Background(), "198.51.100.1", "OTH209901999999", false); reason != "" {
+			t.Fatalf("flagged without Redis: %s", reason)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+// trendWindow returns the range covering the last days up to the end of today in loc, and how
+// many days each bucket spans. Weekly windows start on the Monday of their first week, matching date_trunc('week')
+func trendWindow(interval string, days int, loc *time.Location) (from, to time.Time, step int) {
+	today := utils.StartOfDay(time.Now(), loc)
+	to = today.AddDate(0, 0, 1)
+	from = today.AddDate(0, 0, -(days - 1))
+
+	step = 1
+	if interval == models.TrendIntervalWeek {
+		step = 7
+		from = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	}
+
+	return from, to, step
+}
+
+// trendBucketStarts lists the start date (YYYY-MM-DD) of every bucket in a trend window, oldest first
+// Dates are compared as strings so they line up with the DB's buckets, which are local times without a zone
+func trendBucketStarts(from, to time.Time, step int) []string {
+	var starts []string
+	for start := from; start.Before(to); start = start.AddDate(0, 0, step) {
+		starts = append(starts, start.Format(utils.DateLayout))
+	}
+	return starts
+}
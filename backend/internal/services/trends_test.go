@@ -0,0 +1,56 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+func TestTrendWindow(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	today := utils.StartOfDay(time.Now(), loc)
+
+	t.Run("daily", func(t *testing.T) {
+		from, to, step := trendWindow(models.TrendIntervalDay, 7, loc)
+		if step != 1 || !from.Equal(today.AddDate(0, 0, -6)) || !to.Equal(today.AddDate(0, 0, 1)) {
+			t.Errorf("trendWindow = %v to %v by %d days, want the 7 days ending today", from, to, step)
+		}
+		if starts := trendBucketStarts(from, to, step); len(starts) != 7 || starts[6] != today.Format(utils.DateLayout) {
+			t.Errorf("bucket starts = %v, want 7 days ending %s", starts, today.Format(utils.DateLayout))
+		}
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		from, to, step := trendWindow(models.TrendIntervalWeek, 22, loc)
+		if step != 7 || from.Weekday() != time.Monday || !to.Equal(today.AddDate(0, 0, 1)) {
+			t.Errorf("trendWindow = %s %v to %v by %d days, want Monday-aligned weeks ending today", from.Weekday(), from, to, step)
+		}
+		if gap := today.Sub(from); gap < 21*24*time.Hour || gap >= 28*24*time.Hour {
+			t.Errorf("window starts %v before today, want within the week 21 days back", gap)
+		}
+		if starts := trendBucketStarts(from, to, step); len(starts) != 4 {
+			t.Errorf("bucket starts = %v, want 4 weeks", starts)
+		}
+	})
+}
+
+func TestTrendBucketStarts(t *testing.T) {
+	from := time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC) // A Monday
+	to := time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC)
+
+	want := []string{"2026-02-23", "2026-03-02", "2026-03-09", "2026-03-16"}
+	if got := trendBucketStarts(from, to, 7); !reflect.DeepEqual(got, want) {
+		t.Errorf("weekly starts = %v, want %v", got, want)
+	}
+
+	// Daily buckets run across the month end
+	if got := trendBucketStarts(from.AddDate(0, 0, 5), from.AddDate(0, 0, 8), 1); !reflect.DeepEqual(got, []string{"2026-02-28", "2026-03-01", "2026-03-02"}) {
+		t.Errorf("daily starts = %v", got)
+	}
+}
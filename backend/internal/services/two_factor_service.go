@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/jmoiron/sqlx"
+)
+
+// twoFactorIssuer is the account name authenticator apps show next to the code
+const twoFactorIssuer = "EPR"
+
+// recoveryCodeCount is how many one-time recovery codes a user gets when enabling two-factor authentication
+const recoveryCodeCount = 10
+
+// TwoFactorService manages TOTP two-factor authentication for privileged accounts
+// Secrets are stored encrypted with key; recovery codes are stored as SHA-256 hashes
+type TwoFactorService struct {
+	db           *database.DB
+	userRepo     *repository.UserRepository
+	recoveryRepo *repository.RecoveryCodeRepository
+	key          []byte
+}
+
+// NewTwoFactorService creates a new two-factor service
+// key is the 32-byte secret encryption key from config.TwoFactorKey
+func NewTwoFactorService(
+	db *database.DB,
+	userRepo *repository.UserRepository,
+	recoveryRepo *repository.RecoveryCodeRepository,
+	key []byte,
+) *TwoFactorService {
+	return &TwoFactorService{
+		db:           db,
+		userRepo:     userRepo,
+		recoveryRepo: recoveryRepo,
+		key:          key,
+	}
+}
+
+// Enable generates a new TOTP secret for the user to add to their authenticator app
+// Calling it again before Verify replaces the secret, e.g. if the QR code was lost
+func (s *TwoFactorService) Enable(ctx context.Context, user *models.User) (*models.EnableTwoFactorResponse, error) {
+	if !user.Role.CanUseTwoFactor() {
+		return nil, ErrTwoFactorNotAvailable
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate two-factor secret: %w", err)
+	}
+	encrypted, err := utils.EncryptSecret(s.key, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt two-factor secret: %w", err)
+	}
+
+	if err := s.userRepo.SetTwoFactorSecret(ctx, user.ID, encrypted); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			// Enabled by a concurrent request
+			return nil, ErrTwoFactorAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	otpauthURL := utils.TOTPURL(twoFactorIssuer, user.Email, secret)
+	qrCode, err := utils.GenerateTextQRCode(otpauthURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.EnableTwoFactorResponse{
+		Secret:     secret,
+		OTPAuthURL: otpauthURL,
+		QRCode:     qrCode,
+	}, nil
+}
+
+// Verify activates two-factor authentication once the user proves their app produces valid codes
+// Returns the recovery codes, which are never shown again
+func (s *TwoFactorService) Verify(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactorSecret == nil {
+		return nil, ErrTwoFactorNotStarted
+	}
+
+	secret, err := utils.DecryptSecret(s.key, *user.TwoFactorSecret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := s.userRepo.EnableTwoFactorTx(ctx, tx, user.ID, step); err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return ErrTwoFactorAlreadyEnabled
+			}
+			return err
+		}
+		return s.recoveryRepo.ReplaceTx(ctx, tx, user.ID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔐 Two-factor authentication enabled for user %s", user.ID)
+
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking a current code or recovery code
+func (s *TwoFactorService) Disable(ctx context.Context, user *models.User, code string) error {
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err := s.CheckCode(ctx, user, code, code); err != nil {
+		return err
+	}
+
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := s.userRepo.DisableTwoFactorTx(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return s.recoveryRepo.ReplaceTx(ctx, tx, user.ID, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🔓 Two-factor authentication disabled for user %s", user.ID)
+
+	return nil
+}
+
+// CheckLogin is the second step of logging in for users with two-factor authentication
+// Users without it pass; others need a current TOTP code or an unused recovery code
+func (s *TwoFactorService) CheckLogin(ctx context.Context, user *models.User, totpCode, recoveryCode string) error {
+	if !user.TwoFactorEnabled {
+		return nil
+	}
+	if strings.TrimSpace(totpCode) == "" && strings.TrimSpace(recoveryCode) == "" {
+		return ErrTwoFactorRequired
+	}
+
+	return s.CheckCode(ctx, user, totpCode, recoveryCode)
+}
+
+// CheckCode accepts a TOTP code that hasn't been used yet, or else spends a recovery code
+func (s *TwoFactorService) CheckCode(ctx context.Context, user *models.User, totpCode, recoveryCode string) error {
+	if totpCode != "" && user.TwoFactorSecret != nil {
+		secret, err := utils.DecryptSecret(s.key, *user.TwoFactorSecret)
+		if err != nil {
+			// Most likely TWO_FACTOR_ENCRYPTION_KEY changed - recovery codes still work
+			log.Printf("⚠️  Failed to read two-factor secret for user %s: %v", user.ID, err)
+		} else if step, ok := utils.ValidateTOTP(secret, totpCode, time.Now()); ok {
+			fresh, err := s.userRepo.UseTwoFactorStep(ctx, user.ID, step)
+			if err != nil {
+				return err
+			}
+			if fresh {
+				return nil
+			}
+		}
+	}
+
+	if recoveryCode != "" {
+		used, err := s.recoveryRepo.Use(ctx, user.ID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return err
+		}
+		if used {
+			log.Printf("🔐 User %s used a two-factor recovery code", user.ID)
+			return nil
+		}
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+// generateRecoveryCodes returns recoveryCodeCount codes like "3f9a1-c07be" and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		token, err := utils.GenerateSecureToken(5)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes[i] = token[:5] + "-" + token[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring case, spaces and dashes as typed by the user
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
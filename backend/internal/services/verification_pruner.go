@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+)
+
+// VerificationPruner deletes verification records past the retention period
+// Totals are kept in daily rollups, so dashboards and bill verification counts are unaffected
+type VerificationPruner struct {
+	verificationRepo *repository.VerificationRepository
+	cfg              config.RetentionConfig
+	loc              *time.Location
+}
+
+// NewVerificationPruner creates a new verification pruner
+func NewVerificationPruner(verificationRepo *repository.VerificationRepository, cfg *config.Config) *VerificationPruner {
+	return &VerificationPruner{
+		verificationRepo: verificationRepo,
+		cfg:              cfg.Retention,
+		loc:              cfg.Location(),
+	}
+}
+
+// Run prunes on start and then every Interval until ctx is cancelled
+// Call it in its own goroutine; it does nothing while VerificationRetention is 0
+func (p *VerificationPruner) Run(ctx context.Context) {
+	if p.cfg.VerificationRetention <= 0 {
+		return
+	}
+
+	log.Printf("🧹 Verification pruner started (retention %s, interval %s)", p.cfg.VerificationRetention, p.cfg.Interval)
+
+	for {
+		pruned, err := p.Prune(ctx, time.Now())
+		if err != nil {
+			log.Printf("⚠️  Verification pruning failed after %d records: %v", pruned, err)
+		} else if pruned > 0 {
+			log.Printf("🧹 Pruned %d verification records", pruned)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("🧹 Verification pruner stopped")
+			return
+		case <-time.After(p.cfg.Interval):
+		}
+	}
+}
+
+// Prune removes every verification past its retention as of now, one batch at a time
+// Stops early, without an error, once ctx is cancelled; later batches wait for the next run
+func (p *VerificationPruner) Prune(ctx context.Context, now time.Time) (int64, error) {
+	cutoff := now.Add(-p.cfg.VerificationRetention)
+
+	// Suspicious verifications are kept forever unless they have their own retention
+	var suspiciousCutoff *time.Time
+	if p.cfg.SuspiciousRetention > 0 {
+		t := now.Add(-p.cfg.SuspiciousRetention)
+		suspiciousCutoff = &t
+	}
+
+	var total int64
+	for ctx.Err() == nil {
+		deleted, err := p.verificationRepo.DeleteOlderThan(ctx, cutoff, suspiciousCutoff, p.cfg.Archive, p.cfg.BatchSize, p.loc)
+		if err != nil {
+			if ctx.Err() != nil {
+				return total, nil
+			}
+			return total, err
+		}
+		total += deleted
+		if deleted < int64(p.cfg.BatchSize) {
+			break
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestPruneRemovesOnlyVerificationsPastRetention(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	env.cfg.Retention = config.RetentionConfig{
+		VerificationRetention: 30 * 24 * time.Hour,
+		SuspiciousRetention:   365 * 24 * time.Hour,
+		Archive:               true,
+		BatchSize:             1, // Several batches in one run
+	}
+	pruner := NewVerificationPruner(env.verifications, env.cfg)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+	verification := func(age time.Duration, suspicious bool) *models.Verification {
+		v := testutil.CreateVerification(t, env.db, bill, verifier)
+		env.db.MustExec("UPDATE verifications SET verified_at = $2, is_suspicious = $3, amount_charged = 5 WHERE id = $1",
+			v.ID, now.Add(-age), suspicious)
+		return v
+	}
+	oldValid := verification(40*24*time.Hour, false)
+	recent := verification(24*time.Hour, false)
+	oldSuspicious := verification(40*24*time.Hour, true)
+	ancientSuspicious := verification(400*24*time.Hour, true)
+
+	statsBefore, err := env.verifications.GetStatsByVerifier(ctx, verifier.ID)
+	if err != nil {
+		t.Fatalf("GetStatsByVerifier failed: %v", err)
+	}
+
+	pruned, err := pruner.Prune(ctx, now)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d verifications, want 2", pruned)
+	}
+
+	for _, tt := range []struct {
+		name string
+		v    *models.Verification
+		kept bool
+	}{
+		{"past retention", oldValid, false},
+		{"recent", recent, true},
+		{"suspicious within its own retention", oldSuspicious, true},
+		{"suspicious past its own retention", ancientSuspicious, false},
+	} {
+		if n := testutil.Count(t, env.db, "verifications", "id = $1", tt.v.ID); (n == 1) != tt.kept {
+			t.Errorf("%s: kept = %v, want %v", tt.name, n == 1, tt.kept)
+		}
+	}
+	if n := testutil.Count(t, env.db, "verifications_archive", "id IN ($1, $2)", oldValid.ID, ancientSuspicious.ID); n != 2 {
+		t.Errorf("archived %d pruned verifications, want 2", n)
+	}
+
+	// Rollups keep the totals intact
+	statsAfter, err := env.verifications.GetStatsByVerifier(ctx, verifier.ID)
+	if err != nil {
+		t.Fatalf("GetStatsByVerifier failed: %v", err)
+	}
+	if statsAfter.TotalVerifications != statsBefore.TotalVerifications || statsAfter.TotalSpent != statsBefore.TotalSpent {
+		t.Errorf("stats after pruning = %d verifications, %.2f spent; want %d, %.2f",
+			statsAfter.TotalVerifications, statsAfter.TotalSpent, statsBefore.TotalVerifications, statsBefore.TotalSpent)
+	}
+	if count, err := env.verifications.CountVerificationsByBill(ctx, bill.ID); err != nil || count != 4 {
+		t.Errorf("CountVerificationsByBill = %d, %v; want 4", count, err)
+	}
+
+	// Nothing left to prune on the next run
+	if pruned, err := pruner.Prune(ctx, now); err != nil || pruned != 0 {
+		t.Errorf("second Prune = %d, %v; want 0", pruned, err)
+	}
+}
+
+func TestPruneKeepsSuspiciousVerificationsWithoutTheirOwnRetention(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Retention = config.RetentionConfig{VerificationRetention: time.Hour, BatchSize: 100}
+	pruner := NewVerificationPruner(env.verifications, env.cfg)
+
+	bill := testutil.CreateBill(t, env.db, testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0), 100, nil)
+	suspicious := testutil.CreateVerification(t, env.db, bill, nil)
+	env.db.MustExec("UPDATE verifications SET verified_at = NOW() - INTERVAL '10 years', is_suspicious = true WHERE id = $1", suspicious.ID)
+
+	if pruned, err := pruner.Prune(context.Background(), time.Now()); err != nil || pruned != 0 {
+		t.Errorf("Prune = %d, %v; want 0", pruned, err)
+	}
+	if n := testutil.Count(t, env.db, "verifications_archive", "id = $1", suspicious.ID); n != 0 {
+		t.Error("suspicious verification was archived")
+	}
+}
@@ -3,48 +3,99 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/database"
 	"github.com/ezhilnn/epr-backend/internal/models"
 	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+	"github.com/jmoiron/sqlx"
 )
 
 // VerificationService handles business logic for bill verifications
 type VerificationService struct {
+	db               *database.DB
 	verificationRepo *repository.VerificationRepository
 	billRepo         *repository.BillRepository
+	aliasRepo        *repository.BillAliasRepository
 	userRepo         *repository.UserRepository
+	walletTxRepo     *repository.WalletTransactionRepository
+	outboxRepo       *repository.OutboxRepository
+	counters         *repository.ActivityCounterRepository
+	detector         *SuspiciousActivityDetector
+	fakeBillAlerter  *FakeBillAlerter
+	pricing          *PricingEngine
+	notifier         *LowBalanceNotifier
+	quotaLocation    *time.Location
 	cfg              *config.Config
 }
 
 // NewVerificationService creates a new verification service
 func NewVerificationService(
+	db *database.DB,
 	verificationRepo *repository.VerificationRepository,
 	billRepo *repository.BillRepository,
+	aliasRepo *repository.BillAliasRepository,
 	userRepo *repository.UserRepository,
+	walletTxRepo *repository.WalletTransactionRepository,
+	outboxRepo *repository.OutboxRepository,
+	counters *repository.ActivityCounterRepository,
+	detector *SuspiciousActivityDetector,
+	fakeBillAlerter *FakeBillAlerter,
+	notifier *LowBalanceNotifier,
 	cfg *config.Config,
 ) *VerificationService {
+	// Config.Validate has already checked the timezone
+	quotaLocation, err := time.LoadLocation(cfg.App.VerificationQuotaTimezone)
+	if err != nil {
+		quotaLocation = time.UTC
+	}
+
 	return &VerificationService{
+		db:               db,
 		verificationRepo: verificationRepo,
 		billRepo:         billRepo,
+		aliasRepo:        aliasRepo,
 		userRepo:         userRepo,
+		walletTxRepo:     walletTxRepo,
+		outboxRepo:       outboxRepo,
+		counters:         counters,
+		detector:         detector,
+		fakeBillAlerter:  fakeBillAlerter,
+		pricing:          NewPricingEngine(cfg.Pricing),
+		notifier:         notifier,
+		quotaLocation:    quotaLocation,
 		cfg:              cfg,
 	}
 }
 
 // VerifyBill verifies a bill and charges the user
+// billNumber may also be the bill's short code
 func (s *VerificationService) VerifyBill(
 	ctx context.Context,
 	userID *string,
 	billNumber, ip, userAgent string,
 	userRole models.UserRole,
+	explain bool,
 ) (*models.VerifyBillResponse, error) {
 	startTime := time.Now()
 
+	// Callers who aren't logged in only get a limited number of free verifications per day
+	if userID == nil {
+		if err := s.useAnonymousAllowance(ctx, ip); err != nil {
+			return nil, err
+		}
+	}
+
 	// Try to find bill
-	bill, err := s.billRepo.GetByBillNumber(ctx, billNumber)
+	bill, err := findBillByReference(ctx, s.billRepo, s.aliasRepo, billNumber)
 
 	// Bill not found
 	if err != nil {
@@ -56,150 +107,499 @@ func (s *VerificationService) VerifyBill(
 			Fee:        s.cfg.Pricing.VerificationMinFee,
 		}
 
-		// Record verification (even for not found)
-		if userID != nil {
-			s.recordVerification(ctx, userID, nil, billNumber, response.Fee, false, models.VerificationNotFound, nil, ip, userAgent, int(time.Since(startTime).Milliseconds()))
+		suspiciousReason := s.detector.Check(ctx, ip, billNumber, false)
+		s.fakeBillAlerter.RecordNotFound(ctx, billNumber)
+
+		// Record verification (even for not found, and for anonymous callers so probing is auditable)
+		recordedFee := response.Fee
+		if userID == nil {
+			recordedFee = 0
+		}
+		verification := s.newVerificationRecord(ctx, userID, nil, billNumber, recordedFee, false, models.VerificationNotFound, nil, ip, userAgent, int(time.Since(startTime).Milliseconds()))
+		if userID == nil {
+			verification.PricingRuleApplied = anonymousPricingRule
+		}
+		if suspiciousReason != "" {
+			verification.IsSuspicious = true
+			verification.SuspiciousReason = &suspiciousReason
+		}
+		if err := s.verificationRepo.Create(ctx, verification); err != nil {
+			log.Printf("⚠️  Failed to record verification of unknown bill %s: %v", billNumber, err)
 		}
 
 		return response, nil
 	}
 
+	// Record and report the real bill number when a short code was given
+	billNumber = bill.BillNumber
+
 	// Determine user's access level
 	accessLevel := s.determineAccessLevel(userRole, bill)
 
 	// Calculate pricing
-	fee, wasFree, _ := s.calculatePrice(ctx, userID, bill.Amount, bill.AccessLevel)
-
-	// Check wallet balance if user is authenticated
-	if userID != nil && !wasFree {
-		user, err := s.userRepo.GetByID(ctx, *userID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get user: %w", err)
-		}
-
-		if user.WalletBalance < fee {
-			return nil, fmt.Errorf("insufficient wallet balance. Required: ₹%.2f, Available: ₹%.2f", fee, user.WalletBalance)
-		}
-
-		// Deduct from wallet
-		newBalance := user.WalletBalance - fee
-		if err := s.userRepo.UpdateWalletBalance(ctx, *userID, newBalance); err != nil {
-			return nil, fmt.Errorf("failed to deduct wallet balance: %w", err)
-		}
-
-		// Update verification count and check loyalty
-		earnedFree, err := s.userRepo.IncrementVerificationCount(ctx, *userID)
-		if err != nil {
-			// Log but don't fail
-			fmt.Printf("Warning: Failed to update verification count: %v\n", err)
-		}
-		if earnedFree {
-			fmt.Printf("User %s earned a free verification!\n", *userID)
-		}
-	}
+	fee, wasFree, pricingRule := s.calculatePrice(ctx, userID, bill, accessLevel)
 
 	// Build response based on access level
 	response := s.buildVerificationResponse(bill, accessLevel, fee)
 
 	// Record verification
-	dataRevealed := s.getRevealedFields(accessLevel)
+	dataRevealed := s.getRevealedFields(bill, accessLevel)
 	verificationStatus := models.VerificationValid
+	if response.Status == "expired" {
+		verificationStatus = models.VerificationExpired
+	}
 	if accessLevel == "none" {
 		verificationStatus = models.VerificationRestricted
 		response.Status = "restricted"
 		response.Message = "This bill requires institutional access to view full details."
 	}
+	if explain {
+		response.Explanation = s.explainAccess(userID, userRole, bill, accessLevel, fee, wasFree)
+	}
 
-	if userID != nil {
-		s.recordVerification(ctx, userID, &bill.ID, billNumber, fee, wasFree, verificationStatus, dataRevealed, ip, userAgent, int(time.Since(startTime).Milliseconds()))
+	// Re-hash the stored bill data - a mismatch means it was changed after issuance
+	intact, integrityErr := s.checkDataIntegrity(bill)
+	response.BlockchainVerified = intact
+	response.DataIntegrity = "intact"
+	var suspiciousReason *string
+	if !intact {
+		verificationStatus = models.VerificationSuspicious
+		response.Status = "suspicious"
+		response.DataIntegrity = "tampered"
+		response.Message = "This bill's data does not match its registered fingerprint. It may have been tampered with."
+		reason := "data hash mismatch"
+		if integrityErr != nil {
+			reason = fmt.Sprintf("data hash check failed: %v", integrityErr)
+		}
+		suspiciousReason = &reason
 	}
 
+	// Unusual activity around this IP or bill - flag it without changing the bill's result
+	if activityReason := s.detector.Check(ctx, ip, billNumber, true); activityReason != "" {
+		if suspiciousReason != nil {
+			activityReason = *suspiciousReason + "; " + activityReason
+		} else if s.cfg.Fraud.DowngradeResponse {
+			response.Details = nil
+			response.Message += " Bill details are withheld due to unusual verification activity."
+		}
+		suspiciousReason = &activityReason
+	}
+
+	if userID == nil {
+		// Anonymous verifications aren't charged, but are recorded with the caller's IP so abuse is auditable
+		verification := s.newVerificationRecord(ctx, nil, &bill.ID, billNumber, 0, false, verificationStatus, dataRevealed, ip, userAgent, int(time.Since(startTime).Milliseconds()))
+		verification.PricingRuleApplied = anonymousPricingRule
+		verification.BlockchainVerified = intact
+		verification.IsSuspicious = suspiciousReason != nil
+		verification.SuspiciousReason = suspiciousReason
+
+		if err := s.recordAnonymousVerification(ctx, bill, verification, response); err != nil {
+			log.Printf("⚠️  Failed to record anonymous verification of %s: %v", billNumber, err)
+		}
+		return response, nil
+	}
+
+	// The issuer's webhook is queued with the verification so it is sent exactly when the verification is recorded
+	notification, err := s.issuerNotification(bill, string(userRole), response)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := s.newVerificationRecord(ctx, userID, &bill.ID, billNumber, fee, wasFree, verificationStatus, dataRevealed, ip, userAgent, int(time.Since(startTime).Milliseconds()))
+	verification.PricingRuleApplied = pricingRule
+	verification.BlockchainVerified = intact
+	verification.IsSuspicious = suspiciousReason != nil
+	verification.SuspiciousReason = suspiciousReason
+
+	// Spend the free credit or charge the wallet, and record everything atomically
+	if err := s.settleVerification(ctx, *userID, bill, accessLevel, verification, notification); err != nil {
+		return nil, err
+	}
+	response.Fee = verification.AmountCharged
+
 	return response, nil
 }
 
-// calculatePrice calculates verification price based on bill amount and access level
-func (s *VerificationService) calculatePrice(ctx context.Context, userID *string, billAmount float64, accessLevel models.AccessLevel) (float64, bool, string) {
-	// Check loyalty (every 10th verification is free)
-	if userID != nil {
-		user, err := s.userRepo.GetByID(ctx, *userID)
-		if err == nil && user.FreeVerificationsEarned > 0 {
-			// Use free verification
-			// Note: In production, you'd decrement this in a transaction
-			return 0, true, "loyalty_free"
+// useAnonymousAllowance counts a verification against the IP's free daily allowance
+// Returns an AnonymousQuotaExceededError once the allowance is used up. Counter errors are
+// logged and never block a verification
+func (s *VerificationService) useAnonymousAllowance(ctx context.Context, ip string) error {
+	limit := s.cfg.App.AnonymousDailyFreeVerifications
+	if limit <= 0 {
+		return nil
+	}
+
+	now := time.Now().In(s.quotaLocation)
+	year, month, day := now.Date()
+	resetsAt := time.Date(year, month, day+1, 0, 0, 0, 0, s.quotaLocation)
+
+	count, err := s.counters.IncrementUntil(ctx, "verify_quota:ip:"+ip+":"+now.Format("2006-01-02"), resetsAt)
+	if err != nil {
+		log.Printf("⚠️  Free verification allowance check failed for IP %s: %v", ip, err)
+		return nil
+	}
+	if count > limit {
+		return &AnonymousQuotaExceededError{Limit: limit, ResetsAt: resetsAt}
+	}
+
+	return nil
+}
+
+// anonymousPricingRule marks verifications made without an account, which are never charged
+const anonymousPricingRule = "anonymous"
+
+// recordAnonymousVerification stores an anonymous verification together with the issuer's webhook
+func (s *VerificationService) recordAnonymousVerification(ctx context.Context, bill *models.Bill, verification *models.Verification, response *models.VerifyBillResponse) error {
+	notification, err := s.issuerNotification(bill, "anonymous", response)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := s.verificationRepo.CreateTx(ctx, tx, verification); err != nil {
+			return err
+		}
+		return s.outboxRepo.CreateTx(ctx, tx, notification)
+	})
+}
+
+// issuerNotification builds the outbox message that tells the bill's issuer about the verification
+// The OutboxDispatcher sends it to their webhook, if they configured one
+func (s *VerificationService) issuerNotification(bill *models.Bill, verifierType string, response *models.VerifyBillResponse) (*models.OutboxMessage, error) {
+	notification, err := models.NewOutboxMessage(models.OutboxBillVerifiedWebhook, models.BillVerifiedWebhookMessage{
+		IssuerID: bill.IssuerID,
+		Payload: models.BillVerifiedPayload{
+			BillNumber:   bill.BillNumber,
+			VerifierType: verifierType,
+			Result:       response.Status,
+			VerifiedAt:   time.Now().UTC(),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode verification webhook: %w", err)
+	}
+	return notification, nil
+}
+
+// GetVerificationQuota reports how much of the user's prepaid plan is left this period
+func (s *VerificationService) GetVerificationQuota(ctx context.Context, userID string) (*models.VerificationQuotaResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PlanType == nil {
+		return &models.VerificationQuotaResponse{HasPlan: false}, nil
+	}
+
+	remaining := user.PlanMonthlyQuota - user.PlanQuotaUsed
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &models.VerificationQuotaResponse{
+		HasPlan:      true,
+		PlanType:     *user.PlanType,
+		MonthlyQuota: user.PlanMonthlyQuota,
+		Used:         user.PlanQuotaUsed,
+		Remaining:    remaining,
+		ResetsAt:     user.PlanResetsAt,
+	}, nil
+}
+
+// BillNumberFromQR decodes an uploaded QR image and returns the bill number or short code in its verification link
+// Only links generated for our own frontend are accepted
+func (s *VerificationService) BillNumberFromQR(image io.Reader) (string, error) {
+	link, err := utils.DecodeQRCode(image)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnreadableQR, err)
+	}
+
+	billNumber, err := utils.ParseVerificationLink(link, s.cfg.App.FrontendURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidQRLink, err)
+	}
+
+	return billNumber, nil
+}
+
+// EstimatePrice computes what verifying a bill would cost the caller without charging or recording anything
+// Uses the same pricing and access rules as VerifyBill, including the caller's loyalty credits
+func (s *VerificationService) EstimatePrice(ctx context.Context, userID *string, userRole models.UserRole, billNumber string) (*models.PriceEstimateResponse, error) {
+	bill, err := findBillByReference(ctx, s.billRepo, s.aliasRepo, billNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	accessLevel := s.determineAccessLevel(userRole, bill)
+	fee, isFree, pricingRule := s.calculatePrice(ctx, userID, bill, accessLevel)
+
+	return &models.PriceEstimateResponse{
+		BillNumber:  bill.BillNumber,
+		BillType:    bill.BillType,
+		Fee:         fee,
+		IsFree:      isFree,
+		PricingRule: pricingRule,
+		AccessLevel: accessLevel,
+	}, nil
+}
+
+// settleVerification consumes a loyalty credit or debits the fee, then stores the verification,
+// its ledger entry, the loyalty counter update and the issuer notification in one transaction
+func (s *VerificationService) settleVerification(ctx context.Context, userID string, bill *models.Bill, accessLevel string, verification *models.Verification, notification *models.OutboxMessage) error {
+	var newBalance float64
+	var charged, earnedFree bool
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := s.outboxRepo.CreateTx(ctx, tx, notification); err != nil {
+			return err
 		}
+
+		// Lock the user row so concurrent verifications serialize on the free credit and loyalty count
+		user, err := s.userRepo.GetByIDForUpdateTx(ctx, tx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		newBalance = user.WalletBalance
+
+		if verification.WasFree {
+			consumed, err := s.userRepo.ConsumeFreeVerificationTx(ctx, tx, userID)
+			if err != nil {
+				return err
+			}
+			if !consumed {
+				// Credit was spent by a concurrent request - charge the normal price instead
+				fee, _, rule := s.price(bill, accessLevel, false)
+				verification.WasFree = false
+				verification.AmountCharged = fee
+				verification.PricingRuleApplied = rule
+			}
+		}
+
+		// Prepaid plans cover the verification before the wallet is touched
+		if !verification.WasFree {
+			covered, err := s.userRepo.ConsumePlanQuotaTx(ctx, tx, userID)
+			if err != nil {
+				return err
+			}
+			if covered {
+				verification.CoveredByPlan = true
+				verification.AmountCharged = 0
+				verification.PricingRuleApplied = "plan_quota"
+			}
+		}
+
+		if verification.WasFree || verification.CoveredByPlan {
+			if err := s.verificationRepo.CreateTx(ctx, tx, verification); err != nil {
+				return err
+			}
+			return s.queueReceipt(ctx, tx, verification, newBalance)
+		}
+
+		// Deduct from wallet - fails without charging if the balance can't cover the fee
+		fee := verification.AmountCharged
+		newBalance, err = s.userRepo.AdjustWalletBalanceTx(ctx, tx, userID, -fee)
+		if err != nil {
+			return debitError(err, fee, newBalance)
+		}
+
+		if err := s.verificationRepo.CreateTx(ctx, tx, verification); err != nil {
+			return err
+		}
+
+		// Record the fee in the wallet statement
+		ledgerEntry := &models.WalletTransaction{
+			UserID:       userID,
+			Type:         models.WalletTxVerificationFee,
+			Amount:       -fee,
+			BalanceAfter: newBalance,
+			ReferenceID:  &verification.ID,
+		}
+		if err := s.walletTxRepo.CreateTx(ctx, tx, ledgerEntry); err != nil {
+			return fmt.Errorf("failed to record wallet transaction: %w", err)
+		}
+
+		// Update verification count and check loyalty (paid verifications only)
+		earnedFree, err = s.userRepo.IncrementVerificationCountTx(ctx, tx, userID, s.cfg.Pricing.LoyaltyFreeEveryN)
+		if err != nil {
+			return err
+		}
+
+		charged = true
+		return s.queueReceipt(ctx, tx, verification, newBalance)
+	})
+	if err != nil || !charged {
+		return err
 	}
 
-	// Calculate based on bill amount (1% of bill)
-	percentagePrice := billAmount * s.cfg.Pricing.VerificationPercentage
-	percentagePrice = percentagePrice * 0.5
+	if earnedFree {
+		fmt.Printf("User %s earned a free verification!\n", userID)
+	}
 
-	// Apply min/max constraints
-	minFee := s.cfg.Pricing.VerificationMinFee
-	maxFee := s.cfg.Pricing.VerificationMaxFee
+	// Warn the user if this charge left the wallet running low
+	s.notifier.Check(userID, newBalance)
 
-	finalPrice := percentagePrice
-	pricingRule := "percentage_1_percent"
+	return nil
+}
 
-	if percentagePrice < minFee {
-		finalPrice = minFee
-		pricingRule = "minimum_fee"
-	} else if percentagePrice > maxFee {
-		finalPrice = maxFee
-		pricingRule = "maximum_fee_capped"
+// queueReceipt queues the verifier's receipt email in the settlement transaction
+// The preference is checked when it is sent, so turning receipts off also stops queued ones
+func (s *VerificationService) queueReceipt(ctx context.Context, tx *sqlx.Tx, verification *models.Verification, balanceAfter float64) error {
+	receipt, err := models.NewOutboxMessage(models.OutboxVerificationReceipt, models.VerificationReceiptMessage{
+		UserID:         *verification.VerifierID,
+		VerificationID: verification.ID,
+		BillNumber:     verification.BillNumber,
+		Result:         verification.VerificationStatus,
+		AmountCharged:  verification.AmountCharged,
+		WasFree:        verification.WasFree,
+		CoveredByPlan:  verification.CoveredByPlan,
+		BalanceAfter:   balanceAfter,
+		VerifiedAt:     verification.VerifiedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode verification receipt: %w", err)
 	}
+	return s.outboxRepo.CreateTx(ctx, tx, receipt)
+}
 
-	// Adjust based on access level
-	switch accessLevel {
-	case models.AccessLevelRestricted:
-		finalPrice = finalPrice * 1.5 // 50% more for restricted
-		pricingRule = "restricted_access_premium"
-	case models.AccessLevelGovernment, models.AccessLevelFinancial:
-		finalPrice = maxFee // Always max for government/financial
-		pricingRule = "government_financial_premium"
+// checkDataIntegrity re-hashes the stored bill data and compares it with the recorded hash
+// Returns false (with the cause, if any) when the data can't be matched to its hash
+func (s *VerificationService) checkDataIntegrity(bill *models.Bill) (bool, error) {
+	var billData map[string]interface{}
+	if err := json.Unmarshal(bill.BillData, &billData); err != nil {
+		return false, fmt.Errorf("invalid bill data: %w", err)
+	}
+
+	matches, err := utils.VerifyBillHash(billData, bill.DataHash)
+	if err != nil {
+		return false, err
 	}
 
-	// Ensure within bounds
-	if finalPrice < minFee {
-		finalPrice = minFee
+	return matches, nil
+}
+
+// calculatePrice looks up the caller's loyalty credit and prices the verification with the pricing engine
+// accessLevel is what the caller gets to see, as returned by determineAccessLevel
+func (s *VerificationService) calculatePrice(ctx context.Context, userID *string, bill *models.Bill, accessLevel string) (float64, bool, string) {
+	hasFreeCredit := false
+	if userID != nil {
+		user, err := s.userRepo.GetByID(ctx, *userID)
+		hasFreeCredit = err == nil && user.FreeVerificationsEarned > 0
 	}
-	if finalPrice > maxFee {
-		finalPrice = maxFee
+
+	return s.price(bill, accessLevel, hasFreeCredit)
+}
+
+// price picks the pricing rule for what the caller gets to see
+// Previews are charged as a downgrade rather than at the bill's access level premium
+func (s *VerificationService) price(bill *models.Bill, accessLevel string, hasFreeCredit bool) (float64, bool, string) {
+	if accessLevel == "preview" {
+		return s.pricing.CalculatePreview(bill.BillType, hasFreeCredit)
 	}
+	return s.pricing.Calculate(bill.Amount, bill.AccessLevel, bill.BillType, hasFreeCredit)
+}
 
-	return finalPrice, false, pricingRule
+// fullAccessRoles lists the roles that see all of a bill's data at the given access level
+// nil means everyone, including callers who aren't logged in
+func fullAccessRoles(level models.AccessLevel) []models.UserRole {
+	switch level {
+	case models.AccessLevelPublic:
+		return nil
+	case models.AccessLevelRestricted:
+		// Institutions and verifiers
+		return []models.UserRole{models.RoleInstitutionUser, models.RoleInstitutionAdmin, models.RoleVerifier, models.RoleMasterAdmin}
+	case models.AccessLevelGovernment, models.AccessLevelFinancial:
+		// Only verifiers
+		return []models.UserRole{models.RoleVerifier, models.RoleMasterAdmin}
+	}
+	return []models.UserRole{}
 }
 
 // determineAccessLevel determines what access level the user has
 func (s *VerificationService) determineAccessLevel(userRole models.UserRole, bill *models.Bill) string {
-	// Public bills - everyone gets full access
-	if bill.AccessLevel == models.AccessLevelPublic {
+	roles := fullAccessRoles(bill.AccessLevel)
+	if roles == nil || slices.Contains(roles, userRole) {
 		return "full"
 	}
 
-	// Restricted bills - institutions and verifiers get full access
-	if bill.AccessLevel == models.AccessLevelRestricted {
-		if userRole == models.RoleInstitutionUser ||
-			userRole == models.RoleInstitutionAdmin ||
-			userRole == models.RoleVerifier ||
-			userRole == models.RoleMasterAdmin {
-			return "full"
+	// Government/Financial - others see at most a preview
+	if bill.AccessLevel == models.AccessLevelGovernment || bill.AccessLevel == models.AccessLevelFinancial {
+		if bill.AllowPreview {
+			return "preview" // Others can confirm the bill exists
 		}
-		return "limited" // Public users get limited info
+		return "none" // Others see restricted message
 	}
 
-	// Government/Financial - only verifiers get full access
-	if bill.AccessLevel == models.AccessLevelGovernment || bill.AccessLevel == models.AccessLevelFinancial {
-		if userRole == models.RoleVerifier || userRole == models.RoleMasterAdmin {
-			return "full"
+	return "limited" // Public users get limited info
+}
+
+// explainAccess says why the caller got accessLevel and what full access would take
+// Only the access rules are described - never any of the bill's data beyond what the response already shows
+func (s *VerificationService) explainAccess(userID *string, userRole models.UserRole, bill *models.Bill, accessLevel string, fee float64, wasFree bool) *models.AccessExplanation {
+	currentRole := string(userRole)
+	if userID == nil {
+		currentRole = "anonymous"
+	}
+
+	explanation := &models.AccessExplanation{
+		AccessLevel:     accessLevel,
+		BillAccessLevel: string(bill.AccessLevel),
+		CurrentRole:     currentRole,
+	}
+
+	roles := fullAccessRoles(bill.AccessLevel)
+	for _, role := range roles {
+		explanation.FullAccessRoles = append(explanation.FullAccessRoles, string(role))
+		if role.IsInstitution() {
+			explanation.KYCRequired = true
 		}
-		return "none" // Others see restricted message
 	}
 
-	return "limited"
+	if accessLevel == "full" {
+		explanation.Reason = "You can see all of this bill's details."
+		explanation.FullAccessFee = fee
+		return explanation
+	}
+
+	// Priced like fee, before plan quotas or wallet charges are applied
+	fullFee, _, _ := s.price(bill, "full", wasFree)
+	explanation.FullAccessFee = fullFee
+	explanation.FeeDifference = math.Max(fullFee-fee, 0)
+
+	switch accessLevel {
+	case "limited":
+		explanation.Reason = fmt.Sprintf("This is a %s bill. Only the amount and basic details are shown to %s callers.", bill.AccessLevel, currentRole)
+	case "preview":
+		explanation.Reason = fmt.Sprintf("This is a %s bill. Its issuer allows others to confirm it exists, but its details are only shown to the roles listed.", bill.AccessLevel)
+	case "none":
+		explanation.Reason = fmt.Sprintf("This is a %s bill. Its details are only shown to the roles listed.", bill.AccessLevel)
+	}
+	if userID == nil {
+		explanation.Reason += " Log in with one of those roles to see more."
+	} else if explanation.KYCRequired {
+		explanation.Reason += " Institution accounts need approved KYC."
+	}
+
+	return explanation
 }
 
 // buildVerificationResponse builds the response based on access level
 func (s *VerificationService) buildVerificationResponse(bill *models.Bill, accessLevel string, fee float64) *models.VerifyBillResponse {
+	// Preview - existence, issuer, type and blockchain status only, not even dates
+	if accessLevel == "preview" {
+		return &models.VerifyBillResponse{
+			Success:    true,
+			BillNumber: bill.BillNumber,
+			Status:     "valid",
+			IssuerName: bill.IssuerName,
+			BillType:   string(bill.BillType),
+			Message:    "This bill is registered in the EPR system. Its details are only shown to authorized verifiers.",
+			Details: map[string]interface{}{
+				"blockchain_status": string(bill.BlockchainStatus),
+			},
+			Fee: fee,
+		}
+	}
+
 	response := &models.VerifyBillResponse{
 		Success:    true,
 		BillNumber: bill.BillNumber,
@@ -211,6 +611,15 @@ func (s *VerificationService) buildVerificationResponse(bill *models.Bill, acces
 		Fee:        fee,
 	}
 
+	// A registered bill past its validity window is genuine but no longer in force
+	if bill.ValidUntil != nil {
+		response.ValidUntil = bill.ValidUntil.Format("2006-01-02")
+		if bill.IsExpired(time.Now().In(s.cfg.Location())) {
+			response.Status = "expired"
+			response.Message = fmt.Sprintf("This bill is registered in the EPR system but expired on %s.", bill.ValidUntil.Format("02 January 2006"))
+		}
+	}
+
 	// Add details based on access level
 	if accessLevel == "full" {
 		var billData map[string]interface{}
@@ -218,11 +627,12 @@ func (s *VerificationService) buildVerificationResponse(bill *models.Bill, acces
 			response.Details = billData
 		}
 	} else if accessLevel == "limited" {
-		// Limited access - show only basic info
+		// Limited access - basic info plus whatever the redaction policy allows
 		response.Details = map[string]interface{}{
 			"amount":   bill.Amount,
 			"currency": bill.Currency,
 		}
+		s.applyRedaction(bill, response.Details)
 	} else if accessLevel == "none" {
 		// No access - restricted message
 		response.Status = "restricted"
@@ -232,8 +642,33 @@ func (s *VerificationService) buildVerificationResponse(bill *models.Bill, acces
 	return response
 }
 
+// applyRedaction adds the bill_data fields the redaction policy for the bill's access level allows
+// Masked fields are only included when they hold a string, so other values are never shown raw
+func (s *VerificationService) applyRedaction(bill *models.Bill, details map[string]interface{}) {
+	policy, ok := s.cfg.Redaction[string(bill.AccessLevel)]
+	if !ok {
+		return
+	}
+
+	var billData map[string]interface{}
+	if err := json.Unmarshal(bill.BillData, &billData); err != nil {
+		return
+	}
+
+	for _, field := range policy.Show {
+		if val, exists := billData[field]; exists {
+			details[field] = val
+		}
+	}
+	for _, field := range policy.Mask {
+		if val, ok := billData[field].(string); ok {
+			details[field] = utils.MaskString(val)
+		}
+	}
+}
+
 // getRevealedFields returns what fields were shown to user
-func (s *VerificationService) getRevealedFields(accessLevel string) map[string]interface{} {
+func (s *VerificationService) getRevealedFields(bill *models.Bill, accessLevel string) map[string]interface{} {
 	revealed := make(map[string]interface{})
 
 	switch accessLevel {
@@ -243,6 +678,16 @@ func (s *VerificationService) getRevealedFields(accessLevel string) map[string]i
 	case "limited":
 		revealed["fields_shown"] = []string{"bill_number", "issuer_name", "issue_date", "bill_type", "amount"}
 		revealed["fields_hidden"] = []string{"recipient_details", "line_items", "sensitive_data"}
+		if policy, ok := s.cfg.Redaction[string(bill.AccessLevel)]; ok {
+			revealed["redaction"] = map[string]interface{}{
+				"policy": string(bill.AccessLevel),
+				"shown":  policy.Show,
+				"masked": policy.Mask,
+			}
+		}
+	case "preview":
+		revealed["fields_shown"] = []string{"bill_number", "issuer_name", "bill_type", "blockchain_status"}
+		revealed["fields_hidden"] = []string{"issue_date", "amount", "all_details"}
 	case "none":
 		revealed["fields_shown"] = []string{"bill_number", "issuer_name", "bill_type"}
 		revealed["fields_hidden"] = []string{"all_details"}
@@ -251,8 +696,8 @@ func (s *VerificationService) getRevealedFields(accessLevel string) map[string]i
 	return revealed
 }
 
-// recordVerification saves verification record
-func (s *VerificationService) recordVerification(
+// newVerificationRecord builds the verification row for a request
+func (s *VerificationService) newVerificationRecord(
 	ctx context.Context,
 	userID *string,
 	billID *string,
@@ -263,7 +708,7 @@ func (s *VerificationService) recordVerification(
 	dataRevealed map[string]interface{},
 	ip, userAgent string,
 	responseTime int,
-) {
+) *models.Verification {
 	dataRevealedJSON, _ := json.Marshal(dataRevealed)
 
 	accessLevel := models.AccessLevelPublic
@@ -278,7 +723,7 @@ func (s *VerificationService) recordVerification(
 		}
 	}
 
-	verification := &models.Verification{
+	return &models.Verification{
 		BillID:             billID,
 		BillNumber:         billNumber,
 		VerifierID:         userID,
@@ -293,8 +738,6 @@ func (s *VerificationService) recordVerification(
 		BlockchainVerified: false,
 		ResponseTimeMs:     responseTime,
 	}
-
-	s.verificationRepo.Create(ctx, verification)
 }
 
 // GetVerificationHistory retrieves user's verification history
@@ -312,35 +755,238 @@ func (s *VerificationService) GetVerificationHistory(ctx context.Context, userID
 	}
 
 	// Convert to response format
+	bills := make(map[string]*models.Bill)
 	responses := make([]*models.VerificationHistoryResponse, len(verifications))
 	for i, v := range verifications {
-		// Get bill info if exists
-		issuerName := "Unknown"
-		billType := "Unknown"
-		if v.BillID != nil {
-			bill, err := s.billRepo.GetByID(ctx, *v.BillID)
-			if err == nil {
-				issuerName = bill.IssuerName
-				billType = string(bill.BillType)
+		responses[i] = s.toHistoryResponse(ctx, v, bills)
+	}
+
+	return responses, total, nil
+}
+
+// GetVerificationHistoryAfter retrieves a page of the user's verification history using keyset pagination
+// Returns the cursor for the next page, or nil when this is the last page
+func (s *VerificationService) GetVerificationHistoryAfter(ctx context.Context, userID string, cursor *models.PageCursor, pageSize int) ([]*models.VerificationHistoryResponse, *models.PageCursor, error) {
+	// Fetch one extra row to learn whether another page follows
+	verifications, err := s.verificationRepo.ListByVerifierAfter(ctx, userID, cursor, pageSize+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list verifications: %w", err)
+	}
+
+	var next *models.PageCursor
+	if len(verifications) > pageSize {
+		verifications = verifications[:pageSize]
+		last := verifications[len(verifications)-1]
+		next = &models.PageCursor{Timestamp: last.VerifiedAt, ID: last.ID}
+	}
+
+	bills := make(map[string]*models.Bill)
+	responses := make([]*models.VerificationHistoryResponse, len(verifications))
+	for i, v := range verifications {
+		responses[i] = s.toHistoryResponse(ctx, v, bills)
+	}
+
+	return responses, next, nil
+}
+
+// GetVerification loads a single verification with a summary of the verified bill
+// Only the verifier who made it or a master admin can read it; anonymous verifications are admin-only
+func (s *VerificationService) GetVerification(
+	ctx context.Context,
+	userID string,
+	userRole models.UserRole,
+	verificationID string,
+) (*models.VerificationDetailResponse, error) {
+	verification, err := s.verificationRepo.GetByID(ctx, verificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	isVerifier := verification.VerifierID != nil && *verification.VerifierID == userID
+	if !isVerifier && userRole != models.RoleMasterAdmin {
+		return nil, ErrVerificationAccessDenied
+	}
+
+	detail := &models.VerificationDetailResponse{Verification: verification}
+	if verification.BillID != nil {
+		bill, err := s.billRepo.GetByID(ctx, *verification.BillID)
+		if err != nil && !errors.Is(err, ErrBillNotFound) {
+			return nil, fmt.Errorf("failed to get bill: %w", err)
+		}
+		if bill != nil {
+			detail.Bill = &models.VerificationBillInfo{
+				ID:         bill.ID,
+				BillNumber: bill.BillNumber,
+				BillType:   bill.BillType,
+				IssuerName: bill.IssuerName,
+				IssueDate:  bill.IssueDate,
 			}
 		}
+	}
+
+	return detail, nil
+}
+
+// GetVerificationCertificate loads everything needed for a verification certificate
+// Only the verifier who performed it or a master admin may get one, and only for valid verifications
+func (s *VerificationService) GetVerificationCertificate(
+	ctx context.Context,
+	userID string,
+	userRole models.UserRole,
+	verificationID string,
+) (*models.Verification, *models.Bill, *models.User, error) {
+	verification, err := s.verificationRepo.GetByID(ctx, verificationID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	isVerifier := verification.VerifierID != nil && *verification.VerifierID == userID
+	if !isVerifier && userRole != models.RoleMasterAdmin {
+		return nil, nil, nil, ErrVerificationAccessDenied
+	}
+
+	// Certificates only vouch for bills that checked out
+	if verification.VerificationStatus != models.VerificationValid || verification.BillID == nil {
+		return nil, nil, nil, ErrCertificateUnavailable
+	}
+
+	bill, err := s.billRepo.GetByID(ctx, *verification.BillID)
+	if err != nil {
+		if errors.Is(err, ErrBillNotFound) {
+			return nil, nil, nil, ErrCertificateUnavailable
+		}
+		return nil, nil, nil, fmt.Errorf("failed to get bill: %w", err)
+	}
+
+	// The certificate shows the amount and dates, which a preview deliberately withholds
+	if s.determineAccessLevel(userRole, bill) == "preview" {
+		return nil, nil, nil, ErrCertificateUnavailable
+	}
 
-		responses[i] = &models.VerificationHistoryResponse{
-			ID:         v.ID,
-			BillNumber: v.BillNumber,
-			IssuerName: issuerName,
-			BillType:   billType,
-			Date:       v.VerifiedAt.Format(time.RFC3339),
-			Result:     string(v.VerificationStatus),
-			Fee:        v.AmountCharged,
-			WasFree:    v.WasFree,
+	// Anonymous public verifications have no verifier account
+	// The certificate records a past event, so it is still issued if the verifier has since been deactivated
+	var verifier *models.User
+	if verification.VerifierID != nil {
+		verifier, err = s.userRepo.GetByIDAny(ctx, *verification.VerifierID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get verifier: %w", err)
 		}
 	}
 
-	return responses, total, nil
+	return verification, bill, verifier, nil
+}
+
+// historyExportChunkSize is how many verifications are loaded per query while exporting
+const historyExportChunkSize = 500
+
+// ExportVerificationHistory walks a user's verification history within an optional date range,
+// calling emit for each row. History is read in chunks so large exports never sit in memory
+func (s *VerificationService) ExportVerificationHistory(
+	ctx context.Context,
+	userID string,
+	startDate, endDate *time.Time,
+	emit func(*models.VerificationHistoryResponse) error,
+) error {
+	// Bills are cached for the whole export; verifiers tend to check the same bills repeatedly
+	bills := make(map[string]*models.Bill)
+
+	for offset := 0; ; offset += historyExportChunkSize {
+		verifications, err := s.verificationRepo.SearchVerifications(ctx, userID, nil, startDate, endDate, historyExportChunkSize, offset)
+		if err != nil {
+			return fmt.Errorf("failed to list verifications: %w", err)
+		}
+
+		for _, v := range verifications {
+			if err := emit(s.toHistoryResponse(ctx, v, bills)); err != nil {
+				return err
+			}
+		}
+
+		if len(verifications) < historyExportChunkSize {
+			return nil
+		}
+	}
+}
+
+// toHistoryResponse converts a verification to its history row
+// bills caches lookups across calls; bills that can't be loaded show as "Unknown"
+func (s *VerificationService) toHistoryResponse(ctx context.Context, v *models.Verification, bills map[string]*models.Bill) *models.VerificationHistoryResponse {
+	// Get bill info if exists
+	issuerName := "Unknown"
+	billType := "Unknown"
+	if v.BillID != nil {
+		bill, cached := bills[*v.BillID]
+		if !cached {
+			bill, _ = s.billRepo.GetByID(ctx, *v.BillID)
+			bills[*v.BillID] = bill
+		}
+		if bill != nil {
+			issuerName = bill.IssuerName
+			billType = string(bill.BillType)
+		}
+	}
+
+	return &models.VerificationHistoryResponse{
+		ID:         v.ID,
+		BillNumber: v.BillNumber,
+		IssuerName: issuerName,
+		BillType:   billType,
+		Date:       v.VerifiedAt.Format(time.RFC3339),
+		Result:     string(v.VerificationStatus),
+		Fee:        v.AmountCharged,
+		WasFree:    v.WasFree,
+	}
 }
 
 // GetVerificationStats retrieves statistics
 func (s *VerificationService) GetVerificationStats(ctx context.Context, userID string) (*models.VerificationStats, error) {
 	return s.verificationRepo.GetStatsByVerifier(ctx, userID)
 }
+
+// trendStatuses are the results every trend bucket reports, even when their count is zero
+var trendStatuses = []models.VerificationStatus{
+	models.VerificationValid,
+	models.VerificationInvalid,
+	models.VerificationNotFound,
+	models.VerificationRestricted,
+	models.VerificationExpired,
+	models.VerificationSuspicious,
+}
+
+// GetVerificationTrends buckets the user's verifications over the last days by day or week, oldest first
+// The range ends today and, for weekly buckets, starts on the Monday of the first week
+func (s *VerificationService) GetVerificationTrends(ctx context.Context, userID, interval string, days int) ([]*models.VerificationTrendBucket, error) {
+	from, to, step := trendWindow(interval, days, s.cfg.Location())
+
+	rows, err := s.verificationRepo.TimeSeriesByVerifier(ctx, userID, interval, from, to, s.cfg.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	// Zero-filled buckets for the whole range
+	var buckets []*models.VerificationTrendBucket
+	byStart := make(map[string]*models.VerificationTrendBucket)
+	for _, start := range trendBucketStarts(from, to, step) {
+		bucket := &models.VerificationTrendBucket{
+			Start:    start,
+			ByStatus: make(map[string]int, len(trendStatuses)),
+		}
+		for _, status := range trendStatuses {
+			bucket.ByStatus[string(status)] = 0
+		}
+		buckets = append(buckets, bucket)
+		byStart[bucket.Start] = bucket
+	}
+
+	for _, row := range rows {
+		bucket, ok := byStart[row.Bucket.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		bucket.ByStatus[string(row.Status)] += row.Count
+		bucket.Total += row.Count
+		bucket.Spent += row.Spent
+	}
+
+	return buckets, nil
+}
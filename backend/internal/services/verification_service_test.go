@@ -0,0 +1,781 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/config"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+// verifyAs verifies bill as user and fails the test on error
+func verifyAs(t *testing.T, svc *VerificationService, user *models.User, billNumber string) *models.VerifyBillResponse {
+	t.Helper()
+
+	result, err := svc.VerifyBill(context.Background(), &user.ID, billNumber, "192.0.2.1", "go-test", user.Role, false)
+	if err != nil {
+		t.Fatalf("VerifyBill failed: %v", err)
+	}
+	return result
+}
+
+func TestLoyaltyFreeVerificationIsSpentOnce(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Pricing.LoyaltyFreeEveryN = 2
+	svc := env.verificationService()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	fee, _, _ := NewPricingEngine(env.cfg.Pricing).Calculate(bill.Amount, bill.AccessLevel, bill.BillType, false)
+
+	// Two paid verifications earn one free one, which the third spends; the fourth is paid again
+	wantFees := []float64{fee, fee, 0, fee}
+	for i, want := range wantFees {
+		if got := verifyAs(t, svc, verifier, bill.BillNumber).Fee; got != want {
+			t.Errorf("verification %d fee = %.2f, want %.2f", i+1, got, want)
+		}
+	}
+
+	user, err := env.users.GetByID(context.Background(), verifier.ID)
+	if err != nil {
+		t.Fatalf("failed to reload verifier: %v", err)
+	}
+	if user.FreeVerificationsEarned != 0 {
+		t.Errorf("free verifications left = %d, want 0", user.FreeVerificationsEarned)
+	}
+	if want := 100 - 3*fee; user.WalletBalance != want {
+		t.Errorf("balance = %.2f, want %.2f", user.WalletBalance, want)
+	}
+	if n := testutil.Count(t, env.db, "verifications", "verifier_id = $1 AND was_free", verifier.ID); n != 1 {
+		t.Errorf("free verifications recorded = %d, want 1", n)
+	}
+}
+
+func TestVerifyBillDetectsTamperedData(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.verificationService()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	intact := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	tampered := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+
+	// Change the stored data without re-hashing it, as a direct database edit would
+	env.db.MustExec(`UPDATE bills SET bill_data = '{"recipient_name":"Someone Else"}' WHERE id = $1`, tampered.ID)
+
+	tests := []struct {
+		name          string
+		bill          *models.Bill
+		wantStatus    string
+		wantIntegrity string
+		wantVerified  bool
+	}{
+		{"intact", intact, "valid", "intact", true},
+		{"tampered", tampered, "suspicious", "tampered", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := verifyAs(t, svc, verifier, tt.bill.BillNumber)
+
+			if result.Status != tt.wantStatus || result.DataIntegrity != tt.wantIntegrity || result.BlockchainVerified != tt.wantVerified {
+				t.Errorf("result = %s / %s / blockchain_verified %v, want %s / %s / %v",
+					result.Status, result.DataIntegrity, result.BlockchainVerified, tt.wantStatus, tt.wantIntegrity, tt.wantVerified)
+			}
+
+			var recorded models.Verification
+			if err := env.db.Get(&recorded, "SELECT * FROM verifications WHERE bill_id = $1", tt.bill.ID); err != nil {
+				t.Fatalf("failed to load verification: %v", err)
+			}
+			if string(recorded.VerificationStatus) != tt.wantStatus || recorded.BlockchainVerified != tt.wantVerified {
+				t.Errorf("recorded %s / blockchain_verified %v, want %s / %v",
+					recorded.VerificationStatus, recorded.BlockchainVerified, tt.wantStatus, tt.wantVerified)
+			}
+			if recorded.IsSuspicious != !tt.wantVerified {
+				t.Errorf("is_suspicious = %v, want %v", recorded.IsSuspicious, !tt.wantVerified)
+			}
+		})
+	}
+}
+
+func TestLowBalanceWarningIsSentOnce(t *testing.T) {
+	env := newTestEnv(t)
+	smtp := newSMTPRecorder(t)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	fee, _, _ := NewPricingEngine(env.cfg.Pricing).Calculate(bill.Amount, bill.AccessLevel, bill.BillType, false)
+	if fee <= 0 {
+		t.Fatalf("verifying the test bill is free (fee %.2f); the test needs a paid verification", fee)
+	}
+
+	// The first charge crosses the threshold and the next two stay below it
+	env.cfg.Pricing.LowBalanceThreshold = 2.5 * fee
+	env.cfg.Pricing.LowBalanceNotifyCooldown = time.Hour
+	svc := env.verificationServiceNotifying(NewLowBalanceNotifier(env.users, env.emailServiceVia(smtp.Addr()), env.cfg))
+
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 3*fee)
+	wellFunded := testutil.CreateUser(t, env.db, models.RoleVerifier, 100*fee)
+
+	for i := 0; i < 3; i++ {
+		verifyAs(t, svc, verifier, bill.BillNumber)
+	}
+	verifyAs(t, svc, wellFunded, bill.BillNumber)
+
+	if sent := smtp.WaitForSent(verifier.Email, 1, 5*time.Second); len(sent) != 1 {
+		t.Fatalf("low balance emails = %d, want 1", len(sent))
+	}
+
+	// Give any duplicate sends time to arrive
+	time.Sleep(200 * time.Millisecond)
+	if sent := smtp.SentTo(verifier.Email); len(sent) != 1 {
+		t.Errorf("low balance emails = %d, want exactly 1", len(sent))
+	}
+	if sent := smtp.SentTo(wellFunded.Email); len(sent) != 0 {
+		t.Errorf("well funded user got %d low balance emails, want 0", len(sent))
+	}
+}
+
+func TestEstimatePriceMatchesActualCharge(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.verificationService()
+	ctx := context.Background()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+
+	tests := []struct {
+		name        string
+		amount      float64
+		accessLevel models.AccessLevel
+		freeCredit  bool
+	}{
+		{"minimum fee", 10, models.AccessLevelPublic, false},
+		{"percentage fee", 1000, models.AccessLevelPublic, false},
+		{"capped fee", 100000, models.AccessLevelPublic, false},
+		{"restricted premium", 1000, models.AccessLevelRestricted, false},
+		{"loyalty credit", 1000, models.AccessLevelPublic, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+			bill := testutil.CreateBill(t, env.db, issuer, tt.amount, nil)
+			env.db.MustExec(`UPDATE bills SET access_level = $1 WHERE id = $2`, tt.accessLevel, bill.ID)
+			if tt.freeCredit {
+				env.db.MustExec(`UPDATE users SET free_verifications_earned = 1 WHERE id = $1`, verifier.ID)
+			}
+
+			estimate, err := svc.EstimatePrice(ctx, &verifier.ID, verifier.Role, bill.BillNumber)
+			if err != nil {
+				t.Fatalf("EstimatePrice failed: %v", err)
+			}
+			if estimate.IsFree != tt.freeCredit {
+				t.Errorf("estimate is_free = %v, want %v", estimate.IsFree, tt.freeCredit)
+			}
+
+			// An estimate neither charges nor records anything
+			if got := env.balanceOf(t, verifier.ID); got != 100 {
+				t.Errorf("balance after estimate = %.2f, want 100.00", got)
+			}
+			if n := testutil.Count(t, env.db, "verifications", "verifier_id = $1", verifier.ID); n != 0 {
+				t.Fatalf("verifications after estimate = %d, want 0", n)
+			}
+
+			result := verifyAs(t, svc, verifier, bill.BillNumber)
+			if result.Fee != estimate.Fee {
+				t.Errorf("charged fee = %.2f, estimate = %.2f", result.Fee, estimate.Fee)
+			}
+			if got, want := env.balanceOf(t, verifier.ID), 100-estimate.Fee; got != want {
+				t.Errorf("balance after verification = %.2f, want %.2f", got, want)
+			}
+			if n := testutil.Count(t, env.db, "verifications", "verifier_id = $1 AND pricing_rule_applied = $2", verifier.ID, estimate.PricingRule); n != 1 {
+				t.Errorf("verifications recorded with rule %q = %d, want 1", estimate.PricingRule, n)
+			}
+		})
+	}
+}
+
+func TestVerifyBillReportsExpiredBills(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.verificationService()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	today := time.Now().In(env.cfg.Location())
+
+	tests := []struct {
+		name       string
+		validUntil time.Time
+		wantStatus string
+	}{
+		{"valid until tomorrow", today.AddDate(0, 0, 1), "valid"},
+		{"valid until today", today, "valid"},
+		{"expired yesterday", today.AddDate(0, 0, -1), "expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+			env.db.MustExec(`UPDATE bills SET valid_until = $1 WHERE id = $2`, tt.validUntil.Format("2006-01-02"), bill.ID)
+
+			result := verifyAs(t, svc, verifier, bill.BillNumber)
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", result.Status, tt.wantStatus)
+			}
+			if result.ValidUntil != tt.validUntil.Format("2006-01-02") {
+				t.Errorf("valid_until = %q, want %s", result.ValidUntil, tt.validUntil.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestParallelVerificationsChargeExactly(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Pricing.LoyaltyFreeEveryN = 0
+	svc := env.verificationService()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	fee, _, _ := NewPricingEngine(env.cfg.Pricing).Calculate(bill.Amount, bill.AccessLevel, bill.BillType, false)
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.VerifyBill(context.Background(), &verifier.ID, bill.BillNumber, "192.0.2.1", "go-test", verifier.Role, false); err != nil {
+				t.Errorf("VerifyBill failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := env.balanceOf(t, verifier.ID), 100-n*fee; got != want {
+		t.Errorf("balance = %.2f, want %.2f", got, want)
+	}
+	if got := testutil.Count(t, env.db, "wallet_transactions", "user_id = $1 AND transaction_type = $2", verifier.ID, models.WalletTxVerificationFee); got != n {
+		t.Errorf("verification fee entries = %d, want %d", got, n)
+	}
+}
+
+func TestPlanQuotaExhaustionFallsBackToWallet(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Pricing.LoyaltyFreeEveryN = 0
+	svc := env.verificationService()
+	ctx := context.Background()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+	fee, _, _ := NewPricingEngine(env.cfg.Pricing).Calculate(bill.Amount, bill.AccessLevel, bill.BillType, false)
+
+	plan := "bank_monthly"
+	nextMonth := time.Now().AddDate(0, 1, 0)
+	if err := env.users.SetVerificationPlan(ctx, verifier.ID, &plan, 2, &nextMonth); err != nil {
+		t.Fatalf("SetVerificationPlan failed: %v", err)
+	}
+
+	// Two verifications come out of the quota, the third is charged to the wallet
+	for i, want := range []float64{0, 0, fee} {
+		if got := verifyAs(t, svc, verifier, bill.BillNumber).Fee; got != want {
+			t.Errorf("verification %d fee = %.2f, want %.2f", i+1, got, want)
+		}
+	}
+	if got, want := env.balanceOf(t, verifier.ID), 100-fee; got != want {
+		t.Errorf("balance = %.2f, want %.2f", got, want)
+	}
+	if n := testutil.Count(t, env.db, "verifications", "verifier_id = $1 AND covered_by_plan", verifier.ID); n != 2 {
+		t.Errorf("verifications covered by the plan = %d, want 2", n)
+	}
+
+	// Once the period is over the quota starts again
+	env.db.MustExec("UPDATE users SET plan_resets_at = NOW() - INTERVAL '1 minute' WHERE id = $1", verifier.ID)
+	if _, err := env.users.ResetDuePlanQuotas(ctx, env.cfg.Location()); err != nil {
+		t.Fatalf("ResetDuePlanQuotas failed: %v", err)
+	}
+	user, err := env.users.GetByID(ctx, verifier.ID)
+	if err != nil {
+		t.Fatalf("failed to reload verifier: %v", err)
+	}
+	if user.PlanQuotaUsed != 0 || user.PlanResetsAt == nil || !user.PlanResetsAt.After(time.Now()) {
+		t.Errorf("after reset: used %d, resets at %v; want 0 and a future reset", user.PlanQuotaUsed, user.PlanResetsAt)
+	}
+	if got := verifyAs(t, svc, verifier, bill.BillNumber).Fee; got != 0 {
+		t.Errorf("fee after the reset = %.2f, want 0", got)
+	}
+}
+
+func TestLimitedAccessMasksRedactedFields(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Redaction = map[string]config.RedactionPolicy{
+		string(models.AccessLevelRestricted): {Show: []string{"description"}, Mask: []string{"recipient_name", "recipient_phone"}},
+	}
+	svc := env.verificationService()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	public := testutil.CreateUser(t, env.db, models.RolePublic, 100)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, map[string]interface{}{
+		"recipient_name":  "Rahul Sharma",
+		"recipient_phone": 9876543210.0,
+		"description":     "Consulting",
+		"account_number":  "1234567890",
+	})
+	env.db.MustExec(`UPDATE bills SET access_level = $1 WHERE id = $2`, models.AccessLevelRestricted, bill.ID)
+
+	t.Run("limited access", func(t *testing.T) {
+		result := verifyAs(t, svc, public, bill.BillNumber)
+
+		// Unlisted fields stay hidden and masked fields that aren't strings are dropped rather than shown raw
+		want := map[string]interface{}{
+			"amount":         1000.0,
+			"currency":       "INR",
+			"description":    "Consulting",
+			"recipient_name": "Rahul S*****",
+		}
+		if !reflect.DeepEqual(result.Details, want) {
+			t.Errorf("details = %v, want %v", result.Details, want)
+		}
+
+		// The policy applied is recorded with the verification
+		var revealed struct {
+			Redaction struct {
+				Policy string   `json:"policy"`
+				Shown  []string `json:"shown"`
+				Masked []string `json:"masked"`
+			} `json:"redaction"`
+		}
+		var raw []byte
+		if err := env.db.Get(&raw, `SELECT data_revealed FROM verifications WHERE verifier_id = $1`, public.ID); err != nil {
+			t.Fatalf("failed to load verification: %v", err)
+		}
+		if err := json.Unmarshal(raw, &revealed); err != nil {
+			t.Fatalf("data_revealed is not JSON: %v", err)
+		}
+		if revealed.Redaction.Policy != "restricted" ||
+			!reflect.DeepEqual(revealed.Redaction.Shown, []string{"description"}) ||
+			!reflect.DeepEqual(revealed.Redaction.Masked, []string{"recipient_name", "recipient_phone"}) {
+			t.Errorf("recorded redaction = %+v, want the restricted policy", revealed.Redaction)
+		}
+	})
+
+	t.Run("full access is unmasked", func(t *testing.T) {
+		result := verifyAs(t, svc, verifier, bill.BillNumber)
+		if name := result.Details["recipient_name"]; name != "Rahul Sharma" {
+			t.Errorf("recipient_name = %v, want Rahul Sharma", name)
+		}
+	})
+}
+
+func TestVerificationHistoryAfterWithInsertsBetweenPages(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.verificationService()
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+	want := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		want[testutil.CreateVerification(t, env.db, bill, verifier).ID] = true
+	}
+	// Rows sharing a timestamp are told apart by id
+	env.db.MustExec(`UPDATE verifications SET verified_at = date_trunc('second', NOW()) - INTERVAL '1 hour' WHERE verifier_id = $1`, verifier.ID)
+
+	seen := map[string]int{}
+	var cursor *models.PageCursor
+	for page := 1; ; page++ {
+		history, next, err := svc.GetVerificationHistoryAfter(context.Background(), verifier.ID, cursor, 3)
+		if err != nil {
+			t.Fatalf("page %d: GetVerificationHistoryAfter failed: %v", page, err)
+		}
+		for _, v := range history {
+			seen[v.ID]++
+		}
+		if next == nil {
+			break
+		}
+
+		// A verification made while the client pages lands before the first page, not in a later one
+		testutil.CreateVerification(t, env.db, bill, verifier)
+
+		// Clients only ever see the opaque cursor
+		if cursor, err = models.DecodePageCursor(next.Encode()); err != nil {
+			t.Fatalf("page %d: cursor doesn't round-trip: %v", page, err)
+		}
+	}
+
+	for id := range want {
+		if seen[id] != 1 {
+			t.Errorf("verification %s listed %d times, want once", id, seen[id])
+		}
+	}
+	if len(seen) != len(want) {
+		t.Errorf("listed %d verifications, want the %d that existed when paging started", len(seen), len(want))
+	}
+}
+
+// queuedReceipts returns the verification receipts waiting in the outbox for userID
+func queuedReceipts(t *testing.T, env *testEnv, userID string) []models.VerificationReceiptMessage {
+	t.Helper()
+
+	var payloads []json.RawMessage
+	err := env.db.Select(&payloads, "SELECT payload FROM outbox WHERE kind = $1 AND payload->>'user_id' = $2",
+		models.OutboxVerificationReceipt, userID)
+	if err != nil {
+		t.Fatalf("failed to read queued receipts: %v", err)
+	}
+
+	receipts := make([]models.VerificationReceiptMessage, len(payloads))
+	for i, payload := range payloads {
+		if err := json.Unmarshal(payload, &receipts[i]); err != nil {
+			t.Fatalf("invalid receipt payload: %v", err)
+		}
+	}
+	return receipts
+}
+
+func TestVerificationReceiptIsQueuedWithFee(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Pricing.LoyaltyFreeEveryN = 0
+	svc := env.verificationService()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+
+	result := verifyAs(t, svc, verifier, bill.BillNumber)
+
+	receipts := queuedReceipts(t, env, verifier.ID)
+	if len(receipts) != 1 {
+		t.Fatalf("queued %d receipts, want 1", len(receipts))
+	}
+	receipt := receipts[0]
+	if receipt.BillNumber != bill.BillNumber {
+		t.Errorf("bill number = %q, want %q", receipt.BillNumber, bill.BillNumber)
+	}
+	if receipt.AmountCharged != result.Fee || receipt.WasFree || receipt.CoveredByPlan {
+		t.Errorf("receipt charge = %.2f (free %v, plan %v), want %.2f paid", receipt.AmountCharged, receipt.WasFree, receipt.CoveredByPlan, result.Fee)
+	}
+	if balance := env.balanceOf(t, verifier.ID); receipt.BalanceAfter != balance {
+		t.Errorf("receipt balance = %.2f, want %.2f", receipt.BalanceAfter, balance)
+	}
+
+	// Anonymous verifications have nobody to send a receipt to
+	before := testutil.Count(t, env.db, "outbox", "kind = $1", models.OutboxVerificationReceipt)
+	if _, err := svc.VerifyBill(context.Background(), nil, bill.BillNumber, "192.0.2.2", "go-test", models.RolePublic, false); err != nil {
+		t.Fatalf("anonymous VerifyBill failed: %v", err)
+	}
+	if after := testutil.Count(t, env.db, "outbox", "kind = $1", models.OutboxVerificationReceipt); after != before {
+		t.Errorf("anonymous verification queued %d receipts, want 0", after-before)
+	}
+}
+
+func TestAnonymousVerificationsArePersisted(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.verificationService()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 1000, nil)
+
+	tests := []struct {
+		name       string
+		billNumber string
+		wantStatus models.VerificationStatus
+	}{
+		{"registered bill", bill.BillNumber, models.VerificationValid},
+		{"unknown bill", "EPR-OTH-1999-999999", models.VerificationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const ip = "198.51.100.7"
+			if _, err := svc.VerifyBill(context.Background(), nil, tt.billNumber, ip, "go-test", models.RolePublic, false); err != nil {
+				t.Fatalf("VerifyBill failed: %v", err)
+			}
+
+			var recorded []models.Verification
+			if err := env.db.Select(&recorded, `SELECT id, bill_number, verifier_id, verifier_ip, amount_charged, pricing_rule_applied, verification_status
+				FROM verifications WHERE bill_number = $1`, tt.billNumber); err != nil {
+				t.Fatalf("failed to read verifications: %v", err)
+			}
+			if len(recorded) != 1 {
+				t.Fatalf("recorded %d verifications, want 1", len(recorded))
+			}
+
+			v := recorded[0]
+			if v.VerifierID != nil {
+				t.Errorf("verifier_id = %s, want null", *v.VerifierID)
+			}
+			if v.VerifierIP == nil || *v.VerifierIP != ip {
+				t.Errorf("verifier_ip = %v, want %s", v.VerifierIP, ip)
+			}
+			if v.VerificationStatus != tt.wantStatus {
+				t.Errorf("status = %s, want %s", v.VerificationStatus, tt.wantStatus)
+			}
+			if v.AmountCharged != 0 || v.PricingRuleApplied != anonymousPricingRule {
+				t.Errorf("charged %.2f under %q, want nothing under %q", v.AmountCharged, v.PricingRuleApplied, anonymousPricingRule)
+			}
+		})
+	}
+}
+
+func TestPublicUserGetsPreviewOfPreviewEnabledBill(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Pricing.LoyaltyFreeEveryN = 0
+	svc := env.verificationService()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 100)
+	public := testutil.CreateUser(t, env.db, models.RolePublic, 100)
+	previewable := testutil.CreateBill(t, env.db, issuer, 100000, nil)
+	restricted := testutil.CreateBill(t, env.db, issuer, 100000, nil)
+	env.db.MustExec("UPDATE bills SET access_level = 'financial', allow_preview = (id = $1) WHERE id IN ($1, $2)", previewable.ID, restricted.ID)
+
+	minFee, _, _ := NewPricingEngine(env.cfg.Pricing).CalculatePreview(previewable.BillType, false)
+
+	result := verifyAs(t, svc, public, previewable.BillNumber)
+	if result.Status != "valid" || result.IssuerName != issuer.OrganizationName || result.BillType != string(previewable.BillType) {
+		t.Errorf("preview = status %q, issuer %q, type %q, want the bill confirmed with its issuer and type", result.Status, result.IssuerName, result.BillType)
+	}
+	if result.Fee != minFee {
+		t.Errorf("preview fee = %.2f, want the minimum %.2f", result.Fee, minFee)
+	}
+	if result.IssueDate != "" || len(result.Details) != 1 || result.Details["blockchain_status"] == nil {
+		t.Errorf("preview reveals issue date %q and details %v, want only the blockchain status", result.IssueDate, result.Details)
+	}
+	if balance := env.balanceOf(t, public.ID); balance != 100-minFee {
+		t.Errorf("balance = %.2f, want %.2f", balance, 100-minFee)
+	}
+
+	// Without allow_preview the same user still gets the flat restriction
+	if result := verifyAs(t, svc, public, restricted.BillNumber); result.Status != "restricted" {
+		t.Errorf("status without preview = %q, want restricted", result.Status)
+	}
+}
+
+func TestVerificationTrendsBucketsAndZeroFills(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.verificationService()
+	loc := env.cfg.Location()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 0)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+	// verifiedDaysAgo records a verification at noon, local time, days ago
+	today := utils.StartOfDay(time.Now(), loc)
+	verifiedDaysAgo := func(days int, status models.VerificationStatus, fee float64) {
+		v := testutil.CreateVerification(t, env.db, bill, verifier)
+		env.db.MustExec("UPDATE verifications SET verified_at = $2, verification_status = $3, amount_charged = $4 WHERE id = $1",
+			v.ID, today.AddDate(0, 0, -days).Add(12*time.Hour), status, fee)
+	}
+	verifiedDaysAgo(0, models.VerificationValid, 2)
+	verifiedDaysAgo(0, models.VerificationNotFound, 1)
+	verifiedDaysAgo(2, models.VerificationValid, 3)
+	verifiedDaysAgo(10, models.VerificationValid, 5) // Outside the window
+
+	t.Run("daily", func(t *testing.T) {
+		buckets, err := svc.GetVerificationTrends(context.Background(), verifier.ID, models.TrendIntervalDay, 7)
+		if err != nil {
+			t.Fatalf("GetVerificationTrends failed: %v", err)
+		}
+		if len(buckets) != 7 {
+			t.Fatalf("got %d buckets, want 7", len(buckets))
+		}
+
+		for i, bucket := range buckets {
+			daysAgo := len(buckets) - 1 - i
+			if want := today.AddDate(0, 0, -daysAgo).Format("2006-01-02"); bucket.Start != want {
+				t.Errorf("bucket %d starts %s, want %s", i, bucket.Start, want)
+			}
+			if len(bucket.ByStatus) != len(trendStatuses) {
+				t.Errorf("bucket %s reports %d statuses, want all %d", bucket.Start, len(bucket.ByStatus), len(trendStatuses))
+			}
+
+			wantTotal, wantValid, wantSpent := 0, 0, 0.0
+			switch daysAgo {
+			case 0:
+				wantTotal, wantValid, wantSpent = 2, 1, 3
+			case 2:
+				wantTotal, wantValid, wantSpent = 1, 1, 3
+			}
+			if bucket.Total != wantTotal || bucket.ByStatus[string(models.VerificationValid)] != wantValid || bucket.Spent != wantSpent {
+				t.Errorf("bucket %s = %d total, %d valid, %.2f spent, want %d, %d, %.2f",
+					bucket.Start, bucket.Total, bucket.ByStatus[string(models.VerificationValid)], bucket.Spent, wantTotal, wantValid, wantSpent)
+			}
+		}
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		buckets, err := svc.GetVerificationTrends(context.Background(), verifier.ID, models.TrendIntervalWeek, 28)
+		if err != nil {
+			t.Fatalf("GetVerificationTrends failed: %v", err)
+		}
+
+		total := 0
+		for i, bucket := range buckets {
+			start, err := time.ParseInLocation("2006-01-02", bucket.Start, loc)
+			if err != nil || start.Weekday() != time.Monday {
+				t.Errorf("bucket %d starts %s, want a Monday", i, bucket.Start)
+			}
+			if i > 0 && buckets[i-1].Start != start.AddDate(0, 0, -7).Format("2006-01-02") {
+				t.Errorf("bucket %d starts %s, not a week after %s", i, bucket.Start, buckets[i-1].Start)
+			}
+			total += bucket.Total
+		}
+		if total != 4 {
+			t.Errorf("weekly buckets count %d verifications, want all 4", total)
+		}
+	})
+}
+
+func TestAnonymousDailyAllowance(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.App.AnonymousDailyFreeVerifications = 2
+	env.cfg.App.VerificationQuotaTimezone = "Asia/Kolkata"
+	counters := repository.NewActivityCounterRepository(testutil.Redis(t))
+	svc := NewVerificationService(env.db, env.verifications, env.bills, env.aliases, env.users, env.walletTxs, env.outbox, counters,
+		NewSuspiciousActivityDetector(counters, env.cfg), NewFakeBillAlerter(env.db, counters, env.outbox, env.cfg), nil, env.cfg)
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	verifier := testutil.CreateUser(t, env.db, models.RoleVerifier, 100)
+	bill := testutil.CreateBill(t, env.db, issuer, 100, nil)
+
+	verifyAnonymously := func(ip string) error {
+		_, err := svc.VerifyBill(context.Background(), nil, bill.BillNumber, ip, "go-test", models.RolePublic, false)
+		return err
+	}
+
+	for i := 1; i <= 2; i++ {
+		if err := verifyAnonymously("192.0.2.1"); err != nil {
+			t.Fatalf("free verification %d failed: %v", i, err)
+		}
+	}
+
+	err := verifyAnonymously("192.0.2.1")
+	var quotaErr *AnonymousQuotaExceededError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, ErrAnonymousQuotaExceeded) {
+		t.Fatalf("verification over the allowance error = %v, want %v", err, ErrAnonymousQuotaExceeded)
+	}
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+	resetsAt := quotaErr.ResetsAt.In(loc)
+	if !resetsAt.Equal(utils.StartOfDay(resetsAt, loc)) || time.Until(resetsAt) <= 0 || time.Until(resetsAt) > 24*time.Hour {
+		t.Errorf("allowance resets at %v, want the coming midnight in Asia/Kolkata", resetsAt)
+	}
+
+	// Other addresses have their own allowance
+	if err := verifyAnonymously("192.0.2.2"); err != nil {
+		t.Errorf("verification from another IP failed: %v", err)
+	}
+
+	// Signed-in callers from the same address aren't counted against it
+	for i := 1; i <= 3; i++ {
+		verifyAs(t, svc, verifier, bill.BillNumber)
+	}
+}
+
+func TestExplainLimitedAccessForPublicUser(t *testing.T) {
+	env := newTestEnv(t)
+	svc := env.verificationService()
+	ctx := context.Background()
+
+	issuer := testutil.CreateUser(t, env.db, models.RoleInstitutionUser, 0)
+	public := testutil.CreateUser(t, env.db, models.RolePublic, 1000)
+
+	governmentBill := func(allowPreview bool) *models.Bill {
+		bill := testutil.CreateBill(t, env.db, issuer, 5000, nil)
+		env.db.MustExec("UPDATE bills SET access_level = 'government', allow_preview = $2 WHERE id = $1", bill.ID, allowPreview)
+		bill.AccessLevel = models.AccessLevelGovernment
+		bill.AllowPreview = allowPreview
+		return bill
+	}
+	fullFee, _, _ := svc.price(governmentBill(false), "full", false)
+	previewFee, _, _ := svc.price(governmentBill(true), "preview", false)
+
+	tests := []struct {
+		name           string
+		allowPreview   bool
+		wantAccess     string
+		wantDifference float64
+	}{
+		{"no preview", false, "none", 0},
+		{"preview allowed", true, "preview", fullFee - previewFee},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bill := governmentBill(tt.allowPreview)
+
+			result, err := svc.VerifyBill(ctx, &public.ID, bill.BillNumber, "192.0.2.1", "go-test", public.Role, true)
+			if err != nil {
+				t.Fatalf("VerifyBill failed: %v", err)
+			}
+			explanation := result.Explanation
+			if explanation == nil {
+				t.Fatal("no explanation in the response")
+			}
+
+			if explanation.AccessLevel != tt.wantAccess || explanation.BillAccessLevel != string(models.AccessLevelGovernment) {
+				t.Errorf("access = %s on a %s bill, want %s on a government bill", explanation.AccessLevel, explanation.BillAccessLevel, tt.wantAccess)
+			}
+			if explanation.CurrentRole != string(models.RolePublic) {
+				t.Errorf("current role = %q, want %q", explanation.CurrentRole, models.RolePublic)
+			}
+			wantRoles := []string{string(models.RoleVerifier), string(models.RoleMasterAdmin)}
+			if !reflect.DeepEqual(explanation.FullAccessRoles, wantRoles) {
+				t.Errorf("full access roles = %v, want %v", explanation.FullAccessRoles, wantRoles)
+			}
+			if explanation.KYCRequired {
+				t.Error("KYC reported as required, but no institution role gets full access")
+			}
+			if explanation.FullAccessFee != fullFee || explanation.FeeDifference != tt.wantDifference {
+				t.Errorf("full access fee %.2f (difference %.2f), want %.2f (difference %.2f)",
+					explanation.FullAccessFee, explanation.FeeDifference, fullFee, tt.wantDifference)
+			}
+			if explanation.Reason == "" {
+				t.Error("no reason given")
+			}
+
+			// The explanation describes the rules, not the data
+			if len(result.Details) != 0 {
+				t.Errorf("details = %v, want none for a public user", result.Details)
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal(bill.BillData, &data); err != nil {
+				t.Fatalf("bill data is not JSON: %v", err)
+			}
+			for field := range data {
+				if strings.Contains(explanation.Reason, field) {
+					t.Errorf("reason %q mentions bill field %s", explanation.Reason, field)
+				}
+			}
+		})
+	}
+
+	t.Run("not requested", func(t *testing.T) {
+		if result := verifyAs(t, svc, public, governmentBill(false).BillNumber); result.Explanation != nil {
+			t.Errorf("explanation = %+v, want none without explain", result.Explanation)
+		}
+	})
+
+	t.Run("anonymous caller", func(t *testing.T) {
+		result, err := svc.VerifyBill(ctx, nil, governmentBill(false).BillNumber, "192.0.2.1", "go-test", models.RolePublic, true)
+		if err != nil {
+			t.Fatalf("VerifyBill failed: %v", err)
+		}
+		if result.Explanation == nil || result.Explanation.CurrentRole != "anonymous" {
+			t.Errorf("explanation = %+v, want the anonymous role", result.Explanation)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ezhilnn/epr-backend/internal/database"
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+// WalletService handles wallet credits and the wallet statement
+type WalletService struct {
+	db           *database.DB
+	userRepo     *repository.UserRepository
+	walletTxRepo *repository.WalletTransactionRepository
+}
+
+// NewWalletService creates a new wallet service
+func NewWalletService(
+	db *database.DB,
+	userRepo *repository.UserRepository,
+	walletTxRepo *repository.WalletTransactionRepository,
+) *WalletService {
+	return &WalletService{
+		db:           db,
+		userRepo:     userRepo,
+		walletTxRepo: walletTxRepo,
+	}
+}
+
+// Topup credits the user's wallet and records a ledger entry in the same transaction
+func (s *WalletService) Topup(ctx context.Context, userID string, amount float64) (float64, error) {
+	var newBalance float64
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		newBalance, err = s.CreditTx(ctx, tx, userID, amount, nil, nil)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return newBalance, nil
+}
+
+// CreditTx adds a top-up to the wallet and its ledger entry inside an existing transaction
+// Returns the new balance
+func (s *WalletService) CreditTx(ctx context.Context, tx *sqlx.Tx, userID string, amount float64, referenceID, description *string) (float64, error) {
+	newBalance, err := s.userRepo.AdjustWalletBalanceTx(ctx, tx, userID, amount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update wallet: %w", err)
+	}
+
+	ledgerEntry := &models.WalletTransaction{
+		UserID:       userID,
+		Type:         models.WalletTxTopup,
+		Amount:       amount,
+		BalanceAfter: newBalance,
+		ReferenceID:  referenceID,
+		Description:  description,
+	}
+	if err := s.walletTxRepo.CreateTx(ctx, tx, ledgerEntry); err != nil {
+		return 0, err
+	}
+
+	return newBalance, nil
+}
+
+// ListTransactions returns a page of the user's wallet statement and the total entry count
+func (s *WalletService) ListTransactions(ctx context.Context, userID string, page, pageSize int) ([]*models.WalletTransaction, int, error) {
+	offset := (page - 1) * pageSize
+
+	txns, err := s.walletTxRepo.ListByUser(ctx, userID, pageSize, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list wallet transactions: %w", err)
+	}
+
+	total, err := s.walletTxRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count wallet transactions: %w", err)
+	}
+
+	return txns, total, nil
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/utils"
+)
+
+// webhookTimeout bounds a single webhook request
+const webhookTimeout = 10 * time.Second
+
+// Headers sent with every webhook
+const (
+	WebhookSignatureHeader = "X-EPR-Signature" // "sha256=" + hex HMAC-SHA256 of the body
+	WebhookEventHeader     = "X-EPR-Event"
+	WebhookDeliveryHeader  = "X-EPR-Delivery"
+)
+
+// WebhookService delivers signed event callbacks to institutions' own systems
+// Webhooks are sent by the OutboxDispatcher, which also schedules their retries
+type WebhookService struct {
+	userRepo     *repository.UserRepository
+	deliveryRepo *repository.WebhookDeliveryRepository
+	client       *http.Client
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(userRepo *repository.UserRepository, deliveryRepo *repository.WebhookDeliveryRepository) *WebhookService {
+	return &WebhookService{
+		userRepo:     userRepo,
+		deliveryRepo: deliveryRepo,
+		client:       newWebhookClient(),
+	}
+}
+
+// SetWebhook sets the user's webhook URL and returns the signing secret
+// A secret is generated the first time a URL is set and kept on later changes; an empty URL disables webhooks
+// The URL must be https and resolve only to public addresses (ErrWebhookURLNotAllowed)
+func (s *WebhookService) SetWebhook(ctx context.Context, userID, url string) (string, error) {
+	if url == "" {
+		return "", s.userRepo.SetWebhook(ctx, userID, nil, nil)
+	}
+
+	if err := validateWebhookURL(ctx, url); err != nil {
+		return "", err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	secret := ""
+	if user.WebhookSecret != nil {
+		secret = *user.WebhookSecret
+	} else {
+		secret, err = utils.GenerateSecureToken(32)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	if err := s.userRepo.SetWebhook(ctx, userID, &url, &secret); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// ListDeliveries returns a page of the user's webhook deliveries and the total count
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID string, page, pageSize int) ([]*models.WebhookDelivery, int, error) {
+	offset := (page - 1) * pageSize
+
+	deliveries, err := s.deliveryRepo.ListByUser(ctx, userID, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.deliveryRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// SendBillVerified sends a bill.verified webhook to the bill's issuer, if they configured one
+// Called by the OutboxDispatcher; an error means the delivery should be tried again later
+func (s *WebhookService) SendBillVerified(ctx context.Context, attempt OutboxAttempt, issuerID string, payload models.BillVerifiedPayload) error {
+	payload.Event = models.WebhookEventBillVerified
+	return s.send(ctx, attempt, issuerID, payload.Event, payload)
+}
+
+// send makes one attempt at delivering an event to the issuer's webhook
+// Every attempt at the same outbox message updates one delivery record, so failures can be inspected later.
+// Issuers without a webhook, or whose account is gone, are skipped without error
+func (s *WebhookService) send(ctx context.Context, attempt OutboxAttempt, issuerID, event string, payload interface{}) error {
+	issuer, err := s.userRepo.GetByID(ctx, issuerID)
+	if errors.Is(err, ErrUserNotFound) {
+		// Deactivated issuers no longer receive webhooks
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get issuer: %w", err)
+	}
+	if issuer.WebhookURL == nil || issuer.WebhookSecret == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delivery := &models.WebhookDelivery{
+		OutboxID: &attempt.MessageID,
+		UserID:   issuerID,
+		Event:    event,
+		URL:      *issuer.WebhookURL,
+		Payload:  body,
+		Status:   models.WebhookDeliveryPending,
+	}
+	if err := s.deliveryRepo.CreateForOutbox(ctx, delivery); err != nil {
+		return err
+	}
+
+	statusCode, err := s.post(ctx, delivery, *issuer.WebhookSecret)
+
+	delivery.Attempts++
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+	if err != nil {
+		msg := err.Error()
+		delivery.LastError = &msg
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryDelivered
+	case attempt.Final:
+		delivery.Status = models.WebhookDeliveryFailed
+	}
+
+	if recordErr := s.deliveryRepo.RecordAttempt(ctx, delivery); recordErr != nil {
+		log.Printf("⚠️  Failed to record webhook attempt %s: %v", delivery.ID, recordErr)
+	}
+
+	return err
+}
+
+// post sends one attempt and returns the response status code
+// Any non-2xx response is an error
+func (s *WebhookService) post(ctx context.Context, delivery *models.WebhookDelivery, secret string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	// URLs saved before https was required are refused rather than sent in the clear
+	if req.URL.Scheme != "https" {
+		return 0, ErrWebhookURLNotAllowed
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Payload, secret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the signature header value for a payload
+// Receivers recompute it over the raw request body with their secret and compare in constant time
+func SignWebhookPayload(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ezhilnn/epr-backend/internal/models"
+	"github.com/ezhilnn/epr-backend/internal/repository"
+	"github.com/ezhilnn/epr-backend/internal/testutil"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	// A well-known HMAC-SHA256 test vector
+	got := SignWebhookPayload([]byte("The quick brown fox jumps over the lazy dog"), "key")
+	want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got != want {
+		t.Errorf("SignWebhookPayload = %s, want %s", got, want)
+	}
+}
+
+// webhookRequest is a webhook received by a webhookReceiver
+type webhookRequest struct {
+	Body      []byte
+	Signature string
+	Delivery  string
+	Event     string
+}
+
+// webhookReceiver is an https endpoint answering each webhook with the next status in statuses,
+// then 200 once they run out
+type webhookReceiver struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	received []webhookRequest
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	t.Helper()
+
+	r := &webhookReceiver{statuses: statuses}
+	r.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		r.received = append(r.received, webhookRequest{
+			Body:      body,
+			Signature: req.Header.Get(WebhookSignatureHeader),
+			Delivery:  req.Header.Get(WebhookDeliveryHeader),
+			Event:     req.Header.Get(WebhookEventHeader),
+		})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *webhookReceiver) Received() []webhookRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]webhookRequest(nil), r.received...)
+}
+
+// webhookDispatcher returns an outbox dispatcher that delivers webhooks to receiver, retrying failures
+// up to maxAttempts times almost immediately
+// The webhook client is swapped for one trusting the test server, which also lets it reach loopback
+func (e *testEnv) webhookDispatcher(receiver *webhookReceiver, maxAttempts int) (*OutboxDispatcher, *repository.WebhookDeliveryRepository) {
+	deliveries := repository.NewWebhookDeliveryRepository(e.db.DB)
+	webhooks := NewWebhookService(e.users, deliveries)
+	webhooks.client = receiver.server.Client()
+
+	cfg := *e.cfg
+	cfg.Outbox.PollInterval = time.Millisecond
+	cfg.Outbox.MaxAttempts = maxAttempts
+	return NewOutboxDispatcher(e.outbox, e.emailService(), webhooks, &cfg), deliveries
+}
+
+// queueBillVerified queues a bill.verified webhook for issuer and returns the outbox message ID
+func (e *testEnv) queueBillVerified(t *testing.T, issuer *models.User) string {
+	t.Helper()
+
+	msg, err := models.NewOutboxMessage(models.OutboxBillVerifiedWebhook, models.BillVerifiedWebhookMessage{
+		IssuerID: issuer.ID,
+		Payload: models.BillVerifiedPayload{
+			BillNumber:   "OTH202401000001",
+			VerifierType: string(models.RoleVerifier),
+			Result:       "valid",
+			VerifiedAt:   time.Now().UTC(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode webhook message: %v", err)
+	}
+	if err := e.outbox.Create(context.Background(), msg); err != nil {
+		t.Fatalf("failed to queue webhook: %v", err)
+	}
+	return msg.ID
+}
+
+// runDispatcher processes due messages until none are left or the retries run out
+func runDispatcher(t *testing.T, d *OutboxDispatcher, rounds int) {
+	t.Helper()
+	for i := 0; i < rounds; i++ {
+		if err := d.processBatch(context.Background()); err != nil {
+			t.Fatalf("processBatch failed: %v", err)
+		}
+		// Let the millisecond backoff pass
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// issuerWithWebhook creates an institution whose webhook points at receiver, signed with secret
+func (e *testEnv) issuerWithWebhook(t *testing.T, receiver *webhookReceiver, secret string) *models.User {
+	t.Helper()
+
+	issuer := testutil.CreateUser(t, e.db, models.RoleInstitutionUser, 0)
+	// Set directly: SetWebhook refuses the loopback test server
+	url := receiver.server.URL
+	if err := e.users.SetWebhook(context.Background(), issuer.ID, &url, &secret); err != nil {
+		t.Fatalf("failed to set webhook: %v", err)
+	}
+	return issuer
+}
+
+func TestWebhookIsSignedAndRetried(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	dispatcher, deliveries := env.webhookDispatcher(receiver, 5)
+
+	const secret = "webhook-test-secret"
+	issuer := env.issuerWithWebhook(t, receiver, secret)
+	messageID := env.queueBillVerified(t, issuer)
+
+	runDispatcher(t, dispatcher, 4)
+
+	received := receiver.Received()
+	if len(received) != 3 {
+		t.Fatalf("webhook requests = %d, want 3 (two failures, then success)", len(received))
+	}
+	for i, req := range received {
+		// Recompute the signature the way a receiver would
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(req.Body)
+		want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(req.Signature), []byte(want)) {
+			t.Errorf("request %d signature = %q, want %q", i+1, req.Signature, want)
+		}
+		if req.Event != models.WebhookEventBillVerified {
+			t.Errorf("request %d event = %q, want %q", i+1, req.Event, models.WebhookEventBillVerified)
+		}
+		// Retries are the same delivery
+		if req.Delivery == "" || req.Delivery != received[0].Delivery {
+			t.Errorf("request %d delivery ID = %q, want %q", i+1, req.Delivery, received[0].Delivery)
+		}
+	}
+
+	list, err := deliveries.ListByUser(context.Background(), issuer.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("delivery records = %d, want 1", len(list))
+	}
+	if d := list[0]; d.Status != models.WebhookDeliveryDelivered || d.Attempts != 3 || d.LastStatusCode == nil || *d.LastStatusCode != http.StatusOK {
+		t.Errorf("delivery = %s after %d attempts (last status %v), want delivered after 3 with 200", d.Status, d.Attempts, d.LastStatusCode)
+	}
+	if n := testutil.Count(t, env.db, "outbox", "id = $1 AND status = 'sent'", messageID); n != 1 {
+		t.Error("outbox message is not marked sent")
+	}
+}
+
+func TestWebhookFailsAfterMaxAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	dispatcher, deliveries := env.webhookDispatcher(receiver, 2)
+
+	issuer := env.issuerWithWebhook(t, receiver, "webhook-test-secret")
+	messageID := env.queueBillVerified(t, issuer)
+
+	runDispatcher(t, dispatcher, 4)
+
+	if n := len(receiver.Received()); n != 2 {
+		t.Errorf("webhook requests = %d, want 2", n)
+	}
+
+	list, err := deliveries.ListByUser(context.Background(), issuer.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(list) != 1 || list[0].Status != models.WebhookDeliveryFailed || list[0].Attempts != 2 {
+		t.Fatalf("deliveries = %+v, want one failed after 2 attempts", list)
+	}
+	if list[0].LastError == nil || list[0].LastStatusCode == nil || *list[0].LastStatusCode != http.StatusInternalServerError {
+		t.Errorf("failed delivery doesn't record the last status and error")
+	}
+	if n := testutil.Count(t, env.db, "outbox", "id = $1 AND status = 'failed'", messageID); n != 1 {
+		t.Error("outbox message is not marked failed")
+	}
+}
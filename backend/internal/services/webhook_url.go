@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+)
+
+// Webhook URLs are chosen by institutions, so the server must not be usable to reach its own network:
+// URLs are checked when they're set, and again at dial time in case the name now resolves elsewhere
+
+// blockedWebhookPrefixes are ranges outside the private/loopback/link-local checks that still aren't the public internet
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT, including Alibaba's metadata service
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // Reserved
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which can embed any IPv4 address
+}
+
+// isPublicWebhookIP reports whether a webhook may be delivered to ip
+// Cloud metadata endpoints (169.254.169.254, fd00:ec2::254) fall under link-local and private
+func isPublicWebhookIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvePublicWebhookHost resolves host and fails unless every address it has is public
+// Checking all of them stops a name that mixes a public and an internal address from slipping through
+func resolvePublicWebhookHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve webhook host %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("webhook host %s has no addresses", host)
+	}
+	for _, addr := range addrs {
+		if !isPublicWebhookIP(addr) {
+			return nil, fmt.Errorf("webhook host %s resolves to non-public address %s: %w", host, addr, ErrWebhookURLNotAllowed)
+		}
+	}
+	return addrs, nil
+}
+
+// validateWebhookURL checks that raw is an https URL whose host resolves only to public addresses
+func validateWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return ErrWebhookURLNotAllowed
+	}
+	if _, err := resolvePublicWebhookHost(ctx, u.Hostname()); err != nil {
+		return ErrWebhookURLNotAllowed
+	}
+	return nil
+}
+
+// newWebhookClient returns the HTTP client webhooks are delivered with
+// It never uses a proxy, dials only public addresses and doesn't follow redirects,
+// so a 3xx is reported as a failed delivery instead of being chased to an internal host
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout, KeepAlive: 30 * time.Second}
+
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			addrs, err := resolvePublicWebhookHost(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+
+			// Dial the checked address itself so a second lookup can't return something else
+			var lastErr error
+			for _, addr := range addrs {
+				conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+			return nil, lastErr
+		},
+		TLSHandshakeTimeout:   webhookTimeout,
+		ResponseHeaderTimeout: webhookTimeout,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WorkerManager runs background workers with a shared context so shutdown can stop them and wait for them to finish
+// Workers must return promptly once their context is cancelled
+type WorkerManager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// NewWorkerManager creates a new worker manager
+func NewWorkerManager() *WorkerManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WorkerManager{
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[string]int),
+	}
+}
+
+// Go starts run in its own goroutine with the manager's context
+// name only identifies the worker in shutdown logs
+func (m *WorkerManager) Go(name string, run func(ctx context.Context)) {
+	m.mu.Lock()
+	m.running[name]++
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer func() {
+			m.mu.Lock()
+			m.running[name]--
+			if m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+			m.wg.Done()
+		}()
+		run(m.ctx)
+	}()
+}
+
+// Stop cancels the context every worker was started with
+func (m *WorkerManager) Stop() {
+	m.cancel()
+}
+
+// Wait blocks until every worker has returned or timeout passes
+// Returns an error naming the workers still running on timeout
+func (m *WorkerManager) Wait(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		names := make([]string, 0, len(m.running))
+		for name := range m.running {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("timed out after %s waiting for workers: %v", timeout, names)
+	}
+}
+
+// Shutdown stops every worker and waits up to timeout for them to return
+func (m *WorkerManager) Shutdown(timeout time.Duration) {
+	m.Stop()
+	if err := m.Wait(timeout); err != nil {
+		log.Printf("⚠️  Background workers did not stop cleanly: %v", err)
+		return
+	}
+	log.Println("✅ Background workers stopped")
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkerManagerCancelsAndWaitsForWorkers(t *testing.T) {
+	m := NewWorkerManager()
+
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	m.Go("test", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		// In-flight work finishes after cancellation
+		time.Sleep(10 * time.Millisecond)
+		close(finished)
+	})
+	<-started
+
+	if err := m.Wait(10 * time.Millisecond); err == nil || !strings.Contains(err.Error(), "test") {
+		t.Fatalf("Wait before Stop = %v, want a timeout naming the worker", err)
+	}
+
+	m.Stop()
+	if err := m.Wait(time.Second); err != nil {
+		t.Fatalf("Wait after Stop failed: %v", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Error("Wait returned before the worker finished")
+	}
+}
+
+func TestWorkerManagerWaitNamesStuckWorkers(t *testing.T) {
+	m := NewWorkerManager()
+
+	release := make(chan struct{})
+	defer close(release)
+	m.Go("stuck", func(ctx context.Context) { <-release })
+	m.Go("prompt", func(ctx context.Context) { <-ctx.Done() })
+
+	m.Stop()
+	err := m.Wait(50 * time.Millisecond)
+	if err == nil {
+		t.Fatal("Wait returned although a worker ignores cancellation")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "stuck") || strings.Contains(msg, "prompt") {
+		t.Errorf("error = %q, want only the stuck worker named", msg)
+	}
+}
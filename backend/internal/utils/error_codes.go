@@ -0,0 +1,129 @@
+package utils
+
+import "net/http"
+
+// ErrorCode is a stable, machine-readable identifier sent as "code" in every error response
+// Clients should switch on the code; the "error" message is for display and may change
+type ErrorCode string
+
+// Generic codes, used when nothing more specific applies
+const (
+	CodeBadRequest         ErrorCode = "BAD_REQUEST"
+	CodeUnauthorized       ErrorCode = "UNAUTHORIZED"
+	CodePaymentRequired    ErrorCode = "PAYMENT_REQUIRED"
+	CodeForbidden          ErrorCode = "FORBIDDEN"
+	CodeNotFound           ErrorCode = "NOT_FOUND"
+	CodeConflict           ErrorCode = "CONFLICT"
+	CodeGone               ErrorCode = "GONE"
+	CodePayloadTooLarge    ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodeRateLimited        ErrorCode = "RATE_LIMITED"
+	CodeInternalError      ErrorCode = "INTERNAL_ERROR"
+	CodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeValidationFailed   ErrorCode = "VALIDATION_FAILED" // "details" maps each field to its problem
+	CodeMalformedJSON      ErrorCode = "MALFORMED_JSON"
+	CodePageOutOfRange     ErrorCode = "PAGE_OUT_OF_RANGE"
+)
+
+// Authentication and accounts
+const (
+	CodeAuthRequired            ErrorCode = "AUTH_REQUIRED"
+	CodeInvalidToken            ErrorCode = "INVALID_TOKEN"
+	CodeInsufficientPermissions ErrorCode = "INSUFFICIENT_PERMISSIONS"
+	CodeInvalidCredentials      ErrorCode = "INVALID_CREDENTIALS"
+	CodeAccountDeactivated      ErrorCode = "ACCOUNT_DEACTIVATED"
+	CodeEmailAlreadyRegistered  ErrorCode = "EMAIL_ALREADY_REGISTERED"
+	CodeGSTINAlreadyRegistered  ErrorCode = "GSTIN_ALREADY_REGISTERED"
+	CodeRoleRequiresInvite      ErrorCode = "ROLE_REQUIRES_INVITE"
+	CodeInviteInvalid           ErrorCode = "INVITE_INVALID"
+	CodeInviteExpired           ErrorCode = "INVITE_EXPIRED"
+	CodeInvalidLink             ErrorCode = "INVALID_LINK" // Email verification or password reset link
+	CodeLinkExpired             ErrorCode = "LINK_EXPIRED"
+	CodeUserNotFound            ErrorCode = "USER_NOT_FOUND"
+	CodeCannotDeactivateSelf    ErrorCode = "CANNOT_DEACTIVATE_SELF"
+	CodeLastMasterAdmin         ErrorCode = "LAST_MASTER_ADMIN"
+	CodeReasonRequired          ErrorCode = "REASON_REQUIRED"
+	CodeTwoFactorRequired       ErrorCode = "TWO_FACTOR_REQUIRED" // Log in again with totp_code or recovery_code
+	CodeInvalidTwoFactorCode    ErrorCode = "INVALID_TWO_FACTOR_CODE"
+	CodeTwoFactorNotAvailable   ErrorCode = "TWO_FACTOR_NOT_AVAILABLE"
+	CodeTwoFactorAlreadyEnabled ErrorCode = "TWO_FACTOR_ALREADY_ENABLED"
+	CodeTwoFactorNotStarted     ErrorCode = "TWO_FACTOR_NOT_STARTED"
+	CodeTwoFactorNotEnabled     ErrorCode = "TWO_FACTOR_NOT_ENABLED"
+)
+
+// KYC
+const (
+	CodeKYCRequired        ErrorCode = "KYC_REQUIRED"
+	CodeKYCNotApplicable   ErrorCode = "KYC_NOT_APPLICABLE"
+	CodeKYCAlreadyApproved ErrorCode = "KYC_ALREADY_APPROVED"
+	CodeKYCNotPending      ErrorCode = "KYC_NOT_PENDING"
+)
+
+// Bills
+const (
+	CodeBillNotFound          ErrorCode = "BILL_NOT_FOUND"
+	CodeBillAccessDenied      ErrorCode = "BILL_ACCESS_DENIED"
+	CodeNotBillOwner          ErrorCode = "NOT_BILL_OWNER"
+	CodeNotInstitution        ErrorCode = "NOT_INSTITUTION"
+	CodeEmailNotVerified      ErrorCode = "EMAIL_NOT_VERIFIED"
+	CodeInvalidBillData       ErrorCode = "INVALID_BILL_DATA"
+	CodeUnsupportedCurrency   ErrorCode = "UNSUPPORTED_CURRENCY"
+	CodeBillAmountTooLarge    ErrorCode = "BILL_AMOUNT_TOO_LARGE"
+	CodePreviewNotApplicable  ErrorCode = "PREVIEW_NOT_APPLICABLE"
+	CodeBillHasVerifications  ErrorCode = "BILL_HAS_VERIFICATIONS"
+	CodeBillNotDeleted        ErrorCode = "BILL_NOT_DELETED"
+	CodeBillVerifiedNoDelete  ErrorCode = "BILL_VERIFIED_NO_DELETE"
+	CodeIdempotencyKeyInUse   ErrorCode = "IDEMPOTENCY_KEY_IN_USE"
+	CodeIdempotencyMismatch   ErrorCode = "IDEMPOTENCY_KEY_MISMATCH"
+	CodeDuplicateBill         ErrorCode = "DUPLICATE_BILL"
+	CodeBulkBatchTooLarge     ErrorCode = "BULK_BATCH_TOO_LARGE"
+	CodeExportTooLarge        ErrorCode = "EXPORT_TOO_LARGE"
+	CodeInvalidTransferTarget ErrorCode = "INVALID_TRANSFER_TARGET"
+	CodeTransferInvalid       ErrorCode = "TRANSFER_INVALID"
+	CodeTransferExpired       ErrorCode = "TRANSFER_EXPIRED"
+	CodeNotTransferRecipient  ErrorCode = "NOT_TRANSFER_RECIPIENT"
+)
+
+// Verifications and payments
+const (
+	CodeInsufficientBalance      ErrorCode = "INSUFFICIENT_BALANCE"
+	CodeAnonymousQuotaExceeded   ErrorCode = "ANONYMOUS_QUOTA_EXCEEDED"
+	CodeVerificationNotFound     ErrorCode = "VERIFICATION_NOT_FOUND"
+	CodeVerificationAccessDenied ErrorCode = "VERIFICATION_ACCESS_DENIED"
+	CodeCertificateUnavailable   ErrorCode = "CERTIFICATE_UNAVAILABLE"
+	CodeUnreadableQR             ErrorCode = "UNREADABLE_QR"
+	CodeInvalidQRLink            ErrorCode = "INVALID_QR_LINK"
+	CodePaymentOrderNotFound     ErrorCode = "PAYMENT_ORDER_NOT_FOUND"
+	CodeInvalidPaymentSignature  ErrorCode = "INVALID_PAYMENT_SIGNATURE"
+	CodePaymentAlreadyProcessed  ErrorCode = "PAYMENT_ALREADY_PROCESSED"
+)
+
+// CodeForStatus is the generic code for an HTTP status, used by ErrorResponse
+func CodeForStatus(statusCode int) ErrorCode {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusPaymentRequired:
+		return CodePaymentRequired
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	}
+
+	if statusCode >= 500 {
+		return CodeInternalError
+	}
+	return CodeBadRequest
+}
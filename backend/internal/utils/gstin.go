@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gstinCharset is the base-36 alphabet the GSTIN checksum is computed over
+const gstinCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// ValidateGSTIN checks an Indian GST identification number, e.g. 27AAPFU0939F1ZV
+// A GSTIN is a 2-digit state code, the holder's PAN, an entity number, the letter Z
+// and a check character. Letters must be upper case; the error says which part is wrong
+func ValidateGSTIN(gstin string) error {
+	if len(gstin) != 15 {
+		return errors.New("must be exactly 15 characters")
+	}
+	for _, ch := range gstin {
+		if !strings.ContainsRune(gstinCharset, ch) {
+			return errors.New("must contain only digits and upper case letters")
+		}
+	}
+
+	// 01-38 are states and union territories, 97 is other territory, 99 is the centre
+	state, err := strconv.Atoi(gstin[:2])
+	if err != nil || !(state >= 1 && state <= 38 || state == 97 || state == 99) {
+		return fmt.Errorf("has an unknown state code %q", gstin[:2])
+	}
+
+	if ValidatePAN(gstin[2:12]) != nil {
+		return errors.New("must contain a valid PAN in characters 3-12")
+	}
+	if gstin[12] == '0' {
+		return errors.New("has an invalid entity number")
+	}
+	if gstin[13] != 'Z' {
+		return errors.New("must have Z as the 14th character")
+	}
+
+	if want := gstinCheckChar(gstin[:14]); gstin[14] != want {
+		return errors.New("has an invalid check character")
+	}
+
+	return nil
+}
+
+// gstinCheckChar computes the check character for the first 14 characters of a GSTIN
+// Characters are weighted 1 and 2 alternately, and each product's base-36 digits are summed
+func gstinCheckChar(body string) byte {
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		product := strings.IndexByte(gstinCharset, body[i]) * (i%2 + 1)
+		sum += product/36 + product%36
+	}
+	return gstinCharset[(36-sum%36)%36]
+}
@@ -0,0 +1,55 @@
+package utils
+
+import "testing"
+
+func TestValidateGSTIN(t *testing.T) {
+	tests := []struct {
+		name    string
+		gstin   string
+		wantErr string
+	}{
+		{"valid", "27AAPFU0939F1ZV", ""},
+		{"valid with another state", "07AAGFF2194N1Z1", ""},
+		{"valid company", "24AAACC1206D1ZM", ""},
+		{"valid letter check character", "38AAPFU0939F1ZS", ""},
+		{"valid other territory", "97AAPFU0939F1ZO", ""},
+		{"valid centre jurisdiction", "99AAPFU0939F1ZK", ""},
+		{"valid letter entity number", "27AAPFU0939FAZM", ""},
+
+		{"empty", "", "must be exactly 15 characters"},
+		{"too short", "27AAPFU0939F1Z", "must be exactly 15 characters"},
+		{"too long", "27AAPFU0939F1ZVX", "must be exactly 15 characters"},
+		{"lower case", "27aapfu0939f1zv", "must contain only digits and upper case letters"},
+		{"punctuation", "27AAPFU-939F1ZV", "must contain only digits and upper case letters"},
+		{"state code 00", "00AAPFU0939F1ZB", `has an unknown state code "00"`},
+		{"state code 39", "39AAPFU0939F1ZQ", `has an unknown state code "39"`},
+		{"letters for the state code", "ABAAPFU0939F1ZV", `has an unknown state code "AB"`},
+		{"digit in the PAN letters", "27AAPF10939F1ZO", "must contain a valid PAN in characters 3-12"},
+		{"letter in the PAN digits", "27AAPFUA939F1ZV", "must contain a valid PAN in characters 3-12"},
+		{"entity number 0", "27AAPFU0939F0ZW", "has an invalid entity number"},
+		{"14th character not Z", "27AAPFU0939F1YX", "must have Z as the 14th character"},
+
+		// Checksum failures: each is a valid GSTIN with one character changed
+		{"wrong check character", "27AAPFU0939F1ZA", "has an invalid check character"},
+		{"check digit instead of letter", "27AAPFU0939F1Z5", "has an invalid check character"},
+		{"state code changed", "29AAPFU0939F1ZV", "has an invalid check character"},
+		{"PAN digit changed", "27AAPFU0938F1ZV", "has an invalid check character"},
+		{"adjacent PAN digits swapped", "27AAPFU9039F1ZV", "has an invalid check character"},
+		{"entity number changed", "27AAPFU0939F2ZV", "has an invalid check character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGSTIN(tt.gstin)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateGSTIN(%q) = %v, want nil", tt.gstin, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidateGSTIN(%q) = %v, want %q", tt.gstin, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,26 +1,95 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// JWTKeySet holds the key new tokens are signed with plus older keys that still validate
+// During a secret rotation the old secret moves to Previous under its key ID, so tokens
+// issued before the rotation keep working until they expire
+type JWTKeySet struct {
+	Method   jwt.SigningMethod // HMAC method used to sign new tokens
+	KeyID    string            // kid header on new tokens; empty issues tokens without one
+	Secret   string            // Current signing secret
+	Previous map[string]string // kid -> secret of retired keys
+}
+
+// NewJWTKeySet builds a key set from configuration
+// method is HS256, HS384 or HS512; anything else falls back to HS256
+func NewJWTKeySet(method, keyID, secret string, previous map[string]string) JWTKeySet {
+	signingMethod := jwt.SigningMethod(jwt.SigningMethodHS256)
+	switch method {
+	case "HS384":
+		signingMethod = jwt.SigningMethodHS384
+	case "HS512":
+		signingMethod = jwt.SigningMethodHS512
+	}
+
+	return JWTKeySet{
+		Method:   signingMethod,
+		KeyID:    keyID,
+		Secret:   secret,
+		Previous: previous,
+	}
+}
+
+// sign signs claims with the current key and stamps its kid
+func (k JWTKeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.Method, claims)
+	if k.KeyID != "" {
+		token.Header["kid"] = k.KeyID
+	}
+	return token.SignedString([]byte(k.Secret))
+}
+
+// keyFunc picks the verification key for a token by its kid header
+// Tokens without a kid predate rotation support, so every known key is tried for them
+func (k JWTKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	// Verify signing method
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		keys := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{[]byte(k.Secret)}}
+		for _, secret := range k.Previous {
+			keys.Keys = append(keys.Keys, []byte(secret))
+		}
+		return keys, nil
+	}
+
+	if kid == k.KeyID {
+		return []byte(k.Secret), nil
+	}
+	if secret, ok := k.Previous[kid]; ok {
+		return []byte(secret), nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
 // JWTClaims represents the claims stored in JWT token
 type JWTClaims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID        string `json:"user_id"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"` // As of issue; tokens from before the claim existed read as false
 	jwt.RegisteredClaims
 }
 
 // GenerateAccessToken creates a short-lived access token
-func GenerateAccessToken(userID, email, role, secret string, expiresIn time.Duration) (string, error) {
+func GenerateAccessToken(userID, email, role string, emailVerified bool, keys JWTKeySet, expiresIn time.Duration) (string, error) {
 	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:        userID,
+		Email:         email,
+		Role:          role,
+		EmailVerified: emailVerified,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -28,31 +97,30 @@ func GenerateAccessToken(userID, email, role, secret string, expiresIn time.Dura
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return keys.sign(claims)
 }
 
 // GenerateRefreshToken creates a long-lived refresh token
-func GenerateRefreshToken(userID, secret string, expiresIn time.Duration) (string, error) {
+// Each token gets a unique ID (jti) so it can be revoked on logout
+func GenerateRefreshToken(userID string, keys JWTKeySet, expiresIn time.Duration) (string, error) {
+	jti, err := GenerateSecureToken(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
 	claims := jwt.RegisteredClaims{
+		ID:        jti,
 		Subject:   userID,
 		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
 		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return keys.sign(claims)
 }
 
 // ValidateToken validates a JWT token and returns the claims
-func ValidateToken(tokenString, secret string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+func ValidateToken(tokenString string, keys JWTKeySet) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, keys.keyFunc)
 
 	if err != nil {
 		return nil, err
@@ -66,21 +134,37 @@ func ValidateToken(tokenString, secret string) (*JWTClaims, error) {
 }
 
 // ValidateRefreshToken validates a refresh token and returns the user ID
-func ValidateRefreshToken(tokenString, secret string) (string, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
-
+func ValidateRefreshToken(tokenString string, keys JWTKeySet) (string, error) {
+	claims, err := ParseRefreshToken(tokenString, keys)
 	if err != nil {
 		return "", err
 	}
 
+	return claims.Subject, nil
+}
+
+// ParseRefreshToken validates a refresh token and returns its claims
+// Use this when the token ID or expiry is needed (e.g. for revocation)
+func ParseRefreshToken(tokenString string, keys JWTKeySet) (*jwt.RegisteredClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, keys.keyFunc)
+
+	if err != nil {
+		return nil, err
+	}
+
 	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
-		return claims.Subject, nil
+		return claims, nil
 	}
 
-	return "", fmt.Errorf("invalid refresh token")
+	return nil, fmt.Errorf("invalid refresh token")
+}
+
+// RefreshTokenID returns the identifier used to revoke a refresh token
+// Tokens issued before jti was added fall back to a hash of the token itself
+func RefreshTokenID(claims *jwt.RegisteredClaims, tokenString string) string {
+	if claims.ID != "" {
+		return claims.ID
+	}
+	hash := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(hash[:])
 }
\ No newline at end of file
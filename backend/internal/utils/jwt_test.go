@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTKeyRotation(t *testing.T) {
+	// Before the rotation tokens are signed with key "v1"; tokens from before kid support have no kid at all
+	legacy := NewJWTKeySet("HS256", "", "old-secret", nil)
+	before := NewJWTKeySet("HS256", "v1", "old-secret", nil)
+	// After it "v2" signs new tokens and "v1" only validates
+	after := NewJWTKeySet("HS256", "v2", "new-secret", map[string]string{"v1": "old-secret"})
+	// Once the rotation window is over "v1" is dropped
+	retired := NewJWTKeySet("HS256", "v2", "new-secret", nil)
+	// A key set that reuses the "v2" kid with some other secret
+	forger := NewJWTKeySet("HS256", "v2", "attacker-secret", nil)
+
+	tests := []struct {
+		name    string
+		signer  JWTKeySet
+		keys    JWTKeySet
+		wantErr bool
+	}{
+		{"old key still validates during rotation", before, after, false},
+		{"token without kid still validates during rotation", legacy, after, false},
+		{"new key validates", after, after, false},
+		{"old key rejected once retired", before, retired, true},
+		{"token without kid rejected once its key is retired", legacy, retired, true},
+		{"new token rejected by servers not yet rotated", after, before, true},
+		{"known kid with the wrong secret", forger, after, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access, err := GenerateAccessToken("user-1", "a@example.com", "verifier", true, tt.signer, time.Minute)
+			if err != nil {
+				t.Fatalf("GenerateAccessToken failed: %v", err)
+			}
+			claims, err := ValidateToken(access, tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateToken error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && claims.UserID != "user-1" {
+				t.Errorf("ValidateToken user = %q, want user-1", claims.UserID)
+			}
+
+			refresh, err := GenerateRefreshToken("user-1", tt.signer, time.Minute)
+			if err != nil {
+				t.Fatalf("GenerateRefreshToken failed: %v", err)
+			}
+			userID, err := ValidateRefreshToken(refresh, tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRefreshToken error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && userID != "user-1" {
+				t.Errorf("ValidateRefreshToken user = %q, want user-1", userID)
+			}
+		})
+	}
+}
+
+func TestJWTKeySetStampsKid(t *testing.T) {
+	keys := NewJWTKeySet("HS512", "v2", "new-secret", nil)
+
+	token, err := GenerateAccessToken("user-1", "a@example.com", "verifier", true, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken failed: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &JWTClaims{})
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if kid := parsed.Header["kid"]; kid != "v2" {
+		t.Errorf("kid = %v, want v2", kid)
+	}
+	if alg := parsed.Header["alg"]; alg != "HS512" {
+		t.Errorf("alg = %v, want HS512", alg)
+	}
+}
+
+func TestValidateTokenRejectsUnsignedTokens(t *testing.T) {
+	keys := NewJWTKeySet("HS256", "v1", "secret", nil)
+
+	claims := &JWTClaims{UserID: "user-1", RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("failed to build unsigned token: %v", err)
+	}
+	if _, err := ValidateToken(unsigned, keys); err == nil {
+		t.Error("ValidateToken accepted an unsigned token")
+	}
+}
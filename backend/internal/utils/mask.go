@@ -0,0 +1,30 @@
+package utils
+
+import "strings"
+
+// MaskString partially hides a value for display to users without full access
+// The first word is kept and later words keep only their first letter, e.g. "Rahul Sharma" -> "Rahul S*****"
+// A single word keeps only its first letter
+func MaskString(s string) string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return ""
+	}
+
+	if len(words) == 1 {
+		return maskWord(words[0])
+	}
+
+	masked := make([]string, len(words))
+	masked[0] = words[0]
+	for i, word := range words[1:] {
+		masked[i+1] = maskWord(word)
+	}
+	return strings.Join(masked, " ")
+}
+
+// maskWord keeps the first character of a word and replaces the rest with *
+func maskWord(word string) string {
+	runes := []rune(word)
+	return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+}
@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestMaskString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Rahul Sharma", "Rahul S*****"},
+		{"Rahul Kumar Sharma", "Rahul K**** S*****"},
+		{"Rahul", "R****"},
+		{"R", "R"},
+		{"  Rahul   Sharma ", "Rahul S*****"},
+		{"", ""},
+		{"   ", ""},
+		{"राहुल शर्मा", "राहुल श****"},
+	}
+
+	for _, tt := range tests {
+		if got := MaskString(tt.in); got != tt.want {
+			t.Errorf("MaskString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page size limits for list endpoints, set from config at startup
+var (
+	// DefaultPageSize is used when a request omits page_size or sends an invalid one
+	DefaultPageSize = 10
+
+	// MaxPageSize is the largest page_size list endpoints accept
+	// Larger values fall back to the endpoint's default page size
+	MaxPageSize = 100
+)
+
+// Pagination is the metadata returned alongside a page of results
+type Pagination struct {
+	Page        int  `json:"page"`
+	PageSize    int  `json:"page_size"`
+	MaxPageSize int  `json:"max_page_size"`
+	Total       int  `json:"total"`
+	TotalPages  int  `json:"total_pages"`
+	HasNext     bool `json:"has_next"`
+	HasPrev     bool `json:"has_prev"`
+}
+
+// ParsePagination reads the page and page_size query parameters, defaulting page_size to DefaultPageSize
+// Returns the 1-based page, the page size and the row offset of the page
+func ParsePagination(c *gin.Context) (page, pageSize, offset int) {
+	return ParsePaginationWithDefault(c, DefaultPageSize)
+}
+
+// ParsePaginationWithDefault is ParsePagination for endpoints with their own default page size
+// Pages below 1 become 1; missing, invalid or out of range page sizes become defaultPageSize
+func ParsePaginationWithDefault(c *gin.Context, defaultPageSize int) (page, pageSize, offset int) {
+	defaultPageSize = min(defaultPageSize, MaxPageSize)
+
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err = strconv.Atoi(c.Query("page_size"))
+	if err != nil || pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = defaultPageSize
+	}
+
+	return page, pageSize, (page - 1) * pageSize
+}
+
+// BuildPagination computes pagination metadata for a 1-based page of pageSize items out of total
+func BuildPagination(page, pageSize, total int) Pagination {
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = (total + pageSize - 1) / pageSize
+	}
+
+	return Pagination{
+		Page:        page,
+		PageSize:    pageSize,
+		MaxPageSize: MaxPageSize,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrev:     page > 1,
+	}
+}
+
+// OutOfRange reports whether the page lies beyond the last page
+// Page 1 is always in range so an empty list is a normal, empty response
+func (p Pagination) OutOfRange() bool {
+	return p.Page > 1 && p.Page > p.TotalPages
+}
+
+// PageOutOfRangeResponse rejects a request for a page past the last one
+// The pagination block is included so clients can jump to the last page
+func PageOutOfRangeResponse(c *gin.Context, p Pagination) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"success":    false,
+		"code":       CodePageOutOfRange,
+		"error":      fmt.Sprintf("Page %d is beyond the last page (%d)", p.Page, p.TotalPages),
+		"pagination": p,
+	})
+}
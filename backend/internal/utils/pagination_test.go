@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBuildPagination(t *testing.T) {
+	tests := []struct {
+		name                  string
+		page, pageSize, total int
+		wantPages             int
+		wantNext, wantPrev    bool
+		wantOutOfRange        bool
+	}{
+		{"empty list", 1, 10, 0, 0, false, false, false},
+		{"one partial page", 1, 10, 3, 1, false, false, false},
+		{"exactly one full page", 1, 10, 10, 1, false, false, false},
+		{"one past a full page", 1, 10, 11, 2, true, false, false},
+		{"middle page", 2, 10, 25, 3, true, true, false},
+		{"last partial page", 3, 10, 25, 3, false, true, false},
+		{"last full page", 3, 10, 30, 3, false, true, false},
+		{"one past the last page", 4, 10, 30, 3, false, true, true},
+		{"past the end of an empty list", 2, 10, 0, 0, false, true, true},
+		{"page size of one", 5, 1, 5, 5, false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BuildPagination(tt.page, tt.pageSize, tt.total)
+			if p.TotalPages != tt.wantPages {
+				t.Errorf("TotalPages = %d, want %d", p.TotalPages, tt.wantPages)
+			}
+			if p.HasNext != tt.wantNext || p.HasPrev != tt.wantPrev {
+				t.Errorf("HasNext, HasPrev = %v, %v, want %v, %v", p.HasNext, p.HasPrev, tt.wantNext, tt.wantPrev)
+			}
+			if p.OutOfRange() != tt.wantOutOfRange {
+				t.Errorf("OutOfRange = %v, want %v", p.OutOfRange(), tt.wantOutOfRange)
+			}
+			if p.Page != tt.page || p.PageSize != tt.pageSize || p.Total != tt.total || p.MaxPageSize != MaxPageSize {
+				t.Errorf("Pagination = %+v, want the inputs echoed with MaxPageSize %d", p, MaxPageSize)
+			}
+		})
+	}
+}
+
+func TestPageOutOfRangeResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	PageOutOfRangeResponse(c, BuildPagination(4, 10, 30))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	var body struct {
+		Code       ErrorCode  `json:"code"`
+		Pagination Pagination `json:"pagination"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if body.Code != CodePageOutOfRange {
+		t.Errorf("code = %q, want %q", body.Code, CodePageOutOfRange)
+	}
+	// Clients can jump to the last page from the response
+	if body.Pagination.TotalPages != 3 {
+		t.Errorf("total_pages = %d, want 3", body.Pagination.TotalPages)
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The limits are package settings; restore them for other tests
+	defaultPageSize, maxPageSize := DefaultPageSize, MaxPageSize
+	t.Cleanup(func() { DefaultPageSize, MaxPageSize = defaultPageSize, maxPageSize })
+	DefaultPageSize, MaxPageSize = 10, 50
+
+	parse := func(query string, defaults ...int) (int, int, int) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/bills"+query, nil)
+		if len(defaults) > 0 {
+			return ParsePaginationWithDefault(c, defaults[0])
+		}
+		return ParsePagination(c)
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		defaultSize  []int
+		wantPage     int
+		wantPageSize int
+		wantOffset   int
+	}{
+		{"no parameters", "", nil, 1, 10, 0},
+		{"explicit values", "?page=3&page_size=20", nil, 3, 20, 40},
+		{"maximum page size", "?page=2&page_size=50", nil, 2, 50, 50},
+		{"page size over the maximum", "?page=2&page_size=51", nil, 2, 10, 10},
+		{"zero page", "?page=0&page_size=20", nil, 1, 20, 0},
+		{"negative page", "?page=-4", nil, 1, 10, 0},
+		{"zero page size", "?page_size=0", nil, 1, 10, 0},
+		{"negative page size", "?page_size=-5", nil, 1, 10, 0},
+		{"non-numeric page", "?page=two&page_size=5", nil, 1, 5, 0},
+		{"non-numeric page size", "?page=2&page_size=lots", nil, 2, 10, 10},
+		{"fractional values", "?page=1.5&page_size=2.5", nil, 1, 10, 0},
+		{"empty values", "?page=&page_size=", nil, 1, 10, 0},
+		{"endpoint default", "?page=2", []int{25}, 2, 25, 25},
+		{"endpoint default for an invalid size", "?page_size=x", []int{25}, 1, 25, 0},
+		{"endpoint default above the maximum", "", []int{80}, 1, 50, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, pageSize, offset := parse(tt.query, tt.defaultSize...)
+			if page != tt.wantPage || pageSize != tt.wantPageSize || offset != tt.wantOffset {
+				t.Errorf("parse(%q) = page %d, size %d, offset %d; want %d, %d, %d",
+					tt.query, page, pageSize, offset, tt.wantPage, tt.wantPageSize, tt.wantOffset)
+			}
+		})
+	}
+}
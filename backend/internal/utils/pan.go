@@ -0,0 +1,24 @@
+package utils
+
+import "errors"
+
+// ValidatePAN checks an Indian Permanent Account Number, e.g. AAPFU0939F
+// A PAN is 5 upper case letters, 4 digits and an upper case letter
+func ValidatePAN(pan string) error {
+	if len(pan) != 10 {
+		return errors.New("must be exactly 10 characters")
+	}
+
+	for i := 0; i < len(pan); i++ {
+		ch := pan[i]
+		if i >= 5 && i < 9 {
+			if ch < '0' || ch > '9' {
+				return errors.New("must have digits in characters 6-9")
+			}
+		} else if ch < 'A' || ch > 'Z' {
+			return errors.New("must have upper case letters in characters 1-5 and 10")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import "testing"
+
+func TestValidatePAN(t *testing.T) {
+	tests := []struct {
+		name    string
+		pan     string
+		wantErr string
+	}{
+		{"valid company", "AAPFU0939F", ""},
+		{"valid individual", "ABCPE1234Z", ""},
+
+		{"empty", "", "must be exactly 10 characters"},
+		{"too short", "AAPFU0939", "must be exactly 10 characters"},
+		{"too long", "AAPFU0939FX", "must be exactly 10 characters"},
+		{"lower case", "aapfu0939f", "must have upper case letters in characters 1-5 and 10"},
+		{"digit among the first letters", "AAP1U0939F", "must have upper case letters in characters 1-5 and 10"},
+		{"digit as the last character", "AAPFU09399", "must have upper case letters in characters 1-5 and 10"},
+		{"letter among the digits", "AAPFU09A9F", "must have digits in characters 6-9"},
+		{"punctuation", "AAPFU-939F", "must have digits in characters 6-9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePAN(tt.pan)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidatePAN(%q) = %v, want nil", tt.pan, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidatePAN(%q) = %v, want %q", tt.pan, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,29 +3,155 @@ package utils
 import (
 	"encoding/base64"
 	"fmt"
+	"image"
+	_ "image/jpeg" // Register decoders for uploaded QR images
+	_ "image/png"
+	"io"
+	"net/url"
+	"strings"
 
+	"github.com/makiuchi-d/gozxing"
+	zxingqr "github.com/makiuchi-d/gozxing/qrcode"
 	"github.com/skip2/go-qrcode"
 )
 
+// QR code image sizes in pixels
+const (
+	QRCodeDefaultSize = 256
+	QRCodeMinSize     = 128
+	QRCodeMaxSize     = 1024
+)
+
 // GenerateQRCode generates a QR code for a bill verification link
 func GenerateQRCode(billNumber, frontendURL string) (string, error) {
-	// Create verification URL
-	verificationURL := fmt.Sprintf("%s/verify?bill=%s", frontendURL, billNumber)
+	qrCode, err := GenerateQRCodePNG(billNumber, frontendURL)
+	if err != nil {
+		return "", err
+	}
 	
-	// Generate QR code (256x256 pixels)
-	qrCode, err := qrcode.Encode(verificationURL, qrcode.Medium, 256)
+	return QRCodeDataURL(qrCode), nil
+}
+
+// GenerateTextQRCode encodes arbitrary text, such as an otpauth:// link, as a PNG data URL
+func GenerateTextQRCode(text string) (string, error) {
+	qrCode, err := qrcode.Encode(text, DefaultQRCodeOptions.RecoveryLevel, QRCodeDefaultSize)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate QR code: %w", err)
 	}
-	
-	// Convert to base64 data URL
-	base64QR := base64.StdEncoding.EncodeToString(qrCode)
-	dataURL := fmt.Sprintf("data:image/png;base64,%s", base64QR)
-	
-	return dataURL, nil
+
+	return QRCodeDataURL(qrCode), nil
+}
+
+// GenerateQRCodePNG generates a QR code for a bill verification link as raw PNG bytes
+func GenerateQRCodePNG(billNumber, frontendURL string) ([]byte, error) {
+	return GenerateQRCodePNGSized(billNumber, frontendURL, QRCodeDefaultSize)
+}
+
+// QRCodeOptions controls how a QR code image is rendered
+type QRCodeOptions struct {
+	Size          int                  // Image size in pixels, clamped to QRCodeMinSize..QRCodeMaxSize
+	RecoveryLevel qrcode.RecoveryLevel // Higher levels survive more damage but make denser codes
+}
+
+// DefaultQRCodeOptions are used by GenerateQRCode and GenerateQRCodePNG
+var DefaultQRCodeOptions = QRCodeOptions{
+	Size:          QRCodeDefaultSize,
+	RecoveryLevel: qrcode.Medium,
+}
+
+// GenerateQRCodePNGSized is GenerateQRCodePNG with a custom image size
+func GenerateQRCodePNGSized(billNumber, frontendURL string, size int) ([]byte, error) {
+	opts := DefaultQRCodeOptions
+	opts.Size = size
+	return GenerateQRCodeWithOptions(billNumber, frontendURL, opts)
+}
+
+// GenerateQRCodeWithOptions generates a QR code for a bill verification link as PNG bytes
+func GenerateQRCodeWithOptions(billNumber, frontendURL string, opts QRCodeOptions) ([]byte, error) {
+	size := opts.Size
+	if size < QRCodeMinSize {
+		size = QRCodeMinSize
+	}
+	if size > QRCodeMaxSize {
+		size = QRCodeMaxSize
+	}
+
+	qrCode, err := qrcode.Encode(GenerateVerificationLink(billNumber, frontendURL), opts.RecoveryLevel, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	return qrCode, nil
+}
+
+// ParseQRRecoveryLevel maps a config value ("low", "medium", "high", "highest") to a recovery level
+func ParseQRRecoveryLevel(level string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToLower(level) {
+	case "low":
+		return qrcode.Low, nil
+	case "medium":
+		return qrcode.Medium, nil
+	case "high":
+		return qrcode.High, nil
+	case "highest":
+		return qrcode.Highest, nil
+	}
+	return qrcode.Medium, fmt.Errorf("unknown QR recovery level %q", level)
+}
+
+// QRCodeDataURL wraps PNG bytes in a base64 data URL for embedding in JSON
+func QRCodeDataURL(png []byte) string {
+	return fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(png))
 }
 
 // GenerateVerificationLink creates a shareable verification link
 func GenerateVerificationLink(billNumber, frontendURL string) string {
 	return fmt.Sprintf("%s/verify?bill=%s", frontendURL, billNumber)
-}
\ No newline at end of file
+}
+
+// DecodeQRCode reads a PNG or JPEG image and returns the text encoded in its QR code
+func DecodeQRCode(r io.Reader) (string, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	result, err := zxingqr.NewQRCodeReader().Decode(bitmap, nil)
+	if err != nil {
+		return "", fmt.Errorf("no QR code found in image: %w", err)
+	}
+
+	return result.GetText(), nil
+}
+
+// ParseVerificationLink extracts the bill number from a link built by GenerateVerificationLink
+// The link must point at our own frontend so QR codes can't smuggle in arbitrary URLs
+func ParseVerificationLink(link, frontendURL string) (string, error) {
+	expected, err := url.Parse(strings.TrimRight(frontendURL, "/") + "/verify")
+	if err != nil {
+		return "", fmt.Errorf("invalid frontend URL: %w", err)
+	}
+
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "", fmt.Errorf("invalid verification link: %w", err)
+	}
+
+	if !strings.EqualFold(parsed.Scheme, expected.Scheme) ||
+		!strings.EqualFold(parsed.Host, expected.Host) ||
+		parsed.Path != expected.Path {
+		return "", fmt.Errorf("link does not point to the EPR verification page")
+	}
+
+	billNumber := strings.TrimSpace(parsed.Query().Get("bill"))
+	if billNumber == "" {
+		return "", fmt.Errorf("verification link has no bill number")
+	}
+
+	return billNumber, nil
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestQRCodeRoundTrip(t *testing.T) {
+	const frontendURL = "https://epr.example.com"
+
+	for _, size := range []int{QRCodeMinSize, QRCodeDefaultSize, QRCodeMaxSize} {
+		png, err := GenerateQRCodePNGSized("SAL202401000042", frontendURL, size)
+		if err != nil {
+			t.Fatalf("size %d: GenerateQRCodePNGSized failed: %v", size, err)
+		}
+
+		link, err := DecodeQRCode(bytes.NewReader(png))
+		if err != nil {
+			t.Fatalf("size %d: DecodeQRCode failed: %v", size, err)
+		}
+		if want := GenerateVerificationLink("SAL202401000042", frontendURL); link != want {
+			t.Errorf("size %d: decoded %q, want %q", size, link, want)
+		}
+
+		billNumber, err := ParseVerificationLink(link, frontendURL)
+		if err != nil || billNumber != "SAL202401000042" {
+			t.Errorf("size %d: ParseVerificationLink = %q, %v", size, billNumber, err)
+		}
+	}
+}
+
+func TestDecodeQRCodeUnreadableImage(t *testing.T) {
+	if _, err := DecodeQRCode(strings.NewReader("not an image")); err == nil {
+		t.Error("DecodeQRCode accepted a non-image")
+	}
+}
+
+func TestParseVerificationLink(t *testing.T) {
+	const frontendURL = "https://epr.example.com/"
+
+	tests := []struct {
+		name    string
+		link    string
+		want    string
+		wantErr bool
+	}{
+		{"our link", "https://epr.example.com/verify?bill=SAL202401000042", "SAL202401000042", false},
+		{"host is case insensitive", "https://EPR.example.com/verify?bill=SAL202401000042", "SAL202401000042", false},
+		{"other host", "https://evil.example.com/verify?bill=SAL202401000042", "", true},
+		{"lookalike host", "https://epr.example.com.evil.net/verify?bill=SAL202401000042", "", true},
+		{"plain http", "http://epr.example.com/verify?bill=SAL202401000042", "", true},
+		{"other path", "https://epr.example.com/login?bill=SAL202401000042", "", true},
+		{"no bill number", "https://epr.example.com/verify", "", true},
+		{"not a URL", "SAL202401000042", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVerificationLink(tt.link, frontendURL)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseVerificationLink(%q) = %q, %v; want %q (error %v)", tt.link, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateQRCodePNGSizedClampsSize(t *testing.T) {
+	tests := []struct {
+		size int
+		want int
+	}{
+		{0, QRCodeMinSize},
+		{-50, QRCodeMinSize},
+		{QRCodeMinSize - 1, QRCodeMinSize},
+		{512, 512},
+		{QRCodeMaxSize + 1, QRCodeMaxSize},
+		{100000, QRCodeMaxSize},
+	}
+
+	for _, tt := range tests {
+		png, err := GenerateQRCodePNGSized("SAL202401000042", "https://epr.example.com", tt.size)
+		if err != nil {
+			t.Fatalf("size %d: GenerateQRCodePNGSized failed: %v", tt.size, err)
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(png))
+		if err != nil {
+			t.Fatalf("size %d: not a PNG: %v", tt.size, err)
+		}
+		if cfg.Width != tt.want || cfg.Height != tt.want {
+			t.Errorf("size %d: image is %dx%d, want %dx%d", tt.size, cfg.Width, cfg.Height, tt.want, tt.want)
+		}
+	}
+}
+
+func TestQRCodeRecoveryLevelsDecodeToSameLink(t *testing.T) {
+	const frontendURL = "https://epr.example.com"
+	want := GenerateVerificationLink("MED202401000042", frontendURL)
+
+	for _, name := range []string{"low", "medium", "high", "highest"} {
+		level, err := ParseQRRecoveryLevel(name)
+		if err != nil {
+			t.Fatalf("ParseQRRecoveryLevel(%q) failed: %v", name, err)
+		}
+
+		for _, size := range []int{QRCodeMinSize, QRCodeMaxSize} {
+			png, err := GenerateQRCodeWithOptions("MED202401000042", frontendURL, QRCodeOptions{Size: size, RecoveryLevel: level})
+			if err != nil {
+				t.Fatalf("%s at %dpx: GenerateQRCodeWithOptions failed: %v", name, size, err)
+			}
+			link, err := DecodeQRCode(bytes.NewReader(png))
+			if err != nil {
+				t.Fatalf("%s at %dpx: DecodeQRCode failed: %v", name, size, err)
+			}
+			if link != want {
+				t.Errorf("%s at %dpx: decoded %q, want %q", name, size, link, want)
+			}
+		}
+	}
+}
+
+func TestParseQRRecoveryLevel(t *testing.T) {
+	for _, level := range []string{"low", "Medium", "HIGH", "highest"} {
+		if _, err := ParseQRRecoveryLevel(level); err != nil {
+			t.Errorf("ParseQRRecoveryLevel(%q) failed: %v", level, err)
+		}
+	}
+	for _, level := range []string{"", "max", "h"} {
+		if _, err := ParseQRRecoveryLevel(level); err == nil {
+			t.Errorf("ParseQRRecoveryLevel(%q) accepted an unknown level", level)
+		}
+	}
+}
@@ -14,19 +14,32 @@ func SuccessResponse(c *gin.Context, statusCode int, data interface{}) {
 	})
 }
 
-// ErrorResponse sends an error JSON response
+// ErrorResponse sends an error JSON response with the generic code for the status
+// Prefer ErrorResponseWithCode when the error has a specific code clients can act on
 func ErrorResponse(c *gin.Context, statusCode int, message string) {
+	ErrorResponseWithCode(c, statusCode, CodeForStatus(statusCode), message)
+}
+
+// ErrorResponseWithCode sends an error JSON response with a machine-readable code
+func ErrorResponseWithCode(c *gin.Context, statusCode int, code ErrorCode, message string) {
 	c.JSON(statusCode, gin.H{
 		"success": false,
+		"code":    code,
 		"error":   message,
 	})
 }
 
 // ValidationErrorResponse sends a validation error response
 func ValidationErrorResponse(c *gin.Context, errors interface{}) {
+	ValidationErrorResponseWithCode(c, CodeValidationFailed, errors)
+}
+
+// ValidationErrorResponseWithCode sends a validation error response for a specific rule, e.g. CodeUnsupportedCurrency
+func ValidationErrorResponseWithCode(c *gin.Context, code ErrorCode, errors interface{}) {
 	c.JSON(http.StatusBadRequest, gin.H{
 		"success": false,
+		"code":    code,
 		"error":   "Validation failed",
 		"details": errors,
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorResponseWithCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	ErrorResponseWithCode(c, http.StatusPaymentRequired, CodeInsufficientBalance, "Insufficient wallet balance")
+
+	if w.Code != http.StatusPaymentRequired {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusPaymentRequired)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if body["success"] != false || body["code"] != string(CodeInsufficientBalance) || body["error"] != "Insufficient wallet balance" {
+		t.Errorf("body = %v, want the code alongside the message", body)
+	}
+}
+
+func TestErrorResponseUsesGenericCodeForStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		status int
+		want   ErrorCode
+	}{
+		{http.StatusBadRequest, CodeBadRequest},
+		{http.StatusUnauthorized, CodeUnauthorized},
+		{http.StatusPaymentRequired, CodePaymentRequired},
+		{http.StatusForbidden, CodeForbidden},
+		{http.StatusNotFound, CodeNotFound},
+		{http.StatusConflict, CodeConflict},
+		{http.StatusGone, CodeGone},
+		{http.StatusRequestEntityTooLarge, CodePayloadTooLarge},
+		{http.StatusTooManyRequests, CodeRateLimited},
+		{http.StatusServiceUnavailable, CodeServiceUnavailable},
+		{http.StatusInternalServerError, CodeInternalError},
+		{http.StatusBadGateway, CodeInternalError},
+		{http.StatusUnprocessableEntity, CodeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			ErrorResponse(c, tt.status, "something went wrong")
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body["code"] != string(tt.want) {
+				t.Errorf("code = %v, want %s", body["code"], tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorResponseWithCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	ValidationErrorResponseWithCode(c, CodeUnsupportedCurrency, map[string]string{"currency": "is not supported"})
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	details, _ := body["details"].(map[string]interface{})
+	if body["code"] != string(CodeUnsupportedCurrency) || details["currency"] != "is not supported" {
+		t.Errorf("body = %v, want the rule's code and the field details", body)
+	}
+}
@@ -0,0 +1,31 @@
+package utils
+
+import "time"
+
+// Day and month boundaries are computed in the business timezone (APP_TIMEZONE, see config.Config.Location),
+// which callers pass in. Timestamps are stored in UTC, so convert boundaries with UTC() before handing them to a query
+
+// DateLayout is the YYYY-MM-DD format dates are sent and received in
+const DateLayout = "2006-01-02"
+
+// StartOfDay returns midnight at the start of t's day in loc
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// StartOfMonth returns midnight on the first of t's month in loc
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+}
+
+// ParseDate parses a YYYY-MM-DD date filter as the UTC instant its day starts in loc
+// Dates stored as DATE columns, like a bill's issue_date, are parsed with time.Parse instead
+func ParseDate(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(DateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
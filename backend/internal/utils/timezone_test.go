@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// ist is India Standard Time, which has no daylight saving
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+func TestStartOfDayInIST(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"last second of the IST day", time.Date(2026, 3, 31, 18, 29, 59, 0, time.UTC), time.Date(2026, 3, 30, 18, 30, 0, 0, time.UTC)},
+		{"IST midnight", time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC), time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC)},
+		{"UTC midnight is already mid-morning in IST", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC)},
+		{"year rollover", time.Date(2025, 12, 31, 19, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 18, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StartOfDay(tt.t, ist)
+			if !got.Equal(tt.want) {
+				t.Errorf("StartOfDay(%s) = %s, want %s", tt.t, got.UTC(), tt.want)
+			}
+			if got.Location() != ist {
+				t.Errorf("StartOfDay returned a time in %s, want IST", got.Location())
+			}
+		})
+	}
+}
+
+func TestStartOfMonthInIST(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"still March in IST", time.Date(2026, 3, 31, 18, 29, 59, 0, time.UTC), time.Date(2026, 2, 28, 18, 30, 0, 0, time.UTC)},
+		{"already April in IST", time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC), time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC)},
+		{"February in a leap year", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 18, 30, 0, 0, time.UTC)},
+		{"new year in IST, not yet in UTC", time.Date(2025, 12, 31, 20, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 18, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StartOfMonth(tt.t, ist); !got.Equal(tt.want) {
+				t.Errorf("StartOfMonth(%s) = %s, want %s", tt.t, got.UTC(), tt.want)
+			}
+		})
+	}
+
+	// The month after a 31st is the 1st of the next month, not a normalized date past it
+	next := StartOfMonth(time.Date(2026, 1, 31, 12, 0, 0, 0, ist), ist).AddDate(0, 1, 0)
+	if want := time.Date(2026, 2, 1, 0, 0, 0, 0, ist); !next.Equal(want) {
+		t.Errorf("start of next month = %s, want %s", next, want)
+	}
+}
+
+func TestParseDateInIST(t *testing.T) {
+	got, err := ParseDate("2026-04-01", ist)
+	if err != nil {
+		t.Fatalf("ParseDate failed: %v", err)
+	}
+	if want := time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("ParseDate = %s, want %s", got, want)
+	}
+
+	for _, value := range []string{"", "2026-4-1", "01-04-2026", "2026-02-30"} {
+		if _, err := ParseDate(value, ist); err == nil {
+			t.Errorf("ParseDate(%q) succeeded, want an error", value)
+		}
+	}
+}
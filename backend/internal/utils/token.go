@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// GenerateSecureToken returns a random hex-encoded token of n bytes
+// Used for email verification and password reset links
+func GenerateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// shortCodeAlphabet has 32 characters, leaving out 0, 1, i and l, which are easy to misread
+const shortCodeAlphabet = "23456789abcdefghjkmnopqrstuvwxyz"
+
+// GenerateShortCode returns a random code of n characters for use in short links
+func GenerateShortCode(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = shortCodeAlphabet[b[i]&31] // 5 bits per character, no modulo bias
+	}
+	return string(b), nil
+}
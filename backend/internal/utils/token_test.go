@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateShortCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		code, err := GenerateShortCode(10)
+		if err != nil {
+			t.Fatalf("GenerateShortCode failed: %v", err)
+		}
+		if len(code) != 10 {
+			t.Fatalf("code %q has %d characters, want 10", code, len(code))
+		}
+		for _, r := range code {
+			if !strings.ContainsRune(shortCodeAlphabet, r) {
+				t.Fatalf("code %q contains %q, which isn't in the short code alphabet", code, r)
+			}
+		}
+		if seen[code] {
+			t.Fatalf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+}
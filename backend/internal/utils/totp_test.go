@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key from RFC 6238 appendix B, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; these are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode failed: %v", err)
+		}
+		if code != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, code, tt.want)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+	code := func(step int64) string {
+		c, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatalf("TOTPCode failed: %v", err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(step), step, true},
+		{"previous step", code(step - 1), step - 1, true},
+		{"next step", code(step + 1), step + 1, true},
+		{"two steps old", code(step - 2), 0, false},
+		{"typed with spaces", code(step)[:3] + " " + code(step)[3:], step, true},
+		{"too short", code(step)[:5], 0, false},
+		{"wrong code", "000000", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(rfc6238Secret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP(%q) = %d, %v; want %d, %v", tt.code, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTOTPURL(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret failed: %v", err)
+	}
+
+	u, err := url.Parse(TOTPURL("EPR", "asha@example.com", secret))
+	if err != nil {
+		t.Fatalf("TOTPURL is not a URL: %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/EPR:asha@example.com" {
+		t.Errorf("URL = %s, want otpauth://totp/EPR:asha@example.com", u)
+	}
+	if u.Query().Get("secret") != secret || u.Query().Get("issuer") != "EPR" {
+		t.Errorf("query = %v, want the secret and issuer", u.Query())
+	}
+}
+
+func TestEncryptSecretRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+
+	sealed, err := EncryptSecret(key, rfc6238Secret)
+	if err != nil {
+		t.Fatalf("EncryptSecret failed: %v", err)
+	}
+	if bytes.Contains([]byte(sealed), []byte(rfc6238Secret)) {
+		t.Error("encrypted value contains the secret")
+	}
+	if again, _ := EncryptSecret(key, rfc6238Secret); again == sealed {
+		t.Error("encrypting twice gave the same value; the nonce isn't random")
+	}
+
+	opened, err := DecryptSecret(key, sealed)
+	if err != nil || opened != rfc6238Secret {
+		t.Errorf("DecryptSecret = %q, %v; want the original secret", opened, err)
+	}
+
+	if _, err := DecryptSecret(bytes.Repeat([]byte{8}, 32), sealed); err == nil {
+		t.Error("DecryptSecret succeeded with the wrong key")
+	}
+	if _, err := EncryptSecret(key[:16], rfc6238Secret); err == nil {
+		t.Error("EncryptSecret accepted a 16-byte key")
+	}
+}
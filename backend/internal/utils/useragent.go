@@ -0,0 +1,99 @@
+package utils
+
+import "strings"
+
+// DeviceInfo is what a User-Agent header says about the client
+// Fields are empty when they can't be recognized
+type DeviceInfo struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Device  string `json:"device"` // "Desktop", "Mobile", "Tablet" or "Bot"
+}
+
+// String formats the device for display, e.g. "Chrome on Windows (Desktop)"
+func (d DeviceInfo) String() string {
+	browser, os := d.Browser, d.OS
+	if browser == "" {
+		browser = "Unknown browser"
+	}
+	if os == "" {
+		os = "unknown OS"
+	}
+	if d.Device == "" {
+		return browser + " on " + os
+	}
+	return browser + " on " + os + " (" + d.Device + ")"
+}
+
+// ParseUserAgent picks out the browser, operating system and device class from a User-Agent header
+// Only common clients are recognized; this is for showing users where they signed in, not for security decisions
+func ParseUserAgent(userAgent string) DeviceInfo {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return DeviceInfo{}
+	}
+
+	return DeviceInfo{
+		Browser: parseBrowser(ua),
+		OS:      parseOS(ua),
+		Device:  parseDevice(ua),
+	}
+}
+
+// parseBrowser checks the tokens in priority order - Edge and Opera also claim to be Chrome, and Chrome claims Safari
+func parseBrowser(ua string) string {
+	switch {
+	case strings.Contains(ua, "edg/"), strings.Contains(ua, "edge/"):
+		return "Edge"
+	case strings.Contains(ua, "opr/"), strings.Contains(ua, "opera"):
+		return "Opera"
+	case strings.Contains(ua, "samsungbrowser"):
+		return "Samsung Internet"
+	case strings.Contains(ua, "firefox/"), strings.Contains(ua, "fxios/"):
+		return "Firefox"
+	case strings.Contains(ua, "chrome/"), strings.Contains(ua, "crios/"):
+		return "Chrome"
+	case strings.Contains(ua, "safari/"):
+		return "Safari"
+	case strings.Contains(ua, "curl/"):
+		return "curl"
+	case strings.Contains(ua, "postman"):
+		return "Postman"
+	}
+	return ""
+}
+
+// parseOS checks mobile platforms first since their User-Agents also mention Linux or Mac OS X
+func parseOS(ua string) string {
+	switch {
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return "iOS"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "mac os x"), strings.Contains(ua, "macintosh"):
+		return "macOS"
+	case strings.Contains(ua, "cros"):
+		return "ChromeOS"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	}
+	return ""
+}
+
+// parseDevice classifies the client; Android tablets omit "mobile" from their User-Agent
+func parseDevice(ua string) string {
+	switch {
+	case strings.Contains(ua, "bot"), strings.Contains(ua, "spider"), strings.Contains(ua, "crawl"):
+		return "Bot"
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"),
+		strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return "Tablet"
+	case strings.Contains(ua, "mobi"), strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"):
+		return "Mobile"
+	case strings.Contains(ua, "windows"), strings.Contains(ua, "macintosh"), strings.Contains(ua, "linux"), strings.Contains(ua, "cros"):
+		return "Desktop"
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package utils
+
+import "testing"
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      DeviceInfo
+	}{
+		{
+			"Chrome on Windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			DeviceInfo{Browser: "Chrome", OS: "Windows", Device: "Desktop"},
+		},
+		{
+			"Edge claims to be Chrome",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			DeviceInfo{Browser: "Edge", OS: "Windows", Device: "Desktop"},
+		},
+		{
+			"Safari on iPhone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			DeviceInfo{Browser: "Safari", OS: "iOS", Device: "Mobile"},
+		},
+		{
+			"Chrome on an Android phone",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			DeviceInfo{Browser: "Chrome", OS: "Android", Device: "Mobile"},
+		},
+		{
+			"Samsung Internet on an Android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Safari/537.36",
+			DeviceInfo{Browser: "Samsung Internet", OS: "Android", Device: "Tablet"},
+		},
+		{
+			"Firefox on macOS",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.1; rv:121.0) Gecko/20100101 Firefox/121.0",
+			DeviceInfo{Browser: "Firefox", OS: "macOS", Device: "Desktop"},
+		},
+		{
+			"Firefox on Linux",
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			DeviceInfo{Browser: "Firefox", OS: "Linux", Device: "Desktop"},
+		},
+		{
+			"crawler",
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			DeviceInfo{Device: "Bot"},
+		},
+		{"curl", "curl/8.4.0", DeviceInfo{Browser: "curl"}},
+		{"empty", "", DeviceInfo{}},
+		{"unrecognized", "SomeClient/1.0", DeviceInfo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseUserAgent(tt.userAgent); got != tt.want {
+				t.Errorf("ParseUserAgent = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceInfoString(t *testing.T) {
+	tests := []struct {
+		device DeviceInfo
+		want   string
+	}{
+		{DeviceInfo{Browser: "Chrome", OS: "Windows", Device: "Desktop"}, "Chrome on Windows (Desktop)"},
+		{DeviceInfo{Browser: "curl"}, "curl on unknown OS"},
+		{DeviceInfo{}, "Unknown browser on unknown OS"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.device.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.device, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Report JSON field names in validation errors instead of Go struct field names
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+
+		// binding:"omitempty,gstin" and "omitempty,pan" on optional tax ID fields
+		_ = v.RegisterValidation("gstin", func(fl validator.FieldLevel) bool {
+			return ValidateGSTIN(fl.Field().String()) == nil
+		})
+		_ = v.RegisterValidation("pan", func(fl validator.FieldLevel) bool {
+			return ValidatePAN(fl.Field().String()) == nil
+		})
+	}
+}
+
+// BindingErrorResponse sends the error response for a failed ShouldBindJSON
+// Oversized bodies get 413 and malformed JSON gets its own message; otherwise the details map
+// each offending field to a readable message, e.g. {"amount": "must be greater than 0"}
+func BindingErrorResponse(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ErrorResponseWithCode(c, http.StatusRequestEntityTooLarge, CodePayloadTooLarge, fmt.Sprintf("Request body too large. Maximum size is %d bytes", tooLarge.Limit))
+		return
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		ErrorResponseWithCode(c, http.StatusBadRequest, CodeMalformedJSON, fmt.Sprintf("Malformed JSON at byte %d: %s", syntaxErr.Offset, syntaxErr.Error()))
+		return
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		ErrorResponseWithCode(c, http.StatusBadRequest, CodeMalformedJSON, "Malformed JSON: the request body ended unexpectedly")
+		return
+	}
+
+	ValidationErrorResponse(c, BindingErrors(err))
+}
+
+// BindingErrors converts a request binding error into a field -> message map
+// Errors that aren't about a single field, like malformed JSON, are reported under "body"
+func BindingErrors(err error) map[string]string {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make(map[string]string, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields[fieldPath(fe)] = fieldMessage(fe)
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return map[string]string{typeErr.Field: "must be a " + jsonTypeName(typeErr.Type)}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return map[string]string{"body": "must be valid JSON"}
+	}
+	if errors.Is(err, io.EOF) {
+		return map[string]string{"body": "is required"}
+	}
+
+	return map[string]string{"body": "could not be read"}
+}
+
+// fieldPath returns the JSON path of a failed field without the request struct's name,
+// e.g. "bills[0].amount"
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+// fieldMessage describes why a field failed validation
+func fieldMessage(fe validator.FieldError) string {
+	param := fe.Param()
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "gt":
+		return "must be greater than " + param
+	case "gte":
+		return "must be at least " + param
+	case "lt":
+		return "must be less than " + param
+	case "lte":
+		return "must be at most " + param
+	case "min", "max", "len":
+		return sizeMessage(fe.Tag(), fe.Kind(), param)
+	case "gstin":
+		if err := ValidateGSTIN(fmt.Sprint(fe.Value())); err != nil {
+			return "is not a valid GSTIN: it " + err.Error()
+		}
+		return "must be a valid GSTIN"
+	case "pan":
+		if err := ValidatePAN(fmt.Sprint(fe.Value())); err != nil {
+			return "is not a valid PAN: it " + err.Error()
+		}
+		return "must be a valid PAN"
+	}
+
+	return fmt.Sprintf("failed the %q check", fe.Tag())
+}
+
+// sizeMessage describes a min/max/len failure in terms of the field's kind
+func sizeMessage(tag string, kind reflect.Kind, param string) string {
+	bound := map[string]string{"min": "at least", "max": "at most", "len": "exactly"}[tag]
+
+	switch kind {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", bound, param)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must contain %s %s items", bound, param)
+	}
+	return fmt.Sprintf("must be %s %s", bound, param)
+}
+
+// jsonTypeName names a Go type the way API clients think of it
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return t.String()
+}
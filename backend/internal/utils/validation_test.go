@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindingErrorsWithoutValidationErrors(t *testing.T) {
+	decodeErr := func(body string) error {
+		var v struct {
+			Amount float64 `json:"amount"`
+		}
+		return json.NewDecoder(strings.NewReader(body)).Decode(&v)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want map[string]string
+	}{
+		{"wrong type", decodeErr(`{"amount": "ten"}`), map[string]string{"amount": "must be a number"}},
+		{"malformed JSON", decodeErr(`{"amount": }`), map[string]string{"body": "must be valid JSON"}},
+		{"truncated JSON", decodeErr(`{"amount": 1`), map[string]string{"body": "must be valid JSON"}},
+		{"empty body", io.EOF, map[string]string{"body": "is required"}},
+		{"anything else", errors.New("connection reset"), map[string]string{"body": "could not be read"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BindingErrors(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BindingErrors(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
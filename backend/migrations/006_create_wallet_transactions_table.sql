@@ -0,0 +1,31 @@
+-- Migration: Create wallet_transactions table
+-- Description: Per-user wallet statement (top-ups, fees, refunds) for reconciliation
+
+CREATE TYPE wallet_transaction_type AS ENUM ('topup', 'bill_fee', 'verification_fee', 'refund');
+
+CREATE TABLE wallet_transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- Wallet owner
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Movement
+    transaction_type wallet_transaction_type NOT NULL,
+    amount DECIMAL(10,2) NOT NULL, -- Signed: positive for credits, negative for debits
+    balance_after DECIMAL(10,2) NOT NULL, -- Wallet balance once this entry was applied
+
+    -- Related entity (bill id for bill_fee, verification id for verification_fee)
+    reference_id UUID,
+    description TEXT,
+
+    -- Timestamp (entries are immutable, so no updated_at)
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Indexes
+CREATE INDEX idx_wallet_transactions_user_date ON wallet_transactions(user_id, created_at DESC);
+CREATE INDEX idx_wallet_transactions_reference ON wallet_transactions(reference_id);
+
+-- Comments
+COMMENT ON TABLE wallet_transactions IS 'Immutable wallet statement - one row per balance change';
+COMMENT ON COLUMN wallet_transactions.amount IS 'Signed amount: positive credits the wallet, negative debits it';
@@ -0,0 +1,10 @@
+-- Migration: Add email verification token expiry
+-- Description: Verification links are only valid for a limited time
+
+ALTER TABLE users ADD COLUMN email_verification_expires_at TIMESTAMP;
+
+-- Tokens are looked up when a user clicks the verification link
+CREATE INDEX idx_users_email_verification_token ON users(email_verification_token)
+    WHERE email_verification_token IS NOT NULL;
+
+COMMENT ON COLUMN users.email_verification_expires_at IS 'When the pending email verification token stops being valid';
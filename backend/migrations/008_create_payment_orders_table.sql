@@ -0,0 +1,33 @@
+-- Migration: Create payment_orders table
+-- Description: Wallet top-up orders created at the payment gateway
+
+CREATE TYPE payment_order_status AS ENUM ('created', 'paid');
+
+CREATE TABLE payment_orders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- Who is topping up
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Gateway details
+    gateway VARCHAR(50) NOT NULL, -- 'mock', 'razorpay', etc.
+    gateway_order_id VARCHAR(255) UNIQUE NOT NULL,
+    gateway_payment_id VARCHAR(255) UNIQUE, -- Set once paid; UNIQUE blocks replayed confirmations
+
+    -- Amount
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) DEFAULT 'INR',
+
+    -- Status
+    status payment_order_status NOT NULL DEFAULT 'created',
+
+    -- Timestamps
+    created_at TIMESTAMP DEFAULT NOW(),
+    paid_at TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX idx_payment_orders_user ON payment_orders(user_id, created_at DESC);
+
+-- Comments
+COMMENT ON TABLE payment_orders IS 'Gateway orders for wallet top-ups; a paid order credits the wallet exactly once';
@@ -0,0 +1,6 @@
+-- Migration: Track low wallet balance notifications
+-- Description: Lets us suppress repeated low-balance emails within a cooldown window
+
+ALTER TABLE users ADD COLUMN last_low_balance_notified_at TIMESTAMP;
+
+COMMENT ON COLUMN users.last_low_balance_notified_at IS 'When the last low wallet balance warning email was sent';
@@ -0,0 +1,31 @@
+-- Migration: Create bill_revisions table
+-- Description: Immutable history of bill corrections; bills keep their bill_number across edits
+
+CREATE TABLE bill_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- Which bill, and which version of it (1 = as originally issued)
+    bill_id UUID NOT NULL REFERENCES bills(id) ON DELETE CASCADE,
+    revision_number INTEGER NOT NULL CHECK (revision_number > 0),
+
+    -- Content as it was before the edit
+    bill_data JSONB NOT NULL,
+    data_hash VARCHAR(64) NOT NULL,
+    amount DECIMAL(12,2) NOT NULL,
+    blockchain_tx_id VARCHAR(255), -- On-chain commitment of this version, if any
+
+    -- Who edited and why
+    edited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    edit_reason TEXT,
+
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE (bill_id, revision_number)
+);
+
+-- Indexes
+CREATE INDEX idx_bill_revisions_bill ON bill_revisions(bill_id, revision_number DESC);
+
+-- Comments
+COMMENT ON TABLE bill_revisions IS 'Previous versions of bills, written each time an issuer corrects a bill';
+COMMENT ON COLUMN bill_revisions.revision_number IS 'Version number of the archived content, starting at 1 for the original bill';
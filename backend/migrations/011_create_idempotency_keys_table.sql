@@ -0,0 +1,22 @@
+-- Migration: Create idempotency_keys table
+-- Description: Remembers Idempotency-Key headers on bill creation so client retries don't create duplicate bills
+
+CREATE TABLE idempotency_keys (
+    -- Keys are scoped per user; two institutions may send the same key
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+
+    -- Bill created by the first request with this key
+    bill_id UUID REFERENCES bills(id) ON DELETE SET NULL,
+
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+-- Indexes
+CREATE INDEX idx_idempotency_keys_created ON idempotency_keys(created_at);
+
+-- Comments
+COMMENT ON TABLE idempotency_keys IS 'Idempotency-Key headers seen on bill creation; rows older than the configured window may be reused';
+COMMENT ON COLUMN idempotency_keys.bill_id IS 'Bill returned when the same key is replayed';
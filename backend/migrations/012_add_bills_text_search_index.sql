@@ -0,0 +1,12 @@
+-- Migration: Add full-text search index on bills
+-- Description: Lets issuers find bills by recipient name, description, bill number or issuer name
+
+-- The expression must match billSearchVector in bill_repository.go exactly, otherwise Postgres won't use the index
+CREATE INDEX idx_bills_text_search ON bills USING gin(
+    to_tsvector('simple',
+        COALESCE(bill_data->>'recipient_name', '') || ' ' ||
+        COALESCE(bill_data->>'description', '') || ' ' ||
+        bill_number || ' ' ||
+        issuer_name
+    )
+);
@@ -0,0 +1,10 @@
+-- Migration: Add validity window to bills
+-- Description: Rental agreements, insurance policies etc. are only valid until a date; verification reports them as expired afterwards
+
+ALTER TABLE bills ADD COLUMN valid_until DATE;
+
+-- Verifications of bills past their validity window
+ALTER TYPE verification_status ADD VALUE 'expired';
+
+-- Comments
+COMMENT ON COLUMN bills.valid_until IS 'Last day the bill is valid (inclusive); NULL means it never expires';
@@ -0,0 +1,18 @@
+-- Migration: Add prepaid verification plans
+-- Description: Large verifiers get a monthly verification quota instead of paying per verification from their wallet
+
+ALTER TABLE users ADD COLUMN plan_type VARCHAR(50);
+ALTER TABLE users ADD COLUMN plan_monthly_quota INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN plan_quota_used INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN plan_resets_at TIMESTAMP;
+
+ALTER TABLE verifications ADD COLUMN covered_by_plan BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Indexes
+CREATE INDEX idx_users_plan_resets_at ON users(plan_resets_at) WHERE plan_type IS NOT NULL;
+
+-- Comments
+COMMENT ON COLUMN users.plan_type IS 'Verification plan name; NULL means pay per verification';
+COMMENT ON COLUMN users.plan_quota_used IS 'Verifications covered by the plan since the last reset';
+COMMENT ON COLUMN users.plan_resets_at IS 'When plan_quota_used is next reset to zero';
+COMMENT ON COLUMN verifications.covered_by_plan IS 'Verification was paid for from the plan quota rather than the wallet';
@@ -0,0 +1,31 @@
+-- Migration: Add verification webhooks
+-- Description: Institutions can receive a signed callback whenever one of their bills is verified
+
+ALTER TABLE users ADD COLUMN webhook_url TEXT;
+ALTER TABLE users ADD COLUMN webhook_secret VARCHAR(64);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- What was sent and where
+    event VARCHAR(50) NOT NULL,
+    url TEXT NOT NULL,
+    payload JSONB NOT NULL,
+
+    -- Outcome of the latest attempt
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_status_code INTEGER,
+    last_error TEXT,
+
+    created_at TIMESTAMP DEFAULT NOW(),
+    delivered_at TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX idx_webhook_deliveries_user ON webhook_deliveries(user_id, created_at DESC);
+
+-- Comments
+COMMENT ON TABLE webhook_deliveries IS 'Outbound webhook calls and their delivery attempts';
+COMMENT ON COLUMN users.webhook_secret IS 'HMAC-SHA256 key used to sign webhook payloads';
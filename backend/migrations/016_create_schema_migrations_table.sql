@@ -0,0 +1,16 @@
+-- Migration: Track applied schema version
+-- Description: Records which numbered migrations have run so /health can report the schema version
+
+-- IF NOT EXISTS / ON CONFLICT because the migration runner creates this table before running 001
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Everything up to and including this migration has been applied
+INSERT INTO schema_migrations (version)
+SELECT generate_series(1, 16)
+ON CONFLICT (version) DO NOTHING;
+
+-- Comments
+COMMENT ON TABLE schema_migrations IS 'Numbered migrations applied to this database; later migrations insert their own version';
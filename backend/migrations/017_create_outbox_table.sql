@@ -0,0 +1,28 @@
+-- Migration: Add notification outbox
+-- Description: Emails and webhooks are queued in the same transaction as the change that triggers them
+-- and delivered by a background dispatcher, so a crash mid-send can't lose them
+
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- What to deliver
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('bill_email', 'bill_verified_webhook')),
+    payload JSONB NOT NULL,
+
+    -- Delivery progress
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    created_at TIMESTAMP DEFAULT NOW(),
+    sent_at TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX idx_outbox_pending ON outbox(next_attempt_at) WHERE status = 'pending';
+
+-- Comments
+COMMENT ON TABLE outbox IS 'Notifications waiting to be delivered; rows stay pending until delivered or out of attempts';
+
+INSERT INTO schema_migrations (version) VALUES (17);
@@ -0,0 +1,10 @@
+-- Migration: Add email notification preferences
+-- Description: Users can opt out of optional emails; transactional emails (verification, password reset, KYC) are always sent
+
+ALTER TABLE users ADD COLUMN notification_preferences JSONB NOT NULL
+    DEFAULT '{"daily_summary": true, "login_alerts": true, "low_balance": true, "verification_alerts": true}';
+
+-- Comments
+COMMENT ON COLUMN users.notification_preferences IS 'Which optional emails the user receives';
+
+INSERT INTO schema_migrations (version) VALUES (18);
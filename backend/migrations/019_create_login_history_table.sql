@@ -0,0 +1,25 @@
+-- Migration: Add login history
+-- Description: Recent sign-ins per user, shown on the sessions page and in login alert emails
+
+CREATE TABLE login_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- Where the sign-in came from
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    browser VARCHAR(50),
+    os VARCHAR(50),
+    device VARCHAR(20),
+    location VARCHAR(255),
+
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Indexes
+CREATE INDEX idx_login_history_user ON login_history(user_id, created_at DESC);
+
+-- Comments
+COMMENT ON TABLE login_history IS 'Most recent successful logins per user; older rows are pruned on insert';
+
+INSERT INTO schema_migrations (version) VALUES (19);
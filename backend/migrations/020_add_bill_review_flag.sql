@@ -0,0 +1,12 @@
+-- Migration: Flag unusually large bills for admin review
+-- Description: Bills above the review threshold are still issued but surface in the admin bill search
+
+ALTER TABLE bills ADD COLUMN needs_review BOOLEAN NOT NULL DEFAULT false;
+
+-- Indexes
+CREATE INDEX idx_bills_needs_review ON bills(created_at DESC) WHERE needs_review = true;
+
+-- Comments
+COMMENT ON COLUMN bills.needs_review IS 'Amount is above the review threshold; an admin should confirm it is not a typo';
+
+INSERT INTO schema_migrations (version) VALUES (20);
@@ -0,0 +1,12 @@
+-- Migration: Email verifiers a receipt for each verification
+-- Description: Receipts are queued in the outbox with the charge, and can be turned off per user
+
+ALTER TABLE outbox DROP CONSTRAINT outbox_kind_check;
+ALTER TABLE outbox ADD CONSTRAINT outbox_kind_check
+    CHECK (kind IN ('bill_email', 'bill_verified_webhook', 'verification_receipt'));
+
+-- Existing rows without the key are read as enabled
+ALTER TABLE users ALTER COLUMN notification_preferences
+    SET DEFAULT '{"daily_summary": true, "login_alerts": true, "low_balance": true, "verification_alerts": true, "verification_receipts": true}';
+
+INSERT INTO schema_migrations (version) VALUES (21);
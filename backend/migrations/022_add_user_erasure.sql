@@ -0,0 +1,9 @@
+-- Migration: Let users erase their personal data
+-- Description: Erased accounts keep their row (bills, verifications and the ledger reference it) with personal fields scrubbed
+
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMP;
+
+-- Comments
+COMMENT ON COLUMN users.erased_at IS 'When the user erased their personal data; the account stays deactivated';
+
+INSERT INTO schema_migrations (version) VALUES (22);
@@ -0,0 +1,9 @@
+-- Migration: Let issuers offer a paid preview of government/financial bills
+-- Description: With allow_preview set, public verifiers can confirm the bill exists for the minimum fee without seeing its data
+
+ALTER TABLE bills ADD COLUMN allow_preview BOOLEAN NOT NULL DEFAULT false;
+
+-- Comments
+COMMENT ON COLUMN bills.allow_preview IS 'Public verifiers get existence, issuer, type and blockchain status instead of a flat restriction';
+
+INSERT INTO schema_migrations (version) VALUES (23);
@@ -0,0 +1,14 @@
+-- Migration: Let support staff adjust wallet balances
+-- Description: Admin adjustments are ledger entries of their own type that record which admin made them
+
+ALTER TYPE wallet_transaction_type ADD VALUE IF NOT EXISTS 'admin_adjustment';
+
+ALTER TABLE wallet_transactions ADD COLUMN created_by UUID REFERENCES users(id);
+
+-- Admins may push a balance below zero to claw back an over-credit; the API still guards every other debit
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_wallet_balance_check;
+
+-- Comments
+COMMENT ON COLUMN wallet_transactions.created_by IS 'Admin who made an admin_adjustment entry, NULL for entries the system created';
+
+INSERT INTO schema_migrations (version) VALUES (24);
@@ -0,0 +1,14 @@
+-- Migration: Add bill short-link aliases
+-- Description: Short random codes that stand in for a bill number in verification links and QR codes
+
+CREATE TABLE bill_aliases (
+    short_code VARCHAR(16) PRIMARY KEY,
+    bill_id UUID NOT NULL UNIQUE REFERENCES bills(id) ON DELETE CASCADE,
+
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Comments
+COMMENT ON TABLE bill_aliases IS 'One short code per bill; regenerating a bill''s short link replaces its code';
+
+INSERT INTO schema_migrations (version) VALUES (25);
@@ -0,0 +1,11 @@
+-- Migration: Record admin role changes
+-- Description: Master admins can change a user's role; the latest change is recorded on the user
+
+ALTER TABLE users ADD COLUMN role_changed_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN role_changed_by UUID REFERENCES users(id);
+
+-- Comments
+COMMENT ON COLUMN users.role_changed_at IS 'When a master admin last changed this user''s role; NULL if unchanged since signup';
+COMMENT ON COLUMN users.role_changed_by IS 'Master admin who last changed this user''s role';
+
+INSERT INTO schema_migrations (version) VALUES (26);
@@ -0,0 +1,26 @@
+-- Migration: Add admin signup invites
+-- Description: One-time signup links that bind a role which can't be self-registered, such as verifier
+
+CREATE TABLE user_invites (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email VARCHAR(255) NOT NULL,
+    role user_role NOT NULL,
+    token VARCHAR(64) UNIQUE NOT NULL,
+
+    invited_by UUID NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP NOT NULL,
+
+    -- Set once the invite has been used to sign up
+    used_at TIMESTAMP,
+    used_by UUID REFERENCES users(id) ON DELETE SET NULL,
+
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Indexes
+CREATE INDEX idx_user_invites_email ON user_invites(email);
+
+-- Comments
+COMMENT ON TABLE user_invites IS 'Admin-issued signup links; each can be used once, before expires_at';
+
+INSERT INTO schema_migrations (version) VALUES (27);
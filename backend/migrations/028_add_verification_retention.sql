@@ -0,0 +1,30 @@
+-- Migration: Add verification log retention
+-- Description: Old verifications are pruned into daily rollups (and optionally an archive) so the table stops growing
+
+-- Daily totals of pruned verifications; stats and bill verification counts add these to the live rows
+-- Each prune run adds its own rows, so a day can appear more than once
+CREATE TABLE verification_rollups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    day DATE NOT NULL,
+    verifier_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    bill_id UUID REFERENCES bills(id) ON DELETE CASCADE,
+    verification_status verification_status NOT NULL,
+    verification_count INTEGER NOT NULL,
+    amount_charged DECIMAL(12,2) NOT NULL DEFAULT 0.00,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Pruned rows, when VERIFICATION_ARCHIVE is on
+-- Same columns as verifications (no foreign keys), so rows are copied with SELECT *
+CREATE TABLE verifications_archive (LIKE verifications INCLUDING DEFAULTS);
+
+-- Indexes
+CREATE INDEX idx_verification_rollups_verifier ON verification_rollups(verifier_id, day);
+CREATE INDEX idx_verification_rollups_bill ON verification_rollups(bill_id);
+CREATE INDEX idx_verifications_archive_verifier ON verifications_archive(verifier_id);
+
+-- Comments
+COMMENT ON TABLE verification_rollups IS 'Daily verification counts and spend for records pruned from verifications';
+COMMENT ON TABLE verifications_archive IS 'Verification records pruned from verifications; not read by the API';
+
+INSERT INTO schema_migrations (version) VALUES (28);
@@ -0,0 +1,6 @@
+-- Migration: Add composite index for issuer bill stats
+-- Description: Issuer dashboards filter on issuer_id and is_deleted and bucket by created_at in one pass
+
+CREATE INDEX idx_bills_issuer_stats ON bills(issuer_id, is_deleted, created_at);
+
+INSERT INTO schema_migrations (version) VALUES (29);
@@ -0,0 +1,12 @@
+-- Migration: Record PAN verification results
+-- Description: A pluggable verifier checks the PAN given at signup; its latest result is kept on the user
+
+ALTER TABLE users ADD COLUMN pan_verification_status VARCHAR(20) NOT NULL DEFAULT 'unchecked'
+    CHECK (pan_verification_status IN ('unchecked', 'verified', 'mismatch', 'not_found'));
+ALTER TABLE users ADD COLUMN pan_verified_at TIMESTAMP;
+
+-- Comments
+COMMENT ON COLUMN users.pan_verification_status IS 'Outcome of the last PAN check; unchecked until a verifier has run';
+COMMENT ON COLUMN users.pan_verified_at IS 'When the PAN was last checked by a verifier';
+
+INSERT INTO schema_migrations (version) VALUES (30);
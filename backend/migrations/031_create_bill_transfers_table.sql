@@ -0,0 +1,34 @@
+-- Migration: Add bill ownership transfers
+-- Description: Bills can move to another institution account; each transfer is kept as an audit record
+
+CREATE TABLE bill_transfers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    bill_id UUID NOT NULL REFERENCES bills(id) ON DELETE CASCADE,
+    from_issuer_id UUID NOT NULL REFERENCES users(id),
+    to_issuer_id UUID NOT NULL REFERENCES users(id),
+    requested_by UUID NOT NULL REFERENCES users(id),
+
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'completed', 'cancelled')),
+
+    -- Issuer name shown on the bill before the transfer, and after it if it was changed
+    update_issuer_name BOOLEAN NOT NULL DEFAULT FALSE,
+    previous_issuer_name VARCHAR(255) NOT NULL,
+    new_issuer_name VARCHAR(255),
+
+    -- Set while the receiving account still has to accept; NULL for admin transfers
+    confirmation_token VARCHAR(64) UNIQUE,
+    expires_at TIMESTAMP,
+
+    created_at TIMESTAMP DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX idx_bill_transfers_bill ON bill_transfers(bill_id, created_at DESC);
+CREATE INDEX idx_bill_transfers_pending ON bill_transfers(bill_id) WHERE status = 'pending';
+
+-- Comments
+COMMENT ON TABLE bill_transfers IS 'Bill ownership transfers between institution accounts, pending and completed';
+
+INSERT INTO schema_migrations (version) VALUES (31);
@@ -0,0 +1,25 @@
+-- Migration: Add TOTP two-factor authentication
+-- Description: Verifiers and master admins can require an authenticator app code at login, with one-time recovery codes
+
+-- two_factor_secret is AES-GCM encrypted; it is set on enable and only used at login once two_factor_enabled is true
+ALTER TABLE users ADD COLUMN two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN two_factor_secret TEXT;
+ALTER TABLE users ADD COLUMN two_factor_enabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN two_factor_last_step BIGINT; -- Last accepted TOTP time step, so a code can't be replayed
+
+CREATE TABLE two_factor_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL, -- SHA-256 of the code; the code itself is only shown once
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Indexes
+CREATE UNIQUE INDEX idx_two_factor_recovery_codes_hash ON two_factor_recovery_codes(user_id, code_hash);
+
+-- Comments
+COMMENT ON COLUMN users.two_factor_secret IS 'Encrypted TOTP secret (TWO_FACTOR_ENCRYPTION_KEY)';
+COMMENT ON TABLE two_factor_recovery_codes IS 'One-time codes that stand in for a TOTP code when the authenticator is lost';
+
+INSERT INTO schema_migrations (version) VALUES (32);
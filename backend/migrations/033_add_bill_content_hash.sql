@@ -0,0 +1,14 @@
+-- Migration: Add bill content hashes
+-- Description: data_hash covers generation metadata, so identical submissions get different hashes;
+-- content_hash covers only what the issuer sent and is used to catch accidental double-submits
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_bills_issuer_content_hash ON bills(issuer_id, content_hash, created_at DESC)
+    WHERE is_deleted = false AND content_hash IS NOT NULL;
+
+-- Comments
+COMMENT ON COLUMN bills.content_hash IS 'SHA-256 of bill type, amount and normalized bill_data without system metadata; NULL for bills created before migration 033';
+
+INSERT INTO schema_migrations (version) VALUES (33);
@@ -0,0 +1,29 @@
+-- Migration: Create audit log
+-- Description: Durable record of privileged admin actions - who did what to which record, and what changed
+
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- Who acted; kept when the account is anonymized
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+
+    action VARCHAR(50) NOT NULL,       -- e.g. 'user.role_change', 'kyc.review', 'bill.delete'
+    target_type VARCHAR(30) NOT NULL,  -- 'user', 'bill' or 'invite'
+    target_id VARCHAR(64) NOT NULL,
+
+    request_id VARCHAR(128),           -- X-Request-ID of the API call, for matching log lines
+    metadata JSONB NOT NULL DEFAULT '{}', -- Action details, with "before" and "after" where something changed
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Indexes
+CREATE INDEX idx_audit_log_created ON audit_log(created_at DESC);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id, created_at DESC);
+CREATE INDEX idx_audit_log_target ON audit_log(target_type, target_id, created_at DESC);
+CREATE INDEX idx_audit_log_action ON audit_log(action, created_at DESC);
+
+-- Comments
+COMMENT ON TABLE audit_log IS 'Privileged admin actions, written in the same transaction as the action itself';
+
+INSERT INTO schema_migrations (version) VALUES (34);
@@ -0,0 +1,9 @@
+-- Migration: Alert issuers to lookups of fake bills numbered like theirs
+-- Description: Spikes of not-found verifications under a bill-number prefix owned by one issuer are
+-- sent to that issuer's webhook and email through the outbox
+
+ALTER TABLE outbox DROP CONSTRAINT outbox_kind_check;
+ALTER TABLE outbox ADD CONSTRAINT outbox_kind_check
+    CHECK (kind IN ('bill_email', 'bill_verified_webhook', 'verification_receipt', 'fake_bill_alert_webhook', 'fake_bill_alert_email'));
+
+INSERT INTO schema_migrations (version) VALUES (35);
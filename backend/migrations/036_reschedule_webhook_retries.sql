@@ -0,0 +1,12 @@
+-- Migration: Retry webhooks through the outbox
+-- Description: Each outbox attempt at a webhook makes one request; retries are rescheduled with the
+-- message's next_attempt_at instead of sleeping in the dispatcher, and all of them update one delivery record
+
+ALTER TABLE webhook_deliveries ADD COLUMN outbox_id UUID;
+
+-- One delivery record per outbox message
+CREATE UNIQUE INDEX idx_webhook_deliveries_outbox ON webhook_deliveries(outbox_id) WHERE outbox_id IS NOT NULL;
+
+COMMENT ON COLUMN webhook_deliveries.outbox_id IS 'Outbox message this delivery is for; its retries update this row';
+
+INSERT INTO schema_migrations (version) VALUES (36);
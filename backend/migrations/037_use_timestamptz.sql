@@ -0,0 +1,68 @@
+-- Migration: Store timestamps with their time zone
+-- Description: Every TIMESTAMP column becomes TIMESTAMPTZ, and bill numbers take their month from APP_TIMEZONE.
+-- Existing values were written in the database's default TimeZone (sessions only pin UTC since the APP_TIMEZONE
+-- change), so they are read in that zone; set the database's TimeZone to match before migrating if it has changed
+
+DO $$
+DECLARE
+    v_zone TEXT;
+    v_column RECORD;
+BEGIN
+    -- reset_val is the zone sessions had before this connection's SET TIME ZONE 'UTC'
+    SELECT reset_val INTO v_zone FROM pg_settings WHERE name = 'TimeZone';
+
+    FOR v_column IN
+        SELECT table_name, column_name
+        FROM information_schema.columns
+        WHERE table_schema = current_schema()
+        AND data_type = 'timestamp without time zone'
+    LOOP
+        EXECUTE format(
+            'ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMPTZ USING %I AT TIME ZONE %L',
+            v_column.table_name, v_column.column_name, v_column.column_name, v_zone
+        );
+    END LOOP;
+END $$;
+
+-- Bill numbers carry the year and month they were issued in, which depends on the zone
+DROP FUNCTION generate_bill_number(bill_type);
+
+CREATE OR REPLACE FUNCTION generate_bill_number(p_bill_type bill_type, p_timezone TEXT DEFAULT 'UTC')
+RETURNS VARCHAR(50) AS $$
+DECLARE
+    v_prefix VARCHAR(3);
+    v_month VARCHAR(6);
+    v_sequence INTEGER;
+BEGIN
+    v_prefix := CASE p_bill_type
+        WHEN 'salary_slip'       THEN 'SAL'
+        WHEN 'sales_invoice'     THEN 'INV'
+        WHEN 'medical_bill'      THEN 'MED'
+        WHEN 'purchase_invoice'  THEN 'PUR'
+        WHEN 'rental_agreement'  THEN 'RNT'
+        WHEN 'education_fee'     THEN 'EDU'
+        WHEN 'rent_receipt'      THEN 'RCT'
+        WHEN 'reimbursement'     THEN 'REI'
+        WHEN 'loan_statement'    THEN 'LON'
+        WHEN 'tax_receipt'       THEN 'TAX'
+        WHEN 'insurance_policy'  THEN 'INS'
+        ELSE 'OTH'
+    END;
+
+    -- Current year and month in the business time zone
+    v_month := TO_CHAR(NOW() AT TIME ZONE p_timezone, 'YYYYMM');
+
+    -- Next sequence number for this type/month
+    SELECT COALESCE(MAX(CAST(SUBSTRING(bill_number FROM 10 FOR 6) AS INTEGER)), 0) + 1
+    INTO v_sequence
+    FROM bills
+    WHERE bill_number LIKE v_prefix || v_month || '%';
+
+    -- Format: SAL202501000001 (3 + 4 + 2 + 6 = 15 chars)
+    RETURN v_prefix || v_month || LPAD(v_sequence::TEXT, 6, '0');
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION generate_bill_number IS 'Auto-generates unique bill numbers like SAL202501000001, dated in p_timezone';
+
+INSERT INTO schema_migrations (version) VALUES (37);
@@ -0,0 +1,9 @@
+-- Migration: Remember which request an Idempotency-Key was used for
+-- Description: Replaying a key with a different request body is a client bug, not a retry;
+-- storing a hash of the original request lets the API reject it instead of returning an unrelated bill
+
+ALTER TABLE idempotency_keys ADD COLUMN request_hash VARCHAR(64);
+
+COMMENT ON COLUMN idempotency_keys.request_hash IS 'SHA-256 of the request first sent with this key; NULL for keys stored before this column existed';
+
+INSERT INTO schema_migrations (version) VALUES (38);
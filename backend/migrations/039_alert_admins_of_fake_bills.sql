@@ -0,0 +1,11 @@
+-- Migration: Send fake bill alerts to master admins only
+-- Description: A bill-number prefix is a bill type and month shared by every issuer, so spikes of unknown
+-- numbers under it can't be pinned on one institution. Alerts no longer go to issuer webhooks; drop any still queued
+
+DELETE FROM outbox WHERE kind = 'fake_bill_alert_webhook';
+
+ALTER TABLE outbox DROP CONSTRAINT outbox_kind_check;
+ALTER TABLE outbox ADD CONSTRAINT outbox_kind_check
+    CHECK (kind IN ('bill_email', 'bill_verified_webhook', 'verification_receipt', 'fake_bill_alert_email'));
+
+INSERT INTO schema_migrations (version) VALUES (39);
@@ -0,0 +1,9 @@
+// Package migrations embeds the numbered SQL migrations so the API binary can apply them itself
+package migrations
+
+import "embed"
+
+// FS holds every migration file; database.Migrate only runs the numbered ones
+//
+//go:embed *.sql
+var FS embed.FS